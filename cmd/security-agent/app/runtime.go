@@ -10,6 +10,7 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -40,17 +41,34 @@ var (
 	checkPoliciesCmd = &cobra.Command{
 		Use:   "check-policies",
 		Short: "Check policies and return a report",
-		RunE:  checkPolicies,
+		Long: `Parse and compile the policy files, without running the probe, and report their errors as well as
+the in-kernel filters, the event types and the approvers of the rules`,
+		RunE: checkPolicies,
 	}
 
 	checkPoliciesArgs = struct {
 		dir string
 	}{}
+
+	testPoliciesCmd = &cobra.Command{
+		Use:   "test-policies",
+		Short: "Run the tests of the policies",
+		Long: `Compile the policy files, without running the probe, and evaluate the example events of their tests,
+embedded in the policies or in sibling .test files, against the rules. Fail if a rule doesn't match as expected`,
+		RunE: testPolicies,
+	}
+
+	testPoliciesArgs = struct {
+		dir string
+	}{}
 )
 
 func init() {
 	runtimeCmd.AddCommand(checkPoliciesCmd)
 	checkPoliciesCmd.Flags().StringVar(&checkPoliciesArgs.dir, "policies-dir", coreconfig.DefaultRuntimePoliciesDir, "Path to policies directory")
+
+	runtimeCmd.AddCommand(testPoliciesCmd)
+	testPoliciesCmd.Flags().StringVar(&testPoliciesArgs.dir, "policies-dir", coreconfig.DefaultRuntimePoliciesDir, "Path to policies directory")
 }
 
 func checkPolicies(cmd *cobra.Command, args []string) error {
@@ -66,10 +84,9 @@ func checkPolicies(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// the rules without error are still reported
 	ruleSet := probe.NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
-	if err := policy.LoadPolicies(cfg, ruleSet); err != nil {
-		return err
-	}
+	loadErr := policy.CheckPolicies(cfg, ruleSet)
 
 	rsa := sprobe.NewRuleSetApplier(cfg)
 
@@ -81,6 +98,54 @@ func checkPolicies(cmd *cobra.Command, args []string) error {
 	content, _ := json.MarshalIndent(report, "", "\t")
 	fmt.Printf("%s\n", string(content))
 
+	if loadErr != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", loadErr)
+		return errors.New("invalid policies")
+	}
+
+	return nil
+}
+
+func testPolicies(cmd *cobra.Command, args []string) error {
+	cfg := &secconfig.Config{
+		PoliciesDir: testPoliciesArgs.dir,
+	}
+
+	probe, err := sprobe.NewProbe(cfg)
+	if err != nil {
+		return err
+	}
+
+	ruleSet := probe.NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
+	if err := policy.CheckPolicies(cfg, ruleSet); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return errors.New("invalid policies")
+	}
+
+	results, err := policy.RunTests(cfg, ruleSet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return errors.New("invalid policy tests")
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Passed() {
+			fmt.Printf("PASS %s: %s\n", result.Policy, result.Name)
+			continue
+		}
+
+		failed++
+		fmt.Printf("FAIL %s: %s\n", result.Policy, result.Name)
+		for _, failure := range result.Failures {
+			fmt.Printf("\t%s\n", failure)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d policy tests failed", failed, len(results))
+	}
+
 	return nil
 }
 
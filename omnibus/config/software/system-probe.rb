@@ -23,6 +23,7 @@ build do
     copy "#{ENV['SYSTEM_PROBE_BIN']}/offset-guess-debug.o", "#{install_dir}/embedded/share/system-probe/ebpf/"
     copy "#{ENV['SYSTEM_PROBE_BIN']}/runtime-security.o", "#{install_dir}/embedded/share/system-probe/ebpf/"
     copy "#{ENV['SYSTEM_PROBE_BIN']}/runtime-security-syscall-wrapper.o", "#{install_dir}/embedded/share/system-probe/ebpf/"
+    copy "#{ENV['SYSTEM_PROBE_BIN']}/runtime-security-core.o", "#{install_dir}/embedded/share/system-probe/ebpf/"
   end
 
   copy 'pkg/ebpf/c/COPYING', "#{install_dir}/embedded/share/system-probe/ebpf/"
@@ -33,4 +34,12 @@ build do
 
   copy 'pkg/ebpf/c/tcp-queue-length-kern.c', "#{install_dir}/embedded/share/system-probe/ebpf/"
   copy 'pkg/ebpf/tcp-queue-length-kern-user.h', "#{install_dir}/embedded/share/system-probe/ebpf/"
+
+  # sources of the runtime security programs, compiled on the host when the shipped objects can't be loaded
+  mkdir "#{install_dir}/embedded/share/system-probe/ebpf/runtime/pkg/ebpf/c"
+  mkdir "#{install_dir}/embedded/share/system-probe/ebpf/runtime/pkg/security/ebpf/c"
+  copy 'pkg/ebpf/c/bpf_helpers.h', "#{install_dir}/embedded/share/system-probe/ebpf/runtime/pkg/ebpf/c/"
+  copy 'pkg/ebpf/c/asm_goto_workaround.h', "#{install_dir}/embedded/share/system-probe/ebpf/runtime/pkg/ebpf/c/"
+  copy 'pkg/security/ebpf/c/probe.c', "#{install_dir}/embedded/share/system-probe/ebpf/runtime/pkg/security/ebpf/c/"
+  copy 'pkg/security/ebpf/c/*.h', "#{install_dir}/embedded/share/system-probe/ebpf/runtime/pkg/security/ebpf/c/"
 end
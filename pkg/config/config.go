@@ -750,16 +750,92 @@ func InitConfig(config Config) {
 	// Datadog security agent (runtime)
 	config.BindEnvAndSetDefault("runtime_security_config.enabled", false)
 	config.BindEnvAndSetDefault("runtime_security_config.policies.dir", DefaultRuntimePoliciesDir)
+	config.BindEnvAndSetDefault("runtime_security_config.policies.lists_reload_period", 60)
+	config.BindEnvAndSetDefault("runtime_security_config.policies.watch_period", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.policies.overrides_file", "")
+	config.BindEnvAndSetDefault("runtime_security_config.policies.remote.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.policies.remote.dd_url", "")
+	config.BindEnvAndSetDefault("runtime_security_config.policies.remote.refresh_period", 300)
+	config.BindEnvAndSetDefault("runtime_security_config.policies.remote.cache_dir", "")
+	config.BindEnvAndSetDefault("runtime_security_config.policies.remote.public_key", "")
 	config.BindEnvAndSetDefault("runtime_security_config.socket", "/opt/datadog-agent/run/runtime-security.sock")
 	config.BindEnvAndSetDefault("runtime_security_config.enable_kernel_filters", true)
+	config.BindEnvAndSetDefault("runtime_security_config.enable_pre_evaluation", false)
+	config.BindEnvAndSetDefault("runtime_security_config.filters.approvers_map_size", 255)
+	config.BindEnvAndSetDefault("runtime_security_config.filters.discarders_map_size", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.filters.discarders_overflow", "evict")
+	config.BindEnvAndSetDefault("runtime_security_config.disabled_event_types", []string{})
 	config.BindEnvAndSetDefault("runtime_security_config.syscall_monitor.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.program_stats.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.event_stream.use_ring_buffer", true)
+	config.BindEnvAndSetDefault("runtime_security_config.event_stream.events.buffer_size", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.event_stream.events.watermark", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.event_stream.mountpoints_events.buffer_size", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.event_stream.mountpoints_events.watermark", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.event_stream.batch_size", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.event_stream.batch_flush_interval", 10)
+	config.BindEnvAndSetDefault("runtime_security_config.event_workers.count", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.event_workers.queue_size", 1024)
+	config.BindEnvAndSetDefault("runtime_security_config.fentry_probes", []string{})
+	config.BindEnvAndSetDefault("runtime_security_config.runtime_compilation.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.runtime_compilation.kernel_headers_dirs", []string{})
+	config.BindEnvAndSetDefault("runtime_security_config.runtime_compilation.output_dir", "")
+	config.BindEnvAndSetDefault("runtime_security_config.runtime_compilation.clang_path", "clang")
+	config.BindEnvAndSetDefault("runtime_security_config.runtime_compilation.llc_path", "llc")
+	config.BindEnvAndSetDefault("runtime_security_config.diagnostics.enabled", true)
+	config.BindEnvAndSetDefault("runtime_security_config.diagnostics.dir", "")
+	config.BindEnvAndSetDefault("runtime_security_config.map_sizing.expected_containers", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.map_sizing.process_cache_size", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.map_sizing.dentry_cache_size", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.map_pinning.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.map_pinning.dir", "/sys/fs/bpf/datadog-agent/runtime-security")
+	config.BindEnvAndSetDefault("runtime_security_config.audit_log.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.audit_log.path", "/var/log/datadog/runtime-security-audit.json")
+	config.BindEnvAndSetDefault("runtime_security_config.audit_log.verbose", false)
+	config.BindEnvAndSetDefault("runtime_security_config.audit_log.max_size", 100)
+	config.BindEnvAndSetDefault("runtime_security_config.audit_log.max_files", 10)
+	config.BindEnvAndSetDefault("runtime_security_config.audit_log.compress", true)
+	config.BindEnvAndSetDefault("runtime_security_config.syslog.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.syslog.network", "")
+	config.BindEnvAndSetDefault("runtime_security_config.syslog.address", "")
+	config.BindEnvAndSetDefault("runtime_security_config.syslog.facility", "local0")
+	config.BindEnvAndSetDefault("runtime_security_config.syslog.app_name", "datadog-runtime-security")
+	config.BindEnvAndSetDefault("runtime_security_config.syslog.format", "json")
+	config.BindEnvAndSetDefault("runtime_security_config.webhook.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.webhook.url", "")
+	config.BindEnvAndSetDefault("runtime_security_config.webhook.headers", map[string]string{})
+	config.BindEnvAndSetDefault("runtime_security_config.webhook.tls.ca_file", "")
+	config.BindEnvAndSetDefault("runtime_security_config.webhook.tls.cert_file", "")
+	config.BindEnvAndSetDefault("runtime_security_config.webhook.tls.key_file", "")
+	config.BindEnvAndSetDefault("runtime_security_config.webhook.timeout", 10)
+	config.BindEnvAndSetDefault("runtime_security_config.webhook.max_retries", 3)
+	config.BindEnvAndSetDefault("runtime_security_config.webhook.retry_delay", 500)
 	config.BindEnvAndSetDefault("runtime_security_config.run_path", defaultRunPath)
 	config.BindEnvAndSetDefault("runtime_security_config.event_server.burst", 40)
 	config.BindEnvAndSetDefault("runtime_security_config.event_server.rate", 10)
+	config.BindEnvAndSetDefault("runtime_security_config.event_server.severity_threshold", "info")
+	config.BindEnvAndSetDefault("runtime_security_config.event_server.below_threshold_sample_rate", 0.0)
+	config.BindEnvAndSetDefault("runtime_security_config.event_server.simulation_sample_rate", 0.0)
 	config.BindEnvAndSetDefault("runtime_security_config.load_controller.events_count_threshold", 20000)
 	config.BindEnvAndSetDefault("runtime_security_config.load_controller.discarder_timeout", 10)
 	config.BindEnvAndSetDefault("runtime_security_config.load_controller.control_period", 2)
+	config.BindEnvAndSetDefault("runtime_security_config.load_controller.lost_events_threshold", 1000)
+	config.BindEnvAndSetDefault("runtime_security_config.load_controller.standby_approvers", true)
+	config.BindEnvAndSetDefault("runtime_security_config.container_filter_policies", map[string]string{})
+	config.BindEnvAndSetDefault("runtime_security_config.resync.lost_events_threshold", 1000)
+	config.BindEnvAndSetDefault("runtime_security_config.resync.period", 10)
+	config.BindEnvAndSetDefault("runtime_security_config.resync.sustained_periods", 3)
+	config.BindEnvAndSetDefault("runtime_security_config.kernel_rate_limiter.rate", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.kernel_rate_limiter.burst", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.kernel_rate_limiter.container_rate", 0)
+	config.BindEnvAndSetDefault("runtime_security_config.kernel_rate_limiter.container_burst", 0)
 	config.BindEnvAndSetDefault("runtime_security_config.pid_cache_size", 10000)
+	config.BindEnvAndSetDefault("runtime_security_config.kretprobe_max_active", 512)
+	config.BindEnvAndSetDefault("runtime_security_config.pid_cache_ttl", 60)
+	config.BindEnvAndSetDefault("runtime_security_config.env_tags", []string{})
+	config.BindEnvAndSetDefault("runtime_security_config.erpc_dentry_resolution_enabled", true)
+	config.BindEnvAndSetDefault("runtime_security_config.enforcement.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.enforcement.kill_allowlist", []string{})
 
 	// command line options
 	config.SetKnown("cmd.check.fullsketches")
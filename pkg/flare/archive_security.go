@@ -73,6 +73,11 @@ func CreateSecurityAgentArchive(local bool, logFilePath string, runtimeStatus ma
 		return "", err
 	}
 
+	err = zipRuntimeDiagnostics(tempDir, hostname, permsInfos)
+	if err != nil {
+		return "", err
+	}
+
 	err = zipExpVar(tempDir, hostname)
 	if err != nil {
 		return "", err
@@ -176,3 +181,43 @@ func zipRuntimeFiles(tempDir, hostname string, permsInfos permissionsInfos) erro
 
 	return err
 }
+
+// zipRuntimeDiagnostics adds the diagnostics bundles written by the runtime security module when its eBPF programs
+// failed to load
+func zipRuntimeDiagnostics(tempDir, hostname string, permsInfos permissionsInfos) error {
+	diagnosticsDir := config.Datadog.GetString("runtime_security_config.diagnostics.dir")
+	if diagnosticsDir == "" {
+		diagnosticsDir = filepath.Join(config.Datadog.GetString("runtime_security_config.run_path"), "runtime-security-diagnostics")
+	}
+
+	if _, err := os.Stat(diagnosticsDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	if permsInfos != nil {
+		addParentPerms(diagnosticsDir, permsInfos)
+	}
+
+	err := filepath.Walk(diagnosticsDir, func(src string, f os.FileInfo, err error) error {
+		if f == nil {
+			return nil
+		}
+		if f.IsDir() || f.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(diagnosticsDir, src)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(tempDir, hostname, "runtime-security-diagnostics", rel)
+
+		if permsInfos != nil {
+			permsInfos.add(src)
+		}
+
+		return util.CopyFileAll(src, dst)
+	})
+
+	return err
+}
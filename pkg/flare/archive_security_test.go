@@ -24,6 +24,7 @@ func TestCreateSecurityAgentArchive(t *testing.T) {
 	common.SetupConfig("./test")
 	mockConfig := config.Mock()
 	mockConfig.Set("compliance_config.dir", "./test/compliance.d")
+	mockConfig.Set("runtime_security_config.diagnostics.dir", "./test/runtime-security-diagnostics")
 	logFilePath := "./test/logs/agent.log"
 
 	tests := []struct {
@@ -37,6 +38,8 @@ func TestCreateSecurityAgentArchive(t *testing.T) {
 			expectedFiles: []string{
 				"compliance.d/cis-docker.yaml",
 				"logs/agent.log",
+				"runtime-security-diagnostics/load-failure-20201015T120000.000000000Z-core/diagnostics.json",
+				"runtime-security-diagnostics/load-failure-20201015T120000.000000000Z-core/verifier.log",
 			},
 		},
 		{
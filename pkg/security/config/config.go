@@ -6,11 +6,44 @@
 package config
 
 import (
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
 	aconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
-	"time"
 )
 
+// remotePoliciesPath is the path of the endpoint of the Datadog API delivering the policy bundles
+const remotePoliciesPath = "/api/v2/security/runtime/policies/bundle"
+
+const (
+	// DiscardersOverflowEvict evicts the least recently used discarders of a full discarder map
+	DiscardersOverflowEvict = "evict"
+	// DiscardersOverflowReject rejects the new discarders of a full discarder map, with a warning
+	DiscardersOverflowReject = "reject"
+)
+
+const (
+	// ContainerFilterPolicyAccept passes all the events of a container to user space
+	ContainerFilterPolicyAccept = "accept"
+	// ContainerFilterPolicyDeny only passes the events of a container matching the approvers of their event type
+	ContainerFilterPolicyDeny = "deny"
+)
+
+const (
+	// RuleMatchFormatJSON formats the forwarded rule matches as JSON
+	RuleMatchFormatJSON = "json"
+	// RuleMatchFormatCEF formats the forwarded rule matches as ArcSight Common Event Format records
+	RuleMatchFormatCEF = "cef"
+	// RuleMatchFormatLEEF formats the forwarded rule matches as QRadar Log Event Extended Format records
+	RuleMatchFormatLEEF = "leef"
+)
+
+// EventStreamMaps lists the perf maps whose buffers are configurable
+var EventStreamMaps = []string{"events", "mountpoints_events"}
+
 // Policy represents a policy file in the configuration file
 type Policy struct {
 	Name  string   `mapstructure:"name"`
@@ -26,22 +59,169 @@ type Config struct {
 	BPFDir string
 	// PoliciesDir defines the folder in which the policy files are located
 	PoliciesDir string
+	// ListsReloadPeriod defines the period at which the lists read from files are reloaded, 0 disables the reload
+	ListsReloadPeriod time.Duration
+	// PoliciesOverridesFile defines the file holding the overrides of the rules specific to the host
+	PoliciesOverridesFile string
+	// RemotePoliciesEnabled defines if the policies are fetched from the Datadog backend
+	RemotePoliciesEnabled bool
+	// RemotePoliciesURL is the URL of the endpoint delivering the policy bundles
+	RemotePoliciesURL string
+	// RemotePoliciesRefreshPeriod defines the period at which a new policy bundle is fetched
+	RemotePoliciesRefreshPeriod time.Duration
+	// RemotePoliciesCacheDir defines the folder in which the last valid policy bundle is cached
+	RemotePoliciesCacheDir string
+	// RemotePoliciesPublicKey is the base64 encoded ed25519 public key verifying the signature of the policy bundles
+	RemotePoliciesPublicKey string
+	// APIKey is the API key used to fetch the policy bundles
+	APIKey string
+	// PoliciesWatchPeriod defines the period at which the policy files are checked for changes, 0 disables the check
+	PoliciesWatchPeriod time.Duration
 	// EnableKernelFilters defines if in-kernel filtering should be activated or not
 	EnableKernelFilters bool
 	// EnableApprovers defines if in-kernel approvers should be activated or not
 	EnableApprovers bool
 	// EnableDiscarders defines if in-kernel discarders should be activated or not
 	EnableDiscarders bool
+	// EnablePreEvaluation defines if the rules testing only fields known in kernel should be pre-evaluated in kernel,
+	// so that the events that can't match any of them aren't sent
+	EnablePreEvaluation bool
+	// ApproversMapSize defines the maximum number of entries of each in-kernel approver map. The approvers of an event
+	// type that don't fit are rejected, the event type falling back to the accept mode
+	ApproversMapSize int
+	// DiscardersMapSize defines the maximum number of entries of each in-kernel discarder map, sized for the host
+	// when 0
+	DiscardersMapSize int
+	// DiscardersOverflow defines what happens to the new discarders of a full discarder map, either `evict` or
+	// `reject`
+	DiscardersOverflow string
+	// DisabledEventTypes lists the event types whose probes are detached when the module starts, they can be enabled
+	// again at runtime
+	DisabledEventTypes []string
+	// EventStreamUseRingBuffer defines if the events are sent through ring buffers, shared by all the CPUs, on the
+	// kernels supporting them. The per-CPU perf buffers are used otherwise
+	EventStreamUseRingBuffer bool
+	// EventStreamBufferSizes defines the size in bytes of the buffers of each perf map, per CPU for the perf buffers
+	// and shared by all the CPUs for the ring buffers. The default size of the map is used when 0
+	EventStreamBufferSizes map[string]int
+	// EventStreamWatermarks defines the number of bytes written to the buffers of each perf map before the reader is
+	// woken up, each event wakes it up when 0
+	EventStreamWatermarks map[string]int
+	// EventStreamBatchSize defines the maximum number of events read before they are handled as a batch, each event
+	// is handled once read when 0 or 1
+	EventStreamBatchSize int
+	// EventStreamBatchFlushInterval defines the maximum time an event waits in an incomplete batch
+	EventStreamBatchFlushInterval time.Duration
+	// EventWorkers defines the number of workers decoding and dispatching the events in parallel, the perf reader
+	// handling them itself when 0
+	EventWorkers int
+	// EventWorkersQueueSize defines the number of events, or batches of events, queued per worker, the reader waits
+	// for a worker once its queue is full
+	EventWorkersQueueSize int
+	// KRetProbeMaxActive defines the maximum number of instances of each function probed by a kretprobe at a given
+	// time, the returns past it are missed. The kernel default, based on the number of CPUs, is used when 0
+	KRetProbeMaxActive int
+	// FEntryProbes lists the kernel functions hooked through fentry or fexit programs, instead of kprobes or
+	// kretprobes, on the kernels supporting BPF trampolines
+	FEntryProbes []string
+	// RuntimeCompilationEnabled defines if the eBPF programs are compiled against the kernel headers when the
+	// shipped ones can't be loaded
+	RuntimeCompilationEnabled bool
+	// RuntimeCompilationHeaderDirs lists the directories of the kernel headers, looked up from the kernel
+	// release when empty
+	RuntimeCompilationHeaderDirs []string
+	// RuntimeCompilationOutputDir defines where the compiled eBPF programs are stored
+	RuntimeCompilationOutputDir string
+	// RuntimeCompilationClangPath is the path of the clang binary
+	RuntimeCompilationClangPath string
+	// RuntimeCompilationLLCPath is the path of the llc binary
+	RuntimeCompilationLLCPath string
+	// DiagnosticsEnabled defines if a diagnostics bundle is written when the eBPF programs fail to load
+	DiagnosticsEnabled bool
+	// DiagnosticsDir defines the folder in which the diagnostics bundles are written, attached to the flares
+	DiagnosticsDir string
+	// MapSizingExpectedContainers defines the number of containers the maps are sized for, derived from the number
+	// of CPUs when 0
+	MapSizingExpectedContainers int
+	// MapSizingProcessCacheSize defines the maximum number of entries of the in-kernel process cache, sized for the
+	// host when 0
+	MapSizingProcessCacheSize int
+	// MapSizingDentryCacheSize defines the maximum number of entries of the in-kernel dentry cache, sized for the
+	// host when 0
+	MapSizingDentryCacheSize int
+	// MapPinningEnabled defines if the caches and the discarders of the kernel are pinned to the BPF filesystem, so
+	// that they outlive a restart of the module
+	MapPinningEnabled bool
+	// MapPinningDir defines the directory of the BPF filesystem the maps are pinned to
+	MapPinningDir string
+	// AuditLogEnabled defines if the rule matches are written to a local audit log, independently of the backend
+	AuditLogEnabled bool
+	// AuditLogPath defines the path of the audit log, a newline delimited JSON file
+	AuditLogPath string
+	// AuditLogVerbose defines if all the events are written to the audit log, and not only the rule matches
+	AuditLogVerbose bool
+	// AuditLogMaxSize defines the size, in bytes, past which the audit log is rotated
+	AuditLogMaxSize int64
+	// AuditLogMaxFiles defines the number of rotated audit logs kept
+	AuditLogMaxFiles int
+	// AuditLogCompress defines if the rotated audit logs are compressed with gzip
+	AuditLogCompress bool
+	// SyslogEnabled defines if the rule matches are forwarded to a syslog server
+	SyslogEnabled bool
+	// SyslogNetwork defines how the syslog server is reached, `udp`, `tcp`, or the local daemon when empty
+	SyslogNetwork string
+	// SyslogAddress defines the address of the syslog server, the socket of the local daemon when empty
+	SyslogAddress string
+	// SyslogFacility defines the facility of the syslog messages
+	SyslogFacility string
+	// SyslogAppName defines the application name of the syslog messages
+	SyslogAppName string
+	// SyslogFormat defines the format of the content of the syslog messages, `json`, `cef` or `leef`
+	SyslogFormat string
+	// WebhookEnabled defines if the rule matches are posted to a webhook
+	WebhookEnabled bool
+	// WebhookURL defines the HTTPS URL to which the rule matches are posted
+	WebhookURL string
+	// WebhookHeaders defines the headers added to the requests of the webhook, its authentication for instance
+	WebhookHeaders map[string]string
+	// WebhookCAFile defines the PEM encoded CA certificates verifying the webhook server, the system ones when empty
+	WebhookCAFile string
+	// WebhookCertFile defines the PEM encoded client certificate authenticating to the webhook server
+	WebhookCertFile string
+	// WebhookKeyFile defines the PEM encoded key of the client certificate
+	WebhookKeyFile string
+	// WebhookTimeout defines the timeout of the requests of the webhook
+	WebhookTimeout time.Duration
+	// WebhookMaxRetries defines how many times a failed request of the webhook is retried
+	WebhookMaxRetries int
+	// WebhookRetryDelay defines the delay before the first retry of a failed request, doubled at each retry
+	WebhookRetryDelay time.Duration
 	// SocketPath is the path to the socket that is used to communicate with the security agent
 	SocketPath string
 	// SyscallMonitor defines if the syscall monitor should be activated or not
 	SyscallMonitor bool
+	// ProgramStatsEnabled defines if the kernel should measure the run count and the run time of the eBPF programs.
+	// The measures add an overhead to each run of all the eBPF programs of the host
+	ProgramStatsEnabled bool
 	// EventServerBurst defines the maximum burst of events that can be sent over the grpc server
 	EventServerBurst int
 	// EventServerRate defines the grpc server rate at which events can be sent
 	EventServerRate int
+	// SeverityThreshold defines the severity below which the events of the rules are sampled
+	SeverityThreshold string
+	// BelowThresholdSampleRate defines the ratio, between 0 and 1, of the events below the severity threshold that
+	// are sent, 0 drops all of them
+	BelowThresholdSampleRate float64
+	// SimulationSampleRate defines the ratio, between 0 and 1, of the events of the rules in simulation mode that are
+	// sent, tagged as simulated, 0 drops all of them
+	SimulationSampleRate float64
 	// PIDCacheSize is the size of the user space PID caches
 	PIDCacheSize int
+	// PIDCacheTTL defines the amount of time after which a user space PID cache entry is checked against the kernel
+	PIDCacheTTL time.Duration
+	// EnvTags lists the environment variables, in addition to DD_SERVICE, DD_ENV and DD_VERSION, that are extracted
+	// from the environment of processes and attached as tags to their events
+	EnvTags []string
 	// LoadControllerEventsCountThreshold defines the amount of events past which we will trigger the in-kernel circuit breaker
 	LoadControllerEventsCountThreshold int64
 	// LoadControllerDiscarderTimeout defines the amount of time discarders set by the load controller should last
@@ -49,6 +229,42 @@ type Config struct {
 	// LoadControllerControlPeriod defines the period at which the load controller will empty the user space counter used
 	// to evaluate the amount of events brought back to user space
 	LoadControllerControlPeriod time.Duration
+	// LoadControllerLostEventsThreshold defines the amount of events lost during a control period past which the load
+	// controller tightens the in-kernel filters, 0 disables the tightening
+	LoadControllerLostEventsThreshold int64
+	// LoadControllerStandbyApprovers defines if the load controller may switch the event types left in accept mode to
+	// their approvers when tightening the in-kernel filters
+	LoadControllerStandbyApprovers bool
+	// ContainerFilterPolicies defines the in-kernel filter policy overriding the policy of the event types for some
+	// containers, keyed by container ID
+	ContainerFilterPolicies map[string]string
+	// ResyncLostEventsThreshold defines the amount of events lost during a resync period past which the period counts
+	// as lossy, 0 disables the resyncs
+	ResyncLostEventsThreshold int64
+	// ResyncPeriod defines the period over which the lost events are counted
+	ResyncPeriod time.Duration
+	// ResyncSustainedPeriods defines the number of consecutive lossy periods after which the user space caches are
+	// resynced with the state of the system
+	ResyncSustainedPeriods int
+	// KernelRateLimiterRate defines the maximum rate, in events per second, of each event type sent by the kernel, 0
+	// disables the in-kernel rate limiting
+	KernelRateLimiterRate int
+	// KernelRateLimiterBurst defines the maximum burst of events of each event type sent by the kernel, it defaults to
+	// the rate
+	KernelRateLimiterBurst int
+	// KernelRateLimiterContainerRate defines the maximum rate, in events per second, of each event type sent by the
+	// kernel for the processes of a container, 0 disables the in-kernel rate limiting per container
+	KernelRateLimiterContainerRate int
+	// KernelRateLimiterContainerBurst defines the maximum burst of events of each event type sent by the kernel for
+	// the processes of a container, it defaults to the container rate
+	KernelRateLimiterContainerBurst int
+	// ERPCDentryResolutionEnabled determines if the eRPC dentry resolution is enabled
+	ERPCDentryResolutionEnabled bool
+	// EnforcementEnabled defines if the actions of the rules acting on the system, like kill, are executed. When
+	// disabled, they are only logged
+	EnforcementEnabled bool
+	// KillAllowlist lists the executables of the processes that are never killed by a rule
+	KillAllowlist []string
 }
 
 // NewConfig returns a new Config object
@@ -58,21 +274,101 @@ func NewConfig(cfg *config.AgentConfig) (*Config, error) {
 		EnableKernelFilters:                aconfig.Datadog.GetBool("runtime_security_config.enable_kernel_filters"),
 		EnableApprovers:                    aconfig.Datadog.GetBool("runtime_security_config.enable_approvers"),
 		EnableDiscarders:                   aconfig.Datadog.GetBool("runtime_security_config.enable_discarders"),
+		EnablePreEvaluation:                aconfig.Datadog.GetBool("runtime_security_config.enable_pre_evaluation"),
+		ApproversMapSize:                   aconfig.Datadog.GetInt("runtime_security_config.filters.approvers_map_size"),
+		DiscardersMapSize:                  aconfig.Datadog.GetInt("runtime_security_config.filters.discarders_map_size"),
+		DiscardersOverflow:                 aconfig.Datadog.GetString("runtime_security_config.filters.discarders_overflow"),
+		DisabledEventTypes:                 aconfig.Datadog.GetStringSlice("runtime_security_config.disabled_event_types"),
+		EventStreamUseRingBuffer:           aconfig.Datadog.GetBool("runtime_security_config.event_stream.use_ring_buffer"),
+		EventStreamBatchSize:               aconfig.Datadog.GetInt("runtime_security_config.event_stream.batch_size"),
+		EventStreamBatchFlushInterval:      time.Duration(aconfig.Datadog.GetInt("runtime_security_config.event_stream.batch_flush_interval")) * time.Millisecond,
+		EventWorkers:                       aconfig.Datadog.GetInt("runtime_security_config.event_workers.count"),
+		EventWorkersQueueSize:              aconfig.Datadog.GetInt("runtime_security_config.event_workers.queue_size"),
+		KRetProbeMaxActive:                 aconfig.Datadog.GetInt("runtime_security_config.kretprobe_max_active"),
+		FEntryProbes:                       aconfig.Datadog.GetStringSlice("runtime_security_config.fentry_probes"),
+		RuntimeCompilationEnabled:          aconfig.Datadog.GetBool("runtime_security_config.runtime_compilation.enabled"),
+		RuntimeCompilationHeaderDirs:       aconfig.Datadog.GetStringSlice("runtime_security_config.runtime_compilation.kernel_headers_dirs"),
+		RuntimeCompilationOutputDir:        aconfig.Datadog.GetString("runtime_security_config.runtime_compilation.output_dir"),
+		RuntimeCompilationClangPath:        aconfig.Datadog.GetString("runtime_security_config.runtime_compilation.clang_path"),
+		RuntimeCompilationLLCPath:          aconfig.Datadog.GetString("runtime_security_config.runtime_compilation.llc_path"),
+		DiagnosticsEnabled:                 aconfig.Datadog.GetBool("runtime_security_config.diagnostics.enabled"),
+		DiagnosticsDir:                     aconfig.Datadog.GetString("runtime_security_config.diagnostics.dir"),
+		MapSizingExpectedContainers:        aconfig.Datadog.GetInt("runtime_security_config.map_sizing.expected_containers"),
+		MapSizingProcessCacheSize:          aconfig.Datadog.GetInt("runtime_security_config.map_sizing.process_cache_size"),
+		MapSizingDentryCacheSize:           aconfig.Datadog.GetInt("runtime_security_config.map_sizing.dentry_cache_size"),
+		MapPinningEnabled:                  aconfig.Datadog.GetBool("runtime_security_config.map_pinning.enabled"),
+		MapPinningDir:                      aconfig.Datadog.GetString("runtime_security_config.map_pinning.dir"),
+		AuditLogEnabled:                    aconfig.Datadog.GetBool("runtime_security_config.audit_log.enabled"),
+		AuditLogPath:                       aconfig.Datadog.GetString("runtime_security_config.audit_log.path"),
+		AuditLogVerbose:                    aconfig.Datadog.GetBool("runtime_security_config.audit_log.verbose"),
+		AuditLogMaxSize:                    int64(aconfig.Datadog.GetInt("runtime_security_config.audit_log.max_size")) * 1024 * 1024,
+		AuditLogMaxFiles:                   aconfig.Datadog.GetInt("runtime_security_config.audit_log.max_files"),
+		AuditLogCompress:                   aconfig.Datadog.GetBool("runtime_security_config.audit_log.compress"),
+		SyslogEnabled:                      aconfig.Datadog.GetBool("runtime_security_config.syslog.enabled"),
+		SyslogNetwork:                      aconfig.Datadog.GetString("runtime_security_config.syslog.network"),
+		SyslogAddress:                      aconfig.Datadog.GetString("runtime_security_config.syslog.address"),
+		SyslogFacility:                     aconfig.Datadog.GetString("runtime_security_config.syslog.facility"),
+		SyslogAppName:                      aconfig.Datadog.GetString("runtime_security_config.syslog.app_name"),
+		SyslogFormat:                       aconfig.Datadog.GetString("runtime_security_config.syslog.format"),
+		WebhookEnabled:                     aconfig.Datadog.GetBool("runtime_security_config.webhook.enabled"),
+		WebhookURL:                         aconfig.Datadog.GetString("runtime_security_config.webhook.url"),
+		WebhookHeaders:                     aconfig.Datadog.GetStringMapString("runtime_security_config.webhook.headers"),
+		WebhookCAFile:                      aconfig.Datadog.GetString("runtime_security_config.webhook.tls.ca_file"),
+		WebhookCertFile:                    aconfig.Datadog.GetString("runtime_security_config.webhook.tls.cert_file"),
+		WebhookKeyFile:                     aconfig.Datadog.GetString("runtime_security_config.webhook.tls.key_file"),
+		WebhookTimeout:                     time.Duration(aconfig.Datadog.GetInt("runtime_security_config.webhook.timeout")) * time.Second,
+		WebhookMaxRetries:                  aconfig.Datadog.GetInt("runtime_security_config.webhook.max_retries"),
+		WebhookRetryDelay:                  time.Duration(aconfig.Datadog.GetInt("runtime_security_config.webhook.retry_delay")) * time.Millisecond,
 		SocketPath:                         aconfig.Datadog.GetString("runtime_security_config.socket"),
 		SyscallMonitor:                     aconfig.Datadog.GetBool("runtime_security_config.syscall_monitor.enabled"),
+		ProgramStatsEnabled:                aconfig.Datadog.GetBool("runtime_security_config.program_stats.enabled"),
 		PoliciesDir:                        aconfig.Datadog.GetString("runtime_security_config.policies.dir"),
+		ListsReloadPeriod:                  time.Duration(aconfig.Datadog.GetInt("runtime_security_config.policies.lists_reload_period")) * time.Second,
+		PoliciesWatchPeriod:                time.Duration(aconfig.Datadog.GetInt("runtime_security_config.policies.watch_period")) * time.Second,
+		PoliciesOverridesFile:              aconfig.Datadog.GetString("runtime_security_config.policies.overrides_file"),
+		RemotePoliciesEnabled:              aconfig.Datadog.GetBool("runtime_security_config.policies.remote.enabled"),
+		RemotePoliciesURL:                  aconfig.GetMainEndpoint("https://api.", "runtime_security_config.policies.remote.dd_url") + remotePoliciesPath,
+		RemotePoliciesRefreshPeriod:        time.Duration(aconfig.Datadog.GetInt("runtime_security_config.policies.remote.refresh_period")) * time.Second,
+		RemotePoliciesCacheDir:             aconfig.Datadog.GetString("runtime_security_config.policies.remote.cache_dir"),
+		RemotePoliciesPublicKey:            aconfig.Datadog.GetString("runtime_security_config.policies.remote.public_key"),
+		APIKey:                             aconfig.SanitizeAPIKey(aconfig.Datadog.GetString("api_key")),
 		EventServerBurst:                   aconfig.Datadog.GetInt("runtime_security_config.event_server.burst"),
 		EventServerRate:                    aconfig.Datadog.GetInt("runtime_security_config.event_server.rate"),
+		SeverityThreshold:                  aconfig.Datadog.GetString("runtime_security_config.event_server.severity_threshold"),
+		BelowThresholdSampleRate:           aconfig.Datadog.GetFloat64("runtime_security_config.event_server.below_threshold_sample_rate"),
+		SimulationSampleRate:               aconfig.Datadog.GetFloat64("runtime_security_config.event_server.simulation_sample_rate"),
 		PIDCacheSize:                       aconfig.Datadog.GetInt("runtime_security_config.pid_cache_size"),
+		PIDCacheTTL:                        time.Duration(aconfig.Datadog.GetInt("runtime_security_config.pid_cache_ttl")) * time.Second,
+		EnvTags:                            aconfig.Datadog.GetStringSlice("runtime_security_config.env_tags"),
 		LoadControllerEventsCountThreshold: int64(aconfig.Datadog.GetInt("runtime_security_config.load_controller.events_count_threshold")),
 		LoadControllerDiscarderTimeout:     time.Duration(aconfig.Datadog.GetInt("runtime_security_config.load_controller.discarder_timeout")) * time.Second,
 		LoadControllerControlPeriod:        time.Duration(aconfig.Datadog.GetInt("runtime_security_config.load_controller.control_period")) * time.Second,
+		LoadControllerLostEventsThreshold:  int64(aconfig.Datadog.GetInt("runtime_security_config.load_controller.lost_events_threshold")),
+		LoadControllerStandbyApprovers:     aconfig.Datadog.GetBool("runtime_security_config.load_controller.standby_approvers"),
+		ContainerFilterPolicies:            aconfig.Datadog.GetStringMapString("runtime_security_config.container_filter_policies"),
+		ResyncLostEventsThreshold:          int64(aconfig.Datadog.GetInt("runtime_security_config.resync.lost_events_threshold")),
+		ResyncPeriod:                       time.Duration(aconfig.Datadog.GetInt("runtime_security_config.resync.period")) * time.Second,
+		ResyncSustainedPeriods:             aconfig.Datadog.GetInt("runtime_security_config.resync.sustained_periods"),
+		KernelRateLimiterRate:              aconfig.Datadog.GetInt("runtime_security_config.kernel_rate_limiter.rate"),
+		KernelRateLimiterBurst:             aconfig.Datadog.GetInt("runtime_security_config.kernel_rate_limiter.burst"),
+		KernelRateLimiterContainerRate:     aconfig.Datadog.GetInt("runtime_security_config.kernel_rate_limiter.container_rate"),
+		KernelRateLimiterContainerBurst:    aconfig.Datadog.GetInt("runtime_security_config.kernel_rate_limiter.container_burst"),
+		ERPCDentryResolutionEnabled:        aconfig.Datadog.GetBool("runtime_security_config.erpc_dentry_resolution_enabled"),
+		EnforcementEnabled:                 aconfig.Datadog.GetBool("runtime_security_config.enforcement.enabled"),
+		KillAllowlist:                      aconfig.Datadog.GetStringSlice("runtime_security_config.enforcement.kill_allowlist"),
 	}
 
 	if cfg != nil {
 		c.BPFDir = cfg.SystemProbeBPFDir
 	}
 
+	c.EventStreamBufferSizes = make(map[string]int)
+	c.EventStreamWatermarks = make(map[string]int)
+	for _, name := range EventStreamMaps {
+		c.EventStreamBufferSizes[name] = aconfig.Datadog.GetInt("runtime_security_config.event_stream." + name + ".buffer_size")
+		c.EventStreamWatermarks[name] = aconfig.Datadog.GetInt("runtime_security_config.event_stream." + name + ".watermark")
+	}
+
 	if !c.Enabled {
 		return c, nil
 	}
@@ -89,5 +385,85 @@ func NewConfig(cfg *config.AgentConfig) (*Config, error) {
 		c.EnableKernelFilters = false
 	}
 
+	if c.ApproversMapSize <= 0 || c.DiscardersMapSize < 0 {
+		return nil, errors.New("the sizes of the filter maps should be positive")
+	}
+
+	if c.MapSizingProcessCacheSize < 0 || c.MapSizingDentryCacheSize < 0 {
+		return nil, errors.New("the sizes of the caches should be positive")
+	}
+
+	for _, name := range EventStreamMaps {
+		if c.EventStreamBufferSizes[name] < 0 || c.EventStreamWatermarks[name] < 0 {
+			return nil, errors.Errorf("the buffer size and watermark of %s should be positive", name)
+		}
+	}
+
+	if c.EventStreamBatchSize > 1 && c.EventStreamBatchFlushInterval <= 0 {
+		return nil, errors.New("the flush interval of the event batches should be positive")
+	}
+
+	if c.ResyncLostEventsThreshold > 0 && (c.ResyncPeriod <= 0 || c.ResyncSustainedPeriods <= 0) {
+		return nil, errors.New("the resync period and the number of sustained periods should be positive")
+	}
+
+	if c.KRetProbeMaxActive < 0 {
+		return nil, errors.New("the maximum number of active kretprobes should be positive")
+	}
+
+	if c.EventWorkers < 0 || c.EventWorkersQueueSize <= 0 {
+		return nil, errors.New("the number of event workers and the size of their queues should be positive")
+	}
+
+	if c.DiscardersOverflow != DiscardersOverflowEvict && c.DiscardersOverflow != DiscardersOverflowReject {
+		return nil, errors.Errorf("invalid discarders overflow strategy `%s`, expected `%s` or `%s`", c.DiscardersOverflow, DiscardersOverflowEvict, DiscardersOverflowReject)
+	}
+
+	for containerID, mode := range c.ContainerFilterPolicies {
+		if mode != ContainerFilterPolicyAccept && mode != ContainerFilterPolicyDeny {
+			return nil, errors.Errorf("invalid filter policy `%s` of container `%s`, expected `%s` or `%s`", mode, containerID, ContainerFilterPolicyAccept, ContainerFilterPolicyDeny)
+		}
+	}
+
+	switch c.SyslogFormat {
+	case RuleMatchFormatJSON, RuleMatchFormatCEF, RuleMatchFormatLEEF:
+	default:
+		return nil, errors.Errorf("invalid syslog format `%s`, expected `%s`, `%s` or `%s`", c.SyslogFormat, RuleMatchFormatJSON, RuleMatchFormatCEF, RuleMatchFormatLEEF)
+	}
+
+	if c.PoliciesOverridesFile == "" {
+		c.PoliciesOverridesFile = filepath.Join(c.PoliciesDir, "overrides.yaml")
+	}
+
+	if c.RuntimeCompilationOutputDir == "" {
+		c.RuntimeCompilationOutputDir = filepath.Join(aconfig.Datadog.GetString("runtime_security_config.run_path"), "runtime-security-build")
+	}
+
+	if c.DiagnosticsDir == "" {
+		c.DiagnosticsDir = filepath.Join(aconfig.Datadog.GetString("runtime_security_config.run_path"), "runtime-security-diagnostics")
+	}
+
+	if c.AuditLogEnabled && (c.AuditLogMaxSize <= 0 || c.AuditLogMaxFiles < 0) {
+		return nil, errors.New("the maximum size of the audit log and its number of rotated files should be positive")
+	}
+
+	if c.WebhookEnabled && (c.WebhookTimeout <= 0 || c.WebhookMaxRetries < 0 || c.WebhookRetryDelay < 0) {
+		return nil, errors.New("the timeout of the webhook, its number of retries and their delay should be positive")
+	}
+
+	if c.RemotePoliciesEnabled {
+		if c.RemotePoliciesPublicKey == "" {
+			return nil, errors.New("remote policies require a public key to verify the policy bundles")
+		}
+
+		if c.RemotePoliciesRefreshPeriod <= 0 {
+			return nil, errors.New("the refresh period of the remote policies should be positive")
+		}
+
+		if c.RemotePoliciesCacheDir == "" {
+			c.RemotePoliciesCacheDir = filepath.Join(aconfig.Datadog.GetString("runtime_security_config.run_path"), "runtime-security-remote")
+		}
+	}
+
 	return c, nil
 }
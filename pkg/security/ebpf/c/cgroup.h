@@ -30,12 +30,14 @@ static __attribute__((always_inline)) int trace__cgroup_write(struct pt_regs *ct
     bpf_probe_read(&f, sizeof(f), &kern_f->file);
     struct dentry *dentry = get_file_dentry(f);
 
-    // The last dentry in the cgroup path should be `cgroup.procs`, thus the container ID should be its parent.
+    // The last dentry in the cgroup path should be `cgroup.procs`, thus the container ID should be its parent,
+    // possibly prefixed by the name of the runtime with cgroup v2.
     struct dentry *container_d;
     struct qstr container_qstr;
     bpf_probe_read(&container_d, sizeof(container_d), &dentry->d_parent);
     bpf_probe_read(&container_qstr, sizeof(container_qstr), &container_d->d_name);
-    bpf_probe_read(&new_entry.container.container_id, sizeof(new_entry.container.container_id), (void*) container_qstr.name);
+    u32 offset = get_container_id_offset((const char *)container_qstr.name);
+    bpf_probe_read(&new_entry.container.container_id, sizeof(new_entry.container.container_id), (void*) container_qstr.name + offset);
     bpf_map_update_elem(&proc_cache, &cookie, &new_entry, BPF_ANY);
 
     if (new_cookie) {
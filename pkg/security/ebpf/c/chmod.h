@@ -1,18 +1,53 @@
 #ifndef _CHMOD_H_
 #define _CHMOD_H_
 
+#include "filters.h"
 #include "syscalls.h"
 
+struct bpf_map_def SEC("maps/chmod_basename_approvers") chmod_basename_approvers = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = BASENAME_FILTER_SIZE,
+    .value_size = sizeof(struct filter_t),
+    .max_entries = 255,
+    .pinning = 0,
+    .namespace = "",
+};
+
+struct bpf_map_def SEC("maps/chmod_mode_approvers") chmod_mode_approvers = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
+};
+
 struct chmod_event_t {
     struct kevent_t event;
     struct process_context_t process;
     struct container_context_t container;
     struct syscall_t syscall;
     struct file_t file;
+    struct file_metadata_t metadata;
     u32 mode;
     u32 padding;
 };
 
+// approve_chmod is called by kprobe/security_inode_setattr, once the file is known
+int __attribute__((always_inline)) approve_chmod(struct syscall_cache_t *syscall) {
+    if (syscall->policy.mode != DENY)
+        return 1;
+
+    if ((syscall->policy.flags & BASENAME) > 0 && approved_by_basename(&chmod_basename_approvers, FILTER_CHMOD_BASENAME_APPROVERS, syscall->setattr.dentry) &&
+        approved_by_inode(EVENT_CHMOD, syscall->setattr.dentry, syscall->setattr.path_key.mount_id))
+        return 1;
+
+    if ((syscall->policy.flags & MODE) > 0 && approved_by_mode(&chmod_mode_approvers, FILTER_CHMOD_MODE_APPROVERS, syscall->setattr.mode))
+        return 1;
+
+    return 0;
+}
+
 int __attribute__((always_inline)) trace__sys_chmod(umode_t mode) {
     struct syscall_cache_t syscall = {
         .type = SYSCALL_CHMOD,
@@ -68,6 +103,7 @@ int __attribute__((always_inline)) trace__sys_chmod_ret(struct pt_regs *ctx) {
             .overlay_numlower = get_overlay_numlower(syscall->setattr.dentry),
             .path_id = syscall->setattr.path_key.path_id,
         },
+        .metadata = syscall->setattr.metadata,
         .padding = 0,
         .mode = syscall->setattr.mode,
     };
@@ -77,6 +113,8 @@ int __attribute__((always_inline)) trace__sys_chmod_ret(struct pt_regs *ctx) {
 
     // dentry resolution in setattr.h
 
+    fill_file_generation(event.file);
+
     send_event(ctx, event);
 
     return 0;
@@ -1,18 +1,70 @@
 #ifndef _CHOWN_H_
 #define _CHOWN_H_
 
+#include "filters.h"
 #include "syscalls.h"
 
+struct bpf_map_def SEC("maps/chown_basename_approvers") chown_basename_approvers = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = BASENAME_FILTER_SIZE,
+    .value_size = sizeof(struct filter_t),
+    .max_entries = 255,
+    .pinning = 0,
+    .namespace = "",
+};
+
+struct bpf_map_def SEC("maps/chown_uid_approvers") chown_uid_approvers = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct id_range_t),
+    .max_entries = ID_RANGE_APPROVERS_SIZE,
+    .pinning = 0,
+    .namespace = "",
+};
+
+struct bpf_map_def SEC("maps/chown_gid_approvers") chown_gid_approvers = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct id_range_t),
+    .max_entries = ID_RANGE_APPROVERS_SIZE,
+    .pinning = 0,
+    .namespace = "",
+};
+
 struct chown_event_t {
     struct kevent_t event;
     struct process_context_t process;
     struct container_context_t container;
     struct syscall_t syscall;
     struct file_t file;
+    struct file_metadata_t metadata;
     uid_t user;
     gid_t group;
 };
 
+// approve_chown is called by kprobe/security_inode_setattr, once the file and its new owner are known
+int __attribute__((always_inline)) approve_chown(struct syscall_cache_t *syscall) {
+    if (syscall->policy.mode != DENY)
+        return 1;
+
+    if ((syscall->policy.flags & BASENAME) > 0 && approved_by_basename(&chown_basename_approvers, FILTER_CHOWN_BASENAME_APPROVERS, syscall->setattr.dentry) &&
+        approved_by_inode(EVENT_CHOWN, syscall->setattr.dentry, syscall->setattr.path_key.mount_id))
+        return 1;
+
+    if ((syscall->policy.flags & UID) > 0 && approved_by_id_range(&chown_uid_approvers, FILTER_CHOWN_UID_APPROVERS, syscall->setattr.user))
+        return 1;
+
+    if ((syscall->policy.flags & GID) > 0 && approved_by_id_range(&chown_gid_approvers, FILTER_CHOWN_GID_APPROVERS, syscall->setattr.group))
+        return 1;
+
+    return 0;
+}
+
+// extend_id16 extends the 16 bits ids of the legacy syscalls of the 32 bits processes, -1 leaving the id unchanged
+static __attribute__((always_inline)) u32 extend_id16(u32 id) {
+    return (u16)id == (u16)-1 ? (u32)-1 : (u16)id;
+}
+
 int __attribute__((always_inline)) trace__sys_chown(uid_t user, gid_t group) {
     struct syscall_cache_t syscall = {
         .type = SYSCALL_CHOWN,
@@ -44,15 +96,15 @@ SYSCALL_KPROBE3(chown, const char*, filename, uid_t, user, gid_t, group) {
 }
 
 SYSCALL_KPROBE3(lchown16, const char*, filename, uid_t, user, gid_t, group) {
-    return trace__sys_chown(user, group);
+    return trace__sys_chown(extend_id16(user), extend_id16(group));
 }
 
 SYSCALL_KPROBE3(fchown16, int, fd, uid_t, user, gid_t, group) {
-    return trace__sys_chown(user, group);
+    return trace__sys_chown(extend_id16(user), extend_id16(group));
 }
 
 SYSCALL_KPROBE3(chown16, const char*, filename, uid_t, user, gid_t, group) {
-    return trace__sys_chown(user, group);
+    return trace__sys_chown(extend_id16(user), extend_id16(group));
 }
 
 SYSCALL_KPROBE4(fchownat, int, dirfd, const char*, filename, uid_t, user, gid_t, group) {
@@ -85,6 +137,7 @@ int __attribute__((always_inline)) trace__sys_chown_ret(struct pt_regs *ctx) {
             .overlay_numlower = get_overlay_numlower(syscall->setattr.dentry),
             .path_id = syscall->setattr.path_key.path_id,
         },
+        .metadata = syscall->setattr.metadata,
         .user = syscall->setattr.user,
         .group = syscall->setattr.group,
     };
@@ -94,6 +147,8 @@ int __attribute__((always_inline)) trace__sys_chown_ret(struct pt_regs *ctx) {
 
     // dentry resolution in setattr.h
 
+    fill_file_generation(event.file);
+
     send_event(ctx, event);
 
     return 0;
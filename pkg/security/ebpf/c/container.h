@@ -18,6 +18,28 @@ static __attribute__((always_inline)) u32 copy_container_id(char dst[CONTAINER_I
     return CONTAINER_ID_LEN;
 }
 
+#define CGROUP_PREFIX_MAX_LEN 32
+
+// get_container_id_offset returns the offset of the container ID in the name of a cgroup directory. With the cgroup v2
+// unified hierarchy and systemd slices, the container ID is prefixed by the runtime: docker-<id>.scope,
+// cri-containerd-<id>.scope, crio-<id>.scope, libpod-<id>.scope. With cgroup v1 the directory is the container ID.
+static __attribute__((always_inline)) u32 get_container_id_offset(const char *name) {
+    char prefix[CGROUP_PREFIX_MAX_LEN] = {};
+    bpf_probe_read(&prefix, sizeof(prefix), (void *)name);
+
+    u32 offset = 0;
+#pragma unroll
+    for (int i = 0; i < CGROUP_PREFIX_MAX_LEN; i++) {
+        if (prefix[i] == 0) {
+            break;
+        }
+        if (prefix[i] == '-') {
+            offset = i + 1;
+        }
+    }
+    return offset;
+}
+
 static void __attribute__((always_inline)) fill_container_data(struct proc_cache_t *entry, struct container_context_t *context) {
     if (entry) {
         copy_container_id(context->container_id, entry->container.container_id);
@@ -0,0 +1,80 @@
+#ifndef _COPY_UP_H_
+#define _COPY_UP_H_
+
+#include "defs.h"
+#include "filters.h"
+#include "dentry.h"
+
+// ovl_copy_up_t holds the state of the copy-up of a file of a lower layer to the upper layer of an overlay
+struct ovl_copy_up_t {
+    u64 lower_ino;
+    u32 done;
+    u32 padding;
+};
+
+struct bpf_map_def SEC("maps/ovl_copy_ups") ovl_copy_ups = {
+    .type = BPF_MAP_TYPE_LRU_HASH,
+    .key_size = sizeof(u64),
+    .value_size = sizeof(struct ovl_copy_up_t),
+    .max_entries = 512,
+    .pinning = 0,
+    .namespace = "",
+};
+
+SEC("kprobe/ovl_copy_up_flags")
+int kprobe__ovl_copy_up_flags(struct pt_regs *ctx) {
+    struct dentry *dentry = (struct dentry *)PT_REGS_PARM1(ctx);
+
+    // the overlay inode reports the inode of the lower file, the one the discarders were pushed for
+    struct ovl_copy_up_t copy_up = {
+        .lower_ino = get_dentry_ino(dentry),
+    };
+
+    u64 id = bpf_get_current_pid_tgid();
+    bpf_map_update_elem(&ovl_copy_ups, &id, &copy_up, BPF_ANY);
+
+    return 0;
+}
+
+SEC("kretprobe/ovl_copy_up_flags")
+int kretprobe__ovl_copy_up_flags(struct pt_regs *ctx) {
+    u64 id = bpf_get_current_pid_tgid();
+    struct ovl_copy_up_t *copy_up = bpf_map_lookup_elem(&ovl_copy_ups, &id);
+    if (!copy_up)
+        return 0;
+
+    int retval = PT_REGS_RC(ctx);
+    if (retval) {
+        bpf_map_delete_elem(&ovl_copy_ups, &id);
+        return 0;
+    }
+
+    copy_up->done = 1;
+
+    return 0;
+}
+
+int __attribute__((always_inline)) invalidate_copied_up_inode(struct path_key_t key) {
+    u64 id = bpf_get_current_pid_tgid();
+    struct ovl_copy_up_t *copy_up = bpf_map_lookup_elem(&ovl_copy_ups, &id);
+    if (!copy_up)
+        return 0;
+
+    if (!copy_up->done) {
+        return 0;
+    }
+
+    // the discarders of the lower file would hide the file copied up, and the ones of a previous file of the upper
+    // layer reusing its inode would hide it too
+#pragma unroll
+    for (int i = 1; i < EVENT_MAX; i++) {
+        remove_inode_discarder(i, key.mount_id, copy_up->lower_ino);
+        remove_inode_discarder(i, key.mount_id, key.ino);
+    }
+
+    bpf_map_delete_elem(&ovl_copy_ups, &id);
+
+    return 1;
+}
+
+#endif
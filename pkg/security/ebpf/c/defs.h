@@ -6,20 +6,24 @@
 #define LOAD_CONSTANT(param, var) asm("%0 = " param " ll" : "=r"(var))
 
 #if defined(__x86_64__)
-  #define SYSCALL64_PREFIX "__x64_"
-  #define SYSCALL32_PREFIX "__ia32_"
+  #define SYSCALL64_WRAPPER_PREFIX "__x64_"
+  #define SYSCALL32_WRAPPER_PREFIX "__ia32_"
 
   #define SYSCALL64_PT_REGS_PARM1(x) ((x)->di)
   #define SYSCALL64_PT_REGS_PARM2(x) ((x)->si)
   #define SYSCALL64_PT_REGS_PARM3(x) ((x)->dx)
-  #if USE_SYSCALL_WRAPPER == 1
-   #define SYSCALL64_PT_REGS_PARM4(x) ((x)->r10)
-  #else
   #define SYSCALL64_PT_REGS_PARM4(x) ((x)->cx)
-  #endif
   #define SYSCALL64_PT_REGS_PARM5(x) ((x)->r8)
   #define SYSCALL64_PT_REGS_PARM6(x) ((x)->r9)
 
+  // the syscall wrappers are given the registers of the syscall, the fourth argument is in r10
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM1(x) ((x)->di)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM2(x) ((x)->si)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM3(x) ((x)->dx)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM4(x) ((x)->r10)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM5(x) ((x)->r8)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM6(x) ((x)->r9)
+
   #define SYSCALL32_PT_REGS_PARM1(x) ((x)->bx)
   #define SYSCALL32_PT_REGS_PARM2(x) ((x)->cx)
   #define SYSCALL32_PT_REGS_PARM3(x) ((x)->dx)
@@ -27,9 +31,12 @@
   #define SYSCALL32_PT_REGS_PARM5(x) ((x)->di)
   #define SYSCALL32_PT_REGS_PARM6(x) ((x)->bp)
 
+  // the 32 bits processes have their own syscall wrappers
+  #define SYSCALL32_WRAPPER_HOOKx(...) SYSCALL_ABI_HOOKx(__VA_ARGS__)
+
 #elif defined(__aarch64__)
-  #define SYSCALL64_PREFIX "__arm64_"
-  #define SYSCALL32_PREFIX "__arm32_"
+  #define SYSCALL64_WRAPPER_PREFIX "__arm64_"
+  #define SYSCALL32_WRAPPER_PREFIX "__arm64_"
 
   #define SYSCALL64_PT_REGS_PARM1(x) PT_REGS_PARM1(x)
   #define SYSCALL64_PT_REGS_PARM2(x) PT_REGS_PARM2(x)
@@ -38,6 +45,13 @@
   #define SYSCALL64_PT_REGS_PARM5(x) PT_REGS_PARM5(x)
   #define SYSCALL64_PT_REGS_PARM6(x) PT_REGS_PARM6(x)
 
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM1(x) PT_REGS_PARM1(x)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM2(x) PT_REGS_PARM2(x)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM3(x) PT_REGS_PARM3(x)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM4(x) PT_REGS_PARM4(x)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM5(x) PT_REGS_PARM5(x)
+  #define SYSCALL64_WRAPPER_PT_REGS_PARM6(x) PT_REGS_PARM6(x)
+
   #define SYSCALL32_PT_REGS_PARM1(x) PT_REGS_PARM1(x)
   #define SYSCALL32_PT_REGS_PARM2(x) PT_REGS_PARM2(x)
   #define SYSCALL32_PT_REGS_PARM3(x) PT_REGS_PARM3(x)
@@ -45,6 +59,9 @@
   #define SYSCALL32_PT_REGS_PARM5(x) PT_REGS_PARM5(x)
   #define SYSCALL32_PT_REGS_PARM6(x) PT_REGS_PARM6(x)
 
+  // the 32 bits processes share the syscall wrappers of the 64 bits processes, except the ones of the compat syscalls
+  #define SYSCALL32_WRAPPER_HOOKx(...)
+
 #else
   #error "Unsupported platform"
 #endif
@@ -79,60 +96,90 @@
 #define __SC_DECL(t, a) t a
 #define __SC_PASS(t, a) a
 
-#define SYSCALL_ABI_HOOKx(x,word_size,type,TYPE,prefix,syscall,suffix,...) \
+#define SYSCALL_ABI_HOOKx(x,word_size,type,TYPE,variant,prefix,syscall,suffix,...) \
     int __attribute__((always_inline)) type##__##sys##syscall(__JOIN(x,__SC_DECL,__VA_ARGS__)); \
-    SEC(#type "/" SYSCALL##word_size##_PREFIX #prefix SYSCALL_PREFIX #syscall #suffix) \
-    int type##__ ##word_size##_##prefix ##sys##syscall##suffix(struct pt_regs *ctx) { \
-        SYSCALL_##TYPE##_PROLOG(x,__SC_##word_size##_PARAM,syscall,__VA_ARGS__) \
+    SEC(#type "/" SYSCALL##word_size##_##variant##PREFIX #prefix SYSCALL_PREFIX #syscall #suffix) \
+    int type##__ ##word_size##_##variant##prefix ##sys##syscall##suffix(struct pt_regs *ctx) { \
+        SYSCALL_##variant##TYPE##_PROLOG(x,__SC_##word_size##_##variant##PARAM,syscall,__VA_ARGS__) \
         return type##__sys##syscall(__JOIN(x,__SC_PASS,__VA_ARGS__)); \
     }
 
 #define SYSCALL_HOOK_COMMON(x,type,syscall,...) int __attribute__((always_inline)) type##__sys##syscall(__JOIN(x,__SC_DECL,__VA_ARGS__))
 
-#if USE_SYSCALL_WRAPPER == 1
-  #define SYSCALL_PREFIX "sys"
-  #define __SC_64_PARAM(n, t, a) t a; bpf_probe_read(&a, sizeof(t), (void*) &SYSCALL64_PT_REGS_PARM##n(ctx));
-  #define __SC_32_PARAM(n, t, a) t a; bpf_probe_read(&a, sizeof(t), (void*) &SYSCALL32_PT_REGS_PARM##n(ctx));
-  #define SYSCALL_KPROBE_PROLOG(x,m,syscall,...) \
-    ctx = (struct pt_regs *) PT_REGS_PARM1(ctx); \
-    __MAP(x,m,__VA_ARGS__)
-  #define SYSCALL_KRETPROBE_PROLOG(...)
+#define SYSCALL_PREFIX "sys"
+
+// the syscall wrappers are given the registers of the syscall as their first argument
+#define __SC_64_WRAPPER_PARAM(n, t, a) t a; bpf_probe_read(&a, sizeof(t), (void*) &SYSCALL64_WRAPPER_PT_REGS_PARM##n(ctx));
+// the registers of the 32 bits syscalls are zero extended, their upper half isn't cleared on entry
+#define __SC_32_WRAPPER_PARAM(n, t, a) t a; { u32 __reg; bpf_probe_read(&__reg, sizeof(__reg), (void*) &SYSCALL32_PT_REGS_PARM##n(ctx)); a = (t) (unsigned long) __reg; }
+#define SYSCALL_WRAPPER_KPROBE_PROLOG(x,m,syscall,...) \
+  ctx = (struct pt_regs *) PT_REGS_PARM1(ctx); \
+  __MAP(x,m,__VA_ARGS__)
+#define SYSCALL_WRAPPER_KRETPROBE_PROLOG(...)
+#define SYSCALL_WRAPPER_HOOKx(x,type,TYPE,prefix,name,...) \
+  SYSCALL32_WRAPPER_HOOKx(x,32,type,TYPE,WRAPPER_,prefix,name,,__VA_ARGS__) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,WRAPPER_,,name,,__VA_ARGS__)
+#define SYSCALL_WRAPPER_COMPAT_HOOKx(x,type,TYPE,name,...) \
+  SYSCALL_ABI_HOOKx(x,32,type,TYPE,WRAPPER_,compat_,name,,__VA_ARGS__) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,WRAPPER_,,name,,__VA_ARGS__)
+#define SYSCALL_WRAPPER_COMPAT_TIME_HOOKx(x,type,TYPE,name,...) \
+  SYSCALL_ABI_HOOKx(x,32,type,TYPE,WRAPPER_,compat_,name,,__VA_ARGS__) \
+  SYSCALL32_WRAPPER_HOOKx(x,32,type,TYPE,WRAPPER_,,name,_time32,__VA_ARGS__) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,WRAPPER_,,name,,__VA_ARGS__) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,WRAPPER_,,name,_time32,__VA_ARGS__)
+
+// without the syscall wrappers, the arguments are read from the registers of the syscall function
+#define SYSCALL32_PREFIX ""
+#define SYSCALL64_PREFIX ""
+#define __SC_64_PARAM(n, t, a) t a = (t) SYSCALL64_PT_REGS_PARM##n(ctx);
+#define __SC_32_PARAM(n, t, a) t a = (t) SYSCALL32_PT_REGS_PARM##n(ctx);
+#define SYSCALL_KPROBE_PROLOG(x,m,syscall,...) \
+  __MAP(x,m,__VA_ARGS__)
+#define SYSCALL_KRETPROBE_PROLOG(...)
+#define SYSCALL_NO_WRAPPER_HOOKx(x,type,TYPE,prefix,name,...) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,,compat_,name,,__VA_ARGS__) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,,,name,,__VA_ARGS__)
+#define SYSCALL_NO_WRAPPER_COMPAT_HOOKx(x,type,TYPE,name,...) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,,compat_,name,,__VA_ARGS__) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,,,name,,__VA_ARGS__)
+#define SYSCALL_NO_WRAPPER_COMPAT_TIME_HOOKx(x,type,TYPE,name,...) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,,compat_,name,,__VA_ARGS__) \
+  SYSCALL_ABI_HOOKx(x,64,type,TYPE,,,name,,__VA_ARGS__)
+
+#if USE_CORE == 1
+  // the CO-RE object runs on the kernels with and without the syscall wrappers, the probes of the other variant are
+  // excluded when the object is loaded
   #define SYSCALL_HOOKx(x,type,TYPE,prefix,name,...) \
-    SYSCALL_ABI_HOOKx(x,32,type,TYPE,prefix,name,,__VA_ARGS__) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,,name,,__VA_ARGS__) \
+    SYSCALL_WRAPPER_HOOKx(x,type,TYPE,prefix,name,__VA_ARGS__) \
+    SYSCALL_NO_WRAPPER_HOOKx(x,type,TYPE,prefix,name,__VA_ARGS__) \
     SYSCALL_HOOK_COMMON(x,type,name,__VA_ARGS__)
   #define SYSCALL_COMPAT_HOOKx(x,type,TYPE,name,...) \
-    SYSCALL_ABI_HOOKx(x,32,type,TYPE,compat_,name,,__VA_ARGS__) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,,name,,__VA_ARGS__) \
+    SYSCALL_WRAPPER_COMPAT_HOOKx(x,type,TYPE,name,__VA_ARGS__) \
+    SYSCALL_NO_WRAPPER_COMPAT_HOOKx(x,type,TYPE,name,__VA_ARGS__) \
     SYSCALL_HOOK_COMMON(x,type,name,__VA_ARGS__)
   #define SYSCALL_COMPAT_TIME_HOOKx(x,type,TYPE,name,...) \
-    SYSCALL_ABI_HOOKx(x,32,type,TYPE,compat_,name,,__VA_ARGS__) \
-    SYSCALL_ABI_HOOKx(x,32,type,TYPE,,name,_time32,__VA_ARGS__) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,,name,,__VA_ARGS__) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,,name,_time32,__VA_ARGS__) \
+    SYSCALL_WRAPPER_COMPAT_TIME_HOOKx(x,type,TYPE,name,__VA_ARGS__) \
+    SYSCALL_NO_WRAPPER_COMPAT_TIME_HOOKx(x,type,TYPE,name,__VA_ARGS__) \
+    SYSCALL_HOOK_COMMON(x,type,name,__VA_ARGS__)
+#elif USE_SYSCALL_WRAPPER == 1
+  #define SYSCALL_HOOKx(x,type,TYPE,prefix,name,...) \
+    SYSCALL_WRAPPER_HOOKx(x,type,TYPE,prefix,name,__VA_ARGS__) \
+    SYSCALL_HOOK_COMMON(x,type,name,__VA_ARGS__)
+  #define SYSCALL_COMPAT_HOOKx(x,type,TYPE,name,...) \
+    SYSCALL_WRAPPER_COMPAT_HOOKx(x,type,TYPE,name,__VA_ARGS__) \
+    SYSCALL_HOOK_COMMON(x,type,name,__VA_ARGS__)
+  #define SYSCALL_COMPAT_TIME_HOOKx(x,type,TYPE,name,...) \
+    SYSCALL_WRAPPER_COMPAT_TIME_HOOKx(x,type,TYPE,name,__VA_ARGS__) \
     SYSCALL_HOOK_COMMON(x,type,name,__VA_ARGS__)
 #else
-  #undef SYSCALL32_PREFIX
-  #undef SYSCALL64_PREFIX
-  #define SYSCALL32_PREFIX ""
-  #define SYSCALL64_PREFIX ""
-  #define SYSCALL_PREFIX "sys"
-  #define __SC_64_PARAM(n, t, a) t a = (t) SYSCALL64_PT_REGS_PARM##n(ctx);
-  #define __SC_32_PARAM(n, t, a) t a = (t) SYSCALL32_PT_REGS_PARM##n(ctx);
-  #define SYSCALL_KPROBE_PROLOG(x,m,syscall,...) \
-    __MAP(x,m,__VA_ARGS__)
-  #define SYSCALL_KRETPROBE_PROLOG(...)
   #define SYSCALL_HOOKx(x,type,TYPE,prefix,name,...) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,compat_,name,,__VA_ARGS__) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,,name,,__VA_ARGS__) \
+    SYSCALL_NO_WRAPPER_HOOKx(x,type,TYPE,prefix,name,__VA_ARGS__) \
     SYSCALL_HOOK_COMMON(x,type,name,__VA_ARGS__)
   #define SYSCALL_COMPAT_HOOKx(x,type,TYPE,name,...) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,compat_,name,,__VA_ARGS__) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,,name,,__VA_ARGS__) \
+    SYSCALL_NO_WRAPPER_COMPAT_HOOKx(x,type,TYPE,name,__VA_ARGS__) \
     SYSCALL_HOOK_COMMON(x,type,name,__VA_ARGS__)
   #define SYSCALL_COMPAT_TIME_HOOKx(x,type,TYPE,name,...) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,compat_,name,,__VA_ARGS__) \
-    SYSCALL_ABI_HOOKx(x,64,type,TYPE,,name,,__VA_ARGS__) \
+    SYSCALL_NO_WRAPPER_COMPAT_TIME_HOOKx(x,type,TYPE,name,__VA_ARGS__) \
     SYSCALL_HOOK_COMMON(x,type,name,__VA_ARGS__)
 #endif
 
@@ -166,9 +213,55 @@
 
 #define SYSCALL_COMPAT_TIME_KRETPROBE(name, ...) SYSCALL_COMPAT_TIME_HOOKx(1,kretprobe,KRETPROBE,_##name,struct pt_regs*,ctx)
 
+/*
+ * HOOK_ENTRYx defines the kprobe of a kernel function of x arguments and its fentry counterpart, attached through a
+ * BPF trampoline instead of the kprobe on the kernels supporting them. The hook is given the arguments of the
+ * function, they have to match its prototype
+ */
+#define __HOOK_KPROBE_PARAM(n, t, a) t a = (t) PT_REGS_PARM##n(ctx);
+#define __HOOK_FENTRY_PARAM(n, t, a) t a = (t) ctx[n - 1];
+
+#define HOOK_ENTRYx(x,name,...) \
+    int __attribute__((always_inline)) hook__##name(void *ctx, __JOIN(x,__SC_DECL,__VA_ARGS__)); \
+    SEC("kprobe/" #name) \
+    int kprobe__##name(struct pt_regs *ctx) { \
+        __MAP(x,__HOOK_KPROBE_PARAM,__VA_ARGS__) \
+        return hook__##name(ctx, __JOIN(x,__SC_PASS,__VA_ARGS__)); \
+    } \
+    SEC("fentry/" #name) \
+    int fentry__##name(u64 *ctx) { \
+        __MAP(x,__HOOK_FENTRY_PARAM,__VA_ARGS__) \
+        return hook__##name(ctx, __JOIN(x,__SC_PASS,__VA_ARGS__)); \
+    } \
+    int __attribute__((always_inline)) hook__##name(void *ctx, __JOIN(x,__SC_DECL,__VA_ARGS__))
+
+#define HOOK_ENTRY1(name, ...) HOOK_ENTRYx(1,name,__VA_ARGS__)
+#define HOOK_ENTRY2(name, ...) HOOK_ENTRYx(2,name,__VA_ARGS__)
+#define HOOK_ENTRY3(name, ...) HOOK_ENTRYx(3,name,__VA_ARGS__)
+#define HOOK_ENTRY4(name, ...) HOOK_ENTRYx(4,name,__VA_ARGS__)
+#define HOOK_ENTRY5(name, ...) HOOK_ENTRYx(5,name,__VA_ARGS__)
+#define HOOK_ENTRY6(name, ...) HOOK_ENTRYx(6,name,__VA_ARGS__)
+
+/*
+ * HOOK_EXIT defines the kretprobe of a kernel function of argc arguments and its fexit counterpart. The hook is given
+ * the return value of the function, the fexit programs read it after the arguments
+ */
+#define HOOK_EXIT(name, argc) \
+    int __attribute__((always_inline)) hook_ret__##name(void *ctx, u64 ret); \
+    SEC("kretprobe/" #name) \
+    int kretprobe__##name(struct pt_regs *ctx) { \
+        return hook_ret__##name(ctx, PT_REGS_RC(ctx)); \
+    } \
+    SEC("fexit/" #name) \
+    int fexit__##name(u64 *ctx) { \
+        return hook_ret__##name(ctx, ctx[argc]); \
+    } \
+    int __attribute__((always_inline)) hook_ret__##name(void *ctx, u64 ret)
+
 #define TTY_NAME_LEN 64
 #define CONTAINER_ID_LEN 64
 #define MAX_XATTR_NAME_LEN 200
+#define MAX_XATTR_VALUE_LEN 128
 
 #define bpf_printk(fmt, ...)                       \
 	({                                             \
@@ -181,7 +274,8 @@
 
 enum event_type
 {
-    EVENT_OPEN = 1,
+    EVENT_ANY = 0, // used by the discarders of all the event types
+    EVENT_OPEN,
     EVENT_MKDIR,
     EVENT_LINK,
     EVENT_RENAME,
@@ -228,6 +322,13 @@ struct file_t {
     u32 mount_id;
     u32 overlay_numlower;
     u32 path_id;
+    u32 generation;
+};
+
+struct file_metadata_t {
+    u32 uid;
+    u32 gid;
+    u32 mode;
     u32 padding;
 };
 
@@ -241,12 +342,24 @@ struct process_context_t {
     u32 tid;
     u32 uid;
     u32 gid;
+    u32 pidns;
+    u32 mntns;
+    u32 netns;
+    u32 padding;
 };
 
 struct container_context_t {
     char container_id[CONTAINER_ID_LEN];
 };
 
+struct executable_metadata_t {
+    u64 mtime;
+    u64 ctime;
+    u64 size;
+    u32 mode;
+    u32 padding;
+};
+
 struct proc_cache_t {
     struct file_t executable;
     struct container_context_t container;
@@ -254,6 +367,10 @@ struct proc_cache_t {
     u32 cookie;
     u32 ppid;
     char tty_name[TTY_NAME_LEN];
+    u32 loginuid;
+    u32 sessionid;
+    struct executable_metadata_t executable_metadata;
+    struct file_t interpreter;
 };
 
 struct path_key_t {
@@ -303,9 +420,6 @@ struct bpf_map_def SEC("maps/events") events = {
     .namespace = "",
 };
 
-#define send_event(ctx, event) \
-    bpf_perf_event_output(ctx, &events, bpf_get_smp_processor_id(), &event, sizeof(event))
-
 struct bpf_map_def SEC("maps/mountpoints_events") mountpoints_events = {
     .type = BPF_MAP_TYPE_PERF_EVENT_ARRAY,
     .key_size = sizeof(__u32),
@@ -315,11 +429,112 @@ struct bpf_map_def SEC("maps/mountpoints_events") mountpoints_events = {
     .namespace = "",
 };
 
+// the ring buffers replace the perf maps on the kernels supporting them (5.8+). The maps below are placeholders,
+// swapped for ring buffers by user space when they are used
+struct bpf_map_def SEC("maps/events_ringbuf") events_ringbuf = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
+};
+
+struct bpf_map_def SEC("maps/mountpoints_events_ringbuf") mountpoints_events_ringbuf = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
+};
+
+enum event_stream {
+    EVENTS_STREAM,
+    MOUNTPOINTS_EVENTS_STREAM,
+    EVENT_STREAM_MAX, // has to be the last one
+};
+
+// ring_buffer_lost counts the events that didn't fit in the ring buffer of each stream, the perf maps report their
+// lost events themselves
+struct bpf_map_def SEC("maps/ring_buffer_lost") ring_buffer_lost = {
+    .type = BPF_MAP_TYPE_PERCPU_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u64),
+    .max_entries = EVENT_STREAM_MAX,
+    .pinning = 0,
+    .namespace = "",
+};
+
+#define RINGBUF_OUTPUT_FUNC_ID 130
+#define RINGBUF_QUERY_FUNC_ID 134
+
+// flags of the ring buffer helpers, named after BPF_RB_NO_WAKEUP, BPF_RB_FORCE_WAKEUP and BPF_RB_AVAIL_DATA that
+// the older kernel headers don't define
+#define RINGBUF_NO_WAKEUP (1ULL << 0)
+#define RINGBUF_FORCE_WAKEUP (1ULL << 1)
+#define RINGBUF_AVAIL_DATA 0
+
+static long (*ringbuf_output)(void *ringbuf, void *data, u64 size, u64 flags) = (void *)RINGBUF_OUTPUT_FUNC_ID;
+static u64 (*ringbuf_query)(void *ringbuf, u64 flags) = (void *)RINGBUF_QUERY_FUNC_ID;
+
+// ringbuf_wakeup_flags returns the flags waking up user space once the data of the ring buffer not yet read reaches
+// the watermark of the stream. The reader polls the ring buffer in the meantime. Each event wakes it up without
+// watermark
+static __attribute__((always_inline)) u64 ringbuf_wakeup_flags(void *ring_buffer, u32 stream, u64 size) {
+    u64 watermark;
+    if (stream == EVENTS_STREAM) {
+        LOAD_CONSTANT("events_ringbuf_watermark", watermark);
+    } else {
+        LOAD_CONSTANT("mountpoints_events_ringbuf_watermark", watermark);
+    }
+
+    if (!watermark) {
+        return 0;
+    }
+    return ringbuf_query(ring_buffer, RINGBUF_AVAIL_DATA) + size >= watermark ? RINGBUF_FORCE_WAKEUP : RINGBUF_NO_WAKEUP;
+}
+
+// output_event writes an event to the ring buffer of its stream when user space enabled them, to its perf map
+// otherwise. The verifier only checks the branch selected by the constant, the ring buffer helpers are unknown to
+// the older kernels
+int __attribute__((always_inline)) output_event(void *ctx, void *perf_map, void *ring_buffer, u32 stream, void *data, u64 size) {
+    u64 use_ring_buffer;
+    LOAD_CONSTANT("use_ring_buffer", use_ring_buffer);
+
+    if (!use_ring_buffer) {
+        return bpf_perf_event_output(ctx, perf_map, bpf_get_smp_processor_id(), data, size);
+    }
+
+    int ret = ringbuf_output(ring_buffer, data, size, ringbuf_wakeup_flags(ring_buffer, stream, size));
+    if (ret < 0) {
+        u64 *lost = bpf_map_lookup_elem(&ring_buffer_lost, &stream);
+        if (lost) {
+            *lost += 1;
+        }
+    }
+    return ret;
+}
+
+// is_dentry_cache_invalidating_event returns whether user space invalidates its dentry cache on the events of the given
+// type, they can't be dropped without leaving stale paths in the cache
+static __attribute__((always_inline)) int is_dentry_cache_invalidating_event(u32 type) {
+    return type == EVENT_RENAME || type == EVENT_UNLINK || type == EVENT_RMDIR || type == EVENT_INVALIDATE_DENTRY;
+}
+
+// send_event sends an event to user space, unless the probe is paused, its event type is masked for the container of
+// the process or its rate is exceeded. The kernel event header is the first field of all the events. The process and
+// mount events are still sent while paused, they keep the resolvers of user space up to date. The file events
+// invalidating the dentry cache aren't, user space flushes the cache on resume
+#define send_event(ctx, event) \
+    (!is_probe_paused() && !is_event_type_masked(((struct kevent_t *)&event)->type) && is_event_allowed(((struct kevent_t *)&event)->type) ? \
+        output_event(ctx, &events, &events_ringbuf, EVENTS_STREAM, &event, sizeof(event)) : 0)
+
 #define send_mountpoints_events(ctx, event) \
-    bpf_perf_event_output(ctx, &mountpoints_events, bpf_get_smp_processor_id(), &event, sizeof(event))
+    output_event(ctx, &mountpoints_events, &mountpoints_events_ringbuf, MOUNTPOINTS_EVENTS_STREAM, &event, sizeof(event))
 
 #define send_process_events(ctx, event) \
-    bpf_perf_event_output(ctx, &events, bpf_get_smp_processor_id(), &event, sizeof(event))
+    output_event(ctx, &events, &events_ringbuf, EVENTS_STREAM, &event, sizeof(event))
 
 static __attribute__((always_inline)) u32 ord(u8 c) {
     if (c >= 49 && c <= 57) {
@@ -360,4 +575,7 @@ static __attribute__((always_inline)) u32 atoi(char *buff) {
 // implemented in the probe.c file
 void __attribute__((always_inline)) invalidate_inode(struct pt_regs *ctx, u32 mount_id, u64 inode, int send_invalidate_event);
 
+// implemented in the copy_up.h file
+int __attribute__((always_inline)) invalidate_copied_up_inode(struct path_key_t key);
+
 #endif
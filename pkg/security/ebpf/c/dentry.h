@@ -8,6 +8,7 @@
 
 #include "defs.h"
 #include "filters.h"
+#include "open_filter.h"
 
 #define DENTRY_MAX_DEPTH 16
 #define MNT_OFFSETOF_MNT 32 // offsetof(struct mount, mnt)
@@ -30,6 +31,8 @@ struct path_leaf_t {
   // TODO: reduce the amount of allocated structs during the resolution so that we can take this buffer to its max
   // theoretical value (256), without reaching the eBPF stack max size.
   char name[128];
+  u32 generation;
+  u32 padding;
 };
 
 struct bpf_map_def SEC("maps/pathnames") pathnames = {
@@ -41,6 +44,68 @@ struct bpf_map_def SEC("maps/pathnames") pathnames = {
     .namespace = "",
 };
 
+struct bpf_map_def SEC("maps/path_generation") path_generation = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// get_next_path_generation increments the generation counter and returns its new value. The return value of the
+// atomic add can't be used without the atomics of the v3 instruction set, the counter is read again instead: two
+// concurrent updates may then get the same generation, an overwrite racing with another one can go unnoticed.
+static __attribute__((always_inline)) u32 get_next_path_generation() {
+    u32 key = 0;
+    u32 *generation = bpf_map_lookup_elem(&path_generation, &key);
+    if (!generation) {
+        return 0;
+    }
+    __sync_fetch_and_add(generation, 1);
+    return *generation;
+}
+
+#define PATH_GENERATION_NAME_CHECK_LEN 4
+
+// set_path_generation keeps the generation of a pathnames entry as long as it points to the same parent with the
+// same name. A new generation is used otherwise so that user space can detect that the entry was overwritten after
+// the emission of an event.
+static __attribute__((always_inline)) void set_path_generation(struct path_key_t *key, struct path_leaf_t *leaf) {
+    struct path_leaf_t *prev = bpf_map_lookup_elem(&pathnames, key);
+    if (!prev || prev->parent.ino != leaf->parent.ino || prev->parent.mount_id != leaf->parent.mount_id) {
+        leaf->generation = get_next_path_generation();
+        return;
+    }
+
+    // only compare the beginning of the name to limit the instructions count
+#pragma unroll
+    for (int i = 0; i < PATH_GENERATION_NAME_CHECK_LEN; i++) {
+        if (((u64 *)prev->name)[i] != ((u64 *)leaf->name)[i]) {
+            leaf->generation = get_next_path_generation();
+            return;
+        }
+    }
+
+    leaf->generation = prev->generation;
+}
+
+static __attribute__((always_inline)) u32 get_path_generation(u32 mount_id, u64 ino, u32 path_id) {
+    struct path_key_t key = {
+        .ino = ino,
+        .mount_id = mount_id,
+        .path_id = path_id,
+    };
+
+    struct path_leaf_t *leaf = bpf_map_lookup_elem(&pathnames, &key);
+    if (!leaf) {
+        return 0;
+    }
+    return leaf->generation;
+}
+
+#define fill_file_generation(file) (file).generation = get_path_generation((file).mount_id, (file).inode, (file).path_id)
+
 unsigned long __attribute__((always_inline)) get_inode_ino(struct inode *inode) {
     unsigned long ino;
     bpf_probe_read(&ino, sizeof(inode), &inode->i_ino);
@@ -168,6 +233,18 @@ unsigned long __attribute__((always_inline)) get_dentry_ino(struct dentry *dentr
     return get_inode_ino(d_inode);
 }
 
+void __attribute__((always_inline)) fill_file_metadata(struct dentry *dentry, struct file_metadata_t *metadata) {
+    struct inode *d_inode;
+    bpf_probe_read(&d_inode, sizeof(d_inode), &dentry->d_inode);
+
+    umode_t mode;
+    bpf_probe_read(&mode, sizeof(mode), &d_inode->i_mode);
+    metadata->mode = mode;
+
+    bpf_probe_read(&metadata->uid, sizeof(metadata->uid), &d_inode->i_uid);
+    bpf_probe_read(&metadata->gid, sizeof(metadata->gid), &d_inode->i_gid);
+}
+
 void __attribute__((always_inline)) write_dentry_inode(struct dentry *dentry, struct inode **d_inode) {
     bpf_probe_read(d_inode, sizeof(d_inode), &dentry->d_inode);
 }
@@ -200,6 +277,73 @@ void __attribute__((always_inline)) get_dentry_name(struct dentry *dentry, void
     bpf_probe_read_str(buffer, n, (void *)qstr.name);
 }
 
+// get_basename_approver returns the value of the basename approver of the name of the given dentry, if any
+void * __attribute__((always_inline)) get_basename_approver(void *approvers, u32 filter_map, struct dentry *dentry) {
+    struct open_basename_t basename = {};
+    get_dentry_name(dentry, &basename, sizeof(basename));
+
+    void *approver = bpf_map_lookup_elem(approvers, &basename);
+#ifdef DEBUG
+    if (approver) {
+        bpf_printk("basename %s approved\n", basename.value);
+    }
+#endif
+    count_filter_lookup(filter_map, approver != NULL);
+    return approver;
+}
+
+// approved_by_basename returns whether the name of the given dentry is one of the given basename approvers
+int __attribute__((always_inline)) approved_by_basename(void *approvers, u32 filter_map, struct dentry *dentry) {
+    return get_basename_approver(approvers, filter_map, dentry) != NULL;
+}
+
+// inode_approver_t identifies the approved files of a directory with a given basename. The key without directory
+// holds the mount of the directories approving the basename
+struct inode_approver_t {
+    u64 event_type;
+    struct path_key_t parent;
+    char basename[BASENAME_FILTER_SIZE];
+};
+
+// inode_approvers holds the files approved by the inode of their directory, the files of the watched paths. They
+// still match when the file is created or replaced, its inode changing
+struct bpf_map_def SEC("maps/inode_approvers") inode_approvers = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(struct inode_approver_t),
+    .value_size = sizeof(u32),
+    .max_entries = 1024,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// approved_by_inode returns whether the given dentry, whose basename is approved, is in one of the approved directories
+// of its basename. Only the files of the mount of these directories are checked, the files of the other mounts, the
+// ones of the containers for instance, are approved by their basename
+int __attribute__((always_inline)) approved_by_inode(u64 event_type, struct dentry *dentry, u32 mount_id) {
+    struct inode_approver_t key = {
+        .event_type = event_type,
+    };
+    get_dentry_name(dentry, &key.basename, sizeof(key.basename));
+
+    u32 *approved_mount_id = bpf_map_lookup_elem(&inode_approvers, &key);
+    if (!approved_mount_id || *approved_mount_id != mount_id) {
+        return 1;
+    }
+
+    struct dentry *d_parent = NULL;
+    bpf_probe_read(&d_parent, sizeof(d_parent), &dentry->d_parent);
+    key.parent.ino = get_dentry_ino(d_parent);
+    key.parent.mount_id = mount_id;
+
+    void *approver = bpf_map_lookup_elem(&inode_approvers, &key);
+#ifdef DEBUG
+    if (approver) {
+        bpf_printk("file with inode %d approved\n", key.parent.ino);
+    }
+#endif
+    return count_filter_lookup(FILTER_INODE_APPROVERS, approver != NULL);
+}
+
 #define get_dentry_key_path(dentry, path) (struct path_key_t) { .ino = get_dentry_ino(dentry), .mount_id = get_path_mount_id(path) }
 #define get_inode_key_path(inode, path) (struct path_key_t) { .ino = get_inode_ino(inode), .mount_id = get_path_mount_id(path) }
 
@@ -215,23 +359,33 @@ static __attribute__((always_inline)) void link_dentry_inode(struct path_key_t k
         .path_id = key.path_id,
     };
     struct path_leaf_t map_value = {
-        .parent = key
+        .parent = key,
+        .generation = get_path_generation(key.mount_id, key.ino, key.path_id),
     };
 
     bpf_map_update_elem(&pathnames, &new_key, &map_value, BPF_ANY);
 }
 
-static __attribute__((always_inline)) int resolve_dentry(struct dentry *dentry, struct path_key_t key, u64 event_type) {
+// dentry_refs holds the dentries of the leaves of the resolved paths, the eRPC requests of user space walk them again
+// when the segments of a path were evicted from the pathnames map before the event was handled
+struct bpf_map_def SEC("maps/dentry_refs") dentry_refs = {
+    .type = BPF_MAP_TYPE_LRU_HASH,
+    .key_size = sizeof(struct path_key_t),
+    .value_size = sizeof(u64),
+    .max_entries = 16384,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// walk_dentry inserts the segments of the path of the given dentry in the pathnames map, and copies them to the given
+// segments when they aren't NULL
+static __attribute__((always_inline)) int walk_dentry(struct dentry *dentry, struct path_key_t key, u64 event_type, struct path_leaf_t *segments) {
     struct path_leaf_t map_value = {};
     struct path_key_t next_key = key;
     struct qstr qstr;
     struct dentry *d_parent;
     struct inode *d_inode = NULL;
 
-    if (key.ino == 0 || key.mount_id == 0) {
-        return DENTRY_INVALID;
-    }
-
 #pragma unroll
     for (int i = 0; i < DENTRY_MAX_DEPTH; i++)
     {
@@ -260,8 +414,12 @@ static __attribute__((always_inline)) int resolve_dentry(struct dentry *dentry,
         }
 
         map_value.parent = next_key;
+        set_path_generation(&key, &map_value);
 
         bpf_map_update_elem(&pathnames, &key, &map_value, BPF_ANY);
+        if (segments) {
+            bpf_probe_read(&segments[i], sizeof(map_value), &map_value);
+        }
 
         dentry = d_parent;
         if (next_key.ino == 0)
@@ -275,9 +433,30 @@ static __attribute__((always_inline)) int resolve_dentry(struct dentry *dentry,
     map_value.name[0] = 0;
     map_value.parent.mount_id = 0;
     map_value.parent.ino = 0;
+    map_value.generation = 0;
     bpf_map_update_elem(&pathnames, &next_key, &map_value, BPF_ANY);
 
     return DENTRY_MAX_DEPTH;
 }
 
+static __attribute__((always_inline)) int resolve_dentry(struct dentry *dentry, struct path_key_t key, u64 event_type) {
+    if (key.ino == 0 || key.mount_id == 0) {
+        return DENTRY_INVALID;
+    }
+
+    // the file was copied up by overlayfs during the syscall, its discarders are no longer relevant
+    if (invalidate_copied_up_inode(key)) {
+        event_type = 0;
+    }
+
+    u64 erpc_enabled;
+    LOAD_CONSTANT("erpc_dentry_resolution_enabled", erpc_enabled);
+    if (erpc_enabled) {
+        u64 ref = (u64)dentry;
+        bpf_map_update_elem(&dentry_refs, &key, &ref, BPF_ANY);
+    }
+
+    return walk_dentry(dentry, key, event_type, NULL);
+}
+
 #endif
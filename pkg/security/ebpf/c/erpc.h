@@ -0,0 +1,181 @@
+#ifndef _ERPC_H_
+#define _ERPC_H_
+
+#include "defs.h"
+#include "dentry.h"
+#include "process.h"
+
+#define RPC_CMD 0xdeadc001
+
+enum erpc_op {
+    UNKNOWN_OP,
+    RESOLVE_PATH_OP,
+    GUESS_MNTNS_OFFSET_OP,
+};
+
+struct erpc_request_t {
+    u8 op;
+    char data[256];
+};
+
+struct resolve_path_request_t {
+    struct path_key_t key;
+    u32 slot;
+    u32 request_id;
+};
+
+// DR_ERPC_SLOTS is the number of path resolutions user space can request concurrently, each one writing to its own
+// slot of the dr_erpc_buffers map
+#define DR_ERPC_SLOTS 16
+
+struct dr_erpc_buffer_t {
+    u32 request_id;
+    u32 depth;
+    struct path_leaf_t segments[DENTRY_MAX_DEPTH];
+};
+
+struct bpf_map_def SEC("maps/dr_erpc_buffers") dr_erpc_buffers = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct dr_erpc_buffer_t),
+    .max_entries = DR_ERPC_SLOTS,
+    .pinning = 0,
+    .namespace = "",
+};
+
+struct _tracepoint_syscalls_sys_enter_ioctl
+{
+    unsigned short common_type;
+    unsigned char common_flags;
+    unsigned char common_preempt_count;
+    int common_pid;
+
+    int __syscall_nr;
+    u64 fd;
+    u64 cmd;
+    u64 arg;
+};
+
+int __attribute__((always_inline)) is_erpc_request(u32 cmd) {
+    if (cmd != RPC_CMD) {
+        return 0;
+    }
+
+    // only the process that loaded the probes is allowed to issue requests
+    u64 erpc_pid;
+    LOAD_CONSTANT("erpc_pid", erpc_pid);
+
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    return (pid_tgid >> 32) == erpc_pid;
+}
+
+int __attribute__((always_inline)) handle_resolve_path(void *data) {
+    struct resolve_path_request_t request = {};
+    bpf_probe_read(&request, sizeof(request), data);
+
+    u64 *ref = bpf_map_lookup_elem(&dentry_refs, &request.key);
+    if (!ref) {
+        return 0;
+    }
+    struct dentry *dentry = (struct dentry *)*ref;
+
+    // the dentry may have been freed, and its memory reused, since it was resolved
+    if (get_dentry_ino(dentry) != request.key.ino) {
+        bpf_map_delete_elem(&dentry_refs, &request.key);
+        return 0;
+    }
+
+    u32 slot = request.slot;
+    struct dr_erpc_buffer_t *buffer = bpf_map_lookup_elem(&dr_erpc_buffers, &slot);
+    if (!buffer) {
+        return 0;
+    }
+
+    // the segments are written to a map rather than to user memory, which the kernel lockdown forbids
+    int depth = walk_dentry(dentry, request.key, 0, buffer->segments);
+    buffer->depth = depth;
+    buffer->request_id = request.request_id;
+
+    return 0;
+}
+
+// MNTNS_GUESS_SIZE is the size of the beginning of struct mnt_namespace searched for its inode number
+#define MNTNS_GUESS_SIZE 64
+
+// handle_guess_mntns_offset looks for the inode number of the mount namespace of the agent, given by the request, at
+// the beginning of its struct mnt_namespace, and stores its offset in the mntns_inum_offset map
+int __attribute__((always_inline)) handle_guess_mntns_offset(void *data) {
+    u32 inum = 0;
+    bpf_probe_read(&inum, sizeof(inum), data);
+    if (!inum) {
+        return 0;
+    }
+
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+    struct nsproxy *nsproxy = NULL;
+    bpf_probe_read(&nsproxy, sizeof(nsproxy), &task->nsproxy);
+    if (!nsproxy) {
+        return 0;
+    }
+
+    void *mntns = NULL;
+    bpf_probe_read(&mntns, sizeof(mntns), &nsproxy->mnt_ns);
+    if (!mntns) {
+        return 0;
+    }
+
+    u32 values[MNTNS_GUESS_SIZE / sizeof(u32)] = {};
+    bpf_probe_read(&values, sizeof(values), mntns);
+
+    // the structure starts with a reference counter on all the kernels, the inode number is never at 0
+    u32 key = 0;
+#pragma unroll
+    for (u32 i = 1; i < MNTNS_GUESS_SIZE / sizeof(u32); i++) {
+        if (values[i] == inum) {
+            u32 offset = i * sizeof(u32);
+            bpf_map_update_elem(&mntns_inum_offset, &key, &offset, BPF_ANY);
+            return 0;
+        }
+    }
+
+    return 0;
+}
+
+int __attribute__((always_inline)) handle_erpc_request(struct erpc_request_t *request) {
+    u8 op = 0;
+    bpf_probe_read(&op, sizeof(op), &request->op);
+
+    switch (op) {
+    case RESOLVE_PATH_OP:
+        return handle_resolve_path(&request->data);
+    case GUESS_MNTNS_OFFSET_OP:
+        return handle_guess_mntns_offset(&request->data);
+    }
+
+    return 0;
+}
+
+SEC("kprobe/do_vfs_ioctl")
+int kprobe__do_vfs_ioctl(struct pt_regs *ctx) {
+    if (!is_erpc_request(PT_REGS_PARM3(ctx))) {
+        return 0;
+    }
+
+    return handle_erpc_request((struct erpc_request_t *)PT_REGS_PARM4(ctx));
+}
+
+// sys_enter_ioctl is attached instead of do_vfs_ioctl on the kernels where do_vfs_ioctl can't be hooked
+SEC("tracepoint/syscalls/sys_enter_ioctl")
+int sys_enter_ioctl(struct _tracepoint_syscalls_sys_enter_ioctl *args) {
+    u64 cmd = 0;
+    bpf_probe_read(&cmd, sizeof(cmd), &args->cmd);
+    if (!is_erpc_request(cmd)) {
+        return 0;
+    }
+
+    struct erpc_request_t *request = NULL;
+    bpf_probe_read(&request, sizeof(request), &args->arg);
+    return handle_erpc_request(request);
+}
+
+#endif
@@ -2,6 +2,8 @@
 #define _EXEC_H_
 
 #include <linux/tty.h>
+#include <linux/sched.h>
+#include <linux/binfmts.h>
 
 #include "filters.h"
 #include "syscalls.h"
@@ -36,6 +38,10 @@ struct _tracepoint_sched_process_fork
 void __attribute__((always_inline)) copy_proc_cache(struct proc_cache_t *dst, struct proc_cache_t *src) {
     dst->executable = src->executable;
     copy_container_id(dst->container.container_id, src->container.container_id);
+    dst->loginuid = src->loginuid;
+    dst->sessionid = src->sessionid;
+    dst->executable_metadata = src->executable_metadata;
+    dst->interpreter = src->interpreter;
     return;
 }
 
@@ -52,6 +58,19 @@ static __attribute__((always_inline)) u32 copy_tty_name(char dst[TTY_NAME_LEN],
     return TTY_NAME_LEN;
 }
 
+#define AUDIT_UID_UNSET ((u32)-1)
+
+static __attribute__((always_inline)) void fill_audit_context(struct proc_cache_t *entry) {
+    entry->loginuid = AUDIT_UID_UNSET;
+    entry->sessionid = AUDIT_UID_UNSET;
+
+#ifdef CONFIG_AUDITSYSCALL
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+    bpf_probe_read(&entry->loginuid, sizeof(entry->loginuid), &task->loginuid.val);
+    bpf_probe_read(&entry->sessionid, sizeof(entry->sessionid), &task->sessionid);
+#endif
+}
+
 int __attribute__((always_inline)) trace__sys_execveat() {
     struct syscall_cache_t syscall = {
         .type = SYSCALL_EXEC,
@@ -61,14 +80,30 @@ int __attribute__((always_inline)) trace__sys_execveat() {
     return 0;
 }
 
-SYSCALL_KPROBE0(execve) {
+SYSCALL_COMPAT_KPROBE0(execve) {
     return trace__sys_execveat();
 }
 
-SYSCALL_KPROBE0(execveat) {
+SYSCALL_COMPAT_KPROBE0(execveat) {
     return trace__sys_execveat();
 }
 
+static __attribute__((always_inline)) void fill_executable_metadata(struct inode *inode, struct executable_metadata_t *metadata) {
+    struct ktimeval ts;
+
+    bpf_probe_read(&ts, sizeof(ts), &inode->i_mtime);
+    metadata->mtime = ts.tv_sec * 1000000000 + ts.tv_nsec;
+
+    bpf_probe_read(&ts, sizeof(ts), &inode->i_ctime);
+    metadata->ctime = ts.tv_sec * 1000000000 + ts.tv_nsec;
+
+    bpf_probe_read(&metadata->size, sizeof(metadata->size), &inode->i_size);
+
+    umode_t mode;
+    bpf_probe_read(&mode, sizeof(mode), &inode->i_mode);
+    metadata->mode = mode;
+}
+
 int __attribute__((always_inline)) handle_exec_event(struct pt_regs *ctx, struct syscall_cache_t *syscall) {
     struct file *file = (struct file *)PT_REGS_PARM1(ctx);
     struct inode *inode = (struct inode *)PT_REGS_PARM2(ctx);
@@ -95,11 +130,20 @@ int __attribute__((always_inline)) handle_exec_event(struct pt_regs *ctx, struct
         .cookie = cookie,
     };
 
+    // the login uid and session id are read from the task, they survive setuid transitions (sudo, su, ...)
+    fill_audit_context(&entry);
+
+    // the metadata of the binary at exec time, it may be altered afterwards
+    fill_executable_metadata(inode, &entry.executable_metadata);
+
     // select parent cache entry
     struct proc_cache_t *parent_entry = get_pid_cache(tgid);
     if (parent_entry) {
         // inherit container ID
         copy_container_id(entry.container.container_id, parent_entry->container.container_id);
+
+        // inherit the tty, it will be updated if the process gets a new controlling terminal
+        copy_tty_name(entry.tty_name, parent_entry->tty_name);
     }
     syscall->open.path_key.path_id = path_id;
 
@@ -112,11 +156,51 @@ int __attribute__((always_inline)) handle_exec_event(struct pt_regs *ctx, struct
     // insert pid <-> cookie mapping
     bpf_map_update_elem(&pid_cookie, &tgid, &cookie, BPF_ANY);
 
+    // the discarders of the process were computed for the previous executable
+    remove_pid_discarders(tgid);
+
     pop_syscall(SYSCALL_EXEC);
 
     return 0;
 }
 
+SEC("kprobe/security_bprm_check")
+int kprobe_security_bprm_check(struct pt_regs *ctx) {
+    struct linux_binprm *bprm = (struct linux_binprm *)PT_REGS_PARM1(ctx);
+
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    u32 tgid = pid_tgid >> 32;
+
+    // the cache entry of the new executable was inserted when the file was opened
+    struct proc_cache_t *entry = get_pid_cache(tgid);
+    if (!entry) {
+        return 0;
+    }
+
+    struct file *file;
+    bpf_probe_read(&file, sizeof(file), &bprm->file);
+
+    struct dentry *dentry = get_file_dentry(file);
+    struct path_key_t key = get_dentry_key_path(dentry, &file->f_path);
+
+    // the first check is done on the executed file, the following ones on the interpreters of the scripts. Only
+    // the interpreter of the executed script is kept.
+    if (key.ino == entry->executable.inode || entry->interpreter.inode) {
+        return 0;
+    }
+
+    key.path_id = get_path_id(0);
+    entry->interpreter.inode = key.ino;
+    entry->interpreter.mount_id = key.mount_id;
+    entry->interpreter.overlay_numlower = get_overlay_numlower(dentry);
+    entry->interpreter.path_id = key.path_id;
+
+    // cache dentry
+    resolve_dentry(dentry, key, 0);
+
+    return 0;
+}
+
 SEC("tracepoint/sched/sched_process_fork")
 int sched_process_fork(struct _tracepoint_sched_process_fork *args) {
     u32 pid = 0;
@@ -124,6 +208,9 @@ int sched_process_fork(struct _tracepoint_sched_process_fork *args) {
     bpf_probe_read(&pid, sizeof(pid), &args->child_pid);
     bpf_probe_read(&ppid, sizeof(ppid), &args->parent_pid);
 
+    // the pid may be reused, the discarders of the previous process, if its exit was missed, don't apply
+    remove_pid_discarders(pid);
+
     struct proc_cache_t *parent_entry = get_pid_cache(ppid);
     if (parent_entry) {
         u32 cookie = parent_entry->cookie;
@@ -145,6 +232,10 @@ int sched_process_fork(struct _tracepoint_sched_process_fork *args) {
             .cache_entry.timestamp = parent_entry->timestamp,
             .cache_entry.cookie = parent_entry->cookie,
             .cache_entry.ppid = ppid,
+            .cache_entry.loginuid = parent_entry->loginuid,
+            .cache_entry.sessionid = parent_entry->sessionid,
+            .cache_entry.executable_metadata = parent_entry->executable_metadata,
+            .cache_entry.interpreter = parent_entry->interpreter,
         };
 
         copy_tty_name(event.cache_entry.tty_name, parent_entry->tty_name);
@@ -157,20 +248,13 @@ int sched_process_fork(struct _tracepoint_sched_process_fork *args) {
     return 0;
 }
 
-SEC("kprobe/do_exit")
-int kprobe_do_exit(struct pt_regs *ctx) {
+static __attribute__((always_inline)) int trace__do_exit(void *ctx) {
     u64 pid_tgid = bpf_get_current_pid_tgid();
     u32 tgid = pid_tgid >> 32;
     u32 pid = pid_tgid;
 
     if (tgid == pid) {
-        struct pid_discarder_t key = {
-            .tgid = tgid,
-        };
-        for (int i = 1; i < EVENT_MAX; i++) {
-            key.event_type = i;
-            bpf_map_delete_elem(&pid_discarders, &key);
-        }
+        remove_pid_discarders(tgid);
 
         // send the entry to maintain userspace cache
         struct exit_event_t event = {
@@ -183,6 +267,17 @@ int kprobe_do_exit(struct pt_regs *ctx) {
     return 0;
 }
 
+SEC("kprobe/do_exit")
+int kprobe_do_exit(struct pt_regs *ctx) {
+    return trace__do_exit(ctx);
+}
+
+// sched_process_exit is attached instead of do_exit on the kernels where do_exit can't be hooked
+SEC("tracepoint/sched/sched_process_exit")
+int sched_process_exit(void *args) {
+    return trace__do_exit(args);
+}
+
 SEC("kprobe/exit_itimers")
 int kprobe_exit_itimers(struct pt_regs *ctx) {
     struct signal_struct *signal = (struct signal_struct *)PT_REGS_PARM1(ctx);
@@ -192,8 +287,11 @@ int kprobe_exit_itimers(struct pt_regs *ctx) {
 
     struct proc_cache_t *entry = get_pid_cache(tgid);
     if (entry) {
-        struct tty_struct *tty;
+        struct tty_struct *tty = NULL;
         bpf_probe_read(&tty, sizeof(tty), &signal->tty);
+        if (!tty) {
+            return 0;
+        }
 
         bpf_probe_read_str(entry->tty_name, TTY_NAME_LEN, tty->name);
     }
@@ -201,6 +299,25 @@ int kprobe_exit_itimers(struct pt_regs *ctx) {
     return 0;
 }
 
+SEC("kretprobe/audit_set_loginuid")
+int kretprobe_audit_set_loginuid(struct pt_regs *ctx) {
+    int retval = PT_REGS_RC(ctx);
+    if (retval < 0) {
+        return 0;
+    }
+
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    u32 tgid = pid_tgid >> 32;
+
+    // pam_loginuid writes /proc/self/loginuid, update the cache entry so that forked children inherit it
+    struct proc_cache_t *entry = get_pid_cache(tgid);
+    if (entry) {
+        fill_audit_context(entry);
+    }
+
+    return 0;
+}
+
 SEC("kprobe/do_close_on_exec")
 int kprobe_do_close_on_exec(struct pt_regs *ctx) {
     u64 pid_tgid = bpf_get_current_pid_tgid();
@@ -220,6 +337,10 @@ int kprobe_do_close_on_exec(struct pt_regs *ctx) {
             .cache_entry.container = {},
             .cache_entry.timestamp = entry->timestamp,
             .cache_entry.cookie = entry->cookie,
+            .cache_entry.loginuid = entry->loginuid,
+            .cache_entry.sessionid = entry->sessionid,
+            .cache_entry.executable_metadata = entry->executable_metadata,
+            .cache_entry.interpreter = entry->interpreter,
         };
 
         copy_tty_name(event.cache_entry.tty_name, entry->tty_name);
@@ -2,6 +2,7 @@
 #define _FILTERS_H
 
 #include "process.h"
+#include "container.h"
 
 enum policy_mode
 {
@@ -16,6 +17,10 @@ enum policy_flags
     FLAGS = 2,
     MODE = 4,
     PARENT_NAME = 8,
+    UID = 16,
+    GID = 32,
+    COMM = 64,
+    PREFIX = 128,
 };
 
 struct policy_t {
@@ -27,6 +32,61 @@ struct filter_t {
     char value;
 };
 
+// filter_map identifies a filter map whose lookups are counted
+enum filter_map
+{
+    FILTER_OPEN_BASENAME_APPROVERS,
+    FILTER_UNLINK_BASENAME_APPROVERS,
+    FILTER_RENAME_BASENAME_APPROVERS,
+    FILTER_CHMOD_BASENAME_APPROVERS,
+    FILTER_CHOWN_BASENAME_APPROVERS,
+    FILTER_MKDIR_BASENAME_APPROVERS,
+    FILTER_OPEN_FLAGS_APPROVERS,
+    FILTER_CHMOD_MODE_APPROVERS,
+    FILTER_MKDIR_MODE_APPROVERS,
+    FILTER_CHOWN_UID_APPROVERS,
+    FILTER_CHOWN_GID_APPROVERS,
+    FILTER_COMM_APPROVERS,
+    FILTER_INODE_DISCARDERS,
+    FILTER_PID_DISCARDERS,
+    FILTER_MOUNT_FSTYPE_DISCARDERS,
+    FILTER_MOUNT_ID_DISCARDERS,
+    FILTER_PRE_EVAL_RULES,
+    FILTER_PREFIX_APPROVERS,
+    FILTER_INODE_APPROVERS,
+    FILTER_MAP_MAX,
+};
+
+// filter_stats_t holds the number of lookups of a filter map that matched, and the number of the ones that didn't
+struct filter_stats_t {
+    u64 hits;
+    u64 misses;
+};
+
+// filter_stats holds the counters of the filter maps, one per CPU so that they don't require any atomic operation.
+// They are summed in user space
+struct bpf_map_def SEC("maps/filter_stats") filter_stats = {
+    .type = BPF_MAP_TYPE_PERCPU_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct filter_stats_t),
+    .max_entries = FILTER_MAP_MAX,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// count_filter_lookup counts a lookup of the given filter map, it returns whether the lookup matched
+int __attribute__((always_inline)) count_filter_lookup(u32 filter_map, int hit) {
+    struct filter_stats_t *stats = bpf_map_lookup_elem(&filter_stats, &filter_map);
+    if (stats) {
+        if (hit) {
+            stats->hits++;
+        } else {
+            stats->misses++;
+        }
+    }
+    return hit;
+}
+
 struct bpf_map_def SEC("maps/filter_policy") filter_policy = {
     .type = BPF_MAP_TYPE_ARRAY,
     .key_size = sizeof(u32),
@@ -36,6 +96,165 @@ struct bpf_map_def SEC("maps/filter_policy") filter_policy = {
     .namespace = "",
 };
 
+struct container_policy_key_t {
+    char container_id[CONTAINER_ID_LEN];
+    u64 event_type;
+};
+
+// container_filter_policy holds the policies of the event types overridden for some containers
+struct bpf_map_def SEC("maps/container_filter_policy") container_filter_policy = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(struct container_policy_key_t),
+    .value_size = sizeof(struct policy_t),
+    .max_entries = 1024,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// get_container_policy returns the policy of the event type for the container of the current process, if it was
+// overridden
+struct policy_t * __attribute__((always_inline)) get_container_policy(u64 event_type) {
+    u32 tgid = bpf_get_current_pid_tgid() >> 32;
+    struct proc_cache_t *entry = get_pid_cache(tgid);
+    if (!entry) {
+        return NULL;
+    }
+
+    struct container_policy_key_t key = {
+        .event_type = event_type,
+    };
+    if (!copy_container_id(key.container_id, entry->container.container_id)) {
+        return NULL;
+    }
+
+    return bpf_map_lookup_elem(&container_filter_policy, &key);
+}
+
+// container_event_types holds the bitmask of the event types produced for the processes of each container, driven
+// by the scopes of the rules. The containers without entry produce all the event types
+struct bpf_map_def SEC("maps/container_event_types") container_event_types = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(struct container_context_t),
+    .value_size = sizeof(u64),
+    .max_entries = 1024,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// is_event_type_masked returns whether the events of the given type aren't produced for the container of the current
+// process. The events invalidating the dentry cache of user space are never masked
+int __attribute__((always_inline)) is_event_type_masked(u64 event_type) {
+    if (is_dentry_cache_invalidating_event(event_type)) {
+        return 0;
+    }
+
+    u32 tgid = bpf_get_current_pid_tgid() >> 32;
+    struct proc_cache_t *entry = get_pid_cache(tgid);
+    if (!entry) {
+        return 0;
+    }
+
+    struct container_context_t key = {};
+    if (!copy_container_id(key.container_id, entry->container.container_id)) {
+        return 0;
+    }
+
+    u64 *mask = bpf_map_lookup_elem(&container_event_types, &key);
+    return mask != NULL && (*mask & ((u64)1 << event_type)) == 0;
+}
+
+// probe_paused holds 1 while user space paused the probe. The probes stay attached, the process and mount events are
+// still sent but the file events aren't, including the ones invalidating the dentry cache: user space flushes the
+// cache when it resumes the probe
+struct bpf_map_def SEC("maps/probe_paused") probe_paused = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// is_probe_paused returns whether user space paused the probe
+int __attribute__((always_inline)) is_probe_paused() {
+    u32 key = 0;
+    u32 *paused = bpf_map_lookup_elem(&probe_paused, &key);
+    return paused != NULL && *paused;
+}
+
+struct comm_approver_t {
+    u64 event_type;
+    char comm[TASK_COMM_LEN];
+};
+
+struct bpf_map_def SEC("maps/comm_approvers") comm_approvers = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(struct comm_approver_t),
+    .value_size = sizeof(struct filter_t),
+    .max_entries = 255,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// approved_by_comm returns whether the events of the given type of the current process are approved by its name
+int __attribute__((always_inline)) approved_by_comm(u64 event_type) {
+    struct comm_approver_t key = {
+        .event_type = event_type,
+    };
+    bpf_get_current_comm(&key.comm, sizeof(key.comm));
+
+    struct filter_t *filter = bpf_map_lookup_elem(&comm_approvers, &key);
+    if (filter) {
+#ifdef DEBUG
+        bpf_printk("process %s approved\n", key.comm);
+#endif
+        return count_filter_lookup(FILTER_COMM_APPROVERS, 1);
+    }
+    return count_filter_lookup(FILTER_COMM_APPROVERS, 0);
+}
+
+#define ID_RANGE_APPROVERS_SIZE 16
+
+// id_range_t holds a range of uids or gids, its bounds included. The ranges of an approver table are stored from its
+// first entry, the first disabled one ending the table
+struct id_range_t {
+    u32 enabled;
+    u32 min;
+    u32 max;
+};
+
+int __attribute__((always_inline)) approved_by_id_range(void *ranges, u32 filter_map, u32 id) {
+#pragma unroll
+    for (u32 i = 0; i < ID_RANGE_APPROVERS_SIZE; i++) {
+        u32 key = i;
+        struct id_range_t *range = bpf_map_lookup_elem(ranges, &key);
+        if (range == NULL || !range->enabled) {
+            return count_filter_lookup(filter_map, 0);
+        }
+
+        if (id >= range->min && id <= range->max) {
+#ifdef DEBUG
+            bpf_printk("id %d approved\n", id);
+#endif
+            return count_filter_lookup(filter_map, 1);
+        }
+    }
+    return count_filter_lookup(filter_map, 0);
+}
+
+// approved_by_mode returns whether the given mode has one of the bits of the given mode approvers
+int __attribute__((always_inline)) approved_by_mode(void *approvers, u32 filter_map, umode_t mode) {
+    u32 key = 0;
+    u32 *modes = bpf_map_lookup_elem(approvers, &key);
+    if (modes != NULL && (mode & *modes) > 0) {
+#ifdef DEBUG
+        bpf_printk("mode %d approved\n", mode);
+#endif
+        return count_filter_lookup(filter_map, 1);
+    }
+    return count_filter_lookup(filter_map, 0);
+}
+
 struct inode_discarder_t {
     u64 event_type;
     struct path_key_t path_key;
@@ -64,9 +283,9 @@ int __attribute__((always_inline)) discarded_by_inode(u64 event_type, u32 mount_
 #ifdef DEBUG
         bpf_printk("file with inode %d discarded\n", inode);
 #endif
-        return 1;
+        return count_filter_lookup(FILTER_INODE_DISCARDERS, 1);
     }
-    return 0;
+    return count_filter_lookup(FILTER_INODE_DISCARDERS, 0);
 }
 
 void __attribute__((always_inline)) remove_inode_discarder(u64 event_type, u32 mount_id, u64 inode) {
@@ -100,21 +319,39 @@ struct bpf_map_def SEC("maps/pid_discarders") pid_discarders = { \
     .namespace = "",
 };
 
-int __attribute__((always_inline)) discarded_by_pid(u64 event_type, u32 tgid) {
+int __attribute__((always_inline)) is_pid_discarder(u64 event_type, u32 tgid) {
     struct pid_discarder_t key = {
         .event_type = event_type,
         .tgid = tgid,
     };
 
     struct pid_discarder_parameters_t *params = bpf_map_lookup_elem(&pid_discarders, &key);
-    if (params == NULL || (params->timestamp != 0 && params->timestamp <= bpf_ktime_get_ns())) {
-        return 0;
+    return params != NULL && (params->timestamp == 0 || params->timestamp > bpf_ktime_get_ns());
+}
+
+int __attribute__((always_inline)) discarded_by_pid(u64 event_type, u32 tgid) {
+    if (!is_pid_discarder(event_type, tgid) && !is_pid_discarder(EVENT_ANY, tgid)) {
+        return count_filter_lookup(FILTER_PID_DISCARDERS, 0);
     }
 
 #ifdef DEBUG
         bpf_printk("process with pid %d discarded\n", tgid);
 #endif
-    return 1;
+    return count_filter_lookup(FILTER_PID_DISCARDERS, 1);
+}
+
+// remove_pid_discarders removes the discarders of the given process, the process executed another binary, exited or
+// its pid was reused
+void __attribute__((always_inline)) remove_pid_discarders(u32 tgid) {
+    struct pid_discarder_t key = {
+        .tgid = tgid,
+    };
+
+#pragma unroll
+    for (int i = EVENT_ANY; i < EVENT_MAX; i++) {
+        key.event_type = i;
+        bpf_map_delete_elem(&pid_discarders, &key);
+    }
 }
 
 // cache_syscall checks the event policy in order to see if the syscall struct can be cached
@@ -19,10 +19,6 @@ int __attribute__((always_inline)) trace__sys_link() {
 
     cache_syscall(&syscall, EVENT_LINK);
 
-    if (discarded_by_process(syscall.policy.mode, EVENT_LINK)) {
-        pop_syscall(SYSCALL_LINK);
-    }
-
     return 0;
 }
 
@@ -34,25 +30,37 @@ SYSCALL_KPROBE0(linkat) {
     return trace__sys_link();
 }
 
-SEC("kprobe/vfs_link")
-int kprobe__vfs_link(struct pt_regs *ctx) {
+HOOK_ENTRY4(vfs_link, struct dentry *, dentry, struct inode *, dir, struct dentry *, new_dentry, struct inode **, delegated_inode) {
     struct syscall_cache_t *syscall = peek_syscall(SYSCALL_LINK);
     if (!syscall)
         return 0;
 
-    struct dentry *dentry = (struct dentry *)PT_REGS_PARM1(ctx);
-
     // if second pass, ex: overlayfs, just cache the inode that will be used in ret
     if (syscall->link.target_dentry) {
         syscall->link.real_src_inode = get_dentry_ino(dentry);
+
+        // ensure that we invalidate all the layers
+        invalidate_inode(ctx, syscall->link.src_key.mount_id, syscall->link.real_src_inode, 0);
         return 0;
     }
 
-    syscall->link.target_dentry = (struct dentry *)PT_REGS_PARM3(ctx);
+    syscall->link.target_dentry = new_dentry;
     syscall->link.src_overlay_numlower = get_overlay_numlower(dentry);
     // this is a hard link, source and target dentries are on the same filesystem & mount point
     // target_path was set by kprobe/filename_create before we reach this point.
     syscall->link.src_key = get_dentry_key_path(dentry, syscall->link.target_path);
+
+    // the file gets a new name, the discarders of its inode may not apply to this name
+    invalidate_inode(ctx, syscall->link.src_key.mount_id, syscall->link.src_key.ino, 0);
+
+    u64 enabled;
+    LOAD_CONSTANT("link_event_enabled", enabled);
+
+    if (!enabled || discarded_by_process(syscall->policy.mode, EVENT_LINK)) {
+        pop_syscall(SYSCALL_LINK);
+        return 0;
+    }
+
     // we generate a fake target key as the inode is the same
     syscall->link.target_key.ino = bpf_get_prandom_u32() << 32 | bpf_get_prandom_u32();
     syscall->link.target_key.mount_id = syscall->link.src_key.mount_id;
@@ -104,6 +112,9 @@ int __attribute__((always_inline)) trace__sys_link_ret(struct pt_regs *ctx) {
 
     resolve_dentry(syscall->link.target_dentry, syscall->link.target_key, 0);
 
+    fill_file_generation(event.source);
+    fill_file_generation(event.target);
+
     send_event(ctx, event);
 
     return 0;
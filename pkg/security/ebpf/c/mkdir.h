@@ -1,8 +1,27 @@
 #ifndef _MKDIR_H_
 #define _MKDIR_H_
 
+#include "filters.h"
 #include "syscalls.h"
 
+struct bpf_map_def SEC("maps/mkdir_basename_approvers") mkdir_basename_approvers = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = BASENAME_FILTER_SIZE,
+    .value_size = sizeof(struct filter_t),
+    .max_entries = 255,
+    .pinning = 0,
+    .namespace = "",
+};
+
+struct bpf_map_def SEC("maps/mkdir_mode_approvers") mkdir_mode_approvers = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
+};
+
 struct mkdir_event_t {
     struct kevent_t event;
     struct process_context_t process;
@@ -13,6 +32,20 @@ struct mkdir_event_t {
     u32 padding;
 };
 
+// approve_mkdir is called by kprobe/vfs_mkdir, the directory doesn't exist yet and can't be approved by its inode
+int __attribute__((always_inline)) approve_mkdir(struct syscall_cache_t *syscall) {
+    if (syscall->policy.mode != DENY)
+        return 1;
+
+    if ((syscall->policy.flags & BASENAME) > 0 && approved_by_basename(&mkdir_basename_approvers, FILTER_MKDIR_BASENAME_APPROVERS, syscall->mkdir.dentry))
+        return 1;
+
+    if ((syscall->policy.flags & MODE) > 0 && approved_by_mode(&mkdir_mode_approvers, FILTER_MKDIR_MODE_APPROVERS, syscall->mkdir.mode))
+        return 1;
+
+    return 0;
+}
+
 long __attribute__((always_inline)) trace__sys_mkdir(umode_t mode) {
     struct syscall_cache_t syscall = {
         .type = SYSCALL_MKDIR,
@@ -40,14 +73,11 @@ SYSCALL_KPROBE3(mkdirat, int, dirfd, const char*, filename, umode_t, mode)
     return trace__sys_mkdir(mode);
 }
 
-SEC("kprobe/vfs_mkdir")
-int kprobe__vfs_mkdir(struct pt_regs *ctx) {
+HOOK_ENTRY3(vfs_mkdir, struct inode *, dir, struct dentry *, dentry, umode_t, mode) {
     struct syscall_cache_t *syscall = peek_syscall(SYSCALL_MKDIR);
     if (!syscall)
         return 0;
 
-    struct dentry *dentry = (struct dentry *)PT_REGS_PARM2(ctx);
-
     // if second pass, ex: overlayfs, just cache the inode that will be used in ret
     if (syscall->mkdir.dentry) {
         syscall->mkdir.real_dentry = dentry;
@@ -57,6 +87,10 @@ int kprobe__vfs_mkdir(struct pt_regs *ctx) {
     syscall->mkdir.dentry = dentry;
     syscall->mkdir.path_key = get_dentry_key_path(syscall->mkdir.dentry, syscall->mkdir.path);
 
+    if (!approve_mkdir(syscall)) {
+        pop_syscall(SYSCALL_MKDIR);
+    }
+
     return 0;
 }
 
@@ -101,6 +135,8 @@ int __attribute__((always_inline)) trace__sys_mkdir_ret(struct pt_regs *ctx) {
     struct proc_cache_t *entry = fill_process_data(&event.process);
     fill_container_data(entry, &event.container);
 
+    fill_file_generation(event.file);
+
     send_event(ctx, event);
 
     return 0;
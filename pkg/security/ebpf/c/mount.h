@@ -2,8 +2,7 @@
 #define _MOUNT_H_
 
 #include "syscalls.h"
-
-#define FSTYPE_LEN 16
+#include "mount_filter.h"
 
 struct mount_event_t {
     struct kevent_t event;
@@ -17,7 +16,7 @@ struct mount_event_t {
     unsigned long parent_ino;
     unsigned long root_ino;
     int root_mount_id;
-    u32 padding;
+    u32 discarded;
     char fstype[FSTYPE_LEN];
 };
 
@@ -98,8 +97,12 @@ SYSCALL_COMPAT_KRETPROBE(mount) {
         return 0;
     }
 
-    struct proc_cache_t *entry = fill_process_data(&event.process);
-    fill_container_data(entry, &event.container);
+    // a discarded mount point is still sent to keep the mount cache in sync, without its process context
+    event.discarded = discarded_by_fstype(event.mount_id, event.fstype);
+    if (!event.discarded) {
+        struct proc_cache_t *entry = fill_process_data(&event.process);
+        fill_container_data(entry, &event.container);
+    }
 
     resolve_dentry(dentry, path_key, 0);
 
@@ -0,0 +1,62 @@
+#ifndef _MOUNT_FILTER_H_
+#define _MOUNT_FILTER_H_
+
+#include "syscalls.h"
+
+struct mount_fstype_discarder_t {
+    char fstype[FSTYPE_LEN];
+};
+
+// mount_fstype_discarders holds the filesystem types that no mount rule can match
+struct bpf_map_def SEC("maps/mount_fstype_discarders") mount_fstype_discarders = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(struct mount_fstype_discarder_t),
+    .value_size = sizeof(u8),
+    .max_entries = 64,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// mount_id_discarders holds the mount points whose mount event was discarded, so that their umount event is
+// discarded as well
+struct bpf_map_def SEC("maps/mount_id_discarders") mount_id_discarders = {
+    .type = BPF_MAP_TYPE_LRU_HASH,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u8),
+    .max_entries = 4096,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// discarded_by_fstype returns whether the mount point of the given filesystem type is discarded, its mount ID is then
+// recorded so that its umount event is discarded too
+int __attribute__((always_inline)) discarded_by_fstype(u32 mount_id, char fstype[FSTYPE_LEN]) {
+    struct mount_fstype_discarder_t key = {};
+#pragma unroll
+    for (int i = 0; i < FSTYPE_LEN; i++) {
+        key.fstype[i] = fstype[i];
+    }
+
+    if (!bpf_map_lookup_elem(&mount_fstype_discarders, &key)) {
+        return count_filter_lookup(FILTER_MOUNT_FSTYPE_DISCARDERS, 0);
+    }
+
+    u8 zero = 0;
+    bpf_map_update_elem(&mount_id_discarders, &mount_id, &zero, BPF_ANY);
+
+    return count_filter_lookup(FILTER_MOUNT_FSTYPE_DISCARDERS, 1);
+}
+
+// discarded_by_mount_id returns whether the mount event of the given mount point was discarded, the mount point is
+// forgotten as it is being unmounted
+int __attribute__((always_inline)) discarded_by_mount_id(u32 mount_id) {
+    if (!bpf_map_lookup_elem(&mount_id_discarders, &mount_id)) {
+        return count_filter_lookup(FILTER_MOUNT_ID_DISCARDERS, 0);
+    }
+
+    bpf_map_delete_elem(&mount_id_discarders, &mount_id);
+
+    return count_filter_lookup(FILTER_MOUNT_ID_DISCARDERS, 1);
+}
+
+#endif
@@ -5,11 +5,18 @@
 #include "syscalls.h"
 #include "process.h"
 #include "open_filter.h"
+#include "pre_eval.h"
+#include "prefix_filter.h"
+
+// open_basename_approver_t holds the flags required by the rules approving a basename, 0 when a rule requires none
+struct open_basename_approver_t {
+    u32 flags;
+};
 
 struct bpf_map_def SEC("maps/open_basename_approvers") open_basename_approvers = {
     .type = BPF_MAP_TYPE_HASH,
     .key_size = BASENAME_FILTER_SIZE,
-    .value_size = sizeof(struct filter_t),
+    .value_size = sizeof(struct open_basename_approver_t),
     .max_entries = 255,
     .pinning = 0,
     .namespace = "",
@@ -32,15 +39,33 @@ struct open_event_t {
     struct file_t file;
     u32 flags;
     u32 mode;
+    struct path_key_t link;
+};
+
+// open_pre_eval_t holds an open event being pre-evaluated by a tail call, along with its dentry
+struct open_pre_eval_t {
+    struct open_event_t event;
+    struct dentry *dentry;
+};
+
+struct bpf_map_def SEC("maps/open_pre_eval_events") open_pre_eval_events = {
+    .type = BPF_MAP_TYPE_PERCPU_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct open_pre_eval_t),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
 };
 
-int __attribute__((always_inline)) trace__sys_openat(int flags, umode_t mode) {
+int __attribute__((always_inline)) trace__sys_openat(const char *filename, int flags, umode_t mode) {
     struct syscall_cache_t syscall = {
         .type = SYSCALL_OPEN,
         .policy = {.mode = ACCEPT},
         .open = {
-            .flags = flags,
+            // O_LARGEFILE is implied on 64 bits kernels, only the 32 bits processes set it
+            .flags = flags & ~O_LARGEFILE,
             .mode = mode,
+            .filename = filename,
         }
     };
 
@@ -55,40 +80,32 @@ int __attribute__((always_inline)) trace__sys_openat(int flags, umode_t mode) {
 
 SYSCALL_KPROBE2(creat, const char *, filename, umode_t, mode) {
     int flags = O_CREAT|O_WRONLY|O_TRUNC;
-    return trace__sys_openat(flags, mode);
+    return trace__sys_openat(filename, flags, mode);
 }
 
 SYSCALL_COMPAT_KPROBE3(open_by_handle_at, int, mount_fd, struct file_handle *, handle, int, flags) {
     umode_t mode = 0;
-    return trace__sys_openat(flags, mode);
+    return trace__sys_openat(NULL, flags, mode);
 }
 
 SYSCALL_COMPAT_KPROBE0(truncate) {
     int flags = O_CREAT|O_WRONLY|O_TRUNC;
     umode_t mode = 0;
-    return trace__sys_openat(flags, mode);
+    return trace__sys_openat(NULL, flags, mode);
 }
 
 SYSCALL_COMPAT_KPROBE3(open, const char*, filename, int, flags, umode_t, mode) {
-    return trace__sys_openat(flags, mode);
+    return trace__sys_openat(filename, flags, mode);
 }
 
 SYSCALL_COMPAT_KPROBE4(openat, int, dirfd, const char*, filename, int, flags, umode_t, mode) {
-    return trace__sys_openat(flags, mode);
+    return trace__sys_openat(filename, flags, mode);
 }
 
 int __attribute__((always_inline)) approve_by_basename(struct syscall_cache_t *syscall) {
-    struct open_basename_t basename = {};
-    get_dentry_name(syscall->open.dentry, &basename, sizeof(basename));
-
-    struct filter_t *filter = bpf_map_lookup_elem(&open_basename_approvers, &basename);
-    if (filter) {
-#ifdef DEBUG
-        bpf_printk("open basename %s approved\n", basename.value);
-#endif
-        return 1;
-    }
-    return 0;
+    struct open_basename_approver_t *approver = get_basename_approver(&open_basename_approvers, FILTER_OPEN_BASENAME_APPROVERS, syscall->open.dentry);
+    return approver != NULL && (approver->flags == 0 || (syscall->open.flags & approver->flags) > 0) &&
+        approved_by_inode(EVENT_OPEN, syscall->open.dentry, syscall->open.path_key.mount_id);
 }
 
 int __attribute__((always_inline)) approve_by_flags(struct syscall_cache_t *syscall) {
@@ -98,25 +115,35 @@ int __attribute__((always_inline)) approve_by_flags(struct syscall_cache_t *sysc
 #ifdef DEBUG
         bpf_printk("open flags %d approved\n", syscall->open.flags);
 #endif
-        return 1;
+        return count_filter_lookup(FILTER_OPEN_FLAGS_APPROVERS, 1);
     }
-    return 0;
+    return count_filter_lookup(FILTER_OPEN_FLAGS_APPROVERS, 0);
 }
 
-int __attribute__((always_inline)) filter_open(struct syscall_cache_t *syscall) {
+int __attribute__((always_inline)) filter_open(struct syscall_cache_t *syscall, struct path *path) {
     if (syscall->policy.mode == NO_FILTER)
         return 0;
 
     char pass_to_userspace = syscall->policy.mode == ACCEPT ? 1 : 0;
 
     if (syscall->policy.mode == DENY) {
-        if ((syscall->policy.flags & BASENAME) > 0) {
+        // the approvers match the file reached by the open, the opens through a symbolic link are sent so that the
+        // rules can match the link
+        if (syscall->open.link_key.ino) {
+            pass_to_userspace = 1;
+        }
+
+        if (!pass_to_userspace && (syscall->policy.flags & BASENAME) > 0) {
             pass_to_userspace = approve_by_basename(syscall);
         }
 
         if (!pass_to_userspace && (syscall->policy.flags & FLAGS) > 0) {
            pass_to_userspace = approve_by_flags(syscall);
         }
+
+        if (!pass_to_userspace && (syscall->policy.flags & PREFIX) > 0) {
+            pass_to_userspace = approved_by_prefix(EVENT_OPEN, syscall->open.dentry, path, syscall->open.flags);
+        }
     }
 
     if (!pass_to_userspace) {
@@ -138,7 +165,79 @@ int __attribute__((always_inline)) handle_open_event(struct pt_regs *ctx, struct
     syscall->open.dentry = get_file_dentry(file);
     syscall->open.path_key = get_inode_key_path(inode, &file->f_path);
 
-    return filter_open(syscall);
+    return filter_open(syscall, &file->f_path);
+}
+
+#define LINK_PATH_SIZE 256
+
+// link_path_t holds the path given to an open, compared to the names of the symbolic links the open follows
+struct link_path_t {
+    char value[LINK_PATH_SIZE];
+};
+
+struct bpf_map_def SEC("maps/open_link_paths") open_link_paths = {
+    .type = BPF_MAP_TYPE_PERCPU_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct link_path_t),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// is_trailing_link returns whether the given symbolic link is the last component of the given path. The links
+// followed to reach the directories of the path are skipped, the paths longer than the buffer are never matched and
+// only the beginning of the longest names is compared
+int __attribute__((always_inline)) is_trailing_link(const char *filename, struct dentry *dentry) {
+    u32 key = 0;
+    struct link_path_t *path = bpf_map_lookup_elem(&open_link_paths, &key);
+    if (!path)
+        return 0;
+
+    int len = bpf_probe_read_str(path->value, sizeof(path->value), (void *)filename) - 1;
+    if (len <= 0 || len >= LINK_PATH_SIZE - 1)
+        return 0;
+
+    struct qstr qstr;
+    bpf_probe_read(&qstr, sizeof(qstr), &dentry->d_name);
+    if (qstr.len == 0 || qstr.len > len)
+        return 0;
+
+    char name[BASENAME_FILTER_SIZE] = {};
+    bpf_probe_read_str(&name, sizeof(name), (void *)qstr.name);
+
+    u32 offset = len - qstr.len;
+    if (offset > 0 && path->value[(offset - 1) & (LINK_PATH_SIZE - 1)] != '/')
+        return 0;
+
+#pragma unroll
+    for (int i = 0; i < BASENAME_FILTER_SIZE - 1; i++) {
+        if (i >= qstr.len)
+            return 1;
+        if (path->value[(offset + i) & (LINK_PATH_SIZE - 1)] != name[i])
+            return 0;
+    }
+
+    return 1;
+}
+
+// pick_link is called for each symbolic link followed by a path walk. The link named by the path of an open is
+// resolved here, while the walk holds it, and sent along with the file it points to
+SEC("kprobe/pick_link")
+int kprobe__pick_link(struct pt_regs *ctx) {
+    struct syscall_cache_t *syscall = peek_syscall(SYSCALL_OPEN);
+    if (!syscall || !syscall->open.filename || syscall->open.dentry || syscall->open.link_key.ino)
+        return 0;
+
+    struct path *link = (struct path *)PT_REGS_PARM2(ctx);
+    struct dentry *dentry = get_path_dentry(link);
+    if (!is_trailing_link(syscall->open.filename, dentry))
+        return 0;
+
+    syscall->open.link_key = get_dentry_key_path(dentry, link);
+    syscall->open.link_key.path_id = get_path_id(0);
+    resolve_dentry(dentry, syscall->open.link_key, 0);
+
+    return 0;
 }
 
 SEC("kprobe/vfs_truncate")
@@ -157,28 +256,26 @@ int kprobe__vfs_truncate(struct pt_regs *ctx) {
     syscall->open.dentry = get_path_dentry(path);
     syscall->open.path_key = get_dentry_key_path(syscall->open.dentry, path);
 
-    return filter_open(syscall);
+    return filter_open(syscall, path);
 }
 
-SEC("kretprobe/ovl_dentry_upper")
-int kprobe__ovl_dentry_upper(struct pt_regs *ctx) {
+HOOK_EXIT(ovl_dentry_upper, 1) {
    struct syscall_cache_t *syscall = peek_syscall(SYSCALL_OPEN | SYSCALL_EXEC);
     if (!syscall)
         return 0;
 
-    struct dentry *dentry = (struct dentry *)PT_REGS_RC(ctx);
+    struct dentry *dentry = (struct dentry *)ret;
     syscall->open.path_key.ino = get_dentry_ino(dentry);
 
     return 0;
 }
 
-SEC("kretprobe/ovl_d_real")
-int kretprobe__ovl_d_real(struct pt_regs *ctx) {
+HOOK_EXIT(ovl_d_real, 2) {
    struct syscall_cache_t *syscall = peek_syscall(SYSCALL_OPEN | SYSCALL_EXEC);
     if (!syscall)
         return 0;
 
-    struct dentry *dentry = (struct dentry *)PT_REGS_RC(ctx);
+    struct dentry *dentry = (struct dentry *)ret;
     syscall->open.path_key.ino = get_dentry_ino(dentry);
 
     return 0;
@@ -230,20 +327,62 @@ int __attribute__((always_inline)) trace__sys_open_ret(struct pt_regs *ctx) {
         },
         .flags = syscall->open.flags,
         .mode = syscall->open.mode,
+        .link = syscall->open.link_key,
     };
 
-    int ret = resolve_dentry(syscall->open.dentry, syscall->open.path_key, syscall->policy.mode != NO_FILTER ? EVENT_OPEN : 0);
+    // the discarders match the file reached by the open, not the symbolic link it followed
+    u64 event_type = syscall->policy.mode != NO_FILTER && !syscall->open.link_key.ino ? EVENT_OPEN : 0;
+    int ret = resolve_dentry(syscall->open.dentry, syscall->open.path_key, event_type);
     if (ret == DENTRY_DISCARDED || (ret == DENTRY_INVALID && !(IS_UNHANDLED_ERROR(retval)))) {
        return 0;
     }
 
     fill_process_data(&event.process);
 
+    fill_file_generation(event.file);
+
+    // the rules are pre-evaluated by a tail call when all of them only test fields known in kernel, the event is
+    // sent as is if the tail call fails. The opens through a symbolic link are sent so that the rules can match the link
+    if (!syscall->open.link_key.ino && is_pre_evaluated(EVENT_OPEN)) {
+        u32 key = 0;
+        struct open_pre_eval_t *pre_eval = bpf_map_lookup_elem(&open_pre_eval_events, &key);
+        if (pre_eval) {
+            pre_eval->event = event;
+            pre_eval->dentry = syscall->open.dentry;
+            bpf_tail_call(ctx, &pre_eval_progs, EVENT_OPEN);
+        }
+    }
+
     send_event(ctx, event);
 
     return 0;
 }
 
+SEC("kprobe/pre_eval_open")
+int kprobe__pre_eval_open(struct pt_regs *ctx) {
+    u32 key = 0;
+    struct open_pre_eval_t *pre_eval = bpf_map_lookup_elem(&open_pre_eval_events, &key);
+    if (!pre_eval) {
+        return 0;
+    }
+
+    struct pre_eval_values_t values = {
+        .flags = pre_eval->event.flags,
+        .uid = pre_eval->event.process.uid,
+        .gid = pre_eval->event.process.gid,
+    };
+    bpf_get_current_comm(&values.comm, sizeof(values.comm));
+    get_dentry_name(pre_eval->dentry, &values.basename, sizeof(values.basename));
+
+    if (!pre_evaluate(EVENT_OPEN, &values)) {
+        return 0;
+    }
+
+    send_event(ctx, pre_eval->event);
+
+    return 0;
+}
+
 SYSCALL_KRETPROBE(creat) {
     return trace__sys_open_ret(ctx);
 }
@@ -0,0 +1,132 @@
+#ifndef _PRE_EVAL_H_
+#define _PRE_EVAL_H_
+
+#include "defs.h"
+#include "filters.h"
+#include "open_filter.h"
+
+// PRE_EVAL_MAX_RULES is the maximum number of entries of the decision table of an event type
+#define PRE_EVAL_MAX_RULES 16
+
+enum pre_eval_field
+{
+    PRE_EVAL_BASENAME = 1 << 0,
+    PRE_EVAL_FLAGS = 1 << 1,
+    PRE_EVAL_UID = 1 << 2,
+    PRE_EVAL_GID = 1 << 3,
+    PRE_EVAL_COMM = 1 << 4,
+};
+
+// pre_eval_rule_t is an entry of the decision table of an event type, the conjunction of the values of its fields.
+// An event matches the flags of an entry if it has at least one of them
+struct pre_eval_rule_t {
+    u32 fields;
+    u32 flags;
+    u32 uid;
+    u32 gid;
+    char comm[TASK_COMM_LEN];
+    char basename[BASENAME_FILTER_SIZE];
+};
+
+// pre_eval_values_t holds the values of the fields of an event known in kernel
+struct pre_eval_values_t {
+    u32 flags;
+    u32 uid;
+    u32 gid;
+    char comm[TASK_COMM_LEN];
+    char basename[BASENAME_FILTER_SIZE];
+};
+
+// pre_eval_config holds the number of entries of the decision table of each event type, 0 disables the pre-evaluation
+struct bpf_map_def SEC("maps/pre_eval_config") pre_eval_config = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = EVENT_MAX,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// pre_eval_rules holds the decision tables, the entries of an event type start at event_type * PRE_EVAL_MAX_RULES
+struct bpf_map_def SEC("maps/pre_eval_rules") pre_eval_rules = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct pre_eval_rule_t),
+    .max_entries = EVENT_MAX * PRE_EVAL_MAX_RULES,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// pre_eval_progs holds the programs pre-evaluating the events of each event type, they are tail called once the event
+// is ready to be sent
+struct bpf_map_def SEC("maps/pre_eval_progs") pre_eval_progs = {
+    .type = BPF_MAP_TYPE_PROG_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = EVENT_MAX,
+    .pinning = 0,
+    .namespace = "",
+};
+
+int __attribute__((always_inline)) is_pre_evaluated(u32 event_type) {
+    u32 *rules = bpf_map_lookup_elem(&pre_eval_config, &event_type);
+    return rules != NULL && *rules > 0;
+}
+
+int __attribute__((always_inline)) pre_eval_strings_equal(const char *a, const char *b, int n) {
+#pragma unroll
+    for (int i = 0; i < n; i++) {
+        if (a[i] != b[i]) {
+            return 0;
+        }
+        if (a[i] == 0) {
+            return 1;
+        }
+    }
+    return 1;
+}
+
+int __attribute__((always_inline)) pre_eval_rule_matches(struct pre_eval_rule_t *rule, struct pre_eval_values_t *values) {
+    if ((rule->fields & PRE_EVAL_FLAGS) && (values->flags & rule->flags) == 0) {
+        return 0;
+    }
+    if ((rule->fields & PRE_EVAL_UID) && values->uid != rule->uid) {
+        return 0;
+    }
+    if ((rule->fields & PRE_EVAL_GID) && values->gid != rule->gid) {
+        return 0;
+    }
+    if ((rule->fields & PRE_EVAL_COMM) && !pre_eval_strings_equal(values->comm, rule->comm, TASK_COMM_LEN)) {
+        return 0;
+    }
+    if ((rule->fields & PRE_EVAL_BASENAME) && !pre_eval_strings_equal(values->basename, rule->basename, BASENAME_FILTER_SIZE)) {
+        return 0;
+    }
+    return 1;
+}
+
+// pre_evaluate returns whether the given values match an entry of the decision table of the event type, the events
+// matching none can't match any rule and aren't sent
+int __attribute__((always_inline)) pre_evaluate(u32 event_type, struct pre_eval_values_t *values) {
+    u32 *count = bpf_map_lookup_elem(&pre_eval_config, &event_type);
+    if (!count) {
+        return 1;
+    }
+
+#pragma unroll
+    for (u32 i = 0; i < PRE_EVAL_MAX_RULES; i++) {
+        if (i >= *count) {
+            break;
+        }
+
+        u32 key = event_type * PRE_EVAL_MAX_RULES + i;
+        struct pre_eval_rule_t *rule = bpf_map_lookup_elem(&pre_eval_rules, &key);
+        if (rule && pre_eval_rule_matches(rule, values)) {
+            return count_filter_lookup(FILTER_PRE_EVAL_RULES, 1);
+        }
+    }
+
+    return count_filter_lookup(FILTER_PRE_EVAL_RULES, 0);
+}
+
+#endif
@@ -0,0 +1,156 @@
+#ifndef _PREFIX_FILTER_H_
+#define _PREFIX_FILTER_H_
+
+#include "defs.h"
+#include "filters.h"
+#include "dentry.h"
+
+// PREFIX_MAX_DEPTH is the maximum number of directories walked up to the root of a filesystem
+#define PREFIX_MAX_DEPTH 16
+#define PREFIX_SEGMENT_LEN 32
+// PREFIX_MOUNT_MAX_DEPTH is the maximum number of parent mounts walked up to find the root of the mount namespace
+#define PREFIX_MOUNT_MAX_DEPTH 3
+
+#define MNT_OFFSETOF_MNT_PARENT 16 // offsetof(struct mount, mnt_parent)
+#define MNT_OFFSETOF_MNT_MOUNTPOINT 24 // offsetof(struct mount, mnt_mountpoint)
+
+// the hash of a prefix is the sum of the hashes of its segments weighted by the powers of PREFIX_HASH_PRIME, from the
+// top directory. PREFIX_HASH_PRIME_INV is the inverse of PREFIX_HASH_PRIME modulo 2^64, so that the hashes of all the
+// prefixes of a path are computed while walking up its directories. Aligned with user space
+#define PREFIX_HASH_SEED 0xcbf29ce484222325ULL
+#define PREFIX_HASH_PRIME 0x100000001b3ULL
+#define PREFIX_HASH_PRIME_INV 0xce965057aff6957bULL
+
+struct prefix_approver_key_t {
+    u64 event_type;
+    u64 hash;
+};
+
+// prefix_approver_t holds the flags required by the rules approving a prefix, 0 when a rule requires none
+struct prefix_approver_t {
+    u32 flags;
+};
+
+struct bpf_map_def SEC("maps/prefix_approvers") prefix_approvers = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(struct prefix_approver_key_t),
+    .value_size = sizeof(struct prefix_approver_t),
+    .max_entries = 64,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// get_segment_hash returns the hash of the name of the given dentry, truncated to PREFIX_SEGMENT_LEN - 1 characters
+u64 __attribute__((always_inline)) get_segment_hash(struct dentry *dentry) {
+    u64 segment[PREFIX_SEGMENT_LEN / sizeof(u64)] = {};
+    get_dentry_name(dentry, segment, sizeof(segment));
+
+    u64 hash = PREFIX_HASH_SEED;
+#pragma unroll
+    for (int i = 0; i < PREFIX_SEGMENT_LEN / sizeof(u64); i++) {
+        hash = (hash ^ segment[i]) * PREFIX_HASH_PRIME;
+    }
+    return hash;
+}
+
+// is_rooted_mount returns whether the given mount is mounted at the root of its mount namespace, the paths within its
+// filesystem are then the paths seen by user space
+int __attribute__((always_inline)) is_rooted_mount(struct vfsmount *mnt) {
+    void *mount = (void *)mnt - MNT_OFFSETOF_MNT;
+
+#pragma unroll
+    for (int i = 0; i < PREFIX_MOUNT_MAX_DEPTH; i++) {
+        void *parent = NULL;
+        bpf_probe_read(&parent, sizeof(parent), mount + MNT_OFFSETOF_MNT_PARENT);
+        if (parent == NULL) {
+            return 0;
+        }
+        if (parent == mount) {
+            return 1;
+        }
+
+        struct dentry *mountpoint = NULL;
+        bpf_probe_read(&mountpoint, sizeof(mountpoint), mount + MNT_OFFSETOF_MNT_MOUNTPOINT);
+
+        char name[2] = {};
+        get_dentry_name(mountpoint, name, sizeof(name));
+        if (name[0] != '/') {
+            return 0;
+        }
+
+        mount = parent;
+    }
+    return 0;
+}
+
+// approved_by_prefix returns whether a directory containing the given dentry is a prefix approved for the given
+// flags. The files of the mounts that aren't mounted at the root of their namespace, or too deep to be walked up to
+// the root of their mount, can't be matched against the prefixes and are approved
+int __attribute__((always_inline)) approved_by_prefix(u64 event_type, struct dentry *dentry, struct path *path, u32 flags) {
+    struct vfsmount *mnt = NULL;
+    bpf_probe_read(&mnt, sizeof(mnt), &path->mnt);
+    if (!is_rooted_mount(mnt)) {
+        return count_filter_lookup(FILTER_PREFIX_APPROVERS, 1);
+    }
+
+    // a bind mount may expose a sub directory of its filesystem, the walk stops at the root of the mount
+    struct dentry *mnt_root = NULL;
+    bpf_probe_read(&mnt_root, sizeof(mnt_root), &mnt->mnt_root);
+
+    // cumulated[i] holds the sum of the weighted hashes of the i closest directories, the top directory of the path
+    // ends up weighted by power * PREFIX_HASH_PRIME_INV^(depth - 1) = 1
+    u64 cumulated[PREFIX_MAX_DEPTH + 1] = {};
+    u64 total = 0, weight = 1, power = PREFIX_HASH_PRIME_INV;
+    int depth = -1;
+
+    struct dentry *d_parent = NULL;
+    bpf_probe_read(&d_parent, sizeof(d_parent), &dentry->d_parent);
+    dentry = d_parent;
+
+#pragma unroll
+    for (int i = 0; i < PREFIX_MAX_DEPTH; i++) {
+        bpf_probe_read(&d_parent, sizeof(d_parent), &dentry->d_parent);
+        if (dentry == d_parent || dentry == mnt_root) {
+            depth = i;
+            break;
+        }
+
+        cumulated[i] = total;
+        total += get_segment_hash(dentry) * weight;
+        weight *= PREFIX_HASH_PRIME_INV;
+        power *= PREFIX_HASH_PRIME;
+        dentry = d_parent;
+    }
+
+    if (depth < 0) {
+        bpf_probe_read(&d_parent, sizeof(d_parent), &dentry->d_parent);
+        if (dentry != d_parent && dentry != mnt_root) {
+            return count_filter_lookup(FILTER_PREFIX_APPROVERS, 1);
+        }
+        depth = PREFIX_MAX_DEPTH;
+    }
+
+    // the prefix of the i-th closest directory is made of the directories above it
+    struct prefix_approver_key_t key = {
+        .event_type = event_type,
+    };
+
+#pragma unroll
+    for (int i = 0; i < PREFIX_MAX_DEPTH; i++) {
+        if (i >= depth) {
+            break;
+        }
+
+        key.hash = power * (total - cumulated[i]);
+        struct prefix_approver_t *approver = bpf_map_lookup_elem(&prefix_approvers, &key);
+        if (approver != NULL && (approver->flags == 0 || (flags & approver->flags) > 0)) {
+#ifdef DEBUG
+            bpf_printk("prefix of depth %d approved\n", depth - i);
+#endif
+            return count_filter_lookup(FILTER_PREFIX_APPROVERS, 1);
+        }
+    }
+    return count_filter_lookup(FILTER_PREFIX_APPROVERS, 0);
+}
+
+#endif
@@ -1,3 +1,9 @@
+#if USE_CORE == 1
+// the accesses to the fields of the kernel structures are recorded as CO-RE relocations, applied by user space with
+// the BTF of the running kernel. All the kernel headers have to be included here
+#pragma clang attribute push (__attribute__((preserve_access_index)), apply_to = record)
+#endif
+
 #include <linux/compiler.h>
 
 #include <linux/kconfig.h>
@@ -5,6 +11,21 @@
 #include <linux/types.h>
 #include <linux/version.h>
 
+#if USE_CORE == 1
+#include <linux/binfmts.h>
+#include <linux/dcache.h>
+#include <linux/fs.h>
+#include <linux/mount.h>
+#include <linux/nsproxy.h>
+#include <linux/pid_namespace.h>
+#include <linux/sched.h>
+#include <linux/tty.h>
+#include <net/net_namespace.h>
+#include <uapi/linux/utime.h>
+
+#pragma clang attribute pop
+#endif
+
 #include "defs.h"
 #include "dentry.h"
 #include "exec.h"
@@ -21,6 +42,7 @@
 #include "rename.h"
 #include "cgroup.h"
 #include "open.h"
+#include "copy_up.h"
 #include "utimes.h"
 #include "mount.h"
 #include "umount.h"
@@ -28,6 +50,7 @@
 #include "raw_syscalls.h"
 #include "procfs.h"
 #include "setxattr.h"
+#include "erpc.h"
 
 struct invalidate_dentry_event_t {
     struct kevent_t event;
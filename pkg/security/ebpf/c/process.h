@@ -3,6 +3,9 @@
 
 #include <linux/tty.h>
 #include <linux/sched.h>
+#include <linux/nsproxy.h>
+#include <linux/pid_namespace.h>
+#include <net/net_namespace.h>
 
 struct bpf_map_def SEC("maps/proc_cache") proc_cache = {
     .type = BPF_MAP_TYPE_LRU_HASH,
@@ -22,6 +25,17 @@ struct bpf_map_def SEC("maps/pid_cookie") pid_cookie = {
     .namespace = "",
 };
 
+// mntns_inum_offset holds the offset of the inode number in struct mnt_namespace, read from the kernel BTF or guessed
+// by user space. The mount namespaces are reported as 0 until it is set
+struct bpf_map_def SEC("maps/mntns_inum_offset") mntns_inum_offset = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
+};
+
 struct proc_cache_t * __attribute__((always_inline)) get_pid_cache(u32 tgid) {
     struct proc_cache_t *entry = NULL;
 
@@ -34,6 +48,63 @@ struct proc_cache_t * __attribute__((always_inline)) get_pid_cache(u32 tgid) {
     return entry;
 }
 
+// get_task_pid_ns returns the pid namespace of the given task, as task_active_pid_ns does. The pid namespace of the
+// children in its nsproxy differs between unshare(CLONE_NEWPID) and the next fork, or after setns
+static struct pid_namespace * __attribute__((always_inline)) get_task_pid_ns(struct task_struct *task) {
+    struct pid *pid = NULL;
+    // the kernels exposing their BTF to the CO-RE object all have thread_pid, added in 4.19
+#if USE_CORE == 1 || LINUX_VERSION_CODE >= KERNEL_VERSION(4, 19, 0)
+    bpf_probe_read(&pid, sizeof(pid), &task->thread_pid);
+#else
+    bpf_probe_read(&pid, sizeof(pid), &task->pids[PIDTYPE_PID].pid);
+#endif
+    if (!pid) {
+        return NULL;
+    }
+
+    unsigned int level = 0;
+    bpf_probe_read(&level, sizeof(level), &pid->level);
+
+    struct pid_namespace *pidns = NULL;
+    bpf_probe_read(&pidns, sizeof(pidns), &pid->numbers[level].ns);
+    return pidns;
+}
+
+static void __attribute__((always_inline)) fill_namespace_data(struct process_context_t *data) {
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+
+    struct pid_namespace *pidns = get_task_pid_ns(task);
+    if (pidns) {
+        bpf_probe_read(&data->pidns, sizeof(data->pidns), &pidns->ns.inum);
+    }
+
+    struct nsproxy *nsproxy = NULL;
+    bpf_probe_read(&nsproxy, sizeof(nsproxy), &task->nsproxy);
+    if (!nsproxy) {
+        // exiting task
+        return;
+    }
+
+    // struct mnt_namespace is private to the kernel, the offset of its inode number is provided by user space
+    u32 key = 0;
+    u32 *offset = bpf_map_lookup_elem(&mntns_inum_offset, &key);
+    if (offset && *offset) {
+        void *mntns = NULL;
+        bpf_probe_read(&mntns, sizeof(mntns), &nsproxy->mnt_ns);
+        if (mntns) {
+            bpf_probe_read(&data->mntns, sizeof(data->mntns), mntns + *offset);
+        }
+    }
+
+#ifdef CONFIG_NET_NS
+    struct net *netns = NULL;
+    bpf_probe_read(&netns, sizeof(netns), &nsproxy->net_ns);
+    if (netns) {
+        bpf_probe_read(&data->netns, sizeof(data->netns), &netns->ns.inum);
+    }
+#endif
+}
+
 static struct proc_cache_t * __attribute__((always_inline)) fill_process_data(struct process_context_t *data) {
     // Comm
     bpf_get_current_comm(&data->comm, sizeof(data->comm));
@@ -51,6 +122,9 @@ static struct proc_cache_t * __attribute__((always_inline)) fill_process_data(st
     data->uid = userid >> 32;
     data->gid = userid;
 
+    // Namespaces
+    fill_namespace_data(data);
+
     return NULL;
 }
 
@@ -0,0 +1,169 @@
+#ifndef _RATE_LIMITER_H_
+#define _RATE_LIMITER_H_
+
+#include "defs.h"
+#include "process.h"
+#include "container.h"
+
+#define NSEC_PER_SEC 1000000000
+// the refill of a bucket is computed over 10 seconds at most, so that the number of tokens can't overflow
+#define RATE_LIMITER_MAX_ELAPSED (10 * (u64)NSEC_PER_SEC)
+
+// rate_limiter_config_t holds the rate, in events per second, and the burst of the buckets of an event type. The
+// rate of the buckets of the event types is given per CPU, a null rate disables the rate limiting
+struct rate_limiter_config_t {
+    u64 rate;
+    u64 burst;
+    u64 container_rate;
+    u64 container_burst;
+};
+
+struct bpf_map_def SEC("maps/rate_limiter_config") rate_limiter_config = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct rate_limiter_config_t),
+    .max_entries = EVENT_MAX,
+    .pinning = 0,
+    .namespace = "",
+};
+
+struct rate_limiter_bucket_t {
+    u64 tokens;
+    u64 last_refill;
+};
+
+// rate_limiters holds the buckets of the event types, one per CPU so that they don't require any lock
+struct bpf_map_def SEC("maps/rate_limiters") rate_limiters = {
+    .type = BPF_MAP_TYPE_PERCPU_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct rate_limiter_bucket_t),
+    .max_entries = EVENT_MAX,
+    .pinning = 0,
+    .namespace = "",
+};
+
+struct container_rate_limiter_key_t {
+    char container_id[CONTAINER_ID_LEN];
+    u64 event_type;
+};
+
+// container_rate_limiters holds the buckets of the event types per container. They are shared by all the CPUs, a
+// concurrent refill may be lost which only makes the rate limiting stricter
+struct bpf_map_def SEC("maps/container_rate_limiters") container_rate_limiters = {
+    .type = BPF_MAP_TYPE_LRU_HASH,
+    .key_size = sizeof(struct container_rate_limiter_key_t),
+    .value_size = sizeof(struct rate_limiter_bucket_t),
+    .max_entries = 1024,
+    .pinning = 0,
+    .namespace = "",
+};
+
+struct rate_limiter_drops_t {
+    u64 drops;
+    u64 container_drops;
+};
+
+struct bpf_map_def SEC("maps/rate_limiter_drops") rate_limiter_drops = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(struct rate_limiter_drops_t),
+    .max_entries = EVENT_MAX,
+    .pinning = 0,
+    .namespace = "",
+};
+
+// take_token refills the given bucket according to the time elapsed since its last refill, and takes a token from it
+int __attribute__((always_inline)) take_token(struct rate_limiter_bucket_t *bucket, u64 rate, u64 burst) {
+    u64 now = bpf_ktime_get_ns();
+    u64 elapsed = now - bucket->last_refill;
+    if (elapsed > RATE_LIMITER_MAX_ELAPSED) {
+        elapsed = RATE_LIMITER_MAX_ELAPSED;
+    }
+
+    // the refill time is only moved forward once at least a token was added
+    u64 refill = elapsed * rate / NSEC_PER_SEC;
+    if (refill > 0) {
+        bucket->tokens += refill;
+        if (bucket->tokens > burst) {
+            bucket->tokens = burst;
+        }
+        bucket->last_refill = now;
+    }
+
+    if (bucket->tokens == 0) {
+        return 0;
+    }
+    bucket->tokens--;
+    return 1;
+}
+
+// take_container_token takes a token from the bucket of the event type for the container of the current process, if
+// it runs in a container
+int __attribute__((always_inline)) take_container_token(u32 event_type, struct rate_limiter_config_t *config) {
+    u32 tgid = bpf_get_current_pid_tgid() >> 32;
+    struct proc_cache_t *entry = get_pid_cache(tgid);
+    if (!entry) {
+        return 1;
+    }
+
+    struct container_rate_limiter_key_t key = {
+        .event_type = event_type,
+    };
+    if (!copy_container_id(key.container_id, entry->container.container_id)) {
+        return 1;
+    }
+
+    struct rate_limiter_bucket_t *bucket = bpf_map_lookup_elem(&container_rate_limiters, &key);
+    if (!bucket) {
+        // a new bucket starts full
+        struct rate_limiter_bucket_t new_bucket = {
+            .tokens = config->container_burst,
+            .last_refill = bpf_ktime_get_ns(),
+        };
+        bpf_map_update_elem(&container_rate_limiters, &key, &new_bucket, BPF_ANY);
+
+        bucket = bpf_map_lookup_elem(&container_rate_limiters, &key);
+        if (!bucket) {
+            return 1;
+        }
+    }
+
+    return take_token(bucket, config->container_rate, config->container_burst);
+}
+
+// is_event_allowed returns whether an event of the given type can be sent to user space without exceeding the rate
+// of its event type, and the rate of its event type for its container. The dropped events are counted. The events
+// invalidating the dentry cache of user space are never dropped
+int __attribute__((always_inline)) is_event_allowed(u32 event_type) {
+    if (is_dentry_cache_invalidating_event(event_type)) {
+        return 1;
+    }
+
+    struct rate_limiter_config_t *config = bpf_map_lookup_elem(&rate_limiter_config, &event_type);
+    if (!config) {
+        return 1;
+    }
+
+    if (config->container_rate > 0 && !take_container_token(event_type, config)) {
+        struct rate_limiter_drops_t *drops = bpf_map_lookup_elem(&rate_limiter_drops, &event_type);
+        if (drops) {
+            __sync_fetch_and_add(&drops->container_drops, 1);
+        }
+        return 0;
+    }
+
+    if (config->rate > 0) {
+        struct rate_limiter_bucket_t *bucket = bpf_map_lookup_elem(&rate_limiters, &event_type);
+        if (bucket && !take_token(bucket, config->rate, config->burst)) {
+            struct rate_limiter_drops_t *drops = bpf_map_lookup_elem(&rate_limiter_drops, &event_type);
+            if (drops) {
+                __sync_fetch_and_add(&drops->drops, 1);
+            }
+            return 0;
+        }
+    }
+
+    return 1;
+}
+
+#endif
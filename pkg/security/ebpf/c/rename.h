@@ -1,8 +1,18 @@
 #ifndef _RENAME_H_
 #define _RENAME_H_
 
+#include "filters.h"
 #include "syscalls.h"
 
+struct bpf_map_def SEC("maps/rename_basename_approvers") rename_basename_approvers = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = BASENAME_FILTER_SIZE,
+    .value_size = sizeof(struct filter_t),
+    .max_entries = 255,
+    .pinning = 0,
+    .namespace = "",
+};
+
 struct rename_event_t {
     struct kevent_t event;
     struct process_context_t process;
@@ -12,6 +22,19 @@ struct rename_event_t {
     struct file_t new;
 };
 
+// approve_rename is called with the source dentry before the rename, holding the old name, and after the rename,
+// holding the new name
+int __attribute__((always_inline)) approve_rename(struct syscall_cache_t *syscall, struct dentry *dentry) {
+    if (syscall->policy.mode != DENY)
+        return 1;
+
+    if ((syscall->policy.flags & BASENAME) > 0 && approved_by_basename(&rename_basename_approvers, FILTER_RENAME_BASENAME_APPROVERS, dentry) &&
+        approved_by_inode(EVENT_RENAME, dentry, syscall->rename.src_key.mount_id))
+        return 1;
+
+    return 0;
+}
+
 int __attribute__((always_inline)) trace__sys_rename() {
     struct syscall_cache_t syscall = {
         .type = SYSCALL_RENAME,
@@ -49,6 +72,7 @@ int kprobe__vfs_rename(struct pt_regs *ctx) {
     }
 
     syscall->rename.src_dentry = dentry;
+    syscall->rename.replaced_inode = get_dentry_ino((struct dentry *)PT_REGS_PARM4(ctx));
     syscall->rename.src_overlay_numlower = get_overlay_numlower(syscall->rename.src_dentry);
 
     // we generate a fake source key as the inode is (can be ?) reused
@@ -57,6 +81,8 @@ int kprobe__vfs_rename(struct pt_regs *ctx) {
     // the mount id of path_key is resolved by kprobe/mnt_want_write. It is already set by the time we reach this probe.
     resolve_dentry(syscall->rename.src_dentry, syscall->rename.src_key, 0);
 
+    syscall->rename.approved = approve_rename(syscall, syscall->rename.src_dentry);
+
     return 0;
 }
 
@@ -70,6 +96,11 @@ int __attribute__((always_inline)) trace__sys_rename_ret(struct pt_regs *ctx) {
     // invalidate non ovl inode, case of folder renamed
     invalidate_inode(ctx, syscall->rename.target_key.mount_id, get_dentry_ino(syscall->rename.src_dentry), 1);
 
+    // the file overwritten by the rename lost its last name, its inode may be reused
+    if (retval >= 0 && syscall->rename.replaced_inode) {
+        invalidate_inode(ctx, syscall->rename.target_key.mount_id, syscall->rename.replaced_inode, 1);
+    }
+
     // Warning: we use the src_dentry twice for compatibility with CentOS. Do not change it :)
     // (the mount id was set by kprobe/mnt_want_write)
     syscall->rename.target_key.ino = get_dentry_ino(syscall->rename.src_dentry);
@@ -77,7 +108,12 @@ int __attribute__((always_inline)) trace__sys_rename_ret(struct pt_regs *ctx) {
         syscall->rename.target_key.ino = get_dentry_ino(syscall->rename.real_src_dentry);
     }
 
-    if (discarded_by_process(syscall->policy.mode, EVENT_RENAME) || (IS_UNHANDLED_ERROR(retval))) {
+    // the source dentry now holds the new name of the file
+    if (!syscall->rename.approved) {
+        syscall->rename.approved = approve_rename(syscall, syscall->rename.src_dentry);
+    }
+
+    if (discarded_by_process(syscall->policy.mode, EVENT_RENAME) || !syscall->rename.approved || (IS_UNHANDLED_ERROR(retval))) {
         invalidate_inode(ctx, syscall->rename.target_key.mount_id, syscall->rename.target_key.ino, 1);
         return 0;
     }
@@ -110,6 +146,9 @@ int __attribute__((always_inline)) trace__sys_rename_ret(struct pt_regs *ctx) {
 
         resolve_dentry(syscall->rename.src_dentry, syscall->rename.target_key, 0);
 
+        fill_file_generation(event.old);
+        fill_file_generation(event.new);
+
         send_event(ctx, event);
     }
 
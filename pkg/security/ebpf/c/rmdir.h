@@ -21,8 +21,7 @@ SYSCALL_KPROBE0(rmdir) {
     return 0;
 }
 
-SEC("kprobe/security_inode_rmdir")
-int kprobe__security_inode_rmdir(struct pt_regs *ctx) {
+HOOK_ENTRY2(security_inode_rmdir, struct inode *, dir, struct dentry *, victim) {
     struct syscall_cache_t *syscall = peek_syscall(SYSCALL_RMDIR | SYSCALL_UNLINK);
     if (!syscall)
         return 0;
@@ -38,7 +37,7 @@ int kprobe__security_inode_rmdir(struct pt_regs *ctx) {
             event_type = EVENT_RMDIR;
 
             // we resolve all the information before the file is actually removed
-            dentry = (struct dentry *)PT_REGS_PARM2(ctx);
+            dentry = victim;
 
             // if second pass, ex: overlayfs, just cache the inode that will be used in ret
             if (syscall->rmdir.path_key.ino) {
@@ -58,7 +57,7 @@ int kprobe__security_inode_rmdir(struct pt_regs *ctx) {
             event_type = EVENT_UNLINK;
 
             // we resolve all the information before the file is actually removed
-            dentry = (struct dentry *) PT_REGS_PARM2(ctx);
+            dentry = victim;
 
             // if second pass, ex: overlayfs, just cache the inode that will be used in ret
             if (syscall->unlink.path_key.ino) {
@@ -132,6 +131,8 @@ SYSCALL_KRETPROBE(rmdir) {
         struct proc_cache_t *entry = fill_process_data(&event.process);
         fill_container_data(entry, &event.container);
 
+        fill_file_generation(event.file);
+
         send_event(ctx, event);
     }
 
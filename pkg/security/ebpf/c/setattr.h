@@ -2,16 +2,14 @@
 #define _SETATTR_H_
 
 #include "syscalls.h"
+#include "chmod.h"
+#include "chown.h"
 
-SEC("kprobe/security_inode_setattr")
-int kprobe__security_inode_setattr(struct pt_regs *ctx) {
+HOOK_ENTRY2(security_inode_setattr, struct dentry *, dentry, struct iattr *, iattr) {
     struct syscall_cache_t *syscall = peek_syscall(SYSCALL_UTIME | SYSCALL_CHMOD | SYSCALL_CHOWN);
     if (!syscall)
         return 0;
 
-    struct dentry *dentry = (struct dentry *)PT_REGS_PARM1(ctx);
-
-    struct iattr *iattr = (struct iattr *)PT_REGS_PARM2(ctx);
     if (iattr != NULL) {
         int valid;
         bpf_probe_read(&valid, sizeof(valid), &iattr->ia_valid);
@@ -37,23 +35,34 @@ int kprobe__security_inode_setattr(struct pt_regs *ctx) {
 
     syscall->setattr.dentry = dentry;
 
+    // keep track of the owner and mode of the file before they are changed
+    fill_file_metadata(dentry, &syscall->setattr.metadata);
+
     // the mount id of path_key is resolved by kprobe/mnt_want_write. It is already set by the time we reach this probe.
     syscall->setattr.path_key.ino = get_dentry_ino(syscall->setattr.dentry);
     syscall->setattr.path_key.path_id = get_path_id(0);
 
     u64 event_type = 0;
+    int approved = 1;
     switch (syscall->type) {
         case SYSCALL_UTIME:
             event_type = EVENT_UTIME;
             break;
         case SYSCALL_CHMOD:
             event_type = EVENT_CHMOD;
+            approved = approve_chmod(syscall);
             break;
         case SYSCALL_CHOWN:
             event_type = EVENT_CHOWN;
+            approved = approve_chown(syscall);
             break;
     }
 
+    if (!approved) {
+        pop_syscall(syscall->type);
+        return 0;
+    }
+
     int ret = resolve_dentry(syscall->setattr.dentry, syscall->setattr.path_key, syscall->policy.mode != NO_FILTER ? event_type : 0);
     if (ret == DENTRY_DISCARDED) {
         pop_syscall(syscall->type);
@@ -10,13 +10,18 @@ struct setxattr_event_t {
     struct syscall_t syscall;
     struct file_t file;
     char name[MAX_XATTR_NAME_LEN];
+    u32 value_size;
+    u32 padding;
+    char value[MAX_XATTR_VALUE_LEN];
 };
 
-int __attribute__((always_inline)) trace__sys_setxattr(const char *xattr_name) {
+int __attribute__((always_inline)) trace__sys_setxattr(const char *xattr_name, const void *value, size_t size) {
     struct syscall_cache_t syscall = {
         .type = SYSCALL_SETXATTR,
         .setxattr = {
             .name = xattr_name,
+            .value = value,
+            .size = size,
         }
     };
 
@@ -29,16 +34,16 @@ int __attribute__((always_inline)) trace__sys_setxattr(const char *xattr_name) {
     return 0;
 }
 
-SYSCALL_KPROBE2(setxattr, const char *, filename, const char *, name) {
-    return trace__sys_setxattr(name);
+SYSCALL_KPROBE4(setxattr, const char *, filename, const char *, name, const void *, value, size_t, size) {
+    return trace__sys_setxattr(name, value, size);
 }
 
-SYSCALL_KPROBE2(lsetxattr, const char *, filename, const char *, name) {
-    return trace__sys_setxattr(name);
+SYSCALL_KPROBE4(lsetxattr, const char *, filename, const char *, name, const void *, value, size_t, size) {
+    return trace__sys_setxattr(name, value, size);
 }
 
-SYSCALL_KPROBE2(fsetxattr, int, fd, const char *, name) {
-    return trace__sys_setxattr(name);
+SYSCALL_KPROBE4(fsetxattr, int, fd, const char *, name, const void *, value, size_t, size) {
+    return trace__sys_setxattr(name, value, size);
 }
 
 int __attribute__((always_inline)) trace__sys_removexattr(const char *xattr_name) {
@@ -129,14 +134,27 @@ int __attribute__((always_inline)) trace__sys_setxattr_ret(struct pt_regs *ctx,
             .overlay_numlower = get_overlay_numlower(syscall->setxattr.dentry),
             .path_id = syscall->setxattr.path_key.path_id,
         },
+        .padding = 0,
     };
 
     // copy xattr name
     bpf_probe_read_str(&event.name, MAX_XATTR_NAME_LEN, (void*) syscall->setxattr.name);
 
+    // copy the beginning of the xattr value, value_size holds the actual size of the value
+    event.value_size = syscall->setxattr.size;
+    u32 len = syscall->setxattr.size;
+    if (len > MAX_XATTR_VALUE_LEN) {
+        len = MAX_XATTR_VALUE_LEN;
+    }
+    if (len > 0) {
+        bpf_probe_read(&event.value, len, (void*) syscall->setxattr.value);
+    }
+
     struct proc_cache_t *entry = fill_process_data(&event.process);
     fill_container_data(entry, &event.container);
 
+    fill_file_generation(event.file);
+
     send_event(ctx, event);
 
     return 0;
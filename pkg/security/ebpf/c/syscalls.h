@@ -3,6 +3,7 @@
 
 #include "filters.h"
 #include "process.h"
+#include "rate_limiter.h"
 
 #define FSTYPE_LEN 16
 
@@ -23,6 +24,8 @@ struct syscall_cache_t {
             struct dentry *dentry;
             struct path_key_t path_key;
             u64 real_inode;
+            const char *filename;
+            struct path_key_t link_key;
         } open;
 
         struct {
@@ -38,6 +41,7 @@ struct syscall_cache_t {
             int overlay_numlower;
             int flags;
             u64 real_inode;
+            struct file_metadata_t metadata;
         } unlink;
 
         struct {
@@ -53,6 +57,8 @@ struct syscall_cache_t {
             struct dentry *real_src_dentry;
             struct path_key_t target_key;
             int src_overlay_numlower;
+            int approved;
+            u64 replaced_inode;
         } rename;
 
         struct {
@@ -71,6 +77,7 @@ struct syscall_cache_t {
                 };
             };
             u64 real_inode;
+            struct file_metadata_t metadata;
         } setattr;
 
         struct {
@@ -98,6 +105,8 @@ struct syscall_cache_t {
             struct dentry *dentry;
             struct path_key_t path_key;
             const char *name;
+            const void *value;
+            u64 size;
             u64 real_inode;
         } setxattr;
     };
@@ -114,7 +123,11 @@ struct bpf_map_def SEC("maps/syscalls") syscalls = {
 
 // cache_syscall checks the event policy in order to see if the syscall struct can be cached
 void __attribute__((always_inline)) cache_syscall(struct syscall_cache_t *syscall, u64 event_type) {
-    struct policy_t *policy = bpf_map_lookup_elem(&filter_policy, &event_type);
+    struct policy_t *policy = get_container_policy(event_type);
+    if (!policy) {
+        policy = bpf_map_lookup_elem(&filter_policy, &event_type);
+    }
+
     if (policy) {
         syscall->policy.mode = policy->mode;
         syscall->policy.flags = policy->flags;
@@ -122,6 +135,12 @@ void __attribute__((always_inline)) cache_syscall(struct syscall_cache_t *syscal
         syscall->policy.mode = NO_FILTER;
     }
 
+    // the name of the process is known before any path is resolved, all the events of an approved process are passed
+    // to user space. The syscall is still cached so that the inodes it alters are invalidated
+    if (syscall->policy.mode == DENY && (syscall->policy.flags & COMM) > 0 && approved_by_comm(event_type)) {
+        syscall->policy.mode = ACCEPT;
+    }
+
 #ifdef DEBUG
         bpf_printk("cache/syscall policy for %d is %d\n", event_type, syscall->policy.mode);
 #endif
@@ -2,6 +2,7 @@
 #define _UMOUNT_H_
 
 #include "syscalls.h"
+#include "mount_filter.h"
 
 struct umount_event_t {
     struct kevent_t event;
@@ -9,7 +10,7 @@ struct umount_event_t {
     struct container_context_t container;
     struct syscall_t syscall;
     int mount_id;
-
+    u32 discarded;
 };
 
 SYSCALL_KPROBE0(umount) {
@@ -41,8 +42,12 @@ SYSCALL_KRETPROBE(umount) {
         .mount_id = get_vfsmount_mount_id(syscall->umount.vfs),
     };
 
-    struct proc_cache_t *entry = fill_process_data(&event.process);
-    fill_container_data(entry, &event.container);
+    // the umount event of a discarded mount point is still sent to keep the mount cache in sync
+    event.discarded = discarded_by_mount_id(event.mount_id);
+    if (!event.discarded) {
+        struct proc_cache_t *entry = fill_process_data(&event.process);
+        fill_container_data(entry, &event.container);
+    }
 
     send_mountpoints_events(ctx, event);
 
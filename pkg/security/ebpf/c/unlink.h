@@ -1,19 +1,42 @@
 #ifndef _UNLINK_H_
 #define _UNLINK_H_
 
+#include "filters.h"
 #include "syscalls.h"
 #include "process.h"
 
+struct bpf_map_def SEC("maps/unlink_basename_approvers") unlink_basename_approvers = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = BASENAME_FILTER_SIZE,
+    .value_size = sizeof(struct filter_t),
+    .max_entries = 255,
+    .pinning = 0,
+    .namespace = "",
+};
+
 struct unlink_event_t {
     struct kevent_t event;
     struct process_context_t process;
     struct container_context_t container;
     struct syscall_t syscall;
     struct file_t file;
+    struct file_metadata_t metadata;
     u32 flags;
     u32 padding;
 };
 
+int __attribute__((always_inline)) approve_unlink(struct syscall_cache_t *syscall, struct dentry *dentry) {
+    // the directories removed by unlinkat are reported as rmdir events, the unlink approvers don't apply to them
+    if (syscall->policy.mode != DENY || (syscall->unlink.flags & AT_REMOVEDIR) > 0)
+        return 1;
+
+    if ((syscall->policy.flags & BASENAME) > 0 && approved_by_basename(&unlink_basename_approvers, FILTER_UNLINK_BASENAME_APPROVERS, dentry) &&
+        approved_by_inode(EVENT_UNLINK, dentry, syscall->unlink.path_key.mount_id))
+        return 1;
+
+    return 0;
+}
+
 int __attribute__((always_inline)) trace__sys_unlink(int flags) {
     struct syscall_cache_t syscall = {
         .type = SYSCALL_UNLINK,
@@ -35,15 +58,12 @@ SYSCALL_KPROBE3(unlinkat, int, dirfd, const char*, filename, int, flags) {
     return trace__sys_unlink(flags);
 }
 
-SEC("kprobe/vfs_unlink")
-int kprobe__vfs_unlink(struct pt_regs *ctx) {
+HOOK_ENTRY3(vfs_unlink, struct inode *, dir, struct dentry *, dentry, struct inode **, delegated_inode) {
     struct syscall_cache_t *syscall = peek_syscall(SYSCALL_UNLINK);
     if (!syscall)
         return 0;
 
     // we resolve all the information before the file is actually removed
-    struct dentry *dentry = (struct dentry *) PT_REGS_PARM2(ctx);
-
     u64 inode = get_dentry_ino(dentry);
 
     // ensure that we invalidate all the layers
@@ -57,11 +77,12 @@ int kprobe__vfs_unlink(struct pt_regs *ctx) {
 
     syscall->unlink.path_key.ino = inode;
     syscall->unlink.overlay_numlower = get_overlay_numlower(dentry);
+    fill_file_metadata(dentry, &syscall->unlink.metadata);
 
     if (!syscall->unlink.path_key.path_id)
         syscall->unlink.path_key.path_id = get_path_id(1);
 
-    if (discarded_by_process(syscall->policy.mode, EVENT_UNLINK)) {
+    if (discarded_by_process(syscall->policy.mode, EVENT_UNLINK) || !approve_unlink(syscall, dentry)) {
         pop_syscall(SYSCALL_UNLINK);
 
         return 0;
@@ -107,12 +128,15 @@ int __attribute__((always_inline)) trace__sys_unlink_ret(struct pt_regs *ctx) {
                 .overlay_numlower = syscall->unlink.overlay_numlower,
                 .path_id = syscall->unlink.path_key.path_id,
             },
+            .metadata = syscall->unlink.metadata,
             .flags = syscall->unlink.flags,
         };
 
         struct proc_cache_t *entry = fill_process_data(&event.process);
         fill_container_data(entry, &event.container);
 
+        fill_file_generation(event.file);
+
         send_event(ctx, event);
     }
 
@@ -98,6 +98,8 @@ int __attribute__((always_inline)) trace__sys_utimes_ret(struct pt_regs *ctx) {
 
     // dentry resolution in setattr.h
 
+    fill_file_generation(event.file);
+
     send_event(ctx, event);
 
     return 0;
@@ -54,11 +54,20 @@ func NewDefaultOptions() manager.Options {
 	}
 }
 
-// NewRuntimeSecurityManager returns a new instance of the runtime security module manager
+// NewRuntimeSecurityManager returns a new instance of the runtime security module manager, in charge of the probes of
+// the core family along with the maps and the perf maps of all the families
 func NewRuntimeSecurityManager() *manager.Manager {
 	return &manager.Manager{
-		Probes:   probes.AllProbes(),
+		Probes:   probes.FamilyProbes(probes.CoreFamily),
 		Maps:     probes.AllMaps(),
 		PerfMaps: probes.AllPerfMaps(),
 	}
 }
+
+// NewRuntimeSecurityFamilyManager returns a new instance of the manager of the probes of the given family, its maps
+// being the ones of the runtime security module manager
+func NewRuntimeSecurityFamilyManager(family string) *manager.Manager {
+	return &manager.Manager{
+		Probes: probes.FamilyProbes(family),
+	}
+}
@@ -9,41 +9,87 @@ package probes
 
 import "github.com/DataDog/ebpf/manager"
 
+// Families of probes, each family being loaded, started and stopped by its own manager
+const (
+	// CoreFamily holds the probes the resolvers rely on whatever the events, its manager holds the maps of all the
+	// families
+	CoreFamily = "core"
+	// ProcessFamily holds the probes of the process events
+	ProcessFamily = "process"
+	// FilesystemFamily holds the probes of the file and mount events
+	FilesystemFamily = "filesystem"
+)
+
+// ProbeFamilies lists the families of probes, in the order their managers are started
+var ProbeFamilies = []string{CoreFamily, ProcessFamily, FilesystemFamily}
+
 // allProbes contain the list of all the probes of the runtime security module
 var allProbes []*manager.Probe
 
+// familyProbes contain the probes of the runtime security module per family
+var familyProbes map[string][]*manager.Probe
+
 // AllProbes returns the list of all the probes of the runtime security module
 func AllProbes() []*manager.Probe {
 	if len(allProbes) > 0 {
 		return allProbes
 	}
 
-	allProbes = append(allProbes, getAttrProbes()...)
-	allProbes = append(allProbes, getExecProbes()...)
-	allProbes = append(allProbes, getLinkProbe()...)
-	allProbes = append(allProbes, getMkdirProbes()...)
-	allProbes = append(allProbes, getMountProbes()...)
-	allProbes = append(allProbes, getOpenProbes()...)
-	allProbes = append(allProbes, getRenameProbes()...)
-	allProbes = append(allProbes, getRmdirProbe()...)
-	allProbes = append(allProbes, sharedProbes...)
-	allProbes = append(allProbes, getUnlinkProbes()...)
-	allProbes = append(allProbes, getXattrProbes()...)
-
-	allProbes = append(allProbes,
+	for _, family := range ProbeFamilies {
+		allProbes = append(allProbes, FamilyProbes(family)...)
+	}
+	return allProbes
+}
+
+// FamilyProbes returns the list of the probes of the given family
+func FamilyProbes(family string) []*manager.Probe {
+	if familyProbes != nil {
+		return familyProbes[family]
+	}
+
+	var filesystemProbes []*manager.Probe
+	filesystemProbes = append(filesystemProbes, getAttrProbes()...)
+	filesystemProbes = append(filesystemProbes, getLinkProbe()...)
+	filesystemProbes = append(filesystemProbes, getMkdirProbes()...)
+	filesystemProbes = append(filesystemProbes, getMountProbes()...)
+	filesystemProbes = append(filesystemProbes, getOpenProbes()...)
+	filesystemProbes = append(filesystemProbes, getRenameProbes()...)
+	filesystemProbes = append(filesystemProbes, getRmdirProbe()...)
+	filesystemProbes = append(filesystemProbes, sharedProbes...)
+	filesystemProbes = append(filesystemProbes, getUnlinkProbes()...)
+	filesystemProbes = append(filesystemProbes, getXattrProbes()...)
+
+	familyProbes = map[string][]*manager.Probe{
+		CoreFamily:       getCoreProbes(),
+		ProcessFamily:    getExecProbes(),
+		FilesystemFamily: filesystemProbes,
+	}
+	return familyProbes[family]
+}
+
+// getCoreProbes returns the probes of the core family
+func getCoreProbes() []*manager.Probe {
+	return []*manager.Probe{
 		// Syscall monitor
-		&manager.Probe{
+		{
 			UID:     SecurityAgentUID,
 			Section: "tracepoint/raw_syscalls/sys_enter",
 		},
 		// Snapshot probe
-		&manager.Probe{
+		{
 			UID:     SecurityAgentUID,
 			Section: "kretprobe/get_task_exe_file",
 		},
-	)
-
-	return allProbes
+		// eRPC probes
+		{
+			UID:     SecurityAgentUID,
+			Section: "kprobe/do_vfs_ioctl",
+		},
+		{
+			UID:     SecurityAgentUID,
+			Section: "tracepoint/syscalls/sys_enter_ioctl",
+		},
+	}
 }
 
 // AllMaps returns the list of maps of the runtime security module
@@ -52,19 +98,61 @@ func AllMaps() []*manager.Map {
 		// Filters
 		{Name: "filter_policy"},
 		{Name: "inode_discarders"},
+		{Name: "inode_approvers"},
 		{Name: "pid_discarders"},
+		{Name: "container_filter_policy"},
+		{Name: "comm_approvers"},
+		{Name: "filter_stats"},
+		{Name: "container_event_types"},
+		{Name: "mount_fstype_discarders"},
+		{Name: "mount_id_discarders"},
+		{Name: "pre_eval_config"},
+		{Name: "pre_eval_rules"},
+		{Name: "prefix_approvers"},
+		{Name: "pre_eval_progs"},
+		{Name: "open_pre_eval_events"},
+		{Name: "open_link_paths"},
+		{Name: "probe_paused"},
+		// Rate limiter tables
+		{Name: "rate_limiter_config"},
+		{Name: "rate_limiters"},
+		{Name: "container_rate_limiters"},
+		{Name: "rate_limiter_drops"},
+		// Event stream tables
+		{Name: "events_ringbuf"},
+		{Name: "mountpoints_events_ringbuf"},
+		{Name: "ring_buffer_lost"},
 		// Dentry resolver table
 		{Name: "pathnames"},
+		{Name: "path_generation"},
+		{Name: "dentry_refs"},
+		{Name: "dr_erpc_buffers"},
 		// Snapshot table
 		{Name: "inode_info_cache"},
+		{Name: "ovl_copy_ups"},
 		// Open tables
 		{Name: "open_basename_approvers"},
 		{Name: "open_flags_approvers"},
+		// Unlink tables
+		{Name: "unlink_basename_approvers"},
+		// Rename tables
+		{Name: "rename_basename_approvers"},
+		// Chmod tables
+		{Name: "chmod_basename_approvers"},
+		{Name: "chmod_mode_approvers"},
+		// Chown tables
+		{Name: "chown_basename_approvers"},
+		{Name: "chown_uid_approvers"},
+		{Name: "chown_gid_approvers"},
+		// Mkdir tables
+		{Name: "mkdir_basename_approvers"},
+		{Name: "mkdir_mode_approvers"},
 		// Exec tables
 		{Name: "proc_cache"},
 		{Name: "pid_cookie"},
 		// Mount tables
 		{Name: "mount_id_offset"},
+		{Name: "mntns_inum_offset"},
 		// Syscall monitor tables
 		{Name: "noisy_processes_buffer"},
 		{Name: "noisy_processes_fb"},
@@ -72,6 +160,28 @@ func AllMaps() []*manager.Map {
 	}
 }
 
+// PinnedMaps lists the maps pinned to bpffs when the map pinning is enabled, their entries outliving a restart of the
+// runtime security module. Their layout is versioned by PinnedMapsSchemaVersion
+var PinnedMaps = []string{
+	// Exec tables
+	"proc_cache",
+	"pid_cookie",
+	// Dentry resolver tables
+	"pathnames",
+	"path_generation",
+	// Mount tables
+	"mount_id_discarders",
+	// Filters
+	"inode_discarders",
+	"pid_discarders",
+	"mount_fstype_discarders",
+}
+
+// PinnedMapsSchemaVersion is the version of the layout of the entries of the pinned maps. It has to be bumped whenever
+// the key or the value of one of them changes without changing its size, the maps pinned with another version being
+// discarded
+const PinnedMapsSchemaVersion = 1
+
 // AllPerfMaps returns the list of perf maps of the runtime security module
 func AllPerfMaps() []*manager.PerfMap {
 	return []*manager.PerfMap{
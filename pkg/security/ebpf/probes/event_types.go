@@ -23,12 +23,17 @@ var SelectorsPerEventType = map[eval.EventType][]manager.ProbesSelector{
 
 	// The following events will always be activated, regardless of the rules loaded
 	"*": {
+		// eRPC probe
+		&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/do_vfs_ioctl"}},
+
 		// Exec probes
 		&manager.AllOf{Selectors: []manager.ProbesSelector{
 			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "tracepoint/sched/sched_process_fork"}},
 			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/do_exit"}},
 			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/do_close_on_exec"}},
 			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/exit_itimers"}},
+			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kretprobe/audit_set_loginuid"}},
+			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/security_bprm_check"}},
 		}},
 		&manager.OneOf{Selectors: []manager.ProbesSelector{
 			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/cgroup_procs_write"}},
@@ -40,10 +45,10 @@ var SelectorsPerEventType = map[eval.EventType][]manager.ProbesSelector{
 		}},
 		&manager.OneOf{Selectors: []manager.ProbesSelector{
 			&manager.AllOf{Selectors: ExpandSyscallProbesSelector(
-				manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "execve"}, Entry),
+				manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "execve"}, Entry, true),
 			},
 			&manager.AllOf{Selectors: ExpandSyscallProbesSelector(
-				manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "execveat"}, Entry),
+				manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "execveat"}, Entry, true),
 			},
 		}},
 
@@ -75,6 +80,18 @@ var SelectorsPerEventType = map[eval.EventType][]manager.ProbesSelector{
 			manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "renameat2"}, EntryAndExit),
 		},
 
+		// Link probes, the discarders of the linked inodes are invalidated
+		&manager.AllOf{Selectors: []manager.ProbesSelector{
+			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/vfs_link"}},
+			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/filename_create"}},
+		}},
+		&manager.AllOf{Selectors: ExpandSyscallProbesSelector(
+			manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "link"}, EntryAndExit),
+		},
+		&manager.AllOf{Selectors: ExpandSyscallProbesSelector(
+			manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "linkat"}, EntryAndExit),
+		},
+
 		// unlink rmdir probes
 		&manager.AllOf{Selectors: []manager.ProbesSelector{
 			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/mnt_want_write"}},
@@ -109,6 +126,20 @@ var SelectorsPerEventType = map[eval.EventType][]manager.ProbesSelector{
 				&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/do_dentry_open"}},
 			}},
 		}},
+
+		// Overlayfs copy-up probes, the discarders of the copied up files aren't invalidated on the kernels without
+		// ovl_copy_up_flags
+		&BestEffort{Selectors: []manager.ProbesSelector{
+			&manager.AllOf{Selectors: []manager.ProbesSelector{
+				&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/ovl_copy_up_flags"}},
+				&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kretprobe/ovl_copy_up_flags"}},
+			}},
+		}},
+
+		// Symbolic link probe, the opens don't report the links they follow on the kernels where pick_link is inlined
+		&BestEffort{Selectors: []manager.ProbesSelector{
+			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/pick_link"}},
+		}},
 	},
 
 	// List of probes to activate to capture chmod events
@@ -161,20 +192,6 @@ var SelectorsPerEventType = map[eval.EventType][]manager.ProbesSelector{
 		},
 	},
 
-	// List of probes to activate to capture link events
-	"link": {
-		&manager.AllOf{Selectors: []manager.ProbesSelector{
-			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/vfs_link"}},
-			&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/filename_create"}},
-		}},
-		&manager.AllOf{Selectors: ExpandSyscallProbesSelector(
-			manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "link"}, EntryAndExit),
-		},
-		&manager.AllOf{Selectors: ExpandSyscallProbesSelector(
-			manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "linkat"}, EntryAndExit),
-		},
-	},
-
 	// List of probes to activate to capture mkdir events
 	"mkdir": {
 		&manager.AllOf{Selectors: []manager.ProbesSelector{
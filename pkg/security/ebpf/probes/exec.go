@@ -21,6 +21,10 @@ var execProbes = []*manager.Probe{
 		UID:     SecurityAgentUID,
 		Section: "kprobe/do_exit",
 	},
+	{
+		UID:     SecurityAgentUID,
+		Section: "tracepoint/sched/sched_process_exit",
+	},
 	{
 		UID:     SecurityAgentUID,
 		Section: "kprobe/cgroup_procs_write",
@@ -45,17 +49,25 @@ var execProbes = []*manager.Probe{
 		UID:     SecurityAgentUID,
 		Section: "kprobe/do_close_on_exec",
 	},
+	{
+		UID:     SecurityAgentUID,
+		Section: "kretprobe/audit_set_loginuid",
+	},
+	{
+		UID:     SecurityAgentUID,
+		Section: "kprobe/security_bprm_check",
+	},
 }
 
 func getExecProbes() []*manager.Probe {
 	execProbes = append(execProbes, ExpandSyscallProbes(&manager.Probe{
 		UID:             SecurityAgentUID,
 		SyscallFuncName: "execve",
-	}, Entry)...)
+	}, Entry, true)...)
 	execProbes = append(execProbes, ExpandSyscallProbes(&manager.Probe{
 		UID:             SecurityAgentUID,
 		SyscallFuncName: "execveat",
-	}, Entry)...)
+	}, Entry, true)...)
 
 	return execProbes
 }
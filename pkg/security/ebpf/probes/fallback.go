@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probes
+
+// TracepointFallbacks lists the tracepoints attached in place of the kprobes of internal kernel functions, renamed
+// or inlined on some kernels. A tracepoint is only selected when the kernel function of its kprobe can't be found
+var TracepointFallbacks = map[string]string{
+	"kprobe/do_exit":      "tracepoint/sched/sched_process_exit",
+	"kprobe/do_vfs_ioctl": "tracepoint/syscalls/sys_enter_ioctl",
+}
@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probes
+
+// FEntryFunctions lists the kernel functions whose kprobe or kretprobe has a fentry or fexit counterpart, along with
+// the number of arguments of the function expected by the fentry and fexit programs. A function whose prototype
+// doesn't match in the kernel BTF keeps its kprobe
+var FEntryFunctions = map[string]int{
+	"vfs_unlink":             3,
+	"vfs_mkdir":              3,
+	"vfs_link":               4,
+	"security_inode_rmdir":   2,
+	"security_inode_setattr": 2,
+	"ovl_dentry_upper":       1,
+	"ovl_d_real":             2,
+}
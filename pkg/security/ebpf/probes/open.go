@@ -29,6 +29,18 @@ var openProbes = []*manager.Probe{
 		UID:     SecurityAgentUID,
 		Section: "kprobe/do_dentry_open",
 	},
+	{
+		UID:     SecurityAgentUID,
+		Section: "kprobe/pick_link",
+	},
+	{
+		UID:     SecurityAgentUID,
+		Section: "kprobe/ovl_copy_up_flags",
+	},
+	{
+		UID:     SecurityAgentUID,
+		Section: "kretprobe/ovl_copy_up_flags",
+	},
 }
 
 func getOpenProbes() []*manager.Probe {
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probes
+
+import (
+	"strings"
+
+	"github.com/DataDog/ebpf/manager"
+)
+
+// BestEffort is a selector activating optional probes: they are attached when the kernel allows it, their failure
+// doesn't fail the activation of the other probes
+type BestEffort struct {
+	Selectors []manager.ProbesSelector
+}
+
+// GetProbesIdentificationPairList returns the list of probes that this selector activates
+func (be *BestEffort) GetProbesIdentificationPairList() []manager.ProbeIdentificationPair {
+	var l []manager.ProbeIdentificationPair
+	for _, selector := range be.Selectors {
+		l = append(l, selector.GetProbesIdentificationPairList()...)
+	}
+	return l
+}
+
+// RunValidator never fails, the optional probes are allowed not to run
+func (be *BestEffort) RunValidator(m *manager.Manager) error {
+	return nil
+}
+
+func (be *BestEffort) String() string {
+	var strs []string
+	for _, id := range be.GetProbesIdentificationPairList() {
+		strs = append(strs, id.String())
+	}
+	return strings.Join(strs, ", ")
+}
+
+// EditProbeIdentificationPair changes all the selectors looking for the old ProbeIdentificationPair so that they
+// now select the new one
+func (be *BestEffort) EditProbeIdentificationPair(old manager.ProbeIdentificationPair, new manager.ProbeIdentificationPair) {
+	for _, selector := range be.Selectors {
+		selector.EditProbeIdentificationPair(old, new)
+	}
+}
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probes
+
+import (
+	"testing"
+
+	"github.com/DataDog/ebpf/manager"
+)
+
+func TestBestEffort(t *testing.T) {
+	selectors := []manager.ProbesSelector{
+		&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kprobe/ovl_copy_up_flags"}},
+		&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: SecurityAgentUID, Section: "kretprobe/ovl_copy_up_flags"}},
+	}
+	bestEffort := &BestEffort{Selectors: []manager.ProbesSelector{&manager.AllOf{Selectors: selectors}}}
+
+	// the probes are activated
+	if ids := bestEffort.GetProbesIdentificationPairList(); len(ids) != 2 {
+		t.Errorf("expected the optional probes to be activated, got %v", ids)
+	}
+
+	// none of the probes is running
+	m := &manager.Manager{}
+	if err := (&manager.AllOf{Selectors: selectors}).RunValidator(m); err == nil {
+		t.Fatal("expected the probes not to be running")
+	}
+	if err := bestEffort.RunValidator(m); err != nil {
+		t.Errorf("the optional probes shouldn't fail the activation: %s", err)
+	}
+}
+
+// optionalSections records whether the sections of the given selectors are selected outside of a BestEffort selector
+func optionalSections(selectors []manager.ProbesSelector, optional bool, sections map[string]bool) {
+	for _, selector := range selectors {
+		switch s := selector.(type) {
+		case *BestEffort:
+			optionalSections(s.Selectors, true, sections)
+		case *manager.OneOf:
+			optionalSections(s.Selectors, optional, sections)
+		case *manager.AllOf:
+			optionalSections(s.Selectors, optional, sections)
+		default:
+			for _, id := range s.GetProbesIdentificationPairList() {
+				sections[id.Section] = sections[id.Section] || !optional
+			}
+		}
+	}
+}
+
+func TestCopyUpProbesOptional(t *testing.T) {
+	required := make(map[string]bool)
+	optionalSections(SelectorsPerEventType["*"], false, required)
+
+	for _, section := range []string{"kprobe/ovl_copy_up_flags", "kretprobe/ovl_copy_up_flags"} {
+		if isRequired, found := required[section]; !found || isRequired {
+			t.Errorf("expected %s to be an optional probe", section)
+		}
+	}
+
+	// the overlayfs path resolution still doesn't depend on the copy-up probes
+	if !required["kretprobe/ovl_d_real"] {
+		t.Error("expected kretprobe/ovl_d_real to be selected")
+	}
+}
+
+func TestLinkProbesAlwaysSelected(t *testing.T) {
+	required := make(map[string]bool)
+	optionalSections(SelectorsPerEventType["*"], false, required)
+
+	// the discarders of the linked inodes are invalidated even when no link rule is loaded
+	for _, section := range []string{"kprobe/vfs_link", "kprobe/filename_create"} {
+		if !required[section] {
+			t.Errorf("expected %s to be always selected", section)
+		}
+	}
+
+	if _, exists := SelectorsPerEventType["link"]; exists {
+		t.Error("the link probes shouldn't be selected per event type")
+	}
+}
+
+func TestExpandSyscallSectionsCompat(t *testing.T) {
+	arch, prefix, ia32Prefix := RuntimeArch, syscallPrefix, ia32SyscallPrefix
+	defer func() {
+		RuntimeArch, syscallPrefix, ia32SyscallPrefix = arch, prefix, ia32Prefix
+	}()
+
+	RuntimeArch, syscallPrefix, ia32SyscallPrefix = "x64", "__x64_sys_", "__ia32_"
+
+	tests := []struct {
+		compat   []bool
+		expected string
+	}{
+		{expected: "kprobe/__ia32_sys_open"},
+		{compat: []bool{false}, expected: "kprobe/__ia32_sys_open"},
+		{compat: []bool{true}, expected: "kprobe/__ia32_compat_sys_open"},
+	}
+
+	for _, test := range tests {
+		sections := expandSyscallSections("open", Entry, test.compat...)
+		if len(sections) != 2 || sections[0] != "kprobe/__x64_sys_open" || sections[1] != test.expected {
+			t.Errorf("expected the sections of open with compat %v to end with %s, got %v", test.compat, test.expected, sections)
+		}
+	}
+}
@@ -8,7 +8,9 @@
 package probes
 
 import (
+	"bufio"
 	"bytes"
+	"os"
 	"strings"
 
 	"golang.org/x/sys/unix"
@@ -28,26 +30,64 @@ func resolveRuntimeArch() {
 	switch string(uname.Machine[:bytes.IndexByte(uname.Machine[:], 0)]) {
 	case "x86_64":
 		RuntimeArch = "x64"
+	case "aarch64":
+		RuntimeArch = "arm64"
 	default:
 		RuntimeArch = "ia32"
 	}
 }
 
+// kallsymsPath is the path of the symbols of the kernel
+const kallsymsPath = "/proc/kallsyms"
+
+// hasKernelSymbol returns whether the kernel exports the given symbol
+func hasKernelSymbol(symbol string) bool {
+	f, err := os.Open(kallsymsPath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) >= 3 && fields[2] == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSyscallFnName returns the kernel function of the given syscall. The eBPF library only looks up the syscall
+// wrappers of x86, the ones of arm64 are looked up here
+func GetSyscallFnName(name string) (string, error) {
+	if len(RuntimeArch) == 0 {
+		resolveRuntimeArch()
+	}
+
+	if RuntimeArch == "arm64" && hasKernelSymbol("__arm64_sys_"+name) {
+		return "__arm64_sys_" + name, nil
+	}
+	return manager.GetSyscallFnName(name)
+}
+
 // cache of the syscall prefix depending on kernel version
 var syscallPrefix string
 var ia32SyscallPrefix string
 
 func getSyscallPrefix() string {
 	if syscallPrefix == "" {
-		syscall, err := manager.GetSyscallFnName("open")
+		syscall, err := GetSyscallFnName("open")
 		if err != nil {
 			panic(err)
 		}
 		syscallPrefix = strings.TrimSuffix(syscall, "open")
-		if syscallPrefix != "SyS_" {
-			ia32SyscallPrefix = "__ia32_"
-		} else {
+		switch {
+		case syscallPrefix == "SyS_":
 			ia32SyscallPrefix = "compat_"
+		case RuntimeArch == "arm64":
+			ia32SyscallPrefix = "__arm64_"
+		default:
+			ia32SyscallPrefix = "__ia32_"
 		}
 	}
 
@@ -79,13 +119,22 @@ func expandKprobe(hookpoint string, flag int) []string {
 
 func expandSyscallSections(syscallName string, flag int, compat ...bool) []string {
 	sections := expandKprobe(getSyscallFnName(syscallName), flag)
+	isCompat := len(compat) > 0 && compat[0]
 
-	if RuntimeArch == "x64" {
-		if len(compat) > 0 && syscallPrefix != "SyS_" {
+	switch RuntimeArch {
+	case "x64":
+		if isCompat && syscallPrefix != "SyS_" {
 			sections = append(sections, expandKprobe(getCompatSyscallFnName(syscallName), flag)...)
 		} else {
 			sections = append(sections, expandKprobe(getIA32SyscallFnName(syscallName), flag)...)
 		}
+	case "arm64":
+		// the 32 bits processes share the syscall functions of the 64 bits processes, except the compat syscalls
+		if isCompat && syscallPrefix != "SyS_" {
+			sections = append(sections, expandKprobe(getCompatSyscallFnName(syscallName), flag)...)
+		} else if isCompat {
+			sections = append(sections, expandKprobe(getIA32SyscallFnName(syscallName), flag)...)
+		}
 	}
 
 	return sections
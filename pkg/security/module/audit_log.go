@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-go/statsd"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// auditLogHandlerName is the name of the event handler of the audit log in verbose mode
+	auditLogHandlerName = "audit_log"
+	// auditLogQueueSize is the number of events queued to the audit log in verbose mode, the events it can't keep up
+	// with are dropped
+	auditLogQueueSize = 4096
+	// auditLogWriteQueueSize is the number of records queued to the writer of the audit log, the rule matches it can't
+	// keep up with are dropped
+	auditLogWriteQueueSize = 4096
+)
+
+// AuditLog writes the rule matches, and all the events in verbose mode, to a local newline delimited JSON file rotated
+// once it reaches its maximum size. It doesn't depend on the backend, for the air-gapped environments and the
+// forensic retention. The records are serialized while the event is handled and written by a goroutine of their own,
+// so that the writes and the rotations don't stall the event processing
+type AuditLog struct {
+	// https://github.com/golang/go/issues/36606
+	written int64
+	dropped int64
+	errors  int64
+
+	file    *utils.RotatingFile
+	verbose bool
+	queue   chan []byte
+	done    chan struct{}
+}
+
+// NewAuditLog returns a new audit log
+func NewAuditLog(cfg *config.Config) (*AuditLog, error) {
+	file, err := utils.NewRotatingFile(cfg.AuditLogPath, cfg.AuditLogMaxSize, cfg.AuditLogMaxFiles, cfg.AuditLogCompress)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &AuditLog{
+		file:    file,
+		verbose: cfg.AuditLogVerbose,
+		queue:   make(chan []byte, auditLogWriteQueueSize),
+		done:    make(chan struct{}),
+	}
+	go l.run()
+
+	return l, nil
+}
+
+func (l *AuditLog) run() {
+	defer close(l.done)
+
+	for data := range l.queue {
+		if _, err := l.file.Write(data); err != nil {
+			atomic.AddInt64(&l.errors, 1)
+			log.Debugf("failed to write to the audit log: %s", err)
+			continue
+		}
+		atomic.AddInt64(&l.written, 1)
+	}
+}
+
+// serialize returns the record of the given value, a line of JSON
+func (l *AuditLog) serialize(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		atomic.AddInt64(&l.errors, 1)
+		log.Debugf("failed to serialize the audit log record: %s", err)
+		return nil
+	}
+	return append(data, '\n')
+}
+
+// WriteRuleMatch queues the given serialized rule match. The data is shared with the other sinks, the record is a copy
+// of it. The matches are dropped when the writer can't keep up
+func (l *AuditLog) WriteRuleMatch(data []byte) {
+	record := make([]byte, 0, len(data)+1)
+	record = append(append(record, data...), '\n')
+
+	select {
+	case l.queue <- record:
+	default:
+		atomic.AddInt64(&l.dropped, 1)
+	}
+}
+
+// HandleEvent queues the given event, the audit log handles all the events in verbose mode. It runs in the goroutine
+// of the event handler of the audit log, the events it can't keep up with are dropped by the queue of the handler
+func (l *AuditLog) HandleEvent(event *sprobe.Event) {
+	if data := l.serialize(event); data != nil {
+		l.queue <- data
+	}
+}
+
+// SendStats sends statistics about the records written to the audit log, the ones dropped and the ones that failed to
+// be written, since the last call
+func (l *AuditLog) SendStats(client *statsd.Client) error {
+	if written := atomic.SwapInt64(&l.written, 0); written > 0 {
+		if err := client.Count(sprobe.MetricPrefix+".audit_log.written", written, nil, 1.0); err != nil {
+			return err
+		}
+	}
+	if dropped := atomic.SwapInt64(&l.dropped, 0); dropped > 0 {
+		if err := client.Count(sprobe.MetricPrefix+".audit_log.dropped", dropped, nil, 1.0); err != nil {
+			return err
+		}
+	}
+	if errors := atomic.SwapInt64(&l.errors, 0); errors > 0 {
+		if err := client.Count(sprobe.MetricPrefix+".audit_log.errors", errors, nil, 1.0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the audit log once the queued records are written
+func (l *AuditLog) Close() error {
+	close(l.queue)
+	<-l.done
+
+	return l.file.Close()
+}
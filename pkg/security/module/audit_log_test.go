@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.json")
+	l, err := NewAuditLog(&config.Config{AuditLogPath: path, AuditLogMaxSize: 1024 * 1024, AuditLogMaxFiles: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"rule1", "rule2"} {
+		_, data := serializeTestRuleMatch(t, id)
+		l.WriteRuleMatch(data)
+	}
+
+	// the queued records are written before the audit log is closed
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var match sprobe.RuleMatchSerializer
+		if err := json.Unmarshal(scanner.Bytes(), &match); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, match.RuleID)
+	}
+
+	if len(ids) != 2 || ids[0] != "rule1" || ids[1] != "rule2" {
+		t.Errorf("expected the matches to be written in order, got %v", ids)
+	}
+	if written := atomic.LoadInt64(&l.written); written != 2 {
+		t.Errorf("expected 2 records written, got %d", written)
+	}
+}
+
+func TestAuditLogDrops(t *testing.T) {
+	// the writer isn't started, the queue fills up
+	l := &AuditLog{queue: make(chan []byte, 1)}
+
+	_, data := serializeTestRuleMatch(t, "rule")
+	for i := 0; i < 3; i++ {
+		l.WriteRuleMatch(data)
+	}
+
+	if dropped := atomic.LoadInt64(&l.dropped); dropped != 2 {
+		t.Errorf("expected the matches the writer can't keep up with to be dropped, got %d drops", dropped)
+	}
+}
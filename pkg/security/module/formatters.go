@@ -0,0 +1,238 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	siemVendor  = "Datadog"
+	siemProduct = "Runtime Security"
+	// leefTimeFormat is the format of the LEEF devTime field, declared by the devTimeFormat field
+	leefTimeFormat     = "Jan 02 2006 15:04:05.000 MST"
+	leefTimeFormatJava = "MMM dd yyyy HH:mm:ss.SSS z"
+)
+
+// siemSeverities maps the severities of the rules to the severities of CEF and LEEF, from 0 to 10
+var siemSeverities = map[rules.Severity]int{
+	rules.SeverityInfo:     1,
+	rules.SeverityLow:      3,
+	rules.SeverityMedium:   5,
+	rules.SeverityHigh:     8,
+	rules.SeverityCritical: 10,
+}
+
+// siemField is a field of the extension of a CEF or LEEF record
+type siemField struct {
+	key   string
+	value string
+}
+
+// ruleMatchFormatter formats a rule match as the content of the messages forwarded to the SIEMs, data being the JSON
+// serialization of the match
+type ruleMatchFormatter func(match *sprobe.RuleMatchSerializer, data []byte, severity rules.Severity) ([]byte, error)
+
+// newRuleMatchFormatter returns the formatter of the given format, JSON being the default one
+func newRuleMatchFormatter(format string) ruleMatchFormatter {
+	switch format {
+	case config.RuleMatchFormatCEF:
+		return formatCEF
+	case config.RuleMatchFormatLEEF:
+		return formatLEEF
+	default:
+		return formatJSON
+	}
+}
+
+func formatJSON(match *sprobe.RuleMatchSerializer, data []byte, severity rules.Severity) ([]byte, error) {
+	return data, nil
+}
+
+func siemSeverity(severity rules.Severity) int {
+	if s, exists := siemSeverities[severity]; exists {
+		return s
+	}
+	return siemSeverities[rules.SeverityMedium]
+}
+
+// ruleMatchFiles returns the file of the event of a rule match, the new path of the renames and the new link of the
+// links, along with the path it replaces or links to, if any
+func ruleMatchFiles(event *sprobe.EventSerializer) (*sprobe.FileSerializer, *sprobe.FileSerializer) {
+	switch {
+	case event.File != nil:
+		return event.File, nil
+	case event.New != nil:
+		return event.New, event.Old
+	case event.Target != nil:
+		return event.Target, event.Source
+	default:
+		return nil, nil
+	}
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+	leefValueEscaper    = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	// the record is a single line whose attributes are separated by tabs, the header can't contain any
+	leefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\t", " ", "\n", " ", "\r", " ")
+)
+
+// cefFields maps the rule match to the standard fields of the CEF extension, the fields without equivalent being
+// custom strings and numbers along with their labels
+func cefFields(match *sprobe.RuleMatchSerializer) []siemField {
+	event := match.Event
+	fields := []siemField{
+		{key: "rt", value: strconv.FormatInt(event.Timestamp.UnixNano()/1e6, 10)},
+		{key: "externalId", value: event.ID},
+	}
+
+	if match.RuleVersion != "" {
+		fields = append(fields, siemField{key: "cs3Label", value: "rule_version"}, siemField{key: "cs3", value: match.RuleVersion})
+	}
+
+	if event.Syscall != nil {
+		fields = append(fields,
+			siemField{key: "cat", value: event.Syscall.Type},
+			siemField{key: "cn1Label", value: "retval"},
+			siemField{key: "cn1", value: strconv.FormatInt(event.Syscall.Retval, 10)})
+	}
+
+	if process := event.Process; process != nil {
+		fields = append(fields,
+			siemField{key: "spid", value: strconv.FormatUint(uint64(process.Pid), 10)},
+			siemField{key: "sproc", value: process.Name},
+			siemField{key: "suid", value: strconv.FormatUint(uint64(process.UID), 10)},
+			siemField{key: "cs4Label", value: "process_filename"},
+			siemField{key: "cs4", value: process.Filename})
+	}
+
+	if container := event.Container; container != nil {
+		fields = append(fields, siemField{key: "cs1Label", value: "container_id"}, siemField{key: "cs1", value: container.ID})
+		if container.ImageName != "" {
+			fields = append(fields, siemField{key: "cs2Label", value: "image_name"}, siemField{key: "cs2", value: container.ImageName})
+		}
+	}
+
+	if file, old := ruleMatchFiles(event); file != nil {
+		fields = append(fields,
+			siemField{key: "filePath", value: file.Filename},
+			siemField{key: "fname", value: path.Base(file.Filename)},
+			siemField{key: "fileId", value: strconv.FormatUint(file.Inode, 10)})
+		if file.Mode != nil {
+			fields = append(fields, siemField{key: "filePermission", value: fmt.Sprintf("%04o", *file.Mode)})
+		}
+		if old != nil {
+			fields = append(fields, siemField{key: "oldFilePath", value: old.Filename}, siemField{key: "oldFileName", value: path.Base(old.Filename)})
+		}
+	}
+
+	if mount := event.Mount; mount != nil {
+		fields = append(fields, siemField{key: "filePath", value: mount.MountPoint}, siemField{key: "fileType", value: mount.FSType})
+	}
+
+	return fields
+}
+
+// formatCEF formats the rule match as an ArcSight Common Event Format record
+func formatCEF(match *sprobe.RuleMatchSerializer, data []byte, severity rules.Severity) ([]byte, error) {
+	var buf bytes.Buffer
+	ruleID := cefHeaderEscaper.Replace(match.RuleID)
+	fmt.Fprintf(&buf, "CEF:0|%s|%s|%s|%s|%s|%d|",
+		siemVendor, siemProduct, cefHeaderEscaper.Replace(version.AgentVersion), ruleID, ruleID, siemSeverity(severity))
+
+	for i, field := range cefFields(match) {
+		if i > 0 {
+			buf.WriteRune(' ')
+		}
+		buf.WriteString(field.key + "=" + cefExtensionEscaper.Replace(field.value))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// leefFields maps the rule match to the predefined fields of LEEF, the other ones being custom fields
+func leefFields(match *sprobe.RuleMatchSerializer, severity rules.Severity) []siemField {
+	event := match.Event
+	fields := []siemField{
+		{key: "devTime", value: event.Timestamp.UTC().Format(leefTimeFormat)},
+		{key: "devTimeFormat", value: leefTimeFormatJava},
+		{key: "sev", value: strconv.Itoa(siemSeverity(severity))},
+		{key: "eventId", value: event.ID},
+	}
+
+	if match.RuleVersion != "" {
+		fields = append(fields, siemField{key: "ruleVersion", value: match.RuleVersion})
+	}
+
+	if event.Syscall != nil {
+		fields = append(fields, siemField{key: "cat", value: event.Syscall.Type}, siemField{key: "retval", value: strconv.FormatInt(event.Syscall.Retval, 10)})
+	}
+
+	if process := event.Process; process != nil {
+		fields = append(fields,
+			siemField{key: "pid", value: strconv.FormatUint(uint64(process.Pid), 10)},
+			siemField{key: "processName", value: process.Name},
+			siemField{key: "processPath", value: process.Filename},
+			siemField{key: "uid", value: strconv.FormatUint(uint64(process.UID), 10)},
+			siemField{key: "gid", value: strconv.FormatUint(uint64(process.GID), 10)})
+	}
+
+	if container := event.Container; container != nil {
+		fields = append(fields, siemField{key: "containerId", value: container.ID})
+		if container.ImageName != "" {
+			fields = append(fields, siemField{key: "imageName", value: container.ImageName})
+		}
+	}
+
+	if file, old := ruleMatchFiles(event); file != nil {
+		fields = append(fields,
+			siemField{key: "resource", value: file.Filename},
+			siemField{key: "fileName", value: path.Base(file.Filename)},
+			siemField{key: "inode", value: strconv.FormatUint(file.Inode, 10)})
+		if file.Mode != nil {
+			fields = append(fields, siemField{key: "filePermission", value: fmt.Sprintf("%04o", *file.Mode)})
+		}
+		if old != nil {
+			fields = append(fields, siemField{key: "oldResource", value: old.Filename})
+		}
+	}
+
+	if mount := event.Mount; mount != nil {
+		fields = append(fields, siemField{key: "resource", value: mount.MountPoint}, siemField{key: "fsType", value: mount.FSType})
+	}
+
+	return fields
+}
+
+// formatLEEF formats the rule match as a QRadar Log Event Extended Format 1.0 record, its fields being separated by
+// tabs
+func formatLEEF(match *sprobe.RuleMatchSerializer, data []byte, severity rules.Severity) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "LEEF:1.0|%s|%s|%s|%s|",
+		siemVendor, siemProduct, leefHeaderEscaper.Replace(version.AgentVersion), leefHeaderEscaper.Replace(match.RuleID))
+
+	for i, field := range leefFields(match, severity) {
+		if i > 0 {
+			buf.WriteRune('\t')
+		}
+		buf.WriteString(field.key + "=" + leefValueEscaper.Replace(field.value))
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+func newTestRuleMatch() *sprobe.RuleMatchSerializer {
+	mode := uint32(0644)
+	return &sprobe.RuleMatchSerializer{
+		RuleID:      "rule|1",
+		RuleVersion: "1.0",
+		Severity:    "high",
+		Event: &sprobe.EventSerializer{
+			ID:        "abc",
+			Timestamp: time.Date(2020, 10, 15, 12, 0, 0, 0, time.UTC),
+			Syscall:   &sprobe.SyscallSerializer{Type: "rename", Retval: 0},
+			Process:   &sprobe.ProcessSerializer{Name: "mv", Pid: 42, UID: 1000, GID: 1000, Filename: "/usr/bin/mv"},
+			Container: &sprobe.ContainerSerializer{ID: "c0ffee"},
+			Old:       &sprobe.FileSerializer{Filename: "/etc/a=b", Inode: 1},
+			New:       &sprobe.FileSerializer{Filename: "/etc/new\tfile", Inode: 2, Mode: &mode},
+		},
+	}
+}
+
+// serializeTestRuleMatch returns the match of the given rule by an empty event, along with its JSON serialization, as
+// built by the module for its sinks
+func serializeTestRuleMatch(t *testing.T, ruleID string) (*sprobe.RuleMatchSerializer, []byte) {
+	event := sprobe.NewEvent(nil)
+	event.Timestamp = time.Date(2020, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	match := sprobe.NewRuleMatchSerializer(&eval.Rule{ID: ruleID}, event, rules.SeverityHigh.String(), "1.0", false)
+	data, err := json.Marshal(match)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return match, data
+}
+
+// parseExtension returns the fields of the extension of a record, split by the given function
+func parseExtension(t *testing.T, extension string, split func(string) []string) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range split(extension) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			t.Fatalf("invalid field `%s` in `%s`", field, extension)
+		}
+		fields[kv[0]] = kv[1]
+	}
+	return fields
+}
+
+func TestFormatCEF(t *testing.T) {
+	record, err := formatCEF(newTestRuleMatch(), nil, rules.SeverityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := "CEF:0|Datadog|Runtime Security|" + version.AgentVersion + `|rule\|1|rule\|1|8|`
+	if !strings.HasPrefix(string(record), header) {
+		t.Fatalf("unexpected header: %s", record)
+	}
+
+	// the escaped equal signs don't separate the keys from the values
+	fields := parseExtension(t, strings.TrimPrefix(string(record), header), func(s string) []string {
+		return strings.Split(strings.Replace(s, `\=`, "\x00", -1), " ")
+	})
+	for key, expected := range map[string]string{
+		"rt":             "1602763200000",
+		"externalId":     "abc",
+		"cat":            "rename",
+		"spid":           "42",
+		"sproc":          "mv",
+		"suid":           "1000",
+		"cs1":            "c0ffee",
+		"cs3":            "1.0",
+		"filePath":       "/etc/new\tfile",
+		"fname":          "new\tfile",
+		"fileId":         "2",
+		"filePermission": "0644",
+		"oldFilePath":    "/etc/a\x00b",
+	} {
+		if fields[key] != expected {
+			t.Errorf("expected `%s` for `%s`, got `%s`", expected, key, fields[key])
+		}
+	}
+
+	if _, exists := fields["cs2"]; exists {
+		t.Error("the image name shouldn't be set")
+	}
+}
+
+func TestFormatLEEF(t *testing.T) {
+	record, err := formatLEEF(newTestRuleMatch(), nil, rules.SeverityCritical)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := "LEEF:1.0|Datadog|Runtime Security|" + version.AgentVersion + `|rule\|1|`
+	if !strings.HasPrefix(string(record), header) {
+		t.Fatalf("unexpected header: %s", record)
+	}
+
+	fields := parseExtension(t, strings.TrimPrefix(string(record), header), func(s string) []string {
+		return strings.Split(s, "\t")
+	})
+	for key, expected := range map[string]string{
+		"devTime":     "Oct 15 2020 12:00:00.000 UTC",
+		"sev":         "10",
+		"cat":         "rename",
+		"pid":         "42",
+		"processName": "mv",
+		"containerId": "c0ffee",
+		"resource":    "/etc/new file",
+		"oldResource": "/etc/a=b",
+	} {
+		if fields[key] != expected {
+			t.Errorf("expected `%s` for `%s`, got `%s`", expected, key, fields[key])
+		}
+	}
+
+	// a rule ID can't break the header nor the line of the record
+	match := newTestRuleMatch()
+	match.RuleID = "rule\t1\n\\"
+	if record, err = formatLEEF(match, nil, rules.SeverityCritical); err != nil {
+		t.Fatal(err)
+	}
+	if header := "LEEF:1.0|Datadog|Runtime Security|" + version.AgentVersion + `|rule 1 \\|`; !strings.HasPrefix(string(record), header) {
+		t.Errorf("unexpected header: %s", record)
+	}
+}
@@ -14,16 +14,22 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 
 	"github.com/DataDog/datadog-agent/cmd/system-probe/api"
+	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
 	aconfig "github.com/DataDog/datadog-agent/pkg/process/config"
 	sapi "github.com/DataDog/datadog-agent/pkg/security/api"
 	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
 	"github.com/DataDog/datadog-agent/pkg/security/policy"
 	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
 	"github.com/DataDog/datadog-agent/pkg/security/rules"
@@ -34,14 +40,30 @@ import (
 
 // Module represents the system-probe module for the runtime security agent
 type Module struct {
-	probe        *sprobe.Probe
-	config       *config.Config
-	ruleSet      *rules.RuleSet
-	eventServer  *EventServer
-	grpcServer   *grpc.Server
-	listener     net.Listener
-	statsdClient *statsd.Client
-	rateLimiter  *RateLimiter
+	// the lock is held for reading while an event is evaluated, and for writing while the ruleset is replaced
+	sync.RWMutex
+	// reloadLock serializes the reloads of the policies, the in-kernel filters are applied without holding the lock
+	// of the module
+	reloadLock       sync.Mutex
+	probe            *sprobe.Probe
+	config           *config.Config
+	ruleSet          *rules.RuleSet
+	eventServer      *EventServer
+	grpcServer       *grpc.Server
+	listener         net.Listener
+	statsdClient     *statsd.Client
+	rateLimiter      *RateLimiter
+	killer           *sprobe.Killer
+	severities       *SeverityFilter
+	simulations      *SimulationCounter
+	auditLog         *AuditLog
+	syslogForwarder  *SyslogForwarder
+	webhookSink      *WebhookSink
+	sighupChan       chan os.Signal
+	policiesChecksum string
+	remoteFetcher    *policy.RemoteFetcher
+	// activatedEventTypes holds the event types for which the probes were activated when the module was registered
+	activatedEventTypes map[eval.EventType]bool
 }
 
 // Register the runtime security agent module
@@ -68,8 +90,39 @@ func (m *Module) Register(httpMux *http.ServeMux) error {
 	m.probe.SetEventHandler(m)
 	m.ruleSet.AddListener(m)
 
+	if m.auditLog != nil && m.auditLog.verbose {
+		if err := m.probe.AddEventHandler(auditLogHandlerName, m.auditLog, auditLogQueueSize); err != nil {
+			return err
+		}
+	}
+
+	// dump the in-kernel filters and their lookup counters, to understand why the volume of events is high
+	httpMux.HandleFunc("/debug/runtime_security/filters", func(w http.ResponseWriter, req *http.Request) {
+		dump, err := m.DumpFilters()
+		if err != nil {
+			log.Errorf("unable to dump the in-kernel filters: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, dump)
+	})
+
+	// enable or disable whole event types, to shed load without restarting the agent
+	httpMux.HandleFunc("/runtime_security/event_types", m.handleEventTypes)
+
+	// pause or resume the collection of the events, during incidents or heavy maintenance
+	httpMux.HandleFunc("/runtime_security/pause", m.handlePause)
+
+	// stop, start or restart a family of probes, to recover from a failure without restarting the others
+	httpMux.HandleFunc("/runtime_security/probe_families", m.handleProbeFamilies)
+
 	go m.statsMonitor(context.Background())
 
+	if m.config.ListsReloadPeriod > 0 {
+		go m.listsMonitor(context.Background())
+	}
+
 	// initialize the default values of the probe
 	if err := m.probe.Init(); err != nil {
 		return err
@@ -82,6 +135,10 @@ func (m *Module) Register(httpMux *http.ServeMux) error {
 		return err
 	}
 
+	for _, eventType := range m.ruleSet.GetEventTypes() {
+		m.activatedEventTypes[eventType] = true
+	}
+
 	// initialize the eBPF manager and load the programs and maps in the kernel. At this stage, the probes are not
 	// running yet.
 	if err := m.probe.InitManager(m.ruleSet); err != nil {
@@ -99,20 +156,200 @@ func (m *Module) Register(httpMux *http.ServeMux) error {
 		return err
 	}
 
+	if degraded := m.getDegradedEventTypes(); len(degraded) > 0 {
+		m.eventServer.SendCustomEvent(ProbeDegradedEventID, ProbeDegradedEvent{EventTypes: degraded})
+	}
+
 	// fetch the current state of the system (example: mount points, running processes, ...) so that our user space
 	// context is ready when we start the probes
 	if err := m.probe.Snapshot(); err != nil {
 		return err
 	}
 
+	for _, eventType := range m.config.DisabledEventTypes {
+		if err := m.probe.SetEventTypeEnabled(eventType, false); err != nil {
+			return errors.Wrapf(err, "failed to disable event type `%s`", eventType)
+		}
+	}
+
+	content, _ := json.MarshalIndent(report, "", "\t")
+	log.Debug(string(content))
+
+	signal.Notify(m.sighupChan, syscall.SIGHUP)
+	go m.reloadMonitor(context.Background(), m.reloadPolicies)
+
+	return nil
+}
+
+// Reload loads the policies again and replaces the current ruleset. The new ruleset is fully compiled and its
+// in-kernel filters applied before replacing the current one, which is kept with its filters if the policies are
+// invalid or if the filters can't be applied. The lock of the module is only held to swap the rulesets, the discarders
+// pushed by the current ruleset while the filters are applied are checked again once it is replaced
+func (m *Module) Reload() error {
+	ruleSet := m.probe.NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
+	if err := policy.LoadPolicies(m.config, ruleSet); err != nil {
+		return errors.Wrap(err, "failed to load the policies, the current ruleset is kept")
+	}
+
+	rateLimiter, err := NewRateLimiter(ruleSet)
+	if err != nil {
+		return err
+	}
+
+	// the probes are selected when the module is registered
+	for _, eventType := range ruleSet.GetEventTypes() {
+		if _, exists := probes.SelectorsPerEventType[eventType]; exists && !m.activatedEventTypes[eventType] {
+			log.Warnf("the probes of the event type `%s` are not activated, its rules will be applied after a restart", eventType)
+		}
+	}
+
+	ruleSet.AddListener(m)
+
+	m.reloadLock.Lock()
+	defer m.reloadLock.Unlock()
+
+	currentRuleSet := m.GetRuleSet()
+
+	report, err := m.applyFilters(ruleSet, currentRuleSet)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply the in-kernel filters, the current ruleset is kept")
+	}
+
+	// the rules that didn't change keep their state, their rate limits and the matches counted by their thresholds,
+	// sequences and requirements
+	unchanged := ruleSet.InheritState(currentRuleSet)
+	rateLimiter.Inherit(m.rateLimiter, unchanged)
+
+	// no event is evaluated by the current ruleset once it is replaced, it can't push discarders anymore
+	m.Lock()
+	m.ruleSet = ruleSet
+	m.rateLimiter = rateLimiter
+	m.Unlock()
+	m.eventServer.SetRuleIDs(ruleSet.ListRuleIDs())
+
+	// the current ruleset could push discarders matching the new rules until it was replaced
+	if err := m.probe.RevalidateDiscarders(ruleSet); err != nil {
+		return errors.Wrap(err, "failed to check the in-kernel discarders")
+	}
+
 	content, _ := json.MarshalIndent(report, "", "\t")
 	log.Debug(string(content))
 
 	return nil
 }
 
+// applyFilters replaces the in-kernel filters of the current ruleset with the ones of the given ruleset, and removes
+// the discarders it invalidates. The filters of the current ruleset are kept until the new ones are all applied, they
+// are left as they were when the new ones can't be applied
+func (m *Module) applyFilters(ruleSet *rules.RuleSet, currentRuleSet *rules.RuleSet) (*sprobe.Report, error) {
+	// the discarders of the current ruleset could discard events matching the new rules
+	if err := m.probe.RevalidateDiscarders(ruleSet); err != nil {
+		return nil, errors.Wrap(err, "failed to check the in-kernel discarders")
+	}
+
+	m.probe.BeginFilterUpdate()
+	report, err := sprobe.NewRuleSetApplier(m.config).Apply(ruleSet, m.probe)
+	if err != nil {
+		m.probe.AbortFilterUpdate()
+		return nil, err
+	}
+
+	if err := m.probe.CommitFilterUpdate(); err != nil {
+		// some filters were replaced, the ones of the current ruleset are applied again. The discarders kept are valid
+		// for both rulesets
+		m.probe.BeginFilterUpdate()
+		if _, restoreErr := sprobe.NewRuleSetApplier(m.config).Apply(currentRuleSet, m.probe); restoreErr != nil {
+			m.probe.AbortFilterUpdate()
+			log.Errorf("failed to restore the in-kernel filters of the current ruleset: %s", restoreErr)
+		} else if restoreErr := m.probe.CommitFilterUpdate(); restoreErr != nil {
+			log.Errorf("failed to restore the in-kernel filters of the current ruleset: %s", restoreErr)
+		}
+		return nil, errors.Wrap(err, "failed to replace the in-kernel filters")
+	}
+
+	return report, nil
+}
+
+// reloadMonitor calls reload when a SIGHUP is received, when the policy files change and when a new policy bundle is
+// received
+func (m *Module) reloadMonitor(ctx context.Context, reload func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the policy files are only checked for changes when a watch period is set
+	var watch <-chan time.Time
+	if m.config.PoliciesWatchPeriod > 0 {
+		ticker := time.NewTicker(m.config.PoliciesWatchPeriod)
+		defer ticker.Stop()
+		watch = ticker.C
+	}
+
+	var refresh <-chan time.Time
+	if m.remoteFetcher != nil {
+		ticker := time.NewTicker(m.config.RemotePoliciesRefreshPeriod)
+		defer ticker.Stop()
+		refresh = ticker.C
+
+		// the first bundle is fetched once the module started, the cached bundle or the local policies are loaded
+		// meanwhile
+		if m.updateRemotePolicies(ctx) {
+			reload()
+		}
+	}
+
+	for {
+		select {
+		case <-m.sighupChan:
+			log.Info("SIGHUP received, reloading the policies")
+		case <-watch:
+			checksum, err := policy.PoliciesChecksum(m.config)
+			if err != nil {
+				log.Warnf("failed to check the policies for changes: %s", err)
+				continue
+			}
+			if checksum == m.policiesChecksum {
+				continue
+			}
+			// an invalid policy isn't reloaded again until it changes
+			m.policiesChecksum = checksum
+			log.Info("policies changed, reloading the policies")
+		case <-refresh:
+			if !m.updateRemotePolicies(ctx) {
+				continue
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		reload()
+	}
+}
+
+// updateRemotePolicies fetches the current policy bundle, it returns whether a new bundle was received
+func (m *Module) updateRemotePolicies(ctx context.Context) bool {
+	changed, err := m.remoteFetcher.Update(ctx)
+	if err != nil {
+		log.Warnf("failed to update the remote policies: %s", err)
+		return false
+	}
+	if changed {
+		log.Info("new policy bundle received, reloading the policies")
+	}
+	return changed
+}
+
+func (m *Module) reloadPolicies() {
+	if err := m.Reload(); err != nil {
+		log.Errorf("failed to reload the policies: %s", err)
+		return
+	}
+	log.Infof("policies reloaded, %d rules loaded", len(m.GetRuleSet().ListRuleIDs()))
+}
+
 // Close the module
 func (m *Module) Close() {
+	signal.Stop(m.sighupChan)
+
 	if m.grpcServer != nil {
 		m.grpcServer.Stop()
 	}
@@ -123,14 +360,91 @@ func (m *Module) Close() {
 	}
 
 	m.probe.Close()
+
+	// the probe stopped the handler of the audit log in verbose mode, no event is written to it anymore
+	if m.auditLog != nil {
+		if err := m.auditLog.Close(); err != nil {
+			log.Warnf("failed to close the audit log: %s", err)
+		}
+	}
+
+	if m.syslogForwarder != nil {
+		m.syslogForwarder.Close()
+	}
+
+	if m.webhookSink != nil {
+		m.webhookSink.Close()
+	}
 }
 
-// RuleMatch is called by the ruleset when a rule matches
+// RuleMatch is called by the ruleset when a rule matches. It is called while the event is evaluated, the module
+// lock is thus already held
 func (m *Module) RuleMatch(rule *eval.Rule, event eval.Event) {
-	if m.rateLimiter.Allow(rule.ID) {
-		m.eventServer.SendEvent(rule, event)
-	} else {
+	severity := m.ruleSet.GetSeverity(rule.ID)
+
+	var version string
+	ruleDef := m.ruleSet.GetRuleDefinition(rule.ID)
+	if ruleDef != nil {
+		version = ruleDef.Version
+	}
+
+	simulated := ruleDef != nil && ruleDef.IsSimulated()
+
+	// the match is serialized once for all the sinks, when the first one needs it
+	var match *sprobe.RuleMatchSerializer
+	var data []byte
+	serialize := func() bool {
+		if match == nil {
+			match = sprobe.NewRuleMatchSerializer(rule, event.(*sprobe.Event), severity.String(), version, simulated)
+
+			var err error
+			if data, err = json.Marshal(match); err != nil {
+				log.Debugf("failed to serialize the match of rule `%s`: %s", rule.ID, err)
+			}
+		}
+		return data != nil
+	}
+
+	// all the matches are written to the audit log, whatever their sampling, severity or rate limit
+	if m.auditLog != nil && serialize() {
+		m.auditLog.WriteRuleMatch(data)
+	}
+
+	// the rules in simulation mode raise no signal, their matches are counted and only a sample of their events is sent
+	if simulated {
+		if m.simulations.Match(rule.ID) && m.rateLimiter.Allow(rule.ID, event) && serialize() {
+			m.eventServer.SendEvent(rule, event, data, severity, version, true)
+		}
+		return
+	}
+
+	for _, action := range m.ruleSet.GetActions(rule.ID) {
+		if action.Kill != "" {
+			if err := m.killer.Kill(event.(*sprobe.Event), action.Kill); err != nil {
+				log.Warnf("kill action of rule %s failed: %s", rule.ID, err)
+			}
+		}
+	}
+
+	if !m.severities.Allow(severity) {
+		log.Tracef("Event on rule %s was dropped due to its severity %s", rule.ID, severity)
+		return
+	}
+
+	if !m.rateLimiter.Allow(rule.ID, event) {
 		log.Tracef("Event on rule %s was dropped due to rate limiting", rule.ID)
+		return
+	}
+
+	if !serialize() {
+		return
+	}
+	m.eventServer.SendEvent(rule, event, data, severity, version, false)
+	if m.syslogForwarder != nil {
+		m.syslogForwarder.Forward(match, data, severity)
+	}
+	if m.webhookSink != nil {
+		m.webhookSink.Post(data)
 	}
 }
 
@@ -141,8 +455,30 @@ func (m *Module) EventDiscarderFound(rs *rules.RuleSet, event eval.Event, field
 	}
 }
 
+// HandleProcessExit is called by the probe when a process exits, the variables scoped to the process are dropped as
+// its pid can be reused. The process scope of the ruleset is keyed by pid, see probe.NewRuleSet
+func (m *Module) HandleProcessExit(pid uint32) {
+	m.RLock()
+	defer m.RUnlock()
+
+	m.ruleSet.ReleaseScopeEntry("process", pid)
+}
+
 // HandleEvent is called by the probe when an event arrives from the kernel
 func (m *Module) HandleEvent(event *sprobe.Event) {
+	m.RLock()
+	defer m.RUnlock()
+
+	// the file events of a container are restricted in the kernel to the event types of the rules of its scope, at
+	// its first event once its image and labels are resolved
+	if containerID := event.ResolveContainerID(); containerID != "" && !m.probe.HasContainerEventTypes(containerID) {
+		if workload := event.ResolveWorkload(); workload.IsResolved() {
+			if err := m.probe.SetContainerEventTypes(containerID, m.ruleSet.GetWorkloadEventTypes(workload)); err != nil {
+				log.Debug(err)
+			}
+		}
+	}
+
 	m.ruleSet.Evaluate(event)
 }
 
@@ -159,12 +495,75 @@ func (m *Module) statsMonitor(ctx context.Context) {
 			if err := m.probe.SendStats(m.statsdClient); err != nil {
 				log.Debug(err)
 			}
-			if err := m.rateLimiter.SendStats(m.statsdClient); err != nil {
+			m.RLock()
+			rateLimiter := m.rateLimiter
+			m.RUnlock()
+			if err := rateLimiter.SendStats(m.statsdClient); err != nil {
+				log.Debug(err)
+			}
+			if err := m.severities.SendStats(m.statsdClient); err != nil {
+				log.Debug(err)
+			}
+			if err := m.simulations.SendStats(m.statsdClient); err != nil {
+				log.Debug(err)
+			}
+			if err := m.sendSuppressionStats(); err != nil {
 				log.Debug(err)
 			}
 			if err := m.eventServer.SendStats(m.statsdClient); err != nil {
 				log.Debug(err)
 			}
+			if m.auditLog != nil {
+				if err := m.auditLog.SendStats(m.statsdClient); err != nil {
+					log.Debug(err)
+				}
+			}
+			if m.syslogForwarder != nil {
+				if err := m.syslogForwarder.SendStats(m.statsdClient); err != nil {
+					log.Debug(err)
+				}
+			}
+			if m.webhookSink != nil {
+				if err := m.webhookSink.SendStats(m.statsdClient); err != nil {
+					log.Debug(err)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sendSuppressionStats sends statistics about the number of matches ignored by each suppression of the rules
+func (m *Module) sendSuppressionStats() error {
+	m.RLock()
+	ruleSet := m.ruleSet
+	m.RUnlock()
+
+	for ruleID, hits := range ruleSet.GetSuppressionHits() {
+		for suppressionID, count := range hits {
+			tags := []string{"rule_id:" + ruleID, "suppression_id:" + suppressionID}
+			if err := m.statsdClient.Count(sprobe.MetricPrefix+".rules.suppression.hit", count, tags, 1.0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Module) listsMonitor(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ticker := time.NewTicker(m.config.ListsReloadPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := policy.ReloadLists(m.GetRuleSet()); err != nil {
+				log.Warnf("failed to reload lists: %s", err)
+			}
 		case <-ctx.Done():
 			return
 		}
@@ -179,12 +578,171 @@ func (m *Module) GetStats() map[string]interface{} {
 	}
 
 	return map[string]interface{}{
-		"probe": probeStats,
+		"probe":                probeStats,
+		"degraded_event_types": m.getDegradedEventTypes(),
+	}
+}
+
+// ProbeDegradedEventID is the ID of the event sent when the probes of some event types couldn't be attached
+const ProbeDegradedEventID = "probe_degraded"
+
+// DegradedEventType describes an event type whose probes couldn't all be attached, along with its rules
+type DegradedEventType struct {
+	sprobe.DegradedEventType
+	Rules []string `json:"rules"`
+}
+
+// ProbeDegradedEvent is the event sent when the probes of some event types couldn't be attached, the other event
+// types keep running
+type ProbeDegradedEvent struct {
+	EventTypes []DegradedEventType `json:"event_types"`
+}
+
+// getDegradedEventTypes returns the event types whose probes couldn't all be attached and the rules they affect
+func (m *Module) getDegradedEventTypes() []DegradedEventType {
+	m.RLock()
+	defer m.RUnlock()
+
+	var degraded []DegradedEventType
+	for _, eventType := range m.probe.GetDegradedEventTypes() {
+		report := DegradedEventType{DegradedEventType: eventType, Rules: []string{}}
+		if bucket := m.ruleSet.GetBucket(eventType.EventType); bucket != nil {
+			for _, rule := range bucket.GetRules() {
+				report.Rules = append(report.Rules, rule.ID)
+			}
+		}
+		degraded = append(degraded, report)
+	}
+	return degraded
+}
+
+// ProbeResyncEventID is the ID of the event sent when the caches of the probe were resynced after a sustained loss
+// of events
+const ProbeResyncEventID = "probe_resync"
+
+// HandleResync is called by the probe when its caches were resynced, the events sent around the resync may have
+// gaps
+func (m *Module) HandleResync(event sprobe.ResyncEvent) {
+	m.eventServer.SendCustomEvent(ProbeResyncEventID, event)
+}
+
+// FiltersDump describes the in-kernel filters and the number of their lookups
+type FiltersDump struct {
+	Stats map[string]sprobe.FilterStats
+	Maps  map[string][]sprobe.FilterMapEntry
+}
+
+// DumpFilters returns the entries of the in-kernel filter maps along with their lookup counters
+func (m *Module) DumpFilters() (*FiltersDump, error) {
+	stats, err := m.probe.GetFilterStats()
+	if err != nil {
+		return nil, err
+	}
+
+	maps, err := m.probe.DumpFilters()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FiltersDump{Stats: stats, Maps: maps}, nil
+}
+
+// EventTypesStatus lists the event types disabled at runtime
+type EventTypesStatus struct {
+	Disabled []eval.EventType
+}
+
+// handleEventTypes returns the event types disabled at runtime. A POST request enables or disables the event type
+// given by the `event_type` parameter, depending on the `enabled` parameter
+func (m *Module) handleEventTypes(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		eventType := req.FormValue("event_type")
+		if _, exists := probes.SelectorsPerEventType[eventType]; !exists || eventType == "*" {
+			http.Error(w, fmt.Sprintf("unknown event type `%s`", eventType), http.StatusBadRequest)
+			return
+		}
+
+		enabled, err := strconv.ParseBool(req.FormValue("enabled"))
+		if err != nil {
+			http.Error(w, "invalid `enabled` parameter", http.StatusBadRequest)
+			return
+		}
+
+		if err := m.probe.SetEventTypeEnabled(eventType, enabled); err != nil {
+			log.Errorf("unable to switch event type `%s`: %s", eventType, err)
+			w.WriteHeader(500)
+			return
+		}
 	}
+
+	utils.WriteAsJSON(w, EventTypesStatus{Disabled: m.probe.GetDisabledEventTypes()})
+}
+
+// PauseStatus reports whether the probe is paused
+type PauseStatus struct {
+	Paused bool
+}
+
+// handlePause returns whether the probe is paused. A POST request pauses or resumes the probe, depending on the
+// `paused` parameter
+func (m *Module) handlePause(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		paused, err := strconv.ParseBool(req.FormValue("paused"))
+		if err != nil {
+			http.Error(w, "invalid `paused` parameter", http.StatusBadRequest)
+			return
+		}
+
+		if paused {
+			err = m.probe.Pause()
+		} else {
+			err = m.probe.Resume()
+		}
+
+		if err != nil {
+			log.Errorf("unable to pause or resume the probe: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+	}
+
+	utils.WriteAsJSON(w, PauseStatus{Paused: m.probe.IsPaused()})
+}
+
+// handleProbeFamilies returns the status of the families of probes. A POST request stops, starts or restarts the
+// family given by the `family` parameter, depending on the `action` parameter
+func (m *Module) handleProbeFamilies(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		family := req.FormValue("family")
+
+		var err error
+		switch action := req.FormValue("action"); action {
+		case "stop":
+			err = m.probe.StopProbeFamily(family)
+		case "start":
+			err = m.probe.StartProbeFamily(family)
+		case "restart":
+			err = m.probe.RestartProbeFamily(family)
+		default:
+			http.Error(w, fmt.Sprintf("unknown action `%s`", action), http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Errorf("unable to switch the %s probes: %s", family, err)
+			w.WriteHeader(500)
+			return
+		}
+	}
+
+	utils.WriteAsJSON(w, m.probe.GetProbeFamilies())
 }
 
 // GetRuleSet returns the set of loaded rules
 func (m *Module) GetRuleSet() *rules.RuleSet {
+	m.RLock()
+	defer m.RUnlock()
+
 	return m.ruleSet
 }
 
@@ -221,11 +779,60 @@ func NewModule(cfg *aconfig.AgentConfig) (api.Module, error) {
 		return nil, err
 	}
 
+	severities, err := NewSeverityFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	simulations, err := NewSimulationCounter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	policiesChecksum, err := policy.PoliciesChecksum(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var remoteFetcher *policy.RemoteFetcher
+	if config.RemotePoliciesEnabled {
+		// the bundle is fetched once the module started, the cached bundle or the local policies are loaded first
+		if remoteFetcher, err = policy.NewRemoteFetcher(config); err != nil {
+			return nil, err
+		}
+	}
+
 	ruleSet := probe.NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
 	if err := policy.LoadPolicies(config, ruleSet); err != nil {
 		return nil, err
 	}
 
+	rateLimiter, err := NewRateLimiter(ruleSet)
+	if err != nil {
+		return nil, err
+	}
+
+	var auditLog *AuditLog
+	if config.AuditLogEnabled {
+		if auditLog, err = NewAuditLog(config); err != nil {
+			return nil, err
+		}
+	}
+
+	var syslogForwarder *SyslogForwarder
+	if config.SyslogEnabled {
+		if syslogForwarder, err = NewSyslogForwarder(config); err != nil {
+			return nil, err
+		}
+	}
+
+	var webhookSink *WebhookSink
+	if config.WebhookEnabled {
+		if webhookSink, err = NewWebhookSink(config); err != nil {
+			return nil, err
+		}
+	}
+
 	m := &Module{
 		config:       config,
 		probe:        probe,
@@ -233,7 +840,18 @@ func NewModule(cfg *aconfig.AgentConfig) (api.Module, error) {
 		eventServer:  NewEventServer(ruleSet.ListRuleIDs(), config),
 		grpcServer:   grpc.NewServer(),
 		statsdClient: statsdClient,
-		rateLimiter:  NewRateLimiter(ruleSet.ListRuleIDs()),
+		rateLimiter:  rateLimiter,
+		killer:       sprobe.NewKiller(config),
+		severities:   severities,
+		simulations:  simulations,
+		auditLog:     auditLog,
+		sighupChan:   make(chan os.Signal, 1),
+
+		syslogForwarder:     syslogForwarder,
+		webhookSink:         webhookSink,
+		policiesChecksum:    policiesChecksum,
+		remoteFetcher:       remoteFetcher,
+		activatedEventTypes: make(map[eval.EventType]bool),
 	}
 
 	sapi.RegisterSecurityModuleServer(m.grpcServer, m.eventServer)
@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/policy"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+func TestReloadMonitor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload-monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	policyFile := filepath.Join(dir, "default.policy")
+	writePolicy := func(filename string) {
+		content := "rules:\n  - id: rule\n    expression: open.filename == \"" + filename + "\"\n"
+		if err := ioutil.WriteFile(policyFile, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePolicy("/etc/passwd")
+
+	cfg := &config.Config{PoliciesDir: dir, PoliciesWatchPeriod: 10 * time.Millisecond}
+	checksum, err := policy.PoliciesChecksum(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Module{config: cfg, sighupChan: make(chan os.Signal, 1), policiesChecksum: checksum}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloads := make(chan struct{}, 10)
+	go m.reloadMonitor(ctx, func() { reloads <- struct{}{} })
+
+	expectReload := func(reason string) {
+		select {
+		case <-reloads:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected the policies to be reloaded %s", reason)
+		}
+	}
+
+	m.sighupChan <- syscall.SIGHUP
+	expectReload("on SIGHUP")
+
+	// the policies are only reloaded when they change
+	select {
+	case <-reloads:
+		t.Fatal("unchanged policies shouldn't be reloaded")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	writePolicy("/etc/shadow")
+	expectReload("when a policy file changes")
+}
+
+func TestSimulationCounter(t *testing.T) {
+	for _, rate := range []float64{-0.1, 1.1} {
+		if _, err := NewSimulationCounter(&config.Config{SimulationSampleRate: rate}); err == nil {
+			t.Errorf("expected the sample rate %f to be rejected", rate)
+		}
+	}
+
+	for _, rate := range []float64{0, 1} {
+		c, err := NewSimulationCounter(&config.Config{SimulationSampleRate: rate})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 3; i++ {
+			if sampled := c.Match("rule"); sampled != (rate == 1) {
+				t.Errorf("expected the event to be sampled %t with the sample rate %f", rate == 1, rate)
+			}
+		}
+		if matches := c.matches["rule"]; matches != 3 {
+			t.Errorf("expected all the matches to be counted with the sample rate %f, got %d", rate, matches)
+		}
+	}
+}
+
+func TestModuleRuleMatchSimulation(t *testing.T) {
+	ruleSet := (&sprobe.Probe{}).NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
+	if err := ruleSet.AddRules([]*rules.RuleDefinition{
+		{ID: "trial", Expression: `open.filename == "/etc/shadow"`, Mode: rules.RuleModeSimulation},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rateLimiter, err := NewRateLimiter(ruleSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := sprobe.NewEvent(nil)
+	event.Timestamp = time.Date(2020, 10, 15, 12, 0, 0, 0, time.UTC)
+	rule := &eval.Rule{ID: "trial"}
+
+	for _, rate := range []float64{0, 1} {
+		cfg := &config.Config{SimulationSampleRate: rate, EventServerRate: 10, EventServerBurst: 10}
+		simulations, err := NewSimulationCounter(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := &Module{
+			config:      cfg,
+			ruleSet:     ruleSet,
+			rateLimiter: rateLimiter,
+			simulations: simulations,
+			eventServer: NewEventServer(ruleSet.ListRuleIDs(), cfg),
+		}
+		m.RuleMatch(rule, event)
+
+		if matches := simulations.matches["trial"]; matches != 1 {
+			t.Errorf("expected the match to be counted with the sample rate %f, got %d", rate, matches)
+		}
+
+		select {
+		case msg := <-m.eventServer.msgs:
+			if rate == 0 {
+				t.Error("no event should be sent with the sample rate 0")
+			}
+
+			var simulated bool
+			for _, tag := range msg.Tags {
+				simulated = simulated || tag == "simulated:true"
+			}
+			if !simulated {
+				t.Errorf("expected the event to be tagged as simulated, got %v", msg.Tags)
+			}
+		default:
+			if rate == 1 {
+				t.Error("expected the sampled event to be sent")
+			}
+		}
+	}
+}
+
+func TestRateLimiterInherit(t *testing.T) {
+	newRateLimiter := func(ruleDefs ...*rules.RuleDefinition) *RateLimiter {
+		ruleSet := (&sprobe.Probe{}).NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
+		if err := ruleSet.AddRules(ruleDefs); err != nil {
+			t.Fatal(err)
+		}
+
+		rateLimiter, err := NewRateLimiter(ruleSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rateLimiter
+	}
+
+	rateLimit := &rules.RateLimitDefinition{Limit: 1, Period: time.Hour}
+	previous := newRateLimiter(
+		&rules.RuleDefinition{ID: "unchanged", Expression: `open.filename == "/etc/shadow"`, RateLimit: rateLimit},
+		&rules.RuleDefinition{ID: "new_rate_limit", Expression: `open.filename == "/etc/shadow"`, RateLimit: rateLimit},
+	)
+
+	event := sprobe.NewEvent(nil)
+	for _, id := range []string{"unchanged", "new_rate_limit"} {
+		if !previous.Allow(id, event) {
+			t.Fatalf("expected the first event of %s to be allowed", id)
+		}
+	}
+
+	rateLimiter := newRateLimiter(
+		&rules.RuleDefinition{ID: "unchanged", Expression: `open.filename == "/etc/shadow"`, RateLimit: rateLimit},
+		&rules.RuleDefinition{ID: "new_rate_limit", Expression: `open.filename == "/etc/shadow"`, RateLimit: &rules.RateLimitDefinition{Limit: 2, Period: time.Hour}},
+	)
+	rateLimiter.Inherit(previous, []eval.RuleID{"unchanged", "new_rate_limit"})
+
+	// the limit of the unchanged rule was already reached before the rules were reloaded
+	if rateLimiter.Allow("unchanged", event) {
+		t.Error("expected the unchanged rule to keep its limiter")
+	}
+	if !rateLimiter.Allow("new_rate_limit", event) {
+		t.Error("expected the rule whose rate limit changed to get a new limiter")
+	}
+}
+
+func TestScopedLimiterConcurrentEntries(t *testing.T) {
+	limiter, err := NewScopedLimiter(rate.Every(time.Hour), 1, "process")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the events of a new entry share the same limiter, only the first one is allowed
+	var allowed int64
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.getLimiter(uint32(1234)).Allow() {
+				atomic.AddInt64(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 1 {
+		t.Errorf("expected a single event of the entry to be allowed, got %d", allowed)
+	}
+	if limiter.limiters.Len() != 1 {
+		t.Errorf("expected a single limiter, got %d", limiter.limiters.Len())
+	}
+}
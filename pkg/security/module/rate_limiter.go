@@ -9,12 +9,17 @@ package module
 
 import (
 	"fmt"
+	"reflect"
 	"sync/atomic"
+	"time"
 
 	"github.com/DataDog/datadog-go/statsd"
+	lru "github.com/hashicorp/golang-lru"
 	"golang.org/x/time/rate"
 
 	"github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
 )
 
 const (
@@ -23,6 +28,8 @@ const (
 	// Default Token bucket size. 40 is meant to handle sudden burst of events while making sure that we prevent
 	// flooding.
 	defaultBurst int = 40
+	// Maximum number of entries, processes or containers for instance, for which a scoped limiter is kept
+	maxScopedLimiters = 1024
 )
 
 // Limiter describes an object that applies limits on
@@ -31,6 +38,12 @@ const (
 type Limiter struct {
 	limiter *rate.Limiter
 
+	// scoped limiters, one per entry of the scope of the rate limit of the rule
+	scope    string
+	limit    rate.Limit
+	burst    int
+	limiters *lru.Cache
+
 	// https://github.com/golang/go/issues/36606
 	padding int32
 	dropped int64
@@ -45,29 +58,115 @@ func NewLimiter(limit rate.Limit, burst int) *Limiter {
 	}
 }
 
+// NewScopedLimiter returns a new rule limiter applying the limit to each entry of the given scope
+func NewScopedLimiter(limit rate.Limit, burst int, scope string) (*Limiter, error) {
+	limiters, err := lru.New(maxScopedLimiters)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Limiter{
+		limiter:  rate.NewLimiter(limit, burst),
+		scope:    scope,
+		limit:    limit,
+		burst:    burst,
+		limiters: limiters,
+	}, nil
+}
+
+// getLimiter returns the limiter of the given entry of the scope, the events not belonging to any entry share the
+// same limiter
+func (l *Limiter) getLimiter(key interface{}) *rate.Limiter {
+	if l.limiters == nil || key == nil {
+		return l.limiter
+	}
+
+	if limiter, found := l.limiters.Get(key); found {
+		return limiter.(*rate.Limiter)
+	}
+
+	// another event of the same entry may have added its limiter in the meantime, it is kept so that the burst of
+	// the entry isn't allowed twice
+	limiter := rate.NewLimiter(l.limit, l.burst)
+	if previous, found, _ := l.limiters.PeekOrAdd(key, limiter); found {
+		return previous.(*rate.Limiter)
+	}
+	return limiter
+}
+
 // RateLimiter describes a set of rule rate limiters
 type RateLimiter struct {
 	limiters map[string]*Limiter
+	ruleSet  *rules.RuleSet
 }
 
-// NewRateLimiter initializes an empty rate limiter
-func NewRateLimiter(ids []string) *RateLimiter {
+// NewRateLimiter initializes the rate limiters of the rules of the given ruleset. A rule without rate limit is
+// limited to 10 events per second, with bursts of 40 events
+func NewRateLimiter(ruleSet *rules.RuleSet) (*RateLimiter, error) {
 	limiters := make(map[string]*Limiter)
-	for _, id := range ids {
-		limiters[id] = NewLimiter(defaultLimit, defaultBurst)
+	for _, id := range ruleSet.ListRuleIDs() {
+		ruleDef := ruleSet.GetRuleDefinition(id)
+		if ruleDef == nil || ruleDef.RateLimit == nil {
+			limiters[id] = NewLimiter(defaultLimit, defaultBurst)
+			continue
+		}
+
+		rateLimit := ruleDef.RateLimit
+		limit := rate.Every(rateLimit.Period / time.Duration(rateLimit.Limit))
+
+		if rateLimit.Scope == "" {
+			limiters[id] = NewLimiter(limit, rateLimit.Limit)
+			continue
+		}
+
+		limiter, err := NewScopedLimiter(limit, rateLimit.Limit, rateLimit.Scope)
+		if err != nil {
+			return nil, err
+		}
+		limiters[id] = limiter
 	}
 	return &RateLimiter{
 		limiters: limiters,
+		ruleSet:  ruleSet,
+	}, nil
+}
+
+// Inherit takes over the limiters of the given rules from the previous rate limiter, when their rate limit didn't
+// change, so that reloading the rules doesn't reset their limits
+func (rl *RateLimiter) Inherit(previous *RateLimiter, ruleIDs []eval.RuleID) {
+	for _, id := range ruleIDs {
+		limiter, exists := previous.limiters[id]
+		if !exists {
+			continue
+		}
+
+		var rateLimit, previousRateLimit *rules.RateLimitDefinition
+		if ruleDef := rl.ruleSet.GetRuleDefinition(id); ruleDef != nil {
+			rateLimit = ruleDef.RateLimit
+		}
+		if ruleDef := previous.ruleSet.GetRuleDefinition(id); ruleDef != nil {
+			previousRateLimit = ruleDef.RateLimit
+		}
+
+		if reflect.DeepEqual(rateLimit, previousRateLimit) {
+			rl.limiters[id] = limiter
+		}
 	}
 }
 
 // Allow returns true if a specific rule shall be allowed to sent a new event
-func (rl *RateLimiter) Allow(ruleID string) bool {
+func (rl *RateLimiter) Allow(ruleID string, event eval.Event) bool {
 	ruleLimiter, ok := rl.limiters[ruleID]
 	if !ok {
 		return false
 	}
-	if ruleLimiter.limiter.Allow() {
+
+	var key interface{}
+	if ruleLimiter.scope != "" {
+		key = rl.ruleSet.GetScopeKey(ruleLimiter.scope, event)
+	}
+
+	if ruleLimiter.getLimiter(key).Allow() {
 		atomic.AddInt64(&ruleLimiter.allowed, 1)
 		return true
 	}
@@ -10,6 +10,7 @@ package module
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -27,6 +28,7 @@ import (
 // EventServer represents a gRPC server in charge of receiving events sent by
 // the runtime security system-probe module and forwards them to Datadog
 type EventServer struct {
+	sync.RWMutex
 	msgs          chan *api.SecurityEventMessage
 	expiredEvents map[string]*int64
 	rate          *Limiter
@@ -64,12 +66,17 @@ LOOP:
 }
 
 // SendEvent forwards events sent by the runtime security module to Datadog
-func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event) {
-	data, err := json.Marshal(rules.RuleEvent{Event: event, RuleID: rule.ID})
-	if err != nil {
-		return
+func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event, data []byte, severity rules.Severity, version string, simulated bool) {
+	// the tags of the rule are shared by all its events, they are copied before adding the ones of the event
+	tags := make([]string, 0, len(rule.Tags)+4)
+	tags = append(tags, rule.Tags...)
+	tags = append(tags, "rule_id:"+rule.ID, "severity:"+severity.String())
+	if version != "" {
+		tags = append(tags, "rule_version:"+version)
+	}
+	if simulated {
+		tags = append(tags, "simulated:true")
 	}
-	tags := append(rule.Tags, "rule_id:"+rule.ID)
 	tags = append(tags, event.(*sprobe.Event).GetTags()...)
 	log.Tracef("Sending event message for rule `%s` to security-agent `%s` with tags %v", rule.ID, string(data), tags)
 
@@ -79,7 +86,27 @@ func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event) {
 		Tags:   tags,
 		Data:   data,
 	}
+	e.push(msg)
+}
+
+// SendCustomEvent forwards an event raised by the runtime security module itself, and not by a rule, to Datadog
+func (e *EventServer) SendCustomEvent(id string, event interface{}) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	log.Tracef("Sending custom event message `%s` to security-agent `%s`", id, string(data))
+
+	e.push(&api.SecurityEventMessage{
+		RuleID: id,
+		Type:   id,
+		Tags:   []string{"rule_id:" + id},
+		Data:   data,
+	})
+}
 
+// push queues a message, the oldest message is dropped when the queue is full
+func (e *EventServer) push(msg *api.SecurityEventMessage) {
 	select {
 	case e.msgs <- msg:
 		break
@@ -102,6 +129,9 @@ func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event) {
 
 // expireEvent updates the count of expired messages for the appropriate rule
 func (e *EventServer) expireEvent(msg *api.SecurityEventMessage) {
+	e.RLock()
+	defer e.RUnlock()
+
 	// Update metric
 	count, ok := e.expiredEvents[msg.RuleID]
 	if ok {
@@ -113,6 +143,9 @@ func (e *EventServer) expireEvent(msg *api.SecurityEventMessage) {
 // GetStats returns a map indexed by ruleIDs that describes the amount of events
 // that were expired or rate limited before reaching
 func (e *EventServer) GetStats() map[string]int64 {
+	e.RLock()
+	defer e.RUnlock()
+
 	stats := make(map[string]int64)
 	for ruleID, val := range e.expiredEvents {
 		stats[ruleID] = atomic.SwapInt64(val, 0)
@@ -133,6 +166,23 @@ func (e *EventServer) SendStats(client *statsd.Client) error {
 	return nil
 }
 
+// SetRuleIDs sets the rules for which the expired events are counted, when the policies are reloaded for instance
+func (e *EventServer) SetRuleIDs(ids []string) {
+	e.Lock()
+	defer e.Unlock()
+
+	expiredEvents := make(map[string]*int64)
+	for _, id := range ids {
+		if count, exists := e.expiredEvents[id]; exists {
+			expiredEvents[id] = count
+			continue
+		}
+		var val int64
+		expiredEvents[id] = &val
+	}
+	e.expiredEvents = expiredEvents
+}
+
 // NewEventServer returns a new gRPC event server
 func NewEventServer(ids []string, cfg *config.Config) *EventServer {
 	es := &EventServer{
@@ -140,9 +190,6 @@ func NewEventServer(ids []string, cfg *config.Config) *EventServer {
 		expiredEvents: make(map[string]*int64),
 		rate:          NewLimiter(rate.Limit(cfg.EventServerRate), cfg.EventServerBurst),
 	}
-	for _, id := range ids {
-		var val int64
-		es.expiredEvents[id] = &val
-	}
+	es.SetRuleIDs(ids)
 	return es
 }
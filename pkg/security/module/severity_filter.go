@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+// SeverityFilter samples the events of the rules below a severity threshold, so that noisy informational rules
+// don't consume the event pipeline
+type SeverityFilter struct {
+	threshold  rules.Severity
+	sampleRate float64
+	dropped    [rules.SeverityCritical + 1]int64
+}
+
+// NewSeverityFilter returns a new severity filter
+func NewSeverityFilter(cfg *config.Config) (*SeverityFilter, error) {
+	threshold, err := rules.ParseSeverity(cfg.SeverityThreshold)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid severity threshold")
+	}
+
+	if cfg.BelowThresholdSampleRate < 0 || cfg.BelowThresholdSampleRate > 1 {
+		return nil, fmt.Errorf("invalid sample rate %f, it should be between 0 and 1", cfg.BelowThresholdSampleRate)
+	}
+
+	return &SeverityFilter{
+		threshold:  threshold,
+		sampleRate: cfg.BelowThresholdSampleRate,
+	}, nil
+}
+
+// Allow returns true if an event of the given severity shall be sent
+func (f *SeverityFilter) Allow(severity rules.Severity) bool {
+	if severity >= f.threshold {
+		return true
+	}
+
+	if f.sampleRate > 0 && rand.Float64() < f.sampleRate {
+		return true
+	}
+
+	atomic.AddInt64(&f.dropped[severity], 1)
+	return false
+}
+
+// SendStats sends statistics about the number of events dropped per severity
+func (f *SeverityFilter) SendStats(client *statsd.Client) error {
+	for severity := range f.dropped {
+		if dropped := atomic.SwapInt64(&f.dropped[severity], 0); dropped > 0 {
+			tags := []string{fmt.Sprintf("severity:%s", rules.Severity(severity))}
+			if err := client.Count(probe.MetricPrefix+".rules.severity_filter.drop", dropped, tags, 1.0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
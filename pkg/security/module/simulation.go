@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/DataDog/datadog-go/statsd"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+// SimulationCounter counts the matches of the rules in simulation mode and samples their events, so that new rules
+// can be trialed without raising signals
+type SimulationCounter struct {
+	sync.Mutex
+	sampleRate float64
+	matches    map[rules.RuleID]int64
+}
+
+// NewSimulationCounter returns a new simulation counter
+func NewSimulationCounter(cfg *config.Config) (*SimulationCounter, error) {
+	if cfg.SimulationSampleRate < 0 || cfg.SimulationSampleRate > 1 {
+		return nil, fmt.Errorf("invalid simulation sample rate %f, it should be between 0 and 1", cfg.SimulationSampleRate)
+	}
+
+	return &SimulationCounter{
+		sampleRate: cfg.SimulationSampleRate,
+		matches:    make(map[rules.RuleID]int64),
+	}, nil
+}
+
+// Match counts a match of a rule in simulation mode and returns true if its event shall be sent
+func (c *SimulationCounter) Match(ruleID rules.RuleID) bool {
+	c.Lock()
+	c.matches[ruleID]++
+	c.Unlock()
+
+	return c.sampleRate > 0 && rand.Float64() < c.sampleRate
+}
+
+// SendStats sends statistics about the number of matches per rule in simulation mode
+func (c *SimulationCounter) SendStats(client *statsd.Client) error {
+	c.Lock()
+	matches := c.matches
+	c.matches = make(map[rules.RuleID]int64)
+	c.Unlock()
+
+	for ruleID, count := range matches {
+		tags := []string{fmt.Sprintf("rule_id:%s", ruleID)}
+		if err := client.Count(probe.MetricPrefix+".rules.simulation.match", count, tags, 1.0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
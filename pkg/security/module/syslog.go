@@ -0,0 +1,338 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// syslogDefaultAddress is the socket of the local syslog daemon
+	syslogDefaultAddress = "/dev/log"
+	// syslogQueueSize is the number of messages queued to the syslog server, the ones it can't keep up with are
+	// dropped
+	syslogQueueSize = 1000
+	// syslogTimeout bounds the connection to the syslog server and the writes of the messages
+	syslogTimeout = 5 * time.Second
+	// syslogTimestampFormat is the RFC 5424 timestamp, with a microsecond precision
+	syslogTimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+	syslogNilValue        = "-"
+)
+
+// syslogFacilities are the facilities of RFC 5424, per name
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// syslogSeverities maps the severities of the rules to the severities of RFC 5424
+var syslogSeverities = map[rules.Severity]int{
+	rules.SeverityInfo:     6, // informational
+	rules.SeverityLow:      5, // notice
+	rules.SeverityMedium:   4, // warning
+	rules.SeverityHigh:     3, // error
+	rules.SeverityCritical: 2, // critical
+}
+
+// SyslogForwarder forwards the rule matches to a syslog server, the local daemon or a remote server over UDP or TCP,
+// as RFC 5424 messages whose content is the rule match formatted as JSON, CEF or LEEF. The messages are queued, the
+// ones the server can't keep up with are dropped
+type SyslogForwarder struct {
+	// https://github.com/golang/go/issues/36606
+	sent    int64
+	dropped int64
+	errors  int64
+
+	network      string
+	address      string
+	facility     int
+	hostname     string
+	appName      string
+	procID       string
+	format       ruleMatchFormatter
+	connLock     sync.Mutex
+	conn         net.Conn
+	queue        chan []byte
+	ctx          context.Context
+	cancel       context.CancelFunc
+	done         chan struct{}
+	closeTimeout time.Duration
+}
+
+// NewSyslogForwarder returns a new syslog forwarder, connected to the server once the first message is sent
+func NewSyslogForwarder(cfg *config.Config) (*SyslogForwarder, error) {
+	facility, exists := syslogFacilities[cfg.SyslogFacility]
+	if !exists {
+		return nil, errors.Errorf("unknown syslog facility `%s`", cfg.SyslogFacility)
+	}
+
+	address := cfg.SyslogAddress
+	switch cfg.SyslogNetwork {
+	case "", "unix", "unixgram":
+		if address == "" {
+			address = syslogDefaultAddress
+		}
+	case "udp", "tcp":
+		if address == "" {
+			return nil, errors.Errorf("the address of the %s syslog server is required", cfg.SyslogNetwork)
+		}
+	default:
+		return nil, errors.Errorf("unsupported syslog network `%s`", cfg.SyslogNetwork)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = ""
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	f := &SyslogForwarder{
+		network:      cfg.SyslogNetwork,
+		address:      address,
+		facility:     facility,
+		hostname:     syslogHeaderField(hostname, 255),
+		appName:      syslogHeaderField(cfg.SyslogAppName, 48),
+		procID:       strconv.Itoa(os.Getpid()),
+		format:       newRuleMatchFormatter(cfg.SyslogFormat),
+		queue:        make(chan []byte, syslogQueueSize),
+		ctx:          ctx,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+		closeTimeout: syslogTimeout,
+	}
+	go f.run()
+
+	return f, nil
+}
+
+// syslogHeaderField returns the given value as a field of the header of RFC 5424, made of at most the given number of
+// printable ASCII characters, the nil value when empty
+func syslogHeaderField(value string, maxLength int) string {
+	if value == "" {
+		return syslogNilValue
+	}
+
+	field := []byte(value)
+	if len(field) > maxLength {
+		field = field[:maxLength]
+	}
+	for i, c := range field {
+		if c < 33 || c > 126 {
+			field[i] = '_'
+		}
+	}
+	return string(field)
+}
+
+// formatMessage returns the RFC 5424 message of the given content, without structured data
+func (f *SyslogForwarder) formatMessage(timestamp time.Time, severity rules.Severity, msgID string, content []byte) []byte {
+	syslogSeverity, exists := syslogSeverities[severity]
+	if !exists {
+		syslogSeverity = syslogSeverities[rules.SeverityMedium]
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s %s %s ",
+		f.facility*8+syslogSeverity,
+		timestamp.UTC().Format(syslogTimestampFormat),
+		f.hostname,
+		f.appName,
+		f.procID,
+		syslogHeaderField(msgID, 32),
+		syslogNilValue)
+	buf.Write(content)
+
+	return buf.Bytes()
+}
+
+// Forward queues the given rule match, data being its JSON serialization
+func (f *SyslogForwarder) Forward(match *sprobe.RuleMatchSerializer, data []byte, severity rules.Severity) {
+	content, err := f.format(match, data, severity)
+	if err != nil {
+		atomic.AddInt64(&f.errors, 1)
+		log.Debugf("failed to serialize the syslog message of rule `%s`: %s", match.RuleID, err)
+		return
+	}
+
+	select {
+	case f.queue <- f.formatMessage(match.Event.Timestamp, severity, match.RuleID, content):
+	default:
+		atomic.AddInt64(&f.dropped, 1)
+	}
+}
+
+func (f *SyslogForwarder) dial() (net.Conn, error) {
+	dialer := net.Dialer{Timeout: syslogTimeout}
+	if f.network != "" {
+		return dialer.DialContext(f.ctx, f.network, f.address)
+	}
+
+	// the local daemon usually listens on a datagram socket
+	conn, err := dialer.DialContext(f.ctx, "unixgram", f.address)
+	if err != nil {
+		conn, err = dialer.DialContext(f.ctx, "unix", f.address)
+	}
+	return conn, err
+}
+
+// connect returns the connection to the server, dialed if needed. No connection is returned once the forwarder is
+// closed
+func (f *SyslogForwarder) connect() (net.Conn, error) {
+	f.connLock.Lock()
+	defer f.connLock.Unlock()
+
+	if err := f.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if f.conn == nil {
+		conn, err := f.dial()
+		if err != nil {
+			return nil, err
+		}
+		f.conn = conn
+	}
+	return f.conn, nil
+}
+
+// disconnect closes the given connection if it is still the current one
+func (f *SyslogForwarder) disconnect(conn net.Conn) {
+	f.connLock.Lock()
+	defer f.connLock.Unlock()
+
+	if f.conn == conn {
+		f.conn.Close()
+		f.conn = nil
+	}
+}
+
+// frame returns the given message as it is written to the given connection: one message per datagram, prefixed with
+// its length over TCP, as described by RFC 6587, and terminated by a new line over the local stream sockets
+func frame(conn net.Conn, msg []byte) []byte {
+	switch conn.RemoteAddr().Network() {
+	case "tcp":
+		return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	case "unix":
+		return append(msg, '\n')
+	default:
+		return msg
+	}
+}
+
+// send writes the given message, reconnecting to the server once if the connection failed
+func (f *SyslogForwarder) send(msg []byte) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var conn net.Conn
+		if conn, err = f.connect(); err != nil {
+			continue
+		}
+
+		if err = conn.SetWriteDeadline(time.Now().Add(syslogTimeout)); err == nil {
+			if _, err = conn.Write(frame(conn, msg)); err == nil {
+				return nil
+			}
+		}
+
+		f.disconnect(conn)
+	}
+	return errors.Wrapf(err, "failed to send the message to the syslog server %s", f.address)
+}
+
+func (f *SyslogForwarder) run() {
+	defer close(f.done)
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case msg, ok := <-f.queue:
+			if !ok {
+				return
+			}
+
+			if err := f.send(msg); err != nil {
+				atomic.AddInt64(&f.errors, 1)
+				log.Debug(err)
+				continue
+			}
+			atomic.AddInt64(&f.sent, 1)
+		}
+	}
+}
+
+// SendStats sends statistics about the messages sent to the syslog server, dropped and failed to be sent since the
+// last call
+func (f *SyslogForwarder) SendStats(client *statsd.Client) error {
+	for metric, counter := range map[string]*int64{"sent": &f.sent, "dropped": &f.dropped, "errors": &f.errors} {
+		if count := atomic.SwapInt64(counter, 0); count > 0 {
+			if err := client.Count(sprobe.MetricPrefix+".syslog."+metric, count, nil, 1.0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close stops the forwarder once it sent the queued messages, or after a timeout when the server is unreachable. The
+// pending connection and write are then interrupted, and the connection is closed
+func (f *SyslogForwarder) Close() {
+	close(f.queue)
+
+	select {
+	case <-f.done:
+	case <-time.After(f.closeTimeout):
+		log.Warnf("the queued messages couldn't all be sent to the syslog server %s", f.address)
+	}
+
+	f.cancel()
+
+	f.connLock.Lock()
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+	f.connLock.Unlock()
+
+	<-f.done
+}
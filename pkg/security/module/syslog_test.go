@@ -0,0 +1,187 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func TestSyslogHeaderField(t *testing.T) {
+	for value, expected := range map[string]string{
+		"":                "-",
+		"host":            "host",
+		"my host\n":       "my_host_",
+		"a-very-long-val": "a-very-lon",
+	} {
+		if field := syslogHeaderField(value, 10); field != expected {
+			t.Errorf("expected `%s` for `%s`, got `%s`", expected, value, field)
+		}
+	}
+}
+
+func newTestSyslogForwarder(t *testing.T, network string, address string) *SyslogForwarder {
+	f, err := NewSyslogForwarder(&config.Config{
+		SyslogNetwork:  network,
+		SyslogAddress:  address,
+		SyslogFacility: "auth",
+		SyslogAppName:  "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func forwardTestRuleMatch(t *testing.T, f *SyslogForwarder) {
+	match, data := serializeTestRuleMatch(t, "test_rule")
+	f.Forward(match, data, rules.SeverityHigh)
+}
+
+// checkSyslogMessage checks the header and the content of the message of the test rule match
+func checkSyslogMessage(t *testing.T, msg string) {
+	fields := strings.SplitN(msg, " ", 8)
+	if len(fields) != 8 {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+
+	// auth (4) * 8 + error (3)
+	if fields[0] != "<35>1" || fields[1] != "2020-10-15T12:00:00.000000Z" || fields[3] != "test" || fields[5] != "test_rule" || fields[6] != "-" {
+		t.Errorf("unexpected header: %s", msg)
+	}
+
+	var match sprobe.RuleMatchSerializer
+	if err := json.Unmarshal([]byte(fields[7]), &match); err != nil {
+		t.Fatal(err)
+	}
+	if match.RuleID != "test_rule" || match.Severity != "high" || match.RuleVersion != "1.0" {
+		t.Errorf("unexpected rule match: %+v", match)
+	}
+}
+
+func TestSyslogForwarderUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	f := newTestSyslogForwarder(t, "udp", conn.LocalAddr().String())
+	defer f.Close()
+	forwardTestRuleMatch(t, f)
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSyslogMessage(t, string(buf[:n]))
+}
+
+func TestSyslogForwarderTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	f := newTestSyslogForwarder(t, "tcp", ln.Addr().String())
+	defer f.Close()
+	forwardTestRuleMatch(t, f)
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the messages are prefixed with their length
+	reader := bufio.NewReader(conn)
+	length, err := reader.ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var size int
+	if _, err := fmt.Sscanf(length, "%d ", &size); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(reader, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	checkSyslogMessage(t, string(msg))
+}
+
+func TestSyslogForwarderClose(t *testing.T) {
+	// the server never reads, the write blocks
+	server, client := net.Pipe()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &SyslogForwarder{
+		address:      "pipe",
+		conn:         client,
+		queue:        make(chan []byte, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+		closeTimeout: 100 * time.Millisecond,
+	}
+	go f.run()
+	f.queue <- []byte("message")
+
+	start := time.Now()
+	f.Close()
+	if elapsed := time.Since(start); elapsed >= syslogTimeout {
+		t.Errorf("expected the pending write to be interrupted, closed after %s", elapsed)
+	}
+
+	select {
+	case <-f.done:
+	default:
+		t.Error("the forwarder should be stopped")
+	}
+
+	if _, err := server.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected the connection to be closed, got %v", err)
+	}
+}
+
+func TestSyslogForwarderConfig(t *testing.T) {
+	for _, cfg := range []*config.Config{
+		{SyslogFacility: "unknown"},
+		{SyslogFacility: "local0", SyslogNetwork: "udp"},
+		{SyslogFacility: "local0", SyslogNetwork: "sctp", SyslogAddress: "127.0.0.1:514"},
+	} {
+		if _, err := NewSyslogForwarder(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	// webhookQueueSize is the number of rule matches queued to the webhook, the ones it can't keep up with are dropped
+	webhookQueueSize = 1000
+	// webhookMaxRetryDelay caps the delay between the retries of a request
+	webhookMaxRetryDelay = 30 * time.Second
+	// webhookCloseTimeout bounds the time spent posting the queued rule matches when the module stops
+	webhookCloseTimeout = 5 * time.Second
+	// webhookMaxResponseSize is the size of the response bodies read, so that the connections are reused
+	webhookMaxResponseSize = 64 * 1024
+)
+
+// WebhookSink posts the rule matches, as JSON, to an HTTPS webhook, optionally authenticated with custom headers or
+// mutual TLS. The requests failing because of a network error, a server error or a rate limit are retried with an
+// exponential backoff. The rule matches are queued, the ones the webhook can't keep up with are dropped
+type WebhookSink struct {
+	// https://github.com/golang/go/issues/36606
+	sent    int64
+	dropped int64
+	retries int64
+	errors  int64
+
+	url        string
+	headers    map[string]string
+	client     *http.Client
+	maxRetries int
+	retryDelay time.Duration
+	queue      chan []byte
+	ctx        context.Context
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// loadWebhookTLSConfig sets up the given TLS configuration to verify the webhook server with the configured CA
+// certificates and to authenticate to it with the configured client certificate
+func loadWebhookTLSConfig(cfg *config.Config, tlsConfig *tls.Config) error {
+	if cfg.WebhookCAFile != "" {
+		pem, err := ioutil.ReadFile(cfg.WebhookCAFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to read the CA certificates of the webhook")
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return errors.Errorf("no valid CA certificate found in %s", cfg.WebhookCAFile)
+		}
+	}
+
+	if cfg.WebhookCertFile != "" || cfg.WebhookKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.WebhookCertFile, cfg.WebhookKeyFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to load the client certificate of the webhook")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return nil
+}
+
+// NewWebhookSink returns a new webhook sink
+func NewWebhookSink(cfg *config.Config) (*WebhookSink, error) {
+	u, err := url.Parse(cfg.WebhookURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid webhook URL")
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return nil, errors.Errorf("invalid webhook URL `%s`, expected an HTTPS URL", cfg.WebhookURL)
+	}
+
+	transport := httputils.CreateHTTPTransport()
+	if err := loadWebhookTLSConfig(cfg, transport.TLSClientConfig); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &WebhookSink{
+		url:     cfg.WebhookURL,
+		headers: cfg.WebhookHeaders,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.WebhookTimeout,
+		},
+		maxRetries: cfg.WebhookMaxRetries,
+		retryDelay: cfg.WebhookRetryDelay,
+		queue:      make(chan []byte, webhookQueueSize),
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	go s.run()
+
+	return s, nil
+}
+
+// Post queues the given serialized rule match
+func (s *WebhookSink) Post(data []byte) {
+	select {
+	case s.queue <- data:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+// post posts the given payload once, it returns whether the request can be retried when it failed
+func (s *WebhookSink) post(payload []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(s.ctx)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "datadog-runtime-security/"+version.AgentVersion)
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return s.ctx.Err() == nil, errors.Wrapf(err, "failed to post to the webhook %s", s.url)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, webhookMaxResponseSize))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, errors.Errorf("failed to post to the webhook %s: unexpected status %s", s.url, resp.Status)
+	default:
+		return false, errors.Errorf("failed to post to the webhook %s: unexpected status %s", s.url, resp.Status)
+	}
+}
+
+// send posts the given payload, retrying the failed requests after a delay doubled at each retry
+func (s *WebhookSink) send(payload []byte) error {
+	delay := s.retryDelay
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(payload)
+		if err == nil || !retry || attempt >= s.maxRetries {
+			return err
+		}
+
+		atomic.AddInt64(&s.retries, 1)
+		log.Tracef("%s, retrying in %s", err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return err
+		}
+
+		if delay *= 2; delay > webhookMaxRetryDelay {
+			delay = webhookMaxRetryDelay
+		}
+	}
+}
+
+func (s *WebhookSink) run() {
+	defer close(s.done)
+
+	for payload := range s.queue {
+		if err := s.send(payload); err != nil {
+			atomic.AddInt64(&s.errors, 1)
+			log.Debug(err)
+			continue
+		}
+		atomic.AddInt64(&s.sent, 1)
+	}
+}
+
+// SendStats sends statistics about the rule matches posted to the webhook, dropped and failed to be posted, and about
+// the retried requests, since the last call
+func (s *WebhookSink) SendStats(client *statsd.Client) error {
+	for metric, counter := range map[string]*int64{"sent": &s.sent, "dropped": &s.dropped, "retries": &s.retries, "errors": &s.errors} {
+		if count := atomic.SwapInt64(counter, 0); count > 0 {
+			if err := client.Count(sprobe.MetricPrefix+".webhook."+metric, count, nil, 1.0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close stops the sink once it posted the queued rule matches, the pending requests are cancelled after a timeout
+func (s *WebhookSink) Close() {
+	close(s.queue)
+
+	select {
+	case <-s.done:
+	case <-time.After(webhookCloseTimeout):
+		log.Warnf("the queued rule matches couldn't all be posted to the webhook %s", s.url)
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+}
@@ -0,0 +1,196 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+)
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestClientCert writes a self-signed client certificate and its key to the given directory
+func newTestClientCert(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "runtime-security"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+
+	return cert, certFile, keyFile
+}
+
+func TestWebhookSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clientCert, certFile, keyFile := newTestClientCert(t, dir)
+
+	var requests int32
+	matches := make(chan sprobe.RuleMatchSerializer, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first request fails and is retried
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Token secret" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+
+		var match sprobe.RuleMatchSerializer
+		if err := json.NewDecoder(r.Body).Decode(&match); err != nil {
+			t.Error(err)
+		}
+		matches <- match
+	}))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(dir, "ca.crt")
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+
+	s, err := NewWebhookSink(&config.Config{
+		WebhookURL:        server.URL + "/alerts",
+		WebhookHeaders:    map[string]string{"authorization": "Token secret"},
+		WebhookCAFile:     caFile,
+		WebhookCertFile:   certFile,
+		WebhookKeyFile:    keyFile,
+		WebhookTimeout:    5 * time.Second,
+		WebhookMaxRetries: 2,
+		WebhookRetryDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	_, data := serializeTestRuleMatch(t, "test_rule")
+	s.Post(data)
+
+	select {
+	case match := <-matches:
+		if match.RuleID != "test_rule" || match.Severity != "high" || match.RuleVersion != "1.0" {
+			t.Errorf("unexpected rule match: %+v", match)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the rule match wasn't posted")
+	}
+
+	if retries := atomic.LoadInt64(&s.retries); retries != 1 {
+		t.Errorf("expected 1 retry, got %d", retries)
+	}
+}
+
+func TestWebhookSinkRetries(t *testing.T) {
+	var requests int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	for path, expected := range map[string]int32{
+		// the client errors aren't retried
+		"/invalid": 1,
+		"/failing": 3,
+	} {
+		atomic.StoreInt32(&requests, 0)
+
+		s := &WebhookSink{
+			url:        server.URL + path,
+			client:     server.Client(),
+			maxRetries: 2,
+			retryDelay: time.Millisecond,
+		}
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+
+		if err := s.send([]byte("{}")); err == nil {
+			t.Errorf("expected an error for %s", path)
+		}
+		if count := atomic.LoadInt32(&requests); count != expected {
+			t.Errorf("expected %d requests for %s, got %d", expected, path, count)
+		}
+		s.cancel()
+	}
+}
+
+func TestWebhookSinkConfig(t *testing.T) {
+	for _, cfg := range []*config.Config{
+		{WebhookURL: ""},
+		{WebhookURL: "http://127.0.0.1/alerts"},
+		{WebhookURL: "https://127.0.0.1/alerts", WebhookCAFile: "/does/not/exist"},
+		{WebhookURL: "https://127.0.0.1/alerts", WebhookCertFile: "/does/not/exist"},
+	} {
+		if _, err := NewWebhookSink(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package policy
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+// Overrides holds the overrides of the rules of the policies specific to a host, so that the rules of the default
+// policies can be disabled or suppressed without editing them
+type Overrides struct {
+	Rules []*RuleOverride `yaml:"rules"`
+}
+
+// RuleOverride holds the override of a rule, its suppressions are added to the ones of the rule
+type RuleOverride struct {
+	ID           rules.RuleID                   `yaml:"id"`
+	Enabled      *bool                          `yaml:"enabled"`
+	Suppressions []*rules.SuppressionDefinition `yaml:"suppressions"`
+}
+
+// LoadOverrides loads the overrides file at the given path, a missing file holds no override
+func LoadOverrides(path string) (*Overrides, error) {
+	overrides := &Overrides{}
+	if path == "" {
+		return overrides, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return overrides, nil
+		}
+		return nil, errors.Wrap(err, "failed to load overrides")
+	}
+	defer f.Close()
+
+	if err := yaml.NewDecoder(f).Decode(overrides); err != nil {
+		return nil, errors.Wrap(err, "failed to load overrides")
+	}
+
+	for _, ruleOverride := range overrides.Rules {
+		if ruleOverride.ID == "" {
+			return nil, errors.New("rule override has no rule ID")
+		}
+		if !checkRuleID(ruleOverride.ID) {
+			return nil, fmt.Errorf("rule override ID does not match pattern %s", ruleIDPattern)
+		}
+		if err := checkSuppressions(ruleOverride.ID, ruleOverride.Suppressions); err != nil {
+			return nil, err
+		}
+	}
+
+	return overrides, nil
+}
+
+// apply applies the overrides to the rules of a policy
+func (o *Overrides) apply(policy *Policy) {
+	for _, ruleOverride := range o.Rules {
+		for _, ruleDef := range policy.Rules {
+			if ruleDef.ID != ruleOverride.ID {
+				continue
+			}
+			if ruleOverride.Enabled != nil {
+				ruleDef.Enabled = ruleOverride.Enabled
+			}
+			ruleDef.Suppressions = append(ruleDef.Suppressions, ruleOverride.Suppressions...)
+		}
+	}
+}
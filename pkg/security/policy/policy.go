@@ -6,12 +6,16 @@
 package policy
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/security/config"
 	"github.com/DataDog/datadog-agent/pkg/security/rules"
@@ -21,11 +25,15 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-// Policy represents a policy file which is composed of a list of rules and macros
+// Policy represents a policy file which is composed of a list of rules, macros and lists, along with the tests of
+// its rules. The scope of a policy applies to its rules having no scope of their own
 type Policy struct {
 	Version string                   `yaml:"version"`
+	Scope   *rules.ScopeDefinition   `yaml:"scope"`
 	Rules   []*rules.RuleDefinition  `yaml:"rules"`
 	Macros  []*rules.MacroDefinition `yaml:"macros"`
+	Lists   []*rules.ListDefinition  `yaml:"lists"`
+	Tests   []*TestDefinition        `yaml:"tests"`
 }
 
 var ruleIDPattern = `^([a-zA-Z0-9]*_*)*$`
@@ -35,15 +43,50 @@ func checkRuleID(ruleID string) bool {
 	return pattern.MatchString(ruleID)
 }
 
+// checkSuppressions checks the suppressions of a rule, each one requires an ID as their hits are reported per ID
+func checkSuppressions(ruleID string, suppressions []*rules.SuppressionDefinition) error {
+	for _, suppressionDef := range suppressions {
+		if suppressionDef.ID == "" {
+			return fmt.Errorf("rule %s has a suppression without ID", ruleID)
+		}
+		if !checkRuleID(suppressionDef.ID) {
+			return fmt.Errorf("rule %s suppression ID does not match pattern %s", ruleID, ruleIDPattern)
+		}
+		if suppressionDef.Expression == "" {
+			return fmt.Errorf("rule %s suppression %s has no expression", ruleID, suppressionDef.ID)
+		}
+	}
+	return nil
+}
+
 // LoadPolicy loads a YAML file and returns a new policy
 func LoadPolicy(r io.Reader) (*Policy, error) {
+	return loadPolicy(r, false)
+}
+
+// loadPolicy loads a YAML file and returns a new policy. In strict mode, the unknown fields are reported as errors
+func loadPolicy(r io.Reader, strict bool) (*Policy, error) {
 	policy := &Policy{}
 
 	decoder := yaml.NewDecoder(r)
+	decoder.SetStrict(strict)
 	if err := decoder.Decode(&policy); err != nil {
 		return nil, errors.Wrap(err, "failed to load policy")
 	}
 
+	for _, listDef := range policy.Lists {
+		if listDef.ID == "" {
+			return nil, errors.New("list has no name")
+		}
+		if !checkRuleID(listDef.ID) {
+			return nil, fmt.Errorf("list ID does not match pattern %s", ruleIDPattern)
+		}
+
+		if len(listDef.Values) == 0 && listDef.File == "" {
+			return nil, errors.New("list has neither values nor file")
+		}
+	}
+
 	for _, macroDef := range policy.Macros {
 		if macroDef.ID == "" {
 			return nil, errors.New("macro has no name")
@@ -55,6 +98,12 @@ func LoadPolicy(r io.Reader) (*Policy, error) {
 		if macroDef.Expression == "" {
 			return nil, errors.New("macro has no expression")
 		}
+
+		for _, parameter := range macroDef.Parameters {
+			if parameter == "" || !checkRuleID(parameter) {
+				return nil, fmt.Errorf("macro %s parameter does not match pattern %s", macroDef.ID, ruleIDPattern)
+			}
+		}
 	}
 
 	for _, ruleDef := range policy.Rules {
@@ -68,13 +117,77 @@ func LoadPolicy(r io.Reader) (*Policy, error) {
 		if ruleDef.Expression == "" {
 			return nil, errors.New("rule has no expression")
 		}
+
+		if ruleDef.Mode != "" && ruleDef.Mode != rules.RuleModeSimulation {
+			return nil, fmt.Errorf("rule %s has an unknown mode `%s`", ruleDef.ID, ruleDef.Mode)
+		}
+
+		if _, err := rules.ParseSeverity(ruleDef.Severity); err != nil {
+			return nil, errors.Wrapf(err, "rule %s has an invalid severity", ruleDef.ID)
+		}
+
+		for key := range ruleDef.Tags {
+			if key == "" || key == "rule_id" || key == "rule_version" || key == "simulated" {
+				return nil, fmt.Errorf("rule %s has an invalid tag key `%s`", ruleDef.ID, key)
+			}
+		}
+
+		if err := checkSuppressions(ruleDef.ID, ruleDef.Suppressions); err != nil {
+			return nil, err
+		}
+
+		for _, actionDef := range ruleDef.Actions {
+			if actionDef.Set == nil && actionDef.Kill == "" {
+				return nil, fmt.Errorf("rule %s has an empty action", ruleDef.ID)
+			}
+			if actionDef.Set != nil && (actionDef.Set.Name == "" || actionDef.Set.Value == nil) {
+				return nil, fmt.Errorf("rule %s sets a variable without name or value", ruleDef.ID)
+			}
+			if actionDef.Kill != "" && !rules.KillSignals[actionDef.Kill] {
+				return nil, fmt.Errorf("rule %s sends an unsupported signal `%s`", ruleDef.ID, actionDef.Kill)
+			}
+		}
+
+		if ruleDef.Scope == nil {
+			ruleDef.Scope = policy.Scope
+		}
 	}
 
 	return policy, nil
 }
 
-// LoadPolicies loads the policies listed in the configuration and apply them to the given ruleset
+// LoadPolicies loads the policies listed in the configuration and apply them to the given ruleset. When the remote
+// policies are enabled, the policies of the last valid bundle are loaded instead, if any
 func LoadPolicies(config *config.Config, ruleSet *rules.RuleSet) error {
+	overrides, err := LoadOverrides(config.PoliciesOverridesFile)
+	if err != nil {
+		return err
+	}
+
+	if config.RemotePoliciesEnabled {
+		bundle, err := LoadCachedBundle(config)
+		if err == nil {
+			return loadBundle(config, bundle, overrides, ruleSet)
+		}
+		log.Warnf("no valid remote policy bundle, falling back to the policies of `%s`: %s", config.PoliciesDir, err)
+	}
+
+	return loadPolicyFiles(config, overrides, ruleSet, false)
+}
+
+// CheckPolicies loads the policy files of the policies directory, as LoadPolicies, but also reports the unknown
+// fields of the policies as errors, so that misspelled attributes are not silently ignored
+func CheckPolicies(config *config.Config, ruleSet *rules.RuleSet) error {
+	overrides, err := LoadOverrides(config.PoliciesOverridesFile)
+	if err != nil {
+		return err
+	}
+
+	return loadPolicyFiles(config, overrides, ruleSet, true)
+}
+
+// loadPolicyFiles loads the policy files of the policies directory and apply them to the given ruleset
+func loadPolicyFiles(config *config.Config, overrides *Overrides, ruleSet *rules.RuleSet, strict bool) error {
 	var result *multierror.Error
 
 	policyFiles, err := ioutil.ReadDir(config.PoliciesDir)
@@ -95,27 +208,144 @@ func LoadPolicies(config *config.Config, ruleSet *rules.RuleSet) error {
 		// Open policy path
 		f, err := os.Open(filepath.Join(config.PoliciesDir, filename))
 		if err != nil {
-			result = multierror.Append(result, errors.Wrapf(err, "failed to load policy `%s`", policyPath))
+			result = multierror.Append(result, errors.Wrapf(err, "failed to load policy `%s`", filename))
 			continue
 		}
 
 		// Parse policy file
-		policy, err := LoadPolicy(f)
+		policy, err := loadPolicy(f, strict)
+		f.Close()
+		if err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to load policy `%s`", filename))
+			continue
+		}
+
+		if err := addPolicy(config, policy, overrides, ruleSet); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// addPolicy adds the lists, the macros and the rules of a policy to the given ruleset, once overridden
+func addPolicy(config *config.Config, policy *Policy, overrides *Overrides, ruleSet *rules.RuleSet) error {
+	var result *multierror.Error
+
+	overrides.apply(policy)
+
+	// Add the lists to the ruleset, before the macros and the rules referencing them
+	for _, listDef := range policy.Lists {
+		if listDef.File != "" && !filepath.IsAbs(listDef.File) {
+			listDef.File = filepath.Join(config.PoliciesDir, listDef.File)
+		}
+
+		values, err := loadListValues(listDef)
 		if err != nil {
-			result = multierror.Append(result, errors.Wrapf(err, "failed to load policy `%s`", policyPath))
+			result = multierror.Append(result, err)
 			continue
 		}
 
-		// Add the macros to the ruleset and generate macros evaluators
-		if err := ruleSet.AddMacros(policy.Macros); err != nil {
+		if err := ruleSet.AddList(listDef, values); err != nil {
 			result = multierror.Append(result, err)
 		}
+	}
+
+	// Add the macros to the ruleset and generate macros evaluators
+	if err := ruleSet.AddMacros(policy.Macros); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	// Add rules to the ruleset and generate rules evaluators
+	if err := ruleSet.AddRules(policy.Rules); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	return result.ErrorOrNil()
+}
+
+// loadListValues returns the values of a list, the ones of its definition followed by the ones of its file
+func loadListValues(listDef *rules.ListDefinition) ([]string, error) {
+	values := append([]string{}, listDef.Values...)
+	if listDef.File == "" {
+		return values, nil
+	}
+
+	f, err := os.Open(listDef.File)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load list `%s`", listDef.ID)
+	}
+	defer f.Close()
+
+	// one value per line, empty lines and comments are ignored
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value := strings.TrimSpace(scanner.Text()); value != "" && !strings.HasPrefix(value, "#") {
+			values = append(values, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to load list `%s`", listDef.ID)
+	}
+
+	return values, nil
+}
 
-		// Add rules to the ruleset and generate rules evaluators
-		if err := ruleSet.AddRules(policy.Rules); err != nil {
+// ReloadLists reads again the files of the lists of the given ruleset and replaces their values
+func ReloadLists(ruleSet *rules.RuleSet) error {
+	var result *multierror.Error
+
+	for _, listDef := range ruleSet.GetListDefinitions() {
+		if listDef.File == "" {
+			continue
+		}
+
+		values, err := loadListValues(listDef)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+
+		if err := ruleSet.UpdateList(listDef.ID, values); err != nil {
 			result = multierror.Append(result, err)
 		}
 	}
 
 	return result.ErrorOrNil()
 }
+
+// PoliciesChecksum returns a checksum of the policy files of the policies directory and of the overrides file. It
+// changes whenever a policy file is added, removed or modified, or when the overrides are modified
+func PoliciesChecksum(config *config.Config) (string, error) {
+	policyFiles, err := ioutil.ReadDir(config.PoliciesDir)
+	if err != nil {
+		return "", err
+	}
+
+	var filenames []string
+	for _, policyPath := range policyFiles {
+		if filename := policyPath.Name(); filepath.Ext(filename) == ".policy" {
+			filenames = append(filenames, filepath.Join(config.PoliciesDir, filename))
+		}
+	}
+	if config.PoliciesOverridesFile != "" {
+		filenames = append(filenames, config.PoliciesOverridesFile)
+	}
+
+	h := sha256.New()
+	for _, filename := range filenames {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			if os.IsNotExist(err) && filename == config.PoliciesOverridesFile {
+				continue
+			}
+			return "", errors.Wrapf(err, "failed to read `%s`", filename)
+		}
+
+		fmt.Fprintf(h, "%s:%d\n", filename, len(content))
+		h.Write(content)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadPolicyKillSignal(t *testing.T) {
+	const policy = `rules:
+  - id: kill
+    expression: open.filename == "/etc/shadow"
+    actions:
+      - kill: %s
+`
+
+	if _, err := LoadPolicy(strings.NewReader(strings.Replace(policy, "%s", "SIGKILL", 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(strings.NewReader(strings.Replace(policy, "%s", "SIGUNKNOWN", 1))); err == nil {
+		t.Error("expected a policy sending an unsupported signal to be rejected")
+	}
+}
+
+func TestLoadPolicySuppressionID(t *testing.T) {
+	const policy = `rules:
+  - id: shadow
+    expression: open.filename == "/etc/shadow"
+    suppressions:
+      - id: %s
+        expression: process.name == "backup"
+`
+
+	if _, err := LoadPolicy(strings.NewReader(strings.Replace(policy, "%s", "backup", 1))); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{`""`, `"back-up"`} {
+		if _, err := LoadPolicy(strings.NewReader(strings.Replace(policy, "%s", id, 1))); err == nil {
+			t.Errorf("expected a suppression with the ID %s to be rejected", id)
+		}
+	}
+}
+
+func TestLoadPolicyStrict(t *testing.T) {
+	const policy = `rules:
+  - id: shadow
+    expression: open.filename == "/etc/shadow"
+    severty: high
+`
+
+	// the unknown fields are ignored when the policies are loaded, and reported when they are checked
+	if _, err := loadPolicy(strings.NewReader(policy), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPolicy(strings.NewReader(policy), true); err == nil || !strings.Contains(err.Error(), "severty") {
+		t.Errorf("expected the unknown field to be reported, got %v", err)
+	}
+}
+
+func TestLoadPolicyMode(t *testing.T) {
+	const policy = `rules:
+  - id: trial
+    expression: open.filename == "/etc/shadow"
+    mode: %s
+`
+
+	if _, err := LoadPolicy(strings.NewReader(strings.Replace(policy, "%s", "simulation", 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(strings.NewReader(strings.Replace(policy, "%s", "dry-run", 1))); err == nil {
+		t.Error("expected a rule with an unknown mode to be rejected")
+	}
+
+	// the simulated tag is added to the events of the rules in simulation mode
+	const tagged = `rules:
+  - id: trial
+    expression: open.filename == "/etc/shadow"
+    tags:
+      simulated: "false"
+`
+	if _, err := LoadPolicy(strings.NewReader(tagged)); err == nil {
+		t.Error("expected a rule with the reserved tag key simulated to be rejected")
+	}
+}
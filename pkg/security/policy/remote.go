@@ -0,0 +1,305 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package policy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// bundleFilename is the name of the file caching the last valid bundle
+	bundleFilename = "bundle.json"
+	// versionFilename is the name of the file holding the version of the last accepted bundle, it outlives the cached
+	// bundle so that an older bundle is never accepted again
+	versionFilename = "version"
+	// maxBundleSize is the maximum size of a bundle
+	maxBundleSize = 16 * 1024 * 1024
+	// fetchTimeout is the timeout of the requests fetching the bundles
+	fetchTimeout = 30 * time.Second
+)
+
+// Bundle holds a set of policies delivered by the Datadog backend, indexed by their names
+type Bundle struct {
+	Version  string            `json:"version"`
+	Policies map[string]string `json:"policies"`
+}
+
+// signedBundle is the envelope of a bundle, the signature covers the raw payload of the bundle. Both are base64
+// encoded
+type signedBundle struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+// verifyBundle checks the signature of a bundle and returns its content
+func verifyBundle(publicKey ed25519.PublicKey, data []byte) (*Bundle, error) {
+	var signed signedBundle
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, errors.Wrap(err, "invalid bundle envelope")
+	}
+
+	if !ed25519.Verify(publicKey, signed.Payload, signed.Signature) {
+		return nil, errors.New("invalid bundle signature")
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(signed.Payload, &bundle); err != nil {
+		return nil, errors.Wrap(err, "invalid bundle payload")
+	}
+
+	if bundle.Version == "" {
+		return nil, errors.New("bundle without version")
+	}
+
+	return &bundle, nil
+}
+
+// compareBundleVersions compares two bundle versions, made of numeric segments separated by dots. The segments that
+// aren't numeric are compared as strings
+func compareBundleVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+
+		switch {
+		case aErr == nil && bErr == nil && an != bn:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// lastBundleVersion returns the version of the last bundle accepted, empty if none was
+func lastBundleVersion(config *config.Config) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(config.RemotePoliciesCacheDir, versionFilename))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Wrap(err, "failed to read the version of the last policy bundle")
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func parsePublicKey(value string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid public key")
+	}
+
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size %d", len(key))
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// LoadCachedBundle returns the last valid bundle fetched from the Datadog backend. The signature of the bundle is
+// checked again, so that a modified cache isn't loaded, and a cached bundle older than the last accepted one is
+// rejected
+func LoadCachedBundle(config *config.Config) (*Bundle, error) {
+	publicKey, err := parsePublicKey(config.RemotePoliciesPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(config.RemotePoliciesCacheDir, bundleFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := verifyBundle(publicKey, data)
+	if err != nil {
+		return nil, err
+	}
+
+	lastVersion, err := lastBundleVersion(config)
+	if err != nil {
+		return nil, err
+	}
+	if lastVersion != "" && compareBundleVersions(bundle.Version, lastVersion) < 0 {
+		return nil, fmt.Errorf("cached policy bundle %s older than the last accepted bundle %s", bundle.Version, lastVersion)
+	}
+
+	return bundle, nil
+}
+
+// loadBundle adds the policies of a bundle to the given ruleset, sorted by name
+func loadBundle(config *config.Config, bundle *Bundle, overrides *Overrides, ruleSet *rules.RuleSet) error {
+	var result *multierror.Error
+
+	names := make([]string, 0, len(bundle.Policies))
+	for name := range bundle.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		policy, err := LoadPolicy(strings.NewReader(bundle.Policies[name]))
+		if err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to load policy `%s` of the bundle %s", name, bundle.Version))
+			continue
+		}
+
+		if err := addPolicy(config, policy, overrides, ruleSet); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// RemoteFetcher fetches the policy bundles delivered by the Datadog backend and caches the last valid one
+type RemoteFetcher struct {
+	config    *config.Config
+	client    *http.Client
+	publicKey ed25519.PublicKey
+	etag      string
+}
+
+// NewRemoteFetcher returns a new remote policy fetcher
+func NewRemoteFetcher(config *config.Config) (*RemoteFetcher, error) {
+	publicKey, err := parsePublicKey(config.RemotePoliciesPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(config.RemotePoliciesCacheDir, 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create the remote policies cache")
+	}
+
+	return &RemoteFetcher{
+		config: config,
+		client: &http.Client{
+			Transport: httputils.CreateHTTPTransport(),
+			Timeout:   fetchTimeout,
+		},
+		publicKey: publicKey,
+	}, nil
+}
+
+// Update fetches the current bundle and caches it if it is valid and newer than the last accepted bundle, so that a
+// replayed bundle can't roll the policies back. It returns true if the cached bundle changed
+func (f *RemoteFetcher) Update(ctx context.Context) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, f.config.RemotePoliciesURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("DD-API-KEY", f.config.APIKey)
+	if f.etag != "" {
+		req.Header.Set("If-None-Match", f.etag)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to fetch the policy bundle")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to fetch the policy bundle: unexpected status %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBundleSize+1))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read the policy bundle")
+	}
+	if len(data) > maxBundleSize {
+		return false, fmt.Errorf("policy bundle larger than %d bytes", maxBundleSize)
+	}
+
+	bundle, err := verifyBundle(f.publicKey, data)
+	if err != nil {
+		return false, err
+	}
+
+	lastVersion, err := lastBundleVersion(f.config)
+	if err != nil {
+		return false, err
+	}
+
+	if lastVersion != "" {
+		switch compareBundleVersions(bundle.Version, lastVersion) {
+		case 0:
+			f.etag = resp.Header.Get("ETag")
+			return false, nil
+		case -1:
+			return false, fmt.Errorf("policy bundle %s older than the last accepted bundle %s, rejected", bundle.Version, lastVersion)
+		}
+	}
+
+	if err := f.cache(bundleFilename, data); err != nil {
+		return false, err
+	}
+	if err := f.cache(versionFilename, []byte(bundle.Version)); err != nil {
+		return false, err
+	}
+	f.etag = resp.Header.Get("ETag")
+
+	log.Infof("policy bundle %s received", bundle.Version)
+
+	return true, nil
+}
+
+// cache replaces the given file of the cache, it is written to a temporary file first so that the cache is never
+// partially written
+func (f *RemoteFetcher) cache(filename string, data []byte) error {
+	tmp, err := ioutil.TempFile(f.config.RemotePoliciesCacheDir, filename)
+	if err != nil {
+		return errors.Wrap(err, "failed to cache the policy bundle")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to cache the policy bundle")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to cache the policy bundle")
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(f.config.RemotePoliciesCacheDir, filename))
+}
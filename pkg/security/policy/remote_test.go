@@ -0,0 +1,221 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package policy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+const testPolicy = `rules:
+  - id: %s
+    expression: open.filename == "/etc/%s"
+`
+
+func signBundle(t *testing.T, key ed25519.PrivateKey, bundle *Bundle) []byte {
+	payload, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(&signedBundle{Payload: payload, Signature: ed25519.Sign(key, payload)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newTestBundle(version string, ruleID string) *Bundle {
+	return &Bundle{
+		Version:  version,
+		Policies: map[string]string{"default": fmt.Sprintf(testPolicy, ruleID, ruleID)},
+	}
+}
+
+func newTestRemoteConfig(t *testing.T, publicKey ed25519.PublicKey, url string) *config.Config {
+	dir, err := ioutil.TempDir("", "remote-policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policiesDir := filepath.Join(dir, "policies")
+	if err := os.MkdirAll(policiesDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(policiesDir, "local.policy"), []byte(fmt.Sprintf(testPolicy, "local", "local")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return &config.Config{
+		PoliciesDir:             policiesDir,
+		RemotePoliciesEnabled:   true,
+		RemotePoliciesURL:       url,
+		RemotePoliciesCacheDir:  filepath.Join(dir, "cache"),
+		RemotePoliciesPublicKey: base64.StdEncoding.EncodeToString(publicKey),
+	}
+}
+
+func loadTestRuleIDs(t *testing.T, cfg *config.Config) []string {
+	ruleSet := (&sprobe.Probe{}).NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
+	if err := LoadPolicies(cfg, ruleSet); err != nil {
+		t.Fatal(err)
+	}
+
+	return ruleSet.ListRuleIDs()
+}
+
+func TestVerifyBundle(t *testing.T) {
+	publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := verifyBundle(publicKey, signBundle(t, key, newTestBundle("1.2", "remote")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Version != "1.2" || len(bundle.Policies) != 1 {
+		t.Errorf("unexpected bundle: %+v", bundle)
+	}
+
+	tampered := signBundle(t, key, newTestBundle("1.2", "remote"))
+	var signed signedBundle
+	if err := json.Unmarshal(tampered, &signed); err != nil {
+		t.Fatal(err)
+	}
+	signed.Payload, _ = json.Marshal(newTestBundle("1.3", "remote"))
+	tampered, _ = json.Marshal(&signed)
+
+	for name, data := range map[string][]byte{
+		"envelope":  []byte("not a bundle"),
+		"signature": signBundle(t, otherKey, newTestBundle("1.2", "remote")),
+		"payload":   tampered,
+		"version":   signBundle(t, key, newTestBundle("", "remote")),
+	} {
+		if _, err := verifyBundle(publicKey, data); err == nil {
+			t.Errorf("expected the bundle with an invalid %s to be rejected", name)
+		}
+	}
+}
+
+func TestCompareBundleVersions(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"1", "1", 0},
+		{"1", "2", -1},
+		{"10", "9", 1},
+		{"1.2", "1.10", -1},
+		{"1.2.1", "1.2", 1},
+		{"2020-10-15", "2020-10-14", 1},
+	} {
+		if result := compareBundleVersions(test.a, test.b); result != test.expected {
+			t.Errorf("expected %s compared to %s to be %d, got %d", test.a, test.b, test.expected, result)
+		}
+	}
+}
+
+func TestRemoteFetcher(t *testing.T) {
+	publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var current atomic.Value
+	current.Store(signBundle(t, key, newTestBundle("2", "remote2")))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "api-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write(current.Load().([]byte))
+	}))
+	defer server.Close()
+
+	cfg := newTestRemoteConfig(t, publicKey, server.URL)
+	cfg.APIKey = "api-key"
+	defer os.RemoveAll(filepath.Dir(cfg.PoliciesDir))
+
+	// the local policies are loaded until a bundle is received
+	if ids := loadTestRuleIDs(t, cfg); len(ids) != 1 || ids[0] != "local" {
+		t.Errorf("expected the local policies to be loaded, got %v", ids)
+	}
+
+	fetcher, err := NewRemoteFetcher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := fetcher.Update(context.Background()); err != nil || !changed {
+		t.Fatalf("expected the bundle to be cached: %v", err)
+	}
+	if changed, err := fetcher.Update(context.Background()); err != nil || changed {
+		t.Errorf("the same bundle shouldn't be reported as changed: %v", err)
+	}
+	if ids := loadTestRuleIDs(t, cfg); len(ids) != 1 || ids[0] != "remote2" {
+		t.Errorf("expected the cached bundle to be loaded, got %v", ids)
+	}
+
+	// a replayed older bundle is rejected, the cached bundle is kept
+	older := signBundle(t, key, newTestBundle("1", "remote1"))
+	current.Store(older)
+	if _, err := fetcher.Update(context.Background()); err == nil {
+		t.Error("an older bundle should be rejected")
+	}
+	if ids := loadTestRuleIDs(t, cfg); len(ids) != 1 || ids[0] != "remote2" {
+		t.Errorf("expected the cached bundle to be kept, got %v", ids)
+	}
+
+	// an older bundle written to the cache isn't loaded either
+	if err := ioutil.WriteFile(filepath.Join(cfg.RemotePoliciesCacheDir, bundleFilename), older, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCachedBundle(cfg); err == nil {
+		t.Error("an older cached bundle should be rejected")
+	}
+	if ids := loadTestRuleIDs(t, cfg); len(ids) != 1 || ids[0] != "local" {
+		t.Errorf("expected the local policies to be loaded, got %v", ids)
+	}
+
+	// a corrupted cache falls back to the local policies
+	if err := ioutil.WriteFile(filepath.Join(cfg.RemotePoliciesCacheDir, bundleFilename), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if ids := loadTestRuleIDs(t, cfg); len(ids) != 1 || ids[0] != "local" {
+		t.Errorf("expected the local policies to be loaded, got %v", ids)
+	}
+
+	current.Store(signBundle(t, key, newTestBundle("3", "remote3")))
+	if changed, err := fetcher.Update(context.Background()); err != nil || !changed {
+		t.Fatalf("expected the newer bundle to be cached: %v", err)
+	}
+	if ids := loadTestRuleIDs(t, cfg); len(ids) != 1 || ids[0] != "remote3" {
+		t.Errorf("expected the newer bundle to be loaded, got %v", ids)
+	}
+}
@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// testFileExt is the extension of the test files, the tests of `file.policy` can be written in `file.policy.test`
+const testFileExt = ".test"
+
+// TestDefinition holds the definition of a test of the rules of a policy: an example event, described by its type
+// and the values of its fields, along with the rules expected to match it and the ones expected not to
+type TestDefinition struct {
+	Name    string                     `yaml:"name"`
+	Type    eval.EventType             `yaml:"type"`
+	Fields  map[eval.Field]interface{} `yaml:"fields"`
+	Match   []rules.RuleID             `yaml:"match"`
+	NoMatch []rules.RuleID             `yaml:"no_match"`
+}
+
+// TestFile represents a test file, holding the tests of the policy file it is named after
+type TestFile struct {
+	Tests []*TestDefinition `yaml:"tests"`
+}
+
+// TestResult holds the result of a test, it passed if it has no failure
+type TestResult struct {
+	Policy   string         `json:"policy"`
+	Name     string         `json:"name"`
+	Matches  []rules.RuleID `json:"matches"`
+	Failures []string       `json:"failures,omitempty"`
+}
+
+// Passed returns whether the test passed
+func (r *TestResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+func checkTests(tests []*TestDefinition) error {
+	for i, testDef := range tests {
+		if testDef.Name == "" {
+			return fmt.Errorf("test #%d has no name", i+1)
+		}
+		if len(testDef.Fields) == 0 {
+			return fmt.Errorf("test `%s` has no field", testDef.Name)
+		}
+		if len(testDef.Match) == 0 && len(testDef.NoMatch) == 0 {
+			return fmt.Errorf("test `%s` expects neither matching nor non-matching rules", testDef.Name)
+		}
+	}
+	return nil
+}
+
+// loadTestFile loads the test file at the given path, a missing file holds no test
+func loadTestFile(path string) ([]*TestDefinition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	testFile := &TestFile{}
+
+	decoder := yaml.NewDecoder(f)
+	decoder.SetStrict(true)
+	if err := decoder.Decode(testFile); err != nil {
+		return nil, err
+	}
+
+	return testFile.Tests, checkTests(testFile.Tests)
+}
+
+// loadPolicyTests returns the tests of a policy file, the ones embedded in the policy followed by the ones of its
+// test file
+func loadPolicyTests(path string) ([]*TestDefinition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	policy, err := loadPolicy(f, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkTests(policy.Tests); err != nil {
+		return nil, err
+	}
+
+	tests, err := loadTestFile(path + testFileExt)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(policy.Tests, tests...), nil
+}
+
+// runTest evaluates the event of a test against the given ruleset and checks the rules matching it
+func runTest(ruleSet *rules.RuleSet, testDef *TestDefinition) *TestResult {
+	result := &TestResult{Name: testDef.Name}
+
+	matches, err := ruleSet.MatchEvent(testDef.Type, testDef.Fields)
+	if err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("invalid event: %s", err))
+		return result
+	}
+	result.Matches = matches
+
+	isMatching := func(id rules.RuleID) bool {
+		for _, match := range matches {
+			if match == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, id := range testDef.Match {
+		if ruleSet.GetRuleDefinition(id) == nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("rule `%s` not found", id))
+		} else if !isMatching(id) {
+			result.Failures = append(result.Failures, fmt.Sprintf("rule `%s` doesn't match", id))
+		}
+	}
+
+	for _, id := range testDef.NoMatch {
+		if ruleSet.GetRuleDefinition(id) == nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("rule `%s` not found", id))
+		} else if isMatching(id) {
+			result.Failures = append(result.Failures, fmt.Sprintf("rule `%s` matches", id))
+		}
+	}
+
+	return result
+}
+
+// RunTests runs the tests of the policy files of the policies directory against the given ruleset, the one the
+// policies were loaded in. The returned error reports the test files that couldn't be loaded, the failures of the
+// tests are reported by their results
+func RunTests(config *config.Config, ruleSet *rules.RuleSet) ([]*TestResult, error) {
+	var result *multierror.Error
+
+	policyFiles, err := ioutil.ReadDir(config.PoliciesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*TestResult
+	for _, policyPath := range policyFiles {
+		filename := policyPath.Name()
+		if filepath.Ext(filename) != ".policy" {
+			continue
+		}
+
+		tests, err := loadPolicyTests(filepath.Join(config.PoliciesDir, filename))
+		if err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to load the tests of `%s`", filename))
+			continue
+		}
+
+		for _, testDef := range tests {
+			testResult := runTest(ruleSet, testDef)
+			testResult.Policy = filename
+			results = append(results, testResult)
+		}
+	}
+
+	return results, result.ErrorOrNil()
+}
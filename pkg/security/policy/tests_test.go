@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func TestRunTests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		// the tests embedded in the policy run before the ones of its test file
+		"passing.policy": `rules:
+  - id: shadow
+    expression: open.filename == "/etc/shadow"
+tests:
+  - name: shadow_read
+    type: open
+    fields:
+      open.filename: /etc/shadow
+    match: [shadow]
+`,
+		"passing.policy.test": `tests:
+  - name: passwd_read
+    fields:
+      open.filename: /etc/passwd
+    no_match: [shadow]
+`,
+		"failing.policy": `rules:
+  - id: passwd
+    expression: open.filename == "/etc/passwd"
+`,
+		"failing.policy.test": `tests:
+  - name: hosts_read
+    type: open
+    fields:
+      open.filename: /etc/hosts
+    match: [passwd, unknown]
+`,
+		"malformed.policy": `rules:
+  - id: hosts
+    expression: open.filename == "/etc/hosts"
+`,
+		"malformed.policy.test": `tests:
+  - fields:
+      open.filename: /etc/hosts
+    match: [hosts]
+`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{PoliciesDir: dir}
+	ruleSet := (&sprobe.Probe{}).NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
+	if err := LoadPolicies(cfg, ruleSet); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := RunTests(cfg, ruleSet)
+	if err == nil || !strings.Contains(err.Error(), "failed to load the tests of `malformed.policy`: test #1 has no name") {
+		t.Errorf("expected the malformed test file to be reported, got %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected the results of the tests of the valid test files, got %+v", results)
+	}
+
+	failing := results[0]
+	if failing.Policy != "failing.policy" || failing.Name != "hosts_read" || failing.Passed() {
+		t.Errorf("expected the test of failing.policy to fail, got %+v", failing)
+	}
+	expected := []string{"rule `passwd` doesn't match", "rule `unknown` not found"}
+	if !reflect.DeepEqual(failing.Failures, expected) {
+		t.Errorf("expected the failures %v, got %v", expected, failing.Failures)
+	}
+
+	for i, name := range []string{"shadow_read", "passwd_read"} {
+		result := results[i+1]
+		if result.Policy != "passing.policy" || result.Name != name || !result.Passed() {
+			t.Errorf("expected the test `%s` of passing.policy to pass, got %+v", name, result)
+		}
+	}
+	if matches := results[1].Matches; len(matches) != 1 || matches[0] != "shadow" {
+		t.Errorf("expected the shadow rule to match, got %v", matches)
+	}
+
+	// the embedded tests are checked along with the ones of the test files
+	if err := ioutil.WriteFile(filepath.Join(dir, "passing.policy"), []byte(files["passing.policy"]+"    no_match: []\n  - name: no_expectation\n    fields:\n      open.filename: /etc/shadow\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPolicyTests(filepath.Join(dir, "passing.policy")); err == nil || !strings.Contains(err.Error(), "test `no_expectation` expects neither matching nor non-matching rules") {
+		t.Errorf("expected the embedded test without expectation to be reported, got %v", err)
+	}
+}
@@ -33,6 +33,22 @@ type Applier interface {
 	RegisterProbesSelectors(selectors []manager.ProbesSelector) error
 }
 
+// standbyApplier is implemented by the appliers able to switch an event type left in accept mode to its approvers
+// later on, when the event pipeline is saturated
+type standbyApplier interface {
+	SetStandbyApprovers(eventType eval.EventType, approvers rules.Approvers, flags PolicyFlag)
+}
+
+// containerPolicyApplier is implemented by the appliers able to override the policy of an event type per container
+type containerPolicyApplier interface {
+	ApplyContainerFilterPolicy(containerID string, eventType eval.EventType, mode PolicyMode, flags PolicyFlag) error
+}
+
+// preEvalApplier is implemented by the appliers able to pre-evaluate the rules of an event type in kernel
+type preEvalApplier interface {
+	ApplyDecisionTable(eventType eval.EventType, table rules.DecisionTable) error
+}
+
 func (rsa *RuleSetApplier) applyFilterPolicy(eventType eval.EventType, mode PolicyMode, flags PolicyFlag, applier Applier) error {
 	if err := rsa.reporter.SetFilterPolicy(eventType, mode, flags); err != nil {
 		return err
@@ -64,58 +80,174 @@ func (rsa *RuleSetApplier) registerProbesSelectors(selectors []manager.ProbesSel
 	return nil
 }
 
-func (rsa *RuleSetApplier) setupFilters(rs *rules.RuleSet, eventType eval.EventType, applier Applier) error {
+// setupStandbyApprovers computes the approvers of an event type left in accept mode, so that the applier can switch
+// to them if the event pipeline gets saturated
+func (rsa *RuleSetApplier) setupStandbyApprovers(rs *rules.RuleSet, eventType eval.EventType, applier Applier) {
+	standby, ok := applier.(standbyApplier)
+	if !ok || !rsa.config.LoadControllerStandbyApprovers {
+		return
+	}
+
+	capabilities, exists := allCapabilities[eventType]
+	if !exists {
+		return
+	}
+
+	approvers, err := rs.GetApprovers(eventType, capabilities.GetFieldCapabilities())
+	if err != nil {
+		return
+	}
+
+	if combine, exists := allApproversCombiners[eventType]; exists {
+		approvers = combine(rs, approvers)
+	}
+
+	standby.SetStandbyApprovers(eventType, approvers, capabilities.GetApproversFlags(approvers))
+}
+
+// reportRules reports the event type of the rules of the given event type, and the approvers of each of them
+func (rsa *RuleSetApplier) reportRules(rs *rules.RuleSet, eventType eval.EventType) {
+	bucket := rs.GetBucket(eventType)
+	if bucket == nil {
+		return
+	}
+
+	capabilities, exists := allCapabilities[eventType]
+
+	for _, rule := range bucket.GetRules() {
+		var approvers rules.Approvers
+		if exists && rsa.config.EnableKernelFilters && rsa.config.EnableApprovers {
+			approvers, _ = rs.GetRuleApprovers(rule.ID, capabilities.GetFieldCapabilities())
+		}
+		rsa.reporter.SetRule(rule.ID, eventType, approvers)
+	}
+}
+
+// setupPreEvaluation pre-evaluates the rules of an event type in kernel when all of them only test fields known in
+// kernel, otherwise all the events passing the filters are still sent
+func (rsa *RuleSetApplier) setupPreEvaluation(rs *rules.RuleSet, eventType eval.EventType, applier Applier) error {
+	preEval, ok := applier.(preEvalApplier)
+	if !ok {
+		return nil
+	}
+
+	fieldCaps, exists := allPreEvalCapabilities[eventType]
+	if !rsa.config.EnableKernelFilters || !rsa.config.EnablePreEvaluation || !exists {
+		return nil
+	}
+
+	table, err := rs.GetDecisionTable(eventType, fieldCaps)
+	if err != nil {
+		log.Debugf("rules of `%s` not pre-evaluated: %s", eventType, err)
+		return nil
+	}
+
+	return preEval.ApplyDecisionTable(eventType, table)
+}
+
+// applyAcceptPolicy leaves an event type in accept mode, with its approvers on standby
+func (rsa *RuleSetApplier) applyAcceptPolicy(rs *rules.RuleSet, eventType eval.EventType, applier Applier) error {
+	rsa.setupStandbyApprovers(rs, eventType, applier)
+	return rsa.applyFilterPolicy(eventType, PolicyModeAccept, math.MaxUint8, applier)
+}
+
+// setupContainerFilterPolicies overrides the policies of the event types of the ruleset for the containers of the
+// configuration. A policy that can't be applied leaves the container with the policy of the event type. Only the event
+// types whose approvers were applied can be denied, the other ones have no approver and would drop all the events
+func (rsa *RuleSetApplier) setupContainerFilterPolicies(rs *rules.RuleSet, approved map[eval.EventType]bool, applier Applier) {
+	containerApplier, ok := applier.(containerPolicyApplier)
+	if !ok || !rsa.config.EnableKernelFilters {
+		return
+	}
+
+	for containerID, policy := range rsa.config.ContainerFilterPolicies {
+		for _, eventType := range rs.GetEventTypes() {
+			mode := PolicyModeAccept
+			if policy == config.ContainerFilterPolicyDeny && approved[eventType] {
+				mode = PolicyModeDeny
+			}
+
+			if err := containerApplier.ApplyContainerFilterPolicy(containerID, eventType, mode, math.MaxUint8); err != nil {
+				log.Warnf("couldn't apply the filter policy of `%s` for container `%s`: %v", eventType, containerID, err)
+			}
+		}
+	}
+}
+
+// setupFilters applies the filters of the given event type and returns whether its approvers were applied, the
+// event type being left in accept mode otherwise
+func (rsa *RuleSetApplier) setupFilters(rs *rules.RuleSet, eventType eval.EventType, applier Applier) (bool, error) {
+	rsa.reportRules(rs, eventType)
+
 	if !rsa.config.EnableKernelFilters {
 		if err := rsa.applyFilterPolicy(eventType, PolicyModeNoFilter, math.MaxUint8, applier); err != nil {
-			return err
+			return false, err
 		}
-		return nil
+		return false, nil
 	}
 
 	// if approvers disabled
 	if !rsa.config.EnableApprovers {
-		return rsa.applyFilterPolicy(eventType, PolicyModeAccept, math.MaxUint8, applier)
+		return false, rsa.applyAcceptPolicy(rs, eventType, applier)
 	}
 
 	capabilities, exists := allCapabilities[eventType]
 	if !exists {
-		return rsa.applyFilterPolicy(eventType, PolicyModeAccept, math.MaxUint8, applier)
+		return false, rsa.applyAcceptPolicy(rs, eventType, applier)
 	}
 
 	approvers, err := rs.GetApprovers(eventType, capabilities.GetFieldCapabilities())
 	if err != nil {
-		return rsa.applyFilterPolicy(eventType, PolicyModeAccept, math.MaxUint8, applier)
+		return false, rsa.applyAcceptPolicy(rs, eventType, applier)
+	}
+
+	if combine, exists := allApproversCombiners[eventType]; exists {
+		approvers = combine(rs, approvers)
 	}
 
 	if err := rsa.applyApprovers(eventType, approvers, applier); err != nil {
-		return rsa.applyFilterPolicy(eventType, PolicyModeAccept, math.MaxUint8, applier)
+		return false, rsa.applyAcceptPolicy(rs, eventType, applier)
 	}
 
-	if err := rsa.applyFilterPolicy(eventType, PolicyModeDeny, capabilities.GetFlags(), applier); err != nil {
-		return err
+	if err := rsa.applyFilterPolicy(eventType, PolicyModeDeny, capabilities.GetApproversFlags(approvers), applier); err != nil {
+		return false, err
 	}
 
-	return nil
+	return true, nil
 }
 
 // Apply setup the filters for the provided set of rules and returns the policy report.
 func (rsa *RuleSetApplier) Apply(rs *rules.RuleSet, applier Applier) (*Report, error) {
+	approved := make(map[eval.EventType]bool)
 	for _, eventType := range rs.GetEventTypes() {
-		if err := rsa.setupFilters(rs, eventType, applier); err != nil {
+		hasApprovers, err := rsa.setupFilters(rs, eventType, applier)
+		if err != nil {
+			return nil, err
+		}
+		approved[eventType] = hasApprovers
+
+		if err := rsa.setupPreEvaluation(rs, eventType, applier); err != nil {
 			return nil, err
 		}
 	}
+	rsa.setupContainerFilterPolicies(rs, approved, applier)
 	return rsa.reporter.GetReport(), nil
 }
 
 // SelectProbes applies the loaded set of rules and returns a report
-// of the applied approvers for it.
+// of the applied approvers for it. The probes of an event type that can't be attached degrade this event type only,
+// the probes always activated are required
 func (rsa *RuleSetApplier) SelectProbes(rs *rules.RuleSet, applier Applier) error {
 	var selectedIDs []manager.ProbeIdentificationPair
 	for eventType, selectors := range probes.SelectorsPerEventType {
 		if eventType == "*" || rs.HasRulesForEventType(eventType) {
+			registered := selectors
+			if eventType != "*" {
+				registered = []manager.ProbesSelector{newEventTypeSelector(eventType, selectors)}
+			}
+
 			// register probes selectors
-			if err := rsa.registerProbesSelectors(selectors, applier); err != nil {
+			if err := rsa.registerProbesSelectors(registered, applier); err != nil {
 				return err
 			}
 
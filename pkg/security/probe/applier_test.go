@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DataDog/ebpf/manager"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+type testApplier struct {
+	approversErr      error
+	policies          map[eval.EventType]PolicyMode
+	standby           map[eval.EventType]rules.Approvers
+	containerPolicies map[string]PolicyMode
+}
+
+func newTestApplier() *testApplier {
+	return &testApplier{
+		policies:          make(map[eval.EventType]PolicyMode),
+		standby:           make(map[eval.EventType]rules.Approvers),
+		containerPolicies: make(map[string]PolicyMode),
+	}
+}
+
+func (a *testApplier) Init() error {
+	return nil
+}
+
+func (a *testApplier) ApplyFilterPolicy(eventType eval.EventType, mode PolicyMode, flags PolicyFlag) error {
+	a.policies[eventType] = mode
+	return nil
+}
+
+func (a *testApplier) ApplyApprovers(eventType eval.EventType, approvers rules.Approvers) error {
+	return a.approversErr
+}
+
+func (a *testApplier) RegisterProbesSelectors(selectors []manager.ProbesSelector) error {
+	return nil
+}
+
+func (a *testApplier) SetStandbyApprovers(eventType eval.EventType, approvers rules.Approvers, flags PolicyFlag) {
+	a.standby[eventType] = approvers
+}
+
+func (a *testApplier) ApplyContainerFilterPolicy(containerID string, eventType eval.EventType, mode PolicyMode, flags PolicyFlag) error {
+	a.containerPolicies[containerID+"/"+eventType] = mode
+	return nil
+}
+
+func TestRuleSetApplierStandbyApprovers(t *testing.T) {
+	tests := []struct {
+		name            string
+		enableApprovers bool
+		standby         bool
+		approversErr    error
+		expectedMode    PolicyMode
+		expectedStandby bool
+	}{
+		{name: "approvers", enableApprovers: true, standby: true, expectedMode: PolicyModeDeny},
+		{name: "approvers-disabled", standby: true, expectedMode: PolicyModeAccept, expectedStandby: true},
+		{name: "approvers-failed", enableApprovers: true, standby: true, approversErr: errors.New("map full"), expectedMode: PolicyModeAccept, expectedStandby: true},
+		{name: "standby-disabled", expectedMode: PolicyModeAccept},
+		{name: "standby-disabled-approvers-failed", enableApprovers: true, approversErr: errors.New("map full"), expectedMode: PolicyModeAccept},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+			addRuleExpr(t, rs, `open.filename == "/etc/passwd"`)
+
+			rsa := NewRuleSetApplier(&config.Config{
+				EnableKernelFilters:            true,
+				EnableApprovers:                test.enableApprovers,
+				LoadControllerStandbyApprovers: test.standby,
+			})
+
+			applier := newTestApplier()
+			applier.approversErr = test.approversErr
+			if _, err := rsa.Apply(rs, applier); err != nil {
+				t.Fatal(err)
+			}
+
+			if mode := applier.policies["open"]; mode != test.expectedMode {
+				t.Errorf("expected the policy mode %d, got %d", test.expectedMode, mode)
+			}
+
+			approvers, exists := applier.standby["open"]
+			if exists != test.expectedStandby {
+				t.Fatalf("expected standby approvers: %v, got %v", test.expectedStandby, approvers)
+			}
+			if exists && len(approvers["open.filename"]) == 0 {
+				t.Errorf("expected the approvers of open.filename to be on standby, got %v", approvers)
+			}
+		})
+	}
+}
+
+func TestRuleSetApplierContainerFilterPolicies(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	// rmdir has no approver, it is left in accept mode
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd"`, `unlink.filename == "/etc/shadow"`, `rmdir.filename == "/etc/ssh"`)
+
+	cfg := &config.Config{
+		EnableKernelFilters: true,
+		EnableApprovers:     true,
+		ContainerFilterPolicies: map[string]string{
+			"noisy": config.ContainerFilterPolicyDeny,
+			"quiet": config.ContainerFilterPolicyAccept,
+		},
+	}
+
+	// the policies of the containers are applied with each ruleset, after the in-kernel filters were flushed
+	applier := newTestApplier()
+	if _, err := NewRuleSetApplier(cfg).Apply(rs, applier); err != nil {
+		t.Fatal(err)
+	}
+
+	// denying an event type without approver would drop all its events
+	expected := map[string]PolicyMode{
+		"noisy/open":   PolicyModeDeny,
+		"noisy/rmdir":  PolicyModeAccept,
+		"noisy/unlink": PolicyModeDeny,
+		"quiet/open":   PolicyModeAccept,
+		"quiet/rmdir":  PolicyModeAccept,
+		"quiet/unlink": PolicyModeAccept,
+	}
+	if len(applier.containerPolicies) != len(expected) {
+		t.Errorf("expected the policies %v, got %v", expected, applier.containerPolicies)
+	}
+	for key, mode := range expected {
+		if applier.containerPolicies[key] != mode {
+			t.Errorf("expected the policy %d for %s, got %d", mode, key, applier.containerPolicies[key])
+		}
+	}
+
+	// without in-kernel filters, the events of all the containers are passed
+	cfg.EnableKernelFilters = false
+	applier = newTestApplier()
+	if _, err := NewRuleSetApplier(cfg).Apply(rs, applier); err != nil {
+		t.Fatal(err)
+	}
+	if len(applier.containerPolicies) != 0 {
+		t.Errorf("expected no container policy, got %v", applier.containerPolicies)
+	}
+}
+
+func TestRuleSetApplierRuleReports(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd"`, `open.filename != "/etc/shadow"`, `unlink.filename == "/etc/shadow"`)
+
+	cfg := &config.Config{
+		EnableKernelFilters: true,
+		EnableApprovers:     true,
+	}
+
+	report, err := NewRuleSetApplier(cfg).Apply(rs, newTestApplier())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]eval.EventType{"ID0": "open", "ID1": "open", "ID2": "unlink"}
+	if len(report.Rules) != len(expected) {
+		t.Errorf("expected the rules %v, got %v", expected, report.Rules)
+	}
+	for id, eventType := range expected {
+		if rule, exists := report.Rules[id]; !exists || rule.EventType != eventType {
+			t.Errorf("expected the rule %s to be reported with the event type %s, got %+v", id, eventType, rule)
+		}
+	}
+
+	// the approvers are reported per rule, even when the event type has none
+	if approvers := report.Rules["ID0"].Approvers; len(approvers["open.filename"]) != 1 {
+		t.Errorf("expected the approver of open.filename for ID0, got %v", approvers)
+	}
+	if approvers := report.Rules["ID1"].Approvers; len(approvers) != 0 {
+		t.Errorf("expected no approver for ID1, got %v", approvers)
+	}
+
+	// without approvers, only the event types are reported
+	cfg.EnableApprovers = false
+	if report, err = NewRuleSetApplier(cfg).Apply(rs, newTestApplier()); err != nil {
+		t.Fatal(err)
+	}
+	if approvers := report.Rules["ID0"].Approvers; len(approvers) != 0 {
+		t.Errorf("expected no approver for ID0, got %v", approvers)
+	}
+}
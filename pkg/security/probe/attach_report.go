@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"strings"
+
+	"github.com/DataDog/ebpf/manager"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// ProbeAttachFailure describes a probe that couldn't be attached
+type ProbeAttachFailure struct {
+	Probe string `json:"probe"`
+	Error string `json:"error"`
+}
+
+// DegradedEventType describes an event type whose probes couldn't all be attached, its rules may not match anymore
+type DegradedEventType struct {
+	EventType eval.EventType       `json:"event_type"`
+	Error     string               `json:"error"`
+	Probes    []ProbeAttachFailure `json:"probes"`
+}
+
+// eventTypeSelector groups the selectors of the probes of an event type. Its validation doesn't fail the start of
+// the manager, the event type is reported as degraded instead so that the other event types keep running
+type eventTypeSelector struct {
+	eventType eval.EventType
+	selectors []manager.ProbesSelector
+	degraded  *DegradedEventType
+}
+
+func newEventTypeSelector(eventType eval.EventType, selectors []manager.ProbesSelector) *eventTypeSelector {
+	return &eventTypeSelector{eventType: eventType, selectors: selectors}
+}
+
+// GetProbesIdentificationPairList returns the probes of the selectors of the event type
+func (s *eventTypeSelector) GetProbesIdentificationPairList() []manager.ProbeIdentificationPair {
+	var ids []manager.ProbeIdentificationPair
+	for _, selector := range s.selectors {
+		ids = append(ids, selector.GetProbesIdentificationPairList()...)
+	}
+	return ids
+}
+
+// RunValidator validates the selectors of the event type and records their failures, it never fails
+func (s *eventTypeSelector) RunValidator(m *manager.Manager) error {
+	s.degraded = nil
+
+	var errs []string
+	var failures []ProbeAttachFailure
+	for _, selector := range s.selectors {
+		if err := selector.RunValidator(m); err != nil {
+			errs = append(errs, err.Error())
+			failures = append(failures, probeAttachFailures(m, selector)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		s.degraded = &DegradedEventType{
+			EventType: s.eventType,
+			Error:     strings.Join(errs, " | "),
+			Probes:    failures,
+		}
+	}
+	return nil
+}
+
+// EditProbeIdentificationPair changes the selectors of the old probe so that they select the new one
+func (s *eventTypeSelector) EditProbeIdentificationPair(old manager.ProbeIdentificationPair, new manager.ProbeIdentificationPair) {
+	for _, selector := range s.selectors {
+		selector.EditProbeIdentificationPair(old, new)
+	}
+}
+
+// probeAttachFailures returns the activated probes of the given selector that aren't running
+func probeAttachFailures(m *manager.Manager, selector manager.ProbesSelector) []ProbeAttachFailure {
+	var failures []ProbeAttachFailure
+	for _, id := range selector.GetProbesIdentificationPairList() {
+		probe, exists := m.GetProbe(id)
+		if !exists || !probe.Enabled || probe.IsRunning() {
+			continue
+		}
+
+		failure := ProbeAttachFailure{Probe: id.String(), Error: "not running"}
+		if err := probe.GetLastError(); err != nil {
+			failure.Error = err.Error()
+		}
+		failures = append(failures, failure)
+	}
+	return failures
+}
+
+// degradedEventTypes returns the event types of the given selectors whose validation failed. The failures of the
+// selectors of an event type split across the families of probes are merged
+func degradedEventTypes(selectors []manager.ProbesSelector) []DegradedEventType {
+	var degraded []DegradedEventType
+	for _, selector := range selectors {
+		s, ok := selector.(*eventTypeSelector)
+		if !ok || s.degraded == nil {
+			continue
+		}
+
+		merged := false
+		for i := range degraded {
+			if degraded[i].EventType == s.eventType {
+				degraded[i].Error += " | " + s.degraded.Error
+				degraded[i].Probes = append(degraded[i].Probes, s.degraded.Probes...)
+				merged = true
+			}
+		}
+		if !merged {
+			degraded = append(degraded, *s.degraded)
+		}
+	}
+	return degraded
+}
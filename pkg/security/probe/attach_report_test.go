@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+)
+
+// failingSelector is a selector whose validation always fails
+type failingSelector struct {
+	id manager.ProbeIdentificationPair
+}
+
+func (s *failingSelector) GetProbesIdentificationPairList() []manager.ProbeIdentificationPair {
+	return []manager.ProbeIdentificationPair{s.id}
+}
+
+func (s *failingSelector) RunValidator(m *manager.Manager) error {
+	return errors.Errorf("%s: not attached", s.id)
+}
+
+func (s *failingSelector) EditProbeIdentificationPair(old manager.ProbeIdentificationPair, new manager.ProbeIdentificationPair) {
+}
+
+func TestEventTypeSelector(t *testing.T) {
+	id := func(section string) manager.ProbeIdentificationPair {
+		return manager.ProbeIdentificationPair{UID: probes.SecurityAgentUID, Section: section}
+	}
+
+	open := newEventTypeSelector("open", []manager.ProbesSelector{
+		&failingSelector{id: id("kprobe/vfs_open")},
+	})
+	chmod := newEventTypeSelector("chmod", nil)
+	selectors := []manager.ProbesSelector{open, chmod}
+
+	m := &manager.Manager{}
+	for _, selector := range selectors {
+		if err := selector.RunValidator(m); err != nil {
+			t.Fatalf("the validation of an event type shouldn't fail: %s", err)
+		}
+	}
+
+	degraded := degradedEventTypes(selectors)
+	if len(degraded) != 1 || degraded[0].EventType != "open" {
+		t.Fatalf("expected the open event type to be degraded, got %+v", degraded)
+	}
+	if degraded[0].Error == "" {
+		t.Error("the error of the degraded event type should be reported")
+	}
+
+	// the selectors of an event type are rewritten by the fallbacks
+	exec := newEventTypeSelector("exec", []manager.ProbesSelector{
+		&manager.ProbeSelector{ProbeIdentificationPair: id("kprobe/do_exit")},
+	})
+	replaced := replaceProbe([]manager.ProbesSelector{exec}, id("kprobe/do_exit"), id("tracepoint/sched/sched_process_exit"))
+	ids := selectorsIDs(replaced)
+	if len(ids) != 1 || ids[0].Section != "tracepoint/sched/sched_process_exit" {
+		t.Errorf("unexpected selected probes: %v", ids)
+	}
+}
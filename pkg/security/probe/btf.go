@@ -0,0 +1,357 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// kernelBTFPath is the path of the BTF of the running kernel
+const kernelBTFPath = "/sys/kernel/btf/vmlinux"
+
+const (
+	btfMagic         = 0xeB9F
+	btfHeaderSize    = 24
+	btfTypeSize      = 12
+	btfPointerSize   = 8
+	btfMaxTypeDepth  = 32
+	btfEssentialFlag = "___"
+)
+
+type btfKind uint8
+
+const (
+	btfKindUnknown btfKind = iota
+	btfKindInt
+	btfKindPointer
+	btfKindArray
+	btfKindStruct
+	btfKindUnion
+	btfKindEnum
+	btfKindForward
+	btfKindTypedef
+	btfKindVolatile
+	btfKindConst
+	btfKindRestrict
+	btfKindFunc
+	btfKindFuncProto
+	btfKindVar
+	btfKindDatasec
+	btfKindFloat
+	btfKindDeclTag
+	btfKindTypeTag
+	btfKindEnum64
+)
+
+// btfMember is a member of a struct or an union
+type btfMember struct {
+	name         string
+	typeID       uint32
+	bitOffset    uint32
+	bitfieldSize uint32
+}
+
+// btfType is a type described by BTF. Depending on its kind, size is the size of the type or typeID the type it
+// refers to
+type btfType struct {
+	kind    btfKind
+	name    string
+	size    uint32
+	typeID  uint32
+	members []btfMember
+	nelems  uint32
+	params  int
+}
+
+// btfSpec holds the types of a BTF blob, indexed by their ID. The ID 0 is the void type
+type btfSpec struct {
+	byteOrder binary.ByteOrder
+	types     []*btfType
+	strings   []byte
+	byName    map[string][]uint32
+}
+
+// loadKernelBTF parses the BTF of the running kernel
+func loadKernelBTF() (*btfSpec, error) {
+	data, err := ioutil.ReadFile(kernelBTFPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "kernel BTF not found")
+	}
+	return parseBTF(data)
+}
+
+type btfHeader struct {
+	Magic   uint16
+	Version uint8
+	Flags   uint8
+	HdrLen  uint32
+	TypeOff uint32
+	TypeLen uint32
+	StrOff  uint32
+	StrLen  uint32
+}
+
+// btfByteOrder returns the byte order of a BTF blob, deduced from its magic
+func btfByteOrder(data []byte) (binary.ByteOrder, error) {
+	if len(data) < 2 {
+		return nil, errors.New("BTF blob too short")
+	}
+
+	switch {
+	case binary.LittleEndian.Uint16(data) == btfMagic:
+		return binary.LittleEndian, nil
+	case binary.BigEndian.Uint16(data) == btfMagic:
+		return binary.BigEndian, nil
+	}
+	return nil, errors.New("invalid BTF magic")
+}
+
+// parseBTF parses the types of a BTF blob
+func parseBTF(data []byte) (*btfSpec, error) {
+	bo, err := btfByteOrder(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var header btfHeader
+	if err := binary.Read(bytes.NewReader(data), bo, &header); err != nil {
+		return nil, errors.Wrap(err, "failed to read the BTF header")
+	}
+
+	typeStart := uint64(header.HdrLen) + uint64(header.TypeOff)
+	strStart := uint64(header.HdrLen) + uint64(header.StrOff)
+	if header.HdrLen < btfHeaderSize ||
+		typeStart+uint64(header.TypeLen) > uint64(len(data)) ||
+		strStart+uint64(header.StrLen) > uint64(len(data)) {
+		return nil, errors.New("invalid BTF header")
+	}
+
+	spec := &btfSpec{
+		byteOrder: bo,
+		types:     []*btfType{{kind: btfKindUnknown}},
+		strings:   data[strStart : strStart+uint64(header.StrLen)],
+	}
+
+	raw := data[typeStart : typeStart+uint64(header.TypeLen)]
+	for len(raw) > 0 {
+		t, n, err := spec.parseType(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the BTF type %d", len(spec.types))
+		}
+		spec.types = append(spec.types, t)
+		raw = raw[n:]
+	}
+
+	return spec, nil
+}
+
+// parseType parses the type at the start of raw and returns its length
+func (s *btfSpec) parseType(raw []byte) (*btfType, int, error) {
+	if len(raw) < btfTypeSize {
+		return nil, 0, errors.New("truncated type")
+	}
+
+	bo := s.byteOrder
+	info := bo.Uint32(raw[4:8])
+	vlen := int(info & 0xffff)
+	kindFlag := info&(1<<31) != 0
+
+	t := &btfType{kind: btfKind((info >> 24) & 0x1f)}
+	name, err := s.stringAt(bo.Uint32(raw[0:4]))
+	if err != nil {
+		return nil, 0, err
+	}
+	t.name = name
+
+	switch t.kind {
+	case btfKindPointer, btfKindTypedef, btfKindVolatile, btfKindConst, btfKindRestrict, btfKindFunc,
+		btfKindFuncProto, btfKindVar, btfKindDeclTag, btfKindTypeTag:
+		t.typeID = bo.Uint32(raw[8:12])
+	default:
+		t.size = bo.Uint32(raw[8:12])
+	}
+
+	var extra int
+	switch t.kind {
+	case btfKindInt, btfKindVar, btfKindDeclTag:
+		extra = 4
+	case btfKindArray:
+		extra = 12
+	case btfKindStruct, btfKindUnion, btfKindDatasec, btfKindEnum64:
+		extra = vlen * 12
+	case btfKindEnum, btfKindFuncProto:
+		extra = vlen * 8
+	case btfKindPointer, btfKindForward, btfKindTypedef, btfKindVolatile, btfKindConst, btfKindRestrict,
+		btfKindFunc, btfKindFloat, btfKindTypeTag:
+	default:
+		return nil, 0, errors.Errorf("unknown kind %d", t.kind)
+	}
+
+	if len(raw) < btfTypeSize+extra {
+		return nil, 0, errors.New("truncated type")
+	}
+	data := raw[btfTypeSize : btfTypeSize+extra]
+
+	switch t.kind {
+	case btfKindArray:
+		t.typeID = bo.Uint32(data[0:4])
+		t.nelems = bo.Uint32(data[8:12])
+	case btfKindFuncProto:
+		t.params = vlen
+	case btfKindStruct, btfKindUnion:
+		for i := 0; i < vlen; i++ {
+			member := data[i*12 : (i+1)*12]
+			name, err := s.stringAt(bo.Uint32(member[0:4]))
+			if err != nil {
+				return nil, 0, err
+			}
+
+			m := btfMember{name: name, typeID: bo.Uint32(member[4:8]), bitOffset: bo.Uint32(member[8:12])}
+			if kindFlag {
+				m.bitfieldSize = m.bitOffset >> 24
+				m.bitOffset &= 0xffffff
+			}
+			t.members = append(t.members, m)
+		}
+	}
+
+	return t, btfTypeSize + extra, nil
+}
+
+// stringAt returns the string at the given offset of the string section
+func (s *btfSpec) stringAt(offset uint32) (string, error) {
+	if uint64(offset) >= uint64(len(s.strings)) {
+		if offset == 0 {
+			return "", nil
+		}
+		return "", errors.Errorf("invalid string offset %d", offset)
+	}
+
+	str := s.strings[offset:]
+	if end := bytes.IndexByte(str, 0); end >= 0 {
+		str = str[:end]
+	}
+	return string(str), nil
+}
+
+// typeByID returns the type of the given ID
+func (s *btfSpec) typeByID(id uint32) (*btfType, error) {
+	if uint64(id) >= uint64(len(s.types)) {
+		return nil, errors.Errorf("invalid BTF type ID %d", id)
+	}
+	return s.types[id], nil
+}
+
+// resolveType skips the modifiers and typedefs of the given type
+func (s *btfSpec) resolveType(id uint32) (uint32, *btfType, error) {
+	for depth := 0; depth < btfMaxTypeDepth; depth++ {
+		t, err := s.typeByID(id)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch t.kind {
+		case btfKindTypedef, btfKindVolatile, btfKindConst, btfKindRestrict, btfKindTypeTag:
+			id = t.typeID
+		default:
+			return id, t, nil
+		}
+	}
+	return 0, nil, errors.Errorf("BTF type %d too deep", id)
+}
+
+// sizeOf returns the size of the given type
+func (s *btfSpec) sizeOf(id uint32) (uint32, error) {
+	_, t, err := s.resolveType(id)
+	if err != nil {
+		return 0, err
+	}
+
+	switch t.kind {
+	case btfKindInt, btfKindStruct, btfKindUnion, btfKindEnum, btfKindEnum64, btfKindDatasec, btfKindFloat:
+		return t.size, nil
+	case btfKindPointer:
+		return btfPointerSize, nil
+	case btfKindArray:
+		size, err := s.sizeOf(t.typeID)
+		if err != nil {
+			return 0, err
+		}
+		return size * t.nelems, nil
+	}
+	return 0, errors.Errorf("BTF type %d of kind %d has no size", id, t.kind)
+}
+
+// essentialName returns the name of a type without its flavor, a `struct task_struct___old` is relocated against the
+// `struct task_struct` of the kernel
+func essentialName(name string) string {
+	if index := strings.Index(name, btfEssentialFlag); index > 0 {
+		return name[:index]
+	}
+	return name
+}
+
+// typesByEssentialName returns the IDs of the types of the given kind and essential name
+func (s *btfSpec) typesByEssentialName(kind btfKind, name string) []uint32 {
+	if s.byName == nil {
+		s.byName = make(map[string][]uint32)
+		for id, t := range s.types {
+			if t.name != "" {
+				essential := essentialName(t.name)
+				s.byName[essential] = append(s.byName[essential], uint32(id))
+			}
+		}
+	}
+
+	var ids []uint32
+	for _, id := range s.byName[essentialName(name)] {
+		if s.types[id].kind == kind {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// memberOffset returns the offset in bytes of the member of a struct following the given path of member names
+func (s *btfSpec) memberOffset(structName string, path ...string) (uint32, error) {
+	ids := s.typesByEssentialName(btfKindStruct, structName)
+	if len(ids) == 0 {
+		return 0, errors.Errorf("struct %s not found in the BTF", structName)
+	}
+
+	t, offset := s.types[ids[0]], uint32(0)
+	for i, name := range path {
+		member, bitOffset, err := findMember(s, t, name)
+		if err != nil {
+			return 0, err
+		}
+		if member == nil {
+			return 0, errors.Errorf("struct %s has no member %s", structName, strings.Join(path[:i+1], "."))
+		}
+		offset += bitOffset
+
+		if i < len(path)-1 {
+			if _, t, err = s.resolveType(member.typeID); err != nil {
+				return 0, err
+			}
+			if t.kind != btfKindStruct && t.kind != btfKindUnion {
+				return 0, errors.Errorf("member %s of struct %s isn't a struct", strings.Join(path[:i+1], "."), structName)
+			}
+		}
+	}
+
+	if offset%8 != 0 {
+		return 0, errors.Errorf("member %s of struct %s is a bitfield", strings.Join(path, "."), structName)
+	}
+	return offset / 8, nil
+}
@@ -14,10 +14,22 @@ import (
 
 var allCapabilities = make(map[eval.EventType]Capabilities)
 
+// allPreEvalCapabilities holds the fields that can be pre-evaluated in kernel, per event type, before the events are
+// sent. A value whose type isn't supported matches any value of its field
+var allPreEvalCapabilities = make(map[eval.EventType]rules.FieldCapabilities)
+
+// allApproversCombiners combine the approvers of the different fields of the same rules, per event type
+var allApproversCombiners = make(map[eval.EventType]func(rs *rules.RuleSet, approvers rules.Approvers) rules.Approvers)
+
 // Capability represents the type of values we are able to filter kernel side
 type Capability struct {
 	PolicyFlags     PolicyFlag
 	FieldValueTypes eval.FieldValueType
+	// PatternPolicyFlags are the policy flags of the pattern approvers of the field, when they are looked up in
+	// other tables than the scalar ones
+	PatternPolicyFlags PolicyFlag
+	// ValidateFnc rejects the values that can't be approved in kernel although their type is supported
+	ValidateFnc func(value rules.FilterValue) bool
 }
 
 // Capabilities represents the filtering capabilities for a set of fields
@@ -32,6 +44,23 @@ func (caps Capabilities) GetFlags() PolicyFlag {
 	return flags
 }
 
+// GetApproversFlags returns the policy flags of the fields of the given approvers, only their tables are looked up in
+// kernel
+func (caps Capabilities) GetApproversFlags(approvers rules.Approvers) PolicyFlag {
+	var flags PolicyFlag
+	for field, values := range approvers {
+		cap := caps[field]
+		for _, value := range values {
+			if value.Type == eval.PatternValueType && cap.PatternPolicyFlags != 0 {
+				flags |= cap.PatternPolicyFlags
+			} else {
+				flags |= cap.PolicyFlags
+			}
+		}
+	}
+	return flags
+}
+
 // GetFields returns the fields associated with a set of capabilities
 func (caps Capabilities) GetFields() []eval.Field {
 	var fields []eval.Field
@@ -49,8 +78,9 @@ func (caps Capabilities) GetFieldCapabilities() rules.FieldCapabilities {
 
 	for field, cap := range caps {
 		fcs = append(fcs, rules.FieldCapability{
-			Field: field,
-			Types: cap.FieldValueTypes,
+			Field:       field,
+			Types:       cap.FieldValueTypes,
+			ValidateFnc: cap.ValidateFnc,
 		})
 	}
 
@@ -59,4 +89,21 @@ func (caps Capabilities) GetFieldCapabilities() rules.FieldCapabilities {
 
 func init() {
 	allCapabilities["open"] = openCapabilities
+	allCapabilities["unlink"] = unlinkCapabilities
+	allCapabilities["rename"] = renameCapabilities
+	allCapabilities["chmod"] = chmodCapabilities
+	allCapabilities["chown"] = chownCapabilities
+	allCapabilities["mkdir"] = mkdirCapabilities
+
+	allApproversCombiners["open"] = combineOpenApprovers
+
+	allPreEvalCapabilities["open"] = openPreEvalCapabilities
+
+	// the events of all the event types can be approved by the name of their process
+	for _, capabilities := range allCapabilities {
+		capabilities["process.name"] = Capability{
+			PolicyFlags:     PolicyFlagComm,
+			FieldValueTypes: eval.ScalarValueType,
+		}
+	}
 }
@@ -0,0 +1,27 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+var chmodCapabilities = Capabilities{
+	"chmod.filename": {
+		PolicyFlags:     PolicyFlagBasename,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+	"chmod.basename": {
+		PolicyFlags:     PolicyFlagBasename,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+	"chmod.mode": {
+		PolicyFlags:     PolicyFlagMode,
+		FieldValueTypes: eval.ScalarValueType | eval.BitmaskValueType,
+	},
+}
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func chmodOnNewApprovers(probe *Probe, approvers rules.Approvers) error {
+	filenames := newFilenameApprovers(probe, FileChmodEventType)
+
+	for field, values := range approvers {
+		switch field {
+		case "chmod.basename":
+			filenames.addBasenames(stringValues(values)...)
+
+		case "chmod.filename":
+			filenames.addFilenames(stringValues(values)...)
+
+		case "chmod.mode":
+			if err := approveModes(probe, "chmod_mode_approvers", intValues(values)...); err != nil {
+				return err
+			}
+
+		default:
+			return errors.New("field unknown")
+		}
+	}
+
+	return filenames.apply("chmod_basename_approvers")
+}
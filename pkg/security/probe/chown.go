@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+var chownCapabilities = Capabilities{
+	"chown.filename": {
+		PolicyFlags:     PolicyFlagBasename,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+	"chown.basename": {
+		PolicyFlags:     PolicyFlagBasename,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+	"chown.uid": {
+		PolicyFlags:     PolicyFlagUID,
+		FieldValueTypes: eval.ScalarValueType | eval.RangeValueType,
+	},
+	"chown.gid": {
+		PolicyFlags:     PolicyFlagGID,
+		FieldValueTypes: eval.ScalarValueType | eval.RangeValueType,
+	},
+	"chown.user": {
+		PolicyFlags:     PolicyFlagUID,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+	"chown.group": {
+		PolicyFlags:     PolicyFlagGID,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+func chownOnNewApprovers(probe *Probe, approvers rules.Approvers) error {
+	// the names are resolved to the ids they have when the approvers are applied, an unknown name can't be approved
+	// until /etc/passwd or /etc/group list it, the probe applies the approvers again when they change
+	idRanges := func(fvs rules.FilterValues, resolveID func(name string) (int, error)) ([]eval.IntRange, error) {
+		var ranges []eval.IntRange
+		for _, v := range fvs {
+			switch value := v.Value.(type) {
+			case int:
+				ranges = append(ranges, eval.IntRange{Min: value, Max: value})
+			case eval.IntRange:
+				ranges = append(ranges, value)
+			case string:
+				id, err := resolveID(value)
+				if err != nil {
+					return nil, err
+				}
+				ranges = append(ranges, eval.IntRange{Min: id, Max: id})
+			}
+		}
+		return ranges, nil
+	}
+
+	// the ids and the names of the users, as well as the ones of the groups, share the same table
+	var uidRanges, gidRanges []eval.IntRange
+	filenames := newFilenameApprovers(probe, FileChownEventType)
+	for field, values := range approvers {
+		var ranges []eval.IntRange
+		var err error
+
+		switch field {
+		case "chown.basename":
+			filenames.addBasenames(stringValues(values)...)
+
+		case "chown.filename":
+			filenames.addFilenames(stringValues(values)...)
+
+		case "chown.uid", "chown.user":
+			ranges, err = idRanges(values, probe.resolvers.UserGroupResolver.ResolveUID)
+			uidRanges = append(uidRanges, ranges...)
+
+		case "chown.gid", "chown.group":
+			ranges, err = idRanges(values, probe.resolvers.UserGroupResolver.ResolveGID)
+			gidRanges = append(gidRanges, ranges...)
+
+		default:
+			return errors.New("field unknown")
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := filenames.apply("chown_basename_approvers"); err != nil {
+		return err
+	}
+
+	if len(uidRanges) > 0 {
+		if err := approveIDRanges(probe, "chown_uid_approvers", uidRanges...); err != nil {
+			return err
+		}
+	}
+
+	if len(gidRanges) > 0 {
+		if err := approveIDRanges(probe, "chown_gid_approvers", gidRanges...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
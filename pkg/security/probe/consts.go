@@ -20,6 +20,10 @@ import (
 const (
 	// KERNEL_VERSION(a,b,c) = (a << 16) + (b << 8) + (c)
 	kernel4_13 = (4 << 16) + (13 << 8) //nolint:deadcode,unused
+	kernel5_5  = (5 << 16) + (5 << 8)  //nolint:deadcode,unused
+	kernel5_8  = (5 << 16) + (8 << 8)  //nolint:deadcode,unused
+	kernel5_11 = (5 << 16) + (11 << 8) //nolint:deadcode,unused
+	kernel6_0  = (6 << 16)             //nolint:deadcode,unused
 )
 
 // EventType describes the type of an event sent from the kernel
@@ -64,6 +68,37 @@ const (
 	maxEventType
 )
 
+// fileEventTypes lists the event types of the file events, the only events that can be dropped in the kernel. The
+// process and the mount point events are never dropped as they keep the user space caches in sync, neither are the
+// file events invalidating the dentry cache
+//nolint:deadcode,unused
+var fileEventTypes = []EventType{
+	FileOpenEventType,
+	FileMkdirEventType,
+	FileLinkEventType,
+	FileRenameEventType,
+	FileUnlinkEventType,
+	FileRmdirEventType,
+	FileChmodEventType,
+	FileChownEventType,
+	FileUtimeEventType,
+	FileSetXAttrEventType,
+	FileRemoveXAttrEventType,
+}
+
+// invalidatesDentryCache returns whether the dentry cache is invalidated on the events of the type, they can't be
+// dropped in the kernel without leaving stale paths in the cache. It needs to be aligned with the kernel
+// is_dentry_cache_invalidating_event
+//nolint:unused
+func (t EventType) invalidatesDentryCache() bool {
+	switch t {
+	case FileRenameEventType, FileUnlinkEventType, FileRmdirEventType, InvalidateDentryEventType:
+		return true
+	default:
+		return false
+	}
+}
+
 func (t EventType) String() string {
 	switch t {
 	case FileOpenEventType:
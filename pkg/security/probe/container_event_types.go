@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// containerEventTypesMask returns the mask of the event types produced for the processes of a container: the file
+// event types of its rules and the ones the event handlers subscribed to, along with all the other event types. The
+// file event types invalidating the dentry cache are never masked
+func containerEventTypesMask(eventTypes []eval.EventType, handledEventTypes []EventType) uint64 {
+	mask := uint64(math.MaxUint64)
+	for _, eventType := range fileEventTypes {
+		if !eventType.invalidatesDentryCache() {
+			mask &^= 1 << uint64(eventType)
+		}
+	}
+
+	for _, eventType := range eventTypes {
+		if et := parseEvalEventType(eventType); et != UnknownEventType {
+			mask |= 1 << uint64(et)
+		}
+	}
+	for _, eventType := range handledEventTypes {
+		mask |= 1 << uint64(eventType)
+	}
+
+	return mask
+}
+
+// HasContainerEventTypes returns whether the event types produced for the given container were already restricted
+func (p *Probe) HasContainerEventTypes(containerID string) bool {
+	p.containerEventTypesLock.Lock()
+	defer p.containerEventTypesLock.Unlock()
+
+	return p.containerEventTypes.Contains(containerID)
+}
+
+// SetContainerEventTypes restricts the file events produced by the kernel for the processes of the given container
+// to the given event types, the other events are always produced. The events the event handlers subscribed to are
+// produced as well
+func (p *Probe) SetContainerEventTypes(containerID string, eventTypes []eval.EventType) error {
+	if containerID == "" || len(containerID) > containerIDLen {
+		return errors.Errorf("invalid container ID `%s`", containerID)
+	}
+
+	handledEventTypes, all := p.handledEventTypes()
+
+	p.containerEventTypesLock.Lock()
+	defer p.containerEventTypesLock.Unlock()
+
+	// a handler of all the events requires all of them
+	if all {
+		p.containerEventTypes.Add(containerID, uint64(math.MaxUint64))
+		return nil
+	}
+
+	table := p.Map("container_event_types")
+	if table == nil {
+		return errors.New("map container_event_types not found")
+	}
+
+	mask := containerEventTypesMask(eventTypes, handledEventTypes)
+	if err := table.Put(ebpf.NewStringMapItem(containerID, containerIDLen), ebpf.Uint64MapItem(mask)); err != nil {
+		return errors.Wrapf(err, "failed to restrict the event types of container %s", containerID)
+	}
+	p.containerEventTypes.Add(containerID, mask)
+
+	return nil
+}
+
+// resetContainerEventTypes removes the event types of all the containers, the eviction removes the kernel entries.
+// They are computed again at the next event of each container
+func (p *Probe) resetContainerEventTypes() {
+	p.containerEventTypesLock.Lock()
+	defer p.containerEventTypesLock.Unlock()
+
+	if p.containerEventTypes != nil {
+		p.containerEventTypes.Purge()
+	}
+}
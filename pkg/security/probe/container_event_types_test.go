@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+func TestContainerEventTypesMask(t *testing.T) {
+	mask := containerEventTypesMask([]eval.EventType{"open"}, []EventType{FileChmodEventType})
+
+	for eventType, produced := range map[EventType]bool{
+		FileOpenEventType:         true,
+		FileChmodEventType:        true,
+		FileMkdirEventType:        false,
+		FileSetXAttrEventType:     false,
+		FileRenameEventType:       true,
+		FileUnlinkEventType:       true,
+		FileRmdirEventType:        true,
+		InvalidateDentryEventType: true,
+		ExecEventType:             true,
+		ExitEventType:             true,
+	} {
+		if (mask&(1<<uint64(eventType)) != 0) != produced {
+			t.Errorf("expected the %s events to be produced: %v", eventType, produced)
+		}
+	}
+}
+
+func TestResetContainerEventTypes(t *testing.T) {
+	// the probe may not be initialized yet
+	(&Probe{}).resetContainerEventTypes()
+}
@@ -8,11 +8,56 @@
 package probe
 
 import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
 	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	containerImageCacheSize = 512
+	// containerImageRetryDelay is the delay before the runtimes are queried again for the image of a container they
+	// failed to resolve, the container may not be inspectable yet when it starts
+	containerImageRetryDelay = 30 * time.Second
+	// containerImageRequestsSize is the number of containers waiting for their image to be resolved, the events of the
+	// other unknown containers don't request it
+	containerImageRequestsSize = 128
 )
 
-// ContainerResolver is used to resolve the container context of the events
-type ContainerResolver struct{}
+// ContainerImage holds the image of a container, as reported by the container runtime
+type ContainerImage struct {
+	Name   string
+	Digest string
+}
+
+// containerRuntime is a container runtime the images of the containers are read from
+type containerRuntime interface {
+	// inspect returns the image of the given container, an error when the runtime doesn't know it
+	inspect(containerID string) (*ContainerImage, error)
+	// watch sends the IDs of the containers started by the runtime to the given channel, until the context is done
+	watch(ctx context.Context, started chan<- string) error
+}
+
+// newContainerRuntimes holds the constructors of the container runtimes the agent was built with, registered by the
+// files of their build tags
+var newContainerRuntimes []func() containerRuntime
+
+// ContainerResolver is used to resolve the container context of the events. The images of the containers are resolved
+// in the background, when the runtimes start them or when an event of an unknown container is received, the events of
+// a container don't have an image until then. Only the Docker and containerd runtimes are supported, the latter in the
+// namespace of its configuration
+type ContainerResolver struct {
+	sync.Mutex
+	images   *lru.Cache
+	retries  *lru.Cache
+	pending  map[string]bool
+	requests chan string
+	runtimes []containerRuntime
+}
 
 // GetContainerID returns the container id of the given pid
 func (cr *ContainerResolver) GetContainerID(pid uint32) (utils.ContainerID, error) {
@@ -25,3 +70,109 @@ func (cr *ContainerResolver) ResolveLabels(containerID string) ([]string, error)
 	// Do not use the tagger for now
 	return []string{}, nil
 }
+
+// ResolveImage returns the image name and digest of a container from its container ID. It never queries the runtimes,
+// the image of a container that isn't resolved yet is requested and empty
+func (cr *ContainerResolver) ResolveImage(containerID string) *ContainerImage {
+	if value, exists := cr.images.Get(containerID); exists {
+		return value.(*ContainerImage)
+	}
+
+	cr.request(containerID)
+	return &ContainerImage{}
+}
+
+// request queues the resolution of the image of the given container, unless it is already queued or it failed less
+// than containerImageRetryDelay ago
+func (cr *ContainerResolver) request(containerID string) {
+	cr.Lock()
+	defer cr.Unlock()
+
+	if cr.pending[containerID] {
+		return
+	}
+	if value, exists := cr.retries.Get(containerID); exists && time.Now().Before(value.(time.Time)) {
+		return
+	}
+
+	select {
+	case cr.requests <- containerID:
+		cr.pending[containerID] = true
+	default:
+	}
+}
+
+// resolve queries the runtimes for the image of the given container
+func (cr *ContainerResolver) resolve(containerID string) {
+	var image *ContainerImage
+	var err error
+	for _, runtime := range cr.runtimes {
+		if image, err = runtime.inspect(containerID); err == nil {
+			break
+		}
+	}
+
+	cr.Lock()
+	defer cr.Unlock()
+
+	delete(cr.pending, containerID)
+	if image == nil {
+		log.Debugf("failed to resolve the image of container %s: %v", containerID, err)
+
+		// the runtimes won't be queried again for the events of this container for a while
+		cr.retries.Add(containerID, time.Now().Add(containerImageRetryDelay))
+		return
+	}
+	cr.retries.Remove(containerID)
+	cr.images.Add(containerID, image)
+}
+
+// Run resolves the images of the containers started by the runtimes and of the requested ones until the context is
+// done
+func (cr *ContainerResolver) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, runtime := range cr.runtimes {
+		wg.Add(1)
+		go func(runtime containerRuntime) {
+			defer wg.Done()
+			if err := runtime.watch(ctx, cr.requests); err != nil {
+				log.Debugf("the started containers won't be resolved ahead of their events: %s", err)
+			}
+		}(runtime)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case containerID := <-cr.requests:
+			if _, exists := cr.images.Get(containerID); !exists {
+				cr.resolve(containerID)
+			}
+		}
+	}
+}
+
+// NewContainerResolver returns a new container resolver
+func NewContainerResolver() (*ContainerResolver, error) {
+	images, err := lru.New(containerImageCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	retries, err := lru.New(containerImageCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := &ContainerResolver{
+		images:   images,
+		retries:  retries,
+		pending:  make(map[string]bool),
+		requests: make(chan string, containerImageRequestsSize),
+	}
+	for _, newRuntime := range newContainerRuntimes {
+		cr.runtimes = append(cr.runtimes, newRuntime())
+	}
+	return cr, nil
+}
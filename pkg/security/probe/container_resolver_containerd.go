@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux,containerd
+
+package probe
+
+import (
+	"context"
+	"time"
+
+	"github.com/containerd/containerd/namespaces"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/util/containerd"
+)
+
+// containerdQueryTimeout is the timeout of the queries of the image of a container
+const containerdQueryTimeout = 5 * time.Second
+
+func init() {
+	newContainerRuntimes = append(newContainerRuntimes, func() containerRuntime { return &containerdRuntime{} })
+}
+
+// containerdRuntime reads the images of the containers from containerd, the CRI containers of Kubernetes included
+// when the configured namespace is k8s.io
+type containerdRuntime struct{}
+
+// inspect queries containerd for the image of a container
+func (c *containerdRuntime) inspect(containerID string) (*ContainerImage, error) {
+	cu, err := containerd.GetContainerdUtil()
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := cu.Containers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ctn := range containers {
+		if ctn.ID() != containerID {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), containerdQueryTimeout)
+		defer cancel()
+
+		image, err := ctn.Image(namespaces.WithNamespace(ctx, cu.Namespace()))
+		if err != nil {
+			return nil, err
+		}
+
+		return &ContainerImage{
+			Name:   image.Name(),
+			Digest: image.Target().Digest.String(),
+		}, nil
+	}
+
+	return nil, errors.Errorf("container %s not found in containerd namespace %s", containerID, cu.Namespace())
+}
+
+// watch doesn't prefetch the images of the containerd containers, they are resolved when their first event is
+// received
+func (c *containerdRuntime) watch(ctx context.Context, started chan<- string) error {
+	return nil
+}
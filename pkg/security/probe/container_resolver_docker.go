@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux,docker
+
+package probe
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+)
+
+const dockerEventsSubscriber = "runtime-security-container-resolver"
+
+func init() {
+	newContainerRuntimes = append(newContainerRuntimes, func() containerRuntime { return &dockerRuntime{} })
+}
+
+// dockerRuntime reads the images of the containers from the docker daemon
+type dockerRuntime struct{}
+
+// inspect queries the docker daemon for the image of a container
+func (d *dockerRuntime) inspect(containerID string) (*ContainerImage, error) {
+	du, err := docker.GetDockerUtil()
+	if err != nil {
+		return nil, err
+	}
+
+	co, err := du.Inspect(containerID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := du.ResolveImageNameFromContainer(co)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := du.ResolveImageDigest(co.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContainerImage{
+		Name:   name,
+		Digest: digest,
+	}, nil
+}
+
+// watch sends the IDs of the containers started by the docker daemon to the given channel
+func (d *dockerRuntime) watch(ctx context.Context, started chan<- string) error {
+	du, err := docker.GetDockerUtil()
+	if err != nil {
+		return err
+	}
+
+	events, errs, err := du.SubscribeToContainerEvents(dockerEventsSubscriber)
+	if err != nil {
+		return err
+	}
+	defer du.UnsubscribeFromContainerEvents(dockerEventsSubscriber) //nolint:errcheck
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return errors.Wrap(err, "docker event stream failed")
+		case event, ok := <-events:
+			if !ok {
+				return errors.New("docker event stream closed")
+			}
+			if event.Action != "start" {
+				continue
+			}
+
+			select {
+			case started <- event.ContainerID:
+			default:
+			}
+		}
+	}
+}
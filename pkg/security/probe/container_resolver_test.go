@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testContainerRuntime struct {
+	inspected  int
+	inspectErr error
+	started    []string
+}
+
+func (r *testContainerRuntime) inspect(containerID string) (*ContainerImage, error) {
+	r.inspected++
+	if r.inspectErr != nil {
+		return nil, r.inspectErr
+	}
+	return &ContainerImage{Name: "image:" + containerID, Digest: "sha256:abc"}, nil
+}
+
+func (r *testContainerRuntime) watch(ctx context.Context, started chan<- string) error {
+	for _, containerID := range r.started {
+		started <- containerID
+	}
+	return nil
+}
+
+func TestContainerResolverImage(t *testing.T) {
+	cr, err := NewContainerResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime := &testContainerRuntime{}
+	cr.runtimes = []containerRuntime{runtime}
+
+	// the events don't wait for the runtime, the image is requested once
+	for i := 0; i < 2; i++ {
+		if image := cr.ResolveImage("c0ffee"); image == nil || image.Name != "" {
+			t.Errorf("expected an empty image, got %+v", image)
+		}
+	}
+	if len(cr.requests) != 1 || runtime.inspected != 0 {
+		t.Fatalf("expected a single request and no query of the runtime, got %d requests and %d queries", len(cr.requests), runtime.inspected)
+	}
+
+	// the failures are cached until their retry time
+	runtime.inspectErr = errors.New("container not found")
+	cr.resolve(<-cr.requests)
+	cr.ResolveImage("c0ffee")
+	if len(cr.requests) != 0 || runtime.inspected != 1 {
+		t.Errorf("expected the failure to be cached, got %d requests and %d queries", len(cr.requests), runtime.inspected)
+	}
+
+	cr.retries.Add("c0ffee", time.Now().Add(-time.Second))
+	runtime.inspectErr = nil
+	cr.ResolveImage("c0ffee")
+	cr.resolve(<-cr.requests)
+
+	// the resolved images are cached without expiration
+	for i := 0; i < 2; i++ {
+		if image := cr.ResolveImage("c0ffee"); image.Name != "image:c0ffee" || image.Digest != "sha256:abc" {
+			t.Errorf("unexpected image %+v", image)
+		}
+	}
+	if len(cr.requests) != 0 || runtime.inspected != 2 {
+		t.Errorf("expected the runtime to be queried again once the retry time passed, it was queried %d times", runtime.inspected)
+	}
+}
+
+func TestContainerResolverStartedContainers(t *testing.T) {
+	cr, err := NewContainerResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime := &testContainerRuntime{started: []string{"c0ffee"}}
+	cr.runtimes = []containerRuntime{runtime}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		cr.Run(ctx)
+		close(done)
+	}()
+
+	// the containers started by the runtime are resolved before their first event
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, exists := cr.images.Get("c0ffee"); exists {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the image of the started container wasn't resolved")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	if image := cr.ResolveImage("c0ffee"); image.Name != "image:c0ffee" {
+		t.Errorf("unexpected image %+v", image)
+	}
+}
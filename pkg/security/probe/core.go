@@ -0,0 +1,555 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
+)
+
+// coreAsset is the object compiled with the CO-RE relocations of the accesses to the kernel structures
+const coreAsset = "pkg/security/ebpf/c/runtime-security-core.o"
+
+// coreRelocationKind is the kind of a CO-RE relocation, as defined by `enum bpf_core_relo_kind`
+type coreRelocationKind uint32
+
+const (
+	coreFieldByteOffset coreRelocationKind = iota
+	coreFieldByteSize
+	coreFieldExists
+)
+
+const (
+	bpfClassLD    = 0x00
+	bpfClassLDX   = 0x01
+	bpfClassST    = 0x02
+	bpfClassSTX   = 0x03
+	bpfClassALU   = 0x04
+	bpfClassALU64 = 0x07
+	bpfSourceX    = 0x08
+	bpfLDIMM64    = 0x18
+
+	bpfInstructionSize = 8
+	btfExtCoreHdrLen   = 32
+)
+
+// coreRelocation is a relocation of an instruction of a program, recorded in the .BTF.ext section
+type coreRelocation struct {
+	section    string
+	insnOffset uint32
+	typeID     uint32
+	access     string
+	kind       coreRelocationKind
+}
+
+type btfExtHeader struct {
+	Magic       uint16
+	Version     uint8
+	Flags       uint8
+	HdrLen      uint32
+	FuncInfoOff uint32
+	FuncInfoLen uint32
+	LineInfoOff uint32
+	LineInfoLen uint32
+	CoreReloOff uint32
+	CoreReloLen uint32
+}
+
+// parseCORERelocations parses the CO-RE relocations of a .BTF.ext section, its strings are the ones of the local BTF
+func parseCORERelocations(ext []byte, local *btfSpec) ([]coreRelocation, error) {
+	bo, err := btfByteOrder(ext)
+	if err != nil {
+		return nil, err
+	}
+
+	var header btfExtHeader
+	if len(ext) < btfExtCoreHdrLen {
+		return nil, nil
+	}
+	if err := binary.Read(bytes.NewReader(ext), bo, &header); err != nil {
+		return nil, errors.Wrap(err, "failed to read the BTF.ext header")
+	}
+	if header.HdrLen < btfExtCoreHdrLen || header.CoreReloLen == 0 {
+		return nil, nil
+	}
+
+	start := uint64(header.HdrLen) + uint64(header.CoreReloOff)
+	if start+uint64(header.CoreReloLen) > uint64(len(ext)) || header.CoreReloLen < 4 {
+		return nil, errors.New("invalid BTF.ext CO-RE relocations")
+	}
+	data := ext[start : start+uint64(header.CoreReloLen)]
+
+	recordSize := uint64(bo.Uint32(data[0:4]))
+	if recordSize < 16 {
+		return nil, errors.Errorf("invalid CO-RE relocation size %d", recordSize)
+	}
+	data = data[4:]
+
+	var relocations []coreRelocation
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, errors.New("truncated CO-RE relocations")
+		}
+
+		section, err := local.stringAt(bo.Uint32(data[0:4]))
+		if err != nil {
+			return nil, err
+		}
+		count := uint64(bo.Uint32(data[4:8]))
+		data = data[8:]
+
+		if uint64(len(data)) < count*recordSize {
+			return nil, errors.Errorf("truncated CO-RE relocations of section %s", section)
+		}
+
+		for i := uint64(0); i < count; i++ {
+			record := data[i*recordSize : (i+1)*recordSize]
+
+			access, err := local.stringAt(bo.Uint32(record[8:12]))
+			if err != nil {
+				return nil, err
+			}
+
+			relocations = append(relocations, coreRelocation{
+				section:    section,
+				insnOffset: bo.Uint32(record[0:4]),
+				typeID:     bo.Uint32(record[4:8]),
+				access:     access,
+				kind:       coreRelocationKind(bo.Uint32(record[12:16])),
+			})
+		}
+		data = data[count*recordSize:]
+	}
+
+	return relocations, nil
+}
+
+// coreAccessor is an access to a named member, or to an element of an array
+type coreAccessor struct {
+	name  string
+	index uint32
+	array bool
+}
+
+// coreFieldSpec is the result of an access string applied to a root type
+type coreFieldSpec struct {
+	rootIndex uint32
+	accessors []coreAccessor
+	bitOffset uint32
+	fieldID   uint32
+	bitfield  bool
+}
+
+// parseCOREAccess applies an access string, such as `0:1:2`, to the given local root type. The first index is the
+// index of the root type in an array, the others the indexes of the members or of the array elements
+func parseCOREAccess(local *btfSpec, rootID uint32, access string) (*coreFieldSpec, error) {
+	var indexes []uint32
+	for _, field := range strings.Split(access, ":") {
+		index, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("invalid CO-RE access string `%s`", access)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+
+	rootSize, err := local.sizeOf(rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &coreFieldSpec{
+		rootIndex: indexes[0],
+		bitOffset: indexes[0] * rootSize * 8,
+		fieldID:   rootID,
+	}
+
+	for _, index := range indexes[1:] {
+		_, t, err := local.resolveType(spec.fieldID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch t.kind {
+		case btfKindStruct, btfKindUnion:
+			if index >= uint32(len(t.members)) {
+				return nil, errors.Errorf("invalid member index %d of `%s`", index, t.name)
+			}
+			member := t.members[index]
+
+			// the anonymous members are matched through their own members
+			if member.name != "" {
+				spec.accessors = append(spec.accessors, coreAccessor{name: member.name})
+			}
+			spec.bitOffset += member.bitOffset
+			spec.bitfield = member.bitfieldSize != 0
+			spec.fieldID = member.typeID
+		case btfKindArray:
+			size, err := local.sizeOf(t.typeID)
+			if err != nil {
+				return nil, err
+			}
+
+			spec.accessors = append(spec.accessors, coreAccessor{index: index, array: true})
+			spec.bitOffset += index * size * 8
+			spec.bitfield = false
+			spec.fieldID = t.typeID
+		default:
+			return nil, errors.Errorf("invalid CO-RE access string `%s` of type %d", access, rootID)
+		}
+	}
+
+	return spec, nil
+}
+
+// findMember looks up a member by name, including in the anonymous members, and returns its offset in bits
+func findMember(target *btfSpec, t *btfType, name string) (*btfMember, uint32, error) {
+	for i := range t.members {
+		member := &t.members[i]
+		if member.name == name {
+			return member, member.bitOffset, nil
+		}
+
+		if member.name == "" {
+			_, anonymous, err := target.resolveType(member.typeID)
+			if err != nil {
+				return nil, 0, err
+			}
+			if anonymous.kind != btfKindStruct && anonymous.kind != btfKindUnion {
+				continue
+			}
+
+			if found, offset, err := findMember(target, anonymous, name); err != nil || found != nil {
+				return found, member.bitOffset + offset, err
+			}
+		}
+	}
+	return nil, 0, nil
+}
+
+// areFieldsCompatible returns whether a local field can be relocated to a target field
+func areFieldsCompatible(local *btfSpec, localID uint32, target *btfSpec, targetID uint32) (bool, error) {
+	_, localType, err := local.resolveType(localID)
+	if err != nil {
+		return false, err
+	}
+	_, targetType, err := target.resolveType(targetID)
+	if err != nil {
+		return false, err
+	}
+
+	isScalar := func(kind btfKind) bool {
+		return kind == btfKindInt || kind == btfKindEnum || kind == btfKindEnum64
+	}
+	if isScalar(localType.kind) && isScalar(targetType.kind) {
+		return true, nil
+	}
+	if localType.kind != targetType.kind {
+		return false, nil
+	}
+	if localType.kind == btfKindStruct || localType.kind == btfKindUnion {
+		return essentialName(localType.name) == essentialName(targetType.name), nil
+	}
+	return true, nil
+}
+
+// matchCOREField applies the accessors of a local field spec to a target root type. It returns nil if the target
+// type doesn't have the field
+func matchCOREField(local *btfSpec, spec *coreFieldSpec, target *btfSpec, rootID uint32) (*coreFieldSpec, error) {
+	rootSize, err := target.sizeOf(rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	match := &coreFieldSpec{
+		rootIndex: spec.rootIndex,
+		bitOffset: spec.rootIndex * rootSize * 8,
+		fieldID:   rootID,
+	}
+
+	for _, accessor := range spec.accessors {
+		_, t, err := target.resolveType(match.fieldID)
+		if err != nil {
+			return nil, err
+		}
+
+		if accessor.array {
+			if t.kind != btfKindArray || (t.nelems != 0 && accessor.index >= t.nelems) {
+				return nil, nil
+			}
+
+			size, err := target.sizeOf(t.typeID)
+			if err != nil {
+				return nil, err
+			}
+			match.bitOffset += accessor.index * size * 8
+			match.bitfield = false
+			match.fieldID = t.typeID
+			continue
+		}
+
+		if t.kind != btfKindStruct && t.kind != btfKindUnion {
+			return nil, nil
+		}
+
+		member, offset, err := findMember(target, t, accessor.name)
+		if err != nil || member == nil {
+			return nil, err
+		}
+		match.bitOffset += offset
+		match.bitfield = member.bitfieldSize != 0
+		match.fieldID = member.typeID
+	}
+
+	if compatible, err := areFieldsCompatible(local, spec.fieldID, target, match.fieldID); err != nil || !compatible {
+		return nil, err
+	}
+	return match, nil
+}
+
+// fieldValue returns the value of a field relocation
+func fieldValue(btf *btfSpec, spec *coreFieldSpec, kind coreRelocationKind) (uint64, error) {
+	switch kind {
+	case coreFieldExists:
+		return 1, nil
+	case coreFieldByteOffset, coreFieldByteSize:
+		if spec.bitfield || spec.bitOffset%8 != 0 {
+			return 0, errors.New("the relocations of bitfields aren't supported")
+		}
+		if kind == coreFieldByteOffset {
+			return uint64(spec.bitOffset / 8), nil
+		}
+		size, err := btf.sizeOf(spec.fieldID)
+		return uint64(size), err
+	}
+	return 0, errors.Errorf("CO-RE relocation kind %d not supported", kind)
+}
+
+// relocationValues returns the local value of a relocation, as compiled, and its value for the target kernel
+func relocationValues(local, target *btfSpec, relocation coreRelocation) (uint64, uint64, error) {
+	localRootID, localRoot, err := local.resolveType(relocation.typeID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	spec, err := parseCOREAccess(local, localRootID, relocation.access)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	localValue, err := fieldValue(local, spec, relocation.kind)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var targetValue uint64
+	var matched bool
+	for _, candidateID := range target.typesByEssentialName(localRoot.kind, localRoot.name) {
+		match, err := matchCOREField(local, spec, target, candidateID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if match == nil {
+			continue
+		}
+
+		value, err := fieldValue(target, match, relocation.kind)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		// all the candidates have to agree
+		if matched && value != targetValue {
+			return 0, 0, errors.Errorf("ambiguous relocation of `%s` %s", localRoot.name, relocation.access)
+		}
+		targetValue = value
+		matched = true
+	}
+
+	if !matched {
+		if relocation.kind == coreFieldExists {
+			return localValue, 0, nil
+		}
+		return 0, 0, errors.Errorf("`%s` %s not found in the kernel BTF", localRoot.name, relocation.access)
+	}
+
+	return localValue, targetValue, nil
+}
+
+// patchInstruction replaces the local value of a relocation by its target value in the given instruction
+func patchInstruction(insn []byte, bo binary.ByteOrder, kind coreRelocationKind, localValue, targetValue uint64) error {
+	if len(insn) < bpfInstructionSize {
+		return errors.New("truncated instruction")
+	}
+
+	opcode := insn[0]
+	switch opcode & 0x07 {
+	case bpfClassALU, bpfClassALU64:
+		if opcode&bpfSourceX != 0 {
+			return errors.Errorf("unexpected source register of instruction 0x%x", opcode)
+		}
+		if imm := uint64(bo.Uint32(insn[4:8])); imm != localValue {
+			return errors.Errorf("unexpected immediate value %d, expected %d", imm, localValue)
+		}
+		if targetValue > math.MaxInt32 {
+			return errors.Errorf("value %d out of range", targetValue)
+		}
+		bo.PutUint32(insn[4:8], uint32(targetValue))
+	case bpfClassLDX, bpfClassST, bpfClassSTX:
+		if kind != coreFieldByteOffset {
+			return errors.Errorf("unexpected memory access for relocation kind %d", kind)
+		}
+		if off := int16(bo.Uint16(insn[2:4])); int64(off) != int64(localValue) {
+			return errors.Errorf("unexpected offset %d, expected %d", off, localValue)
+		}
+		if targetValue > math.MaxInt16 {
+			return errors.Errorf("offset %d out of range", targetValue)
+		}
+		bo.PutUint16(insn[2:4], uint16(targetValue))
+	case bpfClassLD:
+		if opcode != bpfLDIMM64 || len(insn) < 2*bpfInstructionSize {
+			return errors.Errorf("unexpected instruction 0x%x", opcode)
+		}
+		if imm := uint64(bo.Uint32(insn[4:8])) | uint64(bo.Uint32(insn[12:16]))<<32; imm != localValue {
+			return errors.Errorf("unexpected immediate value %d, expected %d", imm, localValue)
+		}
+		bo.PutUint32(insn[4:8], uint32(targetValue))
+		bo.PutUint32(insn[12:16], uint32(targetValue>>32))
+	default:
+		return errors.Errorf("unexpected instruction 0x%x", opcode)
+	}
+	return nil
+}
+
+// relocateCOREObject applies the CO-RE relocations of an object to the given kernel BTF and returns the relocated
+// object, along with its ELF description
+func relocateCOREObject(object []byte, target *btfSpec) ([]byte, *elf.File, error) {
+	file, err := elf.NewFile(bytes.NewReader(object))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse the CO-RE object")
+	}
+
+	readSection := func(name string) ([]byte, error) {
+		section := file.Section(name)
+		if section == nil {
+			return nil, errors.Errorf("section %s not found in the CO-RE object", name)
+		}
+		return section.Data()
+	}
+
+	btfData, err := readSection(".BTF")
+	if err != nil {
+		return nil, nil, err
+	}
+	local, err := parseBTF(btfData)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse the BTF of the CO-RE object")
+	}
+
+	extData, err := readSection(".BTF.ext")
+	if err != nil {
+		return nil, nil, err
+	}
+	relocations, err := parseCORERelocations(extData, local)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	relocated := make([]byte, len(object))
+	copy(relocated, object)
+
+	for _, relocation := range relocations {
+		section := file.Section(relocation.section)
+		if section == nil {
+			return nil, nil, errors.Errorf("section %s of a CO-RE relocation not found", relocation.section)
+		}
+
+		localValue, targetValue, err := relocationValues(local, target, relocation)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to relocate instruction %d of %s", relocation.insnOffset/bpfInstructionSize, relocation.section)
+		}
+
+		start := section.Offset + uint64(relocation.insnOffset)
+		end := start + 2*bpfInstructionSize
+		if end > section.Offset+section.Size {
+			end = section.Offset + section.Size
+		}
+		if start >= end || end > uint64(len(relocated)) {
+			return nil, nil, errors.Errorf("invalid instruction offset %d of %s", relocation.insnOffset, relocation.section)
+		}
+
+		if err := patchInstruction(relocated[start:end], file.ByteOrder, relocation.kind, localValue, targetValue); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to relocate instruction %d of %s", relocation.insnOffset/bpfInstructionSize, relocation.section)
+		}
+	}
+
+	return relocated, file, nil
+}
+
+// excludedSyscallSections returns the sections of the syscall probes of the CO-RE object that don't apply to the
+// running kernel: the object holds the probes of the kernels with and without the syscall wrappers
+func excludedSyscallSections(file *elf.File, useSyscallWrapper bool) []string {
+	prefixes := []string{"__x64_", "__ia32_", "__arm64_"}
+	if useSyscallWrapper {
+		prefixes = []string{"sys_", "compat_sys_"}
+	}
+
+	var sections []string
+	for _, section := range file.Sections {
+		els := strings.SplitN(section.Name, "/", 2)
+		if len(els) != 2 || (els[0] != "kprobe" && els[0] != "kretprobe") {
+			continue
+		}
+
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(els[1], prefix) {
+				sections = append(sections, section.Name)
+				break
+			}
+		}
+	}
+	return sections
+}
+
+// loadCOREAsset returns the CO-RE object relocated against the BTF of the running kernel, and the sections to
+// exclude from it
+func loadCOREAsset(bpfDir string, useSyscallWrapper bool) (io.ReaderAt, []string, error) {
+	target, err := loadKernelBTF()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := bytecode.GetReader(bpfDir, coreAsset)
+	if err != nil {
+		return nil, nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	object, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read the CO-RE object")
+	}
+
+	relocated, file, err := relocateCOREObject(object, target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bytes.NewReader(relocated), excludedSyscallSections(file, useSyscallWrapper), nil
+}
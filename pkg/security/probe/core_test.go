@@ -0,0 +1,274 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+// btfBuilder encodes the BTF types in the order of their IDs
+type btfBuilder struct {
+	types   bytes.Buffer
+	strings bytes.Buffer
+}
+
+func newBTFBuilder() *btfBuilder {
+	b := &btfBuilder{}
+	b.strings.WriteByte(0)
+	return b
+}
+
+func (b *btfBuilder) str(s string) uint32 {
+	if s == "" {
+		return 0
+	}
+	offset := uint32(b.strings.Len())
+	b.strings.WriteString(s)
+	b.strings.WriteByte(0)
+	return offset
+}
+
+func (b *btfBuilder) write(values ...uint32) {
+	for _, value := range values {
+		_ = binary.Write(&b.types, binary.LittleEndian, value)
+	}
+}
+
+func (b *btfBuilder) addInt(name string, size uint32) {
+	b.write(b.str(name), uint32(btfKindInt)<<24, size, size*8)
+}
+
+func (b *btfBuilder) addComposite(kind btfKind, name string, size uint32, members ...btfMember) {
+	b.write(b.str(name), uint32(kind)<<24|uint32(len(members)), size)
+	for _, member := range members {
+		b.write(b.str(member.name), member.typeID, member.bitOffset)
+	}
+}
+
+func (b *btfBuilder) addPointer(typeID uint32) {
+	b.write(0, uint32(btfKindPointer)<<24, typeID)
+}
+
+func (b *btfBuilder) addFunc(name string, protoID uint32) {
+	b.write(b.str(name), uint32(btfKindFunc)<<24, protoID)
+}
+
+func (b *btfBuilder) addFuncProto(returnID uint32, paramIDs ...uint32) {
+	b.write(0, uint32(btfKindFuncProto)<<24|uint32(len(paramIDs)), returnID)
+	for _, paramID := range paramIDs {
+		b.write(0, paramID)
+	}
+}
+
+func (b *btfBuilder) bytes() []byte {
+	var data bytes.Buffer
+	_ = binary.Write(&data, binary.LittleEndian, btfHeader{
+		Magic:   btfMagic,
+		Version: 1,
+		HdrLen:  btfHeaderSize,
+		TypeLen: uint32(b.types.Len()),
+		StrOff:  uint32(b.types.Len()),
+		StrLen:  uint32(b.strings.Len()),
+	})
+	data.Write(b.types.Bytes())
+	data.Write(b.strings.Bytes())
+	return data.Bytes()
+}
+
+func TestCOREFieldRelocations(t *testing.T) {
+	// struct foo___local { int a; int b; }
+	lb := newBTFBuilder()
+	lb.addInt("int", 4)
+	lb.addComposite(btfKindStruct, "foo___local", 8, btfMember{name: "a", typeID: 1}, btfMember{name: "b", typeID: 1, bitOffset: 32})
+
+	local, err := parseBTF(lb.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// struct foo { long x; union { int y; int b; }; long a; }
+	tb := newBTFBuilder()
+	tb.addInt("int", 4)
+	tb.addInt("long", 8)
+	tb.addComposite(btfKindUnion, "", 4, btfMember{name: "y", typeID: 1}, btfMember{name: "b", typeID: 1})
+	tb.addComposite(btfKindStruct, "foo", 24, btfMember{name: "x", typeID: 2}, btfMember{typeID: 3, bitOffset: 64}, btfMember{name: "a", typeID: 2, bitOffset: 128})
+
+	target, err := parseBTF(tb.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		access string
+		kind   coreRelocationKind
+		local  uint64
+		target uint64
+	}{
+		{"0:0", coreFieldByteOffset, 0, 16},
+		{"0:1", coreFieldByteOffset, 4, 8},
+		{"0:0", coreFieldByteSize, 4, 8},
+		{"0:1", coreFieldExists, 1, 1},
+		{"1:1", coreFieldByteOffset, 12, 32},
+	}
+
+	for _, test := range tests {
+		localValue, targetValue, err := relocationValues(local, target, coreRelocation{typeID: 2, access: test.access, kind: test.kind})
+		if err != nil {
+			t.Fatalf("failed to relocate %s: %s", test.access, err)
+		}
+		if localValue != test.local || targetValue != test.target {
+			t.Errorf("expected the values %d/%d for %s (kind %d), got %d/%d", test.local, test.target, test.access, test.kind, localValue, targetValue)
+		}
+	}
+
+	// a field missing from the kernel only exists for the existence relocations
+	tb = newBTFBuilder()
+	tb.addInt("int", 4)
+	tb.addComposite(btfKindStruct, "foo", 4, btfMember{name: "a", typeID: 1})
+	if target, err = parseBTF(tb.bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, value, err := relocationValues(local, target, coreRelocation{typeID: 2, access: "0:1", kind: coreFieldExists}); err != nil || value != 0 {
+		t.Errorf("expected a missing field, got %d (%v)", value, err)
+	}
+	if _, _, err := relocationValues(local, target, coreRelocation{typeID: 2, access: "0:1", kind: coreFieldByteOffset}); err == nil {
+		t.Error("the relocation of a missing field should fail")
+	}
+}
+
+func TestPatchInstruction(t *testing.T) {
+	bo := binary.LittleEndian
+
+	// r1 = 4
+	insn := []byte{0xb7, 0x01, 0, 0, 4, 0, 0, 0}
+	if err := patchInstruction(insn, bo, coreFieldByteOffset, 4, 16); err != nil {
+		t.Fatal(err)
+	}
+	if imm := bo.Uint32(insn[4:8]); imm != 16 {
+		t.Errorf("expected the immediate value 16, got %d", imm)
+	}
+
+	// r0 = *(u64 *)(r1 + 8)
+	insn = []byte{0x79, 0x10, 8, 0, 0, 0, 0, 0}
+	if err := patchInstruction(insn, bo, coreFieldByteOffset, 8, 24); err != nil {
+		t.Fatal(err)
+	}
+	if off := bo.Uint16(insn[2:4]); off != 24 {
+		t.Errorf("expected the offset 24, got %d", off)
+	}
+	if err := patchInstruction(insn, bo, coreFieldByteSize, 24, 8); err == nil {
+		t.Error("a size relocation of a memory access shouldn't be patched")
+	}
+
+	// r1 = 1 ll
+	insn = []byte{0x18, 0x01, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	if err := patchInstruction(insn, bo, coreFieldExists, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if imm := bo.Uint32(insn[4:8]); imm != 0 {
+		t.Errorf("expected the immediate value 0, got %d", imm)
+	}
+
+	// the instruction doesn't hold the local value
+	insn = []byte{0xb7, 0x01, 0, 0, 4, 0, 0, 0}
+	if err := patchInstruction(insn, bo, coreFieldByteOffset, 8, 16); err == nil {
+		t.Error("an unexpected immediate value shouldn't be patched")
+	}
+}
+
+func TestKernelBTF(t *testing.T) {
+	if _, err := os.Stat(kernelBTFPath); err != nil {
+		t.Skip("kernel BTF not available")
+	}
+
+	target, err := loadKernelBTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := target.typesByEssentialName(btfKindStruct, "task_struct")
+	if len(ids) != 1 {
+		t.Fatalf("expected one struct task_struct, got %d", len(ids))
+	}
+
+	member, _, err := findMember(target, target.types[ids[0]], "pid")
+	if err != nil || member == nil {
+		t.Fatalf("pid not found in struct task_struct: %v", err)
+	}
+}
+
+func TestKernelBTFRelocations(t *testing.T) {
+	if _, err := os.Stat(kernelBTFPath); err != nil {
+		t.Skip("kernel BTF not available")
+	}
+
+	target, err := loadKernelBTF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// struct list_head___local { void *prev; void *next; }
+	// struct path___local { void *dentry; }
+	// struct task_struct___local { int pid; }
+	lb := newBTFBuilder()
+	lb.addInt("int", 4)
+	lb.addPointer(0)
+	lb.addComposite(btfKindStruct, "list_head___local", 16, btfMember{name: "prev", typeID: 2}, btfMember{name: "next", typeID: 2, bitOffset: 64})
+	lb.addComposite(btfKindStruct, "path___local", 8, btfMember{name: "dentry", typeID: 2})
+	lb.addComposite(btfKindStruct, "task_struct___local", 4, btfMember{name: "pid", typeID: 1})
+
+	local, err := parseBTF(lb.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the layouts of struct list_head and struct path are the same for all the 64 bits kernels
+	tests := []struct {
+		typeID uint32
+		access string
+		kind   coreRelocationKind
+		target uint64
+	}{
+		{3, "0:0", coreFieldByteOffset, 8},
+		{3, "0:1", coreFieldByteOffset, 0},
+		{3, "0:1", coreFieldByteSize, btfPointerSize},
+		{4, "0:0", coreFieldByteOffset, 8},
+		{5, "0:0", coreFieldByteSize, 4},
+	}
+
+	for _, test := range tests {
+		_, value, err := relocationValues(local, target, coreRelocation{typeID: test.typeID, access: test.access, kind: test.kind})
+		if err != nil {
+			t.Fatalf("failed to relocate %s of type %d: %s", test.access, test.typeID, err)
+		}
+		if value != test.target {
+			t.Errorf("expected the value %d for %s of type %d (kind %d), got %d", test.target, test.access, test.typeID, test.kind, value)
+		}
+	}
+
+	// the offset of task_struct.pid depends on the kernel, the relocation agrees with the layout of the struct
+	_, pidOffset, err := relocationValues(local, target, coreRelocation{typeID: 5, access: "0:0", kind: coreFieldByteOffset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := target.memberOffset("task_struct", "pid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, err := target.sizeOf(target.typesByEssentialName(btfKindStruct, "task_struct")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pidOffset != uint64(expected) || pidOffset == 0 || pidOffset+4 > uint64(size) {
+		t.Errorf("unexpected offset %d of task_struct.pid, expected %d within %d bytes", pidOffset, expected, size)
+	}
+}
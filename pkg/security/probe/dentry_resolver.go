@@ -9,6 +9,10 @@ package probe
 
 const (
 	dentryPathKeyNotFound = "error: dentry path key not found"
+	// dentryPathUnreliable is used when the path of a dentry was overwritten before it could be resolved
+	dentryPathUnreliable = "error: dentry path unreliable"
+	// dentryMaxDepth is the maximum number of segments resolved by the kernel
+	dentryMaxDepth = 16
 )
 
 // NewDentryResolver returns a new dentry resolver
@@ -10,20 +10,47 @@ package probe
 import (
 	"C"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"unsafe"
 
 	lib "github.com/DataDog/ebpf"
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/moby/sys/mountinfo"
 	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
 
 	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+const (
+	// maxSnapshotSegments bounds the number of path segments snapshotted from the filesystem, they are never evicted
+	maxSnapshotSegments = 4096
+	// erpcSlots is the number of eRPC path resolutions run concurrently, the size of the dr_erpc_buffers map
+	erpcSlots = 16
 )
 
 // DentryResolver resolves inode/mountID to full paths
 type DentryResolver struct {
-	probe     *Probe
-	pathnames *lib.Map
-	cache     *lru.Cache
+	probe       *Probe
+	pathnames   *lib.Map
+	cache       *lru.Cache
+	erpc        *ERPC
+	erpcBuffers *lib.Map
+	// erpcFreeSlots holds the slots of the dr_erpc_buffers map not used by a request. When all of them are used, the
+	// path is resolved from the pathnames map rather than waiting for one
+	erpcFreeSlots chan uint32
+	erpcRequestID uint32
+	// erpcLock prevents the eRPC file descriptor from being closed during a request
+	erpcLock sync.RWMutex
+
+	// segments of the paths snapshotted from the filesystem, they are not subject to the lru eviction
+	snapshotLock sync.RWMutex
+	snapshot     map[PathKey]PathValue
 }
 
 // ErrInvalidKeyPath is returned when inode or mountid are not valid
@@ -65,23 +92,68 @@ func (p *PathKey) MarshalBinary() ([]byte, error) {
 }
 
 type PathValue struct {
-	Parent PathKey
-	Name   [128]byte
+	Parent     PathKey
+	Name       [128]byte
+	Generation uint32
+	_          uint32
+}
+
+// pathValueSize is the size of a PathValue in the kernel
+const pathValueSize = 16 + 128 + 8
+
+// erpcPathBuffer is the slot of the dr_erpc_buffers map an eRPC path resolution writes the segments of the path to
+type erpcPathBuffer struct {
+	RequestID uint32
+	Depth     uint32
+	Segments  [dentryMaxDepth]PathValue
+}
+
+// ErrPathGenerationMismatch is returned when the leaf of a path was overwritten after the emission of an event
+type ErrPathGenerationMismatch struct {
+	Inode      uint64
+	MountID    uint32
+	Generation uint32
+}
+
+func (e *ErrPathGenerationMismatch) Error() string {
+	return fmt.Sprintf("path of %d/%d overwritten since generation %d", e.Inode, e.MountID, e.Generation)
 }
 
 func (dr *DentryResolver) DelCacheEntry(mountID uint32, inode uint64) {
 	key := PathKey{MountID: mountID, Inode: inode}
 	dr.cache.Remove(key)
+
+	dr.snapshotLock.Lock()
+	delete(dr.snapshot, key)
+	dr.snapshotLock.Unlock()
+}
+
+// Flush drops the cached path segments, the invalidations of some of them may have been lost. The snapshotted
+// segments are kept
+func (dr *DentryResolver) Flush() {
+	dr.cache.Purge()
+}
+
+// lookupCache returns the cached path segment of the provided key, looking at the snapshotted segments last
+func (dr *DentryResolver) lookupCache(key PathKey) (PathValue, bool) {
+	if entry, exists := dr.cache.Get(key); exists {
+		return entry.(PathValue), true
+	}
+
+	dr.snapshotLock.RLock()
+	defer dr.snapshotLock.RUnlock()
+
+	path, exists := dr.snapshot[key]
+	return path, exists
 }
 
 func (dr *DentryResolver) getNameFromCache(mountID uint32, inode uint64) (name string, err error) {
 	key := PathKey{MountID: mountID, Inode: inode}
 
-	entry, exists := dr.cache.Get(key)
+	path, exists := dr.lookupCache(key)
 	if !exists {
 		return "", errors.New("entry not found")
 	}
-	path := entry.(PathValue)
 
 	return C.GoString((*C.char)(unsafe.Pointer(&path.Name))), nil
 }
@@ -115,11 +187,11 @@ func (dr *DentryResolver) ResolveFromCache(mountID uint32, inode uint64) (filena
 	for {
 		cacheKey := PathKey{MountID: key.MountID, Inode: key.Inode}
 
-		entry, exists := dr.cache.Get(cacheKey)
+		entry, exists := dr.lookupCache(cacheKey)
 		if !exists {
 			return "", errors.New("entry not found")
 		}
-		path = entry.(PathValue)
+		path = entry
 
 		// Don't append dentry name if this is the root dentry (i.d. name == '/')
 		if path.Name[0] != '\x00' && path.Name[0] != '/' {
@@ -190,23 +262,141 @@ func (dr *DentryResolver) ResolveFromMap(mountID uint32, inode uint64, pathID ui
 	return filename, err
 }
 
+// ResolveFromERPC resolves the path of the provided inode / mount id / path id by asking the kernel to walk the dentry
+// it last resolved for it. The segments of the path are written to a slot of the dr_erpc_buffers map, an error is
+// returned when no slot is free
+func (dr *DentryResolver) ResolveFromERPC(mountID uint32, inode uint64, pathID uint32) (filename string, err error) {
+	key := PathKey{MountID: mountID, Inode: inode, PathID: pathID}
+	if key.IsNull() {
+		return "", &ErrInvalidKeyPath{Inode: inode, MountID: mountID}
+	}
+
+	dr.erpcLock.RLock()
+	defer dr.erpcLock.RUnlock()
+
+	if dr.erpc == nil {
+		return "", errors.New("eRPC dentry resolution disabled")
+	}
+
+	var slot uint32
+	select {
+	case slot = <-dr.erpcFreeSlots:
+		defer func() { dr.erpcFreeSlots <- slot }()
+	default:
+		return "", errors.New("no free eRPC slot")
+	}
+
+	// the request id tells the segments of the request from the ones of a previous request of the slot
+	requestID := atomic.AddUint32(&dr.erpcRequestID, 1)
+
+	var request ERPCRequest
+	request.OP = ResolvePathOp
+	key.Write(request.Data[0:16])
+	ebpf.ByteOrder.PutUint32(request.Data[16:20], slot)
+	ebpf.ByteOrder.PutUint32(request.Data[20:24], requestID)
+
+	if err = dr.erpc.Request(&request); err != nil {
+		return "", errors.Wrap(err, "eRPC request failed")
+	}
+
+	var buffer erpcPathBuffer
+	if err = dr.erpcBuffers.Lookup(slot, &buffer); err != nil {
+		return "", errors.Wrap(err, "failed to read the eRPC buffer")
+	}
+
+	// the kernel didn't resolve a dentry for this key, or it was freed since then
+	if buffer.RequestID != requestID || buffer.Depth > dentryMaxDepth {
+		return "", errors.Errorf("eRPC path resolution failed for mountID `%d` and inode `%d`", mountID, inode)
+	}
+
+	toAdd := make(map[PathKey]PathValue)
+
+	var resolved bool
+	for _, path := range buffer.Segments[:buffer.Depth] {
+		cacheKey := PathKey{MountID: key.MountID, Inode: key.Inode}
+		toAdd[cacheKey] = path
+
+		// Don't append dentry name if this is the root dentry (i.d. name == '/')
+		if path.Name[0] != '\x00' && path.Name[0] != '/' {
+			filename = "/" + C.GoString((*C.char)(unsafe.Pointer(&path.Name))) + filename
+		}
+
+		if path.Parent.Inode == 0 {
+			resolved = true
+			break
+		}
+
+		// Prepare next key
+		key = path.Parent
+	}
+
+	// the path is deeper than the segments walked by the kernel
+	if !resolved {
+		return "", errors.Errorf("eRPC path resolution failed for mountID `%d` and inode `%d`", mountID, inode)
+	}
+
+	if len(filename) == 0 {
+		filename = "/"
+	}
+
+	for k, v := range toAdd {
+		dr.cache.Add(k, v)
+	}
+
+	return filename, nil
+}
+
 // Resolve the pathname of a dentry, starting at the pathnameKey in the pathnames table
 func (dr *DentryResolver) Resolve(mountID uint32, inode uint64, pathID uint32) string {
 	path, err := dr.ResolveFromCache(mountID, inode)
+	if err != nil {
+		path, err = dr.ResolveFromERPC(mountID, inode, pathID)
+	}
 	if err != nil {
 		path, _ = dr.ResolveFromMap(mountID, inode, pathID)
 	}
 	return path
 }
 
+func (dr *DentryResolver) getGenerationFromCache(mountID uint32, inode uint64) (uint32, bool) {
+	entry, exists := dr.lookupCache(PathKey{MountID: mountID, Inode: inode})
+	if !exists || entry.Generation == 0 {
+		// snapshotted segments don't have a generation, they were checked against the filesystem
+		return 0, false
+	}
+	return entry.Generation, true
+}
+
+// ResolveWithGeneration resolves the pathname of a dentry and ensures that its leaf still has the generation reported
+// by the kernel when the event was sent. Cached segments from another generation are dropped and the path is resolved
+// again from the kernel. An error is returned if the kernel entry was overwritten too, the path can't be trusted.
+func (dr *DentryResolver) ResolveWithGeneration(mountID uint32, inode uint64, pathID uint32, generation uint32) (string, error) {
+	// generation 0 means that the kernel couldn't track the generation of the entry
+	if generation == 0 {
+		return dr.Resolve(mountID, inode, pathID), nil
+	}
+
+	if cached, exists := dr.getGenerationFromCache(mountID, inode); exists && cached != generation {
+		dr.DelCacheEntry(mountID, inode)
+	}
+
+	path := dr.Resolve(mountID, inode, pathID)
+
+	if leaf, exists := dr.getGenerationFromCache(mountID, inode); exists && leaf != generation {
+		dr.DelCacheEntry(mountID, inode)
+		return path, &ErrPathGenerationMismatch{Inode: inode, MountID: mountID, Generation: generation}
+	}
+
+	return path, nil
+}
+
 func (dr *DentryResolver) getParentFromCache(mountID uint32, inode uint64) (uint32, uint64, error) {
 	key := PathKey{MountID: mountID, Inode: inode}
 
-	entry, exists := dr.cache.Get(key)
+	path, exists := dr.lookupCache(key)
 	if !exists {
 		return 0, 0, errors.New("entry not found")
 	}
-	path := entry.(PathValue)
 
 	return path.Parent.MountID, path.Parent.Inode, nil
 }
@@ -231,6 +421,121 @@ func (dr *DentryResolver) GetParent(mountID uint32, inode uint64, pathID uint32)
 	return parentMountID, parentInode, err
 }
 
+// findMount returns the mount of the provided path, as seen by the host, that holds the device of the file
+func findMount(mounts []*mountinfo.Info, pathname string, dev uint64) *mountinfo.Info {
+	var mount *mountinfo.Info
+	for _, m := range mounts {
+		if uint32(m.Major) != unix.Major(dev) || uint32(m.Minor) != unix.Minor(dev) {
+			continue
+		}
+
+		if m.Mountpoint != "/" && pathname != m.Mountpoint && !strings.HasPrefix(pathname, m.Mountpoint+"/") {
+			continue
+		}
+
+		if mount == nil || len(m.Mountpoint) > len(mount.Mountpoint) {
+			mount = m
+		}
+	}
+	return mount
+}
+
+// addSnapshotSegments inserts the provided segments in the snapshot, unless it would then hold more than
+// maxSnapshotSegments segments
+func (dr *DentryResolver) addSnapshotSegments(segments map[PathKey]PathValue) error {
+	dr.snapshotLock.Lock()
+	defer dr.snapshotLock.Unlock()
+
+	size := len(dr.snapshot)
+	for key := range segments {
+		if _, exists := dr.snapshot[key]; !exists {
+			size++
+		}
+	}
+	if size > maxSnapshotSegments {
+		return errors.Errorf("the snapshot is limited to %d path segments", maxSnapshotSegments)
+	}
+
+	for key, value := range segments {
+		dr.snapshot[key] = value
+	}
+	return nil
+}
+
+// SnapshotPath resolves the segments of the provided path from the filesystem of the host and inserts them in the
+// cache, so that events on this path can be resolved even if the kernel didn't see its dentries yet. It returns the
+// key of the path
+func (dr *DentryResolver) SnapshotPath(pathname string) (PathKey, error) {
+	root := utils.ProcRootPath(1)
+	pathname = filepath.Clean(pathname)
+
+	var stat syscall.Stat_t
+	if err := syscall.Lstat(filepath.Join(root, pathname), &stat); err != nil {
+		return PathKey{}, err
+	}
+
+	mounts, err := utils.ParseMountInfoFile(1)
+	if err != nil {
+		return PathKey{}, err
+	}
+
+	mount := findMount(mounts, pathname, stat.Dev)
+	if mount == nil {
+		return PathKey{}, errors.Errorf("couldn't find the mount point of %s", pathname)
+	}
+
+	// bind mounts of a sub directory aren't supported, the dentries above the mount root are unknown
+	if mount.Root != "/" {
+		return PathKey{}, errors.Errorf("%s is in a bind mount of %s", pathname, mount.Root)
+	}
+
+	mountID := uint32(mount.ID)
+	segments := make(map[PathKey]PathValue)
+
+	current := mount.Mountpoint
+	if err := syscall.Lstat(filepath.Join(root, current), &stat); err != nil {
+		return PathKey{}, err
+	}
+	key := PathKey{MountID: mountID, Inode: stat.Ino}
+
+	value := PathValue{}
+	copy(value.Name[:], "/")
+	segments[key] = value
+
+	for _, name := range strings.Split(strings.TrimPrefix(pathname, mount.Mountpoint), "/") {
+		if name == "" {
+			continue
+		}
+
+		current = filepath.Join(current, name)
+		if err := syscall.Lstat(filepath.Join(root, current), &stat); err != nil {
+			return PathKey{}, err
+		}
+
+		value := PathValue{Parent: key}
+		copy(value.Name[:len(value.Name)-1], name)
+
+		key = PathKey{MountID: mountID, Inode: stat.Ino}
+		segments[key] = value
+	}
+
+	return key, dr.addSnapshotSegments(segments)
+}
+
+// Close closes the eRPC file descriptor of the dentry resolver, the paths are then resolved from the maps
+func (dr *DentryResolver) Close() error {
+	dr.erpcLock.Lock()
+	defer dr.erpcLock.Unlock()
+
+	if dr.erpc == nil {
+		return nil
+	}
+
+	err := dr.erpc.Close()
+	dr.erpc = nil
+	return err
+}
+
 // Start the dentry resolver
 func (dr *DentryResolver) Start() error {
 	pathnames, ok, err := dr.probe.manager.GetMap("pathnames")
@@ -247,6 +552,27 @@ func (dr *DentryResolver) Start() error {
 		return err
 	}
 	dr.cache = cache
+	dr.snapshot = make(map[PathKey]PathValue)
+
+	if dr.probe.useERPCDentryResolution() {
+		erpcBuffers, ok, err := dr.probe.manager.GetMap("dr_erpc_buffers")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("map dr_erpc_buffers not found")
+		}
+		dr.erpcBuffers = erpcBuffers
+
+		dr.erpcFreeSlots = make(chan uint32, erpcSlots)
+		for slot := uint32(0); slot < erpcSlots; slot++ {
+			dr.erpcFreeSlots <- slot
+		}
+
+		if dr.erpc, err = NewERPC(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
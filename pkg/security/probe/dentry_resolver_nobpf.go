@@ -21,3 +21,8 @@ func (dr *DentryResolver) GetName(mountID uint32, inode uint64, pathID uint32) s
 func (dr *DentryResolver) Resolve(mountID uint32, inode uint64, pathID uint32) string {
 	return ""
 }
+
+// ResolveWithGeneration resolves the pathname of a dentry and ensures that its leaf matches the provided generation
+func (dr *DentryResolver) ResolveWithGeneration(mountID uint32, inode uint64, pathID uint32, generation uint32) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	lib "github.com/DataDog/ebpf"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/moby/sys/mountinfo"
+	"golang.org/x/sys/unix"
+)
+
+func TestFindMount(t *testing.T) {
+	mounts := []*mountinfo.Info{
+		{ID: 1, Major: 8, Minor: 1, Root: "/", Mountpoint: "/"},
+		{ID: 2, Major: 8, Minor: 2, Root: "/", Mountpoint: "/var"},
+		{ID: 3, Major: 8, Minor: 2, Root: "/lib", Mountpoint: "/var/lib2"},
+		{ID: 4, Major: 8, Minor: 3, Root: "/", Mountpoint: "/var/lib"},
+	}
+
+	tests := []struct {
+		pathname string
+		dev      uint64
+		expected int
+	}{
+		{pathname: "/etc/passwd", dev: unix.Mkdev(8, 1), expected: 1},
+		{pathname: "/var/log/syslog", dev: unix.Mkdev(8, 2), expected: 2},
+		{pathname: "/var/lib/dpkg", dev: unix.Mkdev(8, 3), expected: 4},
+		{pathname: "/var/lib2/dpkg", dev: unix.Mkdev(8, 2), expected: 3},
+		{pathname: "/variable", dev: unix.Mkdev(8, 2), expected: 0},
+	}
+
+	for _, test := range tests {
+		mount := findMount(mounts, test.pathname, test.dev)
+		if test.expected == 0 {
+			if mount != nil {
+				t.Errorf("expected no mount for %s, got %d", test.pathname, mount.ID)
+			}
+			continue
+		}
+
+		if mount == nil || mount.ID != test.expected {
+			t.Errorf("expected mount %d for %s, got %+v", test.expected, test.pathname, mount)
+		}
+	}
+}
+
+func TestDentryResolverClose(t *testing.T) {
+	erpc, err := NewERPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dr := &DentryResolver{erpc: erpc, erpcFreeSlots: make(chan uint32, erpcSlots)}
+	dr.erpcFreeSlots <- 0
+	if err := dr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := unix.FcntlInt(uintptr(erpc.fd), unix.F_GETFD, 0); err != unix.EBADF {
+		t.Errorf("expected the eRPC file descriptor to be closed, got %v", err)
+	}
+	if _, err := dr.ResolveFromERPC(1, 2, 0); err == nil {
+		t.Error("a closed dentry resolver shouldn't send eRPC requests")
+	}
+
+	// closing it again is a no-op
+	if err := dr.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDentryResolverERPCSlots(t *testing.T) {
+	erpc, err := NewERPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer erpc.Close()
+
+	// all the slots are used by other requests, the resolution falls back to the maps instead of waiting
+	dr := &DentryResolver{erpc: erpc, erpcFreeSlots: make(chan uint32, erpcSlots)}
+	if _, err := dr.ResolveFromERPC(1, 2, 0); err == nil {
+		t.Error("a request without free slot shouldn't be sent")
+	}
+}
+
+func TestDentryResolverSnapshotLimit(t *testing.T) {
+	dr := &DentryResolver{snapshot: make(map[PathKey]PathValue)}
+
+	segments := make(map[PathKey]PathValue)
+	for i := 0; i < maxSnapshotSegments; i++ {
+		segments[PathKey{MountID: 1, Inode: uint64(i)}] = PathValue{}
+	}
+	if err := dr.addSnapshotSegments(segments); err != nil {
+		t.Fatal(err)
+	}
+
+	// the segments already snapshotted don't count twice
+	if err := dr.addSnapshotSegments(map[PathKey]PathValue{{MountID: 1, Inode: 0}: {}}); err != nil {
+		t.Error(err)
+	}
+
+	if err := dr.addSnapshotSegments(map[PathKey]PathValue{{MountID: 2, Inode: 0}: {}}); err == nil {
+		t.Error("the snapshot should be limited")
+	}
+	if len(dr.snapshot) != maxSnapshotSegments {
+		t.Errorf("expected %d snapshotted segments, got %d", maxSnapshotSegments, len(dr.snapshot))
+	}
+}
+
+func TestDentryResolverGeneration(t *testing.T) {
+	pathnames, err := lib.NewMap(&lib.MapSpec{
+		Type:       lib.Hash,
+		KeySize:    16,
+		ValueSize:  pathValueSize,
+		MaxEntries: 16,
+	})
+	if err != nil {
+		t.Skipf("eBPF maps unavailable: %s", err)
+	}
+	defer pathnames.Close()
+
+	cache, err := lru.New(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dr := &DentryResolver{pathnames: pathnames, cache: cache}
+
+	newPathValue := func(parent PathKey, name string, generation uint32) PathValue {
+		path := PathValue{Parent: parent, Generation: generation}
+		copy(path.Name[:], name)
+		return path
+	}
+
+	root := PathKey{MountID: 1, Inode: 1}
+	leaf := PathKey{MountID: 1, Inode: 10}
+	dr.cache.Add(root, newPathValue(PathKey{}, "/", 1))
+	dr.cache.Add(leaf, newPathValue(root, "old", 2))
+
+	if path, err := dr.ResolveWithGeneration(leaf.MountID, leaf.Inode, 0, 2); err != nil || path != "/old" {
+		t.Errorf("expected the cached path, got %s: %v", path, err)
+	}
+
+	// the pathnames entry was overwritten by the kernel, the cached segment of another generation is dropped
+	for key, path := range map[PathKey]PathValue{root: newPathValue(PathKey{}, "/", 1), leaf: newPathValue(root, "new", 3)} {
+		keyBuffer := make([]byte, 16)
+		key.Write(keyBuffer)
+		if err := pathnames.Put(keyBuffer, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if path, err := dr.ResolveWithGeneration(leaf.MountID, leaf.Inode, 0, 3); err != nil || path != "/new" {
+		t.Errorf("expected the path to be resolved again from the kernel, got %s: %v", path, err)
+	}
+
+	// an event of the previous generation can't be resolved anymore
+	if _, err := dr.ResolveWithGeneration(leaf.MountID, leaf.Inode, 0, 2); err == nil {
+		t.Error("expected a generation mismatch")
+	} else if _, ok := err.(*ErrPathGenerationMismatch); !ok {
+		t.Errorf("expected a generation mismatch, got %v", err)
+	}
+
+	// without generation, the path is resolved as is
+	if path, err := dr.ResolveWithGeneration(leaf.MountID, leaf.Inode, 0, 0); err != nil || path != "/new" {
+		t.Errorf("expected the path to be resolved, got %s: %v", path, err)
+	}
+}
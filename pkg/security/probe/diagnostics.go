@@ -0,0 +1,230 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// diagnosticsBundlePrefix is the prefix of the directories of the load diagnostics bundles
+	diagnosticsBundlePrefix = "load-failure-"
+	// diagnosticsMaxBundles is the number of load diagnostics bundles kept on disk, the oldest ones being removed
+	diagnosticsMaxBundles = 5
+	// diagnosticsFile is the file of a bundle describing the failure
+	diagnosticsFile = "diagnostics.json"
+	// diagnosticsVerifierLogFile is the file of a bundle holding the error returned by the kernel, and the verifier log
+	// when a program was rejected
+	diagnosticsVerifierLogFile = "verifier.log"
+)
+
+// LoadedAsset identifies the eBPF object the programs are loaded from
+type LoadedAsset struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// LoadDiagnostics describes a failure of a family of probes to load, written to disk so that it can be debugged
+// without reproducing the host
+type LoadDiagnostics struct {
+	Timestamp     time.Time          `json:"timestamp"`
+	Family        string             `json:"family"`
+	Error         string             `json:"error"`
+	KernelVersion string             `json:"kernel_version"`
+	KernelRelease string             `json:"kernel_release"`
+	Arch          string             `json:"arch"`
+	Asset         LoadedAsset        `json:"asset"`
+	Capabilities  KernelCapabilities `json:"capabilities"`
+	Lockdown      KernelLockdown     `json:"lockdown"`
+	KernelOffsets KernelOffsets      `json:"kernel_offsets"`
+	Config        LoadConfig         `json:"config"`
+}
+
+// LoadConfig holds the settings shaping the loaded eBPF programs and maps. The bundles are attached to flares, only
+// the settings listed here are written, the secrets of the configuration never are
+type LoadConfig struct {
+	BPFDir                       string         `json:"bpf_dir"`
+	EnableKernelFilters          bool           `json:"enable_kernel_filters"`
+	EnableApprovers              bool           `json:"enable_approvers"`
+	EnableDiscarders             bool           `json:"enable_discarders"`
+	ApproversMapSize             int            `json:"approvers_map_size"`
+	DiscardersMapSize            int            `json:"discarders_map_size"`
+	DisabledEventTypes           []string       `json:"disabled_event_types"`
+	EventStreamUseRingBuffer     bool           `json:"event_stream_use_ring_buffer"`
+	EventStreamBufferSizes       map[string]int `json:"event_stream_buffer_sizes"`
+	KRetProbeMaxActive           int            `json:"kretprobe_max_active"`
+	FEntryProbes                 []string       `json:"fentry_probes"`
+	RuntimeCompilationEnabled    bool           `json:"runtime_compilation_enabled"`
+	RuntimeCompilationHeaderDirs []string       `json:"runtime_compilation_header_dirs"`
+	MapSizingExpectedContainers  int            `json:"map_sizing_expected_containers"`
+	MapSizingProcessCacheSize    int            `json:"map_sizing_process_cache_size"`
+	MapSizingDentryCacheSize     int            `json:"map_sizing_dentry_cache_size"`
+	MapPinningEnabled            bool           `json:"map_pinning_enabled"`
+	SyscallMonitor               bool           `json:"syscall_monitor"`
+}
+
+// newLoadConfig returns the settings of the given configuration written to the bundles
+func newLoadConfig(cfg *config.Config) LoadConfig {
+	return LoadConfig{
+		BPFDir:                       cfg.BPFDir,
+		EnableKernelFilters:          cfg.EnableKernelFilters,
+		EnableApprovers:              cfg.EnableApprovers,
+		EnableDiscarders:             cfg.EnableDiscarders,
+		ApproversMapSize:             cfg.ApproversMapSize,
+		DiscardersMapSize:            cfg.DiscardersMapSize,
+		DisabledEventTypes:           cfg.DisabledEventTypes,
+		EventStreamUseRingBuffer:     cfg.EventStreamUseRingBuffer,
+		EventStreamBufferSizes:       cfg.EventStreamBufferSizes,
+		KRetProbeMaxActive:           cfg.KRetProbeMaxActive,
+		FEntryProbes:                 cfg.FEntryProbes,
+		RuntimeCompilationEnabled:    cfg.RuntimeCompilationEnabled,
+		RuntimeCompilationHeaderDirs: cfg.RuntimeCompilationHeaderDirs,
+		MapSizingExpectedContainers:  cfg.MapSizingExpectedContainers,
+		MapSizingProcessCacheSize:    cfg.MapSizingProcessCacheSize,
+		MapSizingDentryCacheSize:     cfg.MapSizingDentryCacheSize,
+		MapPinningEnabled:            cfg.MapPinningEnabled,
+		SyscallMonitor:               cfg.SyscallMonitor,
+	}
+}
+
+// formatKernelVersion returns the given kernel version code as major.minor.patch
+func formatKernelVersion(kernelVersion uint32) string {
+	return fmt.Sprintf("%d.%d.%d", kernelVersion>>16, (kernelVersion>>8)&0xff, kernelVersion&0xff)
+}
+
+// verifierLog returns the message of the innermost cause of the given load error. The verifier log of a rejected
+// program is appended to it by the eBPF library
+func verifierLog(err error) string {
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(err) {
+		err = cause
+	}
+	return err.Error()
+}
+
+// identifyAsset returns the size and the hash of the given eBPF object
+func identifyAsset(name string, reader io.ReaderAt) (LoadedAsset, error) {
+	asset := LoadedAsset{Name: name}
+
+	h := sha256.New()
+	size, err := io.Copy(h, io.NewSectionReader(reader, 0, math.MaxInt64))
+	if err != nil {
+		return asset, errors.Wrapf(err, "failed to read %s", name)
+	}
+
+	asset.Size = size
+	asset.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return asset, nil
+}
+
+// newLoadDiagnostics returns the diagnostics of the failure of the given family to load from the given object
+func (p *Probe) newLoadDiagnostics(family string, reader io.ReaderAt, loadErr error) LoadDiagnostics {
+	diagnostics := LoadDiagnostics{
+		Timestamp:     time.Now().UTC(),
+		Family:        family,
+		Error:         loadErr.Error(),
+		KernelVersion: formatKernelVersion(p.kernelVersion),
+		Arch:          runtime.GOARCH,
+		Capabilities:  p.capabilities,
+		Lockdown:      p.lockdown,
+		KernelOffsets: p.kernelOffsets,
+		Config:        newLoadConfig(p.config),
+	}
+
+	if release, err := kernelRelease(); err == nil {
+		diagnostics.KernelRelease = release
+	}
+
+	asset, err := identifyAsset(p.assetName, reader)
+	if err != nil {
+		log.Debugf("failed to identify the eBPF object: %s", err)
+	}
+	diagnostics.Asset = asset
+
+	return diagnostics
+}
+
+// writeLoadDiagnostics writes a bundle describing the failure of the given family to load to the diagnostics
+// directory, and returns its path. The oldest bundles are removed past diagnosticsMaxBundles
+func writeLoadDiagnostics(dir string, diagnostics LoadDiagnostics, verifierLog string) (string, error) {
+	name := diagnosticsBundlePrefix + diagnostics.Timestamp.Format("20060102T150405.000000000Z") + "-" + diagnostics.Family
+	bundle := filepath.Join(dir, name)
+	if err := os.MkdirAll(bundle, 0700); err != nil {
+		return "", errors.Wrapf(err, "failed to create %s", bundle)
+	}
+
+	data, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the load diagnostics")
+	}
+	if err := ioutil.WriteFile(filepath.Join(bundle, diagnosticsFile), data, 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to write the load diagnostics to %s", bundle)
+	}
+	if err := ioutil.WriteFile(filepath.Join(bundle, diagnosticsVerifierLogFile), []byte(verifierLog+"\n"), 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to write the verifier log to %s", bundle)
+	}
+
+	return bundle, pruneLoadDiagnostics(dir, diagnosticsMaxBundles)
+}
+
+// pruneLoadDiagnostics removes the oldest bundles of the given directory, keeping the given number of them
+func pruneLoadDiagnostics(dir string, keep int) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list %s", dir)
+	}
+
+	// the names of the bundles start with their timestamp, they sort by age
+	var bundles []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), diagnosticsBundlePrefix) {
+			bundles = append(bundles, entry.Name())
+		}
+	}
+	sort.Strings(bundles)
+
+	for len(bundles) > keep {
+		if err := os.RemoveAll(filepath.Join(dir, bundles[0])); err != nil {
+			return errors.Wrapf(err, "failed to remove %s", bundles[0])
+		}
+		bundles = bundles[1:]
+	}
+	return nil
+}
+
+// reportLoadFailure writes the diagnostics of the failure of the given family to load from the given object, when
+// enabled
+func (p *Probe) reportLoadFailure(family string, reader io.ReaderAt, loadErr error) {
+	if !p.config.DiagnosticsEnabled {
+		return
+	}
+
+	bundle, err := writeLoadDiagnostics(p.config.DiagnosticsDir, p.newLoadDiagnostics(family, reader, loadErr), verifierLog(loadErr))
+	if err != nil {
+		log.Warnf("failed to write the diagnostics of the %s probes: %s", family, err)
+		return
+	}
+	log.Infof("diagnostics of the failure of the %s probes written to %s", family, bundle)
+}
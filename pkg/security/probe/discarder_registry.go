@@ -0,0 +1,354 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"sync"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// reloadedDiscarderMaps lists the kernel discarder maps whose entries are checked again on reload
+var reloadedDiscarderMaps = []string{"inode_discarders", "pid_discarders", "mount_fstype_discarders"}
+
+type discarderKind int
+
+const (
+	// fieldDiscarderKind is a discarder of a value of a field that no rule of the event type can match
+	fieldDiscarderKind discarderKind = iota
+	// parentDiscarderKind is a discarder of the parent directory of a filename
+	parentDiscarderKind
+	// allEventTypesDiscarderKind is a discarder of a value of a field that no rule, whatever its event type, can match
+	allEventTypesDiscarderKind
+	// loadDiscarderKind is a discarder of the processes flooding the event pipeline, it doesn't depend on the rules
+	loadDiscarderKind
+)
+
+// discarderReason describes why a discarder was pushed in kernel, it is checked against the new ruleset on reload
+type discarderReason struct {
+	kind      discarderKind
+	eventType eval.EventType
+	field     eval.Field
+	value     string
+}
+
+func newFieldDiscarderReason(eventType EventType, field eval.Field, value string) discarderReason {
+	return discarderReason{kind: fieldDiscarderKind, eventType: eventType.String(), field: field, value: value}
+}
+
+func newParentDiscarderReason(eventType EventType, field eval.Field, filename string) discarderReason {
+	return discarderReason{kind: parentDiscarderKind, eventType: eventType.String(), field: field, value: filename}
+}
+
+func newAllEventTypesDiscarderReason(field eval.Field, value string) discarderReason {
+	return discarderReason{kind: allEventTypesDiscarderKind, field: field, value: value}
+}
+
+var loadDiscarderReason = discarderReason{kind: loadDiscarderKind}
+
+// isValid returns whether the discarder is still valid with the given ruleset
+func (r discarderReason) isValid(rs *rules.RuleSet) bool {
+	switch r.kind {
+	case loadDiscarderKind:
+		return true
+	case parentDiscarderKind:
+		isDiscarder, _ := isParentPathDiscarder(rs, parseEvalEventType(r.eventType), r.field, r.value)
+		return isDiscarder
+	}
+
+	event := NewEvent(nil)
+	if err := event.SetFieldValue(r.field, r.value); err != nil {
+		return false
+	}
+
+	if r.kind == allEventTypesDiscarderKind {
+		isDiscarder, _ := rs.IsDiscarderOfAllEventTypes(event, r.field)
+		return isDiscarder
+	}
+
+	// no rule can match an event type without rules
+	if rs.GetBucket(r.eventType) == nil {
+		return true
+	}
+
+	isDiscarder, _ := rs.IsDiscarderOfEventType(event, r.field, r.eventType)
+	return isDiscarder
+}
+
+// discarderRegistry holds the reasons of the discarders pushed in kernel, indexed by the binary encoding of their key
+// as returned when the kernel map is iterated. The kernel entry of a key may have been pushed for several reasons,
+// all of them have to remain valid. The registry of a map is bounded by its size, a discarder evicted from the
+// registry is removed from the kernel on reload
+type discarderRegistry struct {
+	sync.Mutex
+	tables map[string]*simplelru.LRU
+}
+
+func newDiscarderRegistry() *discarderRegistry {
+	return &discarderRegistry{
+		tables: make(map[string]*simplelru.LRU),
+	}
+}
+
+// encodeFilterKey returns the binary encoding of a key, as written to the kernel maps
+func encodeFilterKey(key interface{}) (string, error) {
+	if marshaler, ok := key.(encoding.BinaryMarshaler); ok {
+		data, err := marshaler.MarshalBinary()
+		return string(data), err
+	}
+
+	var buffer bytes.Buffer
+	if err := binary.Write(&buffer, ebpf.ByteOrder, key); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// register records the reason of a discarder pushed in the given map
+func (r *discarderRegistry) register(tableName string, size int, key interface{}, reason discarderReason) error {
+	encoded, err := encodeFilterKey(key)
+	if err != nil {
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	table, exists := r.tables[tableName]
+	if !exists {
+		if table, err = simplelru.NewLRU(size, nil); err != nil {
+			return err
+		}
+		r.tables[tableName] = table
+	}
+
+	reasons, exists := table.Get(encoded)
+	if !exists {
+		reasons = make(map[discarderReason]bool)
+		table.Add(encoded, reasons)
+	}
+	reasons.(map[discarderReason]bool)[reason] = true
+
+	return nil
+}
+
+// isValid returns whether all the reasons of the given discarder are still valid with the given ruleset
+func (r *discarderRegistry) isValid(tableName string, key []byte, rs *rules.RuleSet) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	table, exists := r.tables[tableName]
+	if !exists {
+		return false
+	}
+
+	reasons, exists := table.Peek(string(key))
+	if !exists {
+		return false
+	}
+
+	for reason := range reasons.(map[discarderReason]bool) {
+		if !reason.isValid(rs) {
+			return false
+		}
+	}
+	return true
+}
+
+// reasons returns the reasons of the given discarder
+func (r *discarderRegistry) reasons(tableName string, key []byte) []discarderReason {
+	r.Lock()
+	defer r.Unlock()
+
+	table, exists := r.tables[tableName]
+	if !exists {
+		return nil
+	}
+
+	reasons, exists := table.Peek(string(key))
+	if !exists {
+		return nil
+	}
+
+	var result []discarderReason
+	for reason := range reasons.(map[discarderReason]bool) {
+		result = append(result, reason)
+	}
+	return result
+}
+
+func (r *discarderRegistry) remove(tableName string, key []byte) {
+	r.Lock()
+	defer r.Unlock()
+
+	if table, exists := r.tables[tableName]; exists {
+		table.Remove(string(key))
+	}
+}
+
+// purge removes all the reasons of the given map
+func (r *discarderRegistry) purge(tableName string) {
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.tables, tableName)
+}
+
+// savedDiscarderReason is the reason of a discarder saved along with the pinned maps
+type savedDiscarderReason struct {
+	Kind      discarderKind  `json:"kind"`
+	EventType eval.EventType `json:"event_type,omitempty"`
+	Field     eval.Field     `json:"field,omitempty"`
+	Value     string         `json:"value,omitempty"`
+}
+
+func (r savedDiscarderReason) discarderReason() discarderReason {
+	return discarderReason{kind: r.Kind, eventType: r.EventType, field: r.Field, value: r.Value}
+}
+
+// savedDiscarder holds the reasons of a discarder of a kernel map, saved along with the pinned maps
+type savedDiscarder struct {
+	Table   string                 `json:"table"`
+	Key     []byte                 `json:"key"`
+	Reasons []savedDiscarderReason `json:"reasons"`
+}
+
+// save returns the reasons of the discarders of all the maps, the least recently registered first
+func (r *discarderRegistry) save() []savedDiscarder {
+	r.Lock()
+	defer r.Unlock()
+
+	var discarders []savedDiscarder
+	for _, tableName := range reloadedDiscarderMaps {
+		table, exists := r.tables[tableName]
+		if !exists {
+			continue
+		}
+
+		for _, key := range table.Keys() {
+			reasons, exists := table.Peek(key)
+			if !exists {
+				continue
+			}
+
+			discarder := savedDiscarder{Table: tableName, Key: []byte(key.(string))}
+			for reason := range reasons.(map[discarderReason]bool) {
+				discarder.Reasons = append(discarder.Reasons, savedDiscarderReason{
+					Kind:      reason.kind,
+					EventType: reason.eventType,
+					Field:     reason.field,
+					Value:     reason.value,
+				})
+			}
+			discarders = append(discarders, discarder)
+		}
+	}
+	return discarders
+}
+
+// putDiscarder inserts a discarder in the given filter map, its reason is recorded so that it can be checked again
+// when the rules are reloaded
+func (p *Probe) putDiscarder(tableName string, key, value interface{}, reason discarderReason) error {
+	table := p.Map(tableName)
+	if table == nil {
+		return errors.Errorf("map %s not found", tableName)
+	}
+
+	if err := p.putFilter(tableName, key, value); err != nil {
+		return err
+	}
+
+	return p.discarderRegistry.register(tableName, int(table.ABI().MaxEntries), key, reason)
+}
+
+// mapKeys returns the keys of the given map. The keys are collected before any deletion, the iteration would start
+// over from a deleted key
+func mapKeys(table *lib.Map) ([][]byte, error) {
+	var keys [][]byte
+	var key interface{}
+	for {
+		next, err := table.NextKeyBytes(key)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return keys, nil
+		}
+		key = next
+		keys = append(keys, next)
+	}
+}
+
+// RevalidateDiscarders removes the in-kernel discarders invalidated by the given ruleset, as well as the ones whose
+// reason is unknown. The other discarders are kept, so that a reload doesn't flood the event pipeline with the events
+// they discard
+func (p *Probe) RevalidateDiscarders(rs *rules.RuleSet) error {
+	var kept, removed int
+	var fsTypeRemoved bool
+
+	for _, tableName := range reloadedDiscarderMaps {
+		table := p.Map(tableName)
+		if table == nil {
+			return errors.Errorf("map %s not found", tableName)
+		}
+
+		keys, err := mapKeys(table)
+		if err != nil {
+			return errors.Wrapf(err, "failed to iterate map %s", tableName)
+		}
+
+		for _, key := range keys {
+			if p.discarderRegistry.isValid(tableName, key, rs) {
+				kept++
+				continue
+			}
+
+			if err := table.Delete(key); err != nil && !errors.Is(err, lib.ErrKeyNotExist) {
+				return errors.Wrapf(err, "failed to remove a discarder from map %s", tableName)
+			}
+			p.discarderRegistry.remove(tableName, key)
+			removed++
+
+			if tableName == "mount_fstype_discarders" {
+				fsTypeRemoved = true
+			}
+		}
+	}
+
+	// the umount events of the mount points discarded by a removed filesystem type are sent again
+	if fsTypeRemoved {
+		if err := flushMap(p, "mount_id_discarders"); err != nil {
+			return err
+		}
+	}
+
+	p.mountSourceDiscardersLock.Lock()
+	for source := range p.mountSourceDiscarders {
+		if reason := newFieldDiscarderReason(FileMountEventType, "mount.source", source); reason.isValid(rs) {
+			kept++
+		} else {
+			delete(p.mountSourceDiscarders, source)
+			removed++
+		}
+	}
+	p.mountSourceDiscardersLock.Unlock()
+
+	log.Infof("%d discarders kept and %d removed after the reload of the rules", kept, removed)
+
+	return nil
+}
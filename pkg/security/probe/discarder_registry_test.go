@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+func TestDiscarderReasons(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd"`, `mkdir.filename =~ "/var/run/*" && process.filename == "/usr/bin/mkdir"`)
+
+	reloaded := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, reloaded, `open.filename =~ "/var/log/*"`, `mkdir.filename =~ "/var/run/*" && process.filename == "/usr/bin/mkdir"`)
+
+	tests := []struct {
+		reason  discarderReason
+		valid   bool
+		reloads bool
+	}{
+		{newFieldDiscarderReason(FileOpenEventType, "open.filename", "/var/log/syslog"), true, false},
+		{newFieldDiscarderReason(FileOpenEventType, "open.filename", "/var/tmp/test"), true, true},
+		{newParentDiscarderReason(FileOpenEventType, "open.filename", "/var/log/syslog"), true, false},
+		{newFieldDiscarderReason(FileMkdirEventType, "process.filename", "/usr/bin/vim"), true, true},
+		{newAllEventTypesDiscarderReason("process.filename", "/usr/bin/mkdir"), false, false},
+		{newFieldDiscarderReason(FileUnlinkEventType, "unlink.filename", "/etc/passwd"), true, true},
+		{loadDiscarderReason, true, true},
+	}
+
+	for _, test := range tests {
+		if valid := test.reason.isValid(rs); valid != test.valid {
+			t.Errorf("expected the validity %v for %+v, got %v", test.valid, test.reason, valid)
+		}
+		if valid := test.reason.isValid(reloaded); valid != test.reloads {
+			t.Errorf("expected the validity %v after the reload for %+v, got %v", test.reloads, test.reason, valid)
+		}
+	}
+}
+
+func TestDiscarderRegistry(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd"`)
+
+	registry := newDiscarderRegistry()
+
+	key := inodeDiscarder{
+		eventType: FileOpenEventType,
+		pathKey: PathKey{
+			MountID: 27,
+			Inode:   1234,
+		},
+	}
+
+	// the kernel returns the keys as they were encoded by the library
+	encoded := make([]byte, 24)
+	ebpf.ByteOrder.PutUint64(encoded[0:8], uint64(FileOpenEventType))
+	ebpf.ByteOrder.PutUint64(encoded[8:16], 1234)
+	ebpf.ByteOrder.PutUint32(encoded[16:20], 27)
+
+	if registry.isValid("inode_discarders", encoded, rs) {
+		t.Fatal("an unknown discarder shouldn't be valid")
+	}
+
+	if err := registry.register("inode_discarders", 16, &key, newFieldDiscarderReason(FileOpenEventType, "open.filename", "/etc/shadow")); err != nil {
+		t.Fatal(err)
+	}
+	if !registry.isValid("inode_discarders", encoded, rs) {
+		t.Fatal("the discarder should be valid")
+	}
+
+	// all the reasons of a key have to remain valid
+	if err := registry.register("inode_discarders", 16, &key, newFieldDiscarderReason(FileOpenEventType, "open.filename", "/etc/passwd")); err != nil {
+		t.Fatal(err)
+	}
+	if registry.isValid("inode_discarders", encoded, rs) {
+		t.Fatal("the discarder shouldn't be valid")
+	}
+
+	registry.remove("inode_discarders", encoded)
+	if registry.isValid("inode_discarders", encoded, rs) {
+		t.Fatal("a removed discarder shouldn't be valid")
+	}
+
+	fsType := ebpf.NewStringMapItem("tmpfs", fsTypeLen)
+	if err := registry.register("mount_fstype_discarders", 16, fsType, newFieldDiscarderReason(FileMountEventType, "mount.fs_type", "tmpfs")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := fsType.MarshalBinary()
+	if !registry.isValid("mount_fstype_discarders", data, rs) {
+		t.Fatal("the filesystem type discarder should be valid")
+	}
+}
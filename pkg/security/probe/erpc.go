@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// RPCCmd is the ioctl command used to issue eRPC requests to the kernel
+	RPCCmd uint64 = 0xdeadc001
+
+	// ERPCRequestDataSize is the size of the payload of an eRPC request
+	ERPCRequestDataSize = 256
+)
+
+// ERPC operations
+const (
+	// UnknownOp is the default operation, ignored by the kernel
+	UnknownOp uint8 = iota
+	// ResolvePathOp asks the kernel to walk the dentry of a path and to write its segments to the dr_erpc_buffers map
+	ResolvePathOp
+	// GuessMntNamespaceOffsetOp asks the kernel to look for the given inode number in the mount namespace of the caller,
+	// and to write its offset to the mntns_inum_offset map
+	GuessMntNamespaceOffsetOp
+)
+
+// ERPCRequest defines an eRPC request
+type ERPCRequest struct {
+	OP   uint8
+	Data [ERPCRequestDataSize]byte
+}
+
+// ERPC defines a request channel from userspace to the eBPF programs. Requests are sent through an ioctl syscall
+// on a dedicated file descriptor and are caught by a kprobe before the ioctl is actually handled.
+type ERPC struct {
+	fd int
+}
+
+// Request sends an eRPC request to the kernel
+func (k *ERPC) Request(req *ERPCRequest) error {
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(k.fd), uintptr(RPCCmd), uintptr(unsafe.Pointer(req)))
+
+	// the ioctl command is unknown to the file descriptor, an error is expected
+	if errno != 0 && errno != unix.ENOTTY && errno != unix.EINVAL {
+		return errno
+	}
+
+	return nil
+}
+
+// Close closes the eRPC file descriptor
+func (k *ERPC) Close() error {
+	return unix.Close(k.fd)
+}
+
+// NewERPC returns a new eRPC client
+func NewERPC() (*ERPC, error) {
+	fd, err := unix.Socket(unix.AF_UNIX, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ERPC{
+		fd: fd,
+	}, nil
+}
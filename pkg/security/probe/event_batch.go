@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"sync"
+	"time"
+)
+
+// batchProcesses holds the process cache entries looked up while handling a batch of events, the events of a process
+// sharing the lookup. A nil batchProcesses looks every entry up
+type batchProcesses map[uint32]*ProcessCacheEntry
+
+// get returns the process cache entry of the given pid
+func (b batchProcesses) get(resolver *ProcessResolver, pid uint32) *ProcessCacheEntry {
+	if b == nil {
+		return resolver.Get(pid)
+	}
+
+	entry, found := b[pid]
+	if !found {
+		entry = resolver.Get(pid)
+		b[pid] = entry
+	}
+	return entry
+}
+
+// invalidate drops the entry of the given pid, updated by an exec or exit event of the batch
+func (b batchProcesses) invalidate(pid uint32) {
+	delete(b, pid)
+}
+
+// eventBatcher groups the records read from the perf maps into batches, handled once full or at the flush interval
+type eventBatcher struct {
+	sync.Mutex
+	size          int
+	flushInterval time.Duration
+	records       []eventRecord
+	handler       func(records []eventRecord)
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newEventBatcher(size int, flushInterval time.Duration, handler func(records []eventRecord)) *eventBatcher {
+	return &eventBatcher{
+		size:          size,
+		flushInterval: flushInterval,
+		records:       make([]eventRecord, 0, size),
+		handler:       handler,
+	}
+}
+
+// Start starts flushing the incomplete batches at the flush interval
+func (b *eventBatcher) Start() {
+	b.stop = make(chan struct{})
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		ticker := time.NewTicker(b.flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				b.Flush()
+			}
+		}
+	}()
+}
+
+// Stop stops flushing the batches at the flush interval, and flushes the pending records
+func (b *eventBatcher) Stop() {
+	if b.stop != nil {
+		close(b.stop)
+		b.wg.Wait()
+	}
+	b.Flush()
+}
+
+// Add adds a record to the current batch, handled once full
+func (b *eventBatcher) Add(record eventRecord) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.records = append(b.records, record)
+	if len(b.records) >= b.size {
+		b.flush()
+	}
+}
+
+// Flush handles the pending records
+func (b *eventBatcher) Flush() {
+	b.Lock()
+	defer b.Unlock()
+
+	b.flush()
+}
+
+// flush hands the pending records to the handler. The batches are handled with the lock held so that they are
+// handled in the order they were read, the handler owns the records
+func (b *eventBatcher) flush() {
+	if len(b.records) == 0 {
+		return
+	}
+
+	records := b.records
+	b.records = make([]eventRecord, 0, b.size)
+	b.handler(records)
+}
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventBatcher(t *testing.T) {
+	var batches [][]eventRecord
+	b := newEventBatcher(3, time.Hour, func(records []eventRecord) {
+		batches = append(batches, records)
+	})
+
+	for i := 0; i < 4; i++ {
+		b.Add(eventRecord{data: []byte{byte(i)}})
+	}
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Fatalf("expected a full batch of 3 records, got %v", batches)
+	}
+
+	// the pending records are flushed on stop
+	b.Stop()
+	if len(batches) != 2 || len(batches[1]) != 1 || batches[1][0].data[0] != 3 {
+		t.Fatalf("expected the pending record to be flushed, got %v", batches)
+	}
+
+	b.Flush()
+	if len(batches) != 2 {
+		t.Errorf("an empty batch shouldn't be handled")
+	}
+}
+
+func TestBatchProcesses(t *testing.T) {
+	entry := &ProcessCacheEntry{}
+	processes := batchProcesses{42: entry}
+
+	if processes.get(nil, 42) != entry {
+		t.Error("the entry looked up by the batch should be shared")
+	}
+
+	processes.invalidate(42)
+	if _, found := processes[42]; found {
+		t.Error("the entry of an exec or exit should be looked up again")
+	}
+
+	// the events handled once read have no batch
+	var none batchProcesses
+	none.invalidate(42)
+}
@@ -0,0 +1,258 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// EventHandlerStats describes a handler registered with AddEventHandler and its queue
+type EventHandlerStats struct {
+	EventTypes []string `json:"event_types,omitempty"`
+	QueueSize  int      `json:"queue_size"`
+	Queued     int      `json:"queued"`
+	Handled    int64    `json:"handled"`
+	Dropped    int64    `json:"dropped"`
+}
+
+// subscribedEventHandler is a handler of the events of some types, fed by its own queue of copies of the events and
+// running in its own goroutine
+type subscribedEventHandler struct {
+	// the counters are first, aligned for the atomic operations on goarch=386
+	handled int64
+	dropped int64
+	// droppedSent is the number of dropped events already sent as a metric
+	droppedSent int64
+
+	name    string
+	handler EventHandler
+	// eventTypes holds the event types the handler subscribed to, nil for all of them
+	eventTypes map[EventType]bool
+	queue      chan *Event
+	done       chan struct{}
+}
+
+func newSubscribedEventHandler(name string, handler EventHandler, queueSize int, eventTypes []EventType) *subscribedEventHandler {
+	h := &subscribedEventHandler{
+		name:    name,
+		handler: handler,
+		queue:   make(chan *Event, queueSize),
+		done:    make(chan struct{}),
+	}
+	if len(eventTypes) > 0 {
+		h.eventTypes = make(map[EventType]bool)
+		for _, eventType := range eventTypes {
+			h.eventTypes[eventType] = true
+		}
+	}
+	return h
+}
+
+func (h *subscribedEventHandler) run() {
+	defer close(h.done)
+
+	for event := range h.queue {
+		h.handler.HandleEvent(event)
+		atomic.AddInt64(&h.handled, 1)
+	}
+}
+
+// accepts returns whether the given event would be queued, the handler subscribed to its type and its queue isn't
+// full. The event is counted as dropped otherwise
+func (h *subscribedEventHandler) accepts(event *Event) bool {
+	if h.eventTypes != nil && !h.eventTypes[EventType(event.Type)] {
+		return false
+	}
+
+	// the event isn't copied when the queue is already full
+	if len(h.queue) == cap(h.queue) {
+		atomic.AddInt64(&h.dropped, 1)
+		return false
+	}
+	return true
+}
+
+// push queues a copy of the given event, its fields being already resolved. The event is dropped when the queue is
+// full, a slow handler doesn't block the probe nor the other handlers
+func (h *subscribedEventHandler) push(event *Event) {
+	select {
+	case h.queue <- event.Copy():
+	default:
+		atomic.AddInt64(&h.dropped, 1)
+	}
+}
+
+// stop stops the handler once it handled the queued events
+func (h *subscribedEventHandler) stop() {
+	close(h.queue)
+	<-h.done
+}
+
+func (h *subscribedEventHandler) stats() EventHandlerStats {
+	stats := EventHandlerStats{
+		QueueSize: cap(h.queue),
+		Queued:    len(h.queue),
+		Handled:   atomic.LoadInt64(&h.handled),
+		Dropped:   atomic.LoadInt64(&h.dropped),
+	}
+	for eventType := range h.eventTypes {
+		stats.EventTypes = append(stats.EventTypes, eventType.String())
+	}
+	sort.Strings(stats.EventTypes)
+	return stats
+}
+
+// AddEventHandler registers a handler of the events of the given types, all of them when none is given, along with
+// the handler set with SetEventHandler. The handler runs in its own goroutine, fed by a queue of the given size of
+// copies of the events: the events it can't keep up with are dropped rather than blocking the probe or the other
+// handlers
+func (p *Probe) AddEventHandler(name string, handler EventHandler, queueSize int, eventTypes ...eval.EventType) error {
+	if queueSize <= 0 {
+		return errors.New("the queue size of an event handler should be positive")
+	}
+
+	var types []EventType
+	for _, eventType := range eventTypes {
+		parsed := parseEvalEventType(eventType)
+		if parsed == UnknownEventType {
+			return errors.Errorf("unknown event type `%s`", eventType)
+		}
+		types = append(types, parsed)
+	}
+
+	p.eventHandlersLock.Lock()
+	for _, h := range p.eventHandlers {
+		if h.name == name {
+			p.eventHandlersLock.Unlock()
+			return errors.Errorf("an event handler named `%s` is already registered", name)
+		}
+	}
+
+	h := newSubscribedEventHandler(name, handler, queueSize, types)
+	go h.run()
+	p.eventHandlers = append(p.eventHandlers, h)
+
+	// the event types of the handler may be masked for some containers
+	p.resetContainerEventTypes()
+	p.eventHandlersLock.Unlock()
+
+	// the events of the handler may be dropped by the pre-evaluation
+	p.refreshPreEvaluation()
+
+	return nil
+}
+
+// RemoveEventHandler unregisters the handler of the given name added with AddEventHandler, once it handled its queued
+// events
+func (p *Probe) RemoveEventHandler(name string) error {
+	p.eventHandlersLock.Lock()
+	var removed *subscribedEventHandler
+	for i, h := range p.eventHandlers {
+		if h.name == name {
+			removed = h
+			p.eventHandlers = append(p.eventHandlers[:i:i], p.eventHandlers[i+1:]...)
+			break
+		}
+	}
+	p.eventHandlersLock.Unlock()
+
+	if removed == nil {
+		return errors.Errorf("no event handler named `%s`", name)
+	}
+	p.resetContainerEventTypes()
+	p.refreshPreEvaluation()
+
+	// no event is queued to the handler once removed, the dispatch is left running while it drains its queue
+	removed.stop()
+	return nil
+}
+
+// handledEventTypes returns the event types the handlers added with AddEventHandler subscribed to, and whether one of
+// them handles all the event types
+func (p *Probe) handledEventTypes() ([]EventType, bool) {
+	p.eventHandlersLock.RLock()
+	defer p.eventHandlersLock.RUnlock()
+
+	var eventTypes []EventType
+	for _, h := range p.eventHandlers {
+		if h.eventTypes == nil {
+			return nil, true
+		}
+		for eventType := range h.eventTypes {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes, false
+}
+
+// dispatchToEventHandlers queues the given event to the handlers added with AddEventHandler
+func (p *Probe) dispatchToEventHandlers(event *Event) {
+	p.eventHandlersLock.RLock()
+	resolved := false
+	for _, h := range p.eventHandlers {
+		if !h.accepts(event) {
+			continue
+		}
+
+		// the copies are handled once the event was handled, when the process may have exited and the paths may have
+		// changed. The fields are resolved once, for all the copies
+		if !resolved {
+			event.ResolveFields()
+			resolved = true
+		}
+		h.push(event)
+	}
+	p.eventHandlersLock.RUnlock()
+}
+
+// stopEventHandlers stops the handlers added with AddEventHandler
+func (p *Probe) stopEventHandlers() {
+	p.eventHandlersLock.Lock()
+	handlers := p.eventHandlers
+	p.eventHandlers = nil
+	p.eventHandlersLock.Unlock()
+
+	for _, h := range handlers {
+		h.stop()
+	}
+}
+
+// GetEventHandlerStats returns the stats of the handlers added with AddEventHandler, per name
+func (p *Probe) GetEventHandlerStats() map[string]EventHandlerStats {
+	p.eventHandlersLock.RLock()
+	defer p.eventHandlersLock.RUnlock()
+
+	stats := make(map[string]EventHandlerStats)
+	for _, h := range p.eventHandlers {
+		stats[h.name] = h.stats()
+	}
+	return stats
+}
+
+// sendEventHandlerStats sends the events dropped by the handlers added with AddEventHandler since the last call
+func (p *Probe) sendEventHandlerStats(statsdClient *statsd.Client) error {
+	p.eventHandlersLock.RLock()
+	defer p.eventHandlersLock.RUnlock()
+
+	for _, h := range p.eventHandlers {
+		dropped := atomic.LoadInt64(&h.dropped)
+		if delta := dropped - h.droppedSent; delta > 0 {
+			if err := statsdClient.Count(MetricPrefix+".event_handler.dropped", delta, []string{"handler:" + h.name}, 1.0); err != nil {
+				return err
+			}
+		}
+		h.droppedSent = dropped
+	}
+	return nil
+}
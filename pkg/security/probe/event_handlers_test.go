@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"sync"
+	"testing"
+)
+
+type testEventHandler struct {
+	sync.Mutex
+	events  []EventType
+	blocked chan struct{}
+}
+
+func (h *testEventHandler) HandleEvent(event *Event) {
+	if h.blocked != nil {
+		<-h.blocked
+	}
+
+	h.Lock()
+	h.events = append(h.events, EventType(event.Type))
+	h.Unlock()
+}
+
+func TestEventHandlers(t *testing.T) {
+	p := &Probe{}
+
+	all := &testEventHandler{}
+	if err := p.AddEventHandler("all", all, 16); err != nil {
+		t.Fatal(err)
+	}
+
+	exec := &testEventHandler{}
+	if err := p.AddEventHandler("exec", exec, 16, "exec"); err != nil {
+		t.Fatal(err)
+	}
+
+	slow := &testEventHandler{blocked: make(chan struct{})}
+	if err := p.AddEventHandler("slow", slow, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.AddEventHandler("all", all, 16); err == nil {
+		t.Error("the names of the handlers should be unique")
+	}
+	if _, all := p.handledEventTypes(); !all {
+		t.Error("a handler without event types should handle all of them")
+	}
+	if err := p.AddEventHandler("unknown", all, 16, "unknown"); err == nil {
+		t.Error("an unknown event type should be rejected")
+	}
+
+	// the slow handler blocks on the first event, queues the second one and drops the third one
+	event := NewEvent(nil)
+	for _, eventType := range []EventType{FileOpenEventType, ExecEventType, FileOpenEventType} {
+		event.Type = uint64(eventType)
+		p.DispatchEvent(event)
+	}
+
+	stats := p.GetEventHandlerStats()
+	if stats["slow"].Dropped == 0 {
+		t.Errorf("expected the slow handler to drop events, got %+v", stats["slow"])
+	}
+	if len(stats["exec"].EventTypes) != 1 || stats["exec"].EventTypes[0] != "exec" {
+		t.Errorf("unexpected subscriptions: %+v", stats["exec"])
+	}
+
+	if err := p.RemoveEventHandler("exec"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.RemoveEventHandler("exec"); err == nil {
+		t.Error("a removed handler can't be removed again")
+	}
+
+	close(slow.blocked)
+	p.stopEventHandlers()
+
+	if len(all.events) != 3 {
+		t.Errorf("expected all the events to be handled, got %v", all.events)
+	}
+	if len(exec.events) != 1 || exec.events[0] != ExecEventType {
+		t.Errorf("expected only the exec event to be handled, got %v", exec.events)
+	}
+	if len(slow.events) == 0 || len(slow.events) == 3 {
+		t.Errorf("expected some events to be dropped by the slow handler, got %v", slow.events)
+	}
+}
+
+func TestHandledEventTypes(t *testing.T) {
+	p := &Probe{}
+
+	if err := p.AddEventHandler("exec", &testEventHandler{}, 16, "exec", "exit"); err != nil {
+		t.Fatal(err)
+	}
+	defer p.stopEventHandlers()
+
+	eventTypes, all := p.handledEventTypes()
+	if all || len(eventTypes) != 2 {
+		t.Errorf("expected the exec and exit event types, got %v", eventTypes)
+	}
+
+	if err := p.RemoveEventHandler("exec"); err != nil {
+		t.Fatal(err)
+	}
+	if eventTypes, all := p.handledEventTypes(); all || len(eventTypes) != 0 {
+		t.Errorf("expected no handled event types, got %v", eventTypes)
+	}
+}
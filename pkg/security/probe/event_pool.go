@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"sync"
+)
+
+var eventZero Event
+
+// eventPool recycles the events decoded from the kernel. An event is only valid until the handlers it is dispatched
+// to return, a handler retaining it has to Copy it
+type eventPool struct {
+	pool      sync.Pool
+	resolvers *Resolvers
+}
+
+func newEventPool(resolvers *Resolvers) *eventPool {
+	p := &eventPool{resolvers: resolvers}
+	p.pool.New = func() interface{} {
+		return NewEvent(resolvers)
+	}
+	return p
+}
+
+// Get returns a zeroed event
+func (p *eventPool) Get() *Event {
+	event := p.pool.Get().(*Event)
+	event.resolvers = p.resolvers
+	return event
+}
+
+// Put zeroes the given event, releasing what it references, and recycles it
+func (p *eventPool) Put(event *Event) {
+	*event = eventZero
+	p.pool.Put(event)
+}
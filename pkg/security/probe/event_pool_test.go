@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+)
+
+func TestEventPool(t *testing.T) {
+	resolvers := &Resolvers{}
+	pool := newEventPool(resolvers)
+
+	event := pool.Get()
+	if event.resolvers != resolvers {
+		t.Fatal("the events of the pool should resolve their fields")
+	}
+
+	event.Type = uint64(FileOpenEventType)
+	event.Open.Flags = 1
+	retained := event.Copy()
+	pool.Put(event)
+
+	if event.Type != 0 || event.Open.Flags != 0 || event.resolvers != nil {
+		t.Errorf("a recycled event should be zeroed: %+v", event)
+	}
+	if retained.Type != uint64(FileOpenEventType) || retained.Open.Flags != 1 || retained.resolvers != resolvers {
+		t.Errorf("the copy of an event shouldn't be recycled: %+v", retained)
+	}
+
+	if event = pool.Get(); event.Type != 0 || event.resolvers != resolvers {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
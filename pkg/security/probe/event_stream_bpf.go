@@ -0,0 +1,308 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ringBufferLostPeriod is the period at which the lost events of the ring buffers are reported
+const ringBufferLostPeriod = time.Second
+
+// ringBufferStreams lists the perf maps replaced by a ring buffer and the number of pages of their ring buffer, in
+// the order of the kernel `enum event_stream`
+var ringBufferStreams = []struct {
+	perfMap string
+	pages   int
+}{
+	{perfMap: "events", pages: 8192},
+	{perfMap: "mountpoints_events", pages: 256},
+}
+
+// EventStreamStats describes the buffers of an event stream, with the sizes allocated by the kernel
+type EventStreamStats struct {
+	// Transport is either `perf_buffer` or `ring_buffer`
+	Transport string `json:"transport"`
+	// BufferSize is the size in bytes of the buffers, per CPU for the perf buffers
+	BufferSize int `json:"buffer_size"`
+	// Watermark is the number of bytes written to the buffers before the reader is woken up
+	Watermark int `json:"watermark"`
+}
+
+// eventStream is the transport of the events sent by the kernel on one of the perf maps
+type eventStream interface {
+	// Name returns the name of the perf map
+	Name() string
+	// Start starts reading the events
+	Start() error
+	// Stop stops reading the events
+	Stop() error
+	// Stats returns the buffers of the stream
+	Stats() EventStreamStats
+}
+
+// roundBufferSize returns the given size rounded up to a power of 2 number of pages, as allocated by the kernel for
+// the perf and ring buffers
+func roundBufferSize(size int) int {
+	pageSize := os.Getpagesize()
+
+	pages := 1
+	for pages*pageSize < size {
+		pages <<= 1
+	}
+	return pages * pageSize
+}
+
+// eventStreamBuffers returns the size and watermark of the buffers of the given perf map, the default size being used
+// unless configured. A watermark that doesn't fit in the buffers is ignored
+func (p *Probe) eventStreamBuffers(name string, defaultSize int) (int, int) {
+	size := defaultSize
+	if configured := p.config.EventStreamBufferSizes[name]; configured > 0 {
+		size = configured
+	}
+	size = roundBufferSize(size)
+
+	watermark := p.config.EventStreamWatermarks[name]
+	if watermark >= size {
+		log.Warnf("the watermark of %s isn't smaller than its buffer size of %d bytes, each event wakes up the reader", name, size)
+		watermark = 0
+	}
+	return size, watermark
+}
+
+// perfMapStream reads the events through the per-CPU buffers of a perf map, started and stopped by the manager
+type perfMapStream struct {
+	perfMap *manager.PerfMap
+}
+
+func (s *perfMapStream) Name() string {
+	return s.perfMap.Name
+}
+
+func (s *perfMapStream) Start() error {
+	return nil
+}
+
+func (s *perfMapStream) Stop() error {
+	return nil
+}
+
+func (s *perfMapStream) Stats() EventStreamStats {
+	return EventStreamStats{
+		Transport:  "perf_buffer",
+		BufferSize: s.perfMap.PerfRingBufferSize,
+		Watermark:  s.perfMap.Watermark,
+	}
+}
+
+// ringBufferStream reads the events through a ring buffer shared by all the CPUs, in place of the perf map whose
+// handlers it calls. The ring buffer doesn't report its lost events, they are counted by the kernel
+type ringBufferStream struct {
+	probe     *Probe
+	perfMap   *manager.PerfMap
+	index     uint32
+	ringMap   *lib.Map
+	size      int
+	watermark int
+	reader    *RingBuffer
+	lost      uint64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func (s *ringBufferStream) Name() string {
+	return s.perfMap.Name
+}
+
+func (s *ringBufferStream) Stats() EventStreamStats {
+	return EventStreamStats{
+		Transport:  "ring_buffer",
+		BufferSize: s.size,
+		Watermark:  s.watermark,
+	}
+}
+
+func (s *ringBufferStream) Start() error {
+	reader, err := NewRingBuffer(s.ringMap, func(sample []byte) {
+		s.perfMap.DataHandler(-1, sample, s.perfMap, s.probe.manager)
+	})
+	if err != nil {
+		return err
+	}
+	s.reader = reader
+
+	lostMap, _, err := s.probe.manager.GetMap("ring_buffer_lost")
+	if err != nil || lostMap == nil {
+		return errors.Errorf("map ring_buffer_lost not found")
+	}
+
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(ringBufferLostPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.reportLost(lostMap)
+			}
+		}
+	}()
+
+	s.reader.Start()
+
+	return nil
+}
+
+// reportLost reports the events lost since the last report, the kernel counters are never reset
+func (s *ringBufferStream) reportLost(lostMap *lib.Map) {
+	var perCPU []uint64
+	if err := lostMap.Lookup(ebpf.Uint32MapItem(s.index), &perCPU); err != nil {
+		log.Warnf("failed to read the lost events of the ring buffer of %s: %s", s.perfMap.Name, err)
+		return
+	}
+
+	var lost uint64
+	for _, count := range perCPU {
+		lost += count
+	}
+
+	if lost > s.lost {
+		s.perfMap.LostHandler(-1, lost-s.lost, s.perfMap, s.probe.manager)
+		s.lost = lost
+	}
+}
+
+func (s *ringBufferStream) Stop() error {
+	if s.reader == nil {
+		return s.ringMap.Close()
+	}
+
+	close(s.stop)
+	s.wg.Wait()
+
+	if err := s.reader.Stop(); err != nil {
+		return err
+	}
+	return s.ringMap.Close()
+}
+
+// newRingBufferStreams creates the ring buffers replacing the perf maps, and the map editors swapping them for the
+// placeholders of the kernel programs
+func (p *Probe) newRingBufferStreams() ([]eventStream, map[string]*lib.Map, error) {
+	var streams []eventStream
+	editors := make(map[string]*lib.Map)
+
+	for index, stream := range ringBufferStreams {
+		perfMap, exists := p.manager.GetPerfMap(stream.perfMap)
+		if !exists {
+			return nil, nil, errors.Errorf("perf map %s not found", stream.perfMap)
+		}
+
+		name := stream.perfMap + "_ringbuf"
+		size, watermark := p.eventStreamBuffers(stream.perfMap, stream.pages*os.Getpagesize())
+		if watermark > 0 && !p.isFeatureSupported(featureRingBufferWatermarks) {
+			log.Warnf("the kernel doesn't support the watermarks of the ring buffers, each event wakes up the reader of %s", stream.perfMap)
+			watermark = 0
+		}
+		ringMap, err := newRingBufferMap(name, size)
+		if err != nil {
+			for _, ringMap := range editors {
+				_ = ringMap.Close()
+			}
+			return nil, nil, err
+		}
+		editors[name] = ringMap
+
+		streams = append(streams, &ringBufferStream{
+			probe:     p,
+			perfMap:   perfMap,
+			index:     uint32(index),
+			ringMap:   ringMap,
+			size:      size,
+			watermark: watermark,
+		})
+	}
+
+	return streams, editors, nil
+}
+
+// initEventStreams selects the transport of the events: the ring buffers on the kernels supporting them, unless
+// disabled, the perf maps otherwise. The buffers are sized as configured. It has to be called before the manager is
+// initialized
+func (p *Probe) initEventStreams() {
+	useRingBuffers := p.config.EventStreamUseRingBuffer && p.isFeatureSupported(featureRingBuffers)
+
+	if useRingBuffers {
+		streams, editors, err := p.newRingBufferStreams()
+		if err != nil {
+			log.Warnf("failed to create the ring buffers, the events are sent through the perf maps: %s", err)
+			useRingBuffers = false
+		} else {
+			p.eventStreams = streams
+
+			if p.managerOptions.MapEditors == nil {
+				p.managerOptions.MapEditors = make(map[string]*lib.Map)
+			}
+			for name, ringMap := range editors {
+				p.managerOptions.MapEditors[name] = ringMap
+			}
+
+			// the manager would read the perf maps otherwise
+			p.manager.PerfMaps = nil
+		}
+	}
+
+	if !useRingBuffers {
+		for _, perfMap := range p.manager.PerfMaps {
+			perfMap.PerfRingBufferSize, perfMap.Watermark = p.eventStreamBuffers(perfMap.Name, p.managerOptions.DefaultPerfRingBufferSize)
+			p.eventStreams = append(p.eventStreams, &perfMapStream{perfMap: perfMap})
+		}
+	}
+
+	// the watermarks of the ring buffers are applied by the kernel programs
+	watermarks := make(map[string]uint64)
+	if useRingBuffers {
+		for _, stream := range p.eventStreams {
+			watermarks[stream.Name()] = uint64(stream.Stats().Watermark)
+		}
+	}
+
+	for _, stream := range ringBufferStreams {
+		p.managerOptions.ConstantEditors = append(p.managerOptions.ConstantEditors, manager.ConstantEditor{
+			Name:  stream.perfMap + "_ringbuf_watermark",
+			Value: watermarks[stream.perfMap],
+		})
+	}
+
+	useRingBuffer := uint64(0)
+	if useRingBuffers {
+		useRingBuffer = 1
+		log.Infof("the events are sent through ring buffers")
+	}
+
+	p.managerOptions.ConstantEditors = append(p.managerOptions.ConstantEditors, manager.ConstantEditor{
+		Name:  "use_ring_buffer",
+		Value: useRingBuffer,
+	})
+}
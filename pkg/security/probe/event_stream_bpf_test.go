@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+)
+
+func TestRoundBufferSize(t *testing.T) {
+	pageSize := os.Getpagesize()
+
+	tests := []struct {
+		size     int
+		expected int
+	}{
+		{size: 0, expected: pageSize},
+		{size: 1, expected: pageSize},
+		{size: pageSize, expected: pageSize},
+		{size: pageSize + 1, expected: 2 * pageSize},
+		{size: 3 * pageSize, expected: 4 * pageSize},
+		{size: 256 * pageSize, expected: 256 * pageSize},
+	}
+
+	for _, test := range tests {
+		if size := roundBufferSize(test.size); size != test.expected {
+			t.Errorf("expected %d for %d, got %d", test.expected, test.size, size)
+		}
+	}
+}
+
+func TestEventStreamBuffers(t *testing.T) {
+	pageSize := os.Getpagesize()
+
+	p := &Probe{
+		config: &config.Config{
+			EventStreamBufferSizes: map[string]int{
+				"events":             3 * pageSize,
+				"mountpoints_events": 0,
+			},
+			EventStreamWatermarks: map[string]int{
+				"events":             pageSize,
+				"mountpoints_events": 16 * pageSize,
+			},
+		},
+	}
+
+	size, watermark := p.eventStreamBuffers("events", 8*pageSize)
+	if size != 4*pageSize || watermark != pageSize {
+		t.Errorf("expected a size of %d and a watermark of %d, got %d and %d", 4*pageSize, pageSize, size, watermark)
+	}
+
+	// the watermark of mountpoints_events doesn't fit in its default size
+	size, watermark = p.eventStreamBuffers("mountpoints_events", 8*pageSize)
+	if size != 8*pageSize || watermark != 0 {
+		t.Errorf("expected a size of %d without watermark, got %d and %d", 8*pageSize, size, watermark)
+	}
+
+	size, watermark = p.eventStreamBuffers("unknown", pageSize)
+	if size != pageSize || watermark != 0 {
+		t.Errorf("expected a size of %d without watermark, got %d and %d", pageSize, size, watermark)
+	}
+}
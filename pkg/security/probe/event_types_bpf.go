@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"sort"
+
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// eventTypeSwitch detaches the probes of the event types disabled at runtime, and attaches them again when the event
+// types are enabled. The manager can't attach again a detached probe, the probe is removed from the manager when
+// detached and a clone of its program is hooked when attached again
+type eventTypeSwitch struct {
+	disabled map[eval.EventType]bool
+	// detached holds a copy of the probes detached from the manager
+	detached map[manager.ProbeIdentificationPair]*manager.Probe
+	// hooked holds the probes attached again, their program is a clone closed once detached
+	hooked map[manager.ProbeIdentificationPair]bool
+}
+
+func newEventTypeSwitch() *eventTypeSwitch {
+	return &eventTypeSwitch{
+		disabled: make(map[eval.EventType]bool),
+		detached: make(map[manager.ProbeIdentificationPair]*manager.Probe),
+		hooked:   make(map[manager.ProbeIdentificationPair]bool),
+	}
+}
+
+// selectorsIDs returns the identification pairs of the probes of the given selectors
+func selectorsIDs(selectors []manager.ProbesSelector) []manager.ProbeIdentificationPair {
+	var ids []manager.ProbeIdentificationPair
+	for _, selector := range selectors {
+		ids = append(ids, selector.GetProbesIdentificationPairList()...)
+	}
+	return ids
+}
+
+// isNeeded returns whether a probe is required by the probes always activated or by an enabled event type
+func (s *eventTypeSwitch) isNeeded(id manager.ProbeIdentificationPair) bool {
+	for eventType, selectors := range probes.SelectorsPerEventType {
+		if s.disabled[eventType] {
+			continue
+		}
+
+		for _, selectorID := range selectorsIDs(selectors) {
+			if selectorID.Matches(id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// detach detaches the probes of the given event type that are not required anymore, with the manager of their family
+func (s *eventTypeSwitch) detach(managerOf func(id manager.ProbeIdentificationPair) *manager.Manager, eventType eval.EventType) error {
+	for _, id := range selectorsIDs(probes.SelectorsPerEventType[eventType]) {
+		if _, exists := s.detached[id]; exists || s.isNeeded(id) {
+			continue
+		}
+
+		// the probes of a stopped family are detached once it starts again
+		m := managerOf(id)
+		if m == nil {
+			continue
+		}
+
+		// the selectors list all the alternatives of a probe, only the running ones are detached
+		probe, exists := m.GetProbe(id)
+		if !exists || !probe.IsRunning() {
+			continue
+		}
+
+		if err := m.DetachHook(id.Section, id.UID); err != nil {
+			return errors.Wrapf(err, "failed to detach probe %s", id)
+		}
+		s.detached[id] = probe.Copy()
+
+		if s.hooked[id] {
+			_ = probe.Program().Close()
+			delete(s.hooked, id)
+		}
+	}
+	return nil
+}
+
+// attach attaches again the detached probes of the given event type, with the manager of their family
+func (s *eventTypeSwitch) attach(managerOf func(id manager.ProbeIdentificationPair) *manager.Manager, eventType eval.EventType) error {
+	for _, id := range selectorsIDs(probes.SelectorsPerEventType[eventType]) {
+		probe, exists := s.detached[id]
+		if !exists {
+			continue
+		}
+
+		// the probes of a stopped family are attached by its new manager once it starts again
+		m := managerOf(id)
+		if m == nil {
+			continue
+		}
+
+		// without UID, the program of the section loaded with the collection is cloned
+		if err := m.AddHook("", *probe); err != nil {
+			return errors.Wrapf(err, "failed to attach probe %s", id)
+		}
+		delete(s.detached, id)
+		s.hooked[id] = true
+	}
+	return nil
+}
+
+// forget drops the state of the given probes, their manager being stopped
+func (s *eventTypeSwitch) forget(matches func(id manager.ProbeIdentificationPair) bool) {
+	for id := range s.detached {
+		if matches(id) {
+			delete(s.detached, id)
+		}
+	}
+	for id := range s.hooked {
+		if matches(id) {
+			delete(s.hooked, id)
+		}
+	}
+}
+
+// SetEventTypeEnabled enables or disables an event type at runtime by attaching or detaching its probes, the probes
+// shared with an enabled event type are kept attached. A failed switch can be retried, the probes already switched are
+// skipped
+func (p *Probe) SetEventTypeEnabled(eventType eval.EventType, enabled bool) error {
+	if _, exists := probes.SelectorsPerEventType[eventType]; !exists || eventType == "*" {
+		return errors.Errorf("unknown event type `%s`", eventType)
+	}
+
+	if p.manager == nil {
+		return errors.New("the eBPF manager isn't initialized")
+	}
+
+	p.eventTypeSwitchLock.Lock()
+	defer p.eventTypeSwitchLock.Unlock()
+	p.familiesLock.Lock()
+	defer p.familiesLock.Unlock()
+
+	if enabled {
+		delete(p.eventTypeSwitch.disabled, eventType)
+		if err := p.eventTypeSwitch.attach(p.managerOf, eventType); err != nil {
+			return err
+		}
+		log.Infof("event type `%s` enabled", eventType)
+		return nil
+	}
+
+	p.eventTypeSwitch.disabled[eventType] = true
+	if err := p.eventTypeSwitch.detach(p.managerOf, eventType); err != nil {
+		return err
+	}
+	log.Infof("event type `%s` disabled", eventType)
+	return nil
+}
+
+// GetDisabledEventTypes returns the event types disabled at runtime
+func (p *Probe) GetDisabledEventTypes() []eval.EventType {
+	p.eventTypeSwitchLock.Lock()
+	defer p.eventTypeSwitchLock.Unlock()
+
+	eventTypes := []eval.EventType{}
+	for eventType := range p.eventTypeSwitch.disabled {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+	return eventTypes
+}
@@ -0,0 +1,212 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/DataDog/ebpf/manager"
+)
+
+// EventWorkersStats describes the workers decoding and dispatching the events
+type EventWorkersStats struct {
+	Workers   int   `json:"workers"`
+	QueueSize int   `json:"queue_size"`
+	Queued    int64 `json:"queued"`
+	// Waits is the number of events, or batches of events, the reader had to wait for a worker to queue
+	Waits int64 `json:"waits"`
+}
+
+// eventRecord is a record of a perf map queued to a worker
+type eventRecord struct {
+	data    []byte
+	perfMap *manager.PerfMap
+	// barrier stops the worker until the barrier is released, set on the records queued by Barrier
+	barrier *eventBarrier
+}
+
+// eventBarrier stops all the workers while an event shared by all the processes is applied
+type eventBarrier struct {
+	reached sync.WaitGroup
+	release chan struct{}
+}
+
+// eventWorkers decodes and dispatches the events in parallel. The events of a process are queued to the same worker
+// so that they are handled in the order of the kernel. The events updating the caches shared by all the processes,
+// the exec and exit events updating the process cache, the events invalidating the dentry cache and the mount
+// points, are barriers applied once the workers handled the previous events
+type eventWorkers struct {
+	handler     func(records []eventRecord)
+	queues      []chan []eventRecord
+	wg          sync.WaitGroup
+	barrierLock sync.Mutex
+
+	// the scratch event only decodes the pid of the records, on the reader goroutine of the perf map
+	process ProcessEvent
+
+	queued int64
+	waits  int64
+}
+
+func newEventWorkers(probe *Probe, workers int, queueSize int) *eventWorkers {
+	w := &eventWorkers{
+		handler: probe.decodeEvents,
+		queues:  make([]chan []eventRecord, workers),
+	}
+	for i := range w.queues {
+		w.queues[i] = make(chan []eventRecord, queueSize)
+	}
+	return w
+}
+
+// Start starts the workers
+func (w *eventWorkers) Start() {
+	for _, queue := range w.queues {
+		w.wg.Add(1)
+		go w.run(queue)
+	}
+}
+
+func (w *eventWorkers) run(queue chan []eventRecord) {
+	defer w.wg.Done()
+
+	for records := range queue {
+		atomic.AddInt64(&w.queued, -int64(len(records)))
+
+		if len(records) == 1 && records[0].barrier != nil {
+			records[0].barrier.reached.Done()
+			<-records[0].barrier.release
+			continue
+		}
+
+		w.handler(records)
+	}
+}
+
+// Stop stops the workers once they handled the queued events
+func (w *eventWorkers) Stop() {
+	for _, queue := range w.queues {
+		close(queue)
+	}
+	w.wg.Wait()
+}
+
+// pid returns the pid of the process of the given record, 0 for the events that don't belong to a process
+func (w *eventWorkers) pid(data []byte) uint32 {
+	var header Event
+	read, err := header.UnmarshalBinary(data)
+	if err != nil {
+		return 0
+	}
+	data = data[read:]
+
+	switch EventType(header.Type) {
+	case ExecEventType, ExitEventType, InvalidateDentryEventType:
+		// the exec and exit events are barriers, the invalidated dentries don't belong to a process
+	default:
+		// the process context follows the header of the other events
+		if _, err := w.process.UnmarshalBinary(data); err == nil {
+			return w.process.Pid
+		}
+	}
+	return 0
+}
+
+// isBarrier returns whether the given record updates the caches shared by all the processes. The events invalidating
+// the dentry cache are barriers, the other processes could otherwise resolve a stale path until the worker of the
+// acting process handles them. The exec and exit events are barriers as well: the entry of a process is added with
+// its parent as ancestor, the parent has to be added first, and a pid can't be reused until its exit is handled
+func (w *eventWorkers) isBarrier(data []byte) bool {
+	var header Event
+	if _, err := header.UnmarshalBinary(data); err != nil {
+		return false
+	}
+
+	switch eventType := EventType(header.Type); eventType {
+	case ExecEventType, ExitEventType:
+		return true
+	default:
+		return eventType.invalidatesDentryCache()
+	}
+}
+
+// Queue queues the given records to the workers of their processes, in order. The reader waits for a worker whose
+// queue is full. The readers of the perf maps and ring buffers allocate each record, they aren't copied
+func (w *eventWorkers) Queue(records []eventRecord) {
+	if len(w.queues) == 1 {
+		w.push(w.queues[0], records)
+		return
+	}
+
+	batches := make([][]eventRecord, len(w.queues))
+	for _, record := range records {
+		if w.isBarrier(record.data) {
+			w.pushBatches(batches)
+
+			record := record
+			w.Barrier(func() {
+				w.handler([]eventRecord{record})
+			})
+			continue
+		}
+
+		worker := int(w.pid(record.data)) % len(w.queues)
+		batches[worker] = append(batches[worker], record)
+	}
+	w.pushBatches(batches)
+}
+
+// pushBatches queues the given batches to their workers, and resets them
+func (w *eventWorkers) pushBatches(batches [][]eventRecord) {
+	for worker, batch := range batches {
+		if len(batch) > 0 {
+			w.push(w.queues[worker], batch)
+			batches[worker] = nil
+		}
+	}
+}
+
+// Barrier waits for the workers to handle the queued events, and calls apply while they are stopped
+func (w *eventWorkers) Barrier(apply func()) {
+	w.barrierLock.Lock()
+	defer w.barrierLock.Unlock()
+
+	barrier := &eventBarrier{release: make(chan struct{})}
+	barrier.reached.Add(len(w.queues))
+	for _, queue := range w.queues {
+		w.push(queue, []eventRecord{{barrier: barrier}})
+	}
+	barrier.reached.Wait()
+
+	apply()
+	close(barrier.release)
+}
+
+func (w *eventWorkers) push(queue chan []eventRecord, records []eventRecord) {
+	atomic.AddInt64(&w.queued, int64(len(records)))
+	select {
+	case queue <- records:
+	default:
+		atomic.AddInt64(&w.waits, 1)
+		queue <- records
+	}
+}
+
+// Stats returns the stats of the workers
+func (w *eventWorkers) Stats() EventWorkersStats {
+	stats := EventWorkersStats{
+		Workers: len(w.queues),
+		Queued:  atomic.LoadInt64(&w.queued),
+		Waits:   atomic.LoadInt64(&w.waits),
+	}
+	if len(w.queues) > 0 {
+		stats.QueueSize = cap(w.queues[0])
+	}
+	return stats
+}
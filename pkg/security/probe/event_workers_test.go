@@ -0,0 +1,215 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+)
+
+func testEventRecord(eventType EventType, payload []byte) []byte {
+	data := make([]byte, 16+len(payload))
+	ebpf.ByteOrder.PutUint64(data[0:8], uint64(eventType))
+	copy(data[16:], payload)
+	return data
+}
+
+func TestEventWorkersPid(t *testing.T) {
+	w := newEventWorkers(&Probe{}, 4, 16)
+	record := testEventRecord
+
+	process := make([]byte, 64)
+	ebpf.ByteOrder.PutUint32(process[16:20], 43)
+	if pid := w.pid(record(FileOpenEventType, process)); pid != 43 {
+		t.Errorf("expected the pid of the process context, got %d", pid)
+	}
+
+	if pid := w.pid(record(InvalidateDentryEventType, make([]byte, 16))); pid != 0 {
+		t.Errorf("the invalidated dentries don't belong to a process, got %d", pid)
+	}
+
+	if pid := w.pid([]byte{1, 2, 3}); pid != 0 {
+		t.Errorf("a truncated record doesn't belong to a process, got %d", pid)
+	}
+
+	stats := w.Stats()
+	if stats.Workers != 4 || stats.QueueSize != 16 || stats.Queued != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestEventWorkersBarrier(t *testing.T) {
+	w := newEventWorkers(&Probe{}, 4, 16)
+
+	open := func(pid uint32) eventRecord {
+		process := make([]byte, 64)
+		ebpf.ByteOrder.PutUint32(process[16:20], pid)
+		return eventRecord{data: testEventRecord(FileOpenEventType, process)}
+	}
+
+	var lock sync.Mutex
+	var handled []string
+	w.handler = func(records []eventRecord) {
+		for _, record := range records {
+			name := "invalidate"
+			if pid := ebpf.ByteOrder.Uint32(record.data[32:36]); pid != 0 {
+				// the first open event is slower to handle than the invalidated dentry
+				if pid == 1 {
+					time.Sleep(50 * time.Millisecond)
+				}
+				name = fmt.Sprintf("open-%d", pid)
+			}
+
+			lock.Lock()
+			handled = append(handled, name)
+			lock.Unlock()
+		}
+	}
+	w.Start()
+
+	w.Queue([]eventRecord{
+		open(1),
+		{data: testEventRecord(InvalidateDentryEventType, make([]byte, 64))},
+		open(2),
+	})
+
+	var mounted bool
+	w.Queue([]eventRecord{open(1)})
+	w.Barrier(func() {
+		lock.Lock()
+		mounted = len(handled) == 4
+		lock.Unlock()
+	})
+	w.Stop()
+
+	expected := []string{"open-1", "invalidate", "open-2", "open-1"}
+	if len(handled) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, handled)
+	}
+	for i := range expected {
+		if handled[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, handled)
+		}
+	}
+	if !mounted {
+		t.Error("the barrier should be applied once the queued events are handled")
+	}
+}
+
+func TestEventWorkersRenameBarrier(t *testing.T) {
+	w := newEventWorkers(&Probe{}, 4, 16)
+
+	record := func(eventType EventType, pid uint32) eventRecord {
+		process := make([]byte, 64)
+		ebpf.ByteOrder.PutUint32(process[16:20], pid)
+		return eventRecord{data: testEventRecord(eventType, process)}
+	}
+
+	for _, eventType := range []EventType{FileRenameEventType, FileUnlinkEventType, FileRmdirEventType, InvalidateDentryEventType} {
+		if !w.isBarrier(record(eventType, 1).data) {
+			t.Errorf("`%s` invalidates the dentry cache, it should be a barrier", eventType)
+		}
+	}
+	if w.isBarrier(record(FileOpenEventType, 1).data) {
+		t.Error("`open` doesn't invalidate the dentry cache, it shouldn't be a barrier")
+	}
+
+	var lock sync.Mutex
+	var handled []string
+	w.handler = func(records []eventRecord) {
+		for _, record := range records {
+			pid := ebpf.ByteOrder.Uint32(record.data[32:36])
+			eventType := EventType(ebpf.ByteOrder.Uint64(record.data[0:8]))
+			// the open event of the other process is slower to handle than the rename
+			if eventType == FileOpenEventType && pid == 2 {
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			lock.Lock()
+			handled = append(handled, fmt.Sprintf("%s-%d", eventType, pid))
+			lock.Unlock()
+		}
+	}
+	w.Start()
+
+	// the rename of the first process is handled once the other process resolved the old path, and before it
+	// resolves the new one
+	w.Queue([]eventRecord{
+		record(FileOpenEventType, 2),
+		record(FileRenameEventType, 1),
+		record(FileOpenEventType, 2),
+	})
+	w.Stop()
+
+	expected := []string{"open-2", "rename-1", "open-2"}
+	if len(handled) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, handled)
+	}
+	for i := range expected {
+		if handled[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, handled)
+		}
+	}
+}
+
+func TestEventWorkersProcessCacheBarrier(t *testing.T) {
+	w := newEventWorkers(&Probe{}, 4, 16)
+
+	record := func(eventType EventType, pid uint32) eventRecord {
+		process := make([]byte, 64)
+		ebpf.ByteOrder.PutUint32(process[16:20], pid)
+		return eventRecord{data: testEventRecord(eventType, process)}
+	}
+
+	for _, eventType := range []EventType{ExecEventType, ExitEventType} {
+		if !w.isBarrier(record(eventType, 1).data) {
+			t.Errorf("`%s` updates the process cache, it should be a barrier", eventType)
+		}
+	}
+
+	var lock sync.Mutex
+	var handled []string
+	w.handler = func(records []eventRecord) {
+		for _, record := range records {
+			eventType := EventType(ebpf.ByteOrder.Uint64(record.data[0:8]))
+			pid := ebpf.ByteOrder.Uint32(record.data[32:36])
+			// the exec of the parent is slower to handle than the one of its child
+			if eventType == ExecEventType && pid == 1 {
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			lock.Lock()
+			handled = append(handled, fmt.Sprintf("%s-%d", eventType, pid))
+			lock.Unlock()
+		}
+	}
+	w.Start()
+
+	// the parent is added to the process cache before its child, whose pid is sharded to another worker
+	w.Queue([]eventRecord{
+		record(ExecEventType, 1),
+		record(ExecEventType, 2),
+		record(FileOpenEventType, 2),
+		record(ExitEventType, 2),
+	})
+	w.Stop()
+
+	expected := []string{"exec-1", "exec-2", "open-2", "exit-2"}
+	if len(handled) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, handled)
+	}
+	for i := range expected {
+		if handled[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, handled)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// findKernelFunctions returns which of the given functions are listed in the kernel symbols table
+func findKernelFunctions(path string, functions []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	for _, function := range functions {
+		found[function] = false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the kernel symbols from %s", path)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+
+		if _, exists := found[fields[2]]; exists {
+			found[fields[2]] = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read the kernel symbols from %s", path)
+	}
+	return found, nil
+}
+
+// replaceProbe returns the given selectors with the given probe replaced. The selectors are copied, they are shared
+// by the event types
+func replaceProbe(selectors []manager.ProbesSelector, old, new manager.ProbeIdentificationPair) []manager.ProbesSelector {
+	var replaced []manager.ProbesSelector
+	for _, selector := range selectors {
+		switch s := selector.(type) {
+		case *manager.ProbeSelector:
+			if s.ProbeIdentificationPair.Matches(old) {
+				selector = &manager.ProbeSelector{ProbeIdentificationPair: new}
+			}
+		case *manager.OneOf:
+			selector = &manager.OneOf{Selectors: replaceProbe(s.Selectors, old, new)}
+		case *manager.AllOf:
+			selector = &manager.AllOf{Selectors: replaceProbe(s.Selectors, old, new)}
+		case *probes.BestEffort:
+			selector = &probes.BestEffort{Selectors: replaceProbe(s.Selectors, old, new)}
+		case *eventTypeSelector:
+			selector = newEventTypeSelector(s.eventType, replaceProbe(s.selectors, old, new))
+		}
+		replaced = append(replaced, selector)
+	}
+	return replaced
+}
+
+// selectTracepointFallbacks replaces the kprobes of the kernel functions missing from the kernel by their tracepoint
+// fallbacks in the activated probes. It has to be called before the manager is initialized
+func (p *Probe) selectTracepointFallbacks() {
+	var kprobes, functions []string
+	for kprobe := range probes.TracepointFallbacks {
+		kprobes = append(kprobes, kprobe)
+		functions = append(functions, strings.TrimPrefix(kprobe, "kprobe/"))
+	}
+	sort.Strings(kprobes)
+
+	found, err := findKernelFunctions(utils.KallsymsPath(), functions)
+	if err != nil {
+		log.Warnf("failed to look up the kernel functions, keeping the kprobes: %s", err)
+		return
+	}
+
+	for _, kprobe := range kprobes {
+		function := strings.TrimPrefix(kprobe, "kprobe/")
+		if found[function] {
+			continue
+		}
+
+		tracepoint := probes.TracepointFallbacks[kprobe]
+		log.Infof("kernel function %s not found, %s attached instead", function, tracepoint)
+
+		p.managerOptions.ActivatedProbes = replaceProbe(p.managerOptions.ActivatedProbes,
+			manager.ProbeIdentificationPair{UID: probes.SecurityAgentUID, Section: kprobe},
+			manager.ProbeIdentificationPair{UID: probes.SecurityAgentUID, Section: tracepoint},
+		)
+	}
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/ebpf/manager"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+)
+
+func TestFindKernelFunctions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kallsyms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "kallsyms")
+	kallsyms := "ffffffff81369630 T do_exit\nffffffff8170c2e0 t do_vfs_ioctl.isra.0\nffffffffc0a1b000 t ovl_d_real\t[overlay]\n"
+	if err := ioutil.WriteFile(path, []byte(kallsyms), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := findKernelFunctions(path, []string{"do_exit", "do_vfs_ioctl", "ovl_d_real"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found["do_exit"] || found["do_vfs_ioctl"] || !found["ovl_d_real"] {
+		t.Errorf("unexpected kernel functions: %v", found)
+	}
+}
+
+func TestReplaceProbe(t *testing.T) {
+	id := func(section string) manager.ProbeIdentificationPair {
+		return manager.ProbeIdentificationPair{UID: probes.SecurityAgentUID, Section: section}
+	}
+	selector := func(section string) *manager.ProbeSelector {
+		return &manager.ProbeSelector{ProbeIdentificationPair: id(section)}
+	}
+
+	allOf := &manager.AllOf{Selectors: []manager.ProbesSelector{selector("kprobe/do_exit"), selector("kprobe/exit_itimers")}}
+	bestEffort := &probes.BestEffort{Selectors: []manager.ProbesSelector{selector("kprobe/do_exit")}}
+	selectors := []manager.ProbesSelector{selector("kprobe/do_vfs_ioctl"), allOf, bestEffort}
+
+	replaced := replaceProbe(selectors, id("kprobe/do_exit"), id("tracepoint/sched/sched_process_exit"))
+
+	var sections []string
+	for _, pair := range selectorsIDs(replaced) {
+		sections = append(sections, pair.Section)
+	}
+	if len(sections) != 4 || sections[0] != "kprobe/do_vfs_ioctl" || sections[1] != "tracepoint/sched/sched_process_exit" || sections[2] != "kprobe/exit_itimers" || sections[3] != "tracepoint/sched/sched_process_exit" {
+		t.Errorf("unexpected selected probes: %v", sections)
+	}
+
+	// the selectors shared with the other event types are left untouched
+	if allOf.Selectors[0].(*manager.ProbeSelector).Section != "kprobe/do_exit" || bestEffort.Selectors[0].(*manager.ProbeSelector).Section != "kprobe/do_exit" {
+		t.Error("the selectors shouldn't be modified")
+	}
+}
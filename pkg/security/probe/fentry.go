@@ -0,0 +1,441 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"unsafe"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/asm"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	bpfProgLoadCmd          = 5
+	bpfRawTracepointOpenCmd = 17
+
+	// fentryLogSize is the size of the verifier log retrieved when a fentry or fexit program is rejected
+	fentryLogSize = 64 * 1024
+)
+
+// tracingProgLoadAttr is the `bpf_attr` of BPF_PROG_LOAD, up to the BTF ID of the kernel function a tracing program
+// is attached to. The eBPF library doesn't support it
+type tracingProgLoadAttr struct {
+	progType           uint32
+	insCount           uint32
+	instructions       uint64
+	license            uint64
+	logLevel           uint32
+	logSize            uint32
+	logBuf             uint64
+	kernelVersion      uint32
+	progFlags          uint32
+	progName           [unix.BPF_OBJ_NAME_LEN]byte
+	progIfIndex        uint32
+	expectedAttachType uint32
+	progBTFFd          uint32
+	funcInfoRecSize    uint32
+	funcInfo           uint64
+	funcInfoCnt        uint32
+	lineInfoRecSize    uint32
+	lineInfo           uint64
+	lineInfoCnt        uint32
+	attachBTFID        uint32
+	attachProgFd       uint32
+	_                  uint32
+}
+
+// rawTracepointOpenAttr is the `bpf_attr` of BPF_RAW_TRACEPOINT_OPEN, attaching a tracing program to its trampoline
+type rawTracepointOpenAttr struct {
+	name   uint64
+	progFD uint32
+	_      uint32
+}
+
+// fentryProbe is a kernel function hooked by a fentry or fexit program, through a BPF trampoline, in place of its
+// kprobe or kretprobe. The program stays attached when its event type is disabled at runtime, it ignores the calls
+// once the syscall probes of the event type are detached
+type fentryProbe struct {
+	section    string
+	function   string
+	attachType lib.AttachType
+	replaced   manager.ProbeIdentificationPair
+	spec       *lib.ProgramSpec
+	btfID      uint32
+	progFD     int
+	linkFD     int
+}
+
+// isBPFTrampolineSupported returns whether the kernel attaches the tracing programs through the BPF trampolines. The
+// enterprise kernels backport them, and the architectures got them in different versions: an empty fentry program
+// is attached to a kernel function of the given BTF to find out
+func isBPFTrampolineSupported(kernelBTF *btfSpec) bool {
+	if kernelBTF == nil {
+		return false
+	}
+
+	var functions []string
+	for function := range probes.FEntryFunctions {
+		functions = append(functions, function)
+	}
+	sort.Strings(functions)
+
+	for _, function := range functions {
+		btfID, err := fentryFunctionID(kernelBTF, function)
+		if err != nil {
+			continue
+		}
+
+		fp := &fentryProbe{
+			section:    "fentry/" + function,
+			function:   function,
+			attachType: lib.AttachTraceFEntry,
+			btfID:      btfID,
+			progFD:     -1,
+			linkFD:     -1,
+		}
+		insns := asm.Instructions{
+			asm.Mov.Imm(asm.R0, 0),
+			asm.Return(),
+		}
+
+		err = fp.loadInstructions(insns, ebpf.ByteOrder, "GPL", 0)
+		if err == nil {
+			err = fp.attach()
+		}
+		fp.close()
+
+		if err != nil {
+			log.Debugf("the kernel doesn't support the BPF trampolines: %s", err)
+		}
+		return err == nil
+	}
+	return false
+}
+
+// fentryFunctionID returns the BTF ID of a kernel function, its prototype has to match the one expected by the fentry
+// and fexit programs
+func fentryFunctionID(kernelBTF *btfSpec, function string) (uint32, error) {
+	argc, exists := probes.FEntryFunctions[function]
+	if !exists {
+		return 0, errors.Errorf("no fentry program for %s", function)
+	}
+
+	for _, id := range kernelBTF.typesByEssentialName(btfKindFunc, function) {
+		fn := kernelBTF.types[id]
+		if fn.name != function {
+			continue
+		}
+
+		proto, err := kernelBTF.typeByID(fn.typeID)
+		if err != nil {
+			return 0, err
+		}
+		if proto.kind != btfKindFuncProto || proto.params != argc {
+			return 0, errors.Errorf("%s has %d arguments, expected %d", function, proto.params, argc)
+		}
+		return id, nil
+	}
+	return 0, errors.Errorf("%s not found in the kernel BTF", function)
+}
+
+// withoutProbe returns the given selectors without the probe replaced by a fentry or fexit program. A selector of the
+// replaced probe is satisfied, as is an OneOf selector with a satisfied alternative
+func withoutProbe(selectors []manager.ProbesSelector, id manager.ProbeIdentificationPair) ([]manager.ProbesSelector, bool) {
+	var filtered []manager.ProbesSelector
+	for _, selector := range selectors {
+		switch s := selector.(type) {
+		case *manager.ProbeSelector:
+			if s.ProbeIdentificationPair.Matches(id) {
+				continue
+			}
+		case *manager.OneOf:
+			inner, satisfied := withoutProbe(s.Selectors, id)
+			if satisfied || len(inner) == 0 {
+				continue
+			}
+			selector = &manager.OneOf{Selectors: inner}
+		case *manager.AllOf:
+			inner, _ := withoutProbe(s.Selectors, id)
+			if len(inner) == 0 {
+				continue
+			}
+			selector = &manager.AllOf{Selectors: inner}
+		case *probes.BestEffort:
+			inner, _ := withoutProbe(s.Selectors, id)
+			if len(inner) == 0 {
+				continue
+			}
+			selector = &probes.BestEffort{Selectors: inner}
+		case *eventTypeSelector:
+			inner, _ := withoutProbe(s.selectors, id)
+			if len(inner) == 0 {
+				continue
+			}
+			selector = newEventTypeSelector(s.eventType, inner)
+		}
+		filtered = append(filtered, selector)
+	}
+	return filtered, len(filtered) < len(selectors)
+}
+
+// initFEntryProbes selects the kernel functions hooked by fentry and fexit programs. Their kprobes and kretprobes are
+// left out of the activated probes and are only attached if the fentry or fexit program fails. It has to be called
+// before the manager is initialized
+func (p *Probe) initFEntryProbes(reader io.ReaderAt) error {
+	spec, err := lib.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the eBPF object")
+	}
+
+	var kernelBTF *btfSpec
+	if len(p.config.FEntryProbes) > 0 {
+		if !p.isFeatureSupported(featureFEntryProbes) {
+			log.Warnf("the kernel doesn't support the fentry and fexit programs, using kprobes")
+		} else if kernelBTF, err = loadKernelBTF(); err != nil {
+			log.Warnf("failed to load the kernel BTF, using kprobes: %s", err)
+		}
+	}
+
+	enabled := make(map[string]bool)
+	for _, function := range p.config.FEntryProbes {
+		enabled[function] = true
+	}
+
+	var sections []string
+	for section := range spec.Programs {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		els := strings.SplitN(section, "/", 2)
+		if len(els) != 2 {
+			continue
+		}
+
+		fp := &fentryProbe{section: section, function: els[1], progFD: -1, linkFD: -1}
+		switch els[0] {
+		case "fentry":
+			fp.attachType = lib.AttachTraceFEntry
+			fp.replaced = manager.ProbeIdentificationPair{UID: probes.SecurityAgentUID, Section: "kprobe/" + els[1]}
+		case "fexit":
+			fp.attachType = lib.AttachTraceFExit
+			fp.replaced = manager.ProbeIdentificationPair{UID: probes.SecurityAgentUID, Section: "kretprobe/" + els[1]}
+		default:
+			continue
+		}
+
+		// the eBPF library can't load the tracing programs
+		p.managerOptions.ExcludedSections = append(p.managerOptions.ExcludedSections, section)
+
+		if kernelBTF == nil || !enabled[fp.function] {
+			continue
+		}
+
+		if fp.btfID, err = fentryFunctionID(kernelBTF, fp.function); err != nil {
+			log.Warnf("%s can't be attached, using %s: %s", section, fp.replaced.Section, err)
+			continue
+		}
+		fp.spec = spec.Programs[section]
+
+		p.managerOptions.ActivatedProbes, _ = withoutProbe(p.managerOptions.ActivatedProbes, fp.replaced)
+		p.fentryProbes = append(p.fentryProbes, fp)
+	}
+
+	return nil
+}
+
+// load loads the program of a fentry probe, the maps it refers to are the ones of the manager
+func (fp *fentryProbe) load(p *Probe) error {
+	spec := fp.spec.Copy()
+
+	for _, editor := range p.managerOptions.ConstantEditors {
+		value, ok := editor.Value.(uint64)
+		if !ok {
+			continue
+		}
+
+		applies := len(editor.ProbeIdentificationPairs) == 0
+		for _, id := range editor.ProbeIdentificationPairs {
+			applies = applies || id.Matches(fp.replaced)
+		}
+		if !applies {
+			continue
+		}
+
+		if err := manager.Edit(&spec.Instructions).RewriteConstant(editor.Name, value); err != nil && !manager.IsUnreferencedSymbol(err) {
+			return errors.Wrapf(err, "failed to edit constant %s", editor.Name)
+		}
+	}
+
+	for _, reference := range sortedReferences(spec) {
+		m, exists := p.managerOptions.MapEditors[reference]
+		if !exists {
+			if m, exists, _ = p.manager.GetMap(reference); !exists || m == nil {
+				continue
+			}
+		}
+
+		if err := spec.Instructions.RewriteMapPtr(reference, m.FD()); err != nil {
+			return errors.Wrapf(err, "failed to rewrite map %s", reference)
+		}
+	}
+
+	return fp.loadInstructions(spec.Instructions, spec.ByteOrder, spec.License, spec.KernelVersion)
+}
+
+// loadInstructions loads the given instructions as the program of a fentry probe
+func (fp *fentryProbe) loadInstructions(instructions asm.Instructions, bo binary.ByteOrder, license string, kernelVersion uint32) error {
+	var insns bytes.Buffer
+	if err := instructions.Marshal(&insns, bo); err != nil {
+		return errors.Wrap(err, "failed to marshal the instructions")
+	}
+	bytecode := insns.Bytes()
+	licenseBytes := append([]byte(license), 0)
+
+	attr := tracingProgLoadAttr{
+		progType:           uint32(lib.Tracing),
+		insCount:           uint32(len(bytecode) / asm.InstructionSize),
+		instructions:       uint64(uintptr(unsafe.Pointer(&bytecode[0]))),
+		license:            uint64(uintptr(unsafe.Pointer(&licenseBytes[0]))),
+		kernelVersion:      kernelVersion,
+		expectedAttachType: uint32(fp.attachType),
+		attachBTFID:        fp.btfID,
+	}
+	copy(attr.progName[:unix.BPF_OBJ_NAME_LEN-1], strings.Replace(fp.function, ".", "_", -1))
+
+	fd, err := bpfSyscall(bpfProgLoadCmd, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	if err != nil {
+		// load it again to retrieve the verifier log
+		logBuf := make([]byte, fentryLogSize)
+		attr.logLevel = 1
+		attr.logSize = uint32(len(logBuf))
+		attr.logBuf = uint64(uintptr(unsafe.Pointer(&logBuf[0])))
+
+		if fd, err = bpfSyscall(bpfProgLoadCmd, unsafe.Pointer(&attr), unsafe.Sizeof(attr)); err != nil {
+			verifierLog := string(bytes.TrimRight(logBuf, "\x00"))
+			if len(verifierLog) > 512 {
+				verifierLog = verifierLog[len(verifierLog)-512:]
+			}
+			runtime.KeepAlive(logBuf)
+			return errors.Wrapf(err, "failed to load %s: %s", fp.section, verifierLog)
+		}
+	}
+	runtime.KeepAlive(bytecode)
+	runtime.KeepAlive(licenseBytes)
+
+	fp.progFD = fd
+	return nil
+}
+
+// sortedReferences returns the symbols referred to by the instructions of a program
+func sortedReferences(spec *lib.ProgramSpec) []string {
+	var references []string
+	for reference := range spec.Instructions.ReferenceOffsets() {
+		references = append(references, reference)
+	}
+	sort.Strings(references)
+	return references
+}
+
+// attach attaches the program of a fentry probe to the trampoline of its kernel function
+func (fp *fentryProbe) attach() error {
+	attr := rawTracepointOpenAttr{progFD: uint32(fp.progFD)}
+
+	fd, err := bpfSyscall(bpfRawTracepointOpenCmd, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	if err != nil {
+		return errors.Wrapf(err, "failed to attach %s", fp.section)
+	}
+	fp.linkFD = fd
+	return nil
+}
+
+// close detaches the program of a fentry probe and unloads it
+func (fp *fentryProbe) close() {
+	if fp.linkFD >= 0 {
+		_ = unix.Close(fp.linkFD)
+		fp.linkFD = -1
+	}
+	if fp.progFD >= 0 {
+		_ = unix.Close(fp.progFD)
+		fp.progFD = -1
+	}
+}
+
+func bpfSyscall(cmd int, attr unsafe.Pointer, size uintptr) (int, error) {
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, uintptr(cmd), uintptr(attr), size)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+// startFEntryProbes loads and attaches the fentry and fexit programs, the kprobe or kretprobe of a function is
+// attached instead if its program fails. It has to be called once the manager is started
+func (p *Probe) startFEntryProbes() error {
+	for _, fp := range p.fentryProbes {
+		err := fp.load(p)
+		if err == nil {
+			if err = fp.attach(); err == nil {
+				log.Debugf("%s attached", fp.section)
+				continue
+			}
+		}
+		fp.close()
+		log.Warnf("%s can't be attached, falling back to %s: %s", fp.section, fp.replaced.Section, err)
+
+		if err := p.attachReplacedProbe(fp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachReplacedProbe attaches the kprobe or kretprobe replaced by a fentry or fexit program that failed, with the
+// manager of its family. The probe isn't attached while its family is stopped
+func (p *Probe) attachReplacedProbe(fp *fentryProbe) error {
+	m := p.managerOf(fp.replaced)
+	if m == nil {
+		log.Warnf("the probes of %s are stopped, %s isn't attached", fp.replaced, fp.replaced.Section)
+		return nil
+	}
+
+	probe, exists := m.GetProbe(fp.replaced)
+	if !exists {
+		return errors.Errorf("probe %s not found", fp.replaced)
+	}
+
+	probe.Enabled = true
+	if err := probe.Init(m); err != nil {
+		return errors.Wrapf(err, "failed to initialize probe %s", fp.replaced)
+	}
+	if err := probe.Attach(); err != nil {
+		return errors.Wrapf(err, "failed to attach probe %s", fp.replaced)
+	}
+	return nil
+}
+
+// stopFEntryProbes detaches the fentry and fexit programs
+func (p *Probe) stopFEntryProbes() {
+	for _, fp := range p.fentryProbes {
+		fp.close()
+	}
+}
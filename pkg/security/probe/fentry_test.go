@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/DataDog/ebpf/manager"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+)
+
+func TestFEntryFunctionID(t *testing.T) {
+	// int vfs_unlink(int, int, int); int vfs_mkdir(int); struct vfs_link
+	b := newBTFBuilder()
+	b.addInt("int", 4)
+	b.addFuncProto(1, 1, 1, 1)
+	b.addFunc("vfs_unlink", 2)
+	b.addFuncProto(1, 1)
+	b.addFunc("vfs_mkdir", 4)
+	b.addComposite(btfKindStruct, "vfs_link", 4, btfMember{name: "a", typeID: 1})
+
+	kernelBTF, err := parseBTF(b.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, err := fentryFunctionID(kernelBTF, "vfs_unlink"); err != nil || id != 3 {
+		t.Errorf("expected the BTF ID 3 for vfs_unlink, got %d (%v)", id, err)
+	}
+	if _, err := fentryFunctionID(kernelBTF, "vfs_mkdir"); err == nil {
+		t.Error("a function with an unexpected prototype shouldn't be hooked")
+	}
+	if _, err := fentryFunctionID(kernelBTF, "vfs_link"); err == nil {
+		t.Error("a function missing from the kernel BTF shouldn't be hooked")
+	}
+	if _, err := fentryFunctionID(kernelBTF, "vfs_rename"); err == nil {
+		t.Error("a function without fentry program shouldn't be hooked")
+	}
+}
+
+func TestWithoutProbe(t *testing.T) {
+	id := func(section string) manager.ProbeIdentificationPair {
+		return manager.ProbeIdentificationPair{UID: probes.SecurityAgentUID, Section: section}
+	}
+	selector := func(section string) *manager.ProbeSelector {
+		return &manager.ProbeSelector{ProbeIdentificationPair: id(section)}
+	}
+
+	oneOf := &manager.OneOf{Selectors: []manager.ProbesSelector{selector("kprobe/vfs_unlink"), selector("kprobe/do_unlinkat")}}
+	allOf := &manager.AllOf{Selectors: []manager.ProbesSelector{selector("kprobe/vfs_unlink"), selector("kprobe/vfs_mkdir")}}
+	selectors := []manager.ProbesSelector{selector("kprobe/vfs_unlink"), oneOf, allOf, selector("kprobe/vfs_rmdir")}
+
+	filtered, satisfied := withoutProbe(selectors, id("kprobe/vfs_unlink"))
+	if !satisfied {
+		t.Error("the selectors of the replaced probe should be satisfied")
+	}
+
+	var sections []string
+	for _, pair := range selectorsIDs(filtered) {
+		sections = append(sections, pair.Section)
+	}
+	if len(sections) != 2 || sections[0] != "kprobe/vfs_mkdir" || sections[1] != "kprobe/vfs_rmdir" {
+		t.Errorf("unexpected selected probes: %v", sections)
+	}
+
+	// the selectors shared with the other event types are left untouched
+	if len(oneOf.Selectors) != 2 || len(allOf.Selectors) != 2 {
+		t.Error("the selectors shouldn't be modified")
+	}
+}
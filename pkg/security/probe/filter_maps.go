@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"strings"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+)
+
+// approverMaps lists the hash maps holding the approvers. An approver can't be evicted from a full map without
+// dropping the events it approves, the approvers of an event type that don't fit are rejected and the event type falls
+// back to the accept mode
+var approverMaps = []string{
+	"inode_approvers", "comm_approvers", "open_basename_approvers", "unlink_basename_approvers",
+	"rename_basename_approvers", "chmod_basename_approvers", "chown_basename_approvers", "mkdir_basename_approvers",
+}
+
+// discarderMaps lists the maps holding the discarders. A full discarder map either evicts its least recently used
+// entries or rejects the new ones, depending on the configured overflow strategy
+var discarderMaps = []string{"inode_discarders", "pid_discarders"}
+
+// sizedFilterMaps returns the filter maps whose size is configurable
+func sizedFilterMaps() []string {
+	return append(append([]string{}, approverMaps...), discarderMaps...)
+}
+
+// filterMapSpecEditors returns the editors sizing the filter maps, the discarder maps are sized for the host unless
+// their size is configured. The discarder maps become plain hash maps when the new discarders of a full map are
+// rejected
+func filterMapSpecEditors(cfg *config.Config) map[string]manager.MapSpecEditor {
+	editors := make(map[string]manager.MapSpecEditor)
+	for _, name := range approverMaps {
+		editors[name] = manager.MapSpecEditor{
+			MaxEntries: uint32(cfg.ApproversMapSize),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+
+	for _, name := range discarderMaps {
+		var editor manager.MapSpecEditor
+		if cfg.DiscardersMapSize > 0 {
+			editor.MaxEntries = uint32(cfg.DiscardersMapSize)
+			editor.EditorFlag = manager.EditMaxEntries
+		}
+		if cfg.DiscardersOverflow == config.DiscardersOverflowReject {
+			editor.Type = lib.Hash
+			editor.EditorFlag |= manager.EditType
+		}
+		editors[name] = editor
+	}
+
+	return editors
+}
+
+// isMapFull returns whether a map update failed because the map is full. The library only keeps the message of the
+// errno
+func isMapFull(err error) bool {
+	return err != nil && strings.Contains(err.Error(), unix.E2BIG.Error())
+}
+
+// putFilter inserts an entry in the given filter map, the entries rejected by a full map are counted as overflows of
+// the map
+func (p *Probe) putFilter(tableName string, key, value interface{}) error {
+	table := p.Map(tableName)
+	if table == nil {
+		return errors.Errorf("map %s not found", tableName)
+	}
+
+	err := table.Put(key, value)
+	if isMapFull(err) {
+		if p.filterMonitor != nil {
+			p.filterMonitor.countOverflow(tableName)
+		}
+		return errors.Wrapf(err, "map %s is full", tableName)
+	}
+	return err
+}
+
+// countEntries returns the number of entries of the given map
+func countEntries(table *lib.Map) (uint64, error) {
+	var count uint64
+	var key interface{}
+	for {
+		next, err := table.NextKeyBytes(key)
+		if err != nil {
+			return 0, err
+		}
+		if next == nil {
+			return count, nil
+		}
+		key = next
+		count++
+	}
+}
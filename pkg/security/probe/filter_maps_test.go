@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+)
+
+func TestFilterMapSpecEditors(t *testing.T) {
+	cfg := &config.Config{
+		ApproversMapSize:   1024,
+		DiscardersMapSize:  4096,
+		DiscardersOverflow: config.DiscardersOverflowEvict,
+	}
+
+	editors := filterMapSpecEditors(cfg)
+	if len(editors) != len(sizedFilterMaps()) {
+		t.Fatalf("expected an editor per sized map, got %d", len(editors))
+	}
+
+	if editor := editors["open_basename_approvers"]; editor.MaxEntries != 1024 || editor.EditorFlag != manager.EditMaxEntries {
+		t.Errorf("unexpected approvers editor: %+v", editor)
+	}
+
+	if editor := editors["inode_discarders"]; editor.MaxEntries != 4096 || editor.EditorFlag != manager.EditMaxEntries {
+		t.Errorf("unexpected discarders editor: %+v", editor)
+	}
+
+	cfg.DiscardersOverflow = config.DiscardersOverflowReject
+	editors = filterMapSpecEditors(cfg)
+
+	if editor := editors["pid_discarders"]; editor.Type != lib.Hash || editor.EditorFlag&manager.EditType == 0 {
+		t.Errorf("the discarders map should become a hash map: %+v", editor)
+	}
+
+	if editor := editors["comm_approvers"]; editor.EditorFlag&manager.EditType != 0 {
+		t.Errorf("the type of the approvers map shouldn't be edited: %+v", editor)
+	}
+}
+
+func TestIsMapFull(t *testing.T) {
+	if !isMapFull(errors.New("update failed: argument list too long")) {
+		t.Error("E2BIG should be reported as a full map")
+	}
+
+	if isMapFull(errors.New("update failed: key does not exist")) || isMapFull(nil) {
+		t.Error("only E2BIG should be reported as a full map")
+	}
+}
@@ -0,0 +1,252 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/DataDog/datadog-go/statsd"
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// countedFilterMaps lists the filter maps whose lookups are counted, in the order of their counters in the kernel
+var countedFilterMaps = []string{
+	"open_basename_approvers", "unlink_basename_approvers", "rename_basename_approvers", "chmod_basename_approvers",
+	"chown_basename_approvers", "mkdir_basename_approvers", "open_flags_approvers", "chmod_mode_approvers",
+	"mkdir_mode_approvers", "chown_uid_approvers", "chown_gid_approvers", "comm_approvers", "inode_discarders",
+	"pid_discarders", "mount_fstype_discarders", "mount_id_discarders", "pre_eval_rules", "prefix_approvers",
+	"inode_approvers",
+}
+
+// filterMaps lists the maps holding the in-kernel filters
+var filterMaps = []string{
+	"filter_policy", "container_filter_policy", "inode_approvers", "inode_discarders", "pid_discarders",
+	"comm_approvers", "open_basename_approvers", "open_flags_approvers", "unlink_basename_approvers",
+	"rename_basename_approvers", "chmod_basename_approvers", "chmod_mode_approvers", "chown_basename_approvers",
+	"chown_uid_approvers", "chown_gid_approvers", "mkdir_basename_approvers", "mkdir_mode_approvers",
+	"container_event_types", "mount_fstype_discarders", "mount_id_discarders",
+	"pre_eval_config", "pre_eval_rules", "prefix_approvers",
+}
+
+// FilterStats holds the number of lookups of a filter map that matched, and the number of the ones that didn't
+type FilterStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// FilterMapStats holds the number of entries of a filter map, its capacity and the number of entries it rejected since
+// the probe was started
+type FilterMapStats struct {
+	Entries    uint64
+	MaxEntries uint64
+	Overflows  uint64
+}
+
+// FilterMapEntry describes an entry of a filter map, its key and its value are hex encoded
+type FilterMapEntry struct {
+	Key   string
+	Value string
+}
+
+// FilterMonitor reads the lookup counters of the in-kernel filters and dumps their maps
+type FilterMonitor struct {
+	manager *manager.Manager
+	stats   *lib.Map
+	// sent holds the counters when they were last sent, the kernel never resets them
+	sent map[string]FilterStats
+
+	// overflows holds the number of entries rejected by each full filter map, sentOverflows the numbers when they
+	// were last sent
+	overflows     map[string]uint64
+	sentOverflows map[string]uint64
+	overflowsLock sync.Mutex
+}
+
+// countOverflow counts an entry rejected by a full filter map. The first overflow of a map since the stats were last
+// sent is logged
+func (fm *FilterMonitor) countOverflow(tableName string) {
+	fm.overflowsLock.Lock()
+	defer fm.overflowsLock.Unlock()
+
+	if fm.overflows[tableName] == fm.sentOverflows[tableName] {
+		log.Warnf("filter map %s is full, new entries are rejected", tableName)
+	}
+	fm.overflows[tableName]++
+}
+
+// GetMapStats returns the number of entries, the capacity and the number of overflows of each sized filter map
+func (fm *FilterMonitor) GetMapStats() (map[string]FilterMapStats, error) {
+	fm.overflowsLock.Lock()
+	defer fm.overflowsLock.Unlock()
+
+	stats := make(map[string]FilterMapStats)
+	for _, tableName := range sizedFilterMaps() {
+		table, ok, err := fm.manager.GetMap(tableName)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.Errorf("map %s not found", tableName)
+		}
+
+		entries, err := countEntries(table)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to iterate map %s", tableName)
+		}
+
+		stats[tableName] = FilterMapStats{
+			Entries:    entries,
+			MaxEntries: uint64(table.ABI().MaxEntries),
+			Overflows:  fm.overflows[tableName],
+		}
+	}
+	return stats, nil
+}
+
+// sendMapStats sends the number of entries and the fill ratio of each sized filter map, and the number of entries
+// they rejected since the stats were last sent
+func (fm *FilterMonitor) sendMapStats(statsdClient *statsd.Client) error {
+	stats, err := fm.GetMapStats()
+	if err != nil {
+		return err
+	}
+
+	fm.overflowsLock.Lock()
+	defer fm.overflowsLock.Unlock()
+
+	for tableName, value := range stats {
+		tags := []string{"map:" + tableName}
+		if err := statsdClient.Gauge(MetricPrefix+".filters.map_entries", float64(value.Entries), tags, 1.0); err != nil {
+			return err
+		}
+		if value.MaxEntries > 0 {
+			if err := statsdClient.Gauge(MetricPrefix+".filters.map_fill_ratio", float64(value.Entries)/float64(value.MaxEntries), tags, 1.0); err != nil {
+				return err
+			}
+		}
+
+		overflows := fm.overflows[tableName]
+		if count := overflows - fm.sentOverflows[tableName]; count > 0 {
+			if err := statsdClient.Count(MetricPrefix+".filters.map_overflows", int64(count), tags, 1.0); err != nil {
+				return err
+			}
+		}
+		fm.sentOverflows[tableName] = overflows
+	}
+	return nil
+}
+
+// GetStats returns the number of lookups of each filter map since the probe was started, summed over the CPUs
+func (fm *FilterMonitor) GetStats() (map[string]FilterStats, error) {
+	stats := make(map[string]FilterStats)
+	for i, tableName := range countedFilterMaps {
+		var perCPU []FilterStats
+		if err := fm.stats.Lookup(ebpf.Uint32MapItem(i), &perCPU); err != nil {
+			return nil, errors.Wrapf(err, "failed to read the stats of %s", tableName)
+		}
+
+		var value FilterStats
+		for _, cpuStats := range perCPU {
+			value.Hits += cpuStats.Hits
+			value.Misses += cpuStats.Misses
+		}
+		stats[tableName] = value
+	}
+	return stats, nil
+}
+
+// SendStats sends the number of lookups of each filter map since the stats were last sent
+func (fm *FilterMonitor) SendStats(statsdClient *statsd.Client) error {
+	stats, err := fm.GetStats()
+	if err != nil {
+		return err
+	}
+
+	for tableName, value := range stats {
+		sent := fm.sent[tableName]
+		fm.sent[tableName] = value
+
+		tags := []string{"map:" + tableName}
+		if hits := value.Hits - sent.Hits; hits > 0 {
+			if err := statsdClient.Count(MetricPrefix+".filters.hits", int64(hits), tags, 1.0); err != nil {
+				return err
+			}
+		}
+		if misses := value.Misses - sent.Misses; misses > 0 {
+			if err := statsdClient.Count(MetricPrefix+".filters.misses", int64(misses), tags, 1.0); err != nil {
+				return err
+			}
+		}
+	}
+	return fm.sendMapStats(statsdClient)
+}
+
+// Dump returns the entries of all the filter maps
+func (fm *FilterMonitor) Dump() (map[string][]FilterMapEntry, error) {
+	dump := make(map[string][]FilterMapEntry)
+	for _, tableName := range filterMaps {
+		table, ok, err := fm.manager.GetMap(tableName)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.Errorf("map %s not found", tableName)
+		}
+
+		entries := []FilterMapEntry{}
+		var key interface{}
+		for {
+			next, err := table.NextKeyBytes(key)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to iterate map %s", tableName)
+			}
+			if next == nil {
+				break
+			}
+			key = next
+
+			value, err := table.LookupBytes(next)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read map %s", tableName)
+			}
+			// the entry may have been evicted or removed in the meantime
+			if value == nil {
+				continue
+			}
+
+			entries = append(entries, FilterMapEntry{Key: hex.EncodeToString(next), Value: hex.EncodeToString(value)})
+		}
+		dump[tableName] = entries
+	}
+	return dump, nil
+}
+
+// NewFilterMonitor returns a new filter monitor
+func NewFilterMonitor(manager *manager.Manager) (*FilterMonitor, error) {
+	stats, ok, err := manager.GetMap("filter_stats")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("map filter_stats not found")
+	}
+
+	return &FilterMonitor{
+		manager:       manager,
+		stats:         stats,
+		sent:          make(map[string]FilterStats),
+		overflows:     make(map[string]uint64),
+		sentOverflows: make(map[string]uint64),
+	}, nil
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"io/ioutil"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	lib "github.com/DataDog/ebpf"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+)
+
+func TestCountedFilterMaps(t *testing.T) {
+	content, err := ioutil.ReadFile("../ebpf/c/filters.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enum := regexp.MustCompile(`(?s)enum filter_map\s*\{(.*?)FILTER_MAP_MAX`).FindSubmatch(content)
+	if enum == nil {
+		t.Fatal("filter_map enum not found")
+	}
+
+	// the counters of the kernel are indexed by the filter_map enum
+	var names []string
+	for _, name := range regexp.MustCompile(`FILTER_(\w+),`).FindAllSubmatch(enum[1], -1) {
+		names = append(names, strings.ToLower(string(name[1])))
+	}
+	if strings.Join(names, ",") != strings.Join(countedFilterMaps, ",") {
+		t.Errorf("the counted filter maps %v don't match the kernel ones %v", countedFilterMaps, names)
+	}
+
+	for _, tableName := range countedFilterMaps {
+		found := false
+		for _, filterMap := range filterMaps {
+			found = found || filterMap == tableName
+		}
+		if !found {
+			t.Errorf("counted filter map %s isn't dumped", tableName)
+		}
+	}
+}
+
+func TestFilterMonitorStats(t *testing.T) {
+	stats, err := lib.NewMap(&lib.MapSpec{
+		Type:       lib.PerCPUArray,
+		KeySize:    4,
+		ValueSize:  16,
+		MaxEntries: uint32(len(countedFilterMaps)),
+	})
+	if err != nil {
+		t.Skipf("eBPF maps unavailable: %s", err)
+	}
+	defer stats.Close()
+
+	cpus := runtime.NumCPU()
+	if cpus > 2 {
+		cpus = 2
+	}
+	perCPU := make([]FilterStats, cpus)
+	for i := range perCPU {
+		perCPU[i] = FilterStats{Hits: 1, Misses: 2}
+	}
+	if err := stats.Put(ebpf.Uint32MapItem(1), perCPU); err != nil {
+		t.Fatal(err)
+	}
+
+	fm := &FilterMonitor{stats: stats, sent: make(map[string]FilterStats)}
+	values, err := fm.GetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != len(countedFilterMaps) {
+		t.Errorf("expected the stats of each counted filter map, got %v", values)
+	}
+	if value := values["unlink_basename_approvers"]; value.Hits != uint64(cpus) || value.Misses != uint64(2*cpus) {
+		t.Errorf("expected the counters of the CPUs to be summed, got %+v", value)
+	}
+	if value := values["open_basename_approvers"]; value.Hits != 0 || value.Misses != 0 {
+		t.Errorf("unexpected counters %+v", value)
+	}
+}
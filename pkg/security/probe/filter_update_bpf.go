@@ -0,0 +1,261 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// flagsFilterMaps lists the maps holding a single flags or modes filter, set by setFlagsFilter
+var flagsFilterMaps = []string{"open_flags_approvers", "chmod_mode_approvers", "mkdir_mode_approvers"}
+
+// idRangeFilterMaps lists the maps holding the id ranges set by approveIDRanges
+var idRangeFilterMaps = []string{"chown_uid_approvers", "chown_gid_approvers"}
+
+// filterKeys holds the encoded keys of the entries of the approver and container policy maps, per map. The keys are
+// encoded as the maps expect them, the callers reuse the keys they pass
+type filterKeys map[string]map[string]bool
+
+func (k filterKeys) add(tableName string, encoded string) {
+	if k[tableName] == nil {
+		k[tableName] = make(map[string]bool)
+	}
+	k[tableName][encoded] = true
+}
+
+func (k filterKeys) has(tableName string, encoded string) bool {
+	return k[tableName][encoded]
+}
+
+// deleteFilterKey removes the entry of the given encoded key, the entries of an LRU map may have been evicted in the
+// meantime
+func deleteFilterKey(probe *Probe, tableName string, encoded string) {
+	if table := probe.Map(tableName); table != nil {
+		_ = table.Delete(ebpf.BytesMapItem(encoded))
+	}
+}
+
+// filterWrite is a buffered write of a filter updated in place
+type filterWrite struct {
+	tableName string
+	key       interface{}
+	value     interface{}
+}
+
+// filterUpdate holds the filters of a ruleset while they are applied, they replace the current filters once all of
+// them were applied. The approvers are inserted along with the current ones, the events approved by either ruleset
+// pass during the update. The filters updated in place, the filter policies, the flags and id ranges filters and the
+// decision tables, are buffered until the update is committed
+type filterUpdate struct {
+	// keys holds the keys of the approvers and of the container policies of the new filters
+	keys filterKeys
+	// added holds the keys of the approvers inserted by the update that the current filters don't have
+	added filterKeys
+	// writes holds the filters updated in place, in the order they were applied
+	writes []filterWrite
+	// written holds the encoded keys of the filters updated in place, per map
+	written map[string]map[string]bool
+
+	preEvalRules       map[EventType]uint32
+	userGroupApprovers map[eval.EventType]userGroupApprovers
+	standbyApprovers   map[eval.EventType]standbyApprovers
+}
+
+func (u *filterUpdate) isWritten(tableName string, key interface{}) bool {
+	encoded, err := encodeFilterKey(key)
+	return err == nil && u.written[tableName][encoded]
+}
+
+// BeginFilterUpdate starts the replacement of the in-kernel filters by the ones applied until CommitFilterUpdate is
+// called, or until AbortFilterUpdate drops them. The current filters are kept meanwhile, the other updates of the
+// filters wait for the end of the replacement
+func (p *Probe) BeginFilterUpdate() {
+	p.filtersLock.Lock()
+
+	p.filterUpdate = &filterUpdate{
+		keys:               make(filterKeys),
+		added:              make(filterKeys),
+		written:            make(map[string]map[string]bool),
+		preEvalRules:       make(map[EventType]uint32),
+		userGroupApprovers: make(map[eval.EventType]userGroupApprovers),
+		standbyApprovers:   make(map[eval.EventType]standbyApprovers),
+	}
+}
+
+// AbortFilterUpdate drops the filters applied since BeginFilterUpdate, the current filters are left as they were
+func (p *Probe) AbortFilterUpdate() {
+	defer p.filtersLock.Unlock()
+
+	update := p.filterUpdate
+	p.filterUpdate = nil
+
+	for tableName, keys := range update.added {
+		for encoded := range keys {
+			deleteFilterKey(p, tableName, encoded)
+		}
+	}
+}
+
+// CommitFilterUpdate replaces the current filters by the ones applied since BeginFilterUpdate. The pre-evaluation is
+// disabled while the decision tables are written, and the approvers that the new filters don't have are removed once
+// the filter policies are replaced
+func (p *Probe) CommitFilterUpdate() error {
+	defer p.filtersLock.Unlock()
+
+	update := p.filterUpdate
+	p.filterUpdate = nil
+
+	if err := p.commitFilterUpdate(update); err != nil {
+		// the approvers of both rulesets are kept, the next update removes the ones it doesn't have
+		for tableName, keys := range update.keys {
+			for encoded := range keys {
+				p.filterKeys.add(tableName, encoded)
+			}
+		}
+		return err
+	}
+
+	log.Debugf("in-kernel filters replaced, %d filters updated in place", len(update.writes))
+
+	return nil
+}
+
+func (p *Probe) commitFilterUpdate(update *filterUpdate) error {
+	for eventType := UnknownEventType + 1; eventType != maxEventType; eventType++ {
+		if err := p.setPreEvalRules(eventType, 0); err != nil {
+			return err
+		}
+	}
+
+	for _, write := range update.writes {
+		table := p.Map(write.tableName)
+		if table == nil {
+			return errors.Errorf("map %s not found", write.tableName)
+		}
+		if err := table.Put(write.key, write.value); err != nil {
+			return errors.Wrapf(err, "failed to update map %s", write.tableName)
+		}
+	}
+
+	// the filters of the current ruleset that the new one doesn't set are reset
+	for _, tableName := range flagsFilterMaps {
+		if !update.isWritten(tableName, ebpf.ZeroUint32MapItem) {
+			if err := flushFlagsFilter(p, tableName); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, tableName := range idRangeFilterMaps {
+		if !update.isWritten(tableName, ebpf.ZeroUint32MapItem) {
+			if err := flushIDRanges(p, tableName); err != nil {
+				return err
+			}
+		}
+	}
+
+	table := p.Map("filter_policy")
+	if table == nil {
+		return errors.New("unable to find policy table")
+	}
+
+	for eventType := UnknownEventType + 1; eventType != maxEventType; eventType++ {
+		if !update.isWritten("filter_policy", ebpf.Uint32MapItem(eventType)) {
+			if err := table.Put(ebpf.Uint32MapItem(eventType), &FilterPolicy{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for eventType, count := range update.preEvalRules {
+		if err := p.setPreEvalRules(eventType, count); err != nil {
+			return err
+		}
+	}
+
+	p.userGroupApproversLock.Lock()
+	p.userGroupApprovers = update.userGroupApprovers
+	p.userGroupApproversLock.Unlock()
+
+	p.standbyApproversLock.Lock()
+	p.standbyApprovers = update.standbyApprovers
+	p.standbyApproversLock.Unlock()
+
+	// the event types of the containers depend on the scopes of the rules, they are computed again at the next event
+	// of each container
+	p.resetContainerEventTypes()
+
+	// the stale approvers and container policies are removed last, the new policies don't rely on them
+	for tableName, keys := range p.filterKeys {
+		for encoded := range keys {
+			if !update.keys.has(tableName, encoded) {
+				deleteFilterKey(p, tableName, encoded)
+			}
+		}
+	}
+	p.filterKeys = update.keys
+
+	return nil
+}
+
+// recordFilterKey records the key of an entry of an approver or container policy map. The entries that the next
+// filters don't have are removed once these are committed
+func (p *Probe) recordFilterKey(tableName string, key interface{}) error {
+	encoded, err := encodeFilterKey(key)
+	if err != nil {
+		return err
+	}
+
+	if p.filterUpdate == nil {
+		p.filterKeys.add(tableName, encoded)
+		return nil
+	}
+
+	if !p.filterKeys.has(tableName, encoded) {
+		p.filterUpdate.added.add(tableName, encoded)
+	}
+	p.filterUpdate.keys.add(tableName, encoded)
+	return nil
+}
+
+// putApprover inserts an approver in the given map, along with the approvers of the current filters when they are
+// being replaced
+func (p *Probe) putApprover(tableName string, key, value interface{}) error {
+	if err := p.recordFilterKey(tableName, key); err != nil {
+		return err
+	}
+	return p.putFilter(tableName, key, value)
+}
+
+// putFilterValue writes an entry of a filter updated in place, the write is buffered until the update is committed
+// when the filters are being replaced
+func (p *Probe) putFilterValue(tableName string, key, value interface{}) error {
+	if update := p.filterUpdate; update != nil {
+		encoded, err := encodeFilterKey(key)
+		if err != nil {
+			return err
+		}
+
+		if update.written[tableName] == nil {
+			update.written[tableName] = make(map[string]bool)
+		}
+		update.written[tableName][encoded] = true
+		update.writes = append(update.writes, filterWrite{tableName: tableName, key: key, value: value})
+		return nil
+	}
+
+	table := p.Map(tableName)
+	if table == nil {
+		return errors.Errorf("map %s not found", tableName)
+	}
+	return table.Put(key, value)
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"syscall"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+const (
+	hashCacheSize = 1024
+	// maxHashedFileSize is the size of the largest file hashed, the files are hashed while the event is evaluated and
+	// hashing bigger files would stall the event processing
+	maxHashedFileSize = 4 * 1024 * 1024
+)
+
+// hashKey identifies a version of the content of a file. Unlike the modification time, the change time of a file can't
+// be set by its owner, a checksum is thus never reused for a modified file
+type hashKey struct {
+	mountID uint32
+	inode   uint64
+	ctime   int64
+	size    int64
+}
+
+// HashResolver is used to resolve the checksums of the content of the files
+type HashResolver struct {
+	hashes *lru.Cache
+}
+
+// ResolveSHA256 returns the hex encoded SHA256 checksum of the content of the given file, accessed by the given process.
+// The checksum is cached per inode until the size or the change time of the file change. The path may point to another
+// file by the time it is opened, the file is only hashed if it is the inode of the event, on the given device when it
+// is known.
+func (hr *HashResolver) ResolveSHA256(pid uint32, mountID uint32, device uint32, inode uint64, filename string) (string, error) {
+	// the path is resolved in the mount namespace of the process, its root is read from the host so that the agent can
+	// run in a container
+	if pid == 0 {
+		pid = 1
+	}
+
+	file, err := os.Open(path.Join(utils.ProcRootPath(pid), filename))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", filename)
+	}
+
+	if info.Size() > maxHashedFileSize {
+		return "", fmt.Errorf("%s is too large to be hashed: %d bytes", filename, info.Size())
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", fmt.Errorf("failed to get the change time of %s", filename)
+	}
+
+	if stat.Ino != inode {
+		return "", fmt.Errorf("%s was replaced, inode %d instead of %d", filename, stat.Ino, inode)
+	}
+
+	if device != 0 && stat.Dev != unix.Mkdev(device>>kernelMinorBits, device&kernelMinorMask) {
+		return "", fmt.Errorf("%s was replaced, device %d instead of %d", filename, stat.Dev, device)
+	}
+
+	key := hashKey{mountID: mountID, inode: inode, ctime: stat.Ctim.Nano(), size: info.Size()}
+	if value, exists := hr.hashes.Get(key); exists {
+		return value.(string), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.LimitReader(file, maxHashedFileSize)); err != nil {
+		return "", errors.Wrapf(err, "failed to hash %s", filename)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	hr.hashes.Add(key, checksum)
+
+	return checksum, nil
+}
+
+// NewHashResolver returns a new hash resolver
+func NewHashResolver() (*HashResolver, error) {
+	hashes, err := lru.New(hashCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HashResolver{
+		hashes: hashes,
+	}, nil
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestHashResolver(t *testing.T) {
+	hr, err := NewHashResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ioutil.TempFile("", "hash-resolver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	checksum := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	resolve := func() string {
+		info, err := os.Stat(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hash, err := hr.ResolveSHA256(uint32(os.Getpid()), 1, 0, info.Sys().(*syscall.Stat_t).Ino, file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	if err := ioutil.WriteFile(file.Name(), []byte("content1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash := resolve(); hash != checksum("content1") {
+		t.Errorf("unexpected checksum %s", hash)
+	}
+
+	// the modification time of a file can be restored, a file of the same size isn't hashed again if its change
+	// time doesn't change
+	time.Sleep(20 * time.Millisecond)
+	if err := ioutil.WriteFile(file.Name(), []byte("content2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file.Name(), info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	if hash := resolve(); hash != checksum("content2") {
+		t.Errorf("expected the modified file to be hashed again, got %s", hash)
+	}
+
+	// the path of the event points to another file by the time the event is evaluated
+	info, err = os.Stat(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	if _, err := hr.ResolveSHA256(uint32(os.Getpid()), 1, 0, stat.Ino+1, file.Name()); err == nil {
+		t.Error("a file replaced since the event shouldn't be hashed")
+	}
+
+	device := uint32(unix.Major(stat.Dev))<<kernelMinorBits | uint32(unix.Minor(stat.Dev))
+	if _, err := hr.ResolveSHA256(uint32(os.Getpid()), 1, device, stat.Ino, file.Name()); err != nil {
+		t.Errorf("expected the file of the device of the event to be hashed: %s", err)
+	}
+	if _, err := hr.ResolveSHA256(uint32(os.Getpid()), 1, device+1, stat.Ino, file.Name()); err == nil {
+		t.Error("a file of another device shouldn't be hashed")
+	}
+
+	if err := os.Truncate(file.Name(), maxHashedFileSize+1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hr.ResolveSHA256(uint32(os.Getpid()), 1, 0, stat.Ino, file.Name()); err == nil {
+		t.Error("a file larger than the limit shouldn't be hashed")
+	}
+}
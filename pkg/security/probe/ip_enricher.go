@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"net"
+)
+
+// IPEnricher represents an external provider, such as a threat intelligence list, a geolocation
+// database or an inventory, in charge of annotating the IP addresses of events before they are dispatched
+type IPEnricher interface {
+	EnrichIP(ip net.IP) []string
+}
+
+// NoopIPEnricher is the default IP enricher, it doesn't annotate any IP address
+type NoopIPEnricher struct{}
+
+// EnrichIP returns the tags of the given IP address
+func (NoopIPEnricher) EnrichIP(ip net.IP) []string {
+	return nil
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+const (
+	// processAncestorsField is the iterable field of the ancestors of the process of an event
+	processAncestorsField = "process.ancestors"
+
+	// maxProcessAncestors bounds the iteration over the ancestors, the parent pids of the process cache can loop when
+	// pids are reused
+	maxProcessAncestors = 256
+)
+
+// unsupportedAncestorFields lists the fields of the process context that the process cache doesn't hold, they can't be
+// evaluated on the ancestors
+var unsupportedAncestorFields = map[eval.Field]bool{
+	"process.tid":   true,
+	"process.uid":   true,
+	"process.gid":   true,
+	"process.user":  true,
+	"process.group": true,
+	"process.pidns": true,
+	"process.mntns": true,
+	"process.netns": true,
+}
+
+// ProcessAncestorsIterator iterates over the ancestors of the process of an event, from its parent to the init
+// process. The ancestors are looked up in the process cache, their fields are the ones of the process context of an
+// event and are resolved when evaluated only
+type ProcessAncestorsIterator struct {
+	resolvers *Resolvers
+	entry     *ProcessCacheEntry
+	ancestor  Event
+	depth     int
+}
+
+// Front returns the parent of the process of the event
+func (it *ProcessAncestorsIterator) Front(ctx *eval.Context) unsafe.Pointer {
+	event := (*Event)(ctx.Object)
+
+	// the events built to generate the approvers have no resolvers and no ancestors
+	if event.resolvers == nil {
+		return nil
+	}
+
+	it.resolvers = event.resolvers
+	it.entry = it.resolvers.ProcessResolver.Resolve(event.Process.Pid)
+	it.depth = 0
+
+	return it.Next()
+}
+
+// Next returns the parent of the current ancestor
+func (it *ProcessAncestorsIterator) Next() unsafe.Pointer {
+	if it.entry == nil || it.depth >= maxProcessAncestors {
+		return nil
+	}
+
+	pid := it.entry.PPid
+	if pid == 0 {
+		return nil
+	}
+
+	if it.entry = it.resolvers.ProcessResolver.Resolve(pid); it.entry == nil {
+		return nil
+	}
+	it.depth++
+
+	it.ancestor = Event{resolvers: it.resolvers}
+	it.ancestor.Process.setProcessCacheEntry(pid, it.entry)
+
+	return unsafe.Pointer(&it.ancestor)
+}
+
+// GetIterable returns the iterable field of the given field, the fields of the ancestors of a process are the ones of
+// the process context of an event, `process.ancestors.name` being evaluated as `process.name`. The fields missing from
+// the process cache aren't iterable, they are then unknown fields of the model
+func (m *Model) GetIterable(field eval.Field) (eval.Field, eval.Field, bool) {
+	if strings.HasPrefix(field, processAncestorsField+".") {
+		modelField := "process" + strings.TrimPrefix(field, processAncestorsField)
+		if unsupportedAncestorFields[modelField] {
+			return "", "", false
+		}
+		return processAncestorsField, modelField, true
+	}
+	return "", "", false
+}
+
+// NewIterator returns a new iterator over the elements of the given iterable field
+func (m *Model) NewIterator(iterable eval.Field) (eval.Iterator, error) {
+	if iterable == processAncestorsField {
+		return &ProcessAncestorsIterator{}, nil
+	}
+	return nil, &eval.ErrFieldNotFound{Field: iterable}
+}
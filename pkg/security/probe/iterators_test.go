@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestProcessAncestorsFields(t *testing.T) {
+	m := &Model{}
+
+	if iterable, field, ok := m.GetIterable("process.ancestors.file.mode"); !ok || iterable != processAncestorsField || field != "process.file.mode" {
+		t.Errorf("expected process.ancestors.file.mode to be evaluated as process.file.mode, got %s %s", iterable, field)
+	}
+
+	// the uid of the ancestors isn't in the process cache
+	if _, _, ok := m.GetIterable("process.ancestors.uid"); ok {
+		t.Error("process.ancestors.uid shouldn't be iterable")
+	}
+	if _, err := m.GetEvaluator("process.ancestors.uid"); err == nil {
+		t.Error("process.ancestors.uid shouldn't be a field of the model")
+	}
+
+	entry := &ProcessCacheEntry{
+		FileEvent:  FileEvent{Inode: 42, OverlayNumLower: 1},
+		Comm:       "bash",
+		LoginUID:   1000,
+		SessionID:  3,
+		Executable: ExecutableMetadata{Mode: syscall.S_IFREG | syscall.S_ISUID | 0755},
+	}
+
+	var process ProcessEvent
+	process.setProcessCacheEntry(123, entry)
+
+	if process.Pid != 123 || process.Inode != 42 || process.OverlayNumLower != 1 || process.Comm != "bash" {
+		t.Errorf("unexpected process context %+v", process)
+	}
+	if process.AUID != 1000 || process.SessionID != 3 || !process.ExecSetuid || process.ExecSetgid {
+		t.Errorf("unexpected process context %+v", process)
+	}
+}
@@ -0,0 +1,238 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/asm"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// kernel capabilities detected at startup
+const (
+	capLRUHashMap           = "lru_hash_map"
+	capRingBufferMap        = "ring_buffer_map"
+	capProbeReadStrHelper   = "probe_read_str_helper"
+	capGetCurrentTaskHelper = "get_current_task_helper"
+	capRingBufOutputHelper  = "ringbuf_output_helper"
+	capRingBufQueryHelper   = "ringbuf_query_helper"
+	capBPFTrampoline        = "bpf_trampoline"
+	capKernelBTF            = "kernel_btf"
+)
+
+// features of the probe depending on kernel capabilities
+const (
+	featureLRUMaps              = "lru_maps"
+	featureRingBuffers          = "ring_buffers"
+	featureRingBufferWatermarks = "ring_buffer_watermarks"
+	featureFEntryProbes         = "fentry_probes"
+	featureKRetProbeStats       = "kretprobe_stats"
+)
+
+// requiredCapabilities lists the kernel capabilities without which the probe can't run
+var requiredCapabilities = []string{capProbeReadStrHelper, capGetCurrentTaskHelper}
+
+// featureCapabilities lists the kernel capabilities each feature of the probe depends on. A feature whose
+// capabilities are missing is disabled, the probe running without it
+var featureCapabilities = map[string][]string{
+	featureLRUMaps:              {capLRUHashMap},
+	featureRingBuffers:          {capRingBufferMap, capRingBufOutputHelper},
+	featureRingBufferWatermarks: {capRingBufQueryHelper},
+	featureFEntryProbes:         {capBPFTrampoline},
+}
+
+// helperCapabilities lists the helpers whose availability is detected
+var helperCapabilities = map[string]asm.BuiltinFunc{
+	capProbeReadStrHelper:   asm.FnProbeReadStr,
+	capGetCurrentTaskHelper: asm.FnGetCurrentTask,
+	capRingBufOutputHelper:  asm.BuiltinFunc(130),
+	capRingBufQueryHelper:   asm.BuiltinFunc(134),
+}
+
+// KernelCapabilities holds the kernel capabilities detected at startup
+type KernelCapabilities map[string]bool
+
+// isMapTypeSupported returns whether the kernel can create a map of the given type
+func isMapTypeSupported(spec *lib.MapSpec) bool {
+	m, err := lib.NewMap(spec)
+	if err != nil {
+		return false
+	}
+	_ = m.Close()
+	return true
+}
+
+// isHelperSupported returns whether the kernel provides the given helper to the kprobes. The program calls the helper
+// without its arguments: the verifier rejects it either way, the call of an unknown helper being reported as such
+func isHelperSupported(helper asm.BuiltinFunc, kernelVersion uint32) bool {
+	prog, err := lib.NewProgramWithOptions(&lib.ProgramSpec{
+		Type:          lib.Kprobe,
+		License:       "GPL",
+		KernelVersion: kernelVersion,
+		Instructions: asm.Instructions{
+			helper.Call(),
+			asm.Mov.Imm(asm.R0, 0),
+			asm.Return(),
+		},
+	}, lib.ProgramOptions{LogLevel: 1, LogSize: 4096})
+	if err == nil {
+		_ = prog.Close()
+		return true
+	}
+	return !strings.Contains(err.Error(), "invalid func") && !strings.Contains(err.Error(), "unknown func")
+}
+
+// detectKernelCapabilities detects the capabilities of the running kernel. They are probed rather than deduced from the
+// kernel version, the enterprise kernels backporting them to older versions
+func detectKernelCapabilities(kernelVersion uint32, kernelBTF *btfSpec) KernelCapabilities {
+	capabilities := KernelCapabilities{
+		capLRUHashMap: isMapTypeSupported(&lib.MapSpec{
+			Type:       lib.LRUHash,
+			KeySize:    4,
+			ValueSize:  4,
+			MaxEntries: 1,
+		}),
+		capRingBufferMap: isMapTypeSupported(&lib.MapSpec{
+			Type:       ringBufferMapType,
+			MaxEntries: uint32(os.Getpagesize()),
+		}),
+		capBPFTrampoline: isBPFTrampolineSupported(kernelBTF),
+		capKernelBTF:     kernelBTF != nil,
+	}
+
+	for name, helper := range helperCapabilities {
+		capabilities[name] = isHelperSupported(helper, kernelVersion)
+	}
+	return capabilities
+}
+
+// missing returns the given capabilities that the kernel lacks
+func (c KernelCapabilities) missing(capabilities []string) []string {
+	var missing []string
+	for _, capability := range capabilities {
+		if !c[capability] {
+			missing = append(missing, capability)
+		}
+	}
+	return missing
+}
+
+// disabledFeatures returns the features of the probe disabled by the missing capabilities, along with the reason
+func (c KernelCapabilities) disabledFeatures() map[string]string {
+	disabled := make(map[string]string)
+	for feature, capabilities := range featureCapabilities {
+		if missing := c.missing(capabilities); len(missing) > 0 {
+			disabled[feature] = "missing " + strings.Join(missing, ", ")
+		}
+	}
+	return disabled
+}
+
+// detectCapabilities detects the capabilities and the lockdown mode of the kernel, and disables the features depending
+// on the missing capabilities or forbidden by the lockdown. It fails when the kernel lacks a capability required by
+// the probe
+func (p *Probe) detectCapabilities() error {
+	p.lockdown = detectKernelLockdown(lockdownPath, secureBootPath)
+	log.Infof("kernel lockdown mode: %s, secure boot: %t", p.lockdown.Mode, p.lockdown.SecureBoot)
+
+	// the kernel helpers reading the kernel memory are removed, along with the kprobes, in confidentiality mode
+	if p.lockdown.Mode == LockdownConfidentiality {
+		return errors.New("the kernel is locked down in confidentiality mode, it forbids the kprobes and the eBPF programs reading the kernel memory required by the runtime security probe")
+	}
+
+	// the kernel BTF is only needed at startup, to probe the BPF trampolines and read the layout of the kernel
+	kernelBTF, err := loadKernelBTF()
+	if err != nil {
+		log.Debugf("%s, the kernel offsets are guessed from the kernel version", err)
+	}
+	p.capabilities = detectKernelCapabilities(p.kernelVersion, kernelBTF)
+	p.kernelOffsets = detectKernelOffsets(kernelBTF)
+
+	if missing := p.capabilities.missing(requiredCapabilities); len(missing) > 0 {
+		return errors.Errorf("the kernel lacks %s, required by the runtime security probe", strings.Join(missing, ", "))
+	}
+
+	p.disabledFeatures = p.capabilities.disabledFeatures()
+
+	// the lockdown explains why the helpers it forbids are missing
+	for feature, reason := range p.lockdown.disabledFeatures() {
+		p.disabledFeatures[feature] = reason
+	}
+
+	var features []string
+	for feature := range p.disabledFeatures {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	for _, feature := range features {
+		log.Infof("%s disabled, not supported by the kernel: %s", feature, p.disabledFeatures[feature])
+	}
+	return nil
+}
+
+// isFeatureSupported returns whether the kernel supports the given feature of the probe
+func (p *Probe) isFeatureSupported(feature string) bool {
+	_, disabled := p.disabledFeatures[feature]
+	return !disabled
+}
+
+// downgradeLRUMaps replaces the LRU maps by hash maps on the kernels without LRU maps. The full maps reject the new
+// entries instead of evicting the least recently used ones. It has to be called once the map spec editors are set,
+// before the manager is initialized
+func (p *Probe) downgradeLRUMaps(reader io.ReaderAt) error {
+	if p.isFeatureSupported(featureLRUMaps) {
+		return nil
+	}
+
+	spec, err := lib.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the eBPF object")
+	}
+
+	if p.managerOptions.MapSpecEditors == nil {
+		p.managerOptions.MapSpecEditors = make(map[string]manager.MapSpecEditor)
+	}
+
+	for name, mapSpec := range spec.Maps {
+		editor, edited := p.managerOptions.MapSpecEditors[name]
+		if edited && editor.EditorFlag&manager.EditType != 0 {
+			if editor.Type != lib.LRUHash {
+				continue
+			}
+		} else if mapSpec.Type != lib.LRUHash {
+			continue
+		}
+
+		editor.Type = lib.Hash
+		editor.EditorFlag |= manager.EditType
+		p.managerOptions.MapSpecEditors[name] = editor
+
+		log.Debugf("map %s downgraded to a hash map", name)
+	}
+	return nil
+}
+
+// GetKernelCapabilities returns the kernel capabilities detected at startup
+func (p *Probe) GetKernelCapabilities() KernelCapabilities {
+	return p.capabilities
+}
+
+// GetDisabledFeatures returns the features of the probe disabled by the missing kernel capabilities or by the kernel
+// lockdown, along with the reason
+func (p *Probe) GetDisabledFeatures() map[string]string {
+	return p.disabledFeatures
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+)
+
+func TestKernelCapabilities(t *testing.T) {
+	capabilities := KernelCapabilities{
+		capLRUHashMap:           true,
+		capRingBufferMap:        true,
+		capRingBufOutputHelper:  true,
+		capProbeReadStrHelper:   true,
+		capGetCurrentTaskHelper: true,
+	}
+
+	if missing := capabilities.missing(requiredCapabilities); len(missing) != 0 {
+		t.Errorf("no required capability should be missing, got %v", missing)
+	}
+
+	disabled := capabilities.disabledFeatures()
+	for _, feature := range []string{featureRingBufferWatermarks, featureFEntryProbes} {
+		if _, ok := disabled[feature]; !ok {
+			t.Errorf("%s should be disabled", feature)
+		}
+	}
+	for _, feature := range []string{featureLRUMaps, featureRingBuffers} {
+		if reason, ok := disabled[feature]; ok {
+			t.Errorf("%s shouldn't be disabled: %s", feature, reason)
+		}
+	}
+
+	if reason := disabled[featureFEntryProbes]; reason != "missing "+capBPFTrampoline {
+		t.Errorf("unexpected reason: %s", reason)
+	}
+
+	delete(capabilities, capGetCurrentTaskHelper)
+	if missing := capabilities.missing(requiredCapabilities); len(missing) != 1 || missing[0] != capGetCurrentTaskHelper {
+		t.Errorf("expected %s to be missing, got %v", capGetCurrentTaskHelper, missing)
+	}
+}
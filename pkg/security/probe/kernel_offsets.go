@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// KernelOffsets holds the offsets of the members of the private kernel structures read by the probe, read from the
+// kernel BTF. The enterprise kernels backport the changes of these structures, their version doesn't tell their
+// layout. An offset is 0 when the kernel doesn't expose its BTF, until the probe guesses it
+type KernelOffsets struct {
+	// MountID is the offset of mnt_id in struct mount
+	MountID uint32 `json:"mount_id"`
+	// MntNamespaceInum is the offset of the inode number in struct mnt_namespace
+	MntNamespaceInum uint32 `json:"mnt_namespace_inum"`
+}
+
+// detectKernelOffsets reads the offsets of the private kernel structures in the given kernel BTF
+func detectKernelOffsets(kernelBTF *btfSpec) KernelOffsets {
+	var offsets KernelOffsets
+	if kernelBTF == nil {
+		return offsets
+	}
+
+	var err error
+	if offsets.MountID, err = kernelBTF.memberOffset("mount", "mnt_id"); err != nil {
+		log.Debugf("failed to read the offset of the mount id: %s", err)
+	}
+	if offsets.MntNamespaceInum, err = kernelBTF.memberOffset("mnt_namespace", "ns", "inum"); err != nil {
+		log.Debugf("failed to read the offset of the inode number of the mount namespaces: %s", err)
+	}
+	return offsets
+}
+
+// GetKernelOffsets returns the offsets of the private kernel structures read from the kernel BTF at startup
+func (p *Probe) GetKernelOffsets() KernelOffsets {
+	return p.kernelOffsets
+}
+
+// setMntNamespaceOffset sets the offset of the inode number in struct mnt_namespace read by the kernel. Without the
+// kernel BTF, the kernel looks for the inode number of the mount namespace of the agent in its struct mnt_namespace
+func (p *Probe) setMntNamespaceOffset() error {
+	table := p.Map("mntns_inum_offset")
+	if table == nil {
+		return errors.New("map mntns_inum_offset not found")
+	}
+
+	if offset := p.kernelOffsets.MntNamespaceInum; offset != 0 {
+		return table.Put(ebpf.ZeroUint32MapItem, ebpf.Uint32MapItem(offset))
+	}
+
+	inum, err := utils.GetMountNS(uint32(os.Getpid()))
+	if err != nil {
+		return errors.Wrap(err, "failed to read the mount namespace of the agent")
+	}
+
+	erpc, err := NewERPC()
+	if err != nil {
+		return err
+	}
+	defer erpc.Close()
+
+	var request ERPCRequest
+	request.OP = GuessMntNamespaceOffsetOp
+	ebpf.ByteOrder.PutUint32(request.Data[0:4], uint32(inum))
+	if err := erpc.Request(&request); err != nil {
+		return errors.Wrap(err, "eRPC request failed")
+	}
+
+	var offset uint32
+	if err := table.Lookup(ebpf.ZeroUint32MapItem, &offset); err != nil {
+		return errors.Wrap(err, "failed to read the offset of the inode number of the mount namespaces")
+	}
+	if offset == 0 {
+		return errors.Errorf("failed to guess the offset of the inode number of the mount namespaces")
+	}
+
+	log.Debugf("guessed the offset of the inode number of the mount namespaces: %d", offset)
+	p.kernelOffsets.MntNamespaceInum = offset
+	return nil
+}
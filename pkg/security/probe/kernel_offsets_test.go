@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+)
+
+func TestKernelOffsets(t *testing.T) {
+	// struct ns_common { long stashed; void *ops; unsigned int inum; }
+	// struct mnt_namespace { int count; struct ns_common ns; }
+	// struct mount { long hash; struct { int pad; int mnt_id; }; }
+	b := newBTFBuilder()
+	b.addInt("int", 4)
+	b.addInt("long", 8)
+	b.addComposite(btfKindStruct, "ns_common", 24, btfMember{name: "stashed", typeID: 2}, btfMember{name: "ops", typeID: 2, bitOffset: 64}, btfMember{name: "inum", typeID: 1, bitOffset: 128})
+	b.addComposite(btfKindStruct, "mnt_namespace", 32, btfMember{name: "count", typeID: 1}, btfMember{name: "ns", typeID: 3, bitOffset: 64})
+	b.addComposite(btfKindStruct, "", 8, btfMember{name: "pad", typeID: 1}, btfMember{name: "mnt_id", typeID: 1, bitOffset: 32})
+	b.addComposite(btfKindStruct, "mount", 16, btfMember{name: "hash", typeID: 2}, btfMember{typeID: 5, bitOffset: 64})
+
+	kernelBTF, err := parseBTF(b.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offsets := detectKernelOffsets(kernelBTF)
+	if offsets.MntNamespaceInum != 24 {
+		t.Errorf("expected the inode number of the mount namespaces at 24, got %d", offsets.MntNamespaceInum)
+	}
+	if offsets.MountID != 12 {
+		t.Errorf("expected the mount id at 12, got %d", offsets.MountID)
+	}
+
+	if _, err := kernelBTF.memberOffset("mnt_namespace", "ns", "unknown"); err == nil {
+		t.Error("the offset of a missing member should fail")
+	}
+	if _, err := kernelBTF.memberOffset("mnt_namespace", "count", "inum"); err == nil {
+		t.Error("the offset of a member of a scalar should fail")
+	}
+
+	if offsets := detectKernelOffsets(nil); offsets.MountID != 0 || offsets.MntNamespaceInum != 0 {
+		t.Errorf("expected no offset without the kernel BTF, got %+v", offsets)
+	}
+}
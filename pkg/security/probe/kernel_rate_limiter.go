@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"runtime"
+
+	"github.com/DataDog/datadog-go/statsd"
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+)
+
+// kernelRateLimiterConfig holds the rate, in events per second, and the burst of the in-kernel buckets of an event
+// type. The rate and the burst of the buckets of the event types are given per CPU
+type kernelRateLimiterConfig struct {
+	Rate           uint64
+	Burst          uint64
+	ContainerRate  uint64
+	ContainerBurst uint64
+}
+
+// KernelRateLimiterDrops holds the number of events of an event type dropped by the in-kernel rate limiter, by the
+// buckets of the event types and by the ones of the containers
+type KernelRateLimiterDrops struct {
+	Drops          uint64
+	ContainerDrops uint64
+}
+
+// KernelRateLimiter configures the in-kernel token buckets limiting the rate of the events of each event type, and
+// reports the number of events they dropped
+type KernelRateLimiter struct {
+	drops *lib.Map
+	// sent holds the drop counters when they were last sent, the kernel never resets them
+	sent [maxEventType]KernelRateLimiterDrops
+}
+
+// rateLimitedEventTypes returns the event types limited by the in-kernel rate limiter, the file event types except
+// the ones invalidating the dentry cache
+func rateLimitedEventTypes() []EventType {
+	var eventTypes []EventType
+	for _, eventType := range fileEventTypes {
+		if !eventType.invalidatesDentryCache() {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes
+}
+
+// perCPU splits a rate or a burst between the CPUs, rounded up so that a non zero value stays enabled
+func perCPU(value int) uint64 {
+	if value <= 0 {
+		return 0
+	}
+	cpus := runtime.NumCPU()
+	return uint64((value + cpus - 1) / cpus)
+}
+
+// SendStats sends the number of events dropped by the in-kernel rate limiter since the stats were last sent
+func (rl *KernelRateLimiter) SendStats(statsdClient *statsd.Client) error {
+	for _, eventType := range rateLimitedEventTypes() {
+		var drops KernelRateLimiterDrops
+		if err := rl.drops.Lookup(ebpf.Uint32MapItem(eventType), &drops); err != nil {
+			return errors.Wrapf(err, "failed to read the drops of %s", eventType)
+		}
+
+		sent := rl.sent[eventType]
+		rl.sent[eventType] = drops
+
+		tags := []string{"event_type:" + eventType.String()}
+		if count := drops.Drops - sent.Drops; count > 0 {
+			if err := statsdClient.Count(MetricPrefix+".kernel_rate_limiter.drops", int64(count), tags, 1.0); err != nil {
+				return err
+			}
+		}
+		if count := drops.ContainerDrops - sent.ContainerDrops; count > 0 {
+			if err := statsdClient.Count(MetricPrefix+".kernel_rate_limiter.container_drops", int64(count), tags, 1.0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetStats returns the number of events dropped by the in-kernel rate limiter per event type since the probe was
+// started
+func (rl *KernelRateLimiter) GetStats() (map[string]KernelRateLimiterDrops, error) {
+	stats := make(map[string]KernelRateLimiterDrops)
+	for _, eventType := range rateLimitedEventTypes() {
+		var drops KernelRateLimiterDrops
+		if err := rl.drops.Lookup(ebpf.Uint32MapItem(eventType), &drops); err != nil {
+			return nil, errors.Wrapf(err, "failed to read the drops of %s", eventType)
+		}
+		stats[eventType.String()] = drops
+	}
+	return stats, nil
+}
+
+// NewKernelRateLimiter pushes the configuration of the in-kernel rate limiter and returns a new kernel rate limiter
+func NewKernelRateLimiter(manager *manager.Manager, cfg *config.Config) (*KernelRateLimiter, error) {
+	table, ok, err := manager.GetMap("rate_limiter_config")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("map rate_limiter_config not found")
+	}
+
+	// a bucket without burst would drop all the events, it defaults to one second of events
+	burst, containerBurst := cfg.KernelRateLimiterBurst, cfg.KernelRateLimiterContainerBurst
+	if burst <= 0 {
+		burst = cfg.KernelRateLimiterRate
+	}
+	if containerBurst <= 0 {
+		containerBurst = cfg.KernelRateLimiterContainerRate
+	}
+
+	// the buckets of the event types are per CPU, the ones of the containers are shared by all of them
+	rateLimiterConfig := &kernelRateLimiterConfig{
+		Rate:           perCPU(cfg.KernelRateLimiterRate),
+		Burst:          perCPU(burst),
+		ContainerRate:  uint64(cfg.KernelRateLimiterContainerRate),
+		ContainerBurst: uint64(containerBurst),
+	}
+	for _, eventType := range rateLimitedEventTypes() {
+		if err := table.Put(ebpf.Uint32MapItem(eventType), rateLimiterConfig); err != nil {
+			return nil, errors.Wrapf(err, "failed to configure the in-kernel rate limiter of %s", eventType)
+		}
+	}
+
+	drops, ok, err := manager.GetMap("rate_limiter_drops")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("map rate_limiter_drops not found")
+	}
+
+	return &KernelRateLimiter{
+		drops: drops,
+	}, nil
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+)
+
+func TestRateLimitedEventTypes(t *testing.T) {
+	limited := make(map[EventType]bool)
+	for _, eventType := range rateLimitedEventTypes() {
+		limited[eventType] = true
+	}
+
+	// the dentry cache would keep serving the paths of the renamed and removed files
+	for _, eventType := range []EventType{FileRenameEventType, FileUnlinkEventType, FileRmdirEventType, InvalidateDentryEventType} {
+		if limited[eventType] {
+			t.Errorf("%s invalidates the dentry cache, it shouldn't be rate limited", eventType)
+		}
+	}
+
+	for _, eventType := range []EventType{FileOpenEventType, FileMkdirEventType, FileChmodEventType, FileSetXAttrEventType} {
+		if !limited[eventType] {
+			t.Errorf("%s should be rate limited", eventType)
+		}
+	}
+
+	for _, eventType := range []EventType{ExecEventType, ExitEventType} {
+		if limited[eventType] {
+			t.Errorf("%s keeps the process cache in sync, it shouldn't be rate limited", eventType)
+		}
+	}
+}
+
+func TestPerCPU(t *testing.T) {
+	if perCPU(0) != 0 || perCPU(-1) != 0 {
+		t.Error("a null rate should stay disabled")
+	}
+	if perCPU(1) != 1 {
+		t.Error("a non null rate should stay enabled")
+	}
+}
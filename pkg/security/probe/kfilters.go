@@ -38,6 +38,28 @@ func (f *FilterPolicy) Bytes() ([]byte, error) {
 	return []byte{uint8(f.Mode), uint8(f.Flags)}, nil
 }
 
+// mayMatchChildren returns whether the given filename value may match a file of the given directory, or of one of
+// its sub-directories
+func mayMatchChildren(value eval.FieldValue, dirname string) bool {
+	switch value.Type {
+	case eval.ScalarValueType:
+		return strings.HasPrefix(value.Value.(string), dirname)
+	case eval.PatternValueType:
+		// the wildcards of a pattern match any number of directories, only the part before the first one is compared
+		prefix := strings.SplitN(value.Value.(string), "*", 2)[0]
+		dir := dirname + "/"
+		return strings.HasPrefix(prefix, dir) || strings.HasPrefix(dir, prefix)
+	case eval.CaseInsensitivePatternValueType:
+		// the comparison of the case-insensitive patterns is done on the lower case strings
+		prefix := strings.ToLower(strings.SplitN(value.Value.(string), "*", 2)[0])
+		dir := strings.ToLower(dirname) + "/"
+		return strings.HasPrefix(prefix, dir) || strings.HasPrefix(dir, prefix)
+	}
+
+	// the files matched by a regexp or a list can't be determined
+	return true
+}
+
 func isParentPathDiscarder(rs *rules.RuleSet, eventType EventType, filenameField eval.Field, filename string) (bool, error) {
 	dirname := filepath.Dir(filename)
 
@@ -73,7 +95,7 @@ func isParentPathDiscarder(rs *rules.RuleSet, eventType EventType, filenameField
 		// check filename
 		if values := rule.GetFieldValues(filenameField); len(values) > 0 {
 			for _, value := range values {
-				if strings.HasPrefix(value.Value.(string), dirname) {
+				if mayMatchChildren(value, dirname) {
 					return false, nil
 				}
 			}
@@ -8,14 +8,39 @@
 package probe
 
 import (
-	"github.com/pkg/errors"
+	"path"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/security/rules"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+type containerPolicyKey struct {
+	containerID [containerIDLen]byte
+	eventType   EventType
+}
+
+func newContainerPolicyKey(containerID string, eventType eval.EventType) (*containerPolicyKey, error) {
+	key := &containerPolicyKey{
+		eventType: parseEvalEventType(eventType),
+	}
+
+	if key.eventType == UnknownEventType {
+		return nil, errors.New("unable to parse the eval event type")
+	}
+
+	if containerID == "" || len(containerID) > len(key.containerID) {
+		return nil, errors.Errorf("invalid container ID `%s`", containerID)
+	}
+	copy(key.containerID[:], containerID)
+
+	return key, nil
+}
+
 type pidDiscarder struct {
 	eventType EventType
 	pid       uint32
@@ -26,20 +51,25 @@ type pidDiscarderParameters struct {
 	timestamp uint64
 }
 
-func discardPID(probe *Probe, eventType EventType, pid uint32) (bool, error) {
+func discardPID(probe *Probe, eventType EventType, pid uint32, reason discarderReason) (bool, error) {
 	key := pidDiscarder{
 		eventType: eventType,
 		pid:       pid,
 	}
 
-	table := probe.Map("pid_discarders")
-	if err := table.Put(&key, &pidDiscarderParameters{}); err != nil {
+	if err := probe.putDiscarder("pid_discarders", &key, &pidDiscarderParameters{}, reason); err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
 
+// discardProcess discards all the events of the given process, until it executes another binary or exits
+func discardProcess(probe *Probe, pid uint32, reason discarderReason) (bool, error) {
+	// the discarders of the unknown event type apply to all the event types
+	return discardPID(probe, UnknownEventType, pid, reason)
+}
+
 func discardPIDWithTimeout(probe *Probe, eventType EventType, pid uint32, timeout time.Duration) (bool, error) {
 	key := pidDiscarder{
 		eventType: eventType,
@@ -49,8 +79,7 @@ func discardPIDWithTimeout(probe *Probe, eventType EventType, pid uint32, timeou
 		timestamp: uint64(probe.resolvers.TimeResolver.ComputeMonotonicTimestamp(time.Now().Add(timeout))),
 	}
 
-	table := probe.Map("pid_discarders")
-	if err := table.Put(&key, &params); err != nil {
+	if err := probe.putDiscarder("pid_discarders", &key, &params, loadDiscarderReason); err != nil {
 		return false, err
 	}
 
@@ -77,7 +106,7 @@ func removeDiscarderInode(probe *Probe, mountID uint32, inode uint64) {
 	}
 }
 
-func discardInode(probe *Probe, eventType EventType, mountID uint32, inode uint64) (bool, error) {
+func discardInode(probe *Probe, eventType EventType, mountID uint32, inode uint64, reason discarderReason) (bool, error) {
 	key := inodeDiscarder{
 		eventType: eventType,
 		pathKey: PathKey{
@@ -86,8 +115,7 @@ func discardInode(probe *Probe, eventType EventType, mountID uint32, inode uint6
 		},
 	}
 
-	table := probe.Map("inode_discarders")
-	if err := table.Put(&key, ebpf.ZeroUint8MapItem); err != nil {
+	if err := probe.putDiscarder("inode_discarders", &key, ebpf.ZeroUint8MapItem, reason); err != nil {
 		return false, err
 	}
 
@@ -105,20 +133,57 @@ func discardParentInode(probe *Probe, rs *rules.RuleSet, eventType EventType, fi
 		return false, err
 	}
 
-	return discardInode(probe, eventType, parentMountID, parentInode)
+	return discardInode(probe, eventType, parentMountID, parentInode, newParentDiscarderReason(eventType, field, filename))
+}
+
+// snapshotPath snapshots the dentries of an approved path from the filesystem, its events are resolved even if the
+// kernel didn't see them yet
+func snapshotPath(probe *Probe, filename string) {
+	if _, err := probe.resolvers.DentryResolver.SnapshotPath(filename); err != nil {
+		// the file may not exist yet, its dentries will be resolved from the kernel
+		log.Debugf("couldn't snapshot `%s`: %s", filename, err)
+	}
+}
+
+type prefixApprover struct {
+	eventType EventType
+	hash      uint64
+}
+
+// approvePrefix approves the events of the given type on the files below the given directory prefix, and having one
+// of the given flags when some are required
+func approvePrefix(probe *Probe, eventType EventType, prefix string, flags int) error {
+	key := prefixApprover{
+		eventType: eventType,
+		hash:      prefixHash(prefix),
+	}
+
+	return probe.putApprover("prefix_approvers", &key, ebpf.Uint32MapItem(flags))
 }
 
 func approveBasename(probe *Probe, tableName string, basename string) error {
 	key := ebpf.NewStringMapItem(basename, BasenameFilterSize)
+	return probe.putApprover(tableName, key, ebpf.ZeroUint8MapItem)
+}
 
-	table := probe.Map(tableName)
-	if table == nil {
-		return errors.Errorf("map %s not found", tableName)
-	}
-	if err := table.Put(key, ebpf.ZeroUint8MapItem); err != nil {
-		return err
-	}
+type commApprover struct {
+	eventType EventType
+	comm      [CommFilterSize]byte
+}
 
+// approveComms approves the events of the given type of the processes with the given names. The names are truncated
+// as the kernel does
+func approveComms(probe *Probe, eventType EventType, comms ...string) error {
+	for _, comm := range comms {
+		key := commApprover{
+			eventType: eventType,
+		}
+		copy(key.comm[:CommFilterSize-1], comm)
+
+		if err := probe.putApprover("comm_approvers", &key, ebpf.ZeroUint8MapItem); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -131,6 +196,126 @@ func approveBasenames(probe *Probe, tableName string, basenames ...string) error
 	return nil
 }
 
+// inodeApprover identifies the approved files of a directory with a given basename. The key without directory holds
+// the mount of the directories approving the basename
+type inodeApprover struct {
+	eventType EventType
+	parent    PathKey
+	basename  [BasenameFilterSize]byte
+}
+
+// filenameApprovers collects the basenames and the filenames approved for an event type. The files of the approved
+// paths are approved by the inode of their directory, unless their basename is approved in any directory: by a
+// basename approver, or by a filename whose directory couldn't be snapshotted or is on another mount
+type filenameApprovers struct {
+	probe     *Probe
+	eventType EventType
+	// directories holds the snapshotted directories of the approved files, per basename
+	directories  map[string][]PathKey
+	anyDirectory map[string]bool
+}
+
+func newFilenameApprovers(probe *Probe, eventType EventType) *filenameApprovers {
+	return &filenameApprovers{
+		probe:        probe,
+		eventType:    eventType,
+		directories:  make(map[string][]PathKey),
+		anyDirectory: make(map[string]bool),
+	}
+}
+
+// addBasenames approves the given basenames in any directory
+func (fa *filenameApprovers) addBasenames(basenames ...string) {
+	for _, basename := range basenames {
+		fa.anyDirectory[basename] = true
+	}
+}
+
+// addFilenames approves the given files, and snapshots their paths when they already exist
+func (fa *filenameApprovers) addFilenames(filenames ...string) {
+	for _, filename := range filenames {
+		snapshotPath(fa.probe, filename)
+
+		basename := path.Base(filename)
+		directory, err := fa.probe.resolvers.DentryResolver.SnapshotPath(path.Dir(filename))
+		if err != nil {
+			log.Debugf("couldn't snapshot the directory of `%s`, `%s` is approved in any directory: %s", filename, basename, err)
+			fa.anyDirectory[basename] = true
+			continue
+		}
+		fa.directories[basename] = append(fa.directories[basename], directory)
+	}
+}
+
+// basenames returns the approved basenames
+func (fa *filenameApprovers) basenames() []string {
+	var basenames []string
+	for basename := range fa.anyDirectory {
+		basenames = append(basenames, basename)
+	}
+	for basename := range fa.directories {
+		if !fa.anyDirectory[basename] {
+			basenames = append(basenames, basename)
+		}
+	}
+	return basenames
+}
+
+// directoriesMount returns the mount of the given directories, 0 when they aren't on the same mount
+func directoriesMount(directories []PathKey) uint32 {
+	if len(directories) == 0 {
+		return 0
+	}
+
+	mountID := directories[0].MountID
+	for _, directory := range directories {
+		if directory.MountID != mountID {
+			return 0
+		}
+	}
+	return mountID
+}
+
+// approveInodes inserts the approvers of the directories of the basenames whose directories are all known, and on the
+// same mount
+func (fa *filenameApprovers) approveInodes() error {
+	for basename, directories := range fa.directories {
+		if fa.anyDirectory[basename] {
+			continue
+		}
+
+		mountID := directoriesMount(directories)
+		if mountID == 0 {
+			continue
+		}
+
+		key := inodeApprover{eventType: fa.eventType}
+		copy(key.basename[:BasenameFilterSize-1], basename)
+
+		// the directories are inserted before the mount, which enables the check of the basename
+		for _, directory := range directories {
+			key.parent = PathKey{MountID: directory.MountID, Inode: directory.Inode}
+			if err := fa.probe.putApprover("inode_approvers", &key, ebpf.Uint32MapItem(mountID)); err != nil {
+				return err
+			}
+		}
+
+		key.parent = PathKey{}
+		if err := fa.probe.putApprover("inode_approvers", &key, ebpf.Uint32MapItem(mountID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply inserts the basename approvers in the given table, and the approvers of the directories
+func (fa *filenameApprovers) apply(tableName string) error {
+	if err := approveBasenames(fa.probe, tableName, fa.basenames()...); err != nil {
+		return err
+	}
+	return fa.approveInodes()
+}
+
 func setFlagsFilter(probe *Probe, tableName string, flags ...int) error {
 	var flagsItem ebpf.Uint32MapItem
 
@@ -139,11 +324,7 @@ func setFlagsFilter(probe *Probe, tableName string, flags ...int) error {
 	}
 
 	if flagsItem != 0 {
-		table := probe.Map(tableName)
-		if table == nil {
-			return errors.Errorf("map %s not found", tableName)
-		}
-		if err := table.Put(ebpf.ZeroUint32MapItem, flagsItem); err != nil {
+		if err := probe.putFilterValue(tableName, ebpf.ZeroUint32MapItem, flagsItem); err != nil {
 			return err
 		}
 	}
@@ -154,3 +335,113 @@ func setFlagsFilter(probe *Probe, tableName string, flags ...int) error {
 func approveFlags(probe *Probe, tableName string, flags ...int) error {
 	return setFlagsFilter(probe, tableName, flags...)
 }
+
+// approveModes approves the modes having one of the bits of the given modes, a null mode has none of them
+func approveModes(probe *Probe, tableName string, modes ...int) error {
+	for _, mode := range modes {
+		if mode == 0 {
+			return errors.Errorf("a null mode can't be approved by %s", tableName)
+		}
+	}
+	return setFlagsFilter(probe, tableName, modes...)
+}
+
+func stringValues(fvs rules.FilterValues) []string {
+	var values []string
+	for _, v := range fvs {
+		values = append(values, v.Value.(string))
+	}
+	return values
+}
+
+func intValues(fvs rules.FilterValues) []int {
+	var values []int
+	for _, v := range fvs {
+		values = append(values, v.Value.(int))
+	}
+	return values
+}
+
+// flushMap removes all the entries of the given hash map
+func flushMap(probe *Probe, tableName string) error {
+	table := probe.Map(tableName)
+	if table == nil {
+		return errors.Errorf("map %s not found", tableName)
+	}
+
+	// collect the keys first, deleting entries while iterating may skip some of them
+	var keys [][]byte
+	var key interface{}
+	for {
+		next, err := table.NextKeyBytes(key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to iterate map %s", tableName)
+		}
+		if next == nil {
+			break
+		}
+		keys = append(keys, next)
+		key = next
+	}
+
+	for _, key := range keys {
+		// the entries of an LRU map may have been evicted in the meantime
+		_ = table.Delete(key)
+	}
+
+	return nil
+}
+
+// flushFlagsFilter resets a flags filter set by setFlagsFilter
+func flushFlagsFilter(probe *Probe, tableName string) error {
+	table := probe.Map(tableName)
+	if table == nil {
+		return errors.Errorf("map %s not found", tableName)
+	}
+	return table.Put(ebpf.ZeroUint32MapItem, ebpf.ZeroUint32MapItem)
+}
+
+type idRange struct {
+	enabled uint32
+	min     uint32
+	max     uint32
+}
+
+// MarshalBinary returns the binary representation of an idRange
+func (r *idRange) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 12)
+	ebpf.GetHostByteOrder().PutUint32(b[0:4], r.enabled)
+	ebpf.GetHostByteOrder().PutUint32(b[4:8], r.min)
+	ebpf.GetHostByteOrder().PutUint32(b[8:12], r.max)
+	return b, nil
+}
+
+// approveIDRanges sets the uid or gid ranges approved by the given table, the kernel stops at the first disabled
+// entry so that the remaining entries of a previous set of ranges are disabled
+func approveIDRanges(probe *Probe, tableName string, ranges ...eval.IntRange) error {
+	if len(ranges) > IDRangeApproversSize {
+		return errors.Errorf("too many ranges for %s: %d", tableName, len(ranges))
+	}
+
+	for i := 0; i < IDRangeApproversSize; i++ {
+		var item idRange
+		if i < len(ranges) {
+			// the ids are unsigned in the kernel, a negative bound would wrap around
+			if ranges[i].Min < 0 || ranges[i].Max < 0 {
+				return errors.Errorf("negative id range for %s: %d..%d", tableName, ranges[i].Min, ranges[i].Max)
+			}
+			item = idRange{enabled: 1, min: uint32(ranges[i].Min), max: uint32(ranges[i].Max)}
+		}
+
+		if err := probe.putFilterValue(tableName, ebpf.Uint32MapItem(i), &item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flushIDRanges disables the ranges set by approveIDRanges
+func flushIDRanges(probe *Probe, tableName string) error {
+	return approveIDRanges(probe, tableName)
+}
@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+func TestFilenameApprovers(t *testing.T) {
+	fa := newFilenameApprovers(nil, FileOpenEventType)
+	fa.directories["shadow"] = []PathKey{{MountID: 1, Inode: 10}}
+	fa.directories["passwd"] = []PathKey{{MountID: 1, Inode: 10}}
+	fa.addBasenames("passwd", "hosts")
+
+	basenames := fa.basenames()
+	sort.Strings(basenames)
+	if strings.Join(basenames, ",") != "hosts,passwd,shadow" {
+		t.Errorf("unexpected basenames %v", basenames)
+	}
+
+	if mountID := directoriesMount(fa.directories["shadow"]); mountID != 1 {
+		t.Errorf("expected the directories of shadow to be on mount 1, got %d", mountID)
+	}
+
+	// the files of a basename approved on several mounts are approved by their basename only
+	if mountID := directoriesMount([]PathKey{{MountID: 1, Inode: 10}, {MountID: 2, Inode: 20}}); mountID != 0 {
+		t.Errorf("expected no mount, got %d", mountID)
+	}
+}
+
+func TestUserGroupApproversPending(t *testing.T) {
+	p := &Probe{
+		resolvers:          &Resolvers{UserGroupResolver: NewUserGroupResolver()},
+		userGroupApprovers: make(map[eval.EventType]userGroupApprovers),
+	}
+
+	approvers := rules.Approvers{
+		"chown.user": rules.FilterValues{{Field: "chown.user", Value: "nobody", Type: eval.ScalarValueType}},
+	}
+
+	// the approvers of the names that can't be resolved yet are kept to be applied again
+	if err := p.ApplyApprovers("chown", approvers); err == nil {
+		t.Fatal("expected an error for an unknown user")
+	}
+
+	recorded, exists := p.userGroupApprovers["chown"]
+	if !exists || !recorded.pending {
+		t.Fatalf("expected pending approvers, got %+v", p.userGroupApprovers)
+	}
+
+	if recorded.flags != PolicyFlagUID {
+		t.Errorf("unexpected policy flags %d", recorded.flags)
+	}
+}
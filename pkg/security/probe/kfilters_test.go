@@ -9,6 +9,8 @@ package probe
 
 import (
 	"fmt"
+	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/security/rules"
@@ -96,4 +98,340 @@ func TestIsParentDiscarder(t *testing.T) {
 	if is, _ := isParentPathDiscarder(rs, FileRenameEventType, "rename.old.filename", "/etc/nginx/nginx.conf"); !is {
 		t.Fatal("should be a parent discarder")
 	}
+
+	// the wildcard of the pattern matches the files of the sub-directories
+	rs = rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `unlink.filename =~ "/tmp/*.conf"`)
+
+	if is, _ := isParentPathDiscarder(rs, FileUnlinkEventType, "unlink.filename", "/tmp/cache/nginx.log"); is {
+		t.Fatal("shouldn't be a parent discarder")
+	}
+
+	if is, _ := isParentPathDiscarder(rs, FileUnlinkEventType, "unlink.filename", "/var/cache/nginx.conf"); !is {
+		t.Fatal("should be a parent discarder")
+	}
+
+	// the files matched by a regexp can't be determined
+	rs = rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `unlink.filename =~ r"^/tmp/.*\.conf$"`)
+
+	if is, _ := isParentPathDiscarder(rs, FileUnlinkEventType, "unlink.filename", "/var/cache/nginx.log"); is {
+		t.Fatal("shouldn't be a parent discarder")
+	}
+
+	// the case-insensitive patterns are compared in lower case
+	rs = rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `unlink.filename =~ i"/Etc/*"`)
+
+	if is, _ := isParentPathDiscarder(rs, FileUnlinkEventType, "unlink.filename", "/etc/ssh/sshd_config"); is {
+		t.Fatal("shouldn't be a parent discarder")
+	}
+}
+
+func TestChownUserGroupApprovers(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `chown.user in ["root", "daemon"]`, `chown.group == "shadow" && process.uid == 0`)
+
+	approvers, err := rs.GetApprovers("chown", chownCapabilities.GetFieldCapabilities())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["chown.user"]; !exists || len(values) != 2 {
+		t.Fatalf("expected approvers for the user names, got %v", approvers)
+	}
+
+	if values, exists := approvers["chown.group"]; !exists || len(values) != 1 || values[0].Value != "shadow" {
+		t.Fatalf("expected an approver for the group name, got %v", approvers)
+	}
+}
+
+func TestFileApprovers(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `unlink.filename == "/etc/passwd"`, `unlink.basename == "shadow"`)
+
+	approvers, err := rs.GetApprovers("unlink", unlinkCapabilities.GetFieldCapabilities())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["unlink.filename"]; !exists || len(values) != 1 {
+		t.Fatalf("expected an approver for the filename, got %v", approvers)
+	}
+
+	if values, exists := approvers["unlink.basename"]; !exists || len(values) != 1 {
+		t.Fatalf("expected an approver for the basename, got %v", approvers)
+	}
+
+	rs = rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `chmod.filename in ["/etc/passwd", "/etc/shadow"]`, `chmod.mode & S_IWOTH > 0`)
+
+	approvers, err = rs.GetApprovers("chmod", chmodCapabilities.GetFieldCapabilities())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["chmod.filename"]; !exists || len(values) != 2 {
+		t.Fatalf("expected approvers for the filenames, got %v", approvers)
+	}
+
+	if values, exists := approvers["chmod.mode"]; !exists || len(values) != 1 {
+		t.Fatalf("expected an approver for the mode, got %v", approvers)
+	}
+
+	rs = rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `mkdir.filename =~ "/tmp/*"`)
+
+	if _, err = rs.GetApprovers("mkdir", mkdirCapabilities.GetFieldCapabilities()); err == nil {
+		t.Fatal("shouldn't get approvers for a pattern")
+	}
+}
+
+func TestCommApprovers(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	// the patterns without directory prefix can't be approved
+	addRuleExpr(t, rs, `open.filename =~ "/*.conf" && process.name in ["sshd", "sudo"]`, `open.filename =~ "/**/authorized_keys" && process.name == "sshd"`)
+
+	approvers, err := rs.GetApprovers("open", openCapabilities.GetFieldCapabilities())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["process.name"]; !exists || len(values) != 2 {
+		t.Fatalf("expected approvers for the process names, got %v", approvers)
+	}
+}
+
+func TestCombinedOpenApprovers(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs,
+		`open.filename == "/etc/passwd" && open.flags & O_WRONLY > 0`,
+		`open.filename == "/etc/shadow"`,
+		`open.basename == "passwd" && open.flags & O_RDWR > 0`,
+	)
+
+	approvers, err := rs.GetApprovers("open", openCapabilities.GetFieldCapabilities())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	combined := combineOpenApprovers(rs, approvers)
+	if _, exists := combined["open.flags"]; exists {
+		t.Fatalf("the flags should be combined with the names, got %v", combined)
+	}
+
+	expected := map[eval.Field]map[string]int{
+		"open.filename": {"/etc/passwd": syscall.O_WRONLY, "/etc/shadow": 0},
+		"open.basename": {"passwd": syscall.O_RDWR},
+	}
+
+	for field, values := range expected {
+		if len(combined[field]) != len(values) {
+			t.Fatalf("expected %d approvers for %s, got %v", len(values), field, combined)
+		}
+
+		for _, value := range combined[field] {
+			approver := value.Value.(openApprover)
+			if flags, exists := values[approver.Value]; !exists || flags != approver.Flags {
+				t.Errorf("unexpected approver %v for %s", approver, field)
+			}
+		}
+	}
+
+	// a rule without name approver leaves the approvers unchanged
+	rs = rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd"`, `open.flags & O_CREAT > 0`)
+
+	approvers, err = rs.GetApprovers("open", openCapabilities.GetFieldCapabilities())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if combined := combineOpenApprovers(rs, approvers); combined["open.flags"] == nil {
+		t.Fatalf("the approvers shouldn't be combined, got %v", combined)
+	}
+}
+
+func TestPartialOpenApprovers(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs,
+		`(open.basename == "shadow" || open.flags & O_TRUNC > 0) && process.name =~ r"^/usr/bin/.*"`,
+		`open.basename != "passwd" && open.flags & O_CREAT > 0 && process.name =~ r"^/tmp/.*"`,
+	)
+
+	approvers, err := rs.GetApprovers("open", openCapabilities.GetFieldCapabilities())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["open.basename"]; !exists || len(values) != 1 || values[0].Value != "shadow" {
+		t.Fatalf("expected an approver for the basename, got %v", approvers)
+	}
+
+	if values, exists := approvers["open.flags"]; !exists || len(values) != 2 {
+		t.Fatalf("expected approvers for the flags, got %v", approvers)
+	}
+
+	// the basename of the first rule is approved along with its flags, they can't be combined
+	if combined := combineOpenApprovers(rs, approvers); combined["open.flags"] == nil {
+		t.Fatalf("the approvers shouldn't be combined, got %v", combined)
+	}
+}
+
+func TestOpenPrefixApprovers(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs,
+		`open.filename =~ "/etc/*" && open.flags & O_CREAT > 0`,
+		`open.filename =~ "/usr/bin/*"`,
+		`open.filename == "/etc/passwd"`,
+	)
+
+	approvers, err := rs.GetApprovers("open", openCapabilities.GetFieldCapabilities())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	combined := combineOpenApprovers(rs, approvers)
+
+	expected := map[string]openApprover{
+		"/etc/":       {Value: "/etc/", Flags: syscall.O_CREAT, Prefix: true},
+		"/usr/bin/":   {Value: "/usr/bin/", Prefix: true},
+		"/etc/passwd": {Value: "/etc/passwd"},
+	}
+
+	if len(combined["open.filename"]) != len(expected) {
+		t.Fatalf("expected %d filename approvers, got %v", len(expected), combined)
+	}
+	for _, value := range combined["open.filename"] {
+		approver := value.Value.(openApprover)
+		if approver != expected[approver.Value] || approver.Prefix != (value.Type == eval.PatternValueType) {
+			t.Errorf("unexpected approver %v", approver)
+		}
+	}
+
+	if flags := openCapabilities.GetApproversFlags(combined); flags != PolicyFlagBasename|PolicyFlagPrefix {
+		t.Errorf("expected the basename and prefix flags, got %d", flags)
+	}
+
+	// a pattern matching the files of any directory can't be approved by its prefix
+	rs = rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `open.filename =~ "/etc/*"`, `open.filename =~ "/*.conf"`)
+
+	if approvers, err := rs.GetApprovers("open", openCapabilities.GetFieldCapabilities()); err == nil {
+		t.Fatalf("shouldn't get approvers, got %v", approvers)
+	}
+
+	// the prefix of a case-insensitive pattern would drop the files of the directories whose name differs in case
+	rs = rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `open.filename =~ i"/Etc/*"`)
+
+	if approvers, err := rs.GetApprovers("open", openCapabilities.GetFieldCapabilities()); err == nil {
+		t.Fatalf("shouldn't get approvers, got %v", approvers)
+	}
+
+	value := rules.FilterValue{Field: "open.filename", Value: "/Etc/*", Type: eval.CaseInsensitivePatternValueType}
+	if validateOpenFilename(value) {
+		t.Error("a case-insensitive pattern shouldn't be approved")
+	}
+}
+
+func TestPatternPrefix(t *testing.T) {
+	tests := map[string]string{
+		"/etc/*":                               "/etc/",
+		"/etc/ssh/sshd_*":                      "/etc/ssh/",
+		"/usr/**/bin":                          "/usr/",
+		"/var/*/log/*":                         "/var/",
+		"/*":                                   "",
+		"/*/passwd":                            "",
+		"/etc/passwd":                          "",
+		"*/passwd":                             "",
+		"/a/b/c/d/e/f/g/h/i/*":                 "/a/b/c/d/e/f/g/h/i/",
+		"/a/b/c/d/e/f/g/h/i/j/k/l/m/n/o/p/q/*": "",
+	}
+
+	for pattern, expected := range tests {
+		prefix, err := patternPrefix(pattern)
+		if expected == "" {
+			if err == nil {
+				t.Errorf("pattern `%s` shouldn't have a prefix, got `%s`", pattern, prefix)
+			}
+			continue
+		}
+
+		if err != nil || prefix != expected {
+			t.Errorf("expected prefix `%s` for the pattern `%s`, got `%s` (%v)", expected, pattern, prefix, err)
+		}
+	}
+}
+
+func TestPrefixHash(t *testing.T) {
+	// the kernel walks up the directories of a file from its parent, and computes the hashes of all their prefixes
+	// with the inverse of the prime
+	primeInv := uint64(0xce965057aff6957b)
+	if prefixHashPrime*primeInv != 1 {
+		t.Fatal("the inverse of the prime is wrong")
+	}
+
+	dirs := []string{"etc", "ssh", "a-directory-name-longer-than-the-hashed-segment"}
+
+	var total, cumulated [4]uint64
+	var sum uint64
+	weight, power := uint64(1), primeInv
+	for i := len(dirs) - 1; i >= 0; i-- {
+		depth := len(dirs) - 1 - i
+		cumulated[depth] = sum
+		sum += segmentHash(dirs[i]) * weight
+		weight *= primeInv
+		power *= prefixHashPrime
+	}
+	for depth := range dirs {
+		total[depth] = power * (sum - cumulated[depth])
+	}
+
+	for depth := range dirs {
+		prefix := "/" + strings.Join(dirs[:len(dirs)-depth], "/") + "/"
+		if hash := prefixHash(prefix); hash != total[depth] {
+			t.Errorf("the hash of `%s` isn't aligned with the kernel hash: %x != %x", prefix, hash, total[depth])
+		}
+	}
+
+	if prefixHash("/etc/") == prefixHash("/ssh/") || prefixHash("/etc/ssh/") == prefixHash("/ssh/etc/") {
+		t.Error("the hashes should depend on the names and the order of the directories")
+	}
+}
+
+func TestOpenDecisionTable(t *testing.T) {
+	rs := rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs,
+		`open.filename == "/etc/passwd" && process.name == "cat"`,
+		`open.filename =~ "/etc/*" && open.flags & O_CREAT > 0 && process.uid == 0`,
+	)
+
+	table, err := rs.GetDecisionTable("open", openPreEvalCapabilities)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pattern of the second rule can't be pre-evaluated, it matches any filename
+	if len(table) != 2 {
+		t.Fatalf("expected 2 entries, got %v", table)
+	}
+	for i, fields := range [][]eval.Field{{"open.filename", "process.name"}, {"open.flags", "process.uid"}} {
+		if len(table[i]) != len(fields) {
+			t.Fatalf("expected the fields %v in entry %d, got %v", fields, i, table[i])
+		}
+		for j, field := range fields {
+			if table[i][j].Field != field {
+				t.Errorf("expected the field %s in entry %d, got %v", field, i, table[i])
+			}
+		}
+	}
+
+	// the rules testing a field unknown in kernel can't be pre-evaluated
+	rs = rules.NewRuleSet(&Model{}, func() eval.Event { return &Event{} }, rules.NewOptsWithParams(SECLConstants, nil))
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd"`, `open.filename == "/etc/shadow" && process.filename == "/usr/bin/vim"`)
+
+	if _, err := rs.GetDecisionTable("open", openPreEvalCapabilities); err == nil {
+		t.Error("expected an error for the process filename")
+	}
 }
@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// killSignals maps the signals a rule can send to the process of a matching event, see rules.KillSignals
+var killSignals = map[string]syscall.Signal{
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGSTOP": syscall.SIGSTOP,
+}
+
+// agentExecutables lists the executables of the agent, installed in the same directory as the system-probe
+var agentExecutables = []string{"agent", "process-agent", "security-agent", "system-probe", "trace-agent"}
+
+// Killer sends the signal of the kill action of a rule to the process of the matching event. The init process,
+// the processes of the agent and the allowlisted executables are never signaled.
+type Killer struct {
+	enabled    bool
+	selfPid    uint32
+	agentPaths map[string]bool
+	allowlist  map[string]bool
+	killFnc    func(pid int, sig syscall.Signal) error
+	// startTimeFnc returns the start time of a process in nanoseconds since boot, checked against the time of the
+	// event so that a reused pid isn't signaled
+	startTimeFnc func(pid uint32) (uint64, error)
+}
+
+// NewKiller returns a new Killer
+func NewKiller(cfg *config.Config) *Killer {
+	allowlist := make(map[string]bool)
+	for _, path := range cfg.KillAllowlist {
+		allowlist[path] = true
+	}
+
+	var agentPaths map[string]bool
+	if executable, err := os.Executable(); err == nil {
+		agentPaths = getAgentPaths(executable)
+	}
+
+	return &Killer{
+		enabled:      cfg.EnforcementEnabled,
+		selfPid:      uint32(os.Getpid()),
+		agentPaths:   agentPaths,
+		allowlist:    allowlist,
+		killFnc:      syscall.Kill,
+		startTimeFnc: utils.PidStartTime,
+	}
+}
+
+// getAgentPaths returns the paths of the executables of the agent, given the one of the running process. Only the
+// known executables are returned, the agent may be installed in a directory shared with other executables
+func getAgentPaths(executable string) map[string]bool {
+	executables := []string{executable}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil && resolved != executable {
+		executables = append(executables, resolved)
+	}
+
+	paths := make(map[string]bool)
+	for _, executable := range executables {
+		paths[executable] = true
+		for _, name := range agentExecutables {
+			paths[filepath.Join(filepath.Dir(executable), name)] = true
+		}
+	}
+	return paths
+}
+
+// Kill sends the given signal to the process of the event. When the enforcement is disabled, the signal is only
+// logged.
+func (k *Killer) Kill(event *Event, signal string) error {
+	sig, ok := killSignals[signal]
+	if !ok {
+		return fmt.Errorf("unsupported signal `%s`", signal)
+	}
+
+	var get func(pid uint32) *ProcessCacheEntry
+	if event.resolvers != nil {
+		get = event.resolvers.ProcessResolver.Resolve
+	}
+
+	pid, path := event.Process.Pid, event.Process.ResolveInode(event.resolvers)
+	if pid <= 1 || k.isAgentProcess(pid, path, get) {
+		return fmt.Errorf("process %d can't be killed", pid)
+	}
+
+	if k.allowlist[path] {
+		return fmt.Errorf("process %d is allowlisted: %s", pid, path)
+	}
+
+	if !k.enabled {
+		log.Debugf("enforcement disabled, %s not sent to process %d", signal, pid)
+		return nil
+	}
+
+	if err := k.checkStartTime(pid, event.TimestampRaw); err != nil {
+		return err
+	}
+
+	log.Debugf("sending %s to process %d", signal, pid)
+	return k.killFnc(int(pid), sig)
+}
+
+// checkStartTime returns an error when the given process started after the given time of an event, in nanoseconds
+// since boot: the process of the event exited and its pid was reused. The start time is read with the boot clock
+// while the time of the events excludes the time the host was suspended, a process is then wrongly seen as reused
+// rather than signaled in error
+func (k *Killer) checkStartTime(pid uint32, eventTime uint64) error {
+	if eventTime == 0 {
+		return nil
+	}
+
+	startTime, err := k.startTimeFnc(pid)
+	if err != nil {
+		return fmt.Errorf("failed to check process %d: %w", pid, err)
+	}
+	if startTime > eventTime {
+		return fmt.Errorf("process %d exited, its pid was reused", pid)
+	}
+	return nil
+}
+
+// isAgentProcess returns whether the given process is the agent, runs one of its executables or descends from it,
+// looking up its ancestors with the given function
+func (k *Killer) isAgentProcess(pid uint32, path string, get func(pid uint32) *ProcessCacheEntry) bool {
+	if pid == k.selfPid || k.agentPaths[path] {
+		return true
+	}
+
+	if get == nil {
+		return false
+	}
+	entry := get(pid)
+	return entry != nil && hasAncestor(entry, get, func(pid uint32, _ *ProcessCacheEntry) bool {
+		return pid == k.selfPid
+	})
+}
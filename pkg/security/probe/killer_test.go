@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func TestKiller(t *testing.T) {
+	var killed []int
+
+	killer := NewKiller(&config.Config{
+		EnforcementEnabled: true,
+		KillAllowlist:      []string{"/usr/sbin/sshd"},
+	})
+	killer.killFnc = func(pid int, sig syscall.Signal) error {
+		if sig != syscall.SIGKILL {
+			t.Errorf("unexpected signal %s", sig)
+		}
+		killed = append(killed, pid)
+		return nil
+	}
+
+	newEvent := func(pid uint32, path string) *Event {
+		event := &Event{}
+		event.Process.Pid = pid
+		event.Process.PathnameStr = path
+		return event
+	}
+
+	if err := killer.Kill(newEvent(1234, "/usr/bin/nc"), "SIGKILL"); err != nil {
+		t.Fatal(err)
+	}
+
+	killer.agentPaths = getAgentPaths("/opt/datadog-agent/embedded/bin/system-probe")
+	guarded := []*Event{
+		newEvent(1, "/sbin/init"),
+		newEvent(uint32(os.Getpid()), "/opt/datadog-agent/embedded/bin/system-probe"),
+		newEvent(2345, "/opt/datadog-agent/embedded/bin/process-agent"),
+		newEvent(4321, "/usr/sbin/sshd"),
+	}
+
+	for _, event := range guarded {
+		if err := killer.Kill(event, "SIGKILL"); err == nil {
+			t.Errorf("process %d shouldn't be killed", event.Process.Pid)
+		}
+	}
+
+	if err := killer.Kill(newEvent(1234, "/usr/bin/nc"), "SIGUNKNOWN"); err == nil {
+		t.Error("expected an error for an unsupported signal")
+	}
+
+	killer.enabled = false
+	if err := killer.Kill(newEvent(5678, "/usr/bin/nc"), "SIGKILL"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(killed) != 1 || killed[0] != 1234 {
+		t.Errorf("unexpected killed processes: %v", killed)
+	}
+}
+
+func TestKillerReusedPid(t *testing.T) {
+	var killed []int
+
+	killer := NewKiller(&config.Config{EnforcementEnabled: true})
+	killer.killFnc = func(pid int, sig syscall.Signal) error {
+		killed = append(killed, pid)
+		return nil
+	}
+	startTimes := map[uint32]uint64{1234: 1000, 2345: 3000}
+	killer.startTimeFnc = func(pid uint32) (uint64, error) {
+		startTime, exists := startTimes[pid]
+		if !exists {
+			return 0, errors.New("no such process")
+		}
+		return startTime, nil
+	}
+
+	newEvent := func(pid uint32, timestamp uint64) *Event {
+		event := &Event{TimestampRaw: timestamp}
+		event.Process.Pid = pid
+		event.Process.PathnameStr = "/usr/bin/nc"
+		return event
+	}
+
+	if err := killer.Kill(newEvent(1234, 2000), "SIGKILL"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the process started after the event reuses the pid of the process of the event
+	if err := killer.Kill(newEvent(2345, 2000), "SIGKILL"); err == nil {
+		t.Error("a process started after the event shouldn't be killed")
+	}
+	if err := killer.Kill(newEvent(3456, 2000), "SIGKILL"); err == nil {
+		t.Error("an exited process shouldn't be killed")
+	}
+
+	if len(killed) != 1 || killed[0] != 1234 {
+		t.Errorf("unexpected killed processes: %v", killed)
+	}
+}
+
+func TestKillerAgentPaths(t *testing.T) {
+	killer := NewKiller(&config.Config{})
+
+	// the agent runs from a directory shared with other executables
+	killer.agentPaths = getAgentPaths("/usr/bin/system-probe")
+
+	for path, expected := range map[string]bool{
+		"/usr/bin/system-probe":   true,
+		"/usr/bin/security-agent": true,
+		"/usr/bin/agent":          true,
+		"/usr/bin/nc":             false,
+		"/usr/sbin/system-probe":  false,
+	} {
+		if isAgent := killer.isAgentProcess(1234, path, nil); isAgent != expected {
+			t.Errorf("expected %s to be an agent executable: %v, got %v", path, expected, isAgent)
+		}
+	}
+}
+
+func TestKillerAgentDescendants(t *testing.T) {
+	killer := NewKiller(&config.Config{})
+
+	// 300 is a child of the agent, 301 one of its children. 400 is a sibling of the agent
+	selfPid := uint32(os.Getpid())
+	entries := map[uint32]*ProcessCacheEntry{
+		300:     {PPid: selfPid},
+		301:     {PPid: 300},
+		400:     {PPid: 1},
+		selfPid: {PPid: 1},
+	}
+	get := func(pid uint32) *ProcessCacheEntry {
+		return entries[pid]
+	}
+
+	for pid, expected := range map[uint32]bool{300: true, 301: true, 400: false, 500: false} {
+		if isAgent := killer.isAgentProcess(pid, "/usr/bin/sh", get); isAgent != expected {
+			t.Errorf("expected process %d to be an agent process: %v, got %v", pid, expected, isAgent)
+		}
+	}
+}
+
+func TestKillSignals(t *testing.T) {
+	for name := range rules.KillSignals {
+		if _, ok := killSignals[name]; !ok {
+			t.Errorf("signal %s can't be sent", name)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+)
+
+// kprobeProfilePath is the path of the hits and misses of the kprobes
+const kprobeProfilePath = "/sys/kernel/debug/tracing/kprobe_profile"
+
+// parseKRetProbeMisses returns the missed returns of the kretprobes of the given kprobe_profile, per function. Only the
+// kretprobes whose event name ends with the given suffix are returned, as named by the manager
+func parseKRetProbeMisses(r io.Reader, suffix string) (map[string]int64, error) {
+	misses := make(map[string]int64)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || !strings.HasPrefix(fields[0], "r_") || !strings.HasSuffix(fields[0], suffix) {
+			continue
+		}
+
+		missed, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid misses of %s", fields[0])
+		}
+
+		function := strings.TrimSuffix(strings.TrimPrefix(fields[0], "r_"), suffix)
+		misses[function] += missed
+	}
+
+	return misses, scanner.Err()
+}
+
+// getKRetProbeMisses returns the missed returns of the kretprobes of the probe since they were attached, per function
+func (p *Probe) getKRetProbeMisses() (map[string]int64, error) {
+	f, err := os.Open(kprobeProfilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseKRetProbeMisses(f, manager.SanitizeEventName(fmt.Sprintf("_%s_%d", probes.SecurityAgentUID, os.Getpid())))
+}
+
+// sendKRetProbeMisses sends the returns missed by the kretprobes since the last call, per function
+func (p *Probe) sendKRetProbeMisses(statsdClient *statsd.Client) error {
+	if !p.isFeatureSupported(featureKRetProbeStats) {
+		return nil
+	}
+
+	misses, err := p.getKRetProbeMisses()
+	if err != nil {
+		// debugfs may not be mounted, the misses can't be reported
+		return nil
+	}
+
+	for function, missed := range misses {
+		if delta := missed - p.kretprobeMisses[function]; delta > 0 {
+			if err := statsdClient.Count(MetricPrefix+".kretprobe.missed", delta, []string{"function:" + function}, 1.0); err != nil {
+				return err
+			}
+		}
+	}
+	p.kretprobeMisses = misses
+
+	return nil
+}
@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKRetProbeMisses(t *testing.T) {
+	profile := `  p_vfs_open_security_42                               120                0
+  r___x64_sys_open_security_42                          80               12
+  r_get_task_exe_file_security_42                       10                3
+  r_get_task_exe_file_security_43                       10                5
+  r_tcp_sendmsg_network_42                             900                7
+`
+
+	misses, err := parseKRetProbeMisses(strings.NewReader(profile), "_security_42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(misses) != 2 || misses["__x64_sys_open"] != 12 || misses["get_task_exe_file"] != 3 {
+		t.Errorf("unexpected misses: %v", misses)
+	}
+
+	if _, err := parseKRetProbeMisses(strings.NewReader("r_vfs_open_security_42 1 x\n"), "_security_42"); err == nil {
+		t.Error("expected an error on invalid misses")
+	}
+}
@@ -10,6 +10,7 @@ package probe
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -30,12 +31,15 @@ type LoadController struct {
 	sync.RWMutex
 	probe        *Probe
 	total        int64
+	lost         int64
+	perEventType [maxEventType]int64
 	counters     *simplelru.LRU
 	statsdClient *statsd.Client
 
 	EventsCountThreshold int64
 	DiscarderTimeout     time.Duration
 	ControllerPeriod     time.Duration
+	LostEventsThreshold  int64
 }
 
 // NewLoadController instantiates a new load controller
@@ -52,6 +56,7 @@ func NewLoadController(probe *Probe, statsdClient *statsd.Client) (*LoadControll
 		EventsCountThreshold: probe.config.LoadControllerEventsCountThreshold,
 		DiscarderTimeout:     probe.config.LoadControllerDiscarderTimeout,
 		ControllerPeriod:     probe.config.LoadControllerControlPeriod,
+		LostEventsThreshold:  probe.config.LoadControllerLostEventsThreshold,
 	}
 	return lc, nil
 }
@@ -69,6 +74,7 @@ func (lc *LoadController) Count(eventType EventType, pid uint32) {
 		count := uint64(1)
 		lc.counters.Add(eventCounterLRUKey{Pid: pid, Event: eventType}, &count)
 	}
+	atomic.AddInt64(&lc.perEventType[eventType], 1)
 	newTotal := atomic.AddInt64(&lc.total, 1)
 
 	if newTotal >= lc.EventsCountThreshold {
@@ -76,8 +82,14 @@ func (lc *LoadController) Count(eventType EventType, pid uint32) {
 	}
 }
 
-// discardNoisiestProcess determines the noisiest process and event_type tuple and pushes a temporary discarder
-func (lc *LoadController) discardNoisiestProcess() {
+// CountLost increments the counter of lost events
+func (lc *LoadController) CountLost(count int64) {
+	atomic.AddInt64(&lc.lost, count)
+}
+
+// discardNoisiestProcess determines the noisiest process and event_type tuple and pushes a temporary discarder, it
+// returns the discarded tuple
+func (lc *LoadController) discardNoisiestProcess() (eventCounterLRUKey, bool) {
 	// iterate over the LRU map to retrieve the noisiest process & event_type tuple
 	var maxKey eventCounterLRUKey
 	var maxCount *uint64
@@ -97,14 +109,14 @@ func (lc *LoadController) discardNoisiestProcess() {
 	}
 	if maxCount == nil {
 		// LRU is empty nothing to discard
-		return
+		return maxKey, false
 	}
 
 	// push a temporary discarder on the noisiest process & event type tuple
 	log.Tracef("discarding %s events from pid %d for %s seconds", maxKey.Event, maxKey.Pid, lc.DiscarderTimeout)
 	if _, err := discardPIDWithTimeout(lc.probe, maxKey.Event, maxKey.Pid, lc.DiscarderTimeout); err != nil {
 		log.Warnf("couldn't insert temporary discarder: %v", err)
-		return
+		return maxKey, false
 	}
 
 	// update current total and remove biggest entry from cache
@@ -117,9 +129,73 @@ func (lc *LoadController) discardNoisiestProcess() {
 		}
 		if err := lc.statsdClient.Count(MetricPrefix+".load_controller.pids_discarder", 1, tags, 1.0); err != nil {
 			log.Warnf("couldn't send load_controller.pids_discarder metric: %v", err)
-			return
 		}
 	}
+
+	return maxKey, true
+}
+
+// tightenFilters tightens the in-kernel filters when too many events were lost during the last period: the noisiest
+// event type left in accept mode is switched to its approvers, and the noisiest process is discarded
+func (lc *LoadController) tightenFilters() {
+	lost := atomic.LoadInt64(&lc.lost)
+	if lc.LostEventsThreshold <= 0 || lost < lc.LostEventsThreshold {
+		return
+	}
+
+	var eventTypes []EventType
+	counts := make(map[EventType]int64)
+	for eventType := UnknownEventType + 1; eventType != maxEventType; eventType++ {
+		if count := atomic.LoadInt64(&lc.perEventType[eventType]); count > 0 {
+			eventTypes = append(eventTypes, eventType)
+			counts[eventType] = count
+		}
+	}
+	sort.Slice(eventTypes, func(i, j int) bool {
+		return counts[eventTypes[i]] > counts[eventTypes[j]]
+	})
+
+	// a single event type is switched per period, the other ones may not be needed anymore
+	for _, eventType := range eventTypes {
+		applied, err := lc.probe.applyStandbyApprovers(eventType.String())
+		if err != nil {
+			log.Warnf("couldn't apply the approvers of `%s`: %v", eventType, err)
+			continue
+		}
+
+		if applied {
+			lc.sendEvent("Runtime security filters tightened",
+				fmt.Sprintf("%d events lost, %d `%s` events received: the in-kernel filter policy of `%s` was switched to its approvers", lost, counts[eventType], eventType, eventType),
+				[]string{fmt.Sprintf("event_type:%s", eventType)})
+			break
+		}
+	}
+
+	lc.Lock()
+	key, discarded := lc.discardNoisiestProcess()
+	lc.Unlock()
+
+	if discarded {
+		lc.sendEvent("Runtime security filters tightened",
+			fmt.Sprintf("%d events lost: the `%s` events of pid %d are discarded for %s", lost, key.Event, key.Pid, lc.DiscarderTimeout),
+			[]string{fmt.Sprintf("event_type:%s", key.Event)})
+	}
+}
+
+// sendEvent logs the given tightening of the filters and sends it as a Datadog event
+func (lc *LoadController) sendEvent(title string, text string, tags []string) {
+	log.Warn(text)
+
+	if lc.statsdClient == nil {
+		return
+	}
+
+	event := statsd.NewEvent(title, text)
+	event.AlertType = statsd.Warning
+	event.Tags = tags
+	if err := lc.statsdClient.Event(event); err != nil {
+		log.Warnf("couldn't send the load controller event: %v", err)
+	}
 }
 
 // cleanup resets the internal counters
@@ -137,9 +213,13 @@ func (lc *LoadController) cleanup() {
 		atomic.SwapUint64(counter, 0)
 	}
 	atomic.SwapInt64(&lc.total, 0)
+	atomic.SwapInt64(&lc.lost, 0)
+	for i := range lc.perEventType {
+		atomic.SwapInt64(&lc.perEventType[i], 0)
+	}
 }
 
-// Start resets the internal counters periodically
+// Start tightens the in-kernel filters if needed and resets the internal counters periodically
 func (lc *LoadController) Start(ctx context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -150,6 +230,7 @@ func (lc *LoadController) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			lc.tightenFilters()
 			lc.cleanup()
 		case <-ctx.Done():
 			return
@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	// lockdownPath is the securityfs file of the kernel lockdown mode
+	lockdownPath = "/sys/kernel/security/lockdown"
+	// secureBootPath is the EFI variable of the secure boot state
+	secureBootPath = "/sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+)
+
+// LockdownMode is a lockdown mode of the kernel
+type LockdownMode string
+
+// Lockdown modes of the kernel
+const (
+	LockdownUnknown LockdownMode = "unknown"
+	LockdownNone    LockdownMode = "none"
+	// LockdownIntegrity forbids eBPF programs to write to user memory, and restricts debugfs
+	LockdownIntegrity LockdownMode = "integrity"
+	// LockdownConfidentiality additionally forbids kprobes, perf events and eBPF programs reading kernel memory
+	LockdownConfidentiality LockdownMode = "confidentiality"
+)
+
+// KernelLockdown describes the lockdown restrictions of the kernel detected at startup
+type KernelLockdown struct {
+	Mode       LockdownMode `json:"mode"`
+	SecureBoot bool         `json:"secure_boot"`
+}
+
+// lockdownFeatures lists the features of the probe forbidden by the integrity lockdown mode
+var lockdownFeatures = []string{featureKRetProbeStats}
+
+// parseLockdownMode returns the selected mode of the given lockdown file, written like `none [integrity] confidentiality`
+func parseLockdownMode(content string) LockdownMode {
+	for _, mode := range strings.Fields(content) {
+		if strings.HasPrefix(mode, "[") && strings.HasSuffix(mode, "]") {
+			return LockdownMode(strings.Trim(mode, "[]"))
+		}
+	}
+	return LockdownUnknown
+}
+
+// parseSecureBoot returns whether the given SecureBoot EFI variable is set. The variable starts with 4 bytes of
+// attributes
+func parseSecureBoot(content []byte) bool {
+	return len(content) >= 5 && content[4] == 1
+}
+
+// detectKernelLockdown detects the lockdown mode of the kernel and the secure boot state
+func detectKernelLockdown(lockdownPath, secureBootPath string) KernelLockdown {
+	lockdown := KernelLockdown{Mode: LockdownUnknown}
+
+	if content, err := ioutil.ReadFile(lockdownPath); err == nil {
+		lockdown.Mode = parseLockdownMode(string(content))
+	}
+	if content, err := ioutil.ReadFile(secureBootPath); err == nil {
+		lockdown.SecureBoot = parseSecureBoot(content)
+	}
+
+	return lockdown
+}
+
+// effectiveMode returns the mode the probe runs with. Without securityfs, the kernels booted with secure boot are
+// assumed to be locked down in integrity mode, as most distributions do
+func (l KernelLockdown) effectiveMode() LockdownMode {
+	if l.Mode == LockdownUnknown && l.SecureBoot {
+		return LockdownIntegrity
+	}
+	return l.Mode
+}
+
+// disabledFeatures returns the features of the probe forbidden by the lockdown, along with the reason
+func (l KernelLockdown) disabledFeatures() map[string]string {
+	disabled := make(map[string]string)
+
+	mode := l.effectiveMode()
+	if mode != LockdownIntegrity && mode != LockdownConfidentiality {
+		return disabled
+	}
+
+	reason := "forbidden by the kernel lockdown in " + string(mode) + " mode"
+	if l.Mode == LockdownUnknown {
+		reason += ", assumed from secure boot"
+	}
+	for _, feature := range lockdownFeatures {
+		disabled[feature] = reason
+	}
+	return disabled
+}
+
+// GetKernelLockdown returns the lockdown restrictions of the kernel detected at startup
+func (p *Probe) GetKernelLockdown() KernelLockdown {
+	return p.lockdown
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestParseLockdownMode(t *testing.T) {
+	tests := map[string]LockdownMode{
+		"[none] integrity confidentiality\n": LockdownNone,
+		"none [integrity] confidentiality\n": LockdownIntegrity,
+		"none integrity [confidentiality]\n": LockdownConfidentiality,
+		"":                                   LockdownUnknown,
+	}
+
+	for content, expected := range tests {
+		if mode := parseLockdownMode(content); mode != expected {
+			t.Errorf("expected %s for `%s`, got %s", expected, content, mode)
+		}
+	}
+}
+
+func TestDetectKernelLockdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lockdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	secureBoot := path.Join(dir, "SecureBoot")
+	if err := ioutil.WriteFile(secureBoot, []byte{0x06, 0, 0, 0, 1}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// without securityfs, the lockdown is assumed from secure boot
+	lockdown := detectKernelLockdown(path.Join(dir, "lockdown"), secureBoot)
+	if lockdown.Mode != LockdownUnknown || !lockdown.SecureBoot {
+		t.Fatalf("unexpected lockdown: %+v", lockdown)
+	}
+	if _, disabled := lockdown.disabledFeatures()[featureKRetProbeStats]; !disabled {
+		t.Error("the kretprobe stats should be disabled under secure boot")
+	}
+
+	if err := ioutil.WriteFile(path.Join(dir, "lockdown"), []byte("[none] integrity confidentiality\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lockdown = detectKernelLockdown(path.Join(dir, "lockdown"), secureBoot)
+	if lockdown.Mode != LockdownNone || len(lockdown.disabledFeatures()) != 0 {
+		t.Errorf("no feature should be disabled without lockdown: %+v", lockdown)
+	}
+}
@@ -0,0 +1,428 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"io"
+	"strings"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// probeFamily is a family of probes loaded, started and stopped by its own manager. The manager of the core family
+// holds the maps and the perf maps, the managers of the other families use them
+type probeFamily struct {
+	name    string
+	manager *manager.Manager
+	options manager.Options
+	running bool
+	// err holds the failure of the last initialization or start of the family
+	err error
+}
+
+// ProbeFamilyStatus describes the manager of a family of probes
+type ProbeFamilyStatus struct {
+	Running bool   `json:"running"`
+	Error   string `json:"error,omitempty"`
+}
+
+// familySections returns the family of each probe section
+func familySections() map[string]string {
+	sections := make(map[string]string)
+	for _, family := range probes.ProbeFamilies {
+		for _, probe := range probes.FamilyProbes(family) {
+			sections[probe.Section] = family
+		}
+	}
+	return sections
+}
+
+// sectionFamily returns the family of the given section, the sections of no family belonging to the core one
+func sectionFamily(sections map[string]string, section string) string {
+	if family, exists := sections[section]; exists {
+		return family
+	}
+	return probes.CoreFamily
+}
+
+// splitSelectors returns the given selectors per family. The selectors of an event type are split, the other selectors
+// have to select the probes of a single family
+func splitSelectors(selectors []manager.ProbesSelector, sections map[string]string) (map[string][]manager.ProbesSelector, error) {
+	split := make(map[string][]manager.ProbesSelector)
+	for _, selector := range selectors {
+		if s, ok := selector.(*eventTypeSelector); ok {
+			inner, err := splitSelectors(s.selectors, sections)
+			if err != nil {
+				return nil, err
+			}
+			for family, familySelectors := range inner {
+				split[family] = append(split[family], newEventTypeSelector(s.eventType, familySelectors))
+			}
+			continue
+		}
+
+		var family string
+		for _, id := range selector.GetProbesIdentificationPairList() {
+			idFamily := sectionFamily(sections, id.Section)
+			if family != "" && family != idFamily {
+				return nil, errors.Errorf("the probes of %s belong to both the %s and the %s families", id, family, idFamily)
+			}
+			family = idFamily
+		}
+		if family == "" {
+			family = probes.CoreFamily
+		}
+		split[family] = append(split[family], selector)
+	}
+	return split, nil
+}
+
+// familyOptions returns the options of the manager of the given family, the programs of the other families being left
+// out of its collection. The managers of the families other than the core one use the maps of the core one
+func (p *Probe) familyOptions(family string, spec *lib.CollectionSpec, sections map[string]string, selectors []manager.ProbesSelector) (manager.Options, error) {
+	options := p.managerOptions
+	options.ActivatedProbes = selectors
+	options.ExcludedSections = append([]string{}, p.managerOptions.ExcludedSections...)
+	for section := range spec.Programs {
+		if sectionFamily(sections, section) != family {
+			options.ExcludedSections = append(options.ExcludedSections, section)
+		}
+	}
+
+	if family == probes.CoreFamily {
+		return options, nil
+	}
+
+	// the core manager routes the tail calls and sizes the maps, shared with the other families
+	options.TailCallRouter = nil
+	options.MapSpecEditors = nil
+
+	maps, err := p.sharedMaps(spec)
+	if err != nil {
+		return options, err
+	}
+	options.MapEditors = maps
+
+	return options, nil
+}
+
+// sharedMaps returns the maps of the core manager, used by the managers of the other families in place of their own.
+// The data sections hold the constants of each manager, they aren't shared
+func (p *Probe) sharedMaps(spec *lib.CollectionSpec) (map[string]*lib.Map, error) {
+	maps := make(map[string]*lib.Map)
+	for name := range spec.Maps {
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if m, exists := p.managerOptions.MapEditors[name]; exists {
+			maps[name] = m
+			continue
+		}
+
+		m, exists, err := p.manager.GetMap(name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists || m == nil {
+			return nil, errors.Errorf("map %s not found", name)
+		}
+		maps[name] = m
+	}
+	return maps, nil
+}
+
+// initManagers initializes the manager of each family of probes, the core one first. When isolated, the failure of
+// a family other than the core one leaves it stopped, the other families running without it. The families without
+// any selected probe aren't loaded
+func (p *Probe) initManagers(reader io.ReaderAt, isolated bool) error {
+	spec, err := lib.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return err
+	}
+
+	if err := p.preparePinnedMaps(spec); err != nil {
+		return errors.Wrap(err, "failed to check the pinned maps")
+	}
+
+	p.familySections = familySections()
+	selectors, err := splitSelectors(p.managerOptions.ActivatedProbes, p.familySections)
+	if err != nil {
+		return err
+	}
+
+	p.families = nil
+	for _, name := range probes.ProbeFamilies {
+		if len(p.managerOptions.ActivatedProbes) > 0 && len(selectors[name]) == 0 {
+			log.Debugf("no %s probe selected, the family isn't loaded", name)
+			continue
+		}
+
+		family := &probeFamily{name: name, manager: p.manager}
+		if name != probes.CoreFamily {
+			family.manager = ebpf.NewRuntimeSecurityFamilyManager(name)
+		}
+
+		if family.options, err = p.familyOptions(name, spec, p.familySections, selectors[name]); err == nil {
+			err = family.manager.InitWithOptions(reader, family.options)
+		}
+		if err != nil {
+			p.reportLoadFailure(name, reader, err)
+			err = errors.Wrapf(err, "failed to initialize the %s probes", name)
+			if name == probes.CoreFamily || !isolated {
+				p.stopFamilies()
+				return err
+			}
+			log.Errorf("%s, its events won't be sent", err)
+			family.err = err
+		}
+		p.families = append(p.families, family)
+	}
+
+	p.bytecodeReader = reader
+	return nil
+}
+
+// resetManager replaces the core manager by a new one reading the same perf maps with the same handlers, the event
+// streams reading them are updated
+func (p *Probe) resetManager() {
+	previous := p.manager
+	p.manager = ebpf.NewRuntimeSecurityManager()
+
+	// the ring buffers are read in place of the perf maps
+	if previous.PerfMaps == nil {
+		p.manager.PerfMaps = nil
+		return
+	}
+
+	options := make(map[string]manager.PerfMapOptions)
+	for _, perfMap := range previous.PerfMaps {
+		options[perfMap.Name] = perfMap.PerfMapOptions
+	}
+
+	perfMaps := make(map[string]*manager.PerfMap)
+	for _, perfMap := range p.manager.PerfMaps {
+		perfMap.PerfMapOptions = options[perfMap.Name]
+		perfMaps[perfMap.Name] = perfMap
+	}
+
+	for _, stream := range p.eventStreams {
+		if s, ok := stream.(*perfMapStream); ok && perfMaps[s.perfMap.Name] != nil {
+			s.perfMap = perfMaps[s.perfMap.Name]
+		}
+	}
+}
+
+// stopFamilies stops the initialized managers of the families other than the core one, the core manager holding the
+// maps is stopped on its own
+func (p *Probe) stopFamilies() {
+	for i := len(p.families) - 1; i >= 0; i-- {
+		family := p.families[i]
+		if family.name == probes.CoreFamily || family.err != nil {
+			continue
+		}
+
+		// the families stopped at runtime are already stopped
+		if err := family.manager.Stop(manager.CleanInternal); err != nil && err != manager.ErrManagerNotInitialized {
+			log.Warnf("failed to stop the %s probes: %s", family.name, err)
+		}
+		family.running = false
+	}
+}
+
+// startManagers starts the managers of the families, the core one first. The failure of a family other than the core
+// one leaves it stopped, the other families running without it
+func (p *Probe) startManagers() error {
+	for _, family := range p.families {
+		if family.err != nil {
+			continue
+		}
+
+		if err := p.startFamily(family); err != nil {
+			if family.name == probes.CoreFamily {
+				return err
+			}
+			log.Errorf("%s, its events won't be sent", err)
+		}
+	}
+	return nil
+}
+
+// startFamily starts the manager of a family
+func (p *Probe) startFamily(family *probeFamily) error {
+	if err := family.manager.Start(); err != nil {
+		family.err = errors.Wrapf(err, "failed to start the %s probes", family.name)
+		return family.err
+	}
+	family.running = true
+	family.err = nil
+	return nil
+}
+
+// getFamily returns the loaded family of the given name
+func (p *Probe) getFamily(name string) (*probeFamily, error) {
+	for _, family := range p.families {
+		if family.name == name {
+			return family, nil
+		}
+	}
+	return nil, errors.Errorf("unknown probe family `%s`", name)
+}
+
+// managerOf returns the manager of the family of the given probe, nil if the family isn't running. The caller holds
+// the lock of the families once the probe is started
+func (p *Probe) managerOf(id manager.ProbeIdentificationPair) *manager.Manager {
+	family, err := p.getFamily(sectionFamily(p.familySections, id.Section))
+	if err != nil || !family.running {
+		return nil
+	}
+	return family.manager
+}
+
+// familyProbes returns the probes of the running managers
+func (p *Probe) familyProbes() []*manager.Probe {
+	var all []*manager.Probe
+	for _, family := range p.families {
+		if family.running {
+			all = append(all, family.manager.Probes...)
+		}
+	}
+	return all
+}
+
+// activatedProbes returns the activated selectors of the loaded families
+func (p *Probe) activatedProbes() []manager.ProbesSelector {
+	var selectors []manager.ProbesSelector
+	for _, family := range p.families {
+		if family.err != nil {
+			// the event types of a failed family are all degraded
+			for _, selector := range family.options.ActivatedProbes {
+				if s, ok := selector.(*eventTypeSelector); ok {
+					s.degraded = &DegradedEventType{EventType: s.eventType, Error: family.err.Error()}
+				}
+			}
+		}
+		selectors = append(selectors, family.options.ActivatedProbes...)
+	}
+	return selectors
+}
+
+// StopProbeFamily stops the probes of the given family, the events of the family aren't sent anymore. The core family
+// holds the maps of all the families, it can't be stopped on its own
+func (p *Probe) StopProbeFamily(name string) error {
+	if name == probes.CoreFamily {
+		return errors.New("the core probes can't be stopped on their own")
+	}
+
+	p.eventTypeSwitchLock.Lock()
+	defer p.eventTypeSwitchLock.Unlock()
+	p.familiesLock.Lock()
+	defer p.familiesLock.Unlock()
+
+	family, err := p.getFamily(name)
+	if err != nil {
+		return err
+	}
+	if !family.running {
+		return nil
+	}
+
+	if err := family.manager.Stop(manager.CleanInternal); err != nil {
+		return errors.Wrapf(err, "failed to stop the %s probes", name)
+	}
+	family.running = false
+
+	// the hooked probes of the family were closed along with the manager
+	p.eventTypeSwitch.forget(func(id manager.ProbeIdentificationPair) bool {
+		return sectionFamily(p.familySections, id.Section) == name
+	})
+
+	log.Infof("%s probes stopped", name)
+	return nil
+}
+
+// StartProbeFamily loads and starts again the probes of a stopped family, or of a family that failed. The probes of
+// the event types disabled at runtime are detached again
+func (p *Probe) StartProbeFamily(name string) error {
+	p.eventTypeSwitchLock.Lock()
+	defer p.eventTypeSwitchLock.Unlock()
+	p.familiesLock.Lock()
+	defer p.familiesLock.Unlock()
+
+	family, err := p.getFamily(name)
+	if err != nil {
+		return err
+	}
+	if family.running {
+		return nil
+	}
+
+	// a stopped manager can't be initialized again, a new one loads the programs of the family
+	family.manager = ebpf.NewRuntimeSecurityFamilyManager(name)
+	if err := family.manager.InitWithOptions(p.bytecodeReader, family.options); err != nil {
+		family.err = errors.Wrapf(err, "failed to initialize the %s probes", name)
+		return family.err
+	}
+	if err := p.startFamily(family); err != nil {
+		return err
+	}
+
+	p.eventTypeSwitch.forget(func(id manager.ProbeIdentificationPair) bool {
+		return sectionFamily(p.familySections, id.Section) == name
+	})
+	for eventType := range p.eventTypeSwitch.disabled {
+		if err := p.eventTypeSwitch.detach(p.managerOf, eventType); err != nil {
+			log.Errorf("failed to detach the probes of `%s` again: %s", eventType, err)
+		}
+	}
+
+	// the new manager activates the probes of the selectors only, the kprobes replacing a failed fentry or fexit
+	// program are attached again
+	for _, fp := range p.fentryProbes {
+		if fp.linkFD < 0 && sectionFamily(p.familySections, fp.replaced.Section) == name {
+			if err := p.attachReplacedProbe(fp); err != nil {
+				log.Errorf("%s", err)
+			}
+		}
+	}
+
+	p.degradedEventTypes = degradedEventTypes(p.activatedProbes())
+
+	log.Infof("%s probes started", name)
+	return nil
+}
+
+// RestartProbeFamily stops the probes of the given family and starts them again
+func (p *Probe) RestartProbeFamily(name string) error {
+	if err := p.StopProbeFamily(name); err != nil {
+		return err
+	}
+	return p.StartProbeFamily(name)
+}
+
+// GetProbeFamilies returns the status of the loaded families of probes
+func (p *Probe) GetProbeFamilies() map[string]ProbeFamilyStatus {
+	p.familiesLock.Lock()
+	defer p.familiesLock.Unlock()
+
+	statuses := make(map[string]ProbeFamilyStatus)
+	for _, family := range p.families {
+		status := ProbeFamilyStatus{Running: family.running}
+		if family.err != nil {
+			status.Error = family.err.Error()
+		}
+		statuses[family.name] = status
+	}
+	return statuses
+}
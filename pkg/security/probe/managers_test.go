@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/DataDog/ebpf/manager"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+)
+
+func TestProbeFamilies(t *testing.T) {
+	families := make(map[string]string)
+	for _, family := range probes.ProbeFamilies {
+		for _, probe := range probes.FamilyProbes(family) {
+			if other, exists := families[probe.Section]; exists && other != family {
+				t.Errorf("%s belongs to both the %s and the %s families", probe.Section, other, family)
+			}
+			families[probe.Section] = family
+		}
+	}
+
+	if len(probes.AllProbes()) == 0 || len(families) == 0 {
+		t.Fatal("expected the probes of the families")
+	}
+}
+
+func TestSplitSelectors(t *testing.T) {
+	sections := familySections()
+
+	var selectors []manager.ProbesSelector
+	for eventType, eventTypeSelectors := range probes.SelectorsPerEventType {
+		if eventType == "*" {
+			selectors = append(selectors, eventTypeSelectors...)
+		} else {
+			selectors = append(selectors, newEventTypeSelector(eventType, eventTypeSelectors))
+		}
+	}
+
+	split, err := splitSelectors(selectors, sections)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for family, familySelectors := range split {
+		for _, id := range selectorsIDs(familySelectors) {
+			if sectionFamily(sections, id.Section) != family {
+				t.Errorf("%s selected by the %s family", id, family)
+			}
+		}
+	}
+
+	for _, family := range probes.ProbeFamilies {
+		if len(split[family]) == 0 {
+			t.Errorf("expected selectors for the %s family", family)
+		}
+	}
+
+	// a selector can't span families
+	mixed := &manager.AllOf{Selectors: []manager.ProbesSelector{
+		&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: probes.SecurityAgentUID, Section: "kprobe/do_exit"}},
+		&manager.ProbeSelector{ProbeIdentificationPair: manager.ProbeIdentificationPair{UID: probes.SecurityAgentUID, Section: "kprobe/vfs_truncate"}},
+	}}
+	if _, err := splitSelectors([]manager.ProbesSelector{mixed}, sections); err == nil {
+		t.Error("expected an error for a selector spanning families")
+	}
+}
+
+func TestDegradedEventTypesMerged(t *testing.T) {
+	process := newEventTypeSelector("exec", nil)
+	process.degraded = &DegradedEventType{EventType: "exec", Error: "process"}
+	filesystem := newEventTypeSelector("exec", nil)
+	filesystem.degraded = &DegradedEventType{EventType: "exec", Error: "filesystem"}
+
+	degraded := degradedEventTypes([]manager.ProbesSelector{process, filesystem})
+	if len(degraded) != 1 || degraded[0].Error != "process | filesystem" {
+		t.Errorf("expected the failures of the families to be merged, got %+v", degraded)
+	}
+}
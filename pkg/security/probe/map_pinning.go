@@ -0,0 +1,460 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// pinnedMapsSchemaFile is the file of the pinning directory describing the layout of the pinned maps
+	pinnedMapsSchemaFile = "schema.json"
+	// pinnedDiscardersFile is the file of the pinning directory holding the reasons of the pinned discarders
+	pinnedDiscardersFile = "discarders.json"
+)
+
+// volatilePinnedMaps lists the pinned maps flushed on restart: while no program was attached, a discarded pid may have
+// been reused and a cached dentry renamed. The entries of the process cache are overwritten by the snapshot of /proc
+var volatilePinnedMaps = []string{"pid_discarders", "pathnames"}
+
+// pinnedMapSchema describes the layout of a pinned map, checked against the map of the loaded object on restart
+type pinnedMapSchema struct {
+	Version    int         `json:"version"`
+	Type       lib.MapType `json:"type"`
+	KeySize    uint32      `json:"key_size"`
+	ValueSize  uint32      `json:"value_size"`
+	MaxEntries uint32      `json:"max_entries"`
+	Flags      uint32      `json:"flags"`
+}
+
+func newPinnedMapSchema(abi lib.MapABI) pinnedMapSchema {
+	return pinnedMapSchema{
+		Version:    probes.PinnedMapsSchemaVersion,
+		Type:       abi.Type,
+		KeySize:    abi.KeySize,
+		ValueSize:  abi.ValueSize,
+		MaxEntries: abi.MaxEntries,
+		Flags:      abi.Flags,
+	}
+}
+
+// pinnedMapState is what became of a pinned map when the probe started
+type pinnedMapState string
+
+const (
+	// pinnedMapCreated is a map pinned for the first time
+	pinnedMapCreated pinnedMapState = "created"
+	// pinnedMapReused is a pinned map used as is
+	pinnedMapReused pinnedMapState = "reused"
+	// pinnedMapMigrated is a pinned map whose entries were copied to a new map, its size or its flags having changed
+	pinnedMapMigrated pinnedMapState = "migrated"
+	// pinnedMapDiscarded is a pinned map replaced by an empty one, the layout of its entries having changed
+	pinnedMapDiscarded pinnedMapState = "discarded"
+)
+
+// checkPinnedMap returns what becomes of a pinned map of the given schema, nil when unknown, given the schema of the
+// map of the loaded object
+func checkPinnedMap(pinned *pinnedMapSchema, expected pinnedMapSchema) pinnedMapState {
+	if pinned == nil || pinned.Version != expected.Version || pinned.Type != expected.Type ||
+		pinned.KeySize != expected.KeySize || pinned.ValueSize != expected.ValueSize {
+		return pinnedMapDiscarded
+	}
+
+	if pinned.MaxEntries != expected.MaxEntries || pinned.Flags != expected.Flags {
+		return pinnedMapMigrated
+	}
+	return pinnedMapReused
+}
+
+// expectedMapABI returns the layout of the given map once edited by the given editor
+func expectedMapABI(spec *lib.MapSpec, editor manager.MapSpecEditor) lib.MapABI {
+	abi := lib.MapABI{
+		Type:       spec.Type,
+		KeySize:    spec.KeySize,
+		ValueSize:  spec.ValueSize,
+		MaxEntries: spec.MaxEntries,
+		Flags:      spec.Flags,
+	}
+	if editor.EditorFlag&manager.EditType != 0 {
+		abi.Type = editor.Type
+	}
+	if editor.EditorFlag&manager.EditMaxEntries != 0 {
+		abi.MaxEntries = editor.MaxEntries
+	}
+	if editor.EditorFlag&manager.EditFlags != 0 {
+		abi.Flags = editor.Flags
+	}
+	return abi
+}
+
+// isBPFFS returns whether the given directory is on the BPF filesystem
+func isBPFFS(dir string) bool {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return false
+	}
+	return uint32(stat.Type) == uint32(unix.BPF_FS_MAGIC)
+}
+
+// readPinnedMapsSchema returns the layout of the pinned maps of the given directory
+func readPinnedMapsSchema(dir string) (map[string]pinnedMapSchema, error) {
+	schema := make(map[string]pinnedMapSchema)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, pinnedMapsSchemaFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return schema, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, errors.Wrapf(err, "invalid schema of the pinned maps")
+	}
+	return schema, nil
+}
+
+// writePinnedMapsSchema writes the layout of the pinned maps to the given directory
+func writePinnedMapsSchema(dir string, schema map[string]pinnedMapSchema) error {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, pinnedMapsSchemaFile), data, 0600)
+}
+
+// preparePinnedMaps checks the maps pinned by a previous run against the maps of the given object, before the
+// managers are initialized. The pinned maps of the same layout are reused by the manager, the entries of the ones
+// whose size or flags changed are copied to new maps once loaded, the other ones are discarded. When the map pinning
+// is disabled, the maps pinned by a previous run are removed
+func (p *Probe) preparePinnedMaps(spec *lib.CollectionSpec) error {
+	dir := p.config.MapPinningDir
+	if !p.config.MapPinningEnabled {
+		if _, err := os.Stat(filepath.Join(dir, pinnedMapsSchemaFile)); err == nil {
+			log.Infof("map pinning disabled, removing the maps pinned to %s", dir)
+			if err := os.RemoveAll(dir); err != nil {
+				log.Warnf("failed to remove the pinned maps: %s", err)
+			}
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Warnf("failed to create the pinning directory, the maps aren't pinned: %s", err)
+		return nil
+	}
+	if !isBPFFS(dir) {
+		log.Warnf("%s isn't on the BPF filesystem, the maps aren't pinned", dir)
+		return nil
+	}
+
+	schema, err := readPinnedMapsSchema(dir)
+	if err != nil {
+		log.Warnf("failed to read the schema of the pinned maps, discarding them: %s", err)
+	}
+
+	if p.pinnedMaps == nil {
+		p.pinnedMaps = make(map[string]pinnedMapState)
+	}
+	if p.migratedMaps == nil {
+		p.migratedMaps = make(map[string]*lib.Map)
+	}
+
+	pinPaths := make(map[string]string)
+	for _, name := range probes.PinnedMaps {
+		mapSpec, exists := spec.Maps[name]
+		if !exists {
+			continue
+		}
+		pinPath := filepath.Join(dir, name)
+		pinPaths[name] = pinPath
+
+		// the map was checked against another object, before the programs were compiled on the host
+		if _, checked := p.pinnedMaps[name]; checked {
+			continue
+		}
+
+		if _, err := os.Stat(pinPath); os.IsNotExist(err) {
+			p.pinnedMaps[name] = pinnedMapCreated
+			continue
+		}
+
+		pinned, err := lib.LoadPinnedMap(pinPath)
+		if err != nil {
+			log.Debugf("failed to load the pinned map %s, discarding it: %s", name, err)
+			if err := os.Remove(pinPath); err != nil {
+				return errors.Wrapf(err, "failed to remove the pinned map %s", name)
+			}
+			p.pinnedMaps[name] = pinnedMapDiscarded
+			continue
+		}
+
+		var pinnedSchema *pinnedMapSchema
+		if s, exists := schema[name]; exists && s.Version == probes.PinnedMapsSchemaVersion {
+			// the layout of the pinned map is read from the kernel, the schema only tells its version
+			s = newPinnedMapSchema(pinned.ABI())
+			pinnedSchema = &s
+		}
+
+		state := checkPinnedMap(pinnedSchema, newPinnedMapSchema(expectedMapABI(mapSpec, p.managerOptions.MapSpecEditors[name])))
+		switch state {
+		case pinnedMapReused:
+			// the manager loads the pinned map in place of a new one
+			pinned.Close()
+		case pinnedMapMigrated:
+			// the pinned map is kept open until its entries are copied
+			p.migratedMaps[name] = pinned
+		default:
+			pinned.Close()
+		}
+
+		if state != pinnedMapReused {
+			if err := os.Remove(pinPath); err != nil {
+				return errors.Wrapf(err, "failed to remove the pinned map %s", name)
+			}
+		}
+		p.pinnedMaps[name] = state
+		log.Debugf("pinned map %s %s", name, state)
+	}
+
+	for _, m := range p.manager.Maps {
+		if pinPath, pinned := pinPaths[m.Name]; pinned {
+			m.PinPath = pinPath
+		}
+	}
+	return nil
+}
+
+// migratePinnedMaps copies the entries of the maps pinned by a previous run whose size or flags changed to the maps of
+// the loaded object. The entries past the size of a smaller map are dropped
+func (p *Probe) migratePinnedMaps() {
+	for name, pinned := range p.migratedMaps {
+		table := p.Map(name)
+		if table == nil {
+			pinned.Close()
+			continue
+		}
+
+		var copied int
+		var key, value []byte
+		entries := pinned.Iterate()
+		for entries.Next(&key, &value) {
+			if err := table.Put(key, value); err != nil {
+				log.Debugf("failed to copy the entries of the pinned map %s: %s", name, err)
+				break
+			}
+			copied++
+		}
+		if err := entries.Err(); err != nil {
+			log.Debugf("failed to iterate the pinned map %s: %s", name, err)
+		}
+		pinned.Close()
+
+		log.Infof("%d entries of the pinned map %s migrated", copied, name)
+	}
+	p.migratedMaps = nil
+}
+
+// initPinnedMaps completes the pinning of the maps once the managers are initialized: the entries of the migrated
+// maps are copied, the reasons of the inherited discarders restored and checked against the files and the given
+// ruleset, the maps that can't be trusted flushed, and the schema of the maps written for the next run
+func (p *Probe) initPinnedMaps(rs *rules.RuleSet) error {
+	if !p.config.MapPinningEnabled || len(p.pinnedMaps) == 0 {
+		return nil
+	}
+	dir := p.config.MapPinningDir
+
+	p.migratePinnedMaps()
+
+	if err := p.restoreDiscarders(filepath.Join(dir, pinnedDiscardersFile)); err != nil {
+		log.Warnf("failed to restore the reasons of the pinned discarders: %s", err)
+	}
+
+	if err := p.flushVolatilePinnedMaps(); err != nil {
+		return err
+	}
+
+	// the discarded inodes may have been removed and reused while no program was attached
+	if err := p.validatePinnedInodeDiscarders(); err != nil {
+		return errors.Wrap(err, "failed to check the pinned inode discarders")
+	}
+
+	// the discarders inherited from the previous run could discard events matching the new rules
+	if err := p.RevalidateDiscarders(rs); err != nil {
+		return errors.Wrap(err, "failed to check the pinned discarders")
+	}
+
+	schema := make(map[string]pinnedMapSchema)
+	for name := range p.pinnedMaps {
+		if table := p.Map(name); table != nil {
+			schema[name] = newPinnedMapSchema(table.ABI())
+		}
+	}
+	return writePinnedMapsSchema(dir, schema)
+}
+
+// flushVolatilePinnedMaps removes the entries inherited from the previous run of the maps that can't be trusted once
+// the programs were detached
+func (p *Probe) flushVolatilePinnedMaps() error {
+	for _, name := range volatilePinnedMaps {
+		if state := p.pinnedMaps[name]; state != pinnedMapReused && state != pinnedMapMigrated {
+			continue
+		}
+
+		if err := flushMap(p, name); err != nil {
+			return err
+		}
+		p.discarderRegistry.purge(name)
+		log.Debugf("entries of the pinned map %s flushed", name)
+	}
+	return nil
+}
+
+// isDiscardedInode returns whether the file of the given reason of an inode discarder is still the given inode, the
+// inode of a parent discarder being the one of the parent directory of the file
+func isDiscardedInode(reason discarderReason, inode uint64) bool {
+	filename := reason.value
+	if reason.kind == parentDiscarderKind {
+		filename = path.Dir(filename)
+	}
+	if !path.IsAbs(filename) {
+		return false
+	}
+
+	info, err := os.Stat(path.Join(utils.ProcRootPath(1), filename))
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Ino == inode
+}
+
+// validatePinnedInodeDiscarders removes the inode discarders inherited from the previous run whose file doesn't
+// resolve to the discarded inode anymore, as well as the ones whose reason is unknown
+func (p *Probe) validatePinnedInodeDiscarders() error {
+	if state := p.pinnedMaps["inode_discarders"]; state != pinnedMapReused && state != pinnedMapMigrated {
+		return nil
+	}
+
+	table := p.Map("inode_discarders")
+	if table == nil {
+		return errors.New("map inode_discarders not found")
+	}
+
+	keys, err := mapKeys(table)
+	if err != nil {
+		return err
+	}
+
+	var removed int
+	for _, key := range keys {
+		// the key starts with the event type, followed by the inode
+		valid := len(key) >= 16
+		reasons := p.discarderRegistry.reasons("inode_discarders", key)
+		if len(reasons) == 0 {
+			valid = false
+		}
+		for _, reason := range reasons {
+			if !valid {
+				break
+			}
+			valid = isDiscardedInode(reason, ebpf.ByteOrder.Uint64(key[8:16]))
+		}
+		if valid {
+			continue
+		}
+
+		if err := table.Delete(key); err != nil && !errors.Is(err, lib.ErrKeyNotExist) {
+			return err
+		}
+		p.discarderRegistry.remove("inode_discarders", key)
+		removed++
+	}
+
+	log.Debugf("%d pinned inode discarders removed, their files changed", removed)
+	return nil
+}
+
+// mapsCleanup returns the maps removed when the probe is closed. The pinned maps are kept for the next run
+func (p *Probe) mapsCleanup() manager.MapCleanupType {
+	if !p.config.MapPinningEnabled || len(p.pinnedMaps) == 0 {
+		return manager.CleanAll
+	}
+	return manager.CleanAll &^ (manager.CleanInternalPinned | manager.CleanExternalPinned | manager.CleanExternalPinnedAndEdited)
+}
+
+// savePinnedDiscarders writes the reasons of the discarders to the pinning directory, so that the next run can check
+// the pinned discarders against its ruleset
+func (p *Probe) savePinnedDiscarders() error {
+	if !p.config.MapPinningEnabled || len(p.pinnedMaps) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(p.discarderRegistry.save())
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(p.config.MapPinningDir, pinnedDiscardersFile), data, 0600)
+}
+
+// restoreDiscarders registers the reasons of the discarders saved to the given file. The file is removed, the
+// reasons being saved again when the probe is closed
+func (p *Probe) restoreDiscarders(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(file)
+
+	var discarders []savedDiscarder
+	if err := json.Unmarshal(data, &discarders); err != nil {
+		return errors.Wrap(err, "invalid discarders")
+	}
+
+	for _, discarder := range discarders {
+		table := p.Map(discarder.Table)
+		if table == nil {
+			continue
+		}
+
+		for _, reason := range discarder.Reasons {
+			if err := p.discarderRegistry.register(discarder.Table, int(table.ABI().MaxEntries), discarder.Key, reason.discarderReason()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetPinnedMaps returns what became of the pinned maps when the probe started
+func (p *Probe) GetPinnedMaps() map[string]string {
+	states := make(map[string]string)
+	for name, state := range p.pinnedMaps {
+		states[name] = string(state)
+	}
+	return states
+}
@@ -0,0 +1,183 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"syscall"
+	"testing"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/manager"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+)
+
+func TestCheckPinnedMap(t *testing.T) {
+	expected := pinnedMapSchema{Version: probes.PinnedMapsSchemaVersion, Type: lib.LRUHash, KeySize: 8, ValueSize: 64, MaxEntries: 4096}
+
+	for _, test := range []struct {
+		name     string
+		edit     func(schema *pinnedMapSchema)
+		expected pinnedMapState
+	}{
+		{name: "same", edit: func(schema *pinnedMapSchema) {}, expected: pinnedMapReused},
+		{name: "resized", edit: func(schema *pinnedMapSchema) { schema.MaxEntries = 1024 }, expected: pinnedMapMigrated},
+		{name: "flags", edit: func(schema *pinnedMapSchema) { schema.Flags = 1 }, expected: pinnedMapMigrated},
+		{name: "version", edit: func(schema *pinnedMapSchema) { schema.Version-- }, expected: pinnedMapDiscarded},
+		{name: "downgraded", edit: func(schema *pinnedMapSchema) { schema.Type = lib.Hash }, expected: pinnedMapDiscarded},
+		{name: "value", edit: func(schema *pinnedMapSchema) { schema.ValueSize = 72 }, expected: pinnedMapDiscarded},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			pinned := expected
+			test.edit(&pinned)
+			if state := checkPinnedMap(&pinned, expected); state != test.expected {
+				t.Errorf("expected the pinned map to be %s, got %s", test.expected, state)
+			}
+		})
+	}
+
+	if state := checkPinnedMap(nil, expected); state != pinnedMapDiscarded {
+		t.Errorf("expected a pinned map of unknown schema to be discarded, got %s", state)
+	}
+}
+
+func TestExpectedMapABI(t *testing.T) {
+	spec := &lib.MapSpec{Type: lib.LRUHash, KeySize: 8, ValueSize: 64, MaxEntries: 4096}
+
+	abi := expectedMapABI(spec, manager.MapSpecEditor{Type: lib.Hash, MaxEntries: 1024, EditorFlag: manager.EditMaxEntries})
+	if abi.Type != lib.LRUHash || abi.MaxEntries != 1024 {
+		t.Errorf("unexpected ABI: %+v", abi)
+	}
+
+	abi = expectedMapABI(spec, manager.MapSpecEditor{Type: lib.Hash, EditorFlag: manager.EditType})
+	if abi.Type != lib.Hash || abi.MaxEntries != 4096 {
+		t.Errorf("unexpected ABI: %+v", abi)
+	}
+}
+
+func TestPinnedMapsSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pinned-maps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if schema, err := readPinnedMapsSchema(dir); err != nil || len(schema) != 0 {
+		t.Fatalf("expected an empty schema, got %v: %v", schema, err)
+	}
+
+	schema := map[string]pinnedMapSchema{
+		"proc_cache": newPinnedMapSchema(lib.MapABI{Type: lib.LRUHash, KeySize: 8, ValueSize: 64, MaxEntries: 4096}),
+	}
+	if err := writePinnedMapsSchema(dir, schema); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readPinnedMapsSchema(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, schema) {
+		t.Errorf("expected %+v, got %+v", schema, read)
+	}
+}
+
+func TestSaveDiscarderRegistry(t *testing.T) {
+	registry := newDiscarderRegistry()
+
+	reasons := []discarderReason{
+		newFieldDiscarderReason(FileOpenEventType, "open.filename", "/etc/passwd"),
+		loadDiscarderReason,
+	}
+	for _, reason := range reasons {
+		if err := registry.register("inode_discarders", 16, uint64(42), reason); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := json.Marshal(registry.save())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var discarders []savedDiscarder
+	if err := json.Unmarshal(data, &discarders); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(discarders) != 1 || discarders[0].Table != "inode_discarders" || len(discarders[0].Reasons) != 2 {
+		t.Fatalf("unexpected discarders: %+v", discarders)
+	}
+
+	key, _ := encodeFilterKey(uint64(42))
+	if string(discarders[0].Key) != key {
+		t.Errorf("unexpected key: %v", discarders[0].Key)
+	}
+
+	restored := make(map[discarderReason]bool)
+	for _, reason := range discarders[0].Reasons {
+		restored[reason.discarderReason()] = true
+	}
+	for _, reason := range reasons {
+		if !restored[reason] {
+			t.Errorf("reason %+v not restored", reason)
+		}
+	}
+}
+
+func TestIsDiscardedInode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "discarded-inode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "discarded")
+	if err := ioutil.WriteFile(filename, []byte{}, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	inodeOf := func(filename string) uint64 {
+		var stat syscall.Stat_t
+		if err := syscall.Stat(filename, &stat); err != nil {
+			t.Fatal(err)
+		}
+		return stat.Ino
+	}
+	inode, parentInode := inodeOf(filename), inodeOf(dir)
+
+	reason := newFieldDiscarderReason(FileOpenEventType, "open.filename", filename)
+	if !isDiscardedInode(reason, inode) {
+		t.Error("expected the inode of the file to be discarded")
+	}
+
+	parentReason := newParentDiscarderReason(FileOpenEventType, "open.filename", filename)
+	if !isDiscardedInode(parentReason, parentInode) || isDiscardedInode(parentReason, inode) {
+		t.Error("expected the inode of the parent directory to be discarded")
+	}
+
+	// the file was replaced while the programs were detached
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filename, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if inodeOf(filename) != inode && isDiscardedInode(reason, inode) {
+		t.Error("expected the discarder of a replaced file to be invalid")
+	}
+
+	if isDiscardedInode(newFieldDiscarderReason(FileOpenEventType, "open.basename", "discarded"), inode) {
+		t.Error("expected a discarder without path to be invalid")
+	}
+}
@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"io"
+	"runtime"
+	"sort"
+
+	"github.com/DataDog/datadog-go/statsd"
+	lib "github.com/DataDog/ebpf"
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// containersPerCPU is the container density expected when it isn't configured
+	containersPerCPU = 4
+	// mapEntryOverhead is the approximate memory used by the kernel for each entry of a hash map, on top of its key
+	// and value
+	mapEntryOverhead = 64
+	// mapMemoryRatio is the part of the memory of the host the automatically sized maps can use
+	mapMemoryRatio = 100
+)
+
+const (
+	// processCacheMaxSize is the maximum computed size of the process caches
+	processCacheMaxSize = 131072
+	// dentryCacheMaxSize is the maximum computed size of the dentry cache
+	dentryCacheMaxSize = 1048576
+	// discarderCacheMaxSize is the maximum computed size of the discarder maps
+	discarderCacheMaxSize = 65536
+)
+
+// mapSizeRange bounds the size of a map, the minimum is the size of the map in the eBPF object
+type mapSizeRange struct {
+	min uint32
+	max uint32
+}
+
+// hostProfile describes the resources of the host the maps are sized for
+type hostProfile struct {
+	cpus       int
+	memory     uint64
+	containers int
+}
+
+// detectHostProfile returns the profile of the host, the expected number of containers is derived from the number of
+// CPUs when it isn't configured
+func detectHostProfile(cfg *config.Config) hostProfile {
+	profile := hostProfile{
+		cpus:       runtime.NumCPU(),
+		containers: cfg.MapSizingExpectedContainers,
+	}
+
+	var info unix.Sysinfo_t
+	if err := unix.Sysinfo(&info); err != nil {
+		log.Warnf("failed to get the memory of the host: %s", err)
+	} else {
+		profile.memory = uint64(info.Totalram) * uint64(info.Unit)
+	}
+
+	if profile.containers <= 0 {
+		profile.containers = profile.cpus * containersPerCPU
+	}
+	return profile
+}
+
+func (r mapSizeRange) clamp(size uint64) uint32 {
+	if size < uint64(r.min) {
+		return r.min
+	}
+	if size > uint64(r.max) {
+		return r.max
+	}
+	return uint32(size)
+}
+
+// computeMapSizes returns the maximum number of entries of the caches and discarder maps of the given map specs,
+// which are never smaller than in the eBPF object. The processes of the host size the process cache, the dentry and
+// discarder maps being proportional to it. The configured sizes are kept as they are, the computed ones are scaled
+// down to fit in a part of the memory of the host
+func computeMapSizes(cfg *config.Config, profile hostProfile, specs map[string]*lib.MapSpec) map[string]uint32 {
+	processes := 1024 + 128*uint64(profile.cpus) + 64*uint64(profile.containers)
+
+	computed := map[string]struct {
+		size uint64
+		max  uint32
+	}{
+		"proc_cache":       {size: processes, max: processCacheMaxSize},
+		"pid_cookie":       {size: processes, max: processCacheMaxSize},
+		"inode_info_cache": {size: processes, max: processCacheMaxSize},
+		"pathnames":        {size: processes * 16, max: dentryCacheMaxSize},
+		"inode_discarders": {size: processes / 8, max: discarderCacheMaxSize},
+		"pid_discarders":   {size: processes / 8, max: discarderCacheMaxSize},
+	}
+
+	sizes := make(map[string]uint32)
+	ranges := make(map[string]mapSizeRange)
+	for name, c := range computed {
+		spec, exists := specs[name]
+		if !exists {
+			continue
+		}
+		ranges[name] = mapSizeRange{min: spec.MaxEntries, max: c.max}
+		sizes[name] = ranges[name].clamp(c.size)
+	}
+
+	configured := make(map[string]uint32)
+	if cfg.MapSizingProcessCacheSize > 0 {
+		for _, name := range []string{"proc_cache", "pid_cookie", "inode_info_cache"} {
+			configured[name] = uint32(cfg.MapSizingProcessCacheSize)
+		}
+	}
+	if cfg.MapSizingDentryCacheSize > 0 {
+		configured["pathnames"] = uint32(cfg.MapSizingDentryCacheSize)
+	}
+	if cfg.DiscardersMapSize > 0 {
+		for _, name := range discarderMaps {
+			configured[name] = uint32(cfg.DiscardersMapSize)
+		}
+	}
+
+	var computedMemory uint64
+	for name, size := range sizes {
+		if _, exists := configured[name]; !exists {
+			computedMemory += uint64(size) * (uint64(specs[name].KeySize) + uint64(specs[name].ValueSize) + mapEntryOverhead)
+		}
+	}
+
+	if budget := profile.memory / mapMemoryRatio; budget > 0 && computedMemory > budget {
+		for name, size := range sizes {
+			sizes[name] = ranges[name].clamp(uint64(size) * budget / computedMemory)
+		}
+	}
+
+	for name, size := range configured {
+		if _, exists := sizes[name]; exists {
+			sizes[name] = size
+		}
+	}
+	return sizes
+}
+
+// sizeMaps sizes the caches and discarder maps of the eBPF object for the host. It has to be called once the filter
+// maps editors are set, and before the manager is initialized
+func (p *Probe) sizeMaps(reader io.ReaderAt) error {
+	spec, err := lib.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the eBPF object")
+	}
+
+	profile := detectHostProfile(p.config)
+	p.mapSizes = computeMapSizes(p.config, profile, spec.Maps)
+
+	if p.managerOptions.MapSpecEditors == nil {
+		p.managerOptions.MapSpecEditors = make(map[string]manager.MapSpecEditor)
+	}
+
+	var names []string
+	for name := range p.mapSizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// the discarder maps keep the type set by their editor
+		editor := p.managerOptions.MapSpecEditors[name]
+		editor.MaxEntries = p.mapSizes[name]
+		editor.EditorFlag |= manager.EditMaxEntries
+		p.managerOptions.MapSpecEditors[name] = editor
+
+		log.Debugf("map %s sized to %d entries", name, p.mapSizes[name])
+	}
+
+	log.Infof("maps sized for %d CPUs, %d MB of memory and %d containers", profile.cpus, profile.memory>>20, profile.containers)
+	return nil
+}
+
+// GetMapSizes returns the maximum number of entries of the maps sized for the host
+func (p *Probe) GetMapSizes() map[string]uint32 {
+	return p.mapSizes
+}
+
+// sendMapSizes sends the maximum number of entries of the maps sized for the host
+func (p *Probe) sendMapSizes(statsdClient *statsd.Client) error {
+	for name, size := range p.mapSizes {
+		if err := statsdClient.Gauge(MetricPrefix+".maps.max_entries", float64(size), []string{"map:" + name}, 1.0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
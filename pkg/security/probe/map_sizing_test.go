@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	lib "github.com/DataDog/ebpf"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+)
+
+func TestComputeMapSizes(t *testing.T) {
+	specs := map[string]*lib.MapSpec{
+		"proc_cache":       {KeySize: 4, ValueSize: 252, MaxEntries: 4095},
+		"pid_cookie":       {KeySize: 4, ValueSize: 4, MaxEntries: 4097},
+		"inode_info_cache": {KeySize: 16, ValueSize: 48, MaxEntries: 4096},
+		"pathnames":        {KeySize: 16, ValueSize: 128, MaxEntries: 64000},
+		"inode_discarders": {KeySize: 16, ValueSize: 8, MaxEntries: 512},
+		"pid_discarders":   {KeySize: 4, ValueSize: 8, MaxEntries: 512},
+	}
+
+	// a small host gets the sizes of the eBPF object
+	sizes := computeMapSizes(&config.Config{}, hostProfile{cpus: 2, memory: 4 << 30, containers: 8}, specs)
+	for name, spec := range specs {
+		if sizes[name] != spec.MaxEntries {
+			t.Errorf("expected map %s to keep its size %d on a small host, got %d", name, spec.MaxEntries, sizes[name])
+		}
+	}
+
+	// the caches grow with the CPUs and the containers
+	sizes = computeMapSizes(&config.Config{}, hostProfile{cpus: 64, memory: 256 << 30, containers: 500}, specs)
+	if sizes["proc_cache"] != 1024+128*64+64*500 || sizes["pathnames"] != 16*sizes["proc_cache"] || sizes["inode_discarders"] != sizes["proc_cache"]/8 {
+		t.Errorf("unexpected sizes for a large host: %v", sizes)
+	}
+
+	// the caches are scaled down to fit in the memory ...
+	sizes = computeMapSizes(&config.Config{}, hostProfile{cpus: 64, memory: 1 << 30, containers: 500}, specs)
+	if sizes["proc_cache"] >= 1024+128*64+64*500 || sizes["proc_cache"] < specs["proc_cache"].MaxEntries || sizes["pid_cookie"] < specs["pid_cookie"].MaxEntries {
+		t.Errorf("the process cache should be scaled down: %v", sizes)
+	}
+
+	// ... unless configured
+	cfg := &config.Config{MapSizingProcessCacheSize: 100000, MapSizingDentryCacheSize: 70000, DiscardersMapSize: 1000}
+	sizes = computeMapSizes(cfg, hostProfile{cpus: 64, memory: 1 << 30, containers: 500}, specs)
+	if sizes["proc_cache"] != 100000 || sizes["pid_cookie"] != 100000 || sizes["pathnames"] != 70000 || sizes["pid_discarders"] != 1000 {
+		t.Errorf("the configured sizes should be kept: %v", sizes)
+	}
+
+	// the maps missing from the eBPF object aren't sized
+	delete(specs, "inode_info_cache")
+	if _, exists := computeMapSizes(cfg, hostProfile{cpus: 2, memory: 4 << 30, containers: 8}, specs)["inode_info_cache"]; exists {
+		t.Error("a map missing from the eBPF object shouldn't be sized")
+	}
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func mkdirOnNewApprovers(probe *Probe, approvers rules.Approvers) error {
+	for field, values := range approvers {
+		switch field {
+		case "mkdir.basename":
+			if err := approveBasenames(probe, "mkdir_basename_approvers", stringValues(values)...); err != nil {
+				return err
+			}
+
+		// the directory doesn't exist yet, it can't be approved by its inode
+		case "mkdir.filename":
+			for _, value := range stringValues(values) {
+				if err := approveBasename(probe, "mkdir_basename_approvers", path.Base(value)); err != nil {
+					return err
+				}
+			}
+
+		case "mkdir.mode":
+			if err := approveModes(probe, "mkdir_mode_approvers", intValues(values)...); err != nil {
+				return err
+			}
+
+		default:
+			return errors.New("field unknown")
+		}
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ package probe
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os/user"
@@ -21,7 +22,6 @@ import (
 	"time"
 	"unsafe"
 
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
 	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
@@ -62,10 +62,16 @@ func (m *Model) NewEvent() eval.Event {
 	return &Event{}
 }
 
+// IsCostlyField returns whether the given field is costly to resolve, the checksums require the files to be read
+func (m *Model) IsCostlyField(field eval.Field) bool {
+	return strings.HasSuffix(field, ".sha256")
+}
+
 // ValidateField validates the value of a field
 func (m *Model) ValidateField(key string, field eval.FieldValue) error {
-	// check that all path are absolute
-	if strings.HasSuffix(key, "filename") || strings.HasSuffix(key, "_path") {
+	// check that all path are absolute, neither a regexp nor a list is a path
+	if (strings.HasSuffix(key, "filename") || strings.HasSuffix(key, "_path")) && field.Type != eval.RegexpValueType &&
+		field.Type != eval.ListValueType {
 		value, ok := field.Value.(string)
 		if ok {
 			if value != path.Clean(value) || !path.IsAbs(value) {
@@ -74,6 +80,15 @@ func (m *Model) ValidateField(key string, field eval.FieldValue) error {
 		}
 	}
 
+	// the checksums are lowercase hex encoded SHA256 checksums
+	if strings.HasSuffix(key, ".sha256") && field.Type == eval.ScalarValueType {
+		if value, ok := field.Value.(string); ok {
+			if decoded, err := hex.DecodeString(value); err != nil || len(decoded) != 32 || strings.ToLower(value) != value {
+				return fmt.Errorf("invalid checksum `%s`, a lowercase hex encoded SHA256 checksum is expected", value)
+			}
+		}
+	}
+
 	switch key {
 
 	case "event.retval":
@@ -99,16 +114,6 @@ func (e *SyscallEvent) UnmarshalBinary(data []byte) (int, error) {
 	return 8, nil
 }
 
-func (e *SyscallEvent) marshalJSON(eventType EventType, resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"type":"%s",`, eventType.String())
-	fmt.Fprintf(&buf, `"retval":%d`, e.Retval)
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
-}
-
 // BinaryUnmarshaler interface implemented by every event type
 type BinaryUnmarshaler interface {
 	UnmarshalBinary(data []byte) (int, error)
@@ -119,30 +124,59 @@ type FileEvent struct {
 	MountID         uint32 `field:"-"`
 	Inode           uint64 `field:"inode"`
 	PathID          uint32 `field:"-"`
+	Generation      uint32 `field:"-"`
 	OverlayNumLower int32  `field:"overlay_numlower"`
 	PathnameStr     string `field:"filename" handler:"ResolveInode,string"`
 	ContainerPath   string `field:"container_path" handler:"ResolveContainerPath,string"`
 	BasenameStr     string `field:"basename" handler:"ResolveBasename,string"`
+
+	SecurityLabel         string `field:"security_label" handler:"ResolveSecurityLabel,string"`
+	securityLabelResolved bool   `field:"-"`
+
+	SHA256         string `field:"sha256" handler:"ResolveSHA256,string"`
+	sha256Resolved bool   `field:"-"`
+
+	// DestinationPath is the path of the file reached through the symbolic link of the event, the file of the event
+	// being the link. Only the opens report the links they follow
+	DestinationPath    string `field:"destination.path" handler:"ResolveDestination,string"`
+	destinationMountID uint32 `field:"-"`
+	destinationInode   uint64 `field:"-"`
+	destinationPathID  uint32 `field:"-"`
+	destinationGen     uint32 `field:"-"`
+
+	// pid is the process the file was accessed by, the paths are resolved in its mount namespace
+	pid uint32 `field:"-"`
 }
 
 // ResolveInode resolves the inode to a full path
 func (e *FileEvent) ResolveInode(resolvers *Resolvers) string {
 	if len(e.PathnameStr) == 0 {
-		e.PathnameStr = resolvers.DentryResolver.Resolve(e.MountID, e.Inode, e.PathID)
-		if e.PathnameStr == dentryPathKeyNotFound {
-			return e.PathnameStr
-		}
+		e.PathnameStr = resolvePath(resolvers, e.MountID, e.Inode, e.PathID, e.Generation)
+	}
 
-		_, mountPath, rootPath, err := resolvers.MountResolver.GetMountPath(e.MountID)
-		if err == nil {
-			if strings.HasPrefix(e.PathnameStr, rootPath) && rootPath != "/" {
-				e.PathnameStr = strings.Replace(e.PathnameStr, rootPath, "", 1)
-			}
-			e.PathnameStr = path.Join(mountPath, e.PathnameStr)
+	return e.PathnameStr
+}
+
+// resolvePath resolves the full path of the given file
+func resolvePath(resolvers *Resolvers, mountID uint32, inode uint64, pathID uint32, generation uint32) string {
+	pathnameStr, err := resolvers.DentryResolver.ResolveWithGeneration(mountID, inode, pathID, generation)
+	if err != nil {
+		pathnameStr = dentryPathUnreliable
+	}
+
+	if pathnameStr == dentryPathKeyNotFound || pathnameStr == dentryPathUnreliable {
+		return pathnameStr
+	}
+
+	_, mountPath, rootPath, err := resolvers.MountResolver.GetMountPath(mountID)
+	if err == nil {
+		if strings.HasPrefix(pathnameStr, rootPath) && rootPath != "/" {
+			pathnameStr = strings.Replace(pathnameStr, rootPath, "", 1)
 		}
+		pathnameStr = path.Join(mountPath, pathnameStr)
 	}
 
-	return e.PathnameStr
+	return pathnameStr
 }
 
 // ResolveContainerPath resolves the inode to a path relative to the container
@@ -172,21 +206,64 @@ func (e *FileEvent) ResolveBasename(resolvers *Resolvers) string {
 	return e.BasenameStr
 }
 
-func (e *FileEvent) marshalJSONInode(resolvers *Resolvers, inode uint64) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"filename":"%s",`, e.ResolveInode(resolvers))
-	fmt.Fprintf(&buf, `"container_path":"%s",`, e.ResolveContainerPath(resolvers))
-	fmt.Fprintf(&buf, `"inode":%d,`, inode)
-	fmt.Fprintf(&buf, `"mount_id":%d,`, e.MountID)
-	fmt.Fprintf(&buf, `"overlay_numlower":%d`, e.OverlayNumLower)
-	buf.WriteRune('}')
+// ResolveSecurityLabel resolves the SELinux label of the file
+func (e *FileEvent) ResolveSecurityLabel(resolvers *Resolvers) string {
+	if !e.securityLabelResolved {
+		e.securityLabelResolved = true
+
+		filename := e.ResolveInode(resolvers)
+		if !path.IsAbs(filename) {
+			return ""
+		}
 
-	return buf.Bytes(), nil
+		label, err := resolvers.SecurityLabelResolver.ResolveSecurityLabel(e.pid, e.MountID, e.Inode, filename)
+		if err != nil {
+			return ""
+		}
+		e.SecurityLabel = label
+	}
+	return e.SecurityLabel
+}
+
+// ResolveSHA256 resolves the SHA256 checksum of the content of the file
+func (e *FileEvent) ResolveSHA256(resolvers *Resolvers) string {
+	if !e.sha256Resolved {
+		e.sha256Resolved = true
+
+		filename := e.ResolveInode(resolvers)
+		if !path.IsAbs(filename) {
+			return ""
+		}
+
+		// the device reported by stat for the files of a btrfs subvolume isn't the one of its super block
+		var device uint32
+		if dev, fsType, exists := resolvers.MountResolver.GetDevice(e.MountID); exists && fsType != "btrfs" {
+			device = dev
+		}
+
+		hash, err := resolvers.HashResolver.ResolveSHA256(e.pid, e.MountID, device, e.Inode, filename)
+		if err != nil {
+			return ""
+		}
+		e.SHA256 = hash
+	}
+	return e.SHA256
+}
+
+// ResolveDestination resolves the path of the file reached through the symbolic link of the event, the link and the
+// file it points to were both resolved in kernel when the event occurred
+func (e *FileEvent) ResolveDestination(resolvers *Resolvers) string {
+	if len(e.DestinationPath) == 0 && e.destinationInode != 0 {
+		e.DestinationPath = resolvePath(resolvers, e.destinationMountID, e.destinationInode, e.destinationPathID, e.destinationGen)
+	}
+	return e.DestinationPath
 }
 
-func (e *FileEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	return e.marshalJSONInode(resolvers, e.Inode)
+// setLink makes the given symbolic link the file of the event, the file the event reached becoming its destination
+func (e *FileEvent) setLink(mountID uint32, inode uint64, pathID uint32) {
+	e.destinationMountID, e.destinationInode, e.destinationPathID, e.destinationGen = e.MountID, e.Inode, e.PathID, e.Generation
+	e.MountID, e.Inode, e.PathID, e.Generation = mountID, inode, pathID, 0
+	e.OverlayNumLower = 0
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
@@ -198,6 +275,7 @@ func (e *FileEvent) UnmarshalBinary(data []byte) (int, error) {
 	e.MountID = ebpf.ByteOrder.Uint32(data[8:12])
 	e.OverlayNumLower = int32(ebpf.ByteOrder.Uint32(data[12:16]))
 	e.PathID = ebpf.ByteOrder.Uint32(data[16:20])
+	e.Generation = ebpf.ByteOrder.Uint32(data[20:24])
 
 	return 24, nil
 }
@@ -216,38 +294,46 @@ func unmarshalBinary(data []byte, binaryUnmarshalers ...BinaryUnmarshaler) (int,
 
 // Bytes returns a binary representation of itself
 func (e *FileEvent) Bytes() []byte {
-	b := make([]byte, 16)
+	b := make([]byte, 24)
 	ebpf.ByteOrder.PutUint64(b[0:8], e.Inode)
 	ebpf.ByteOrder.PutUint32(b[8:12], e.MountID)
 	ebpf.ByteOrder.PutUint32(b[12:16], uint32(e.OverlayNumLower))
 	ebpf.ByteOrder.PutUint32(b[16:20], e.PathID)
+	ebpf.ByteOrder.PutUint32(b[20:24], e.Generation)
 	return b
 }
 
-// ChmodEvent represents a chmod event
-type ChmodEvent struct {
-	SyscallEvent
-	FileEvent
+// FileMetadata holds the owner and mode of a file as they were before the event altered them
+type FileMetadata struct {
+	UID  uint32 `field:"uid"`
+	GID  uint32 `field:"gid"`
 	Mode uint32 `field:"mode"`
 }
 
-func (e *ChmodEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"filename":"%s",`, e.ResolveInode(resolvers))
-	fmt.Fprintf(&buf, `"container_path":"%s",`, e.ResolveContainerPath(resolvers))
-	fmt.Fprintf(&buf, `"inode":%d,`, e.Inode)
-	fmt.Fprintf(&buf, `"mount_id":%d,`, e.MountID)
-	fmt.Fprintf(&buf, `"overlay_numlower":%d,`, e.OverlayNumLower)
-	fmt.Fprintf(&buf, `"mode":%d`, e.Mode)
-	buf.WriteRune('}')
+// UnmarshalBinary unmarshals a binary representation of itself
+func (e *FileMetadata) UnmarshalBinary(data []byte) (int, error) {
+	if len(data) < 16 {
+		return 0, ErrNotEnoughData
+	}
+	e.UID = ebpf.ByteOrder.Uint32(data[0:4])
+	e.GID = ebpf.ByteOrder.Uint32(data[4:8])
+	e.Mode = ebpf.ByteOrder.Uint32(data[8:12])
+	// padding
+
+	return 16, nil
+}
 
-	return buf.Bytes(), nil
+// ChmodEvent represents a chmod event
+type ChmodEvent struct {
+	SyscallEvent
+	FileEvent
+	FileMetadata FileMetadata `field:"file"`
+	Mode         uint32       `field:"mode"`
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
 func (e *ChmodEvent) UnmarshalBinary(data []byte) (int, error) {
-	n, err := unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent)
+	n, err := unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent, &e.FileMetadata)
 	if err != nil {
 		return n, err
 	}
@@ -265,28 +351,32 @@ func (e *ChmodEvent) UnmarshalBinary(data []byte) (int, error) {
 type ChownEvent struct {
 	SyscallEvent
 	FileEvent
-	UID int32 `field:"uid"`
-	GID int32 `field:"gid"`
+	FileMetadata FileMetadata `field:"file"`
+	UID          int32        `field:"uid"`
+	GID          int32        `field:"gid"`
+	User         string       `field:"user" handler:"ResolveUser,string"`
+	Group        string       `field:"group" handler:"ResolveGroup,string"`
 }
 
-func (e *ChownEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"filename":"%s",`, e.ResolveInode(resolvers))
-	fmt.Fprintf(&buf, `"container_path":"%s",`, e.ResolveContainerPath(resolvers))
-	fmt.Fprintf(&buf, `"inode":%d,`, e.Inode)
-	fmt.Fprintf(&buf, `"mount_id":%d,`, e.MountID)
-	fmt.Fprintf(&buf, `"overlay_numlower":%d,`, e.OverlayNumLower)
-	fmt.Fprintf(&buf, `"uid":%d,`, e.UID)
-	fmt.Fprintf(&buf, `"gid":%d`, e.GID)
-	buf.WriteRune('}')
+// ResolveUser resolves the new owner of the file to a username
+func (e *ChownEvent) ResolveUser(resolvers *Resolvers) string {
+	if len(e.User) == 0 && e.UID >= 0 {
+		e.User = resolvers.UserGroupResolver.ResolveUser(int(e.UID))
+	}
+	return e.User
+}
 
-	return buf.Bytes(), nil
+// ResolveGroup resolves the new group of the file to a group name
+func (e *ChownEvent) ResolveGroup(resolvers *Resolvers) string {
+	if len(e.Group) == 0 && e.GID >= 0 {
+		e.Group = resolvers.UserGroupResolver.ResolveGroup(int(e.GID))
+	}
+	return e.Group
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
 func (e *ChownEvent) UnmarshalBinary(data []byte) (int, error) {
-	n, err := unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent)
+	n, err := unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent, &e.FileMetadata)
 	if err != nil {
 		return n, err
 	}
@@ -307,23 +397,11 @@ type SetXAttrEvent struct {
 	FileEvent
 	Namespace string `field:"namespace" handler:"GetNamespace,string"`
 	Name      string `field:"name" handler:"GetName,string"`
+	Value     string `field:"value" handler:"GetValue,string"`
+	ValueSize uint32 `field:"value_size"`
 
-	NameRaw [200]byte
-}
-
-func (e *SetXAttrEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"filename":"%s",`, e.ResolveInode(resolvers))
-	fmt.Fprintf(&buf, `"container_path":"%s",`, e.ResolveContainerPath(resolvers))
-	fmt.Fprintf(&buf, `"inode":%d,`, e.Inode)
-	fmt.Fprintf(&buf, `"mount_id":%d,`, e.MountID)
-	fmt.Fprintf(&buf, `"overlay_numlower":%d,`, e.OverlayNumLower)
-	fmt.Fprintf(&buf, `"attribute_name":"%s",`, e.GetName(resolvers))
-	fmt.Fprintf(&buf, `"attribute_namespace":"%s"`, e.GetNamespace(resolvers))
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
+	NameRaw  [200]byte
+	ValueRaw [128]byte
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
@@ -334,12 +412,15 @@ func (e *SetXAttrEvent) UnmarshalBinary(data []byte) (int, error) {
 	}
 
 	data = data[n:]
-	if len(data) < 200 {
+	if len(data) < 336 {
 		return n, ErrNotEnoughData
 	}
 	utils.SliceToArray(data[0:200], unsafe.Pointer(&e.NameRaw))
+	e.ValueSize = ebpf.ByteOrder.Uint32(data[200:204])
+	// 4 of padding
+	utils.SliceToArray(data[208:336], unsafe.Pointer(&e.ValueRaw))
 
-	return n + 200, nil
+	return n + 336, nil
 }
 
 // GetName returns the string representation of the extended attribute name
@@ -350,6 +431,18 @@ func (e *SetXAttrEvent) GetName(resolvers *Resolvers) string {
 	return e.Name
 }
 
+// GetValue returns the beginning of the extended attribute value, up to the size of the captured value
+func (e *SetXAttrEvent) GetValue(resolvers *Resolvers) string {
+	if len(e.Value) == 0 {
+		size := int(e.ValueSize)
+		if size > len(e.ValueRaw) {
+			size = len(e.ValueRaw)
+		}
+		e.Value = string(e.ValueRaw[:size])
+	}
+	return e.Value
+}
+
 // GetNamespace returns the string representation of the extended attribute namespace
 func (e *SetXAttrEvent) GetNamespace(resolvers *Resolvers) string {
 	if len(e.Namespace) == 0 {
@@ -365,25 +458,12 @@ func (e *SetXAttrEvent) GetNamespace(resolvers *Resolvers) string {
 type OpenEvent struct {
 	SyscallEvent
 	FileEvent
+	// Flags are the flags of the syscall without O_LARGEFILE, implied on 64 bits kernels and only set by the 32 bits
+	// processes
 	Flags uint32 `field:"flags"`
 	Mode  uint32 `field:"mode"`
 }
 
-func (e *OpenEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"filename":"%s",`, e.ResolveInode(resolvers))
-	fmt.Fprintf(&buf, `"container_path":"%s",`, e.ResolveContainerPath(resolvers))
-	fmt.Fprintf(&buf, `"inode":%d,`, e.Inode)
-	fmt.Fprintf(&buf, `"mount_id":%d,`, e.MountID)
-	fmt.Fprintf(&buf, `"overlay_numlower":%d,`, e.OverlayNumLower)
-	fmt.Fprintf(&buf, `"mode":%d,`, e.Mode)
-	fmt.Fprintf(&buf, `"flags":"%s"`, OpenFlags(e.Flags))
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
-}
-
 // UnmarshalBinary unmarshals a binary representation of itself
 func (e *OpenEvent) UnmarshalBinary(data []byte) (int, error) {
 	n, err := unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent)
@@ -392,13 +472,18 @@ func (e *OpenEvent) UnmarshalBinary(data []byte) (int, error) {
 	}
 
 	data = data[n:]
-	if len(data) < 8 {
+	if len(data) < 24 {
 		return n, ErrNotEnoughData
 	}
 
 	e.Flags = ebpf.ByteOrder.Uint32(data[0:4])
 	e.Mode = ebpf.ByteOrder.Uint32(data[4:8])
-	return n + 8, nil
+
+	// the key of the symbolic link followed by the open, if any
+	if inode := ebpf.ByteOrder.Uint64(data[8:16]); inode != 0 {
+		e.setLink(ebpf.ByteOrder.Uint32(data[16:20]), inode, ebpf.ByteOrder.Uint32(data[20:24]))
+	}
+	return n + 24, nil
 }
 
 // MkdirEvent represents a mkdir event
@@ -408,20 +493,6 @@ type MkdirEvent struct {
 	Mode int32 `field:"mode"`
 }
 
-func (e *MkdirEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"filename":"%s",`, e.ResolveInode(resolvers))
-	fmt.Fprintf(&buf, `"container_path":"%s",`, e.ResolveContainerPath(resolvers))
-	fmt.Fprintf(&buf, `"inode":%d,`, e.Inode)
-	fmt.Fprintf(&buf, `"mount_id":%d,`, e.MountID)
-	fmt.Fprintf(&buf, `"overlay_numlower":%d,`, e.OverlayNumLower)
-	fmt.Fprintf(&buf, `"mode":%d`, e.Mode)
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
-}
-
 // UnmarshalBinary unmarshals a binary representation of itself
 func (e *MkdirEvent) UnmarshalBinary(data []byte) (int, error) {
 	n, err := unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent)
@@ -444,10 +515,6 @@ type RmdirEvent struct {
 	FileEvent
 }
 
-func (e *RmdirEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	return e.FileEvent.marshalJSON(resolvers)
-}
-
 // UnmarshalBinary unmarshals a binary representation of itself
 func (e *RmdirEvent) UnmarshalBinary(data []byte) (int, error) {
 	return unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent)
@@ -457,26 +524,13 @@ func (e *RmdirEvent) UnmarshalBinary(data []byte) (int, error) {
 type UnlinkEvent struct {
 	SyscallEvent
 	FileEvent
-	Flags uint32 `field:"flags"`
-}
-
-func (e *UnlinkEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"filename":"%s",`, e.ResolveInode(resolvers))
-	fmt.Fprintf(&buf, `"flags":"%s",`, UnlinkFlags(e.Flags))
-	fmt.Fprintf(&buf, `"container_path":"%s",`, e.ResolveContainerPath(resolvers))
-	fmt.Fprintf(&buf, `"inode":%d,`, e.Inode)
-	fmt.Fprintf(&buf, `"mount_id":%d,`, e.MountID)
-	fmt.Fprintf(&buf, `"overlay_numlower":%d`, e.OverlayNumLower)
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
+	FileMetadata FileMetadata `field:"file"`
+	Flags        uint32       `field:"flags"`
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
 func (e *UnlinkEvent) UnmarshalBinary(data []byte) (int, error) {
-	n, err := unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent)
+	n, err := unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent, &e.FileMetadata)
 	if err != nil {
 		return n, err
 	}
@@ -502,27 +556,6 @@ func (e *RenameEvent) UnmarshalBinary(data []byte) (int, error) {
 	return unmarshalBinary(data, &e.SyscallEvent, &e.Old, &e.New)
 }
 
-func (e *RenameEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-
-	// use the new.inode as the old one is a fake one generated from the probe
-	buf.WriteString(`"old":`)
-	d, err := e.Old.marshalJSONInode(resolvers, e.New.Inode)
-	if err != nil {
-		return d, err
-	}
-	buf.Write(d)
-
-	buf.WriteString(`,"new":`)
-	d, err = e.New.marshalJSONInode(resolvers, e.New.Inode)
-	if err != nil {
-		return d, err
-	}
-	buf.Write(d)
-
-	return buf.Bytes(), nil
-}
-
 // UtimesEvent represents a utime event
 type UtimesEvent struct {
 	SyscallEvent
@@ -531,21 +564,6 @@ type UtimesEvent struct {
 	Mtime time.Time
 }
 
-func (e *UtimesEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"filename":"%s",`, e.ResolveInode(resolvers))
-	fmt.Fprintf(&buf, `"container_path":"%s",`, e.ResolveContainerPath(resolvers))
-	fmt.Fprintf(&buf, `"inode":%d,`, e.Inode)
-	fmt.Fprintf(&buf, `"mount_id":%d,`, e.MountID)
-	fmt.Fprintf(&buf, `"overlay_numlower":%d,`, e.OverlayNumLower)
-	fmt.Fprintf(&buf, `"access_time":"%s",`, e.Atime)
-	fmt.Fprintf(&buf, `"modification_time":"%s"`, e.Mtime)
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
-}
-
 // UnmarshalBinary unmarshals a binary representation of itself
 func (e *UtimesEvent) UnmarshalBinary(data []byte) (int, error) {
 	n, err := unmarshalBinary(data, &e.SyscallEvent, &e.FileEvent)
@@ -581,60 +599,24 @@ func (e *LinkEvent) UnmarshalBinary(data []byte) (int, error) {
 	return unmarshalBinary(data, &e.SyscallEvent, &e.Source, &e.Target)
 }
 
-func (e *LinkEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-
-	// use the source.inode as the target one is a fake one generated from the probe
-	buf.WriteString(`"source":`)
-	d, err := e.Source.marshalJSONInode(resolvers, e.Source.Inode)
-	if err != nil {
-		return d, err
-	}
-	buf.Write(d)
-
-	buf.WriteString(`,"target":`)
-	d, err = e.Target.marshalJSONInode(resolvers, e.Source.Inode)
-	if err != nil {
-		return d, err
-	}
-	buf.Write(d)
-
-	return buf.Bytes(), nil
-}
-
 // MountEvent represents a mount event
 type MountEvent struct {
 	SyscallEvent
-	MountID       uint32
-	GroupID       uint32
-	Device        uint32
-	ParentMountID uint32
-	ParentInode   uint64
-	FSType        string
-	MountPointStr string
-	RootMountID   uint32
-	RootInode     uint64
-	RootStr       string
-
-	FSTypeRaw [16]byte
-}
-
-func (e *MountEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"mount_point":"%s",`, e.ResolveMountPoint(resolvers))
-	fmt.Fprintf(&buf, `"parent_mount_id":%d,`, e.ParentMountID)
-	fmt.Fprintf(&buf, `"parent_inode":%d,`, e.ParentInode)
-	fmt.Fprintf(&buf, `"root_inode":%d,`, e.RootInode)
-	fmt.Fprintf(&buf, `"root_mount_id":%d,`, e.RootInode)
-	fmt.Fprintf(&buf, `"root":"%s",`, e.ResolveRoot(resolvers))
-	fmt.Fprintf(&buf, `"mount_id":%d,`, e.MountID)
-	fmt.Fprintf(&buf, `"group_id":%d,`, e.GroupID)
-	fmt.Fprintf(&buf, `"device":%d,`, e.Device)
-	fmt.Fprintf(&buf, `"fstype":"%s"`, e.GetFSType())
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
+	MountID       uint32 `field:"-"`
+	GroupID       uint32 `field:"-"`
+	MasterID      uint32 `field:"-"`
+	Device        uint32 `field:"-"`
+	ParentMountID uint32 `field:"-"`
+	ParentInode   uint64 `field:"-"`
+	FSType        string `field:"fs_type" handler:"ResolveFSType,string"`
+	MountPointStr string `field:"mount_point" handler:"ResolveMountPoint,string"`
+	RootMountID   uint32 `field:"-"`
+	RootInode     uint64 `field:"-"`
+	RootStr       string `field:"root" handler:"ResolveRoot,string"`
+	Source        string `field:"source"`
+
+	FSTypeRaw [16]byte `field:"-"`
+	discarded bool     `field:"-"`
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
@@ -656,8 +638,7 @@ func (e *MountEvent) UnmarshalBinary(data []byte) (int, error) {
 	e.ParentInode = ebpf.ByteOrder.Uint64(data[16:24])
 	e.RootInode = ebpf.ByteOrder.Uint64(data[24:32])
 	e.RootMountID = ebpf.ByteOrder.Uint32(data[32:36])
-
-	// Notes: bytes 36 to 40 are used to pad the structure
+	e.discarded = ebpf.ByteOrder.Uint32(data[36:40]) != 0
 
 	utils.SliceToArray(data[40:56], unsafe.Pointer(&e.FSTypeRaw))
 
@@ -688,19 +669,17 @@ func (e *MountEvent) GetFSType() string {
 	return e.FSType
 }
 
+// ResolveFSType resolves the filesystem type of the mountpoint
+func (e *MountEvent) ResolveFSType(resolvers *Resolvers) string {
+	return e.GetFSType()
+}
+
 // UmountEvent represents an umount event
 type UmountEvent struct {
 	SyscallEvent
 	MountID uint32
-}
 
-func (e *UmountEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"mount_id":%d`, e.MountID)
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
+	discarded bool `field:"-"`
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
@@ -711,34 +690,23 @@ func (e *UmountEvent) UnmarshalBinary(data []byte) (int, error) {
 	}
 
 	data = data[n:]
-	if len(data) < 4 {
+	if len(data) < 8 {
 		return 0, ErrNotEnoughData
 	}
 
 	e.MountID = ebpf.ByteOrder.Uint32(data[0:4])
-	return 4, nil
+	e.discarded = ebpf.ByteOrder.Uint32(data[4:8]) != 0
+	return 8, nil
 }
 
 // ContainerEvent holds the container context of an event
 type ContainerEvent struct {
-	ID string `field:"id" handler:"ResolveContainerID,string"`
+	ID          string `field:"id" handler:"ResolveContainerID,string"`
+	ImageName   string `field:"image_name" handler:"ResolveImageName,string"`
+	ImageDigest string `field:"image_digest" handler:"ResolveImageDigest,string"`
 
-	IDRaw [64]byte `field:"-"`
-}
-
-func (e *ContainerEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	if len(e.GetContainerID()) == 0 {
-		return nil, nil
-	}
-
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	if id := e.GetContainerID(); len(id) > 0 {
-		fmt.Fprintf(&buf, `"container_id":"%s"`, id)
-	}
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
+	IDRaw         [64]byte `field:"-"`
+	imageResolved bool     `field:"-"`
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
@@ -761,6 +729,33 @@ func (e *ContainerEvent) ResolveContainerID(resolvers *Resolvers) string {
 	return e.GetContainerID()
 }
 
+// resolveImage resolves the image of the container from the container runtime
+func (e *ContainerEvent) resolveImage(resolvers *Resolvers) {
+	if e.imageResolved {
+		return
+	}
+
+	if id := e.GetContainerID(); len(id) > 0 {
+		if image := resolvers.ContainerResolver.ResolveImage(id); image != nil {
+			e.ImageName, e.ImageDigest = image.Name, image.Digest
+		}
+	}
+	e.imageResolved = true
+}
+
+// ResolveImageName resolves the image name of the container, only the Docker and containerd containers have one
+func (e *ContainerEvent) ResolveImageName(resolvers *Resolvers) string {
+	e.resolveImage(resolvers)
+	return e.ImageName
+}
+
+// ResolveImageDigest resolves the image digest (sha256) of the container, tags being mutable the digest
+// is the reliable way to identify the image. Only the Docker and containerd containers have one
+func (e *ContainerEvent) ResolveImageDigest(resolvers *Resolvers) string {
+	e.resolveImage(resolvers)
+	return e.ImageDigest
+}
+
 // GetContainerID returns the container ID of the event
 func (e *ContainerEvent) GetContainerID() string {
 	if len(e.ID) == 0 {
@@ -780,7 +775,7 @@ type ExecEvent struct {
 
 // UnmarshalBinary unmarshals a binary representation of itself
 func (e *ExecEvent) UnmarshalBinary(data []byte) (int, error) {
-	if len(data) < 168 {
+	if len(data) < 240 {
 		return 0, ErrNotEnoughData
 	}
 
@@ -844,11 +839,65 @@ type ProcessEvent struct {
 	GID       uint32    `field:"gid"`
 	User      string    `field:"user" handler:"ResolveUser,string"`
 	Group     string    `field:"group" handler:"ResolveGroup,string"`
+	Pidns     uint32    `field:"pidns"`
+	Mntns     uint32    `field:"mntns"`
+	Netns     uint32    `field:"netns"`
+	AUID      uint32    `field:"auid" handler:"ResolveAUID,int"`
+	SessionID uint32    `field:"session_id" handler:"ResolveSessionID,int"`
 	Timestamp time.Time `field:"-" handler:"ResolveTimestamp,string"`
+	CreatedAt uint64    `field:"created_at" handler:"ResolveCreatedAt,int"`
+	Tags      []string  `field:"-"`
+
+	ExecMTime  uint64 `field:"file.mtime" handler:"ResolveExecMTime,int"`
+	ExecCTime  uint64 `field:"file.ctime" handler:"ResolveExecCTime,int"`
+	ExecSize   uint64 `field:"file.size" handler:"ResolveExecSize,int"`
+	ExecMode   uint32 `field:"file.mode" handler:"ResolveExecMode,int"`
+	ExecSetuid bool   `field:"file.setuid" handler:"ResolveExecSetuid,bool"`
+	ExecSetgid bool   `field:"file.setgid" handler:"ResolveExecSetgid,bool"`
+
+	InterpreterPathnameStr string `field:"interpreter.filename" handler:"ResolveInterpreter,string"`
+	SecurityContext        string `field:"security_context" handler:"ResolveSecurityContext,string"`
+	SessionSourceIP        string `field:"session.source_ip" handler:"ResolveSessionSourceIP,string"`
+
+	auditResolved       bool `field:"-"`
+	tagsResolved        bool `field:"-"`
+	executableResolved  bool `field:"-"`
+	interpreterResolved bool `field:"-"`
+	contextResolved     bool `field:"-"`
+	sessionResolved     bool `field:"-"`
 
 	CommRaw [16]byte `field:"-"`
 }
 
+// setProcessCacheEntry sets the fields of the process context held by the given process cache entry, the ancestors of a
+// process only exist in the process cache
+func (p *ProcessEvent) setProcessCacheEntry(pid uint32, entry *ProcessCacheEntry) {
+	p.FileEvent = entry.FileEvent
+	p.FileEvent.pid = pid
+	p.Pid = pid
+	p.Comm = entry.Comm
+	p.TTYName = entry.GetTTY()
+	p.Timestamp = entry.Timestamp
+
+	p.AUID, p.SessionID = entry.LoginUID, entry.SessionID
+	p.auditResolved = true
+
+	p.ExecMTime = entry.Executable.MTime
+	p.ExecCTime = entry.Executable.CTime
+	p.ExecSize = entry.Executable.Size
+	p.ExecMode = entry.Executable.Mode
+	p.ExecSetuid = entry.Executable.Mode&syscall.S_ISUID != 0
+	p.ExecSetgid = entry.Executable.Mode&syscall.S_ISGID != 0
+	p.executableResolved = true
+
+	p.SecurityContext = entry.SecurityContext
+	p.contextResolved = true
+	p.SessionSourceIP = entry.SessionSourceIP
+	p.sessionResolved = true
+	p.Tags = entry.Tags
+	p.tagsResolved = true
+}
+
 // ResolveTimestamp converts a raw timestamp to a time object
 func (p *ProcessEvent) ResolveTimestamp(resolvers *Resolvers) time.Time {
 	if p.Timestamp.IsZero() {
@@ -863,6 +912,16 @@ func (p *ProcessEvent) ResolveTimestamp(resolvers *Resolvers) time.Time {
 	return p.Timestamp
 }
 
+// ResolveCreatedAt resolves the creation time of the process, in nanoseconds since epoch
+func (p *ProcessEvent) ResolveCreatedAt(resolvers *Resolvers) uint64 {
+	if p.CreatedAt == 0 {
+		if timestamp := p.ResolveTimestamp(resolvers); !timestamp.IsZero() {
+			p.CreatedAt = uint64(timestamp.UnixNano())
+		}
+	}
+	return p.CreatedAt
+}
+
 // ResolveInode resolves the inode to a full path
 func (p *ProcessEvent) ResolveInode(resolvers *Resolvers) string {
 	if p.PathnameStr == "" {
@@ -897,28 +956,6 @@ func (p *ProcessEvent) ResolveBasename(resolvers *Resolvers) string {
 	return p.BasenameStr
 }
 
-func (p *ProcessEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"name":"%s",`, p.ResolveComm(resolvers))
-	if tty := p.ResolveTTY(resolvers); tty != "" {
-		fmt.Fprintf(&buf, `"tty_name":"%s",`, tty)
-	}
-	fmt.Fprintf(&buf, `"pid":%d,`, p.Pid)
-	fmt.Fprintf(&buf, `"tid":%d,`, p.Tid)
-	fmt.Fprintf(&buf, `"uid":%d,`, p.UID)
-	fmt.Fprintf(&buf, `"gid":%d,`, p.GID)
-	fmt.Fprintf(&buf, `"filename":"%s",`, p.ResolveInode(resolvers))
-	fmt.Fprintf(&buf, `"container_path":"%s",`, p.ResolveContainerPath(resolvers))
-	fmt.Fprintf(&buf, `"inode":%d,`, p.Inode)
-	fmt.Fprintf(&buf, `"mount_id":%d,`, p.MountID)
-	fmt.Fprintf(&buf, `"overlay_numlower":%d,`, p.OverlayNumLower)
-	fmt.Fprintf(&buf, `"timestamp":"%s"`, p.ResolveTimestamp(resolvers))
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
-}
-
 // ResolveTTY resolves the name of the process tty
 func (p *ProcessEvent) ResolveTTY(resolvers *Resolvers) string {
 	if p.TTYName == "" {
@@ -930,6 +967,142 @@ func (p *ProcessEvent) ResolveTTY(resolvers *Resolvers) string {
 	return p.TTYName
 }
 
+// resolveAudit resolves the login uid and the session id of the process from the process cache
+func (p *ProcessEvent) resolveAudit(resolvers *Resolvers) {
+	if p.auditResolved {
+		return
+	}
+
+	p.AUID, p.SessionID = utils.AuditUnset, utils.AuditUnset
+	if entry := resolvers.ProcessResolver.Resolve(p.Pid); entry != nil {
+		p.AUID, p.SessionID = entry.LoginUID, entry.SessionID
+	}
+	p.auditResolved = true
+}
+
+// resolveExecutable resolves the metadata of the executable of the process, as it was at exec time, from the process cache
+func (p *ProcessEvent) resolveExecutable(resolvers *Resolvers) {
+	if p.executableResolved {
+		return
+	}
+
+	if entry := resolvers.ProcessResolver.Resolve(p.Pid); entry != nil {
+		p.ExecMTime = entry.Executable.MTime
+		p.ExecCTime = entry.Executable.CTime
+		p.ExecSize = entry.Executable.Size
+		p.ExecMode = entry.Executable.Mode
+		p.ExecSetuid = entry.Executable.Mode&syscall.S_ISUID != 0
+		p.ExecSetgid = entry.Executable.Mode&syscall.S_ISGID != 0
+	}
+	p.executableResolved = true
+}
+
+// ResolveExecMTime resolves the modification time of the executable, in nanoseconds since epoch
+func (p *ProcessEvent) ResolveExecMTime(resolvers *Resolvers) uint64 {
+	p.resolveExecutable(resolvers)
+	return p.ExecMTime
+}
+
+// ResolveExecCTime resolves the change time of the executable, in nanoseconds since epoch
+func (p *ProcessEvent) ResolveExecCTime(resolvers *Resolvers) uint64 {
+	p.resolveExecutable(resolvers)
+	return p.ExecCTime
+}
+
+// ResolveExecSize resolves the size of the executable
+func (p *ProcessEvent) ResolveExecSize(resolvers *Resolvers) uint64 {
+	p.resolveExecutable(resolvers)
+	return p.ExecSize
+}
+
+// ResolveExecMode resolves the mode of the executable
+func (p *ProcessEvent) ResolveExecMode(resolvers *Resolvers) uint32 {
+	p.resolveExecutable(resolvers)
+	return p.ExecMode
+}
+
+// ResolveExecSetuid returns whether the executable has the setuid bit set
+func (p *ProcessEvent) ResolveExecSetuid(resolvers *Resolvers) bool {
+	p.resolveExecutable(resolvers)
+	return p.ExecSetuid
+}
+
+// ResolveExecSetgid returns whether the executable has the setgid bit set
+func (p *ProcessEvent) ResolveExecSetgid(resolvers *Resolvers) bool {
+	p.resolveExecutable(resolvers)
+	return p.ExecSetgid
+}
+
+// ResolveInterpreter resolves the path of the interpreter of the executed script, empty if the executable isn't a script
+func (p *ProcessEvent) ResolveInterpreter(resolvers *Resolvers) string {
+	if !p.interpreterResolved {
+		if entry := resolvers.ProcessResolver.Resolve(p.Pid); entry != nil && entry.Interpreter.Inode != 0 {
+			p.InterpreterPathnameStr = entry.Interpreter.ResolveInode(resolvers)
+		}
+		p.interpreterResolved = true
+	}
+	return p.InterpreterPathnameStr
+}
+
+// ResolveSecurityContext resolves the LSM security context of the process, its SELinux context or its AppArmor profile
+func (p *ProcessEvent) ResolveSecurityContext(resolvers *Resolvers) string {
+	if !p.contextResolved {
+		if entry := resolvers.ProcessResolver.Resolve(p.Pid); entry != nil {
+			p.SecurityContext = entry.SecurityContext
+		}
+		p.contextResolved = true
+	}
+	return p.SecurityContext
+}
+
+// ResolveSessionSourceIP resolves the remote address of the SSH session the process belongs to
+func (p *ProcessEvent) ResolveSessionSourceIP(resolvers *Resolvers) string {
+	if !p.sessionResolved {
+		if entry := resolvers.ProcessResolver.Resolve(p.Pid); entry != nil {
+			p.SessionSourceIP = entry.SessionSourceIP
+		}
+		p.sessionResolved = true
+	}
+	return p.SessionSourceIP
+}
+
+// ResolveSecurityLabel resolves the SELinux label of the executable of the process
+func (p *ProcessEvent) ResolveSecurityLabel(resolvers *Resolvers) string {
+	p.ResolveInode(resolvers)
+	p.FileEvent.pid = p.Pid
+	return p.FileEvent.ResolveSecurityLabel(resolvers)
+}
+
+// ResolveSHA256 resolves the SHA256 checksum of the executable of the process
+func (p *ProcessEvent) ResolveSHA256(resolvers *Resolvers) string {
+	p.ResolveInode(resolvers)
+	p.FileEvent.pid = p.Pid
+	return p.FileEvent.ResolveSHA256(resolvers)
+}
+
+// ResolveTags resolves the tags extracted from the environment of the process
+func (p *ProcessEvent) ResolveTags(resolvers *Resolvers) []string {
+	if !p.tagsResolved {
+		if entry := resolvers.ProcessResolver.Resolve(p.Pid); entry != nil {
+			p.Tags = entry.Tags
+		}
+		p.tagsResolved = true
+	}
+	return p.Tags
+}
+
+// ResolveAUID resolves the login uid of the process, this is the uid of the user who logged in, even after sudo/su
+func (p *ProcessEvent) ResolveAUID(resolvers *Resolvers) uint32 {
+	p.resolveAudit(resolvers)
+	return p.AUID
+}
+
+// ResolveSessionID resolves the audit session id of the process
+func (p *ProcessEvent) ResolveSessionID(resolvers *Resolvers) uint32 {
+	p.resolveAudit(resolvers)
+	return p.SessionID
+}
+
 // ResolveComm resolves the comm of the process
 func (p *ProcessEvent) ResolveComm(resolvers *Resolvers) string {
 	if len(p.Comm) == 0 {
@@ -958,7 +1131,7 @@ func (p *ProcessEvent) ResolveGroup(resolvers *Resolvers) string {
 
 // UnmarshalBinary unmarshals a binary representation of itself
 func (p *ProcessEvent) UnmarshalBinary(data []byte) (int, error) {
-	if len(data) < 32 {
+	if len(data) < 48 {
 		return 0, ErrNotEnoughData
 	}
 
@@ -968,8 +1141,12 @@ func (p *ProcessEvent) UnmarshalBinary(data []byte) (int, error) {
 	p.Tid = ebpf.ByteOrder.Uint32(data[20:24])
 	p.UID = ebpf.ByteOrder.Uint32(data[24:28])
 	p.GID = ebpf.ByteOrder.Uint32(data[28:32])
+	p.Pidns = ebpf.ByteOrder.Uint32(data[32:36])
+	p.Mntns = ebpf.ByteOrder.Uint32(data[36:40])
+	p.Netns = ebpf.ByteOrder.Uint32(data[40:44])
 
-	return 32, nil
+	// 4 of padding
+	return 48, nil
 }
 
 // Event represents an event sent from the kernel
@@ -993,7 +1170,7 @@ type Event struct {
 	Link             LinkEvent             `yaml:"link" field:"link" event:"link"`
 	SetXAttr         SetXAttrEvent         `yaml:"setxattr" field:"setxattr" event:"setxattr"`
 	RemoveXAttr      SetXAttrEvent         `yaml:"removexattr" field:"removexattr" event:"removexattr"`
-	Mount            MountEvent            `field:"-"`
+	Mount            MountEvent            `yaml:"mount" field:"mount" event:"mount"`
 	Umount           UmountEvent           `field:"-"`
 	Exec             ExecEvent             `field:"-"`
 	Exit             ExitEvent             `field:"-"`
@@ -1002,6 +1179,17 @@ type Event struct {
 	resolvers *Resolvers `field:"-"`
 }
 
+// setFilesPid sets the process the files of the event were accessed by, the process of the event
+func (e *Event) setFilesPid() {
+	for _, file := range []*FileEvent{
+		&e.Chmod.FileEvent, &e.Chown.FileEvent, &e.Open.FileEvent, &e.Mkdir.FileEvent, &e.Rmdir.FileEvent,
+		&e.Rename.Old, &e.Rename.New, &e.Unlink.FileEvent, &e.Utimes.FileEvent, &e.Link.Source, &e.Link.Target,
+		&e.SetXAttr.FileEvent, &e.RemoveXAttr.FileEvent,
+	} {
+		file.pid = e.Process.Pid
+	}
+}
+
 func (e *Event) String() string {
 	d, err := json.Marshal(e)
 	if err != nil {
@@ -1010,11 +1198,6 @@ func (e *Event) String() string {
 	return string(d)
 }
 
-type eventMarshaler struct {
-	field      string
-	marshalFnc func(resolvers *Resolvers) ([]byte, error)
-}
-
 // ResolveMonotonicTimestamp resolves the monolitic kernel timestamp to an absolute time
 func (e *Event) ResolveMonotonicTimestamp(resolvers *Resolvers) time.Time {
 	if e.Timestamp.IsZero() {
@@ -1025,226 +1208,7 @@ func (e *Event) ResolveMonotonicTimestamp(resolvers *Resolvers) time.Time {
 
 // MarshalJSON returns the JSON encoding of the event
 func (e *Event) MarshalJSON() ([]byte, error) {
-	eventID, _ := uuid.NewRandom()
-
-	var buf bytes.Buffer
-	buf.WriteRune('{')
-	fmt.Fprintf(&buf, `"id":"%s",`, eventID)
-	fmt.Fprintf(&buf, `"timestamp":"%s"`, e.ResolveMonotonicTimestamp(e.resolvers))
-
-	var entries []eventMarshaler
-
-	eventType := EventType(e.Type)
-
-	eventMarshalJSON := func(e *SyscallEvent) func(*Resolvers) ([]byte, error) {
-		return func(resolvers *Resolvers) ([]byte, error) {
-			return e.marshalJSON(eventType, resolvers)
-		}
-	}
-
-	switch eventType {
-	case FileChmodEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Chmod.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "process",
-				marshalFnc: e.Process.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "container",
-				marshalFnc: e.Container.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "file",
-				marshalFnc: e.Chmod.marshalJSON,
-			})
-	case FileChownEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Chown.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "process",
-				marshalFnc: e.Process.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "container",
-				marshalFnc: e.Container.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "file",
-				marshalFnc: e.Chown.marshalJSON,
-			})
-	case FileOpenEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Open.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "process",
-				marshalFnc: e.Process.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "container",
-				marshalFnc: e.Container.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "file",
-				marshalFnc: e.Open.marshalJSON,
-			})
-	case FileMkdirEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Mkdir.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "process",
-				marshalFnc: e.Process.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "container",
-				marshalFnc: e.Container.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "file",
-				marshalFnc: e.Mkdir.marshalJSON,
-			})
-	case FileRmdirEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Rmdir.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "process",
-				marshalFnc: e.Process.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "container",
-				marshalFnc: e.Container.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "file",
-				marshalFnc: e.Rmdir.marshalJSON,
-			})
-	case FileUnlinkEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Unlink.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "process",
-				marshalFnc: e.Process.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "container",
-				marshalFnc: e.Container.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "file",
-				marshalFnc: e.Unlink.marshalJSON,
-			})
-	case FileRenameEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Rename.SyscallEvent),
-			},
-			eventMarshaler{
-				marshalFnc: e.Rename.marshalJSON,
-			})
-	case FileUtimeEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Utimes.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "process",
-				marshalFnc: e.Process.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "container",
-				marshalFnc: e.Container.marshalJSON,
-			},
-			eventMarshaler{
-				field:      "file",
-				marshalFnc: e.Utimes.marshalJSON,
-			})
-	case FileLinkEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Link.SyscallEvent),
-			},
-			eventMarshaler{
-				marshalFnc: e.Link.marshalJSON,
-			})
-	case FileMountEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Mount.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "mount",
-				marshalFnc: e.Mount.marshalJSON,
-			})
-	case FileUmountEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.Umount.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "umount",
-				marshalFnc: e.Umount.marshalJSON,
-			})
-	case FileSetXAttrEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.SetXAttr.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "file",
-				marshalFnc: e.SetXAttr.marshalJSON,
-			})
-	case FileRemoveXAttrEventType:
-		entries = append(entries,
-			eventMarshaler{
-				field:      "syscall",
-				marshalFnc: eventMarshalJSON(&e.RemoveXAttr.SyscallEvent),
-			},
-			eventMarshaler{
-				field:      "file",
-				marshalFnc: e.RemoveXAttr.marshalJSON,
-			})
-	}
-
-	for _, entry := range entries {
-		d, err := entry.marshalFnc(e.resolvers)
-		if err != nil {
-			return nil, errors.Wrapf(err, "in %s", entry.field)
-		}
-		if d != nil {
-			buf.WriteRune(',')
-			if entry.field != "" {
-				buf.WriteString(`"` + entry.field + `":`)
-			}
-			buf.Write(d)
-		}
-	}
-	buf.WriteRune('}')
-
-	return buf.Bytes(), nil
+	return json.Marshal(NewEventSerializer(e))
 }
 
 // GetType returns the event type
@@ -1255,7 +1219,11 @@ func (e *Event) GetType() string {
 // GetTags returns the list of tags specific to this event
 func (e *Event) GetTags() []string {
 	// TODO: add container tags once we collect them
-	return []string{"type:" + e.GetType()}
+	tags := []string{"type:" + e.GetType()}
+	if e.resolvers != nil {
+		tags = append(tags, e.Process.ResolveTags(e.resolvers)...)
+	}
+	return tags
 }
 
 // GetPointer return an unsafe.Pointer of the Event
@@ -1279,6 +1247,22 @@ func (e *Event) Clone() Event {
 	return *e
 }
 
+// Copy returns a copy of the event allocated on the heap. The events dispatched by the probe are recycled once
+// handled, the handlers retaining them have to copy them
+func (e *Event) Copy() *Event {
+	event := *e
+	return &event
+}
+
+// ResolveFields resolves the serialized fields of the event. The caches of the resolvers change once the event is
+// handled, the copies of the events serialized later have to be resolved first
+func (e *Event) ResolveFields() {
+	// the events built without resolvers have nothing to resolve
+	if e.resolvers != nil {
+		_ = NewEventSerializer(e)
+	}
+}
+
 // NewEvent returns a new event
 func NewEvent(resolvers *Resolvers) *Event {
 	return &Event{
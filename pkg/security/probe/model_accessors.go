@@ -33,6 +33,40 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "chmod.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Chmod.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "chmod.file.gid":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Chmod.FileMetadata.GID) },
+
+			Field: field,
+		}, nil
+
+	case "chmod.file.mode":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Chmod.FileMetadata.Mode) },
+
+			Field: field,
+		}, nil
+
+	case "chmod.file.uid":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Chmod.FileMetadata.UID) },
+
+			Field: field,
+		}, nil
+
 	case "chmod.filename":
 
 		return &eval.StringEvaluator{
@@ -75,6 +109,26 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "chmod.security_label":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Chmod.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "chmod.sha256":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Chmod.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "chown.basename":
 
 		return &eval.StringEvaluator{
@@ -95,6 +149,40 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "chown.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Chown.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "chown.file.gid":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Chown.FileMetadata.GID) },
+
+			Field: field,
+		}, nil
+
+	case "chown.file.mode":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Chown.FileMetadata.Mode) },
+
+			Field: field,
+		}, nil
+
+	case "chown.file.uid":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Chown.FileMetadata.UID) },
+
+			Field: field,
+		}, nil
+
 	case "chown.filename":
 
 		return &eval.StringEvaluator{
@@ -113,6 +201,16 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "chown.group":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Chown.ResolveGroup((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "chown.inode":
 
 		return &eval.IntEvaluator{
@@ -137,6 +235,26 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "chown.security_label":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Chown.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "chown.sha256":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Chown.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "chown.uid":
 
 		return &eval.IntEvaluator{
@@ -145,6 +263,16 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "chown.user":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Chown.ResolveUser((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "container.id":
 
 		return &eval.StringEvaluator{
@@ -155,6 +283,26 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "container.image_digest":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Container.ResolveImageDigest((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "container.image_name":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Container.ResolveImageName((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "link.retval":
 
 		return &eval.IntEvaluator{
@@ -183,6 +331,16 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "link.source.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Link.Source.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "link.source.filename":
 
 		return &eval.StringEvaluator{
@@ -209,6 +367,26 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "link.source.security_label":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Link.Source.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "link.source.sha256":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Link.Source.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "link.target.basename":
 
 		return &eval.StringEvaluator{
@@ -229,6 +407,16 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "link.target.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Link.Target.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "link.target.filename":
 
 		return &eval.StringEvaluator{
@@ -255,6 +443,26 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "link.target.security_label":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Link.Target.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "link.target.sha256":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Link.Target.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "mkdir.basename":
 
 		return &eval.StringEvaluator{
@@ -275,6 +483,16 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "mkdir.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Mkdir.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "mkdir.filename":
 
 		return &eval.StringEvaluator{
@@ -317,635 +535,1191 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
-	case "open.basename":
+	case "mkdir.security_label":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Open.ResolveBasename((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Mkdir.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "open.container_path":
+	case "mkdir.sha256":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Open.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Mkdir.ResolveSHA256((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "open.filename":
+	case "mount.fs_type":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Open.ResolveInode((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Mount.ResolveFSType((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "open.flags":
+	case "mount.mount_point":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.Flags) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Mount.ResolveMountPoint((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "open.inode":
+	case "mount.retval":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.Inode) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Mount.Retval) },
 
 			Field: field,
 		}, nil
 
-	case "open.mode":
+	case "mount.root":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.Mode) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Mount.ResolveRoot((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "open.overlay_numlower":
+	case "mount.source":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.OverlayNumLower) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string { return (*Event)(ctx.Object).Mount.Source },
 
 			Field: field,
 		}, nil
 
-	case "open.retval":
+	case "open.basename":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.Retval) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Open.ResolveBasename((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "process.basename":
+	case "open.container_path":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Process.ResolveBasename((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Open.ResolveContainerPath((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "process.container_path":
+	case "open.destination.path":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Process.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Open.ResolveDestination((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "process.filename":
+	case "open.filename":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Process.ResolveInode((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Open.ResolveInode((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "process.gid":
+	case "open.flags":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.GID) },
-
-			Field: field,
-		}, nil
-
-	case "process.group":
-
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Process.ResolveGroup((*Event)(ctx.Object).resolvers)
-			},
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.Flags) },
 
 			Field: field,
 		}, nil
 
-	case "process.inode":
+	case "open.inode":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.Inode) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.Inode) },
 
 			Field: field,
 		}, nil
 
-	case "process.name":
+	case "open.mode":
 
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Process.ResolveComm((*Event)(ctx.Object).resolvers)
-			},
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.Mode) },
 
 			Field: field,
 		}, nil
 
-	case "process.overlay_numlower":
+	case "open.overlay_numlower":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.OverlayNumLower) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.OverlayNumLower) },
 
 			Field: field,
 		}, nil
 
-	case "process.pid":
+	case "open.retval":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.Pid) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Open.Retval) },
 
 			Field: field,
 		}, nil
 
-	case "process.tid":
+	case "open.security_label":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.Tid) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Open.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "process.tty_name":
+	case "open.sha256":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Process.ResolveTTY((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Open.ResolveSHA256((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "process.uid":
+	case "process.auid":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.UID) },
+			EvalFnc: func(ctx *eval.Context) int {
+				return int((*Event)(ctx.Object).Process.ResolveAUID((*Event)(ctx.Object).resolvers))
+			},
 
 			Field: field,
 		}, nil
 
-	case "process.user":
+	case "process.basename":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Process.ResolveUser((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveBasename((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "removexattr.basename":
+	case "process.container_path":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).RemoveXAttr.ResolveBasename((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveContainerPath((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "removexattr.container_path":
+	case "process.created_at":
 
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).RemoveXAttr.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int {
+				return int((*Event)(ctx.Object).Process.ResolveCreatedAt((*Event)(ctx.Object).resolvers))
 			},
 
 			Field: field,
 		}, nil
 
-	case "removexattr.filename":
+	case "process.destination.path":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).RemoveXAttr.ResolveInode((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveDestination((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "removexattr.inode":
+	case "process.file.ctime":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).RemoveXAttr.Inode) },
+			EvalFnc: func(ctx *eval.Context) int {
+				return int((*Event)(ctx.Object).Process.ResolveExecCTime((*Event)(ctx.Object).resolvers))
+			},
 
 			Field: field,
 		}, nil
 
-	case "removexattr.name":
+	case "process.file.mode":
 
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).RemoveXAttr.GetName((*Event)(ctx.Object).resolvers)
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int {
+				return int((*Event)(ctx.Object).Process.ResolveExecMode((*Event)(ctx.Object).resolvers))
 			},
 
 			Field: field,
 		}, nil
 
-	case "removexattr.namespace":
+	case "process.file.mtime":
 
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).RemoveXAttr.GetNamespace((*Event)(ctx.Object).resolvers)
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int {
+				return int((*Event)(ctx.Object).Process.ResolveExecMTime((*Event)(ctx.Object).resolvers))
 			},
 
 			Field: field,
 		}, nil
 
-	case "removexattr.overlay_numlower":
+	case "process.file.setgid":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).RemoveXAttr.OverlayNumLower) },
+		return &eval.BoolEvaluator{
+			EvalFnc: func(ctx *eval.Context) bool {
+				return (*Event)(ctx.Object).Process.ResolveExecSetgid((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "removexattr.retval":
+	case "process.file.setuid":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).RemoveXAttr.Retval) },
+		return &eval.BoolEvaluator{
+			EvalFnc: func(ctx *eval.Context) bool {
+				return (*Event)(ctx.Object).Process.ResolveExecSetuid((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "rename.new.basename":
+	case "process.file.size":
 
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Rename.New.ResolveBasename((*Event)(ctx.Object).resolvers)
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int {
+				return int((*Event)(ctx.Object).Process.ResolveExecSize((*Event)(ctx.Object).resolvers))
 			},
 
 			Field: field,
 		}, nil
 
-	case "rename.new.container_path":
+	case "process.filename":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Rename.New.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveInode((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "rename.new.filename":
+	case "process.gid":
 
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Rename.New.ResolveInode((*Event)(ctx.Object).resolvers)
-			},
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.GID) },
 
 			Field: field,
 		}, nil
 
-	case "rename.new.inode":
+	case "process.group":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.New.Inode) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Process.ResolveGroup((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "rename.new.overlay_numlower":
+	case "process.inode":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.New.OverlayNumLower) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.Inode) },
 
 			Field: field,
 		}, nil
 
-	case "rename.old.basename":
+	case "process.interpreter.filename":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Rename.Old.ResolveBasename((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveInterpreter((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "rename.old.container_path":
+	case "process.mntns":
 
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Rename.Old.ResolveContainerPath((*Event)(ctx.Object).resolvers)
-			},
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.Mntns) },
 
 			Field: field,
 		}, nil
 
-	case "rename.old.filename":
+	case "process.name":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Rename.Old.ResolveInode((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveComm((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "rename.old.inode":
+	case "process.netns":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.Old.Inode) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.Netns) },
 
 			Field: field,
 		}, nil
 
-	case "rename.old.overlay_numlower":
+	case "process.overlay_numlower":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.Old.OverlayNumLower) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.OverlayNumLower) },
 
 			Field: field,
 		}, nil
 
-	case "rename.retval":
+	case "process.pid":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.Retval) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.Pid) },
 
 			Field: field,
 		}, nil
 
-	case "rmdir.basename":
+	case "process.pidns":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.Pidns) },
+
+			Field: field,
+		}, nil
+
+	case "process.security_context":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Rmdir.ResolveBasename((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveSecurityContext((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "rmdir.container_path":
+	case "process.security_label":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Rmdir.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "rmdir.filename":
+	case "process.session.source_ip":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Rmdir.ResolveInode((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveSessionSourceIP((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "rmdir.inode":
+	case "process.session_id":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rmdir.Inode) },
+			EvalFnc: func(ctx *eval.Context) int {
+				return int((*Event)(ctx.Object).Process.ResolveSessionID((*Event)(ctx.Object).resolvers))
+			},
 
 			Field: field,
 		}, nil
 
-	case "rmdir.overlay_numlower":
+	case "process.sha256":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rmdir.OverlayNumLower) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Process.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "rmdir.retval":
+	case "process.tid":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rmdir.Retval) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.Tid) },
 
 			Field: field,
 		}, nil
 
-	case "setxattr.basename":
+	case "process.tty_name":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).SetXAttr.ResolveBasename((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveTTY((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "setxattr.container_path":
+	case "process.uid":
 
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).SetXAttr.ResolveContainerPath((*Event)(ctx.Object).resolvers)
-			},
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Process.UID) },
 
 			Field: field,
 		}, nil
 
-	case "setxattr.filename":
+	case "process.user":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).SetXAttr.ResolveInode((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Process.ResolveUser((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "setxattr.inode":
+	case "removexattr.basename":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).SetXAttr.Inode) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).RemoveXAttr.ResolveBasename((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "setxattr.name":
+	case "removexattr.container_path":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).SetXAttr.GetName((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).RemoveXAttr.ResolveContainerPath((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "setxattr.namespace":
+	case "removexattr.destination.path":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).SetXAttr.GetNamespace((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).RemoveXAttr.ResolveDestination((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "setxattr.overlay_numlower":
+	case "removexattr.filename":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).SetXAttr.OverlayNumLower) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).RemoveXAttr.ResolveInode((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "setxattr.retval":
+	case "removexattr.inode":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).SetXAttr.Retval) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).RemoveXAttr.Inode) },
 
 			Field: field,
 		}, nil
 
-	case "unlink.basename":
+	case "removexattr.name":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Unlink.ResolveBasename((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).RemoveXAttr.GetName((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "unlink.container_path":
+	case "removexattr.namespace":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Unlink.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).RemoveXAttr.GetNamespace((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "unlink.filename":
+	case "removexattr.overlay_numlower":
 
-		return &eval.StringEvaluator{
-			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Unlink.ResolveInode((*Event)(ctx.Object).resolvers)
-			},
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).RemoveXAttr.OverlayNumLower) },
 
 			Field: field,
 		}, nil
 
-	case "unlink.flags":
+	case "removexattr.retval":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.Flags) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).RemoveXAttr.Retval) },
 
 			Field: field,
 		}, nil
 
-	case "unlink.inode":
+	case "removexattr.security_label":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.Inode) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).RemoveXAttr.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "unlink.overlay_numlower":
+	case "removexattr.sha256":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.OverlayNumLower) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).RemoveXAttr.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "unlink.retval":
+	case "removexattr.value":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).RemoveXAttr.GetValue((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "removexattr.value_size":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.Retval) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).RemoveXAttr.ValueSize) },
 
 			Field: field,
 		}, nil
 
-	case "utimes.basename":
+	case "rename.new.basename":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Utimes.ResolveBasename((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Rename.New.ResolveBasename((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "utimes.container_path":
+	case "rename.new.container_path":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Utimes.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Rename.New.ResolveContainerPath((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "utimes.filename":
+	case "rename.new.destination.path":
 
 		return &eval.StringEvaluator{
 			EvalFnc: func(ctx *eval.Context) string {
-				return (*Event)(ctx.Object).Utimes.ResolveInode((*Event)(ctx.Object).resolvers)
+				return (*Event)(ctx.Object).Rename.New.ResolveDestination((*Event)(ctx.Object).resolvers)
 			},
 
 			Field: field,
 		}, nil
 
-	case "utimes.inode":
+	case "rename.new.filename":
 
-		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Utimes.Inode) },
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rename.New.ResolveInode((*Event)(ctx.Object).resolvers)
+			},
 
 			Field: field,
 		}, nil
 
-	case "utimes.overlay_numlower":
+	case "rename.new.inode":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Utimes.OverlayNumLower) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.New.Inode) },
 
 			Field: field,
 		}, nil
 
-	case "utimes.retval":
+	case "rename.new.overlay_numlower":
 
 		return &eval.IntEvaluator{
-			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Utimes.Retval) },
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.New.OverlayNumLower) },
 
 			Field: field,
 		}, nil
 
-	}
+	case "rename.new.security_label":
 
-	return nil, &eval.ErrFieldNotFound{Field: field}
-}
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rename.New.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
 
-func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
-	switch field {
+			Field: field,
+		}, nil
 
-	case "chmod.basename":
+	case "rename.new.sha256":
 
-		return e.Chmod.ResolveBasename(e.resolvers), nil
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rename.New.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
 
-	case "chmod.container_path":
+			Field: field,
+		}, nil
 
-		return e.Chmod.ResolveContainerPath(e.resolvers), nil
+	case "rename.old.basename":
 
-	case "chmod.filename":
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rename.Old.ResolveBasename((*Event)(ctx.Object).resolvers)
+			},
 
-		return e.Chmod.ResolveInode(e.resolvers), nil
+			Field: field,
+		}, nil
 
-	case "chmod.inode":
+	case "rename.old.container_path":
 
-		return int(e.Chmod.Inode), nil
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rename.Old.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rename.old.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rename.Old.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rename.old.filename":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rename.Old.ResolveInode((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rename.old.inode":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.Old.Inode) },
+
+			Field: field,
+		}, nil
+
+	case "rename.old.overlay_numlower":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.Old.OverlayNumLower) },
+
+			Field: field,
+		}, nil
+
+	case "rename.old.security_label":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rename.Old.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rename.old.sha256":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rename.Old.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rename.retval":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rename.Retval) },
+
+			Field: field,
+		}, nil
+
+	case "rmdir.basename":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rmdir.ResolveBasename((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rmdir.container_path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rmdir.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rmdir.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rmdir.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rmdir.filename":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rmdir.ResolveInode((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rmdir.inode":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rmdir.Inode) },
+
+			Field: field,
+		}, nil
+
+	case "rmdir.overlay_numlower":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rmdir.OverlayNumLower) },
+
+			Field: field,
+		}, nil
+
+	case "rmdir.retval":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Rmdir.Retval) },
+
+			Field: field,
+		}, nil
+
+	case "rmdir.security_label":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rmdir.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "rmdir.sha256":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Rmdir.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.basename":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).SetXAttr.ResolveBasename((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.container_path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).SetXAttr.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).SetXAttr.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.filename":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).SetXAttr.ResolveInode((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.inode":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).SetXAttr.Inode) },
+
+			Field: field,
+		}, nil
+
+	case "setxattr.name":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).SetXAttr.GetName((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.namespace":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).SetXAttr.GetNamespace((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.overlay_numlower":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).SetXAttr.OverlayNumLower) },
+
+			Field: field,
+		}, nil
+
+	case "setxattr.retval":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).SetXAttr.Retval) },
+
+			Field: field,
+		}, nil
+
+	case "setxattr.security_label":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).SetXAttr.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.sha256":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).SetXAttr.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.value":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).SetXAttr.GetValue((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "setxattr.value_size":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).SetXAttr.ValueSize) },
+
+			Field: field,
+		}, nil
+
+	case "unlink.basename":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Unlink.ResolveBasename((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "unlink.container_path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Unlink.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "unlink.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Unlink.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "unlink.file.gid":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.FileMetadata.GID) },
+
+			Field: field,
+		}, nil
+
+	case "unlink.file.mode":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.FileMetadata.Mode) },
+
+			Field: field,
+		}, nil
+
+	case "unlink.file.uid":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.FileMetadata.UID) },
+
+			Field: field,
+		}, nil
+
+	case "unlink.filename":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Unlink.ResolveInode((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "unlink.flags":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.Flags) },
+
+			Field: field,
+		}, nil
+
+	case "unlink.inode":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.Inode) },
+
+			Field: field,
+		}, nil
+
+	case "unlink.overlay_numlower":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.OverlayNumLower) },
+
+			Field: field,
+		}, nil
+
+	case "unlink.retval":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Unlink.Retval) },
+
+			Field: field,
+		}, nil
+
+	case "unlink.security_label":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Unlink.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "unlink.sha256":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Unlink.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "utimes.basename":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Utimes.ResolveBasename((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "utimes.container_path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Utimes.ResolveContainerPath((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "utimes.destination.path":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Utimes.ResolveDestination((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "utimes.filename":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Utimes.ResolveInode((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "utimes.inode":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Utimes.Inode) },
+
+			Field: field,
+		}, nil
+
+	case "utimes.overlay_numlower":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Utimes.OverlayNumLower) },
+
+			Field: field,
+		}, nil
+
+	case "utimes.retval":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int { return int((*Event)(ctx.Object).Utimes.Retval) },
+
+			Field: field,
+		}, nil
+
+	case "utimes.security_label":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Utimes.ResolveSecurityLabel((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "utimes.sha256":
+
+		return &eval.StringEvaluator{
+			EvalFnc: func(ctx *eval.Context) string {
+				return (*Event)(ctx.Object).Utimes.ResolveSHA256((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	}
+
+	return nil, &eval.ErrFieldNotFound{Field: field}
+}
+
+func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
+	switch field {
+
+	case "chmod.basename":
+
+		return e.Chmod.ResolveBasename(e.resolvers), nil
+
+	case "chmod.container_path":
+
+		return e.Chmod.ResolveContainerPath(e.resolvers), nil
+
+	case "chmod.destination.path":
+
+		return e.Chmod.ResolveDestination(e.resolvers), nil
+
+	case "chmod.file.gid":
+
+		return int(e.Chmod.FileMetadata.GID), nil
+
+	case "chmod.file.mode":
+
+		return int(e.Chmod.FileMetadata.Mode), nil
+
+	case "chmod.file.uid":
+
+		return int(e.Chmod.FileMetadata.UID), nil
+
+	case "chmod.filename":
+
+		return e.Chmod.ResolveInode(e.resolvers), nil
+
+	case "chmod.inode":
+
+		return int(e.Chmod.Inode), nil
 
 	case "chmod.mode":
 
@@ -959,6 +1733,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Chmod.Retval), nil
 
+	case "chmod.security_label":
+
+		return e.Chmod.ResolveSecurityLabel(e.resolvers), nil
+
+	case "chmod.sha256":
+
+		return e.Chmod.ResolveSHA256(e.resolvers), nil
+
 	case "chown.basename":
 
 		return e.Chown.ResolveBasename(e.resolvers), nil
@@ -967,6 +1749,22 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Chown.ResolveContainerPath(e.resolvers), nil
 
+	case "chown.destination.path":
+
+		return e.Chown.ResolveDestination(e.resolvers), nil
+
+	case "chown.file.gid":
+
+		return int(e.Chown.FileMetadata.GID), nil
+
+	case "chown.file.mode":
+
+		return int(e.Chown.FileMetadata.Mode), nil
+
+	case "chown.file.uid":
+
+		return int(e.Chown.FileMetadata.UID), nil
+
 	case "chown.filename":
 
 		return e.Chown.ResolveInode(e.resolvers), nil
@@ -975,6 +1773,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Chown.GID), nil
 
+	case "chown.group":
+
+		return e.Chown.ResolveGroup(e.resolvers), nil
+
 	case "chown.inode":
 
 		return int(e.Chown.Inode), nil
@@ -987,14 +1789,34 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Chown.Retval), nil
 
+	case "chown.security_label":
+
+		return e.Chown.ResolveSecurityLabel(e.resolvers), nil
+
+	case "chown.sha256":
+
+		return e.Chown.ResolveSHA256(e.resolvers), nil
+
 	case "chown.uid":
 
 		return int(e.Chown.UID), nil
 
+	case "chown.user":
+
+		return e.Chown.ResolveUser(e.resolvers), nil
+
 	case "container.id":
 
 		return e.Container.ResolveContainerID(e.resolvers), nil
 
+	case "container.image_digest":
+
+		return e.Container.ResolveImageDigest(e.resolvers), nil
+
+	case "container.image_name":
+
+		return e.Container.ResolveImageName(e.resolvers), nil
+
 	case "link.retval":
 
 		return int(e.Link.Retval), nil
@@ -1007,6 +1829,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Link.Source.ResolveContainerPath(e.resolvers), nil
 
+	case "link.source.destination.path":
+
+		return e.Link.Source.ResolveDestination(e.resolvers), nil
+
 	case "link.source.filename":
 
 		return e.Link.Source.ResolveInode(e.resolvers), nil
@@ -1019,6 +1845,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Link.Source.OverlayNumLower), nil
 
+	case "link.source.security_label":
+
+		return e.Link.Source.ResolveSecurityLabel(e.resolvers), nil
+
+	case "link.source.sha256":
+
+		return e.Link.Source.ResolveSHA256(e.resolvers), nil
+
 	case "link.target.basename":
 
 		return e.Link.Target.ResolveBasename(e.resolvers), nil
@@ -1027,6 +1861,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Link.Target.ResolveContainerPath(e.resolvers), nil
 
+	case "link.target.destination.path":
+
+		return e.Link.Target.ResolveDestination(e.resolvers), nil
+
 	case "link.target.filename":
 
 		return e.Link.Target.ResolveInode(e.resolvers), nil
@@ -1039,6 +1877,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Link.Target.OverlayNumLower), nil
 
+	case "link.target.security_label":
+
+		return e.Link.Target.ResolveSecurityLabel(e.resolvers), nil
+
+	case "link.target.sha256":
+
+		return e.Link.Target.ResolveSHA256(e.resolvers), nil
+
 	case "mkdir.basename":
 
 		return e.Mkdir.ResolveBasename(e.resolvers), nil
@@ -1047,6 +1893,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Mkdir.ResolveContainerPath(e.resolvers), nil
 
+	case "mkdir.destination.path":
+
+		return e.Mkdir.ResolveDestination(e.resolvers), nil
+
 	case "mkdir.filename":
 
 		return e.Mkdir.ResolveInode(e.resolvers), nil
@@ -1067,6 +1917,34 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Mkdir.Retval), nil
 
+	case "mkdir.security_label":
+
+		return e.Mkdir.ResolveSecurityLabel(e.resolvers), nil
+
+	case "mkdir.sha256":
+
+		return e.Mkdir.ResolveSHA256(e.resolvers), nil
+
+	case "mount.fs_type":
+
+		return e.Mount.ResolveFSType(e.resolvers), nil
+
+	case "mount.mount_point":
+
+		return e.Mount.ResolveMountPoint(e.resolvers), nil
+
+	case "mount.retval":
+
+		return int(e.Mount.Retval), nil
+
+	case "mount.root":
+
+		return e.Mount.ResolveRoot(e.resolvers), nil
+
+	case "mount.source":
+
+		return e.Mount.Source, nil
+
 	case "open.basename":
 
 		return e.Open.ResolveBasename(e.resolvers), nil
@@ -1075,6 +1953,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Open.ResolveContainerPath(e.resolvers), nil
 
+	case "open.destination.path":
+
+		return e.Open.ResolveDestination(e.resolvers), nil
+
 	case "open.filename":
 
 		return e.Open.ResolveInode(e.resolvers), nil
@@ -1099,6 +1981,18 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Open.Retval), nil
 
+	case "open.security_label":
+
+		return e.Open.ResolveSecurityLabel(e.resolvers), nil
+
+	case "open.sha256":
+
+		return e.Open.ResolveSHA256(e.resolvers), nil
+
+	case "process.auid":
+
+		return int(e.Process.ResolveAUID(e.resolvers)), nil
+
 	case "process.basename":
 
 		return e.Process.ResolveBasename(e.resolvers), nil
@@ -1107,6 +2001,38 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Process.ResolveContainerPath(e.resolvers), nil
 
+	case "process.created_at":
+
+		return int(e.Process.ResolveCreatedAt(e.resolvers)), nil
+
+	case "process.destination.path":
+
+		return e.Process.ResolveDestination(e.resolvers), nil
+
+	case "process.file.ctime":
+
+		return int(e.Process.ResolveExecCTime(e.resolvers)), nil
+
+	case "process.file.mode":
+
+		return int(e.Process.ResolveExecMode(e.resolvers)), nil
+
+	case "process.file.mtime":
+
+		return int(e.Process.ResolveExecMTime(e.resolvers)), nil
+
+	case "process.file.setgid":
+
+		return e.Process.ResolveExecSetgid(e.resolvers), nil
+
+	case "process.file.setuid":
+
+		return e.Process.ResolveExecSetuid(e.resolvers), nil
+
+	case "process.file.size":
+
+		return int(e.Process.ResolveExecSize(e.resolvers)), nil
+
 	case "process.filename":
 
 		return e.Process.ResolveInode(e.resolvers), nil
@@ -1123,10 +2049,22 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Process.Inode), nil
 
+	case "process.interpreter.filename":
+
+		return e.Process.ResolveInterpreter(e.resolvers), nil
+
+	case "process.mntns":
+
+		return int(e.Process.Mntns), nil
+
 	case "process.name":
 
 		return e.Process.ResolveComm(e.resolvers), nil
 
+	case "process.netns":
+
+		return int(e.Process.Netns), nil
+
 	case "process.overlay_numlower":
 
 		return int(e.Process.OverlayNumLower), nil
@@ -1135,6 +2073,30 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Process.Pid), nil
 
+	case "process.pidns":
+
+		return int(e.Process.Pidns), nil
+
+	case "process.security_context":
+
+		return e.Process.ResolveSecurityContext(e.resolvers), nil
+
+	case "process.security_label":
+
+		return e.Process.ResolveSecurityLabel(e.resolvers), nil
+
+	case "process.session.source_ip":
+
+		return e.Process.ResolveSessionSourceIP(e.resolvers), nil
+
+	case "process.session_id":
+
+		return int(e.Process.ResolveSessionID(e.resolvers)), nil
+
+	case "process.sha256":
+
+		return e.Process.ResolveSHA256(e.resolvers), nil
+
 	case "process.tid":
 
 		return int(e.Process.Tid), nil
@@ -1159,6 +2121,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.RemoveXAttr.ResolveContainerPath(e.resolvers), nil
 
+	case "removexattr.destination.path":
+
+		return e.RemoveXAttr.ResolveDestination(e.resolvers), nil
+
 	case "removexattr.filename":
 
 		return e.RemoveXAttr.ResolveInode(e.resolvers), nil
@@ -1183,6 +2149,22 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.RemoveXAttr.Retval), nil
 
+	case "removexattr.security_label":
+
+		return e.RemoveXAttr.ResolveSecurityLabel(e.resolvers), nil
+
+	case "removexattr.sha256":
+
+		return e.RemoveXAttr.ResolveSHA256(e.resolvers), nil
+
+	case "removexattr.value":
+
+		return e.RemoveXAttr.GetValue(e.resolvers), nil
+
+	case "removexattr.value_size":
+
+		return int(e.RemoveXAttr.ValueSize), nil
+
 	case "rename.new.basename":
 
 		return e.Rename.New.ResolveBasename(e.resolvers), nil
@@ -1191,6 +2173,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Rename.New.ResolveContainerPath(e.resolvers), nil
 
+	case "rename.new.destination.path":
+
+		return e.Rename.New.ResolveDestination(e.resolvers), nil
+
 	case "rename.new.filename":
 
 		return e.Rename.New.ResolveInode(e.resolvers), nil
@@ -1203,6 +2189,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Rename.New.OverlayNumLower), nil
 
+	case "rename.new.security_label":
+
+		return e.Rename.New.ResolveSecurityLabel(e.resolvers), nil
+
+	case "rename.new.sha256":
+
+		return e.Rename.New.ResolveSHA256(e.resolvers), nil
+
 	case "rename.old.basename":
 
 		return e.Rename.Old.ResolveBasename(e.resolvers), nil
@@ -1211,6 +2205,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Rename.Old.ResolveContainerPath(e.resolvers), nil
 
+	case "rename.old.destination.path":
+
+		return e.Rename.Old.ResolveDestination(e.resolvers), nil
+
 	case "rename.old.filename":
 
 		return e.Rename.Old.ResolveInode(e.resolvers), nil
@@ -1223,6 +2221,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Rename.Old.OverlayNumLower), nil
 
+	case "rename.old.security_label":
+
+		return e.Rename.Old.ResolveSecurityLabel(e.resolvers), nil
+
+	case "rename.old.sha256":
+
+		return e.Rename.Old.ResolveSHA256(e.resolvers), nil
+
 	case "rename.retval":
 
 		return int(e.Rename.Retval), nil
@@ -1235,6 +2241,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Rmdir.ResolveContainerPath(e.resolvers), nil
 
+	case "rmdir.destination.path":
+
+		return e.Rmdir.ResolveDestination(e.resolvers), nil
+
 	case "rmdir.filename":
 
 		return e.Rmdir.ResolveInode(e.resolvers), nil
@@ -1251,6 +2261,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Rmdir.Retval), nil
 
+	case "rmdir.security_label":
+
+		return e.Rmdir.ResolveSecurityLabel(e.resolvers), nil
+
+	case "rmdir.sha256":
+
+		return e.Rmdir.ResolveSHA256(e.resolvers), nil
+
 	case "setxattr.basename":
 
 		return e.SetXAttr.ResolveBasename(e.resolvers), nil
@@ -1259,6 +2277,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.SetXAttr.ResolveContainerPath(e.resolvers), nil
 
+	case "setxattr.destination.path":
+
+		return e.SetXAttr.ResolveDestination(e.resolvers), nil
+
 	case "setxattr.filename":
 
 		return e.SetXAttr.ResolveInode(e.resolvers), nil
@@ -1283,6 +2305,22 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.SetXAttr.Retval), nil
 
+	case "setxattr.security_label":
+
+		return e.SetXAttr.ResolveSecurityLabel(e.resolvers), nil
+
+	case "setxattr.sha256":
+
+		return e.SetXAttr.ResolveSHA256(e.resolvers), nil
+
+	case "setxattr.value":
+
+		return e.SetXAttr.GetValue(e.resolvers), nil
+
+	case "setxattr.value_size":
+
+		return int(e.SetXAttr.ValueSize), nil
+
 	case "unlink.basename":
 
 		return e.Unlink.ResolveBasename(e.resolvers), nil
@@ -1291,6 +2329,22 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Unlink.ResolveContainerPath(e.resolvers), nil
 
+	case "unlink.destination.path":
+
+		return e.Unlink.ResolveDestination(e.resolvers), nil
+
+	case "unlink.file.gid":
+
+		return int(e.Unlink.FileMetadata.GID), nil
+
+	case "unlink.file.mode":
+
+		return int(e.Unlink.FileMetadata.Mode), nil
+
+	case "unlink.file.uid":
+
+		return int(e.Unlink.FileMetadata.UID), nil
+
 	case "unlink.filename":
 
 		return e.Unlink.ResolveInode(e.resolvers), nil
@@ -1311,6 +2365,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Unlink.Retval), nil
 
+	case "unlink.security_label":
+
+		return e.Unlink.ResolveSecurityLabel(e.resolvers), nil
+
+	case "unlink.sha256":
+
+		return e.Unlink.ResolveSHA256(e.resolvers), nil
+
 	case "utimes.basename":
 
 		return e.Utimes.ResolveBasename(e.resolvers), nil
@@ -1319,6 +2381,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Utimes.ResolveContainerPath(e.resolvers), nil
 
+	case "utimes.destination.path":
+
+		return e.Utimes.ResolveDestination(e.resolvers), nil
+
 	case "utimes.filename":
 
 		return e.Utimes.ResolveInode(e.resolvers), nil
@@ -1335,6 +2401,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Utimes.Retval), nil
 
+	case "utimes.security_label":
+
+		return e.Utimes.ResolveSecurityLabel(e.resolvers), nil
+
+	case "utimes.sha256":
+
+		return e.Utimes.ResolveSHA256(e.resolvers), nil
+
 	}
 
 	return nil, &eval.ErrFieldNotFound{Field: field}
@@ -1349,6 +2423,18 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "chmod.container_path":
 		return "chmod", nil
 
+	case "chmod.destination.path":
+		return "chmod", nil
+
+	case "chmod.file.gid":
+		return "chmod", nil
+
+	case "chmod.file.mode":
+		return "chmod", nil
+
+	case "chmod.file.uid":
+		return "chmod", nil
+
 	case "chmod.filename":
 		return "chmod", nil
 
@@ -1364,18 +2450,39 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "chmod.retval":
 		return "chmod", nil
 
+	case "chmod.security_label":
+		return "chmod", nil
+
+	case "chmod.sha256":
+		return "chmod", nil
+
 	case "chown.basename":
 		return "chown", nil
 
 	case "chown.container_path":
 		return "chown", nil
 
+	case "chown.destination.path":
+		return "chown", nil
+
+	case "chown.file.gid":
+		return "chown", nil
+
+	case "chown.file.mode":
+		return "chown", nil
+
+	case "chown.file.uid":
+		return "chown", nil
+
 	case "chown.filename":
 		return "chown", nil
 
 	case "chown.gid":
 		return "chown", nil
 
+	case "chown.group":
+		return "chown", nil
+
 	case "chown.inode":
 		return "chown", nil
 
@@ -1385,12 +2492,27 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "chown.retval":
 		return "chown", nil
 
+	case "chown.security_label":
+		return "chown", nil
+
+	case "chown.sha256":
+		return "chown", nil
+
 	case "chown.uid":
 		return "chown", nil
 
+	case "chown.user":
+		return "chown", nil
+
 	case "container.id":
 		return "*", nil
 
+	case "container.image_digest":
+		return "*", nil
+
+	case "container.image_name":
+		return "*", nil
+
 	case "link.retval":
 		return "link", nil
 
@@ -1400,6 +2522,9 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "link.source.container_path":
 		return "link", nil
 
+	case "link.source.destination.path":
+		return "link", nil
+
 	case "link.source.filename":
 		return "link", nil
 
@@ -1409,12 +2534,21 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "link.source.overlay_numlower":
 		return "link", nil
 
+	case "link.source.security_label":
+		return "link", nil
+
+	case "link.source.sha256":
+		return "link", nil
+
 	case "link.target.basename":
 		return "link", nil
 
 	case "link.target.container_path":
 		return "link", nil
 
+	case "link.target.destination.path":
+		return "link", nil
+
 	case "link.target.filename":
 		return "link", nil
 
@@ -1424,12 +2558,21 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "link.target.overlay_numlower":
 		return "link", nil
 
+	case "link.target.security_label":
+		return "link", nil
+
+	case "link.target.sha256":
+		return "link", nil
+
 	case "mkdir.basename":
 		return "mkdir", nil
 
 	case "mkdir.container_path":
 		return "mkdir", nil
 
+	case "mkdir.destination.path":
+		return "mkdir", nil
+
 	case "mkdir.filename":
 		return "mkdir", nil
 
@@ -1445,12 +2588,36 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "mkdir.retval":
 		return "mkdir", nil
 
+	case "mkdir.security_label":
+		return "mkdir", nil
+
+	case "mkdir.sha256":
+		return "mkdir", nil
+
+	case "mount.fs_type":
+		return "mount", nil
+
+	case "mount.mount_point":
+		return "mount", nil
+
+	case "mount.retval":
+		return "mount", nil
+
+	case "mount.root":
+		return "mount", nil
+
+	case "mount.source":
+		return "mount", nil
+
 	case "open.basename":
 		return "open", nil
 
 	case "open.container_path":
 		return "open", nil
 
+	case "open.destination.path":
+		return "open", nil
+
 	case "open.filename":
 		return "open", nil
 
@@ -1460,19 +2627,52 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "open.inode":
 		return "open", nil
 
-	case "open.mode":
-		return "open", nil
+	case "open.mode":
+		return "open", nil
+
+	case "open.overlay_numlower":
+		return "open", nil
+
+	case "open.retval":
+		return "open", nil
+
+	case "open.security_label":
+		return "open", nil
+
+	case "open.sha256":
+		return "open", nil
+
+	case "process.auid":
+		return "*", nil
+
+	case "process.basename":
+		return "*", nil
+
+	case "process.container_path":
+		return "*", nil
+
+	case "process.created_at":
+		return "*", nil
+
+	case "process.destination.path":
+		return "*", nil
+
+	case "process.file.ctime":
+		return "*", nil
 
-	case "open.overlay_numlower":
-		return "open", nil
+	case "process.file.mode":
+		return "*", nil
 
-	case "open.retval":
-		return "open", nil
+	case "process.file.mtime":
+		return "*", nil
 
-	case "process.basename":
+	case "process.file.setgid":
 		return "*", nil
 
-	case "process.container_path":
+	case "process.file.setuid":
+		return "*", nil
+
+	case "process.file.size":
 		return "*", nil
 
 	case "process.filename":
@@ -1487,15 +2687,42 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "process.inode":
 		return "*", nil
 
+	case "process.interpreter.filename":
+		return "*", nil
+
+	case "process.mntns":
+		return "*", nil
+
 	case "process.name":
 		return "*", nil
 
+	case "process.netns":
+		return "*", nil
+
 	case "process.overlay_numlower":
 		return "*", nil
 
 	case "process.pid":
 		return "*", nil
 
+	case "process.pidns":
+		return "*", nil
+
+	case "process.security_context":
+		return "*", nil
+
+	case "process.security_label":
+		return "*", nil
+
+	case "process.session.source_ip":
+		return "*", nil
+
+	case "process.session_id":
+		return "*", nil
+
+	case "process.sha256":
+		return "*", nil
+
 	case "process.tid":
 		return "*", nil
 
@@ -1514,6 +2741,9 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "removexattr.container_path":
 		return "removexattr", nil
 
+	case "removexattr.destination.path":
+		return "removexattr", nil
+
 	case "removexattr.filename":
 		return "removexattr", nil
 
@@ -1532,12 +2762,27 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "removexattr.retval":
 		return "removexattr", nil
 
+	case "removexattr.security_label":
+		return "removexattr", nil
+
+	case "removexattr.sha256":
+		return "removexattr", nil
+
+	case "removexattr.value":
+		return "removexattr", nil
+
+	case "removexattr.value_size":
+		return "removexattr", nil
+
 	case "rename.new.basename":
 		return "rename", nil
 
 	case "rename.new.container_path":
 		return "rename", nil
 
+	case "rename.new.destination.path":
+		return "rename", nil
+
 	case "rename.new.filename":
 		return "rename", nil
 
@@ -1547,12 +2792,21 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "rename.new.overlay_numlower":
 		return "rename", nil
 
+	case "rename.new.security_label":
+		return "rename", nil
+
+	case "rename.new.sha256":
+		return "rename", nil
+
 	case "rename.old.basename":
 		return "rename", nil
 
 	case "rename.old.container_path":
 		return "rename", nil
 
+	case "rename.old.destination.path":
+		return "rename", nil
+
 	case "rename.old.filename":
 		return "rename", nil
 
@@ -1562,6 +2816,12 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "rename.old.overlay_numlower":
 		return "rename", nil
 
+	case "rename.old.security_label":
+		return "rename", nil
+
+	case "rename.old.sha256":
+		return "rename", nil
+
 	case "rename.retval":
 		return "rename", nil
 
@@ -1571,6 +2831,9 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "rmdir.container_path":
 		return "rmdir", nil
 
+	case "rmdir.destination.path":
+		return "rmdir", nil
+
 	case "rmdir.filename":
 		return "rmdir", nil
 
@@ -1583,12 +2846,21 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "rmdir.retval":
 		return "rmdir", nil
 
+	case "rmdir.security_label":
+		return "rmdir", nil
+
+	case "rmdir.sha256":
+		return "rmdir", nil
+
 	case "setxattr.basename":
 		return "setxattr", nil
 
 	case "setxattr.container_path":
 		return "setxattr", nil
 
+	case "setxattr.destination.path":
+		return "setxattr", nil
+
 	case "setxattr.filename":
 		return "setxattr", nil
 
@@ -1607,12 +2879,36 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "setxattr.retval":
 		return "setxattr", nil
 
+	case "setxattr.security_label":
+		return "setxattr", nil
+
+	case "setxattr.sha256":
+		return "setxattr", nil
+
+	case "setxattr.value":
+		return "setxattr", nil
+
+	case "setxattr.value_size":
+		return "setxattr", nil
+
 	case "unlink.basename":
 		return "unlink", nil
 
 	case "unlink.container_path":
 		return "unlink", nil
 
+	case "unlink.destination.path":
+		return "unlink", nil
+
+	case "unlink.file.gid":
+		return "unlink", nil
+
+	case "unlink.file.mode":
+		return "unlink", nil
+
+	case "unlink.file.uid":
+		return "unlink", nil
+
 	case "unlink.filename":
 		return "unlink", nil
 
@@ -1628,12 +2924,21 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "unlink.retval":
 		return "unlink", nil
 
+	case "unlink.security_label":
+		return "unlink", nil
+
+	case "unlink.sha256":
+		return "unlink", nil
+
 	case "utimes.basename":
 		return "utimes", nil
 
 	case "utimes.container_path":
 		return "utimes", nil
 
+	case "utimes.destination.path":
+		return "utimes", nil
+
 	case "utimes.filename":
 		return "utimes", nil
 
@@ -1646,6 +2951,12 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "utimes.retval":
 		return "utimes", nil
 
+	case "utimes.security_label":
+		return "utimes", nil
+
+	case "utimes.sha256":
+		return "utimes", nil
+
 	}
 
 	return "", &eval.ErrFieldNotFound{Field: field}
@@ -1662,6 +2973,22 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "chmod.destination.path":
+
+		return reflect.String, nil
+
+	case "chmod.file.gid":
+
+		return reflect.Int, nil
+
+	case "chmod.file.mode":
+
+		return reflect.Int, nil
+
+	case "chmod.file.uid":
+
+		return reflect.Int, nil
+
 	case "chmod.filename":
 
 		return reflect.String, nil
@@ -1682,6 +3009,14 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "chmod.security_label":
+
+		return reflect.String, nil
+
+	case "chmod.sha256":
+
+		return reflect.String, nil
+
 	case "chown.basename":
 
 		return reflect.String, nil
@@ -1690,6 +3025,22 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "chown.destination.path":
+
+		return reflect.String, nil
+
+	case "chown.file.gid":
+
+		return reflect.Int, nil
+
+	case "chown.file.mode":
+
+		return reflect.Int, nil
+
+	case "chown.file.uid":
+
+		return reflect.Int, nil
+
 	case "chown.filename":
 
 		return reflect.String, nil
@@ -1698,6 +3049,10 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "chown.group":
+
+		return reflect.String, nil
+
 	case "chown.inode":
 
 		return reflect.Int, nil
@@ -1710,14 +3065,34 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "chown.security_label":
+
+		return reflect.String, nil
+
+	case "chown.sha256":
+
+		return reflect.String, nil
+
 	case "chown.uid":
 
 		return reflect.Int, nil
 
+	case "chown.user":
+
+		return reflect.String, nil
+
 	case "container.id":
 
 		return reflect.String, nil
 
+	case "container.image_digest":
+
+		return reflect.String, nil
+
+	case "container.image_name":
+
+		return reflect.String, nil
+
 	case "link.retval":
 
 		return reflect.Int, nil
@@ -1730,133 +3105,273 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "link.source.destination.path":
+
+		return reflect.String, nil
+
 	case "link.source.filename":
 
 		return reflect.String, nil
 
-	case "link.source.inode":
+	case "link.source.inode":
+
+		return reflect.Int, nil
+
+	case "link.source.overlay_numlower":
+
+		return reflect.Int, nil
+
+	case "link.source.security_label":
+
+		return reflect.String, nil
+
+	case "link.source.sha256":
+
+		return reflect.String, nil
+
+	case "link.target.basename":
+
+		return reflect.String, nil
+
+	case "link.target.container_path":
+
+		return reflect.String, nil
+
+	case "link.target.destination.path":
+
+		return reflect.String, nil
+
+	case "link.target.filename":
+
+		return reflect.String, nil
+
+	case "link.target.inode":
+
+		return reflect.Int, nil
+
+	case "link.target.overlay_numlower":
+
+		return reflect.Int, nil
+
+	case "link.target.security_label":
+
+		return reflect.String, nil
+
+	case "link.target.sha256":
+
+		return reflect.String, nil
+
+	case "mkdir.basename":
+
+		return reflect.String, nil
+
+	case "mkdir.container_path":
+
+		return reflect.String, nil
+
+	case "mkdir.destination.path":
+
+		return reflect.String, nil
+
+	case "mkdir.filename":
+
+		return reflect.String, nil
+
+	case "mkdir.inode":
+
+		return reflect.Int, nil
+
+	case "mkdir.mode":
+
+		return reflect.Int, nil
+
+	case "mkdir.overlay_numlower":
+
+		return reflect.Int, nil
+
+	case "mkdir.retval":
+
+		return reflect.Int, nil
+
+	case "mkdir.security_label":
+
+		return reflect.String, nil
+
+	case "mkdir.sha256":
+
+		return reflect.String, nil
+
+	case "mount.fs_type":
+
+		return reflect.String, nil
+
+	case "mount.mount_point":
+
+		return reflect.String, nil
+
+	case "mount.retval":
+
+		return reflect.Int, nil
+
+	case "mount.root":
+
+		return reflect.String, nil
+
+	case "mount.source":
+
+		return reflect.String, nil
+
+	case "open.basename":
+
+		return reflect.String, nil
+
+	case "open.container_path":
+
+		return reflect.String, nil
+
+	case "open.destination.path":
+
+		return reflect.String, nil
+
+	case "open.filename":
+
+		return reflect.String, nil
+
+	case "open.flags":
+
+		return reflect.Int, nil
+
+	case "open.inode":
+
+		return reflect.Int, nil
+
+	case "open.mode":
 
 		return reflect.Int, nil
 
-	case "link.source.overlay_numlower":
+	case "open.overlay_numlower":
 
 		return reflect.Int, nil
 
-	case "link.target.basename":
+	case "open.retval":
 
-		return reflect.String, nil
+		return reflect.Int, nil
 
-	case "link.target.container_path":
+	case "open.security_label":
 
 		return reflect.String, nil
 
-	case "link.target.filename":
+	case "open.sha256":
 
 		return reflect.String, nil
 
-	case "link.target.inode":
+	case "process.auid":
 
 		return reflect.Int, nil
 
-	case "link.target.overlay_numlower":
+	case "process.basename":
 
-		return reflect.Int, nil
+		return reflect.String, nil
 
-	case "mkdir.basename":
+	case "process.container_path":
 
 		return reflect.String, nil
 
-	case "mkdir.container_path":
+	case "process.created_at":
 
-		return reflect.String, nil
+		return reflect.Int, nil
 
-	case "mkdir.filename":
+	case "process.destination.path":
 
 		return reflect.String, nil
 
-	case "mkdir.inode":
+	case "process.file.ctime":
 
 		return reflect.Int, nil
 
-	case "mkdir.mode":
+	case "process.file.mode":
 
 		return reflect.Int, nil
 
-	case "mkdir.overlay_numlower":
+	case "process.file.mtime":
 
 		return reflect.Int, nil
 
-	case "mkdir.retval":
+	case "process.file.setgid":
 
-		return reflect.Int, nil
+		return reflect.Bool, nil
 
-	case "open.basename":
+	case "process.file.setuid":
 
-		return reflect.String, nil
+		return reflect.Bool, nil
 
-	case "open.container_path":
+	case "process.file.size":
 
-		return reflect.String, nil
+		return reflect.Int, nil
 
-	case "open.filename":
+	case "process.filename":
 
 		return reflect.String, nil
 
-	case "open.flags":
+	case "process.gid":
 
 		return reflect.Int, nil
 
-	case "open.inode":
+	case "process.group":
 
-		return reflect.Int, nil
+		return reflect.String, nil
 
-	case "open.mode":
+	case "process.inode":
 
 		return reflect.Int, nil
 
-	case "open.overlay_numlower":
+	case "process.interpreter.filename":
 
-		return reflect.Int, nil
+		return reflect.String, nil
 
-	case "open.retval":
+	case "process.mntns":
 
 		return reflect.Int, nil
 
-	case "process.basename":
+	case "process.name":
 
 		return reflect.String, nil
 
-	case "process.container_path":
+	case "process.netns":
 
-		return reflect.String, nil
+		return reflect.Int, nil
 
-	case "process.filename":
+	case "process.overlay_numlower":
 
-		return reflect.String, nil
+		return reflect.Int, nil
 
-	case "process.gid":
+	case "process.pid":
 
 		return reflect.Int, nil
 
-	case "process.group":
+	case "process.pidns":
+
+		return reflect.Int, nil
+
+	case "process.security_context":
 
 		return reflect.String, nil
 
-	case "process.inode":
+	case "process.security_label":
 
-		return reflect.Int, nil
+		return reflect.String, nil
 
-	case "process.name":
+	case "process.session.source_ip":
 
 		return reflect.String, nil
 
-	case "process.overlay_numlower":
+	case "process.session_id":
 
 		return reflect.Int, nil
 
-	case "process.pid":
+	case "process.sha256":
 
-		return reflect.Int, nil
+		return reflect.String, nil
 
 	case "process.tid":
 
@@ -1882,6 +3397,10 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "removexattr.destination.path":
+
+		return reflect.String, nil
+
 	case "removexattr.filename":
 
 		return reflect.String, nil
@@ -1906,6 +3425,22 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "removexattr.security_label":
+
+		return reflect.String, nil
+
+	case "removexattr.sha256":
+
+		return reflect.String, nil
+
+	case "removexattr.value":
+
+		return reflect.String, nil
+
+	case "removexattr.value_size":
+
+		return reflect.Int, nil
+
 	case "rename.new.basename":
 
 		return reflect.String, nil
@@ -1914,6 +3449,10 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "rename.new.destination.path":
+
+		return reflect.String, nil
+
 	case "rename.new.filename":
 
 		return reflect.String, nil
@@ -1926,6 +3465,14 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "rename.new.security_label":
+
+		return reflect.String, nil
+
+	case "rename.new.sha256":
+
+		return reflect.String, nil
+
 	case "rename.old.basename":
 
 		return reflect.String, nil
@@ -1934,6 +3481,10 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "rename.old.destination.path":
+
+		return reflect.String, nil
+
 	case "rename.old.filename":
 
 		return reflect.String, nil
@@ -1946,6 +3497,14 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "rename.old.security_label":
+
+		return reflect.String, nil
+
+	case "rename.old.sha256":
+
+		return reflect.String, nil
+
 	case "rename.retval":
 
 		return reflect.Int, nil
@@ -1958,6 +3517,10 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "rmdir.destination.path":
+
+		return reflect.String, nil
+
 	case "rmdir.filename":
 
 		return reflect.String, nil
@@ -1974,6 +3537,14 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "rmdir.security_label":
+
+		return reflect.String, nil
+
+	case "rmdir.sha256":
+
+		return reflect.String, nil
+
 	case "setxattr.basename":
 
 		return reflect.String, nil
@@ -1982,6 +3553,10 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "setxattr.destination.path":
+
+		return reflect.String, nil
+
 	case "setxattr.filename":
 
 		return reflect.String, nil
@@ -2006,6 +3581,22 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "setxattr.security_label":
+
+		return reflect.String, nil
+
+	case "setxattr.sha256":
+
+		return reflect.String, nil
+
+	case "setxattr.value":
+
+		return reflect.String, nil
+
+	case "setxattr.value_size":
+
+		return reflect.Int, nil
+
 	case "unlink.basename":
 
 		return reflect.String, nil
@@ -2014,6 +3605,22 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "unlink.destination.path":
+
+		return reflect.String, nil
+
+	case "unlink.file.gid":
+
+		return reflect.Int, nil
+
+	case "unlink.file.mode":
+
+		return reflect.Int, nil
+
+	case "unlink.file.uid":
+
+		return reflect.Int, nil
+
 	case "unlink.filename":
 
 		return reflect.String, nil
@@ -2034,6 +3641,14 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "unlink.security_label":
+
+		return reflect.String, nil
+
+	case "unlink.sha256":
+
+		return reflect.String, nil
+
 	case "utimes.basename":
 
 		return reflect.String, nil
@@ -2042,6 +3657,10 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "utimes.destination.path":
+
+		return reflect.String, nil
+
 	case "utimes.filename":
 
 		return reflect.String, nil
@@ -2058,6 +3677,14 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "utimes.security_label":
+
+		return reflect.String, nil
+
+	case "utimes.sha256":
+
+		return reflect.String, nil
+
 	}
 
 	return reflect.Invalid, &eval.ErrFieldNotFound{Field: field}
@@ -2081,6 +3708,40 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "chmod.destination.path":
+
+		if e.Chmod.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chmod.DestinationPath"}
+		}
+		return nil
+
+	case "chmod.file.gid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chmod.FileMetadata.GID"}
+		}
+		e.Chmod.FileMetadata.GID = uint32(v)
+		return nil
+
+	case "chmod.file.mode":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chmod.FileMetadata.Mode"}
+		}
+		e.Chmod.FileMetadata.Mode = uint32(v)
+		return nil
+
+	case "chmod.file.uid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chmod.FileMetadata.UID"}
+		}
+		e.Chmod.FileMetadata.UID = uint32(v)
+		return nil
+
 	case "chmod.filename":
 
 		if e.Chmod.PathnameStr, ok = value.(string); !ok {
@@ -2124,6 +3785,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Chmod.Retval = int64(v)
 		return nil
 
+	case "chmod.security_label":
+
+		if e.Chmod.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chmod.SecurityLabel"}
+		}
+		return nil
+
+	case "chmod.sha256":
+
+		if e.Chmod.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chmod.SHA256"}
+		}
+		return nil
+
 	case "chown.basename":
 
 		if e.Chown.BasenameStr, ok = value.(string); !ok {
@@ -2138,6 +3813,40 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "chown.destination.path":
+
+		if e.Chown.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chown.DestinationPath"}
+		}
+		return nil
+
+	case "chown.file.gid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chown.FileMetadata.GID"}
+		}
+		e.Chown.FileMetadata.GID = uint32(v)
+		return nil
+
+	case "chown.file.mode":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chown.FileMetadata.Mode"}
+		}
+		e.Chown.FileMetadata.Mode = uint32(v)
+		return nil
+
+	case "chown.file.uid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chown.FileMetadata.UID"}
+		}
+		e.Chown.FileMetadata.UID = uint32(v)
+		return nil
+
 	case "chown.filename":
 
 		if e.Chown.PathnameStr, ok = value.(string); !ok {
@@ -2154,6 +3863,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Chown.GID = int32(v)
 		return nil
 
+	case "chown.group":
+
+		if e.Chown.Group, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chown.Group"}
+		}
+		return nil
+
 	case "chown.inode":
 
 		v, ok := value.(int)
@@ -2181,6 +3897,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Chown.Retval = int64(v)
 		return nil
 
+	case "chown.security_label":
+
+		if e.Chown.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chown.SecurityLabel"}
+		}
+		return nil
+
+	case "chown.sha256":
+
+		if e.Chown.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chown.SHA256"}
+		}
+		return nil
+
 	case "chown.uid":
 
 		v, ok := value.(int)
@@ -2190,6 +3920,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Chown.UID = int32(v)
 		return nil
 
+	case "chown.user":
+
+		if e.Chown.User, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Chown.User"}
+		}
+		return nil
+
 	case "container.id":
 
 		if e.Container.ID, ok = value.(string); !ok {
@@ -2197,6 +3934,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "container.image_digest":
+
+		if e.Container.ImageDigest, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Container.ImageDigest"}
+		}
+		return nil
+
+	case "container.image_name":
+
+		if e.Container.ImageName, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Container.ImageName"}
+		}
+		return nil
+
 	case "link.retval":
 
 		v, ok := value.(int)
@@ -2220,6 +3971,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "link.source.destination.path":
+
+		if e.Link.Source.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Link.Source.DestinationPath"}
+		}
+		return nil
+
 	case "link.source.filename":
 
 		if e.Link.Source.PathnameStr, ok = value.(string); !ok {
@@ -2245,6 +4003,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Link.Source.OverlayNumLower = int32(v)
 		return nil
 
+	case "link.source.security_label":
+
+		if e.Link.Source.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Link.Source.SecurityLabel"}
+		}
+		return nil
+
+	case "link.source.sha256":
+
+		if e.Link.Source.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Link.Source.SHA256"}
+		}
+		return nil
+
 	case "link.target.basename":
 
 		if e.Link.Target.BasenameStr, ok = value.(string); !ok {
@@ -2259,6 +4031,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "link.target.destination.path":
+
+		if e.Link.Target.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Link.Target.DestinationPath"}
+		}
+		return nil
+
 	case "link.target.filename":
 
 		if e.Link.Target.PathnameStr, ok = value.(string); !ok {
@@ -2284,6 +4063,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Link.Target.OverlayNumLower = int32(v)
 		return nil
 
+	case "link.target.security_label":
+
+		if e.Link.Target.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Link.Target.SecurityLabel"}
+		}
+		return nil
+
+	case "link.target.sha256":
+
+		if e.Link.Target.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Link.Target.SHA256"}
+		}
+		return nil
+
 	case "mkdir.basename":
 
 		if e.Mkdir.BasenameStr, ok = value.(string); !ok {
@@ -2298,6 +4091,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "mkdir.destination.path":
+
+		if e.Mkdir.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Mkdir.DestinationPath"}
+		}
+		return nil
+
 	case "mkdir.filename":
 
 		if e.Mkdir.PathnameStr, ok = value.(string); !ok {
@@ -2341,6 +4141,57 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Mkdir.Retval = int64(v)
 		return nil
 
+	case "mkdir.security_label":
+
+		if e.Mkdir.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Mkdir.SecurityLabel"}
+		}
+		return nil
+
+	case "mkdir.sha256":
+
+		if e.Mkdir.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Mkdir.SHA256"}
+		}
+		return nil
+
+	case "mount.fs_type":
+
+		if e.Mount.FSType, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Mount.FSType"}
+		}
+		return nil
+
+	case "mount.mount_point":
+
+		if e.Mount.MountPointStr, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Mount.MountPointStr"}
+		}
+		return nil
+
+	case "mount.retval":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Mount.Retval"}
+		}
+		e.Mount.Retval = int64(v)
+		return nil
+
+	case "mount.root":
+
+		if e.Mount.RootStr, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Mount.RootStr"}
+		}
+		return nil
+
+	case "mount.source":
+
+		if e.Mount.Source, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Mount.Source"}
+		}
+		return nil
+
 	case "open.basename":
 
 		if e.Open.BasenameStr, ok = value.(string); !ok {
@@ -2355,6 +4206,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "open.destination.path":
+
+		if e.Open.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Open.DestinationPath"}
+		}
+		return nil
+
 	case "open.filename":
 
 		if e.Open.PathnameStr, ok = value.(string); !ok {
@@ -2407,6 +4265,29 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Open.Retval = int64(v)
 		return nil
 
+	case "open.security_label":
+
+		if e.Open.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Open.SecurityLabel"}
+		}
+		return nil
+
+	case "open.sha256":
+
+		if e.Open.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Open.SHA256"}
+		}
+		return nil
+
+	case "process.auid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.AUID"}
+		}
+		e.Process.AUID = uint32(v)
+		return nil
+
 	case "process.basename":
 
 		if e.Process.BasenameStr, ok = value.(string); !ok {
@@ -2421,6 +4302,72 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "process.created_at":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.CreatedAt"}
+		}
+		e.Process.CreatedAt = uint64(v)
+		return nil
+
+	case "process.destination.path":
+
+		if e.Process.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.DestinationPath"}
+		}
+		return nil
+
+	case "process.file.ctime":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.ExecCTime"}
+		}
+		e.Process.ExecCTime = uint64(v)
+		return nil
+
+	case "process.file.mode":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.ExecMode"}
+		}
+		e.Process.ExecMode = uint32(v)
+		return nil
+
+	case "process.file.mtime":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.ExecMTime"}
+		}
+		e.Process.ExecMTime = uint64(v)
+		return nil
+
+	case "process.file.setgid":
+
+		if e.Process.ExecSetgid, ok = value.(bool); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.ExecSetgid"}
+		}
+		return nil
+
+	case "process.file.setuid":
+
+		if e.Process.ExecSetuid, ok = value.(bool); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.ExecSetuid"}
+		}
+		return nil
+
+	case "process.file.size":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.ExecSize"}
+		}
+		e.Process.ExecSize = uint64(v)
+		return nil
+
 	case "process.filename":
 
 		if e.Process.PathnameStr, ok = value.(string); !ok {
@@ -2453,6 +4400,22 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Process.Inode = uint64(v)
 		return nil
 
+	case "process.interpreter.filename":
+
+		if e.Process.InterpreterPathnameStr, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.InterpreterPathnameStr"}
+		}
+		return nil
+
+	case "process.mntns":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.Mntns"}
+		}
+		e.Process.Mntns = uint32(v)
+		return nil
+
 	case "process.name":
 
 		if e.Process.Comm, ok = value.(string); !ok {
@@ -2460,6 +4423,15 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "process.netns":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.Netns"}
+		}
+		e.Process.Netns = uint32(v)
+		return nil
+
 	case "process.overlay_numlower":
 
 		v, ok := value.(int)
@@ -2478,6 +4450,52 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Process.Pid = uint32(v)
 		return nil
 
+	case "process.pidns":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.Pidns"}
+		}
+		e.Process.Pidns = uint32(v)
+		return nil
+
+	case "process.security_context":
+
+		if e.Process.SecurityContext, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.SecurityContext"}
+		}
+		return nil
+
+	case "process.security_label":
+
+		if e.Process.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.SecurityLabel"}
+		}
+		return nil
+
+	case "process.session.source_ip":
+
+		if e.Process.SessionSourceIP, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.SessionSourceIP"}
+		}
+		return nil
+
+	case "process.session_id":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.SessionID"}
+		}
+		e.Process.SessionID = uint32(v)
+		return nil
+
+	case "process.sha256":
+
+		if e.Process.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.SHA256"}
+		}
+		return nil
+
 	case "process.tid":
 
 		v, ok := value.(int)
@@ -2524,6 +4542,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "removexattr.destination.path":
+
+		if e.RemoveXAttr.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "RemoveXAttr.DestinationPath"}
+		}
+		return nil
+
 	case "removexattr.filename":
 
 		if e.RemoveXAttr.PathnameStr, ok = value.(string); !ok {
@@ -2572,6 +4597,36 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.RemoveXAttr.Retval = int64(v)
 		return nil
 
+	case "removexattr.security_label":
+
+		if e.RemoveXAttr.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "RemoveXAttr.SecurityLabel"}
+		}
+		return nil
+
+	case "removexattr.sha256":
+
+		if e.RemoveXAttr.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "RemoveXAttr.SHA256"}
+		}
+		return nil
+
+	case "removexattr.value":
+
+		if e.RemoveXAttr.Value, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "RemoveXAttr.Value"}
+		}
+		return nil
+
+	case "removexattr.value_size":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "RemoveXAttr.ValueSize"}
+		}
+		e.RemoveXAttr.ValueSize = uint32(v)
+		return nil
+
 	case "rename.new.basename":
 
 		if e.Rename.New.BasenameStr, ok = value.(string); !ok {
@@ -2586,6 +4641,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "rename.new.destination.path":
+
+		if e.Rename.New.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Rename.New.DestinationPath"}
+		}
+		return nil
+
 	case "rename.new.filename":
 
 		if e.Rename.New.PathnameStr, ok = value.(string); !ok {
@@ -2611,6 +4673,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Rename.New.OverlayNumLower = int32(v)
 		return nil
 
+	case "rename.new.security_label":
+
+		if e.Rename.New.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Rename.New.SecurityLabel"}
+		}
+		return nil
+
+	case "rename.new.sha256":
+
+		if e.Rename.New.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Rename.New.SHA256"}
+		}
+		return nil
+
 	case "rename.old.basename":
 
 		if e.Rename.Old.BasenameStr, ok = value.(string); !ok {
@@ -2625,6 +4701,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "rename.old.destination.path":
+
+		if e.Rename.Old.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Rename.Old.DestinationPath"}
+		}
+		return nil
+
 	case "rename.old.filename":
 
 		if e.Rename.Old.PathnameStr, ok = value.(string); !ok {
@@ -2650,6 +4733,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Rename.Old.OverlayNumLower = int32(v)
 		return nil
 
+	case "rename.old.security_label":
+
+		if e.Rename.Old.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Rename.Old.SecurityLabel"}
+		}
+		return nil
+
+	case "rename.old.sha256":
+
+		if e.Rename.Old.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Rename.Old.SHA256"}
+		}
+		return nil
+
 	case "rename.retval":
 
 		v, ok := value.(int)
@@ -2673,6 +4770,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "rmdir.destination.path":
+
+		if e.Rmdir.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Rmdir.DestinationPath"}
+		}
+		return nil
+
 	case "rmdir.filename":
 
 		if e.Rmdir.PathnameStr, ok = value.(string); !ok {
@@ -2707,6 +4811,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Rmdir.Retval = int64(v)
 		return nil
 
+	case "rmdir.security_label":
+
+		if e.Rmdir.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Rmdir.SecurityLabel"}
+		}
+		return nil
+
+	case "rmdir.sha256":
+
+		if e.Rmdir.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Rmdir.SHA256"}
+		}
+		return nil
+
 	case "setxattr.basename":
 
 		if e.SetXAttr.BasenameStr, ok = value.(string); !ok {
@@ -2721,6 +4839,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "setxattr.destination.path":
+
+		if e.SetXAttr.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "SetXAttr.DestinationPath"}
+		}
+		return nil
+
 	case "setxattr.filename":
 
 		if e.SetXAttr.PathnameStr, ok = value.(string); !ok {
@@ -2769,6 +4894,36 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.SetXAttr.Retval = int64(v)
 		return nil
 
+	case "setxattr.security_label":
+
+		if e.SetXAttr.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "SetXAttr.SecurityLabel"}
+		}
+		return nil
+
+	case "setxattr.sha256":
+
+		if e.SetXAttr.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "SetXAttr.SHA256"}
+		}
+		return nil
+
+	case "setxattr.value":
+
+		if e.SetXAttr.Value, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "SetXAttr.Value"}
+		}
+		return nil
+
+	case "setxattr.value_size":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "SetXAttr.ValueSize"}
+		}
+		e.SetXAttr.ValueSize = uint32(v)
+		return nil
+
 	case "unlink.basename":
 
 		if e.Unlink.BasenameStr, ok = value.(string); !ok {
@@ -2783,6 +4938,40 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "unlink.destination.path":
+
+		if e.Unlink.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Unlink.DestinationPath"}
+		}
+		return nil
+
+	case "unlink.file.gid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Unlink.FileMetadata.GID"}
+		}
+		e.Unlink.FileMetadata.GID = uint32(v)
+		return nil
+
+	case "unlink.file.mode":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Unlink.FileMetadata.Mode"}
+		}
+		e.Unlink.FileMetadata.Mode = uint32(v)
+		return nil
+
+	case "unlink.file.uid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Unlink.FileMetadata.UID"}
+		}
+		e.Unlink.FileMetadata.UID = uint32(v)
+		return nil
+
 	case "unlink.filename":
 
 		if e.Unlink.PathnameStr, ok = value.(string); !ok {
@@ -2826,6 +5015,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Unlink.Retval = int64(v)
 		return nil
 
+	case "unlink.security_label":
+
+		if e.Unlink.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Unlink.SecurityLabel"}
+		}
+		return nil
+
+	case "unlink.sha256":
+
+		if e.Unlink.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Unlink.SHA256"}
+		}
+		return nil
+
 	case "utimes.basename":
 
 		if e.Utimes.BasenameStr, ok = value.(string); !ok {
@@ -2840,6 +5043,13 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "utimes.destination.path":
+
+		if e.Utimes.DestinationPath, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Utimes.DestinationPath"}
+		}
+		return nil
+
 	case "utimes.filename":
 
 		if e.Utimes.PathnameStr, ok = value.(string); !ok {
@@ -2874,6 +5084,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Utimes.Retval = int64(v)
 		return nil
 
+	case "utimes.security_label":
+
+		if e.Utimes.SecurityLabel, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Utimes.SecurityLabel"}
+		}
+		return nil
+
+	case "utimes.sha256":
+
+		if e.Utimes.SHA256, ok = value.(string); !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Utimes.SHA256"}
+		}
+		return nil
+
 	}
 
 	return &eval.ErrFieldNotFound{Field: field}
@@ -11,6 +11,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
 )
 
 func TestMkdirJSON(t *testing.T) {
@@ -50,3 +52,35 @@ func TestMkdirJSON(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestOpenEventSymlink(t *testing.T) {
+	data := make([]byte, 56)
+	ebpf.ByteOrder.PutUint64(data[8:16], 33)
+	ebpf.ByteOrder.PutUint32(data[16:20], 1)
+	ebpf.ByteOrder.PutUint32(data[24:28], 2)
+	ebpf.ByteOrder.PutUint32(data[28:32], 3)
+
+	var e OpenEvent
+	if _, err := e.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if e.Inode != 33 || e.destinationInode != 0 {
+		t.Errorf("expected an open without link to have no destination, got %+v", e.FileEvent)
+	}
+
+	// the link followed by the open
+	ebpf.ByteOrder.PutUint64(data[40:48], 44)
+	ebpf.ByteOrder.PutUint32(data[48:52], 4)
+	ebpf.ByteOrder.PutUint32(data[52:56], 5)
+
+	e = OpenEvent{}
+	if _, err := e.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if e.Inode != 44 || e.MountID != 4 || e.PathID != 5 || e.Generation != 0 {
+		t.Errorf("expected the file of the open to be the link, got %+v", e.FileEvent)
+	}
+	if e.destinationInode != 33 || e.destinationMountID != 1 || e.destinationPathID != 2 || e.destinationGen != 3 {
+		t.Errorf("expected the destination of the open to be the file it reached, got %+v", e.FileEvent)
+	}
+}
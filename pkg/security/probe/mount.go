@@ -16,9 +16,9 @@ import (
 
 	"github.com/moby/sys/mountinfo"
 	"github.com/pkg/errors"
-	"golang.org/x/sys/unix"
 
 	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var (
@@ -29,20 +29,25 @@ var (
 // newMountEventFromMountInfo - Creates a new MountEvent from parsed MountInfo data
 func newMountEventFromMountInfo(mnt *mountinfo.Info) (*MountEvent, error) {
 	var err error
-	var groupID uint64
+	var groupID, masterID uint64
 
 	// Has optional fields, which is a space separated list of values.
 	// Example: shared:2 master:7
-	if len(mnt.Optional) > 0 {
-		for _, field := range strings.Split(mnt.Optional, ",") {
-			optionSplit := strings.SplitN(field, ":", 2)
-			if len(optionSplit) == 2 {
-				target, value := optionSplit[0], optionSplit[1]
-				if target == "shared" || target == "master" {
-					if groupID, err = strconv.ParseUint(value, 10, 64); err != nil {
-						return nil, err
-					}
-				}
+	for _, field := range strings.Fields(mnt.Optional) {
+		optionSplit := strings.SplitN(field, ":", 2)
+		if len(optionSplit) != 2 {
+			continue
+		}
+
+		target, value := optionSplit[0], optionSplit[1]
+		switch target {
+		case "shared":
+			if groupID, err = strconv.ParseUint(value, 10, 32); err != nil {
+				return nil, err
+			}
+		case "master":
+			if masterID, err = strconv.ParseUint(value, 10, 32); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -54,11 +59,40 @@ func newMountEventFromMountInfo(mnt *mountinfo.Info) (*MountEvent, error) {
 		RootStr:       mnt.Root,
 		MountID:       uint32(mnt.ID),
 		GroupID:       uint32(groupID),
-		Device:        uint32(unix.Mkdev(uint32(mnt.Major), uint32(mnt.Minor))),
+		MasterID:      uint32(masterID),
+		Device:        uint32(mnt.Major)<<kernelMinorBits | uint32(mnt.Minor),
 		FSType:        mnt.Fstype,
+		Source:        mnt.Source,
 	}, nil
 }
 
+// kernel encoding of the device numbers, see include/linux/kdev_t.h
+const (
+	kernelMinorBits = 20
+	kernelMinorMask = 1<<kernelMinorBits - 1
+)
+
+// ResolveSource resolves the source of a mount reported by the kernel: the source listed in the mountinfo of the
+// process that created it (block device, nfs share, tmpfs, ...), or the name of the block device when the process is
+// already gone.
+func (mr *MountResolver) ResolveSource(e *MountEvent, pid uint32) string {
+	if mounts, err := utils.ParseMountInfoFile(pid); err == nil {
+		for _, mnt := range mounts {
+			if uint32(mnt.ID) == e.MountID {
+				return mnt.Source
+			}
+		}
+	}
+
+	if major, minor := e.Device>>kernelMinorBits, e.Device&kernelMinorMask; major != 0 {
+		if name, err := utils.BlockDeviceName(major, minor); err == nil {
+			return "/dev/" + name
+		}
+	}
+
+	return e.GetFSType()
+}
+
 // IsOverlayFS returns whether it is an overlay fs
 func (m *MountEvent) IsOverlayFS() bool {
 	return m.GetFSType() == "overlay"
@@ -70,13 +104,32 @@ type MountResolver struct {
 	lock    sync.RWMutex
 	mounts  map[uint32]*MountEvent
 	devices map[uint32]map[uint32]*MountEvent
+	// namespaces holds the pid whose mountinfo was parsed, per mount namespace
+	namespaces map[uint64]uint32
 }
 
-// SyncCache - Snapshots the current mount points of the system by reading through /proc/[pid]/mountinfo.
+// SyncCache - Snapshots the current mount points of the system by reading through /proc/[pid]/mountinfo. The
+// mountinfo file is only parsed once per mount namespace, as long as the process it was parsed for lives in it.
 func (mr *MountResolver) SyncCache(pid uint32) error {
 	mr.lock.Lock()
 	defer mr.lock.Unlock()
 
+	mountNS, err := utils.GetMountNS(pid)
+	if err != nil {
+		if pErr, ok := errors.Cause(err).(*os.PathError); ok {
+			return pErr
+		}
+		return err
+	}
+
+	// the inode of a dead namespace can be reused by a new one, the namespace is known to be the one parsed only while
+	// the process of the parsed mountinfo is still in it
+	if syncedPid, exists := mr.namespaces[mountNS]; exists {
+		if syncedNS, err := utils.GetMountNS(syncedPid); err == nil && syncedNS == mountNS {
+			return nil
+		}
+	}
+
 	mnts, err := utils.ParseMountInfoFile(pid)
 	if err != nil {
 		pErr, ok := err.(*os.PathError)
@@ -92,12 +145,45 @@ func (mr *MountResolver) SyncCache(pid uint32) error {
 			return err
 		}
 
-		mr.insert(*e)
+		mr.reconcile(*e)
 	}
+	mr.namespaces[mountNS] = pid
 
 	return nil
 }
 
+// Resync reads again the mount points of the mount namespaces of the given pids, once per namespace, after a loss of
+// mount events. The cached mount points are reconciled with the mountinfo files
+func (mr *MountResolver) Resync(pids []uint32) {
+	mr.lock.Lock()
+	mr.namespaces = make(map[uint64]uint32)
+	mr.lock.Unlock()
+
+	for _, pid := range pids {
+		if err := mr.SyncCache(pid); err != nil && !os.IsNotExist(err) {
+			log.Debugf("couldn't resync the mount points of %d: %s", pid, err)
+		}
+	}
+}
+
+// reconcile inserts a mount point read from procfs, replacing the cached entry of the same mount ID if it exists. Mount
+// IDs are unique across mount namespaces, so the same mount may be reported by several namespaces.
+func (mr *MountResolver) reconcile(e MountEvent) {
+	if cached, exists := mr.mounts[e.MountID]; exists {
+		if cached.Device == e.Device && cached.ParentMountID == e.ParentMountID && cached.RootStr == e.RootStr {
+			// keep the entry as is, the mount point may have been resolved from another namespace view
+			cached.GroupID, cached.MasterID = e.GroupID, e.MasterID
+			return
+		}
+
+		if mounts, exists := mr.devices[cached.Device]; exists {
+			delete(mounts, cached.MountID)
+		}
+	}
+
+	mr.insert(e)
+}
+
 func (mr *MountResolver) deleteChildren(parent *MountEvent) {
 	for _, mount := range mr.mounts {
 		if mount.ParentMountID == parent.MountID {
@@ -131,6 +217,51 @@ func (mr *MountResolver) delete(mount *MountEvent) {
 
 	mr.deleteChildren(mount)
 	mr.deleteDevice(mount)
+	mr.deletePropagated(mount)
+}
+
+// isPropagatedCopy returns whether the given mount may be a copy of the other one created by mount propagation: a
+// mount of the same root dentry of the same device, on the same mount point dentry. The mount points are not compared
+// as strings, the ones read from the mountinfo files are relative to the root of the process, the ones of the kernel
+// events are resolved paths. The dentries of the mount points aren't known for the mounts read from procfs.
+func isPropagatedCopy(mount *MountEvent, other *MountEvent) bool {
+	if mount.Device != other.Device {
+		return false
+	}
+
+	if mount.RootInode != 0 && other.RootInode != 0 {
+		if mount.RootInode != other.RootInode {
+			return false
+		}
+	} else if mount.RootStr != other.RootStr {
+		return false
+	}
+
+	return mount.ParentInode == 0 || other.ParentInode == 0 || mount.ParentInode == other.ParentInode
+}
+
+// deletePropagated deletes the copies of a mount that were created by mount propagation. When a mount is removed from
+// a shared mount point, the kernel also removes the copies attached to the peers and to the slaves of its parent.
+func (mr *MountResolver) deletePropagated(mount *MountEvent) {
+	parent, exists := mr.mounts[mount.ParentMountID]
+	if !exists || parent.GroupID == 0 {
+		return
+	}
+
+	for _, propagated := range mr.mounts {
+		if !isPropagatedCopy(mount, propagated) {
+			continue
+		}
+
+		peer, exists := mr.mounts[propagated.ParentMountID]
+		if !exists || peer.MountID == parent.MountID {
+			continue
+		}
+
+		if peer.GroupID == parent.GroupID || peer.MasterID == parent.GroupID {
+			mr.delete(propagated)
+		}
+	}
 }
 
 // Delete a mount from the cache
@@ -248,12 +379,26 @@ func (mr *MountResolver) GetMountPath(mountID uint32) (string, string, string, e
 	return mr.getOverlayPath(ref), mr.getParentPath(mountID), mount.RootStr, nil
 }
 
+// GetDevice returns the device of a mount identified by its mount ID, in the kernel encoding, and the type of its file
+// system
+func (mr *MountResolver) GetDevice(mountID uint32) (uint32, string, bool) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+
+	mount, ok := mr.mounts[mountID]
+	if !ok {
+		return 0, "", false
+	}
+	return mount.Device, mount.GetFSType(), true
+}
+
 // NewMountResolver instantiates a new mount resolver
 func NewMountResolver(probe *Probe) *MountResolver {
 	return &MountResolver{
-		probe:   probe,
-		lock:    sync.RWMutex{},
-		devices: make(map[uint32]map[uint32]*MountEvent),
-		mounts:  make(map[uint32]*MountEvent),
+		probe:      probe,
+		lock:       sync.RWMutex{},
+		devices:    make(map[uint32]map[uint32]*MountEvent),
+		mounts:     make(map[uint32]*MountEvent),
+		namespaces: make(map[uint64]uint32),
 	}
 }
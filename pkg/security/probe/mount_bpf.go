@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -23,7 +24,9 @@ func (mr *MountResolver) setMountIDOffset() error {
 	}
 
 	var offsetItem ebpf.Uint32MapItem
-	if suseKernel {
+	if offset := mr.probe.kernelOffsets.MountID; offset != 0 {
+		offsetItem = ebpf.Uint32MapItem(offset)
+	} else if suseKernel {
 		offsetItem = 292
 	} else if mr.probe.kernelVersion != 0 && mr.probe.kernelVersion <= kernel4_13 {
 		offsetItem = 268
@@ -45,3 +48,78 @@ func (mr *MountResolver) setMountIDOffset() error {
 func (mr *MountResolver) Start() error {
 	return mr.setMountIDOffset()
 }
+
+const (
+	// fsTypeLen is the size of the filesystem types in the kernel, including the trailing NUL
+	fsTypeLen = 16
+	// maxMountSourceDiscarders is the maximum number of mount sources discarded in user space
+	maxMountSourceDiscarders = 1024
+)
+
+// discardMountFSType discards the mount points of the given filesystem type in the kernel, they are still sent to
+// keep the mount cache in sync but they aren't dispatched
+func discardMountFSType(probe *Probe, fsType string) (bool, error) {
+	if fsType == "" || len(fsType) >= fsTypeLen {
+		return false, nil
+	}
+
+	reason := newFieldDiscarderReason(FileMountEventType, "mount.fs_type", fsType)
+	if err := probe.putDiscarder("mount_fstype_discarders", ebpf.NewStringMapItem(fsType, fsTypeLen), ebpf.ZeroUint8MapItem, reason); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// discardMountSource discards the mount points of the given source. The source is only resolved in user space, from
+// the mountinfo of the process, the mount points are thus discarded once their source is resolved
+func discardMountSource(probe *Probe, source string) bool {
+	if source == "" {
+		return false
+	}
+
+	probe.mountSourceDiscardersLock.Lock()
+	defer probe.mountSourceDiscardersLock.Unlock()
+
+	if len(probe.mountSourceDiscarders) >= maxMountSourceDiscarders {
+		return false
+	}
+	probe.mountSourceDiscarders[source] = true
+
+	return true
+}
+
+// isMountSourceDiscarded returns whether the mount points of the given source are discarded
+func (p *Probe) isMountSourceDiscarded(source string) bool {
+	p.mountSourceDiscardersLock.Lock()
+	defer p.mountSourceDiscardersLock.Unlock()
+
+	return p.mountSourceDiscarders[source]
+}
+
+func mountOnNewDiscarder(rs *rules.RuleSet, event *Event, probe *Probe, discarder Discarder) error {
+	value, err := event.GetFieldValue(discarder.Field)
+	if err != nil {
+		return err
+	}
+	str := value.(string)
+
+	if probe.IsInvalidDiscarder(discarder.Field, str) {
+		return nil
+	}
+
+	switch discarder.Field {
+	case "mount.fs_type":
+		log.Tracef("apply mount.fs_type discarder with value `%s`", str)
+
+		if _, err := discardMountFSType(probe, str); err != nil {
+			return errors.Wrapf(err, "unable to set the mount discarder of the filesystem type `%s`", str)
+		}
+	case "mount.source":
+		log.Tracef("apply mount.source discarder with value `%s`", str)
+
+		discardMountSource(probe, str)
+	}
+
+	return nil
+}
@@ -8,9 +8,13 @@
 package probe
 
 import (
+	"os"
 	"testing"
 
+	"github.com/moby/sys/mountinfo"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
 )
 
 func TestMountResolver(t *testing.T) {
@@ -349,3 +353,112 @@ func TestMountResolver(t *testing.T) {
 		})
 	}
 }
+
+func TestMountInfoPropagation(t *testing.T) {
+	e, err := newMountEventFromMountInfo(&mountinfo.Info{
+		ID:         638,
+		Parent:     27,
+		Major:      8,
+		Minor:      1,
+		Root:       "/var/lib/data",
+		Mountpoint: "/data",
+		Optional:   "shared:12 master:7",
+		Fstype:     "ext4",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint32(12), e.GroupID)
+	assert.Equal(t, uint32(7), e.MasterID)
+	assert.Equal(t, "/var/lib/data", e.RootStr)
+	// the device of the parsed mounts is encoded as the one of the mounts reported by the kernel
+	assert.Equal(t, uint32(8<<kernelMinorBits|1), e.Device)
+}
+
+func TestMountResolverPropagatedUmount(t *testing.T) {
+	mr := NewMountResolver(nil)
+
+	// shared root mount and its slave bind mount
+	mr.Insert(MountEvent{MountID: 27, GroupID: 1, Device: 1, ParentMountID: 1, FSType: "ext4", MountPointStr: "/"})
+	mr.Insert(MountEvent{MountID: 176, MasterID: 1, Device: 1, ParentMountID: 27, FSType: "ext4", MountPointStr: "/mnt/slave", RootStr: "/"})
+
+	// a tmpfs mounted on the shared mount, propagated to the slave
+	mr.Insert(MountEvent{MountID: 200, Device: 42, ParentMountID: 27, FSType: "tmpfs", MountPointStr: "/tmp/shared"})
+	mr.Insert(MountEvent{MountID: 201, Device: 42, ParentMountID: 176, FSType: "tmpfs", MountPointStr: "/tmp/shared"})
+
+	_, p, _, err := mr.GetMountPath(201)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "/mnt/slave/tmp/shared", p)
+
+	if err := mr.Delete(200); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, ErrMountNotFound, mr.Delete(201))
+}
+
+func TestMountResolverPropagatedUmountPaths(t *testing.T) {
+	mr := NewMountResolver(nil)
+
+	mr.Insert(MountEvent{MountID: 27, GroupID: 1, Device: 1, ParentMountID: 1, FSType: "ext4", MountPointStr: "/"})
+	mr.Insert(MountEvent{MountID: 176, MasterID: 1, Device: 1, ParentMountID: 27, FSType: "ext4", MountPointStr: "/mnt/slave", RootStr: "/"})
+
+	// the mount point of the copy was read from the mountinfo of a chrooted process, its path differs
+	mr.Insert(MountEvent{MountID: 300, Device: 43, ParentMountID: 27, FSType: "ext4", MountPointStr: "/srv/data", RootStr: "/"})
+	mr.Insert(MountEvent{MountID: 301, Device: 43, ParentMountID: 176, FSType: "ext4", MountPointStr: "/data", RootStr: "/"})
+
+	// another root of the same device mounted on the slave isn't a copy
+	mr.Insert(MountEvent{MountID: 302, Device: 43, ParentMountID: 176, FSType: "ext4", MountPointStr: "/srv/data", RootStr: "/backup"})
+
+	// a copy on another mount point dentry isn't deleted either
+	mr.Insert(MountEvent{MountID: 310, Device: 44, ParentMountID: 27, ParentInode: 10, RootInode: 2, FSType: "ext4", MountPointStr: "/srv/logs"})
+	mr.Insert(MountEvent{MountID: 311, Device: 44, ParentMountID: 176, ParentInode: 10, RootInode: 2, FSType: "ext4", MountPointStr: "/logs"})
+	mr.Insert(MountEvent{MountID: 312, Device: 44, ParentMountID: 176, ParentInode: 11, RootInode: 2, FSType: "ext4", MountPointStr: "/srv/logs"})
+
+	if err := mr.Delete(300); err != nil {
+		t.Fatal(err)
+	}
+	if err := mr.Delete(310); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, ErrMountNotFound, mr.Delete(301))
+	assert.Equal(t, ErrMountNotFound, mr.Delete(311))
+	assert.NoError(t, mr.Delete(302))
+	assert.NoError(t, mr.Delete(312))
+}
+
+func TestMountResolverNamespaceReuse(t *testing.T) {
+	mr := NewMountResolver(nil)
+
+	pid := uint32(os.Getpid())
+	mountNS, err := utils.GetMountNS(pid)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	if err := mr.SyncCache(pid); err != nil {
+		t.Fatal(err)
+	}
+	if len(mr.mounts) == 0 {
+		t.Fatal("expected the mount points of the namespace to be cached")
+	}
+
+	// the namespace is only parsed once
+	mr.mounts = make(map[uint32]*MountEvent)
+	if err := mr.SyncCache(pid); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, mr.mounts)
+
+	// the process of the parsed mountinfo is gone, the inode of its namespace may have been reused
+	mr.namespaces[mountNS] = 1 << 30
+	if err := mr.SyncCache(pid); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, mr.mounts)
+	assert.Equal(t, pid, mr.namespaces[mountNS])
+}
@@ -8,13 +8,16 @@
 package probe
 
 import (
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
 )
 
 var openCapabilities = Capabilities{
 	"open.filename": {
-		PolicyFlags:     PolicyFlagBasename,
-		FieldValueTypes: eval.ScalarValueType,
+		PolicyFlags:        PolicyFlagBasename,
+		FieldValueTypes:    eval.ScalarValueType | eval.PatternValueType,
+		PatternPolicyFlags: PolicyFlagPrefix,
+		ValidateFnc:        validateOpenFilename,
 	},
 	"open.basename": {
 		PolicyFlags:     PolicyFlagBasename,
@@ -25,3 +28,136 @@ var openCapabilities = Capabilities{
 		FieldValueTypes: eval.ScalarValueType | eval.BitmaskValueType,
 	},
 }
+
+// validateOpenFilename only accepts the filenames and the filename patterns having a directory prefix. The
+// case-insensitive patterns match other directories than their prefix, they can't be approved in kernel
+func validateOpenFilename(value rules.FilterValue) bool {
+	switch value.Type {
+	case eval.ScalarValueType:
+		return true
+	case eval.PatternValueType:
+	default:
+		return false
+	}
+
+	pattern, ok := value.Value.(string)
+	if !ok {
+		return false
+	}
+
+	_, err := patternPrefix(pattern)
+	return err == nil
+}
+
+// openPreEvalCapabilities lists the fields of the open events pre-evaluated in kernel
+var openPreEvalCapabilities = rules.FieldCapabilities{
+	{Field: "open.filename", Types: eval.ScalarValueType},
+	{Field: "open.basename", Types: eval.ScalarValueType},
+	{Field: "open.flags", Types: eval.BitmaskValueType},
+	{Field: "process.uid", Types: eval.ScalarValueType},
+	{Field: "process.gid", Types: eval.ScalarValueType},
+	{Field: "process.name", Types: eval.ScalarValueType},
+}
+
+// openApprover is the value of a filename or a basename approver of open events, along with the flags required by
+// the rules approving it, 0 when one of them requires none. The value of a prefix approver is a directory prefix
+type openApprover struct {
+	Value  string
+	Flags  int
+	Prefix bool
+}
+
+// mergeOpenFlags merges the flags required by a rule approving the given value with the ones of the other rules
+func mergeOpenFlags(approvers map[string]int, value string, flags int) {
+	if current, exists := approvers[value]; !exists {
+		approvers[value] = flags
+	} else if current == 0 || flags == 0 {
+		approvers[value] = 0
+	} else {
+		approvers[value] = current | flags
+	}
+}
+
+// combineOpenApprovers combines the filename and basename approvers of each open rule with the flags approvers of the
+// same rule, an event is then approved only if both its name and its flags may match one of the rules. The filename
+// patterns are reduced to their directory prefix. The approvers are left unchanged if one of the rules can't be
+// approved by its filename or its basename
+func combineOpenApprovers(rs *rules.RuleSet, approvers rules.Approvers) rules.Approvers {
+	bucket := rs.GetBucket("open")
+	if bucket == nil {
+		return approvers
+	}
+
+	nameCaps := Capabilities{
+		"open.filename": openCapabilities["open.filename"],
+		"open.basename": openCapabilities["open.basename"],
+	}.GetFieldCapabilities()
+
+	flagsCaps := Capabilities{
+		"open.flags": openCapabilities["open.flags"],
+	}.GetFieldCapabilities()
+
+	names := make(map[eval.Field]map[string]int)
+	prefixes := make(map[string]int)
+	for _, rule := range bucket.GetRules() {
+		nameApprovers, err := rs.GetRuleApprovers(rule.ID, nameCaps)
+		if err != nil {
+			return approvers
+		}
+
+		// a null flag, O_RDONLY for instance, can't be approved by the bits of the flags
+		var flags int
+		if flagsApprovers, err := rs.GetRuleApprovers(rule.ID, flagsCaps); err == nil {
+			for _, value := range flagsApprovers["open.flags"] {
+				if value.Value.(int) == 0 {
+					flags = 0
+					break
+				}
+				flags |= value.Value.(int)
+			}
+		}
+
+		for field, values := range nameApprovers {
+			if names[field] == nil {
+				names[field] = make(map[string]int)
+			}
+			for _, value := range values {
+				switch value.Type {
+				case eval.ScalarValueType:
+					mergeOpenFlags(names[field], value.Value.(string), flags)
+					continue
+				case eval.PatternValueType:
+				default:
+					return approvers
+				}
+
+				prefix, err := patternPrefix(value.Value.(string))
+				if err != nil {
+					return approvers
+				}
+				mergeOpenFlags(prefixes, prefix, flags)
+			}
+		}
+	}
+
+	combined := make(rules.Approvers)
+	for field, values := range names {
+		for value, flags := range values {
+			combined[field] = append(combined[field], rules.FilterValue{
+				Field: field,
+				Value: openApprover{Value: value, Flags: flags},
+				Type:  eval.ScalarValueType,
+			})
+		}
+	}
+
+	for prefix, flags := range prefixes {
+		combined["open.filename"] = append(combined["open.filename"], rules.FilterValue{
+			Field: "open.filename",
+			Value: openApprover{Value: prefix, Flags: flags, Prefix: true},
+			Type:  eval.PatternValueType,
+		})
+	}
+
+	return combined
+}
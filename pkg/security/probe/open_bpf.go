@@ -12,39 +12,73 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
 )
 
-func openOnNewApprovers(probe *Probe, approvers rules.Approvers) error {
-	stringValues := func(fvs rules.FilterValues) []string {
-		var values []string
-		for _, v := range fvs {
-			values = append(values, v.Value.(string))
-		}
-		return values
-	}
+// openApprovers returns the values of the given filename or basename approvers, with the flags required by the rules
+// approving them when they were combined. The patterns are reduced to their directory prefix
+func openApprovers(fvs rules.FilterValues) ([]openApprover, error) {
+	var approvers []openApprover
+	for _, v := range fvs {
+		switch value := v.Value.(type) {
+		case openApprover:
+			approvers = append(approvers, value)
+		case string:
+			switch v.Type {
+			case eval.ScalarValueType:
+				approvers = append(approvers, openApprover{Value: value})
+				continue
+			case eval.PatternValueType:
+			default:
+				return nil, errors.Errorf("value `%s` of type %d can't be approved in kernel", value, v.Type)
+			}
 
-	intValues := func(fvs rules.FilterValues) []int {
-		var values []int
-		for _, v := range fvs {
-			values = append(values, v.Value.(int))
+			prefix, err := patternPrefix(value)
+			if err != nil {
+				return nil, err
+			}
+			approvers = append(approvers, openApprover{Value: prefix, Prefix: true})
 		}
-		return values
 	}
+	return approvers, nil
+}
+
+func openOnNewApprovers(probe *Probe, approvers rules.Approvers) error {
+	// a filename and a basename approver may share the same basename
+	basenames := make(map[string]int)
+	filenames := newFilenameApprovers(probe, FileOpenEventType)
 
 	for field, values := range approvers {
 		switch field {
 		case "open.basename":
-			if err := approveBasenames(probe, "open_basename_approvers", stringValues(values)...); err != nil {
+			nameApprovers, err := openApprovers(values)
+			if err != nil {
 				return err
 			}
 
+			for _, approver := range nameApprovers {
+				mergeOpenFlags(basenames, approver.Value, approver.Flags)
+				filenames.addBasenames(approver.Value)
+			}
+
 		case "open.filename":
-			for _, value := range stringValues(values) {
-				basename := path.Base(value)
-				if err := approveBasename(probe, "open_basename_approvers", basename); err != nil {
-					return err
+			nameApprovers, err := openApprovers(values)
+			if err != nil {
+				return err
+			}
+
+			for _, approver := range nameApprovers {
+				if approver.Prefix {
+					if err := approvePrefix(probe, FileOpenEventType, approver.Value, approver.Flags); err != nil {
+						return err
+					}
+					continue
 				}
+
+				mergeOpenFlags(basenames, path.Base(approver.Value), approver.Flags)
+				filenames.addFilenames(approver.Value)
 			}
 
 		case "open.flags":
@@ -57,5 +91,11 @@ func openOnNewApprovers(probe *Probe, approvers rules.Approvers) error {
 		}
 	}
 
-	return nil
+	for basename, flags := range basenames {
+		if err := probe.putApprover("open_basename_approvers", ebpf.NewStringMapItem(basename, BasenameFilterSize), ebpf.Uint32MapItem(flags)); err != nil {
+			return err
+		}
+	}
+
+	return filenames.approveInodes()
 }
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Pause stops the kernel from sending the events of the rules, until the probe is resumed. The probes stay attached
+// and the maps and resolvers are kept: the process and mount events are still sent, so that the events are resolved
+// as soon as the probe resumes. The file events invalidating the dentry cache aren't sent either, the cache is flushed
+// when the probe resumes
+func (p *Probe) Pause() error {
+	return p.setPaused(true)
+}
+
+// Resume resumes the sending of the events of the rules by the kernel, and flushes the dentry cache as the files
+// renamed or removed while paused weren't invalidated
+func (p *Probe) Resume() error {
+	if err := p.setPaused(false); err != nil {
+		return err
+	}
+
+	p.resolvers.DentryResolver.Flush()
+	return nil
+}
+
+// IsPaused returns whether the probe is paused
+func (p *Probe) IsPaused() bool {
+	p.pausedLock.Lock()
+	defer p.pausedLock.Unlock()
+
+	return p.paused
+}
+
+func (p *Probe) setPaused(paused bool) error {
+	p.pausedLock.Lock()
+	defer p.pausedLock.Unlock()
+
+	table := p.Map("probe_paused")
+	if table == nil {
+		return errors.New("map probe_paused not found")
+	}
+
+	value := uint32(0)
+	if paused {
+		value = 1
+	}
+
+	if err := table.Put(ebpf.ZeroUint32MapItem, ebpf.Uint32MapItem(value)); err != nil {
+		return errors.Wrap(err, "failed to update the map probe_paused")
+	}
+	p.paused = paused
+
+	if paused {
+		log.Infof("probe paused, the kernel doesn't send the events of the rules anymore")
+	} else {
+		log.Infof("probe resumed")
+	}
+	return nil
+}
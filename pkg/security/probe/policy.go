@@ -30,9 +30,18 @@ const (
 	PolicyFlagBasename PolicyFlag = 1
 	PolicyFlagFlags    PolicyFlag = 2
 	PolicyFlagMode     PolicyFlag = 4
+	PolicyFlagUID      PolicyFlag = 16
+	PolicyFlagGID      PolicyFlag = 32
+	PolicyFlagComm     PolicyFlag = 64
+	PolicyFlagPrefix   PolicyFlag = 128
 
 	// need to be aligned with the kernel size
 	BasenameFilterSize = 32
+	// CommFilterSize is the size of the process names of the approvers, it needs to be aligned with the kernel size
+	CommFilterSize = 16
+	// IDRangeApproversSize is the maximum number of uid or gid ranges approved for an event type, it needs to be
+	// aligned with the kernel size
+	IDRangeApproversSize = 16
 )
 
 func (m PolicyMode) String() string {
@@ -69,5 +78,17 @@ func (f PolicyFlag) MarshalJSON() ([]byte, error) {
 	if f&PolicyFlagMode != 0 {
 		flags = append(flags, `"mode"`)
 	}
+	if f&PolicyFlagUID != 0 {
+		flags = append(flags, `"uid"`)
+	}
+	if f&PolicyFlagGID != 0 {
+		flags = append(flags, `"gid"`)
+	}
+	if f&PolicyFlagComm != 0 {
+		flags = append(flags, `"comm"`)
+	}
+	if f&PolicyFlagPrefix != 0 {
+		flags = append(flags, `"prefix"`)
+	}
 	return []byte("[" + strings.Join(flags, ",") + "]"), nil
 }
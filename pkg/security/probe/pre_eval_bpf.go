@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"path"
+
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxPreEvalRules is the maximum number of entries of the decision table of an event type in kernel
+const maxPreEvalRules = 16
+
+// preEvalField identifies a field pre-evaluated in kernel
+type preEvalField uint32
+
+const (
+	preEvalBasename preEvalField = 1 << iota
+	preEvalFlags
+	preEvalUID
+	preEvalGID
+	preEvalComm
+)
+
+// preEvalFields associates the fields of the models with the fields pre-evaluated in kernel, a filename is
+// pre-evaluated by its basename
+var preEvalFields = map[string]preEvalField{
+	"open.filename": preEvalBasename,
+	"open.basename": preEvalBasename,
+	"open.flags":    preEvalFlags,
+	"process.uid":   preEvalUID,
+	"process.gid":   preEvalGID,
+	"process.name":  preEvalComm,
+}
+
+// preEvalRule is the kernel representation of an entry of a decision table
+type preEvalRule struct {
+	Fields   preEvalField
+	Flags    uint32
+	UID      uint32
+	GID      uint32
+	Comm     [CommFilterSize]byte
+	Basename [BasenameFilterSize]byte
+}
+
+// newPreEvalRule returns the kernel representation of the given entry of a decision table. The names are truncated as
+// the kernel does, when two values of an entry apply to the same kernel field only the first one is pre-evaluated
+func newPreEvalRule(entry rules.FilterValues) *preEvalRule {
+	var rule preEvalRule
+	for _, value := range entry {
+		field, exists := preEvalFields[value.Field]
+		if !exists || rule.Fields&field != 0 {
+			continue
+		}
+
+		switch field {
+		case preEvalBasename:
+			basename, ok := value.Value.(string)
+			if !ok {
+				continue
+			}
+			if value.Field == "open.filename" {
+				basename = path.Base(basename)
+			}
+			copy(rule.Basename[:BasenameFilterSize-1], basename)
+		case preEvalFlags:
+			// a null flag, O_RDONLY for instance, can't be pre-evaluated by the bits of the flags
+			flags, ok := value.Value.(int)
+			if !ok || flags == 0 {
+				continue
+			}
+			rule.Flags = uint32(flags)
+		case preEvalUID, preEvalGID:
+			id, ok := value.Value.(int)
+			if !ok {
+				continue
+			}
+			if field == preEvalUID {
+				rule.UID = uint32(id)
+			} else {
+				rule.GID = uint32(id)
+			}
+		case preEvalComm:
+			comm, ok := value.Value.(string)
+			if !ok {
+				continue
+			}
+			copy(rule.Comm[:CommFilterSize-1], comm)
+		}
+		rule.Fields |= field
+	}
+	return &rule
+}
+
+// preEvalTailCalls lists the programs pre-evaluating the events of each event type
+var preEvalTailCalls = []manager.TailCallRoute{
+	{
+		ProgArrayName: "pre_eval_progs",
+		Key:           uint32(FileOpenEventType),
+		ProbeIdentificationPair: manager.ProbeIdentificationPair{
+			Section: "kprobe/pre_eval_open",
+		},
+	},
+}
+
+// setPreEvalRules sets the number of entries of the decision table of the given event type, 0 when its rules aren't
+// pre-evaluated
+func (p *Probe) setPreEvalRules(eventType EventType, count uint32) error {
+	// the decision table being applied is enabled once the filters are replaced
+	if p.filterUpdate != nil {
+		p.filterUpdate.preEvalRules[eventType] = count
+		return nil
+	}
+
+	p.preEvalRulesLock.Lock()
+	defer p.preEvalRulesLock.Unlock()
+
+	if p.preEvalRules == nil {
+		p.preEvalRules = make(map[EventType]uint32)
+	}
+	p.preEvalRules[eventType] = count
+
+	return p.applyPreEvalConfig(eventType, count)
+}
+
+// applyPreEvalConfig enables the pre-evaluation of the given event type with the given number of entries. The events
+// the handlers added with AddEventHandler subscribed to are never pre-evaluated, they receive all of them
+func (p *Probe) applyPreEvalConfig(eventType EventType, count uint32) error {
+	config := p.Map("pre_eval_config")
+	if config == nil {
+		return errors.New("map pre_eval_config not found")
+	}
+
+	if handledEventTypes, all := p.handledEventTypes(); all {
+		count = 0
+	} else {
+		for _, handled := range handledEventTypes {
+			if handled == eventType {
+				count = 0
+			}
+		}
+	}
+
+	return config.Put(ebpf.Uint32MapItem(eventType), ebpf.Uint32MapItem(count))
+}
+
+// refreshPreEvaluation enables or disables the pre-evaluation of the event types once the event types the handlers
+// subscribed to changed
+func (p *Probe) refreshPreEvaluation() {
+	p.preEvalRulesLock.Lock()
+	defer p.preEvalRulesLock.Unlock()
+
+	for eventType, count := range p.preEvalRules {
+		if err := p.applyPreEvalConfig(eventType, count); err != nil {
+			log.Errorf("failed to refresh the pre-evaluation of `%s`: %v", eventType, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func TestPreEvalRuleFlags(t *testing.T) {
+	rule := newPreEvalRule(rules.FilterValues{
+		{Field: "open.flags", Value: syscall.O_CREAT},
+		{Field: "process.uid", Value: 0},
+	})
+	if rule.Fields != preEvalFlags|preEvalUID || rule.Flags != syscall.O_CREAT {
+		t.Errorf("expected the flags and the uid to be pre-evaluated, got %+v", rule)
+	}
+
+	// O_RDONLY has no bit set, the kernel would drop all the events of the rule
+	rule = newPreEvalRule(rules.FilterValues{
+		{Field: "open.flags", Value: syscall.O_RDONLY},
+		{Field: "open.basename", Value: "shadow"},
+	})
+	if rule.Fields != preEvalBasename || rule.Flags != 0 {
+		t.Errorf("expected only the basename to be pre-evaluated, got %+v", rule)
+	}
+
+	rule = newPreEvalRule(rules.FilterValues{{Field: "open.flags", Value: syscall.O_RDONLY}})
+	if rule.Fields != 0 {
+		t.Errorf("expected an entry matching all the events, got %+v", rule)
+	}
+}
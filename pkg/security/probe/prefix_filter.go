@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+)
+
+const (
+	// PrefixMaxDepth is the maximum number of directories of an approved prefix, it needs to be aligned with the
+	// kernel depth
+	PrefixMaxDepth = 16
+	// PrefixSegmentLen is the size of the hashed directory names, it needs to be aligned with the kernel size
+	PrefixSegmentLen = 32
+
+	prefixHashSeed  uint64 = 0xcbf29ce484222325
+	prefixHashPrime uint64 = 0x100000001b3
+)
+
+// patternPrefix returns the directory prefix of a filename pattern, its part up to the last separator preceding the
+// first wildcard. The patterns without wildcard, and the ones matching the files of any directory, have no prefix
+func patternPrefix(pattern string) (string, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return "", errors.Errorf("pattern `%s` isn't absolute", pattern)
+	}
+
+	index := strings.Index(pattern, "*")
+	if index == -1 {
+		return "", errors.Errorf("pattern `%s` has no wildcard", pattern)
+	}
+
+	prefix := pattern[:strings.LastIndex(pattern[:index], "/")+1]
+	if prefix == "/" {
+		return "", errors.Errorf("pattern `%s` matches the files of any directory", pattern)
+	}
+
+	if depth := strings.Count(prefix, "/") - 1; depth > PrefixMaxDepth {
+		return "", errors.Errorf("prefix `%s` is deeper than %d directories", prefix, PrefixMaxDepth)
+	}
+
+	return prefix, nil
+}
+
+// segmentHash returns the hash of a directory name, computed on its words like the kernel does. The name is truncated
+// as read by the kernel
+func segmentHash(segment string) uint64 {
+	var buffer [PrefixSegmentLen]byte
+	copy(buffer[:PrefixSegmentLen-1], segment)
+
+	hash := prefixHashSeed
+	for i := 0; i < PrefixSegmentLen; i += 8 {
+		hash = (hash ^ ebpf.ByteOrder.Uint64(buffer[i:i+8])) * prefixHashPrime
+	}
+	return hash
+}
+
+// prefixHash returns the hash of a directory prefix, the sum of the hashes of its directories weighted by the powers
+// of the prime from the top directory. It needs to be aligned with the kernel hash
+func prefixHash(prefix string) uint64 {
+	var hash uint64
+	weight := uint64(1)
+	for _, segment := range strings.Split(strings.Trim(prefix, "/"), "/") {
+		hash += segmentHash(segment) * weight
+		weight *= prefixHashPrime
+	}
+	return hash
+}
@@ -8,8 +8,11 @@
 package probe
 
 import (
+	"strings"
+
 	"github.com/DataDog/datadog-agent/pkg/security/rules"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
 )
 
 // SupportedDiscarders lists all field which supports discarders
@@ -21,5 +24,61 @@ func (p *Probe) NewRuleSet(opts *rules.Opts) *rules.RuleSet {
 		return NewEvent(p.resolvers)
 	}
 
+	// variables set by rule actions, rate limits, thresholds and sequences are scoped to the process, the session,
+	// the container or the user of the event. The session of a process is inherited by its children, it thus scopes
+	// a process tree
+	opts.VariableScopes["process"] = func(ctx *eval.Context) interface{} {
+		return (*Event)(ctx.Object).Process.Pid
+	}
+	opts.VariableScopes["session"] = func(ctx *eval.Context) interface{} {
+		event := (*Event)(ctx.Object)
+		if sessionID := event.Process.ResolveSessionID(event.resolvers); sessionID != utils.AuditUnset {
+			return sessionID
+		}
+		return nil
+	}
+	opts.VariableScopes["container"] = func(ctx *eval.Context) interface{} {
+		event := (*Event)(ctx.Object)
+		if id := event.Container.ResolveContainerID(event.resolvers); id != "" {
+			return id
+		}
+		return nil
+	}
+	opts.VariableScopes["user"] = func(ctx *eval.Context) interface{} {
+		return (*Event)(ctx.Object).Process.UID
+	}
+
+	// the scopes of the rules select workloads by image and labels
+	opts.Workload = func(ctx *eval.Context) *rules.Workload {
+		return (*Event)(ctx.Object).ResolveWorkload()
+	}
+
 	return rules.NewRuleSet(&Model{}, eventCtor, opts)
 }
+
+// ResolveContainerID returns the ID of the container of the event, empty if the process doesn't run in a container
+func (e *Event) ResolveContainerID() string {
+	return e.Container.ResolveContainerID(e.resolvers)
+}
+
+// ResolveWorkload returns the workload of the event, its container ID, image and labels. The labels of a container
+// are formatted as `key:value` by the container resolver
+func (e *Event) ResolveWorkload() *rules.Workload {
+	workload := &rules.Workload{
+		ContainerID: e.ResolveContainerID(),
+	}
+	if workload.ContainerID == "" {
+		return workload
+	}
+
+	workload.ImageName = e.Container.ResolveImageName(e.resolvers)
+	if labels, err := e.resolvers.ContainerResolver.ResolveLabels(workload.ContainerID); err == nil {
+		workload.Labels = make(map[string]string, len(labels))
+		for _, label := range labels {
+			if kv := strings.SplitN(label, ":", 2); len(kv) == 2 {
+				workload.Labels[kv[0]] = kv[1]
+			}
+		}
+	}
+	return workload
+}
@@ -10,12 +10,19 @@ package probe
 import (
 	"context"
 	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-go/statsd"
 	lib "github.com/DataDog/ebpf"
 	"github.com/DataDog/ebpf/manager"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/golang-lru/simplelru"
 	"github.com/pkg/errors"
 
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
@@ -30,13 +37,25 @@ import (
 const (
 	// MetricPrefix is the prefix of the metrics sent by the runtime security agent
 	MetricPrefix = "datadog.runtime_security"
+
+	containerIDLen = 64
+	// containerEventTypesSize is the number of entries of the kernel map holding the event types of the containers
+	containerEventTypesSize = 1024
 )
 
 // EventHandler represents an handler for the events sent by the probe
 type EventHandler interface {
+	// HandleEvent handles an event of the probe. The event is recycled once it returns, it has to be copied to be
+	// retained. The handlers added with AddEventHandler are given copies, they own them
 	HandleEvent(event *Event)
 }
 
+// ProcessExitHandler is implemented by the event handlers notified of the exit of the processes. The exit events
+// aren't dispatched, the pid of an exited process can be reused right away
+type ProcessExitHandler interface {
+	HandleProcessExit(pid uint32)
+}
+
 // Discarder represents a discarder which is basically the field that we know for sure
 // that the value will be always rejected by the rules
 type Discarder struct {
@@ -59,17 +78,129 @@ type Probe struct {
 	managerOptions    manager.Options
 	config            *config.Config
 	handler           EventHandler
+	ipEnricher        IPEnricher
 	resolvers         *Resolvers
 	onDiscardersFncs  map[eval.EventType][]onDiscarderFnc
 	syscallMonitor    *SyscallMonitor
+	filterMonitor     *FilterMonitor
+	rateLimiter       *KernelRateLimiter
 	loadController    *LoadController
+	resyncController  *resyncController
 	kernelVersion     uint32
 	_                 uint32 // padding for goarch=386
 	eventsStats       EventsStats
 	startTime         time.Time
-	event             *Event
-	mountEvent        *Event
+	eventPool         *eventPool
 	invalidDiscarders map[eval.Field]map[interface{}]bool
+
+	// userGroupApprovers holds the approvers of user or group names per event type, applied again when the names
+	// are resolved to other ids
+	userGroupApprovers     map[eval.EventType]userGroupApprovers
+	userGroupApproversLock sync.Mutex
+
+	// standbyApprovers holds the approvers of the event types left in accept mode, the load controller switches an
+	// event type to them when the event pipeline is saturated
+	standbyApprovers     map[eval.EventType]standbyApprovers
+	standbyApproversLock sync.Mutex
+
+	// filtersLock serializes the updates of the in-kernel filters. filterUpdate holds the filters of the ruleset
+	// replacing the current one, if any, and filterKeys the keys of the approvers and container policies of the
+	// current filters, the ones the next filters don't have are removed once these are applied
+	filtersLock  sync.Mutex
+	filterUpdate *filterUpdate
+	filterKeys   filterKeys
+
+	// containerEventTypes holds the containers whose event types were restricted in the kernel, as many as the
+	// kernel map can hold. The kernel entries of the containers evicted from the cache are removed
+	containerEventTypes     *simplelru.LRU
+	containerEventTypesLock sync.Mutex
+
+	// mountSourceDiscarders holds the mount sources that no rule can match, the kernel doesn't know the sources
+	mountSourceDiscarders     map[string]bool
+	mountSourceDiscardersLock sync.Mutex
+	// discarderRegistry holds the reasons of the in-kernel discarders, checked again when the rules are reloaded
+	discarderRegistry *discarderRegistry
+
+	// kretprobeMisses holds the returns missed by the kretprobes per function, as of the last stats sent
+	kretprobeMisses map[string]int64
+
+	// programStats collects the stats of the eBPF programs, nil when the kernel doesn't measure them
+	programStats *programStats
+
+	// mapSizes holds the maximum number of entries of the maps sized for the host
+	mapSizes map[string]uint32
+
+	// families holds the families of probes and their managers, the core one first. The manager of the core family is
+	// the manager of the probe, holding the maps
+	families       []*probeFamily
+	familySections map[string]string
+	familiesLock   sync.Mutex
+	// bytecodeReader is the object the programs of the families are loaded from, assetName its name
+	bytecodeReader io.ReaderAt
+	assetName      string
+
+	// fentryProbes holds the kernel functions hooked by fentry or fexit programs in place of their kprobes
+	fentryProbes []*fentryProbe
+
+	// eventTypeSwitch holds the event types disabled at runtime and their detached probes
+	eventTypeSwitch     *eventTypeSwitch
+	eventTypeSwitchLock sync.Mutex
+
+	// ctx is cancelled when the probe is closed, stopping the background routines tracked by wg
+	ctx       context.Context
+	cancelFnc context.CancelFunc
+	wg        sync.WaitGroup
+
+	// eventStreams holds the transports of the events sent by the kernel, ring buffers or perf maps
+	eventStreams []eventStream
+
+	// eventWorkers decodes and dispatches the events in parallel, nil when the readers of the perf maps handle them
+	eventWorkers *eventWorkers
+	// eventBatcher groups the events read from the perf maps into batches, nil when they are handled once read
+	eventBatcher *eventBatcher
+
+	// capabilities holds the kernel capabilities detected at startup, disabledFeatures the features of the probe
+	// disabled by the missing ones
+	capabilities     KernelCapabilities
+	disabledFeatures map[string]string
+	// lockdown holds the lockdown restrictions of the kernel detected at startup
+	lockdown KernelLockdown
+	// kernelOffsets holds the offsets of the private kernel structures read from the kernel BTF at startup
+	kernelOffsets KernelOffsets
+
+	// pinnedMaps holds what became of the pinned maps when the probe started, migratedMaps the maps pinned by the
+	// previous run whose entries are copied to the new maps once loaded
+	pinnedMaps   map[string]pinnedMapState
+	migratedMaps map[string]*lib.Map
+
+	// degradedEventTypes holds the event types whose probes couldn't all be attached
+	degradedEventTypes []DegradedEventType
+
+	// eventHandlers holds the handlers added with AddEventHandler, each fed by its own queue
+	eventHandlers     []*subscribedEventHandler
+	eventHandlersLock sync.RWMutex
+
+	// preEvalRules holds the number of entries of the decision table of each pre-evaluated event type, the
+	// pre-evaluation is disabled while an event handler subscribes to the event type
+	preEvalRules     map[EventType]uint32
+	preEvalRulesLock sync.Mutex
+
+	// paused defines if the kernel stopped sending the events of the rules
+	paused     bool
+	pausedLock sync.Mutex
+}
+
+type standbyApprovers struct {
+	approvers rules.Approvers
+	flags     PolicyFlag
+}
+
+// userGroupApprovers holds the approvers of an event type comparing user or group names, along with the flags of its
+// policy in deny mode. The event type of pending approvers is left in accept mode until their names can be resolved
+type userGroupApprovers struct {
+	approvers rules.Approvers
+	flags     PolicyFlag
+	pending   bool
 }
 
 // Map returns a map by its name
@@ -100,6 +231,7 @@ func (p *Probe) Init() error {
 
 	// Set default options of the manager
 	p.managerOptions = ebpf.NewDefaultOptions()
+	p.managerOptions.DefaultKProbeMaxActive = p.config.KRetProbeMaxActive
 
 	if p.config.SyscallMonitor {
 		// Add syscall monitor probes
@@ -117,25 +249,52 @@ func (p *Probe) Init() error {
 	return nil
 }
 
+// getBytecodeReader returns the CO-RE object relocated for the running kernel when the kernel exposes its BTF, the
+// pre-built object matching the syscall wrappers of the kernel otherwise
+func (p *Probe) getBytecodeReader(useSyscallWrapper bool) (io.ReaderAt, error) {
+	reader, excludedSections, err := loadCOREAsset(p.config.BPFDir, useSyscallWrapper)
+	if err == nil {
+		log.Infof("loading the CO-RE object relocated with the kernel BTF")
+		p.managerOptions.ExcludedSections = append(p.managerOptions.ExcludedSections, excludedSections...)
+		p.assetName = coreAsset
+		return reader, nil
+	}
+	log.Warnf("failed to load the CO-RE object, falling back to the pre-built object: %s", err)
+
+	asset := "pkg/security/ebpf/c/runtime-security"
+	if useSyscallWrapper {
+		asset += "-syscall-wrapper"
+	}
+	p.assetName = asset + ".o"
+	return bytecode.GetReader(p.config.BPFDir, p.assetName)
+}
+
 // InitManager initializes the eBPF managers
 func (p *Probe) InitManager(rs *rules.RuleSet) error {
 	p.startTime = time.Now()
 	p.detectKernelVersion()
 
-	asset := "pkg/security/ebpf/c/runtime-security"
-	openSyscall, err := manager.GetSyscallFnName("open")
-	if err != nil {
+	if err := p.detectCapabilities(); err != nil {
 		return err
 	}
-	if !strings.HasPrefix(openSyscall, "SyS_") && !strings.HasPrefix(openSyscall, "sys_") {
-		asset += "-syscall-wrapper"
+
+	openSyscall, err := probes.GetSyscallFnName("open")
+	if err != nil {
+		return err
 	}
+	useSyscallWrapper := !strings.HasPrefix(openSyscall, "SyS_") && !strings.HasPrefix(openSyscall, "sys_")
 
-	bytecodeReader, err := bytecode.GetReader(p.config.BPFDir, asset+".o")
+	bytecodeReader, err := p.getBytecodeReader(useSyscallWrapper)
 	if err != nil {
 		return err
 	}
 
+	p.selectTracepointFallbacks()
+
+	if err := p.initFEntryProbes(bytecodeReader); err != nil {
+		return err
+	}
+
 	p.manager = ebpf.NewRuntimeSecurityManager()
 
 	// Set data and lost handlers
@@ -154,6 +313,37 @@ func (p *Probe) InitManager(rs *rules.RuleSet) error {
 		}
 	}
 
+	p.initEventStreams()
+
+	// eRPC requests are only accepted from the current process
+	p.managerOptions.ConstantEditors = append(p.managerOptions.ConstantEditors, manager.ConstantEditor{
+		Name:  "erpc_pid",
+		Value: uint64(os.Getpid()),
+	})
+
+	// the dentries of the resolved paths are only kept for the eRPC requests when they are enabled
+	erpcDentryResolution := uint64(0)
+	if p.useERPCDentryResolution() {
+		erpcDentryResolution = 1
+	}
+	p.managerOptions.ConstantEditors = append(p.managerOptions.ConstantEditors, manager.ConstantEditor{
+		Name:  "erpc_dentry_resolution_enabled",
+		Value: erpcDentryResolution,
+	})
+
+	p.managerOptions.MapSpecEditors = filterMapSpecEditors(p.config)
+	if err := p.sizeMaps(bytecodeReader); err != nil {
+		return err
+	}
+	if err := p.downgradeLRUMaps(bytecodeReader); err != nil {
+		return err
+	}
+
+	// the programs pre-evaluating the events are only tail called once a decision table is applied
+	p.managerOptions.TailCallRouter = append(p.managerOptions.TailCallRouter, preEvalTailCalls...)
+
+	p.excludeUnselectedProbes()
+
 	// ApplyConstants is called to apply
 	for _, eventType := range rs.GetEventTypes() {
 		if constants, exists := constantEditors[eventType]; exists {
@@ -161,7 +351,33 @@ func (p *Probe) InitManager(rs *rules.RuleSet) error {
 		}
 	}
 
-	if err := p.manager.InitWithOptions(bytecodeReader, p.managerOptions); err != nil {
+	// the failure of a family is isolated once the programs can't be compiled against the kernel headers
+	if err := p.initManagers(bytecodeReader, !p.config.RuntimeCompilationEnabled); err != nil {
+		if !p.config.RuntimeCompilationEnabled {
+			return err
+		}
+		log.Warnf("failed to load the eBPF programs, compiling them against the kernel headers: %s", err)
+
+		if bytecodeReader, err = compileRuntimeAsset(p.config, useSyscallWrapper); err != nil {
+			return errors.Wrap(err, "failed to compile the eBPF programs")
+		}
+		p.assetName = "runtime-compiled"
+
+		// the tracing programs are loaded from the compiled object as well
+		p.fentryProbes = nil
+		if err := p.initFEntryProbes(bytecodeReader); err != nil {
+			return err
+		}
+
+		// a manager whose initialization failed can't be initialized again
+		p.resetManager()
+
+		if err := p.initManagers(bytecodeReader, true); err != nil {
+			return err
+		}
+	}
+
+	if err := p.initPinnedMaps(rs); err != nil {
 		return err
 	}
 
@@ -176,28 +392,147 @@ func (p *Probe) InitManager(rs *rules.RuleSet) error {
 		}
 	}
 
+	p.filterMonitor, err = NewFilterMonitor(p.manager)
+	if err != nil {
+		return err
+	}
+
+	p.rateLimiter, err = NewKernelRateLimiter(p.manager, p.config)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // Start the runtime security probe
 func (p *Probe) Start() error {
-	if err := p.manager.Start(); err != nil {
+	if err := p.startManagers(); err != nil {
 		return err
 	}
+	if err := p.startFEntryProbes(); err != nil {
+		return err
+	}
+	if err := p.setMntNamespaceOffset(); err != nil {
+		log.Warnf("the mount namespaces of the processes won't be reported: %s", err)
+	}
+	p.startProgramStats()
+
+	// the event types with missing probes are reported, the other ones keep running
+	p.degradedEventTypes = degradedEventTypes(p.activatedProbes())
+	for _, degraded := range p.degradedEventTypes {
+		log.Warnf("the probes of the event type `%s` couldn't all be attached, its rules may not match: %s", degraded.EventType, degraded.Error)
+	}
+	if p.eventWorkers != nil {
+		p.eventWorkers.Start()
+	}
+	if p.eventBatcher != nil {
+		p.eventBatcher.Start()
+	}
+	for _, stream := range p.eventStreams {
+		if err := stream.Start(); err != nil {
+			return err
+		}
+	}
 	go p.loadController.Start(context.Background())
+	if p.resyncController != nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.resyncController.Start(p.ctx)
+		}()
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.userGroupMonitor(p.ctx)
+	}()
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.resolvers.ContainerResolver.Run(p.ctx)
+	}()
 	return nil
 }
 
+// userGroupMonitor applies the approvers of user or group names again when /etc/passwd or /etc/group change. The
+// events are passed to user space while the names can't be resolved, the approvers are retried at the next change
+func (p *Probe) userGroupMonitor(ctx context.Context) {
+	ticker := time.NewTicker(userGroupRefreshPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			changed, err := p.resolvers.UserGroupResolver.Refresh()
+			if err != nil {
+				log.Warnf("failed to resolve the users and the groups: %s", err)
+			}
+			if !changed {
+				continue
+			}
+
+			p.filtersLock.Lock()
+			p.userGroupApproversLock.Lock()
+			for eventType, approvers := range p.userGroupApprovers {
+				if err := allApproversFncs[eventType](p, approvers.approvers); err != nil {
+					if approvers.pending {
+						continue
+					}
+
+					log.Errorf("Error while resolving the users and the groups of the approvers of `%s`, setting in-kernel policy to `%s`: %s", eventType, PolicyModeAccept, err)
+					if err := p.ApplyFilterPolicy(eventType, PolicyModeAccept, math.MaxUint8); err != nil {
+						log.Errorf("failed to apply the filter policy of `%s`: %s", eventType, err)
+						continue
+					}
+					approvers.pending = true
+					p.userGroupApprovers[eventType] = approvers
+					continue
+				}
+
+				if approvers.pending {
+					log.Infof("users and groups of the approvers of `%s` resolved", eventType)
+					if err := p.ApplyFilterPolicy(eventType, PolicyModeDeny, approvers.flags); err != nil {
+						log.Errorf("failed to apply the filter policy of `%s`: %s", eventType, err)
+						continue
+					}
+					approvers.pending = false
+					p.userGroupApprovers[eventType] = approvers
+				}
+			}
+			p.userGroupApproversLock.Unlock()
+			p.filtersLock.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // SetEventHandler set the probe event handler
 func (p *Probe) SetEventHandler(handler EventHandler) {
 	p.handler = handler
 }
 
-// DispatchEvent sends an event to probe event handler
+// SetIPEnricher set the provider used to annotate the IP addresses of events, such as the source IP of the SSH
+// session of a process. It has to be set before the probe is started
+func (p *Probe) SetIPEnricher(enricher IPEnricher) {
+	if enricher == nil {
+		enricher = NoopIPEnricher{}
+	}
+	p.ipEnricher = enricher
+}
+
+// EnrichIP returns the tags of the given IP address
+func (p *Probe) EnrichIP(ip net.IP) []string {
+	return p.ipEnricher.EnrichIP(ip)
+}
+
+// DispatchEvent sends an event to the probe event handler, then queues it to the handlers added with AddEventHandler
 func (p *Probe) DispatchEvent(event *Event) {
 	if p.handler != nil {
 		p.handler.HandleEvent(event)
 	}
+	p.dispatchToEventHandlers(event)
 }
 
 // SendStats sends statistics about the probe to Datadog
@@ -208,6 +543,38 @@ func (p *Probe) SendStats(statsdClient *statsd.Client) error {
 		}
 	}
 
+	if err := p.resolvers.ProcessResolver.SendStats(statsdClient); err != nil {
+		return err
+	}
+
+	if p.filterMonitor != nil {
+		if err := p.filterMonitor.SendStats(statsdClient); err != nil {
+			return err
+		}
+	}
+
+	if err := p.sendMapSizes(statsdClient); err != nil {
+		return err
+	}
+
+	if err := p.sendKRetProbeMisses(statsdClient); err != nil {
+		return err
+	}
+
+	if err := p.sendProgramStats(statsdClient); err != nil {
+		return err
+	}
+
+	if err := p.sendEventHandlerStats(statsdClient); err != nil {
+		return err
+	}
+
+	if p.rateLimiter != nil {
+		if err := p.rateLimiter.SendStats(statsdClient); err != nil {
+			return err
+		}
+	}
+
 	if err := statsdClient.Count(MetricPrefix+".events.lost", p.eventsStats.GetAndResetLost(), nil, 1.0); err != nil {
 		return err
 	}
@@ -257,9 +624,86 @@ func (p *Probe) GetStats() (map[string]interface{}, error) {
 		perEventType[eventType.String()] = p.eventsStats.GetEventCount(eventType)
 	}
 
+	if err == nil && p.filterMonitor != nil {
+		stats["filters"], err = p.filterMonitor.GetStats()
+	}
+
+	if err == nil && p.filterMonitor != nil {
+		stats["filter_maps"], err = p.filterMonitor.GetMapStats()
+	}
+
+	if err == nil && p.rateLimiter != nil {
+		stats["kernel_rate_limiter"], err = p.rateLimiter.GetStats()
+	}
+
+	stats["map_sizes"] = p.mapSizes
+	stats["event_streams"] = p.GetEventStreamStats()
+	stats["paused"] = p.IsPaused()
+	stats["degraded_event_types"] = p.degradedEventTypes
+	stats["kernel_capabilities"] = p.capabilities
+	stats["kernel_lockdown"] = p.lockdown
+	stats["kernel_offsets"] = p.kernelOffsets
+	if p.config.MapPinningEnabled {
+		stats["pinned_maps"] = p.GetPinnedMaps()
+	}
+	if p.isFeatureSupported(featureKRetProbeStats) {
+		if misses, err := p.getKRetProbeMisses(); err == nil {
+			stats["kretprobe_misses"] = misses
+		}
+	}
+	if programStats := p.GetProgramStats(); programStats != nil {
+		stats["program_stats"] = programStats
+	}
+	if p.eventWorkers != nil {
+		stats["event_workers"] = p.eventWorkers.Stats()
+	}
+	if handlerStats := p.GetEventHandlerStats(); len(handlerStats) > 0 {
+		stats["event_handlers"] = handlerStats
+	}
+	if p.resyncController != nil {
+		stats["resyncs"] = p.resyncController.Resyncs()
+	}
+	stats["disabled_features"] = p.disabledFeatures
+	stats["probe_families"] = p.GetProbeFamilies()
+
 	return stats, err
 }
 
+// GetEventStreamStats returns the buffers of the event streams, per perf map
+func (p *Probe) GetEventStreamStats() map[string]EventStreamStats {
+	stats := make(map[string]EventStreamStats)
+	for _, stream := range p.eventStreams {
+		stats[stream.Name()] = stream.Stats()
+	}
+	return stats
+}
+
+// useERPCDentryResolution returns whether the paths are resolved through eRPC requests to the kernel programs
+func (p *Probe) useERPCDentryResolution() bool {
+	return p.config.ERPCDentryResolutionEnabled
+}
+
+// GetDegradedEventTypes returns the event types whose probes couldn't all be attached when the probe started
+func (p *Probe) GetDegradedEventTypes() []DegradedEventType {
+	return p.degradedEventTypes
+}
+
+// GetFilterStats returns the number of lookups of each in-kernel filter map since the probe was started
+func (p *Probe) GetFilterStats() (map[string]FilterStats, error) {
+	if p.filterMonitor == nil {
+		return nil, errors.New("the eBPF manager isn't initialized")
+	}
+	return p.filterMonitor.GetStats()
+}
+
+// DumpFilters returns the entries of all the in-kernel filter maps
+func (p *Probe) DumpFilters() (map[string][]FilterMapEntry, error) {
+	if p.filterMonitor == nil {
+		return nil, errors.New("the eBPF manager isn't initialized")
+	}
+	return p.filterMonitor.Dump()
+}
+
 // GetEventsStats returns statistics about the events received by the probe
 func (p *Probe) GetEventsStats() EventsStats {
 	return p.eventsStats
@@ -268,29 +712,19 @@ func (p *Probe) GetEventsStats() EventsStats {
 func (p *Probe) handleLostEvents(CPU int, count uint64, perfMap *manager.PerfMap, manager *manager.Manager) {
 	log.Tracef("lost %d events\n", count)
 	p.eventsStats.CountLost(int64(count))
+	p.loadController.CountLost(int64(count))
+	if p.resyncController != nil {
+		p.resyncController.CountLost(int64(count))
+	}
 }
 
-var eventZero Event
-
-func (p *Probe) zeroEvent() *Event {
-	*p.event = eventZero
-	p.event.resolvers = p.resolvers
-	return p.event
-}
-
-func (p *Probe) zeroMountEvent() *Event {
-	*p.mountEvent = eventZero
-	p.event.resolvers = p.resolvers
-	return p.mountEvent
-}
-
-func (p *Probe) unmarshalProcessContainer(data []byte, event *Event) (int, error) {
+func (p *Probe) unmarshalProcessContainer(data []byte, event *Event, processes batchProcesses) (int, error) {
 	read, err := unmarshalBinary(data, &event.Process, &event.Container)
 	if err != nil {
 		return 0, err
 	}
 
-	if entry := p.resolvers.ProcessResolver.Get(event.Process.Pid); entry != nil {
+	if entry := processes.get(p.resolvers.ProcessResolver, event.Process.Pid); entry != nil {
 		event.Process.FileEvent = entry.FileEvent
 		event.Container = entry.ContainerEvent
 	}
@@ -299,8 +733,25 @@ func (p *Probe) unmarshalProcessContainer(data []byte, event *Event) (int, error
 }
 
 func (p *Probe) handleMountEvent(CPU int, data []byte, perfMap *manager.PerfMap, manager *manager.Manager) {
+	if p.eventWorkers == nil {
+		p.decodeMountEvent(data, perfMap)
+		return
+	}
+
+	// the mount points are shared by the processes, the events read before are handled first
+	if p.eventBatcher != nil {
+		p.eventBatcher.Flush()
+	}
+	p.eventWorkers.Barrier(func() {
+		p.decodeMountEvent(data, perfMap)
+	})
+}
+
+// decodeMountEvent decodes the given record of the mount points perf map, and updates the mount cache
+func (p *Probe) decodeMountEvent(data []byte, perfMap *manager.PerfMap) {
 	offset := 0
-	event := p.zeroMountEvent()
+	event := p.eventPool.Get()
+	defer p.eventPool.Put(event)
 
 	read, err := event.UnmarshalBinary(data)
 	if err != nil {
@@ -313,13 +764,16 @@ func (p *Probe) handleMountEvent(CPU int, data []byte, perfMap *manager.PerfMap,
 
 	log.Tracef("Decoding event %s(%d)", eventType, event.Type)
 
-	read, err = p.unmarshalProcessContainer(data[offset:], event)
+	read, err = p.unmarshalProcessContainer(data[offset:], event, nil)
 	if err != nil {
 		log.Errorf("failed to decode event `%s`: %s", err, eventType)
 		return
 	}
 	offset += read
 
+	// the discarded mount points only update the mount cache, they aren't dispatched
+	var discarded bool
+
 	switch eventType {
 	case FileMountEventType:
 		if _, err := event.Mount.UnmarshalBinary(data[offset:]); err != nil {
@@ -331,6 +785,12 @@ func (p *Probe) handleMountEvent(CPU int, data []byte, perfMap *manager.PerfMap,
 		event.Mount.ResolveMountPoint(p.resolvers)
 		// Resolve root
 		event.Mount.ResolveRoot(p.resolvers)
+		// Resolve the source while the mount is still listed in the mountinfo of the process, the process context
+		// of a mount point discarded by the kernel isn't sent
+		if discarded = event.Mount.discarded; !discarded {
+			event.Mount.Source = p.resolvers.MountResolver.ResolveSource(&event.Mount, event.Process.Pid)
+			discarded = p.isMountSourceDiscarded(event.Mount.Source)
+		}
 		// Insert new mount point in cache
 		p.resolvers.MountResolver.Insert(event.Mount)
 	case FileUmountEventType:
@@ -338,6 +798,7 @@ func (p *Probe) handleMountEvent(CPU int, data []byte, perfMap *manager.PerfMap,
 			log.Errorf("failed to decode umount event: %s (offset %d, len %d)", err, offset, len(data))
 			return
 		}
+		discarded = event.Umount.discarded
 		// Delete new mount point from cache
 		if err := p.resolvers.MountResolver.Delete(event.Umount.MountID); err != nil {
 			log.Errorf("failed to delete mount point %d from cache: %s", event.Umount.MountID, err)
@@ -348,13 +809,53 @@ func (p *Probe) handleMountEvent(CPU int, data []byte, perfMap *manager.PerfMap,
 	}
 
 	p.eventsStats.CountEventType(eventType, 1)
+	if discarded {
+		return
+	}
 	p.loadController.Count(eventType, event.Process.Pid)
 	p.DispatchEvent(event)
 }
 
 func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, manager *manager.Manager) {
+	record := eventRecord{data: data, perfMap: perfMap}
+
+	switch {
+	case p.eventBatcher != nil:
+		p.eventBatcher.Add(record)
+	case p.eventWorkers != nil:
+		p.eventWorkers.Queue([]eventRecord{record})
+	default:
+		p.decodeEvent(data, perfMap, nil)
+	}
+}
+
+// handleEventBatch handles a batch of records read from the perf maps
+func (p *Probe) handleEventBatch(records []eventRecord) {
+	if p.eventWorkers != nil {
+		p.eventWorkers.Queue(records)
+		return
+	}
+	p.decodeEvents(records)
+}
+
+// decodeEvents decodes and dispatches the given records in order. The lookups of the processes are shared by the
+// events of the records
+func (p *Probe) decodeEvents(records []eventRecord) {
+	var processes batchProcesses
+	if len(records) > 1 {
+		processes = make(batchProcesses)
+	}
+
+	for _, record := range records {
+		p.decodeEvent(record.data, record.perfMap, processes)
+	}
+}
+
+// decodeEvent decodes the given record of the perf map and dispatches its event, recycled once handled
+func (p *Probe) decodeEvent(data []byte, perfMap *manager.PerfMap, processes batchProcesses) {
 	offset := 0
-	event := p.zeroEvent()
+	event := p.eventPool.Get()
+	defer p.eventPool.Put(event)
 
 	read, err := event.UnmarshalBinary(data)
 	if err != nil {
@@ -375,6 +876,7 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 		}
 
 		p.resolvers.ProcessResolver.AddEntry(event.Exec.Pid, event.Exec.ProcessCacheEntry)
+		processes.invalidate(event.Exec.Pid)
 
 		return
 	case ExitEventType:
@@ -384,8 +886,12 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 		}
 
 		// as far as we keep only one perf for all the event we can delete the entry right away, there won't be
-		// any race
+		// any race. The event workers handle the events of a process in order as well
 		p.resolvers.ProcessResolver.DelEntry(event.Exit.Pid)
+		processes.invalidate(event.Exit.Pid)
+		if handler, ok := p.handler.(ProcessExitHandler); ok {
+			handler.HandleProcessExit(event.Exit.Pid)
+		}
 
 		// no need to dispatch
 		return
@@ -410,7 +916,7 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 		return
 	}
 
-	read, err = p.unmarshalProcessContainer(data[offset:], event)
+	read, err = p.unmarshalProcessContainer(data[offset:], event, processes)
 	if err != nil {
 		log.Errorf("failed to decode event `%s`: %s", eventType, err)
 		return
@@ -494,6 +1000,8 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 		return
 	}
 
+	event.setFilesPid()
+
 	log.Tracef("Dispatching event %+v\n", event)
 
 	p.eventsStats.CountEventType(eventType, 1)
@@ -523,14 +1031,44 @@ func (p *Probe) OnNewDiscarder(rs *rules.RuleSet, event *Event, field eval.Field
 	return nil
 }
 
+// ApplyDecisionTable pre-evaluates the rules of the given event type in kernel with the given decision table. The
+// events are still all sent when the table is too large, when one of its entries matches all of them or when an event
+// handler subscribed to the event type
+func (p *Probe) ApplyDecisionTable(eventType eval.EventType, table rules.DecisionTable) error {
+	et := parseEvalEventType(eventType)
+	if et == UnknownEventType {
+		return errors.New("unable to parse the eval event type")
+	}
+
+	entries := make([]*preEvalRule, 0, len(table))
+	for _, entry := range table {
+		rule := newPreEvalRule(entry)
+		if rule.Fields == 0 {
+			log.Debugf("rules of `%s` not pre-evaluated: an entry matches all the events", eventType)
+			return p.setPreEvalRules(et, 0)
+		}
+		entries = append(entries, rule)
+	}
+
+	if len(entries) == 0 || len(entries) > maxPreEvalRules {
+		log.Debugf("rules of `%s` not pre-evaluated: %d entries", eventType, len(entries))
+		return p.setPreEvalRules(et, 0)
+	}
+
+	for i, rule := range entries {
+		if err := p.putFilterValue("pre_eval_rules", ebpf.Uint32MapItem(uint32(et)*maxPreEvalRules+uint32(i)), rule); err != nil {
+			return errors.Wrapf(err, "failed to apply the decision table of `%s`", eventType)
+		}
+	}
+
+	log.Infof("Pre-evaluating the rules of `%s` in kernel with %d entries", eventType, len(entries))
+
+	return p.setPreEvalRules(et, uint32(len(entries)))
+}
+
 // ApplyFilterPolicy is called when a passing policy for an event type is applied
 func (p *Probe) ApplyFilterPolicy(eventType eval.EventType, mode PolicyMode, flags PolicyFlag) error {
 	log.Infof("Setting in-kernel filter policy to `%s` for `%s`", mode, eventType)
-	table := p.Map("filter_policy")
-	if table == nil {
-		return errors.New("unable to find policy table")
-	}
-
 	et := parseEvalEventType(eventType)
 	if et == UnknownEventType {
 		return errors.New("unable to parse the eval event type")
@@ -541,7 +1079,27 @@ func (p *Probe) ApplyFilterPolicy(eventType eval.EventType, mode PolicyMode, fla
 		Flags: flags,
 	}
 
-	return table.Put(ebpf.Uint32MapItem(et), policy)
+	return p.putFilterValue("filter_policy", ebpf.Uint32MapItem(et), policy)
+}
+
+// ApplyContainerFilterPolicy overrides the policy of an event type for the given container, the policy of the event
+// type still applies to the other containers and to the host
+func (p *Probe) ApplyContainerFilterPolicy(containerID string, eventType eval.EventType, mode PolicyMode, flags PolicyFlag) error {
+	log.Infof("Setting in-kernel filter policy to `%s` for `%s` in container `%s`", mode, eventType, containerID)
+	key, err := newContainerPolicyKey(containerID, eventType)
+	if err != nil {
+		return err
+	}
+
+	policy := &FilterPolicy{
+		Mode:  mode,
+		Flags: flags,
+	}
+
+	if err := p.recordFilterKey("container_filter_policy", key); err != nil {
+		return err
+	}
+	return p.putFilterValue("container_filter_policy", key, policy)
 }
 
 // ApplyApprovers applies approvers
@@ -551,11 +1109,180 @@ func (p *Probe) ApplyApprovers(eventType eval.EventType, approvers rules.Approve
 		return nil
 	}
 
+	p.userGroupApproversLock.Lock()
+	defer p.userGroupApproversLock.Unlock()
+
+	// the flags of the policy in deny mode depend on all the approvers, the process names included
+	flags := allCapabilities[eventType].GetApproversFlags(approvers)
+
+	// the process name approvers are common to all the event types
+	if values, exists := approvers["process.name"]; exists {
+		if err := approveComms(p, parseEvalEventType(eventType), stringValues(values)...); err != nil {
+			log.Errorf("Error while adding approvers fallback in-kernel policy to `%s` for `%s`: %s", PolicyModeAccept, eventType, err)
+			return err
+		}
+
+		eventApprovers := make(rules.Approvers, len(approvers))
+		for field, values := range approvers {
+			if field != "process.name" {
+				eventApprovers[field] = values
+			}
+		}
+		approvers = eventApprovers
+	}
+
 	err := fnc(p, approvers)
+
+	// the approvers of user or group names are recorded even if they can't be resolved yet, the event type is then left
+	// in accept mode by the caller and the monitor applies them again once their names are resolved
+	recorded := p.userGroupApprovers
+	if p.filterUpdate != nil {
+		recorded = p.filterUpdate.userGroupApprovers
+	}
+
+	for field := range approvers {
+		if isUserGroupField(field) {
+			recorded[eventType] = userGroupApprovers{approvers: approvers, flags: flags, pending: err != nil}
+			break
+		}
+	}
+
 	if err != nil {
 		log.Errorf("Error while adding approvers fallback in-kernel policy to `%s` for `%s`: %s", PolicyModeAccept, eventType, err)
+		return err
+	}
+
+	return nil
+}
+
+// SetStandbyApprovers sets the approvers of an event type left in accept mode, along with the flags of its policy in
+// deny mode
+func (p *Probe) SetStandbyApprovers(eventType eval.EventType, approvers rules.Approvers, flags PolicyFlag) {
+	// the standby approvers of the current filters are kept until the ones being applied replace them
+	if p.filterUpdate != nil {
+		p.filterUpdate.standbyApprovers[eventType] = standbyApprovers{approvers: approvers, flags: flags}
+		return
+	}
+
+	p.standbyApproversLock.Lock()
+	defer p.standbyApproversLock.Unlock()
+
+	p.standbyApprovers[eventType] = standbyApprovers{approvers: approvers, flags: flags}
+}
+
+// applyStandbyApprovers switches an event type left in accept mode to its approvers, it returns whether the event
+// type had approvers to switch to
+func (p *Probe) applyStandbyApprovers(eventType eval.EventType) (bool, error) {
+	p.filtersLock.Lock()
+	defer p.filtersLock.Unlock()
+
+	p.standbyApproversLock.Lock()
+	defer p.standbyApproversLock.Unlock()
+
+	standby, exists := p.standbyApprovers[eventType]
+	if !exists {
+		return false, nil
+	}
+	delete(p.standbyApprovers, eventType)
+
+	if err := p.ApplyApprovers(eventType, standby.approvers); err != nil {
+		return false, err
 	}
-	return err
+
+	if err := p.ApplyFilterPolicy(eventType, PolicyModeDeny, standby.flags); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// FlushFilters resets the in-kernel filters to their initial state, before any ruleset was applied: the filter
+// policies are removed, as well as all the approvers and the discarders
+func (p *Probe) FlushFilters() error {
+	if err := p.FlushApprovers(); err != nil {
+		return err
+	}
+	return p.flushDiscarders()
+}
+
+// flushDiscarders removes all the discarders
+func (p *Probe) flushDiscarders() error {
+	p.mountSourceDiscardersLock.Lock()
+	p.mountSourceDiscarders = make(map[string]bool)
+	p.mountSourceDiscardersLock.Unlock()
+
+	for _, tableName := range []string{"inode_discarders", "pid_discarders", "mount_fstype_discarders", "mount_id_discarders"} {
+		if err := flushMap(p, tableName); err != nil {
+			return err
+		}
+		p.discarderRegistry.purge(tableName)
+	}
+
+	return nil
+}
+
+// FlushApprovers resets the filter policies, the approvers and the pre-evaluation tables to their initial state. The
+// discarders are kept, they are checked against the new ruleset with RevalidateDiscarders
+func (p *Probe) FlushApprovers() error {
+	p.userGroupApproversLock.Lock()
+	p.userGroupApprovers = make(map[eval.EventType]userGroupApprovers)
+	p.userGroupApproversLock.Unlock()
+
+	p.standbyApproversLock.Lock()
+	p.standbyApprovers = make(map[eval.EventType]standbyApprovers)
+	p.standbyApproversLock.Unlock()
+
+	p.filtersLock.Lock()
+	p.filterKeys = make(filterKeys)
+	p.filtersLock.Unlock()
+
+	// the event types of the containers depend on the scopes of the rules, they are computed again at the next event
+	// of each container
+	p.resetContainerEventTypes()
+
+	table := p.Map("filter_policy")
+	if table == nil {
+		return errors.New("unable to find policy table")
+	}
+
+	for eventType := UnknownEventType + 1; eventType != maxEventType; eventType++ {
+		if err := table.Put(ebpf.Uint32MapItem(eventType), &FilterPolicy{}); err != nil {
+			return err
+		}
+	}
+
+	for _, tableName := range []string{"open_basename_approvers", "unlink_basename_approvers", "rename_basename_approvers",
+		"chmod_basename_approvers", "chown_basename_approvers", "mkdir_basename_approvers", "inode_approvers",
+		"container_filter_policy", "comm_approvers", "prefix_approvers"} {
+		if err := flushMap(p, tableName); err != nil {
+			return err
+		}
+	}
+
+	table = p.Map("pre_eval_config")
+	if table == nil {
+		return errors.New("map pre_eval_config not found")
+	}
+
+	for eventType := UnknownEventType + 1; eventType != maxEventType; eventType++ {
+		if err := table.Put(ebpf.Uint32MapItem(eventType), ebpf.ZeroUint32MapItem); err != nil {
+			return err
+		}
+	}
+
+	for _, tableName := range []string{"chown_uid_approvers", "chown_gid_approvers"} {
+		if err := flushIDRanges(p, tableName); err != nil {
+			return err
+		}
+	}
+
+	for _, tableName := range []string{"open_flags_approvers", "chmod_mode_approvers", "mkdir_mode_approvers"} {
+		if err := flushFlagsFilter(p, tableName); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // RegisterProbesSelectors register the given probes selectors
@@ -571,7 +1298,48 @@ func (p *Probe) Snapshot() error {
 }
 
 func (p *Probe) Close() error {
-	return p.manager.Stop(manager.CleanAll)
+	for _, stream := range p.eventStreams {
+		if err := stream.Stop(); err != nil {
+			log.Warnf("failed to stop an event stream: %s", err)
+		}
+	}
+	p.stopFEntryProbes()
+	p.stopProgramStats()
+	p.familiesLock.Lock()
+	p.stopFamilies()
+	p.familiesLock.Unlock()
+
+	// the reasons of the pinned discarders are saved for the next run
+	if err := p.savePinnedDiscarders(); err != nil {
+		log.Warnf("failed to save the reasons of the pinned discarders: %s", err)
+	}
+	// the background routines use the manager and the maps, they're stopped first
+	p.cancelFnc()
+	p.wg.Wait()
+
+	// every step is run even if a previous one failed, the workers must be stopped before the sinks of the events
+	// are closed
+	var result *multierror.Error
+	if err := p.manager.Stop(p.mapsCleanup()); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	// the perf maps are stopped, no record can be queued anymore
+	if p.eventBatcher != nil {
+		p.eventBatcher.Stop()
+	}
+	if p.eventWorkers != nil {
+		p.eventWorkers.Stop()
+	}
+
+	// the events are dispatched by the readers and the workers, they're all stopped
+	p.stopEventHandlers()
+
+	// no path is resolved anymore
+	if err := p.resolvers.DentryResolver.Close(); err != nil {
+		result = multierror.Append(result, errors.Wrap(err, "failed to close the eRPC file descriptor"))
+	}
+	return result.ErrorOrNil()
 }
 
 // IsInvalidDiscarder returns whether the given value is a valid discarder for the given field
@@ -607,19 +1375,46 @@ func getInvalidDiscarders() map[eval.Field]map[interface{}]bool {
 // NewProbe instantiates a new runtime security agent probe
 func NewProbe(config *config.Config, client *statsd.Client) (*Probe, error) {
 	p := &Probe{
-		config:            config,
-		onDiscardersFncs:  make(map[eval.EventType][]onDiscarderFnc),
-		invalidDiscarders: getInvalidDiscarders(),
+		config:                config,
+		ipEnricher:            NoopIPEnricher{},
+		onDiscardersFncs:      make(map[eval.EventType][]onDiscarderFnc),
+		invalidDiscarders:     getInvalidDiscarders(),
+		userGroupApprovers:    make(map[eval.EventType]userGroupApprovers),
+		standbyApprovers:      make(map[eval.EventType]standbyApprovers),
+		filterKeys:            make(filterKeys),
+		mountSourceDiscarders: make(map[string]bool),
+		discarderRegistry:     newDiscarderRegistry(),
+		eventTypeSwitch:       newEventTypeSwitch(),
 	}
+	p.ctx, p.cancelFnc = context.WithCancel(context.Background())
 
 	resolvers, err := NewResolvers(p)
 	if err != nil {
 		return nil, err
 	}
 
+	// the kernel entry of a container is removed once it is evicted from the cache, the container can then produce
+	// all the event types until it is restricted again
+	p.containerEventTypes, err = simplelru.NewLRU(containerEventTypesSize, func(key interface{}, value interface{}) {
+		if table := p.Map("container_event_types"); table != nil {
+			_ = table.Delete(ebpf.NewStringMapItem(key.(string), containerIDLen))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	p.resolvers = resolvers
-	p.event = NewEvent(p.resolvers)
-	p.mountEvent = NewEvent(p.resolvers)
+	p.eventPool = newEventPool(p.resolvers)
+	if config.EventWorkers > 0 {
+		p.eventWorkers = newEventWorkers(p, config.EventWorkers, config.EventWorkersQueueSize)
+	}
+	if config.ResyncLostEventsThreshold > 0 {
+		p.resyncController = newResyncController(p)
+	}
+	if config.EventStreamBatchSize > 1 {
+		p.eventBatcher = newEventBatcher(config.EventStreamBatchSize, config.EventStreamBatchFlushInterval, p.handleEventBatch)
+	}
 	p.loadController, err = NewLoadController(p, client)
 	if err != nil {
 		return nil, err
@@ -633,12 +1428,23 @@ func processDiscarderWrapper(eventType EventType, fnc onDiscarderFnc) onDiscarde
 		if discarder.Field == "process.filename" {
 			log.Tracef("apply process.filename discarder for event `%s`, inode: %d", eventType, event.Process.Inode)
 
-			// discard by PID for long running process
-			if _, err := discardPID(probe, eventType, event.Process.Pid); err != nil {
+			value, err := event.GetFieldValue(discarder.Field)
+			if err != nil {
+				return err
+			}
+			filename := value.(string)
+			reason := newFieldDiscarderReason(eventType, discarder.Field, filename)
+
+			// discard by PID for long running process, all its events are discarded if no rule can match it
+			if isDiscarder, _ := rs.IsDiscarderOfAllEventTypes(event, discarder.Field); isDiscarder {
+				if _, err := discardProcess(probe, event.Process.Pid, newAllEventTypesDiscarderReason(discarder.Field, filename)); err != nil {
+					return err
+				}
+			} else if _, err := discardPID(probe, eventType, event.Process.Pid, reason); err != nil {
 				return err
 			}
 
-			_, err := discardInode(probe, eventType, event.Process.MountID, event.Process.Inode)
+			_, err = discardInode(probe, eventType, event.Process.MountID, event.Process.Inode, reason)
 			return err
 		}
 
@@ -678,7 +1484,7 @@ func filenameDiscarderWrapper(eventType EventType, fnc onDiscarderFnc, getter in
 					log.Tracef("apply `%s.filename` inode discarder for event `%s`, inode: %d", eventType, eventType, inode)
 
 					// not able to discard the parent then only discard the filename
-					_, err = discardInode(probe, eventType, mountID, inode)
+					_, err = discardInode(probe, eventType, mountID, inode, newFieldDiscarderReason(eventType, field, filename))
 				}
 			} else {
 				log.Tracef("apply `%s.filename` parent inode discarder for event `%s` with value `%s`", eventType, eventType, filename)
@@ -702,6 +1508,11 @@ func filenameDiscarderWrapper(eventType EventType, fnc onDiscarderFnc, getter in
 func init() {
 	// approvers
 	allApproversFncs["open"] = openOnNewApprovers
+	allApproversFncs["unlink"] = unlinkOnNewApprovers
+	allApproversFncs["rename"] = renameOnNewApprovers
+	allApproversFncs["chmod"] = chmodOnNewApprovers
+	allApproversFncs["chown"] = chownOnNewApprovers
+	allApproversFncs["mkdir"] = mkdirOnNewApprovers
 
 	// discarders
 	SupportedDiscarders["process.filename"] = true
@@ -709,6 +1520,11 @@ func init() {
 	allDiscarderFncs["open"] = processDiscarderWrapper(FileOpenEventType,
 		filenameDiscarderWrapper(FileOpenEventType, nil,
 			func(event *Event) (eval.Field, uint32, uint64, uint32, bool) {
+				// the path of an open through a symbolic link is the path of the link, the kernel discards the files
+				// the opens reach
+				if event.Open.destinationInode != 0 {
+					return "", 0, 0, 0, false
+				}
 				return "open.filename", event.Open.MountID, event.Open.Inode, event.Open.PathID, false
 			}))
 	SupportedDiscarders["open.filename"] = true
@@ -773,6 +1589,10 @@ func init() {
 			}))
 	SupportedDiscarders["removexattr.filename"] = true
 
+	allDiscarderFncs["mount"] = mountOnNewDiscarder
+	SupportedDiscarders["mount.fs_type"] = true
+	SupportedDiscarders["mount.source"] = true
+
 	// constant rewrites
 	constantEditors["unlink"] = []manager.ConstantEditor{
 		{Name: "unlink_event_enabled", Value: uint64(1)},
@@ -786,4 +1606,8 @@ func init() {
 	constantEditors["rename"] = []manager.ConstantEditor{
 		{Name: "rename_event_enabled", Value: uint64(1)},
 	}
+
+	constantEditors["link"] = []manager.ConstantEditor{
+		{Name: "link_event_enabled", Value: uint64(1)},
+	}
 }
@@ -31,6 +31,11 @@ func (p *Probe) ApplyFilterPolicy(eventType eval.EventType, mode PolicyMode, fla
 	return nil
 }
 
+// ApplyContainerFilterPolicy overrides the policy of an event type for the given container
+func (p *Probe) ApplyContainerFilterPolicy(containerID string, eventType eval.EventType, mode PolicyMode, flags PolicyFlag) error {
+	return nil
+}
+
 // ApplyApprovers applies approvers
 func (p *Probe) ApplyApprovers(eventType eval.EventType, approvers rules.Approvers) error {
 	return nil
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"sort"
+
+	"github.com/DataDog/ebpf/manager"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// unselectedSections returns the sections of the given probes matched by none of the kept probes
+func unselectedSections(allProbes []*manager.Probe, kept []manager.ProbeIdentificationPair) []string {
+	keptSections := make(map[string]bool)
+	for _, id := range kept {
+		keptSections[id.Section] = true
+	}
+
+	unselected := make(map[string]bool)
+	for _, probe := range allProbes {
+		if !keptSections[probe.Section] {
+			unselected[probe.Section] = true
+		}
+	}
+
+	var sections []string
+	for section := range unselected {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	return sections
+}
+
+// excludeUnselectedProbes leaves the programs of the probes that no activated selector requires out of the collection,
+// so that only the programs of the event types of the ruleset are loaded in the kernel. The programs attached or
+// routed by user space, and the kprobes replaced by fentry or fexit programs, are kept. It has to be called once the
+// probes are selected, before the manager is initialized
+func (p *Probe) excludeUnselectedProbes() {
+	// the manager activates all the probes without selector
+	if len(p.managerOptions.ActivatedProbes) == 0 {
+		return
+	}
+
+	kept := append([]manager.ProbeIdentificationPair{}, snapshotProbeIDs...)
+	kept = append(kept, selectorsIDs(p.managerOptions.ActivatedProbes)...)
+	for _, route := range p.managerOptions.TailCallRouter {
+		kept = append(kept, route.ProbeIdentificationPair)
+	}
+	for _, fp := range p.fentryProbes {
+		kept = append(kept, fp.replaced)
+	}
+
+	sections := unselectedSections(probes.AllProbes(), kept)
+	for _, section := range sections {
+		log.Debugf("probe %s not required by the ruleset, its program isn't loaded", section)
+	}
+	p.managerOptions.ExcludedSections = append(p.managerOptions.ExcludedSections, sections...)
+
+	log.Infof("%d probes left out, not required by the ruleset", len(sections))
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/DataDog/ebpf/manager"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
+)
+
+func TestUnselectedSections(t *testing.T) {
+	allProbes := []*manager.Probe{
+		{UID: probes.SecurityAgentUID, Section: "kprobe/vfs_unlink"},
+		{UID: probes.SecurityAgentUID, Section: "kprobe/vfs_mkdir"},
+		{UID: probes.SecurityAgentUID, Section: "kprobe/security_inode_setattr"},
+		{UID: probes.SecurityAgentUID, Section: "kretprobe/get_task_exe_file"},
+	}
+
+	// the tail calls are routed without UID
+	kept := []manager.ProbeIdentificationPair{
+		{UID: probes.SecurityAgentUID, Section: "kprobe/vfs_mkdir"},
+		{Section: "kretprobe/get_task_exe_file"},
+	}
+
+	sections := unselectedSections(allProbes, kept)
+	if len(sections) != 2 || sections[0] != "kprobe/security_inode_setattr" || sections[1] != "kprobe/vfs_unlink" {
+		t.Errorf("unexpected unselected sections: %v", sections)
+	}
+
+	// the probes always activated are never left out
+	sections = unselectedSections(probes.AllProbes(), selectorsIDs(probes.SelectorsPerEventType["*"]))
+	for _, section := range sections {
+		for _, id := range selectorsIDs(probes.SelectorsPerEventType["*"]) {
+			if id.Section == section {
+				t.Errorf("%s is always activated", section)
+			}
+		}
+	}
+	if len(sections) == 0 {
+		t.Error("the probes of the event types should be left out")
+	}
+}
@@ -9,6 +9,9 @@ package probe
 
 import (
 	"bytes"
+	"net"
+	"path"
+	"strings"
 	"time"
 	"unsafe"
 
@@ -16,23 +19,67 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/security/utils"
 )
 
+// ExecutableMetadata holds the metadata of the executable of a process, as it was when the process was executed
+type ExecutableMetadata struct {
+	MTime uint64
+	CTime uint64
+	Size  uint64
+	Mode  uint32
+}
+
+// UnmarshalBinary unmarshals a binary representation of itself
+func (m *ExecutableMetadata) UnmarshalBinary(data []byte) (int, error) {
+	if len(data) < 32 {
+		return 0, ErrNotEnoughData
+	}
+	m.MTime = ebpf.ByteOrder.Uint64(data[0:8])
+	m.CTime = ebpf.ByteOrder.Uint64(data[8:16])
+	m.Size = ebpf.ByteOrder.Uint64(data[16:24])
+	m.Mode = ebpf.ByteOrder.Uint32(data[24:28])
+	// padding
+
+	return 32, nil
+}
+
+// Bytes returns a binary representation of itself
+func (m *ExecutableMetadata) Bytes() []byte {
+	b := make([]byte, 32)
+	ebpf.ByteOrder.PutUint64(b[0:8], m.MTime)
+	ebpf.ByteOrder.PutUint64(b[8:16], m.CTime)
+	ebpf.ByteOrder.PutUint64(b[16:24], m.Size)
+	ebpf.ByteOrder.PutUint32(b[24:28], m.Mode)
+	return b
+}
+
 // ProcessCacheEntry this structure holds the container context that we keep in kernel for each process
 type ProcessCacheEntry struct {
+	// https://github.com/golang/go/issues/36606
+	// validatedAt is the time, in unix nanoseconds, the entry was last checked against the kernel process cache. The
+	// entries are shared by the events being resolved, it is read and written atomically
+	validatedAt int64
+
 	FileEvent
 	ContainerEvent
-	TimestampRaw uint64
-	Timestamp    time.Time
-	Cookie       uint32
-	TTYName      string
-	Comm         string
-	PPid         uint32
+	TimestampRaw    uint64
+	Timestamp       time.Time
+	Cookie          uint32
+	TTYName         string
+	Comm            string
+	PPid            uint32
+	LoginUID        uint32
+	SessionID       uint32
+	Executable      ExecutableMetadata
+	Interpreter     FileEvent
+	SecurityContext string
+	SessionSourceIP string
+	Tags            []string
 
 	TTYNameRaw [64]byte
 }
 
 // UnmarshalBinary returns the binary representation of itself
 func (pc *ProcessCacheEntry) UnmarshalBinary(data []byte) (int, error) {
-	if len(data) < 164 {
+	if len(data) < 232 {
 		return 0, ErrNotEnoughData
 	}
 
@@ -48,7 +95,34 @@ func (pc *ProcessCacheEntry) UnmarshalBinary(data []byte) (int, error) {
 	// skip 4 for padding
 	utils.SliceToArray(data[read+16:read+80], unsafe.Pointer(&pc.TTYNameRaw))
 
-	return read + 80, nil
+	pc.LoginUID = ebpf.ByteOrder.Uint32(data[read+80 : read+84])
+	pc.SessionID = ebpf.ByteOrder.Uint32(data[read+84 : read+88])
+
+	n, err := unmarshalBinary(data[read+88:], &pc.Executable, &pc.Interpreter)
+	if err != nil {
+		return 0, err
+	}
+
+	return read + 88 + n, nil
+}
+
+// Bytes returns a binary representation of itself, following the layout of the kernel process cache entries
+func (pc *ProcessCacheEntry) Bytes() []byte {
+	b := make([]byte, 232)
+	copy(b[0:24], pc.FileEvent.Bytes())
+	copy(b[24:88], pc.ContainerEvent.Bytes())
+	ebpf.ByteOrder.PutUint64(b[88:96], pc.TimestampRaw)
+	ebpf.ByteOrder.PutUint32(b[96:100], pc.Cookie)
+	ebpf.ByteOrder.PutUint32(b[100:104], pc.PPid)
+
+	// keep the trailing NULL byte of the tty name
+	copy(b[104:167], pc.GetTTY())
+
+	ebpf.ByteOrder.PutUint32(b[168:172], pc.LoginUID)
+	ebpf.ByteOrder.PutUint32(b[172:176], pc.SessionID)
+	copy(b[176:208], pc.Executable.Bytes())
+	copy(b[208:232], pc.Interpreter.Bytes())
+	return b
 }
 
 // GetTTY returns the TTY
@@ -58,3 +132,83 @@ func (pc *ProcessCacheEntry) GetTTY() string {
 	}
 	return pc.TTYName
 }
+
+// unifiedServiceTags maps the environment variables of the unified service tagging to their tag
+var unifiedServiceTags = map[string]string{
+	"DD_SERVICE": "service",
+	"DD_ENV":     "env",
+	"DD_VERSION": "version",
+}
+
+// newEnvTagVars returns the environment variables extracted as tags, along with the name of their tag
+func newEnvTagVars(extraVars []string) map[string]string {
+	vars := make(map[string]string, len(unifiedServiceTags)+len(extraVars))
+	for _, name := range extraVars {
+		vars[name] = strings.ToLower(name)
+	}
+	for name, tag := range unifiedServiceTags {
+		vars[name] = tag
+	}
+	return vars
+}
+
+// envTags returns the tags of the variables of the given environment
+func envTags(env []string, vars map[string]string) []string {
+	var tags []string
+	for _, variable := range env {
+		kv := strings.SplitN(variable, "=", 2)
+		if len(kv) != 2 || len(kv[1]) == 0 {
+			continue
+		}
+
+		if tag, found := vars[kv[0]]; found {
+			tags = append(tags, tag+":"+kv[1])
+		}
+	}
+	return tags
+}
+
+// sshdFilename is the basename of the executable of the SSH server
+const sshdFilename = "sshd"
+
+// hasSSHDAncestor returns whether the given entry descends from sshd, looking up its ancestors with the given
+// function. sshd sets the SSH variables of the sessions, any other process can set them to any value
+func hasSSHDAncestor(entry *ProcessCacheEntry, get func(pid uint32) *ProcessCacheEntry) bool {
+	return hasAncestor(entry, get, func(pid uint32, ancestor *ProcessCacheEntry) bool {
+		// the name of a process can be changed by the process itself, not the path of its executable
+		return path.Base(ancestor.PathnameStr) == sshdFilename
+	})
+}
+
+// hasAncestor returns whether one of the ancestors of the given entry matches, looking up its ancestors with the given
+// function
+func hasAncestor(entry *ProcessCacheEntry, get func(pid uint32) *ProcessCacheEntry, match func(pid uint32, ancestor *ProcessCacheEntry) bool) bool {
+	for depth, ppid := 0, entry.PPid; ppid != 0 && depth < maxProcessAncestors; depth++ {
+		ancestor := get(ppid)
+		if ancestor == nil {
+			return false
+		}
+
+		if match(ppid, ancestor) {
+			return true
+		}
+		ppid = ancestor.PPid
+	}
+	return false
+}
+
+// sshSourceIP returns the remote address of the SSH session described by the given environment, sshd sets
+// SSH_CONNECTION to "client_ip client_port server_ip server_port" and SSH_CLIENT to "client_ip client_port server_port"
+func sshSourceIP(env []string) string {
+	for _, variable := range env {
+		kv := strings.SplitN(variable, "=", 2)
+		if len(kv) != 2 || (kv[0] != "SSH_CONNECTION" && kv[0] != "SSH_CLIENT") {
+			continue
+		}
+
+		if fields := strings.Fields(kv[1]); len(fields) > 0 && net.ParseIP(fields[0]) != nil {
+			return fields[0]
+		}
+	}
+	return ""
+}
@@ -9,9 +9,11 @@ package probe
 
 import (
 	"os"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/DataDog/datadog-go/statsd"
 	lib "github.com/DataDog/ebpf"
 	"github.com/DataDog/ebpf/manager"
 	lru "github.com/hashicorp/golang-lru"
@@ -46,6 +48,11 @@ type ProcessResolver struct {
 	procCacheMap   *lib.Map
 	pidCookieMap   *lib.Map
 	entryCache     *lru.Cache
+	envTagVars     map[string]string
+
+	hits      int64
+	misses    int64
+	evictions int64
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
@@ -64,34 +71,116 @@ func (p *ProcessResolver) AddEntry(pid uint32, entry ProcessCacheEntry) {
 }
 
 func (p *ProcessResolver) addEntry(pid uint32, entry *ProcessCacheEntry) {
+	// a forked process shares the cookie of its parent, inherit the paths resolved for the parent. This also covers
+	// the processes snapshotted from /proc, the dentries of their executables never reached the kernel cache.
+	parent := p.Get(entry.PPid)
+	if parent != nil && parent.Cookie == entry.Cookie {
+		if len(entry.PathnameStr) == 0 {
+			entry.PathnameStr = parent.PathnameStr
+			entry.ContainerPath = parent.ContainerPath
+		}
+		if len(entry.Interpreter.PathnameStr) == 0 {
+			entry.Interpreter.PathnameStr = parent.Interpreter.PathnameStr
+		}
+		if entry.Tags == nil {
+			entry.Tags = parent.Tags
+		}
+		if len(entry.SecurityContext) == 0 {
+			entry.SecurityContext = parent.SecurityContext
+		}
+	}
+
+	// the SSH session of a process is the one of its ancestors, whatever the binaries they executed
+	if parent != nil && len(entry.SessionSourceIP) == 0 {
+		entry.SessionSourceIP = parent.SessionSourceIP
+	}
+
+	// the environment is only replaced by an exec, it is read once per executed binary
+	if entry.Tags == nil {
+		p.resolveEnv(pid, entry)
+	}
+
+	// the security context transitions on exec, an error means that no LSM is enabled
+	if len(entry.SecurityContext) == 0 {
+		entry.SecurityContext, _ = utils.PidSecurityContext(pid)
+	}
+
 	// resolve now, so that the dentry cache is up to date
 	entry.FileEvent.ResolveInode(p.resolvers)
 	entry.FileEvent.ResolveContainerPath(p.resolvers)
+	if entry.Interpreter.Inode != 0 {
+		entry.Interpreter.ResolveInode(p.resolvers)
+	}
 	entry.ContainerEvent.ResolveContainerID(p.resolvers)
 
 	if entry.Timestamp.IsZero() {
 		entry.Timestamp = p.resolvers.TimeResolver.ResolveMonotonicTimestamp(entry.TimestampRaw)
 	}
 
-	// check for an existing entry first to inherit ppid
+	// check for an existing entry first to inherit ppid, a fork event reports the ppid of a reused pid
 	prevEntry, ok := p.entryCache.Get(pid)
-	if ok {
+	if ok && entry.PPid == 0 {
 		entry.PPid = prevEntry.(*ProcessCacheEntry).PPid
 	}
 
-	p.entryCache.Add(pid, entry)
+	atomic.StoreInt64(&entry.validatedAt, time.Now().UnixNano())
+	p.insertEntry(pid, entry)
+}
+
+// insertEntry inserts the given entry in the cache. Only the entries pushed out of the cache are counted as evictions,
+// the removals of the exit events and of the invalid entries aren't
+func (p *ProcessResolver) insertEntry(pid uint32, entry *ProcessCacheEntry) {
+	if evicted := p.entryCache.Add(pid, entry); evicted {
+		atomic.AddInt64(&p.evictions, 1)
+	}
+}
+
+// resolveEnv fills the entry with the tags and the SSH session extracted from the environment of the given pid. The
+// SSH variables are only trusted for the descendants of sshd
+func (p *ProcessResolver) resolveEnv(pid uint32, entry *ProcessCacheEntry) {
+	env, err := utils.PidEnv(pid)
+	if err != nil {
+		return
+	}
+
+	entry.Tags = envTags(env, p.envTagVars)
+	if len(entry.SessionSourceIP) == 0 && hasSSHDAncestor(entry, p.Get) {
+		entry.SessionSourceIP = sshSourceIP(env)
+	}
 }
 
+// DelEntry removes the entry of the given pid from the cache
 func (p *ProcessResolver) DelEntry(pid uint32) {
 	p.entryCache.Remove(pid)
 }
 
-func (p *ProcessResolver) resolve(pid uint32) *ProcessCacheEntry {
+// lookupCookie returns the cookie of the given pid from the kernel
+func (p *ProcessResolver) lookupCookie(pid uint32) ([]byte, error) {
 	pidb := make([]byte, 4)
 	ebpf.ByteOrder.PutUint32(pidb, pid)
 
-	cookieb, err := p.pidCookieMap.LookupBytes(pidb)
-	if err != nil {
+	return p.pidCookieMap.LookupBytes(pidb)
+}
+
+// isValid checks that an entry older than the cache TTL still matches the kernel process cache. A mismatching
+// cookie means that the pid was reused and that the exit event of the previous process was lost. An entry unknown
+// to the kernel is kept, it remains the best known context of the pid.
+func (p *ProcessResolver) isValid(pid uint32, entry *ProcessCacheEntry) bool {
+	if p.probe.config.PIDCacheTTL == 0 || time.Since(time.Unix(0, atomic.LoadInt64(&entry.validatedAt))) < p.probe.config.PIDCacheTTL {
+		return true
+	}
+
+	if cookieb, err := p.lookupCookie(pid); err == nil && cookieb != nil && ebpf.ByteOrder.Uint32(cookieb) != entry.Cookie {
+		return false
+	}
+
+	atomic.StoreInt64(&entry.validatedAt, time.Now().UnixNano())
+	return true
+}
+
+func (p *ProcessResolver) resolve(pid uint32) *ProcessCacheEntry {
+	cookieb, err := p.lookupCookie(pid)
+	if err != nil || cookieb == nil {
 		return nil
 	}
 
@@ -110,12 +199,52 @@ func (p *ProcessResolver) resolve(pid uint32) *ProcessCacheEntry {
 	return &entry
 }
 
+// Resync reconciles the cache with the running processes after a loss of events: the entries of the processes whose
+// exit was lost are dropped, and the pids unknown to the cache or reused since are resolved again from the kernel
+// process cache. It returns the number of dropped and resolved entries
+func (p *ProcessResolver) Resync(pids []uint32) (int, int) {
+	running := make(map[uint32]bool, len(pids))
+	for _, pid := range pids {
+		running[pid] = true
+	}
+
+	var dropped, resolved int
+	for _, key := range p.entryCache.Keys() {
+		if pid := key.(uint32); !running[pid] {
+			p.entryCache.Remove(pid)
+			dropped++
+		}
+	}
+
+	for _, pid := range pids {
+		if entry, exists := p.entryCache.Peek(pid); exists {
+			cookieb, err := p.lookupCookie(pid)
+			if err != nil || cookieb == nil || ebpf.ByteOrder.Uint32(cookieb) == entry.(*ProcessCacheEntry).Cookie {
+				continue
+			}
+			p.entryCache.Remove(pid)
+			dropped++
+		}
+
+		if p.resolve(pid) != nil {
+			resolved++
+		}
+	}
+
+	return dropped, resolved
+}
+
 // Resolve returns the cache entry for the given pid
 func (p *ProcessResolver) Resolve(pid uint32) *ProcessCacheEntry {
 	entry, exists := p.entryCache.Get(pid)
 	if exists {
-		return entry.(*ProcessCacheEntry)
+		if p.isValid(pid, entry.(*ProcessCacheEntry)) {
+			atomic.AddInt64(&p.hits, 1)
+			return entry.(*ProcessCacheEntry)
+		}
+		p.entryCache.Remove(pid)
 	}
+	atomic.AddInt64(&p.misses, 1)
 
 	// fallback request the map directly, the perf event may be delayed
 	return p.resolve(pid)
@@ -129,6 +258,23 @@ func (p *ProcessResolver) Get(pid uint32) *ProcessCacheEntry {
 	return nil
 }
 
+// SendStats sends process cache metrics
+func (p *ProcessResolver) SendStats(statsdClient *statsd.Client) error {
+	if err := statsdClient.Gauge(MetricPrefix+".process_resolver.cache_size", float64(p.entryCache.Len()), nil, 1.0); err != nil {
+		return err
+	}
+
+	if err := statsdClient.Count(MetricPrefix+".process_resolver.hits", atomic.SwapInt64(&p.hits, 0), nil, 1.0); err != nil {
+		return err
+	}
+
+	if err := statsdClient.Count(MetricPrefix+".process_resolver.misses", atomic.SwapInt64(&p.misses, 0), nil, 1.0); err != nil {
+		return err
+	}
+
+	return statsdClient.Count(MetricPrefix+".process_resolver.evictions", atomic.SwapInt64(&p.evictions, 0), nil, 1.0)
+}
+
 // Start starts the resolver
 func (p *ProcessResolver) Start() error {
 	// initializes the list of snapshot probes
@@ -207,6 +353,14 @@ func (p *ProcessResolver) retrieveInodeInfo(inode uint64) (*InodeInfo, error) {
 	return &info, nil
 }
 
+// insertKernelEntry inserts a process cache entry and its pid <-> cookie mapping in the kernel maps
+func (p *ProcessResolver) insertKernelEntry(pid uint32, entry *ProcessCacheEntry) error {
+	if err := p.procCacheMap.Put(ebpf.Uint32MapItem(entry.Cookie), ebpf.BytesMapItem(entry.Bytes())); err != nil {
+		return err
+	}
+	return p.pidCookieMap.Put(ebpf.Uint32MapItem(pid), ebpf.Uint32MapItem(entry.Cookie))
+}
+
 // snapshotProcess snapshots /proc for the provided pid. This method returns true if it updated the kernel process cache.
 func (p *ProcessResolver) snapshotProcess(proc *process.FilledProcess) bool {
 	pid := uint32(proc.Pid)
@@ -254,6 +408,12 @@ func (p *ProcessResolver) snapshotProcess(proc *process.FilledProcess) bool {
 		return false
 	}
 	inode := stat.Ino
+	executable := ExecutableMetadata{
+		MTime: uint64(stat.Mtim.Nano()),
+		CTime: uint64(stat.Ctim.Nano()),
+		Size:  uint64(stat.Size),
+		Mode:  stat.Mode,
+	}
 
 	info, err := p.retrieveInodeInfo(inode)
 	if err != nil {
@@ -261,6 +421,10 @@ func (p *ProcessResolver) snapshotProcess(proc *process.FilledProcess) bool {
 		return false
 	}
 
+	// an error means that audit isn't enabled, the values are then left unset
+	loginUID, _ := utils.PidLoginUID(pid)
+	sessionID, _ := utils.PidSessionID(pid)
+
 	// preset and add the entry to the cache
 	entry := &ProcessCacheEntry{
 		FileEvent: FileEvent{
@@ -272,13 +436,24 @@ func (p *ProcessResolver) snapshotProcess(proc *process.FilledProcess) bool {
 		ContainerEvent: ContainerEvent{
 			ID: string(containerID),
 		},
-		Timestamp: timestamp,
-		Comm:      proc.Name,
-		TTYName:   utils.PidTTY(pid),
+		TimestampRaw: uint64(p.resolvers.TimeResolver.ComputeMonotonicTimestamp(timestamp)),
+		Timestamp:    timestamp,
+		Cookie:       utils.NewCookie(),
+		PPid:         uint32(proc.Ppid),
+		Comm:         proc.Name,
+		TTYName:      utils.PidTTY(pid),
+		LoginUID:     loginUID,
+		SessionID:    sessionID,
+		Executable:   executable,
 	}
 
 	log.Tracef("Add process cache entry: %s %s %d/%d", proc.Name, pathnameStr, pid, inode)
 
+	// push the entry to the kernel so that the children forked by this process inherit its context
+	if err := p.insertKernelEntry(pid, entry); err != nil {
+		log.Debug(errors.Wrapf(err, "snapshot failed for %d: couldn't insert kernel cache entry", pid))
+	}
+
 	p.addEntry(pid, entry)
 
 	return true
@@ -340,14 +515,17 @@ func (p *ProcessResolver) Snapshot(containerResolver *ContainerResolver, mountRe
 
 // NewProcessResolver returns a new process resolver
 func NewProcessResolver(probe *Probe, resolvers *Resolvers) (*ProcessResolver, error) {
+	p := &ProcessResolver{
+		probe:      probe,
+		resolvers:  resolvers,
+		envTagVars: newEnvTagVars(probe.config.EnvTags),
+	}
+
 	cache, err := lru.New(probe.config.PIDCacheSize)
 	if err != nil {
 		return nil, err
 	}
+	p.entryCache = cache
 
-	return &ProcessResolver{
-		probe:      probe,
-		resolvers:  resolvers,
-		entryCache: cache,
-	}, nil
+	return p, nil
 }
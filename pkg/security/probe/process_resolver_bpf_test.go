@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+)
+
+func TestProcessResolverEvictions(t *testing.T) {
+	p, err := NewProcessResolver(&Probe{config: &config.Config{PIDCacheSize: 2}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pid := uint32(1); pid <= 3; pid++ {
+		p.insertEntry(pid, &ProcessCacheEntry{})
+	}
+	if evictions := atomic.LoadInt64(&p.evictions); evictions != 1 {
+		t.Errorf("expected the entry pushed out of the cache to be counted, got %d evictions", evictions)
+	}
+
+	// the entries replaced or removed explicitly aren't evictions
+	p.insertEntry(3, &ProcessCacheEntry{})
+	p.DelEntry(2)
+	p.entryCache.Purge()
+	if evictions := atomic.LoadInt64(&p.evictions); evictions != 1 {
+		t.Errorf("expected only the evictions to be counted, got %d evictions", evictions)
+	}
+}
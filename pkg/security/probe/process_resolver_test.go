@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProcessCacheEntryBinary(t *testing.T) {
+	entry := ProcessCacheEntry{
+		FileEvent: FileEvent{
+			Inode:           33,
+			MountID:         44,
+			OverlayNumLower: 2,
+			PathID:          55,
+			Generation:      66,
+		},
+		ContainerEvent: ContainerEvent{
+			ID: "c6f3b8f0c3c537a66e4511efd8b0e2a8ce3bbf2e3c1ad798b81c6288ff19b3a0",
+		},
+		TimestampRaw: 123456789,
+		Cookie:       77,
+		PPid:         88,
+		TTYName:      "pts1",
+		LoginUID:     1000,
+		SessionID:    3,
+		Executable: ExecutableMetadata{
+			MTime: 1600000000000000000,
+			CTime: 1600000001000000000,
+			Size:  4096,
+			Mode:  0104755,
+		},
+		Interpreter: FileEvent{
+			Inode:   99,
+			MountID: 44,
+			PathID:  56,
+		},
+	}
+
+	var result ProcessCacheEntry
+	if _, err := result.UnmarshalBinary(entry.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if result.FileEvent.Inode != entry.Inode || result.MountID != entry.MountID || result.OverlayNumLower != entry.OverlayNumLower ||
+		result.PathID != entry.PathID || result.Generation != entry.Generation {
+		t.Errorf("file mismatch: %+v vs %+v", result.FileEvent, entry.FileEvent)
+	}
+
+	if id := result.GetContainerID(); id != entry.ID {
+		t.Errorf("expected container ID %s, got %s", entry.ID, id)
+	}
+
+	if result.TimestampRaw != entry.TimestampRaw || result.Cookie != entry.Cookie || result.PPid != entry.PPid {
+		t.Errorf("process mismatch: %+v vs %+v", result, entry)
+	}
+
+	if tty := result.GetTTY(); tty != entry.TTYName {
+		t.Errorf("expected tty %s, got %s", entry.TTYName, tty)
+	}
+
+	if result.LoginUID != entry.LoginUID || result.SessionID != entry.SessionID {
+		t.Errorf("audit mismatch: %d/%d vs %d/%d", result.LoginUID, result.SessionID, entry.LoginUID, entry.SessionID)
+	}
+
+	if result.Executable != entry.Executable {
+		t.Errorf("executable mismatch: %+v vs %+v", result.Executable, entry.Executable)
+	}
+
+	if result.Interpreter.Inode != entry.Interpreter.Inode || result.Interpreter.MountID != entry.Interpreter.MountID ||
+		result.Interpreter.PathID != entry.Interpreter.PathID {
+		t.Errorf("interpreter mismatch: %+v vs %+v", result.Interpreter, entry.Interpreter)
+	}
+}
+
+func TestEnvTags(t *testing.T) {
+	env := []string{
+		"PATH=/usr/bin:/bin",
+		"DD_SERVICE=web",
+		"DD_ENV=",
+		"DD_VERSION=1.2=3",
+		"TEAM=security",
+		"OTHER",
+	}
+
+	tags := envTags(env, newEnvTagVars([]string{"TEAM"}))
+	if expected := []string{"service:web", "version:1.2=3", "team:security"}; !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, tags)
+	}
+}
+
+func TestSSHSourceIP(t *testing.T) {
+	tests := []struct {
+		env      []string
+		expected string
+	}{
+		{env: []string{"PATH=/usr/bin:/bin", "SSH_CONNECTION=192.168.1.10 51234 10.0.0.1 22"}, expected: "192.168.1.10"},
+		{env: []string{"SSH_CLIENT=2001:db8::1 51234 22"}, expected: "2001:db8::1"},
+		{env: []string{"SSH_CONNECTION=", "SSH_CLIENT=invalid 51234 22"}, expected: ""},
+		{env: []string{"PATH=/usr/bin:/bin"}, expected: ""},
+	}
+
+	for _, test := range tests {
+		if sourceIP := sshSourceIP(test.env); sourceIP != test.expected {
+			t.Errorf("expected source IP `%s` for %v, got `%s`", test.expected, test.env, sourceIP)
+		}
+	}
+}
+
+func TestHasSSHDAncestor(t *testing.T) {
+	entries := map[uint32]*ProcessCacheEntry{
+		1:  {FileEvent: FileEvent{PathnameStr: "/sbin/init"}},
+		10: {FileEvent: FileEvent{PathnameStr: "/usr/sbin/sshd"}, PPid: 1},
+		11: {FileEvent: FileEvent{PathnameStr: "/usr/sbin/sshd"}, PPid: 10},
+		12: {FileEvent: FileEvent{PathnameStr: "/bin/bash"}, PPid: 11},
+		20: {FileEvent: FileEvent{PathnameStr: "/usr/bin/cron"}, PPid: 1},
+		21: {FileEvent: FileEvent{PathnameStr: "/bin/sh"}, PPid: 20},
+		// a process can rename itself to sshd, not change its executable
+		30: {Comm: "sshd", FileEvent: FileEvent{PathnameStr: "/tmp/fake"}, PPid: 1},
+		// the parent pids loop when pids are reused
+		40: {FileEvent: FileEvent{PathnameStr: "/bin/sh"}, PPid: 41},
+		41: {FileEvent: FileEvent{PathnameStr: "/bin/sh"}, PPid: 40},
+	}
+	get := func(pid uint32) *ProcessCacheEntry {
+		return entries[pid]
+	}
+
+	tests := []struct {
+		entry    *ProcessCacheEntry
+		expected bool
+	}{
+		{entry: &ProcessCacheEntry{PPid: 12}, expected: true},
+		{entry: &ProcessCacheEntry{PPid: 11}, expected: true},
+		{entry: &ProcessCacheEntry{PPid: 21}, expected: false},
+		{entry: &ProcessCacheEntry{PPid: 30}, expected: false},
+		{entry: &ProcessCacheEntry{PPid: 40}, expected: false},
+		{entry: &ProcessCacheEntry{PPid: 99}, expected: false},
+		{entry: &ProcessCacheEntry{}, expected: false},
+	}
+
+	for _, test := range tests {
+		if found := hasSSHDAncestor(test.entry, get); found != test.expected {
+			t.Errorf("expected an sshd ancestor for the child of %d to be %v, got %v", test.entry.PPid, test.expected, found)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"unsafe"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	bpfObjGetInfoByFDCmd = 15
+	bpfEnableStatsCmd    = 32
+
+	// bpfStatsRunTime enables the run count and the run time of the programs
+	bpfStatsRunTime = 0
+)
+
+// enableStatsAttr is the `bpf_attr` of BPF_ENABLE_STATS
+type enableStatsAttr struct {
+	statsType uint32
+}
+
+// objGetInfoByFDAttr is the `bpf_attr` of BPF_OBJ_GET_INFO_BY_FD
+type objGetInfoByFDAttr struct {
+	fd      uint32
+	infoLen uint32
+	info    uint64
+}
+
+// programInfo is the `bpf_prog_info` of a program, up to its run count. The eBPF library doesn't return the stats
+type programInfo struct {
+	progType             uint32
+	id                   uint32
+	tag                  [unix.BPF_TAG_SIZE]byte
+	jitedProgLen         uint32
+	xlatedProgLen        uint32
+	jitedProgInsns       uint64
+	xlatedProgInsns      uint64
+	loadTime             uint64
+	createdByUID         uint32
+	nrMapIDs             uint32
+	mapIDs               uint64
+	name                 [unix.BPF_OBJ_NAME_LEN]byte
+	ifIndex              uint32
+	gplCompatible        uint32
+	netnsDev             uint64
+	netnsIno             uint64
+	nrJitedKsyms         uint32
+	nrJitedFuncLens      uint32
+	jitedKsyms           uint64
+	jitedFuncLens        uint64
+	btfID                uint32
+	funcInfoRecSize      uint32
+	funcInfo             uint64
+	nrFuncInfo           uint32
+	nrLineInfo           uint32
+	lineInfo             uint64
+	jitedLineInfo        uint64
+	nrJitedLineInfo      uint32
+	lineInfoRecSize      uint32
+	jitedLineInfoRecSize uint32
+	nrProgTags           uint32
+	progTags             uint64
+	runTimeNs            uint64
+	runCnt               uint64
+}
+
+// ProgramStats describes the runs of an eBPF program since the stats were enabled
+type ProgramStats struct {
+	RunCount  uint64 `json:"run_count"`
+	RunTimeNs uint64 `json:"run_time_ns"`
+}
+
+// programStats collects the stats of the programs of the probe. The kernel keeps counting the runs of the programs
+// as long as its file descriptor is open
+type programStats struct {
+	fd int
+	// sent holds the stats as of the last ones sent
+	sent map[string]ProgramStats
+}
+
+// enableProgramStats enables the stats of the eBPF programs, the kernel measuring each run once enabled
+func enableProgramStats() (*programStats, error) {
+	attr := enableStatsAttr{statsType: bpfStatsRunTime}
+
+	fd, err := bpfSyscall(bpfEnableStatsCmd, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to enable the stats of the eBPF programs")
+	}
+	return &programStats{fd: fd, sent: make(map[string]ProgramStats)}, nil
+}
+
+// getProgramStats returns the stats of the program of the given file descriptor
+func getProgramStats(progFD int) (ProgramStats, error) {
+	var info programInfo
+	attr := objGetInfoByFDAttr{
+		fd:      uint32(progFD),
+		infoLen: uint32(unsafe.Sizeof(info)),
+		info:    uint64(uintptr(unsafe.Pointer(&info))),
+	}
+
+	if _, err := bpfSyscall(bpfObjGetInfoByFDCmd, unsafe.Pointer(&attr), unsafe.Sizeof(attr)); err != nil {
+		return ProgramStats{}, err
+	}
+	return ProgramStats{RunCount: info.runCnt, RunTimeNs: info.runTimeNs}, nil
+}
+
+// close stops measuring the runs of the programs
+func (ps *programStats) close() {
+	_ = unix.Close(ps.fd)
+}
+
+// startProgramStats enables the stats of the eBPF programs when they are configured and supported by the kernel
+func (p *Probe) startProgramStats() {
+	if !p.config.ProgramStatsEnabled {
+		return
+	}
+
+	stats, err := enableProgramStats()
+	if err != nil {
+		log.Debugf("the stats of the eBPF programs are not available: %s", err)
+		return
+	}
+	p.programStats = stats
+}
+
+// stopProgramStats stops measuring the runs of the programs
+func (p *Probe) stopProgramStats() {
+	if p.programStats != nil {
+		p.programStats.close()
+		p.programStats = nil
+	}
+}
+
+// GetProgramStats returns the stats of the running programs of the probe, per section. The programs of the
+// same section are summed up. It returns nil when the kernel doesn't measure the programs
+func (p *Probe) GetProgramStats() map[string]ProgramStats {
+	if p.programStats == nil {
+		return nil
+	}
+
+	p.familiesLock.Lock()
+	familyProbes := p.familyProbes()
+	p.familiesLock.Unlock()
+
+	programs := make(map[string][]int)
+	for _, probe := range familyProbes {
+		if probe.IsRunning() && probe.Program() != nil {
+			programs[probe.Section] = append(programs[probe.Section], probe.Program().FD())
+		}
+	}
+	for _, fp := range p.fentryProbes {
+		if fp.linkFD >= 0 {
+			programs[fp.section] = append(programs[fp.section], fp.progFD)
+		}
+	}
+
+	stats := make(map[string]ProgramStats)
+	for section, fds := range programs {
+		for _, fd := range fds {
+			progStats, err := getProgramStats(fd)
+			if err != nil {
+				log.Debugf("failed to get the stats of %s: %s", section, err)
+				continue
+			}
+
+			sum := stats[section]
+			sum.RunCount += progStats.RunCount
+			sum.RunTimeNs += progStats.RunTimeNs
+			stats[section] = sum
+		}
+	}
+	return stats
+}
+
+// sendProgramStats sends the runs of the programs since the last call, per section
+func (p *Probe) sendProgramStats(statsdClient *statsd.Client) error {
+	if p.programStats == nil {
+		return nil
+	}
+
+	stats := p.GetProgramStats()
+	for section, progStats := range stats {
+		sent := p.programStats.sent[section]
+		if progStats.RunCount < sent.RunCount || progStats.RunTimeNs < sent.RunTimeNs {
+			// a program of the section was detached
+			sent = ProgramStats{}
+		}
+
+		tags := []string{"program:" + section}
+		if err := statsdClient.Count(MetricPrefix+".ebpf.program.run_count", int64(progStats.RunCount-sent.RunCount), tags, 1.0); err != nil {
+			return err
+		}
+		if err := statsdClient.Count(MetricPrefix+".ebpf.program.run_time_ns", int64(progStats.RunTimeNs-sent.RunTimeNs), tags, 1.0); err != nil {
+			return err
+		}
+	}
+	p.programStats.sent = stats
+
+	return nil
+}
@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+)
+
+func TestProgramInfoLayout(t *testing.T) {
+	var info programInfo
+
+	// offsets of run_time_ns and run_cnt in the bpf_prog_info of the kernel
+	if offset := unsafe.Offsetof(info.runTimeNs); offset != 192 {
+		t.Errorf("expected run_time_ns at 192, got %d", offset)
+	}
+	if offset := unsafe.Offsetof(info.runCnt); offset != 200 {
+		t.Errorf("expected run_cnt at 200, got %d", offset)
+	}
+}
+
+func TestProgramStatsDisabled(t *testing.T) {
+	// measuring the runs slows down all the eBPF programs of the host, the stats are only enabled when configured
+	p := &Probe{config: &config.Config{}}
+	p.startProgramStats()
+	if p.programStats != nil {
+		t.Error("the stats of the eBPF programs shouldn't be enabled by default")
+	}
+	if stats := p.GetProgramStats(); stats != nil {
+		t.Errorf("expected no program stats, got %v", stats)
+	}
+}
@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// renameCapabilities holds the fields of both names of a file, the kernel approves a rename by either of them
+var renameCapabilities = Capabilities{
+	"rename.old.filename": {
+		PolicyFlags:     PolicyFlagBasename,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+	"rename.old.basename": {
+		PolicyFlags:     PolicyFlagBasename,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+	"rename.new.filename": {
+		PolicyFlags:     PolicyFlagBasename,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+	"rename.new.basename": {
+		PolicyFlags:     PolicyFlagBasename,
+		FieldValueTypes: eval.ScalarValueType,
+	},
+}
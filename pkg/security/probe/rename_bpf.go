@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func renameOnNewApprovers(probe *Probe, approvers rules.Approvers) error {
+	// the old and the new names share the same approvers
+	filenames := newFilenameApprovers(probe, FileRenameEventType)
+
+	for field, values := range approvers {
+		switch field {
+		case "rename.old.basename", "rename.new.basename":
+			filenames.addBasenames(stringValues(values)...)
+
+		case "rename.old.filename", "rename.new.filename":
+			filenames.addFilenames(stringValues(values)...)
+
+		default:
+			return errors.New("field unknown")
+		}
+	}
+
+	return filenames.apply("rename_basename_approvers")
+}
@@ -19,15 +19,23 @@ type PolicyReport struct {
 	Approvers rules.Approvers
 }
 
+// RuleReport describes the event type of a rule and the approvers it requires, if any
+type RuleReport struct {
+	EventType eval.EventType
+	Approvers rules.Approvers `json:",omitempty"`
+}
+
 // Report describes the event types and their associated policy reports
 type Report struct {
 	Policies map[string]*PolicyReport
+	Rules    map[string]*RuleReport
 }
 
 // NewReport returns a new report
 func NewReport() *Report {
 	return &Report{
 		Policies: make(map[string]*PolicyReport),
+		Rules:    make(map[string]*RuleReport),
 	}
 }
 
@@ -58,6 +66,11 @@ func (r *Reporter) SetApprovers(eventType eval.EventType, approvers rules.Approv
 	return nil
 }
 
+// SetRule is called for each rule of an event type, with the approvers of the rule if it has any
+func (r *Reporter) SetRule(id eval.RuleID, eventType eval.EventType, approvers rules.Approvers) {
+	r.report.Rules[id] = &RuleReport{EventType: eventType, Approvers: approvers}
+}
+
 // GetReport returns the report
 func (r *Reporter) GetReport() *Report {
 	return r.report
@@ -7,6 +7,10 @@
 
 package probe
 
+import (
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
 // NewResolvers creates a new instance of Resolvers
 func NewResolvers(probe *Probe) (*Resolvers, error) {
 	dentryResolver, err := NewDentryResolver(probe)
@@ -19,12 +23,37 @@ func NewResolvers(probe *Probe) (*Resolvers, error) {
 		return nil, err
 	}
 
+	containerResolver, err := NewContainerResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	hashResolver, err := NewHashResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	securityLabelResolver, err := NewSecurityLabelResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	// the event types of the rules using unknown user or group names are left in accept mode until the names can be
+	// resolved, the probe applies their approvers again when /etc/passwd or /etc/group change
+	userGroupResolver := NewUserGroupResolver()
+	if _, err := userGroupResolver.Refresh(); err != nil {
+		log.Warnf("failed to resolve the users and the groups: %s", err)
+	}
+
 	resolvers := &Resolvers{
-		probe:             probe,
-		DentryResolver:    dentryResolver,
-		MountResolver:     NewMountResolver(probe),
-		TimeResolver:      timeResolver,
-		ContainerResolver: &ContainerResolver{},
+		probe:                 probe,
+		DentryResolver:        dentryResolver,
+		MountResolver:         NewMountResolver(probe),
+		TimeResolver:          timeResolver,
+		ContainerResolver:     containerResolver,
+		HashResolver:          hashResolver,
+		SecurityLabelResolver: securityLabelResolver,
+		UserGroupResolver:     userGroupResolver,
 	}
 
 	processResolver, err := NewProcessResolver(probe, resolvers)
@@ -7,14 +7,24 @@
 
 package probe
 
+import (
+	"net"
+
+	"github.com/DataDog/gopsutil/process"
+	"github.com/pkg/errors"
+)
+
 // Resolvers holds the list of the event attribute resolvers
 type Resolvers struct {
-	probe             *Probe
-	DentryResolver    *DentryResolver
-	MountResolver     *MountResolver
-	ContainerResolver *ContainerResolver
-	TimeResolver      *TimeResolver
-	ProcessResolver   *ProcessResolver
+	probe                 *Probe
+	DentryResolver        *DentryResolver
+	MountResolver         *MountResolver
+	ContainerResolver     *ContainerResolver
+	TimeResolver          *TimeResolver
+	ProcessResolver       *ProcessResolver
+	HashResolver          *HashResolver
+	SecurityLabelResolver *SecurityLabelResolver
+	UserGroupResolver     *UserGroupResolver
 }
 
 // Start the resolvers
@@ -30,6 +40,35 @@ func (r *Resolvers) Start() error {
 	return r.DentryResolver.Start()
 }
 
+// Resync reconciles the caches of the resolvers with the state of the system after a sustained loss of events. It
+// returns the number of process cache entries dropped and resolved again
+func (r *Resolvers) Resync() (int, int, error) {
+	allPids, err := process.Pids()
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "couldn't list the running processes")
+	}
+
+	pids := make([]uint32, 0, len(allPids))
+	for _, pid := range allPids {
+		pids = append(pids, uint32(pid))
+	}
+
+	r.DentryResolver.Flush()
+	r.MountResolver.Resync(pids)
+	dropped, resolved := r.ProcessResolver.Resync(pids)
+
+	return dropped, resolved, nil
+}
+
+// ResolveIPTags returns the tags the IP enricher of the probe annotates the given IP address with
+func (r *Resolvers) ResolveIPTags(ip string) []string {
+	addr := net.ParseIP(ip)
+	if r.probe == nil || addr == nil {
+		return nil
+	}
+	return r.probe.EnrichIP(addr)
+}
+
 // Snapshot collects data on the current state of the system to populate user space and kernel space caches.
 func (r *Resolvers) Snapshot() error {
 	return r.ProcessResolver.Snapshot(r.ContainerResolver, r.MountResolver)
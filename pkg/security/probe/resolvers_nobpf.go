@@ -9,10 +9,18 @@ package probe
 
 // Resolvers holds the list of the event attribute resolvers
 type Resolvers struct {
-	probe             *Probe
-	DentryResolver    *DentryResolver
-	MountResolver     *MountResolver
-	ContainerResolver *ContainerResolver
-	TimeResolver      *TimeResolver
-	ProcessResolver   *ProcessResolver
+	probe                 *Probe
+	DentryResolver        *DentryResolver
+	MountResolver         *MountResolver
+	ContainerResolver     *ContainerResolver
+	TimeResolver          *TimeResolver
+	ProcessResolver       *ProcessResolver
+	HashResolver          *HashResolver
+	SecurityLabelResolver *SecurityLabelResolver
+	UserGroupResolver     *UserGroupResolver
+}
+
+// ResolveIPTags returns the tags the IP enricher of the probe annotates the given IP address with
+func (r *Resolvers) ResolveIPTags(ip string) []string {
+	return nil
 }
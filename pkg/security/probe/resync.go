@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ResyncEvent describes a resync of the caches of the probe after a sustained loss of events, the events sent
+// around it may have gaps
+type ResyncEvent struct {
+	// Lost is the number of events lost during the lossy periods
+	Lost int64 `json:"lost"`
+	// Periods is the number of consecutive lossy periods
+	Periods int `json:"periods"`
+	// DroppedProcesses is the number of process cache entries of exited processes or reused pids that were dropped
+	DroppedProcesses int `json:"dropped_processes"`
+	// ResolvedProcesses is the number of process cache entries resolved again from the kernel
+	ResolvedProcesses int       `json:"resolved_processes"`
+	Timestamp         time.Time `json:"timestamp"`
+	Error             string    `json:"error,omitempty"`
+}
+
+// ResyncHandler is implemented by the event handlers notified of the resyncs of the probe
+type ResyncHandler interface {
+	HandleResync(event ResyncEvent)
+}
+
+// resyncController resyncs the caches of the probe when events are lost during consecutive periods
+type resyncController struct {
+	probe     *Probe
+	threshold int64
+	period    time.Duration
+	periods   int
+
+	// lost is the number of events lost during the current period
+	lost int64
+	// sustained and sustainedLost hold the consecutive lossy periods and their lost events
+	sustained     int
+	sustainedLost int64
+
+	resyncs int64
+}
+
+func newResyncController(probe *Probe) *resyncController {
+	return &resyncController{
+		probe:     probe,
+		threshold: probe.config.ResyncLostEventsThreshold,
+		period:    probe.config.ResyncPeriod,
+		periods:   probe.config.ResyncSustainedPeriods,
+	}
+}
+
+// CountLost counts the events lost during the current period
+func (rc *resyncController) CountLost(count int64) {
+	atomic.AddInt64(&rc.lost, count)
+}
+
+// tick closes the current period and returns the event of the resync to run, if the loss was sustained long enough
+func (rc *resyncController) tick(now time.Time) (ResyncEvent, bool) {
+	lost := atomic.SwapInt64(&rc.lost, 0)
+	if lost < rc.threshold {
+		rc.sustained, rc.sustainedLost = 0, 0
+		return ResyncEvent{}, false
+	}
+
+	rc.sustained++
+	rc.sustainedLost += lost
+	if rc.sustained < rc.periods {
+		return ResyncEvent{}, false
+	}
+
+	event := ResyncEvent{Lost: rc.sustainedLost, Periods: rc.sustained, Timestamp: now}
+	rc.sustained, rc.sustainedLost = 0, 0
+	return event, true
+}
+
+// Start resyncs the caches of the probe after the sustained losses, until the context is done
+func (rc *resyncController) Start(ctx context.Context) {
+	ticker := time.NewTicker(rc.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if event, resync := rc.tick(now); resync {
+				rc.probe.resync(event)
+				atomic.AddInt64(&rc.resyncs, 1)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Resyncs returns the number of resyncs since the probe started
+func (rc *resyncController) Resyncs() int64 {
+	return atomic.LoadInt64(&rc.resyncs)
+}
+
+// resync reconciles the caches of the resolvers with the state of the system and notifies the event handler
+func (p *Probe) resync(event ResyncEvent) {
+	log.Warnf("%d events lost during %d periods, resyncing the caches with the running processes and the mount points", event.Lost, event.Periods)
+
+	dropped, resolved, err := p.resolvers.Resync()
+	if err != nil {
+		log.Errorf("failed to resync the caches: %s", err)
+		event.Error = err.Error()
+	}
+	event.DroppedProcesses, event.ResolvedProcesses = dropped, resolved
+
+	if handler, ok := p.handler.(ResyncHandler); ok {
+		handler.HandleResync(event)
+	}
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResyncController(t *testing.T) {
+	rc := &resyncController{threshold: 100, period: time.Second, periods: 2}
+	now := time.Now()
+
+	rc.CountLost(150)
+	if _, resync := rc.tick(now); resync {
+		t.Fatal("a single lossy period shouldn't trigger a resync")
+	}
+
+	// a period below the threshold resets the sustained loss
+	rc.CountLost(50)
+	if _, resync := rc.tick(now); resync {
+		t.Fatal("a period below the threshold shouldn't trigger a resync")
+	}
+
+	rc.CountLost(120)
+	if _, resync := rc.tick(now); resync {
+		t.Fatal("the loss should be sustained for 2 periods")
+	}
+
+	rc.CountLost(130)
+	event, resync := rc.tick(now)
+	if !resync {
+		t.Fatal("expected a resync after 2 lossy periods")
+	}
+	if event.Lost != 250 || event.Periods != 2 || !event.Timestamp.Equal(now) {
+		t.Errorf("unexpected resync event: %+v", event)
+	}
+
+	rc.CountLost(200)
+	if _, resync := rc.tick(now); resync {
+		t.Error("the sustained loss should be reset by a resync")
+	}
+}
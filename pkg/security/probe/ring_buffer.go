@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	lib "github.com/DataDog/ebpf"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ringBufferMapType is BPF_MAP_TYPE_RINGBUF, unknown to the eBPF library
+	ringBufferMapType lib.MapType = 27
+
+	// the header of a ring buffer record holds its length and flags, then the page offset of the record
+	ringBufferHeaderSize  = 8
+	ringBufferBusyBit     = 1 << 31
+	ringBufferDiscardBit  = 1 << 30
+	ringBufferPollTimeout = 100 // milliseconds
+)
+
+// newRingBufferMap creates a ring buffer map, its size has to be a power of 2 multiple of the page size
+func newRingBufferMap(name string, size int) (*lib.Map, error) {
+	if pageSize := os.Getpagesize(); size < pageSize || size&(size-1) != 0 {
+		return nil, errors.Errorf("invalid ring buffer size %d, expected a power of 2 multiple of %d", size, pageSize)
+	}
+
+	return lib.NewMap(&lib.MapSpec{
+		Name:       name,
+		Type:       ringBufferMapType,
+		MaxEntries: uint32(size),
+	})
+}
+
+// readRingBufferRecords passes the records written between the consumer and producer positions to the handler and
+// returns the new consumer position. The data is mapped twice in a row so that the records wrapping around the end
+// of the buffer are contiguous. The reading stops at the first record still being written
+func readRingBufferRecords(data []byte, mask uint64, consumer, producer uint64, handler func(sample []byte)) uint64 {
+	for consumer < producer {
+		offset := consumer & mask
+		header := atomic.LoadUint32((*uint32)(unsafe.Pointer(&data[offset])))
+		if header&ringBufferBusyBit != 0 {
+			break
+		}
+
+		length := uint64(header &^ (ringBufferBusyBit | ringBufferDiscardBit))
+		if header&ringBufferDiscardBit == 0 {
+			start := offset + ringBufferHeaderSize
+			sample := make([]byte, length)
+			copy(sample, data[start:start+length])
+			handler(sample)
+		}
+
+		// the records are aligned on 8 bytes
+		consumer += (length + ringBufferHeaderSize + 7) &^ 7
+	}
+	return consumer
+}
+
+// RingBuffer reads the records of a BPF ring buffer, shared by all the CPUs. The records are read in the order they
+// were reserved by the kernel
+type RingBuffer struct {
+	ringMap  *lib.Map
+	consumer []byte
+	producer []byte
+	data     []byte
+	mask     uint64
+	epollFD  int
+	handler  func(sample []byte)
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewRingBuffer maps the given ring buffer map, the handler is called with each of its records
+func NewRingBuffer(ringMap *lib.Map, handler func(sample []byte)) (*RingBuffer, error) {
+	size := int(ringMap.ABI().MaxEntries)
+	pageSize := os.Getpagesize()
+
+	// the consumer position is the only page writable by user space
+	consumer, err := unix.Mmap(ringMap.FD(), 0, pageSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to map the consumer position of the ring buffer")
+	}
+
+	producer, err := unix.Mmap(ringMap.FD(), int64(pageSize), pageSize+2*size, unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		_ = unix.Munmap(consumer)
+		return nil, errors.Wrap(err, "failed to map the data of the ring buffer")
+	}
+
+	epollFD, err := unix.EpollCreate1(unix.EPOLL_CLOEXEC)
+	if err != nil {
+		_ = unix.Munmap(producer)
+		_ = unix.Munmap(consumer)
+		return nil, errors.Wrap(err, "failed to create the epoll instance of the ring buffer")
+	}
+
+	event := unix.EpollEvent{Events: unix.EPOLLIN, Fd: int32(ringMap.FD())}
+	if err := unix.EpollCtl(epollFD, unix.EPOLL_CTL_ADD, ringMap.FD(), &event); err != nil {
+		_ = unix.Close(epollFD)
+		_ = unix.Munmap(producer)
+		_ = unix.Munmap(consumer)
+		return nil, errors.Wrap(err, "failed to poll the ring buffer")
+	}
+
+	return &RingBuffer{
+		ringMap:  ringMap,
+		consumer: consumer,
+		producer: producer,
+		data:     producer[pageSize:],
+		mask:     uint64(size - 1),
+		epollFD:  epollFD,
+		handler:  handler,
+		stop:     make(chan struct{}),
+	}, nil
+}
+
+func (rb *RingBuffer) consumerPosition() *uint64 {
+	return (*uint64)(unsafe.Pointer(&rb.consumer[0]))
+}
+
+func (rb *RingBuffer) producerPosition() *uint64 {
+	return (*uint64)(unsafe.Pointer(&rb.producer[0]))
+}
+
+// read reads all the records available and releases their space to the kernel
+func (rb *RingBuffer) read() {
+	consumer := atomic.LoadUint64(rb.consumerPosition())
+	for {
+		producer := atomic.LoadUint64(rb.producerPosition())
+		if consumer >= producer {
+			return
+		}
+
+		next := readRingBufferRecords(rb.data, rb.mask, consumer, producer, rb.handler)
+		if next == consumer {
+			// the next record is still being written
+			return
+		}
+		consumer = next
+		atomic.StoreUint64(rb.consumerPosition(), consumer)
+	}
+}
+
+// Start starts reading the records of the ring buffer
+func (rb *RingBuffer) Start() {
+	rb.wg.Add(1)
+	go func() {
+		defer rb.wg.Done()
+
+		events := make([]unix.EpollEvent, 1)
+		for {
+			select {
+			case <-rb.stop:
+				return
+			default:
+			}
+
+			if _, err := unix.EpollWait(rb.epollFD, events, ringBufferPollTimeout); err != nil && err != unix.EINTR {
+				return
+			}
+			rb.read()
+		}
+	}()
+}
+
+// Stop stops reading the ring buffer and unmaps it, the map itself isn't closed
+func (rb *RingBuffer) Stop() error {
+	close(rb.stop)
+	rb.wg.Wait()
+
+	var result error
+	if err := unix.Close(rb.epollFD); err != nil {
+		result = err
+	}
+	if err := unix.Munmap(rb.producer); err != nil {
+		result = err
+	}
+	if err := unix.Munmap(rb.consumer); err != nil {
+		result = err
+	}
+	return result
+}
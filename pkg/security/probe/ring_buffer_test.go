@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+)
+
+// ringBufferTest emulates the data of a ring buffer, mapped twice in a row
+type ringBufferTest struct {
+	data     []byte
+	size     uint64
+	producer uint64
+}
+
+func (rb *ringBufferTest) write(sample string, flags uint32) {
+	record := make([]byte, (uint64(len(sample))+ringBufferHeaderSize+7)&^7)
+	ebpf.ByteOrder.PutUint32(record[0:4], uint32(len(sample))|flags)
+	copy(record[ringBufferHeaderSize:], sample)
+
+	for i, b := range record {
+		offset := (rb.producer + uint64(i)) % rb.size
+		rb.data[offset] = b
+		rb.data[offset+rb.size] = b
+	}
+	rb.producer += uint64(len(record))
+}
+
+func TestRingBufferRecords(t *testing.T) {
+	// the records read before are already released
+	rb := &ringBufferTest{data: make([]byte, 256), size: 128, producer: 100}
+
+	var samples []string
+	handler := func(sample []byte) {
+		samples = append(samples, string(sample))
+	}
+
+	rb.write("first record", 0)
+	rb.write("wrapping around", 0)
+	rb.write("discarded", ringBufferDiscardBit)
+
+	consumer := readRingBufferRecords(rb.data, rb.size-1, 100, rb.producer, handler)
+	if consumer != rb.producer {
+		t.Errorf("expected the consumer position %d, got %d", rb.producer, consumer)
+	}
+	if len(samples) != 2 || samples[0] != "first record" || samples[1] != "wrapping around" {
+		t.Errorf("unexpected samples: %v", samples)
+	}
+
+	// the reading stops at the records still being written
+	samples = nil
+	busy := rb.producer
+	rb.write("busy", ringBufferBusyBit)
+	rb.write("next", 0)
+
+	if consumer = readRingBufferRecords(rb.data, rb.size-1, consumer, rb.producer, handler); consumer != busy {
+		t.Errorf("expected the consumer position %d, got %d", busy, consumer)
+	}
+	if len(samples) != 0 {
+		t.Errorf("unexpected samples: %v", samples)
+	}
+}
@@ -0,0 +1,300 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// runtimeSourceDir is the directory of the bpf dir holding the sources of the eBPF programs, with the layout of
+	// the repository
+	runtimeSourceDir = "runtime"
+	// runtimeCompilationTimeout is the maximum duration of a compilation
+	runtimeCompilationTimeout = 2 * time.Minute
+)
+
+// runtimeSourceFile is the main source file of the eBPF programs, relative to the source directory
+var runtimeSourceFile = filepath.Join("pkg", "security", "ebpf", "c", "probe.c")
+
+// runtimeCompiler builds the eBPF programs against the headers of the running kernel, when the pre-built object
+// can't be loaded
+type runtimeCompiler struct {
+	sourceDir  string
+	outputDir  string
+	headerDirs []string
+	clangPath  string
+	llcPath    string
+	arch       string
+}
+
+// kernelArch returns the name of the architecture used by the kernel headers
+func kernelArch() string {
+	switch runtime.GOARCH {
+	case "amd64", "386":
+		return "x86"
+	case "arm64":
+		return "arm64"
+	}
+	return runtime.GOARCH
+}
+
+// kernelRelease returns the release of the running kernel
+func kernelRelease() (string, error) {
+	var uname unix.Utsname
+	if err := unix.Uname(&uname); err != nil {
+		return "", errors.Wrap(err, "failed to get the kernel release")
+	}
+	return string(bytes.TrimRight(uname.Release[:], "\x00")), nil
+}
+
+// findKernelHeaders returns the header directories of the given kernel release, looked up at the locations used by
+// the distributions
+func findKernelHeaders(release string) []string {
+	candidates := []string{
+		filepath.Join("/lib/modules", release, "build"),
+		filepath.Join("/lib/modules", release, "source"),
+		filepath.Join("/usr/src/kernels", release),
+		filepath.Join("/usr/src", "linux-headers-"+release),
+	}
+
+	var dirs []string
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, "include")); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+func newRuntimeCompiler(cfg *config.Config) (*runtimeCompiler, error) {
+	headerDirs := cfg.RuntimeCompilationHeaderDirs
+	if len(headerDirs) == 0 {
+		release, err := kernelRelease()
+		if err != nil {
+			return nil, err
+		}
+
+		if headerDirs = findKernelHeaders(release); len(headerDirs) == 0 {
+			return nil, errors.Errorf("no kernel headers found for %s", release)
+		}
+	}
+
+	return &runtimeCompiler{
+		sourceDir:  filepath.Join(cfg.BPFDir, runtimeSourceDir),
+		outputDir:  cfg.RuntimeCompilationOutputDir,
+		headerDirs: headerDirs,
+		clangPath:  cfg.RuntimeCompilationClangPath,
+		llcPath:    cfg.RuntimeCompilationLLCPath,
+		arch:       kernelArch(),
+	}, nil
+}
+
+// cflags returns the flags used to compile the eBPF programs, they match the ones of the pre-built objects except
+// for the warnings of the host headers
+func (c *runtimeCompiler) cflags(useSyscallWrapper bool) []string {
+	useWrapper := "0"
+	if useSyscallWrapper {
+		useWrapper = "1"
+	}
+
+	flags := []string{
+		"-D__KERNEL__",
+		"-DCONFIG_64BIT",
+		"-D__BPF_TRACING__",
+		`-DKBUILD_MODNAME="foo"`,
+		"-DUSE_SYSCALL_WRAPPER=" + useWrapper,
+		"-Wno-unused-value",
+		"-Wno-pointer-sign",
+		"-Wno-compare-distinct-pointer-types",
+		"-Wno-address-of-packed-member",
+		"-Wno-unknown-warning-option",
+		"-Wno-gnu-variable-sized-type-not-at-end",
+		"-include", filepath.Join(c.sourceDir, "pkg", "ebpf", "c", "asm_goto_workaround.h"),
+		"-O2",
+		"-emit-llvm",
+		"-fno-stack-protector",
+	}
+
+	subdirs := []string{
+		"include",
+		"include/uapi",
+		"include/generated/uapi",
+		filepath.Join("arch", c.arch, "include"),
+		filepath.Join("arch", c.arch, "include/uapi"),
+		filepath.Join("arch", c.arch, "include/generated"),
+	}
+	for _, dir := range c.headerDirs {
+		for _, subdir := range subdirs {
+			flags = append(flags, "-isystem", filepath.Join(dir, subdir))
+		}
+	}
+	return flags
+}
+
+// inputHash returns a hash of the sources, the flags and the kernel headers, the object compiled for the same
+// inputs is reused
+func (c *runtimeCompiler) inputHash(flags []string) (string, error) {
+	h := sha256.New()
+	for _, flag := range flags {
+		h.Write([]byte(flag))
+		h.Write([]byte{0})
+	}
+
+	var files []string
+	err := filepath.Walk(c.sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && (strings.HasSuffix(path, ".c") || strings.HasSuffix(path, ".h")) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the sources of %s", c.sourceDir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read %s", file)
+		}
+		h.Write([]byte(file))
+		h.Write(data)
+	}
+
+	// the headers of a kernel release may be updated by the distribution packages
+	for _, dir := range c.headerDirs {
+		if info, err := os.Stat(filepath.Join(dir, "include")); err == nil {
+			h.Write([]byte(dir + info.ModTime().String()))
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// isTrusted returns whether the given file or directory can only be written by the agent: it has to be owned by the
+// user of the agent, not be a symbolic link and not be writable by its group nor by the other users
+func isTrusted(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || info.Mode()&os.ModeSymlink != 0 {
+		return false
+	}
+	return int(stat.Uid) == os.Geteuid() && info.Mode().Perm()&0022 == 0
+}
+
+// checkOutputDir creates the output directory and checks that only the agent can write to it, the objects it holds
+// are loaded in the kernel
+func (c *runtimeCompiler) checkOutputDir() error {
+	if err := os.MkdirAll(c.outputDir, 0700); err != nil {
+		return errors.Wrapf(err, "failed to create %s", c.outputDir)
+	}
+
+	info, err := os.Lstat(c.outputDir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() || !isTrusted(info) {
+		return errors.Errorf("%s should be a directory owned by the agent and only writable by it", c.outputDir)
+	}
+	return nil
+}
+
+// compile builds the eBPF programs and returns the path of the object
+func (c *runtimeCompiler) compile(useSyscallWrapper bool) (string, error) {
+	flags := c.cflags(useSyscallWrapper)
+
+	hash, err := c.inputHash(flags)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.checkOutputDir(); err != nil {
+		return "", err
+	}
+
+	// an object that another user could have written is compiled again
+	objFile := filepath.Join(c.outputDir, "runtime-security-"+hash[:16]+".o")
+	if info, err := os.Lstat(objFile); err == nil {
+		if info.Mode().IsRegular() && isTrusted(info) {
+			log.Infof("using the eBPF programs compiled against the kernel headers %s", objFile)
+			return objFile, nil
+		}
+		log.Warnf("%s isn't only writable by the agent, compiling the eBPF programs again", objFile)
+	}
+
+	bcFile := strings.TrimSuffix(objFile, ".o") + ".bc"
+	defer os.Remove(bcFile)
+
+	ctx, cancel := context.WithTimeout(context.Background(), runtimeCompilationTimeout)
+	defer cancel()
+
+	srcFile := filepath.Join(c.sourceDir, runtimeSourceFile)
+	clangArgs := append(flags, "-c", srcFile, "-o", bcFile)
+	if output, err := exec.CommandContext(ctx, c.clangPath, clangArgs...).CombinedOutput(); err != nil {
+		return "", errors.Wrapf(err, "failed to compile %s: %s", srcFile, output)
+	}
+
+	// the object is renamed once complete, an interrupted compilation doesn't leave a truncated object
+	tmpFile := objFile + ".tmp"
+	defer os.Remove(tmpFile)
+
+	if output, err := exec.CommandContext(ctx, c.llcPath, "-march=bpf", "-filetype=obj", "-o", tmpFile, bcFile).CombinedOutput(); err != nil {
+		return "", errors.Wrapf(err, "failed to compile %s: %s", bcFile, output)
+	}
+	if err := os.Chmod(tmpFile, 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to write %s", objFile)
+	}
+	if err := os.Rename(tmpFile, objFile); err != nil {
+		return "", errors.Wrapf(err, "failed to write %s", objFile)
+	}
+
+	log.Infof("eBPF programs compiled against the kernel headers %s", objFile)
+	return objFile, nil
+}
+
+// compileRuntimeAsset builds the eBPF programs against the headers of the running kernel and returns a reader of
+// the object
+func compileRuntimeAsset(cfg *config.Config, useSyscallWrapper bool) (io.ReaderAt, error) {
+	compiler, err := newRuntimeCompiler(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	objFile, err := compiler.compile(useSyscallWrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(objFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", objFile)
+	}
+	return bytes.NewReader(data), nil
+}
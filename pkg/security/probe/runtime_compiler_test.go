@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile creates a file and its parent directories
+func writeTestFile(t *testing.T, path string, content string, perm os.FileMode) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRuntimeCompiler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runtime-compiler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	headersDir := filepath.Join(dir, "headers")
+	writeTestFile(t, filepath.Join(headersDir, "include", "linux", "types.h"), "", 0600)
+
+	sourceDir := filepath.Join(dir, "runtime")
+	writeTestFile(t, filepath.Join(sourceDir, runtimeSourceFile), "#include \"defs.h\"\n", 0600)
+	writeTestFile(t, filepath.Join(sourceDir, "pkg", "security", "ebpf", "c", "defs.h"), "", 0600)
+
+	// the fake compilers count their invocations
+	counter := filepath.Join(dir, "count")
+	script := "#!/bin/sh\necho >> " + counter + "\nwhile [ $# -gt 0 ]; do if [ \"$1\" = \"-o\" ]; then echo obj > \"$2\"; fi; shift; done\n"
+	writeTestFile(t, filepath.Join(dir, "clang"), script, 0700)
+	writeTestFile(t, filepath.Join(dir, "llc"), script, 0700)
+
+	compiler := &runtimeCompiler{
+		sourceDir:  sourceDir,
+		outputDir:  filepath.Join(dir, "build"),
+		headerDirs: []string{headersDir},
+		clangPath:  filepath.Join(dir, "clang"),
+		llcPath:    filepath.Join(dir, "llc"),
+		arch:       "x86",
+	}
+
+	objFile, err := compiler.compile(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(objFile); err != nil {
+		t.Fatalf("object not found: %s", err)
+	}
+
+	// the object is reused as long as the inputs don't change
+	if cached, err := compiler.compile(false); err != nil || cached != objFile {
+		t.Errorf("expected the compiled object to be reused, got %s (%v)", cached, err)
+	}
+	if count, _ := ioutil.ReadFile(counter); len(count) != 2 {
+		t.Errorf("expected one compilation, got %d compiler invocations", len(count))
+	}
+
+	// an object other users can write to may have been replaced, it is compiled again
+	if err := os.Chmod(objFile, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if recompiled, err := compiler.compile(false); err != nil || recompiled != objFile {
+		t.Errorf("expected the object to be compiled again, got %s (%v)", recompiled, err)
+	}
+	if count, _ := ioutil.ReadFile(counter); len(count) != 4 {
+		t.Errorf("expected two compilations, got %d compiler invocations", len(count))
+	}
+	if info, err := os.Stat(objFile); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the object to only be writable by the agent, got %v", info.Mode())
+	}
+
+	// the objects of a directory other users can write to aren't loaded
+	if err := os.Chmod(compiler.outputDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := compiler.compile(false); err == nil {
+		t.Error("expected the output directory to be refused")
+	}
+	if err := os.Chmod(compiler.outputDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if other, err := compiler.compile(true); err != nil || other == objFile {
+		t.Errorf("expected another object for the syscall wrappers, got %s (%v)", other, err)
+	}
+
+	writeTestFile(t, filepath.Join(sourceDir, "pkg", "security", "ebpf", "c", "defs.h"), "#define FOO 1\n", 0600)
+	if updated, err := compiler.compile(false); err != nil || updated == objFile {
+		t.Errorf("expected the updated sources to be compiled again, got %s (%v)", updated, err)
+	}
+
+	// a failed compilation doesn't leave an object behind
+	compiler.llcPath = "/bin/false"
+	writeTestFile(t, filepath.Join(sourceDir, "pkg", "security", "ebpf", "c", "defs.h"), "#define FOO 2\n", 0600)
+	if _, err := compiler.compile(false); err == nil {
+		t.Error("the compilation should fail")
+	}
+	if objFile, _ := compiler.compile(false); objFile != "" {
+		t.Errorf("unexpected object %s", objFile)
+	}
+}
+
+func TestFindKernelHeaders(t *testing.T) {
+	if dirs := findKernelHeaders("0.0.0-missing"); len(dirs) != 0 {
+		t.Errorf("unexpected kernel headers: %v", dirs)
+	}
+}
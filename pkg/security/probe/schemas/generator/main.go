@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/security/probe"
+)
+
+var output string
+
+func main() {
+	flag.StringVar(&output, "output", "", "Directory of the generated JSON schemas")
+	flag.Parse()
+
+	if output == "" {
+		log.Fatal("an output directory is required")
+	}
+
+	for name, schema := range probe.Schemas() {
+		data, err := schema.Encode()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(output, name), data, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"fmt"
+	"path"
+
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+const securityLabelCacheSize = 1024
+
+// securityLabelKey identifies a version of the extended attributes of a file, setting or removing one updates the
+// change time of the file
+type securityLabelKey struct {
+	mountID uint32
+	inode   uint64
+	ctime   int64
+}
+
+// SecurityLabelResolver is used to resolve the SELinux labels of the files
+type SecurityLabelResolver struct {
+	labels *lru.Cache
+}
+
+// ResolveSecurityLabel returns the SELinux label of the given file, accessed by the given process, empty when the file
+// has none. The label is cached per inode until the change time of the file changes, it is only read if the path still
+// points to the inode of the event
+func (sr *SecurityLabelResolver) ResolveSecurityLabel(pid uint32, mountID uint32, inode uint64, filename string) (string, error) {
+	// the path is resolved in the mount namespace of the process, its root is read from the host so that the agent can
+	// run in a container
+	if pid == 0 {
+		pid = 1
+	}
+	rootFilename := path.Join(utils.ProcRootPath(pid), filename)
+
+	var stat unix.Stat_t
+	if err := unix.Lstat(rootFilename, &stat); err != nil {
+		return "", err
+	}
+
+	if stat.Ino != inode {
+		return "", fmt.Errorf("%s was replaced, inode %d instead of %d", filename, stat.Ino, inode)
+	}
+
+	key := securityLabelKey{mountID: mountID, inode: inode, ctime: stat.Ctim.Nano()}
+	if value, exists := sr.labels.Get(key); exists {
+		return value.(string), nil
+	}
+
+	// the files without label and the filesystems without extended attributes have an empty label
+	label, err := utils.FileSecurityLabel(rootFilename)
+	if err != nil && err != unix.ENODATA && err != unix.ENOTSUP {
+		return "", err
+	}
+	sr.labels.Add(key, label)
+
+	return label, nil
+}
+
+// NewSecurityLabelResolver returns a new security label resolver
+func NewSecurityLabelResolver() (*SecurityLabelResolver, error) {
+	labels, err := lru.New(securityLabelCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecurityLabelResolver{
+		labels: labels,
+	}, nil
+}
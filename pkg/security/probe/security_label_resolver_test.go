@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSecurityLabelResolver(t *testing.T) {
+	sr, err := NewSecurityLabelResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ioutil.TempFile("", "security-label-resolver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	stat := func() *syscall.Stat_t {
+		info, err := os.Stat(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Sys().(*syscall.Stat_t)
+	}
+
+	pid := uint32(os.Getpid())
+	inode := stat().Ino
+	label, err := sr.ResolveSecurityLabel(pid, 1, inode, file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the label is cached per inode, the file isn't read again until its change time changes
+	key := securityLabelKey{mountID: 1, inode: inode, ctime: stat().Ctim.Nano()}
+	if _, exists := sr.labels.Get(key); !exists {
+		t.Fatalf("expected the label of inode %d to be cached", inode)
+	}
+	sr.labels.Add(key, "cached_label")
+	if cached, _ := sr.ResolveSecurityLabel(pid, 1, inode, file.Name()); cached != "cached_label" {
+		t.Errorf("expected the cached label, got `%s`", cached)
+	}
+
+	// the change time has a granularity of a tick
+	time.Sleep(10 * time.Millisecond)
+	if err := os.Chmod(file.Name(), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if updated, _ := sr.ResolveSecurityLabel(pid, 1, inode, file.Name()); updated != label {
+		t.Errorf("expected the label to be read again once the file changed, got `%s` instead of `%s`", updated, label)
+	}
+
+	// the label of a replaced file isn't read
+	if _, err := sr.ResolveSecurityLabel(pid, 1, inode+1, file.Name()); err == nil {
+		t.Error("expected the label of a replaced file not to be resolved")
+	}
+}
@@ -0,0 +1,445 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+//go:generate go run github.com/DataDog/datadog-agent/pkg/security/probe/schemas/generator -output schemas
+
+package probe
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+// EventSchemaVersion is the version of the JSON serialization of the events and of the rule matches, published in
+// the schemas directory. Adding an optional field is a minor change, renaming, removing or changing the type of a
+// field is a major one
+const EventSchemaVersion = "1.1.0"
+
+// FileDestinationSerializer serializes the path of a file referenced by another one
+type FileDestinationSerializer struct {
+	Filename string `json:"filename"`
+}
+
+// FileMetadataSerializer serializes the owner and the mode of a file before the event altered them
+type FileMetadataSerializer struct {
+	UID  uint32 `json:"uid"`
+	GID  uint32 `json:"gid"`
+	Mode uint32 `json:"mode"`
+}
+
+// FileSerializer serializes a file, along with the fields specific to the event on this file
+type FileSerializer struct {
+	Filename        string `json:"filename"`
+	ContainerPath   string `json:"container_path,omitempty"`
+	SecurityLabel   string `json:"security_label,omitempty"`
+	SHA256          string `json:"sha256,omitempty"`
+	Inode           uint64 `json:"inode"`
+	MountID         uint32 `json:"mount_id"`
+	OverlayNumLower int32  `json:"overlay_numlower"`
+
+	Destination *FileDestinationSerializer `json:"destination,omitempty"`
+
+	File  *FileMetadataSerializer `json:"file,omitempty"`
+	Mode  *uint32                 `json:"mode,omitempty"`
+	Flags string                  `json:"flags,omitempty"`
+	UID   *int32                  `json:"uid,omitempty"`
+	GID   *int32                  `json:"gid,omitempty"`
+	User  string                  `json:"user,omitempty"`
+	Group string                  `json:"group,omitempty"`
+
+	AccessTime       *time.Time `json:"access_time,omitempty"`
+	ModificationTime *time.Time `json:"modification_time,omitempty"`
+
+	AttributeName      string `json:"attribute_name,omitempty"`
+	AttributeNamespace string `json:"attribute_namespace,omitempty"`
+	AttributeValue     string `json:"attribute_value,omitempty"`
+	AttributeValueSize uint32 `json:"attribute_value_size,omitempty"`
+}
+
+// ProcessExecutableSerializer serializes the metadata of the executable of a process, as it was at exec time
+type ProcessExecutableSerializer struct {
+	MTime *time.Time `json:"mtime,omitempty"`
+	CTime *time.Time `json:"ctime,omitempty"`
+	Size  uint64     `json:"size"`
+	Mode  uint32     `json:"mode"`
+}
+
+// ProcessSessionSerializer serializes the SSH session of a process
+type ProcessSessionSerializer struct {
+	SourceIP     string   `json:"source_ip"`
+	SourceIPTags []string `json:"source_ip_tags,omitempty"`
+}
+
+// ProcessSerializer serializes the process context of an event
+type ProcessSerializer struct {
+	Name      string  `json:"name"`
+	TTYName   string  `json:"tty_name,omitempty"`
+	Pid       uint32  `json:"pid"`
+	Tid       uint32  `json:"tid"`
+	UID       uint32  `json:"uid"`
+	GID       uint32  `json:"gid"`
+	Pidns     uint32  `json:"pidns"`
+	Mntns     uint32  `json:"mntns"`
+	Netns     uint32  `json:"netns"`
+	AUID      *uint32 `json:"auid,omitempty"`
+	SessionID *uint32 `json:"session_id,omitempty"`
+
+	Filename        string                       `json:"filename"`
+	ContainerPath   string                       `json:"container_path,omitempty"`
+	Interpreter     *FileDestinationSerializer   `json:"interpreter,omitempty"`
+	SecurityContext string                       `json:"security_context,omitempty"`
+	SecurityLabel   string                       `json:"security_label,omitempty"`
+	SHA256          string                       `json:"sha256,omitempty"`
+	Session         *ProcessSessionSerializer    `json:"session,omitempty"`
+	Inode           uint64                       `json:"inode"`
+	MountID         uint32                       `json:"mount_id"`
+	OverlayNumLower int32                        `json:"overlay_numlower"`
+	File            *ProcessExecutableSerializer `json:"file,omitempty"`
+	Timestamp       *time.Time                   `json:"timestamp,omitempty"`
+}
+
+// ContainerSerializer serializes the container context of an event
+type ContainerSerializer struct {
+	ID          string `json:"container_id"`
+	ImageName   string `json:"image_name,omitempty"`
+	ImageDigest string `json:"image_digest,omitempty"`
+}
+
+// SyscallSerializer serializes the type of an event and the return value of its syscall
+type SyscallSerializer struct {
+	Type   string `json:"type"`
+	Retval int64  `json:"retval"`
+}
+
+// MountSerializer serializes a mount event
+type MountSerializer struct {
+	MountPoint    string `json:"mount_point"`
+	ParentMountID uint32 `json:"parent_mount_id"`
+	ParentInode   uint64 `json:"parent_inode"`
+	RootInode     uint64 `json:"root_inode"`
+	RootMountID   uint32 `json:"root_mount_id"`
+	Root          string `json:"root"`
+	MountID       uint32 `json:"mount_id"`
+	GroupID       uint32 `json:"group_id"`
+	Device        uint32 `json:"device"`
+	Source        string `json:"source,omitempty"`
+	FSType        string `json:"fstype"`
+}
+
+// UmountSerializer serializes an umount event
+type UmountSerializer struct {
+	MountID uint32 `json:"mount_id"`
+}
+
+// EventSerializer serializes an event. Only the fields of its type are set, along with its process and container
+// contexts, the events of an unknown type only report their id and timestamp
+type EventSerializer struct {
+	SchemaVersion string               `json:"schema_version"`
+	ID            string               `json:"id"`
+	Timestamp     time.Time            `json:"timestamp"`
+	Syscall       *SyscallSerializer   `json:"syscall,omitempty"`
+	Process       *ProcessSerializer   `json:"process,omitempty"`
+	Container     *ContainerSerializer `json:"container,omitempty"`
+	File          *FileSerializer      `json:"file,omitempty"`
+	Old           *FileSerializer      `json:"old,omitempty"`
+	New           *FileSerializer      `json:"new,omitempty"`
+	Source        *FileSerializer      `json:"source,omitempty"`
+	Target        *FileSerializer      `json:"target,omitempty"`
+	Mount         *MountSerializer     `json:"mount,omitempty"`
+	Umount        *UmountSerializer    `json:"umount,omitempty"`
+}
+
+// RuleMatchSerializer serializes the match of a rule by an event, as it is sent to the backend
+type RuleMatchSerializer struct {
+	RuleID      string           `json:"rule_id"`
+	RuleVersion string           `json:"rule_version,omitempty"`
+	Severity    string           `json:"severity"`
+	Simulated   bool             `json:"simulated,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
+	Event       *EventSerializer `json:"event"`
+}
+
+// timePtr returns a pointer to the given time, nil when unset
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// nanoTimePtr returns the time of the given nanoseconds since epoch, nil when unset
+func nanoTimePtr(nsec uint64) *time.Time {
+	if nsec == 0 {
+		return nil
+	}
+	t := time.Unix(0, int64(nsec))
+	return &t
+}
+
+func newFileSerializer(fe *FileEvent, resolvers *Resolvers, inode uint64) *FileSerializer {
+	s := &FileSerializer{
+		Filename:        fe.ResolveInode(resolvers),
+		ContainerPath:   fe.ResolveContainerPath(resolvers),
+		SecurityLabel:   fe.ResolveSecurityLabel(resolvers),
+		Inode:           inode,
+		MountID:         fe.MountID,
+		OverlayNumLower: fe.OverlayNumLower,
+	}
+	// only the checksum of a file hashed during the evaluation of the rules is reported
+	if fe.sha256Resolved {
+		s.SHA256 = fe.SHA256
+	}
+	if destination := fe.ResolveDestination(resolvers); destination != "" {
+		s.Destination = &FileDestinationSerializer{Filename: destination}
+	}
+	return s
+}
+
+func newFileMetadataSerializer(fm *FileMetadata) *FileMetadataSerializer {
+	return &FileMetadataSerializer{
+		UID:  fm.UID,
+		GID:  fm.GID,
+		Mode: fm.Mode,
+	}
+}
+
+func newChmodSerializer(e *ChmodEvent, resolvers *Resolvers) *FileSerializer {
+	s := newFileSerializer(&e.FileEvent, resolvers, e.Inode)
+	mode := e.Mode
+	s.File = newFileMetadataSerializer(&e.FileMetadata)
+	s.Mode = &mode
+	return s
+}
+
+func newChownSerializer(e *ChownEvent, resolvers *Resolvers) *FileSerializer {
+	s := newFileSerializer(&e.FileEvent, resolvers, e.Inode)
+	s.File = newFileMetadataSerializer(&e.FileMetadata)
+	uid, gid := e.UID, e.GID
+	s.UID, s.GID = &uid, &gid
+	s.User = e.ResolveUser(resolvers)
+	s.Group = e.ResolveGroup(resolvers)
+	return s
+}
+
+func newOpenSerializer(e *OpenEvent, resolvers *Resolvers) *FileSerializer {
+	mode := e.Mode
+	s := newFileSerializer(&e.FileEvent, resolvers, e.Inode)
+	s.Mode = &mode
+	s.Flags = OpenFlags(e.Flags).String()
+	return s
+}
+
+func newMkdirSerializer(e *MkdirEvent, resolvers *Resolvers) *FileSerializer {
+	s := newFileSerializer(&e.FileEvent, resolvers, e.Inode)
+	mode := uint32(e.Mode)
+	s.Mode = &mode
+	return s
+}
+
+func newUnlinkSerializer(e *UnlinkEvent, resolvers *Resolvers) *FileSerializer {
+	s := newFileSerializer(&e.FileEvent, resolvers, e.Inode)
+	s.File = newFileMetadataSerializer(&e.FileMetadata)
+	s.Flags = UnlinkFlags(e.Flags).String()
+	return s
+}
+
+func newUtimesSerializer(e *UtimesEvent, resolvers *Resolvers) *FileSerializer {
+	s := newFileSerializer(&e.FileEvent, resolvers, e.Inode)
+	s.AccessTime = timePtr(e.Atime)
+	s.ModificationTime = timePtr(e.Mtime)
+	return s
+}
+
+func newXAttrSerializer(e *SetXAttrEvent, resolvers *Resolvers) *FileSerializer {
+	s := newFileSerializer(&e.FileEvent, resolvers, e.Inode)
+	s.AttributeName = e.GetName(resolvers)
+	s.AttributeNamespace = e.GetNamespace(resolvers)
+	if e.ValueSize > 0 {
+		s.AttributeValue = hex.EncodeToString([]byte(e.GetValue(resolvers)))
+		s.AttributeValueSize = e.ValueSize
+	}
+	return s
+}
+
+func newMountSerializer(e *MountEvent, resolvers *Resolvers) *MountSerializer {
+	return &MountSerializer{
+		MountPoint:    e.ResolveMountPoint(resolvers),
+		ParentMountID: e.ParentMountID,
+		ParentInode:   e.ParentInode,
+		RootInode:     e.RootInode,
+		RootMountID:   e.RootMountID,
+		Root:          e.ResolveRoot(resolvers),
+		MountID:       e.MountID,
+		GroupID:       e.GroupID,
+		Device:        e.Device,
+		Source:        e.Source,
+		FSType:        e.GetFSType(),
+	}
+}
+
+func newProcessSerializer(p *ProcessEvent, resolvers *Resolvers) *ProcessSerializer {
+	s := &ProcessSerializer{
+		Name:            p.ResolveComm(resolvers),
+		TTYName:         p.ResolveTTY(resolvers),
+		Pid:             p.Pid,
+		Tid:             p.Tid,
+		UID:             p.UID,
+		GID:             p.GID,
+		Pidns:           p.Pidns,
+		Mntns:           p.Mntns,
+		Netns:           p.Netns,
+		Filename:        p.ResolveInode(resolvers),
+		ContainerPath:   p.ResolveContainerPath(resolvers),
+		SecurityContext: p.ResolveSecurityContext(resolvers),
+		SecurityLabel:   p.ResolveSecurityLabel(resolvers),
+		Inode:           p.Inode,
+		MountID:         p.MountID,
+		OverlayNumLower: p.OverlayNumLower,
+		Timestamp:       timePtr(p.ResolveTimestamp(resolvers)),
+	}
+
+	if auid := p.ResolveAUID(resolvers); auid != utils.AuditUnset {
+		sessionID := p.ResolveSessionID(resolvers)
+		s.AUID, s.SessionID = &auid, &sessionID
+	}
+	if interpreter := p.ResolveInterpreter(resolvers); interpreter != "" {
+		s.Interpreter = &FileDestinationSerializer{Filename: interpreter}
+	}
+	if p.sha256Resolved {
+		s.SHA256 = p.SHA256
+	}
+	if sourceIP := p.ResolveSessionSourceIP(resolvers); sourceIP != "" {
+		s.Session = &ProcessSessionSerializer{SourceIP: sourceIP, SourceIPTags: resolvers.ResolveIPTags(sourceIP)}
+	}
+
+	p.resolveExecutable(resolvers)
+	s.File = &ProcessExecutableSerializer{
+		MTime: nanoTimePtr(p.ExecMTime),
+		CTime: nanoTimePtr(p.ExecCTime),
+		Size:  p.ExecSize,
+		Mode:  p.ExecMode,
+	}
+
+	return s
+}
+
+func newContainerSerializer(e *ContainerEvent, resolvers *Resolvers) *ContainerSerializer {
+	id := e.GetContainerID()
+	if len(id) == 0 {
+		return nil
+	}
+
+	return &ContainerSerializer{
+		ID:          id,
+		ImageName:   e.ResolveImageName(resolvers),
+		ImageDigest: e.ResolveImageDigest(resolvers),
+	}
+}
+
+func newSyscallSerializer(eventType EventType, e *SyscallEvent) *SyscallSerializer {
+	return &SyscallSerializer{
+		Type:   eventType.String(),
+		Retval: e.Retval,
+	}
+}
+
+// NewEventSerializer returns the serializer of the given event, resolving the fields it reports
+func NewEventSerializer(e *Event) *EventSerializer {
+	eventID, _ := uuid.NewRandom()
+	resolvers := e.resolvers
+
+	s := &EventSerializer{
+		SchemaVersion: EventSchemaVersion,
+		ID:            eventID.String(),
+		Timestamp:     e.ResolveMonotonicTimestamp(resolvers),
+	}
+
+	eventType := EventType(e.Type)
+	switch eventType {
+	case FileChmodEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Chmod.SyscallEvent)
+		s.File = newChmodSerializer(&e.Chmod, resolvers)
+	case FileChownEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Chown.SyscallEvent)
+		s.File = newChownSerializer(&e.Chown, resolvers)
+	case FileOpenEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Open.SyscallEvent)
+		s.File = newOpenSerializer(&e.Open, resolvers)
+	case FileMkdirEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Mkdir.SyscallEvent)
+		s.File = newMkdirSerializer(&e.Mkdir, resolvers)
+	case FileRmdirEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Rmdir.SyscallEvent)
+		s.File = newFileSerializer(&e.Rmdir.FileEvent, resolvers, e.Rmdir.Inode)
+	case FileUnlinkEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Unlink.SyscallEvent)
+		s.File = newUnlinkSerializer(&e.Unlink, resolvers)
+	case FileRenameEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Rename.SyscallEvent)
+		// use the new.inode as the old one is a fake one generated from the probe
+		s.Old = newFileSerializer(&e.Rename.Old, resolvers, e.Rename.New.Inode)
+		s.New = newFileSerializer(&e.Rename.New, resolvers, e.Rename.New.Inode)
+	case FileUtimeEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Utimes.SyscallEvent)
+		s.File = newUtimesSerializer(&e.Utimes, resolvers)
+	case FileLinkEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Link.SyscallEvent)
+		// use the source.inode as the target one is a fake one generated from the probe
+		s.Source = newFileSerializer(&e.Link.Source, resolvers, e.Link.Source.Inode)
+		s.Target = newFileSerializer(&e.Link.Target, resolvers, e.Link.Source.Inode)
+	case FileMountEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Mount.SyscallEvent)
+		s.Mount = newMountSerializer(&e.Mount, resolvers)
+	case FileUmountEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.Umount.SyscallEvent)
+		s.Umount = &UmountSerializer{MountID: e.Umount.MountID}
+	case FileSetXAttrEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.SetXAttr.SyscallEvent)
+		s.File = newXAttrSerializer(&e.SetXAttr, resolvers)
+	case FileRemoveXAttrEventType:
+		s.Syscall = newSyscallSerializer(eventType, &e.RemoveXAttr.SyscallEvent)
+		s.File = newXAttrSerializer(&e.RemoveXAttr, resolvers)
+	default:
+		return s
+	}
+
+	s.Process = newProcessSerializer(&e.Process, resolvers)
+	s.Container = newContainerSerializer(&e.Container, resolvers)
+
+	return s
+}
+
+// NewRuleMatchSerializer returns the serializer of the match of the given rule by the given event
+func NewRuleMatchSerializer(rule *eval.Rule, event *Event, severity string, version string, simulated bool) *RuleMatchSerializer {
+	return &RuleMatchSerializer{
+		RuleID:      rule.ID,
+		RuleVersion: version,
+		Severity:    severity,
+		Simulated:   simulated,
+		Tags:        rule.Tags,
+		Event:       NewEventSerializer(event),
+	}
+}
+
+// Schemas returns the JSON schemas of the serialization of the events and of the rule matches, per file name
+func Schemas() map[string]*utils.JSONSchema {
+	event := utils.GenerateJSONSchema("event.schema.json", "Runtime security event", &EventSerializer{})
+	event.Description = "Event reported by the runtime security probe, schema version " + EventSchemaVersion
+
+	ruleMatch := utils.GenerateJSONSchema("rule_match.schema.json", "Runtime security rule match", &RuleMatchSerializer{})
+	ruleMatch.Description = "Rule matched by an event of the runtime security probe, schema version " + EventSchemaVersion
+
+	return map[string]*utils.JSONSchema{
+		"event.schema.json":      event,
+		"rule_match.schema.json": ruleMatch,
+	}
+}
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+)
+
+func TestSchemas(t *testing.T) {
+	for name, schema := range Schemas() {
+		expected, err := schema.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		published, err := ioutil.ReadFile(filepath.Join("schemas", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(published, expected) {
+			t.Errorf("the published schema %s is outdated, run `go generate` to update it", name)
+		}
+	}
+}
+
+func TestEventSerializer(t *testing.T) {
+	tr, err := NewTimeResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := lru.New(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Add(uint32(123), &ProcessCacheEntry{
+		FileEvent: FileEvent{PathnameStr: "/usr/bin/aaa", ContainerPath: "/"},
+		LoginUID:  1000,
+		SessionID: 2,
+	})
+	resolvers := &Resolvers{
+		TimeResolver:    tr,
+		ProcessResolver: &ProcessResolver{probe: &Probe{config: &config.Config{}}, entryCache: cache},
+	}
+
+	e := NewEvent(resolvers)
+	e.Type = uint64(FileChmodEventType)
+	e.Process = ProcessEvent{
+		Comm: "aaa",
+		Pid:  123,
+	}
+	e.Chmod = ChmodEvent{
+		FileEvent: FileEvent{
+			Inode:       33,
+			PathnameStr: "/etc/\"quoted\"",
+		},
+		FileMetadata: FileMetadata{Mode: 0644},
+		Mode:         0600,
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// all the serialized fields are declared in the schema
+	var serializer EventSerializer
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&serializer); err != nil {
+		t.Fatal(err)
+	}
+
+	if serializer.SchemaVersion != EventSchemaVersion {
+		t.Errorf("unexpected schema version: %s", serializer.SchemaVersion)
+	}
+	if serializer.Syscall == nil || serializer.Syscall.Type != "chmod" {
+		t.Errorf("unexpected syscall: %+v", serializer.Syscall)
+	}
+	if serializer.File == nil || serializer.File.Filename != e.Chmod.PathnameStr || serializer.File.Mode == nil || *serializer.File.Mode != 0600 {
+		t.Errorf("unexpected file: %+v", serializer.File)
+	}
+	if serializer.Process == nil || serializer.Process.Filename != "/usr/bin/aaa" || serializer.Process.AUID == nil || *serializer.Process.AUID != 1000 {
+		t.Errorf("unexpected process: %+v", serializer.Process)
+	}
+	if serializer.Container != nil {
+		t.Errorf("expected no container, got %+v", serializer.Container)
+	}
+}
+
+type testIPEnricher map[string][]string
+
+func (e testIPEnricher) EnrichIP(ip net.IP) []string {
+	return e[ip.String()]
+}
+
+func TestEventSerializerIPTags(t *testing.T) {
+	cache, err := lru.New(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Add(uint32(123), &ProcessCacheEntry{
+		FileEvent:       FileEvent{PathnameStr: "/usr/bin/aaa"},
+		SessionSourceIP: "10.0.0.1",
+	})
+
+	probe := &Probe{config: &config.Config{}}
+	probe.SetIPEnricher(testIPEnricher{"10.0.0.1": {"threat:known"}})
+	resolvers := &Resolvers{
+		probe:           probe,
+		ProcessResolver: &ProcessResolver{probe: probe, entryCache: cache},
+	}
+
+	e := NewEvent(resolvers)
+	e.Process = ProcessEvent{Pid: 123}
+
+	s := newProcessSerializer(&e.Process, resolvers)
+	if s.Session == nil || s.Session.SourceIP != "10.0.0.1" {
+		t.Fatalf("unexpected session: %+v", s.Session)
+	}
+	if len(s.Session.SourceIPTags) != 1 || s.Session.SourceIPTags[0] != "threat:known" {
+		t.Errorf("expected the source IP to be enriched, got %v", s.Session.SourceIPTags)
+	}
+
+	// the default enricher doesn't annotate any address
+	probe.SetIPEnricher(nil)
+	e.Process = ProcessEvent{Pid: 123}
+	if s := newProcessSerializer(&e.Process, resolvers); s.Session == nil || len(s.Session.SourceIPTags) != 0 {
+		t.Errorf("unexpected session: %+v", s.Session)
+	}
+}
@@ -124,8 +124,11 @@ func (sm *SyscallMonitor) CollectStats(collector SyscallStatsCollector) error {
 			}
 		}
 
-		if err := collector.Count(processSyscall.Process, Syscall(processSyscall.ID), value); err != nil {
-			return err
+		// the syscalls missing from the identifiers aren't reported
+		if syscall, exists := newSyscall(processSyscall.ID); exists {
+			if err := collector.Count(processSyscall.Process, syscall, value); err != nil {
+				return err
+			}
 		}
 	}
 
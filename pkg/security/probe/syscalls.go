@@ -16,7 +16,7 @@ import (
 // Syscall represents a syscall identifier
 type Syscall int
 
-// Linux syscall identifiers
+// Linux syscall identifiers, numbered after the syscalls of x86_64
 const (
 	SysRead Syscall = iota
 	SysWrite
@@ -323,6 +323,20 @@ const (
 	SysPrlimit64
 )
 
+// archSyscalls maps the syscall numbers of the running architecture to the syscall identifiers, nil when they are
+// the same
+var archSyscalls map[uint32]Syscall
+
+// newSyscall returns the identifier of the given syscall number of the running architecture, false when the
+// identifiers don't include it
+func newSyscall(nr uint32) (Syscall, bool) {
+	if archSyscalls == nil {
+		return Syscall(nr), true
+	}
+	syscall, exists := archSyscalls[nr]
+	return syscall, exists
+}
+
 // MarshalText maps the syscall identifier to UTF-8-encoded text and returns the result
 func (s Syscall) MarshalText() ([]byte, error) {
 	return []byte(strings.ToLower(strings.TrimPrefix(s.String(), "Sys"))), nil
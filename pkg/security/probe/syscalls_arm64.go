@@ -0,0 +1,266 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+func init() {
+	// the syscalls of arm64 are numbered after the generic table of the kernel, the legacy syscalls of x86_64 are
+	// missing from it
+	archSyscalls = map[uint32]Syscall{
+		unix.SYS_IO_SETUP:               SysIoSetup,
+		unix.SYS_IO_DESTROY:             SysIoDestroy,
+		unix.SYS_IO_SUBMIT:              SysIoSubmit,
+		unix.SYS_IO_CANCEL:              SysIoCancel,
+		unix.SYS_IO_GETEVENTS:           SysIoGetevents,
+		unix.SYS_SETXATTR:               SysSetxattr,
+		unix.SYS_LSETXATTR:              SysLsetxattr,
+		unix.SYS_FSETXATTR:              SysFsetxattr,
+		unix.SYS_GETXATTR:               SysGetxattr,
+		unix.SYS_LGETXATTR:              SysLgetxattr,
+		unix.SYS_FGETXATTR:              SysFgetxattr,
+		unix.SYS_LISTXATTR:              SysListxattr,
+		unix.SYS_LLISTXATTR:             SysLlistxattr,
+		unix.SYS_FLISTXATTR:             SysFlistxattr,
+		unix.SYS_REMOVEXATTR:            SysRemovexattr,
+		unix.SYS_LREMOVEXATTR:           SysLremovexattr,
+		unix.SYS_FREMOVEXATTR:           SysFremovexattr,
+		unix.SYS_GETCWD:                 SysGetcwd,
+		unix.SYS_LOOKUP_DCOOKIE:         SysLookupDcookie,
+		unix.SYS_EVENTFD2:               SysEventfd2,
+		unix.SYS_EPOLL_CREATE1:          SysEpollCreate1,
+		unix.SYS_EPOLL_CTL:              SysEpollCtl,
+		unix.SYS_EPOLL_PWAIT:            SysEpollPwait,
+		unix.SYS_DUP:                    SysDup,
+		unix.SYS_DUP3:                   SysDup3,
+		unix.SYS_FCNTL:                  SysFcntl,
+		unix.SYS_INOTIFY_INIT1:          SysInotifyInit1,
+		unix.SYS_INOTIFY_ADD_WATCH:      SysInotifyAddWatch,
+		unix.SYS_INOTIFY_RM_WATCH:       SysInotifyRmWatch,
+		unix.SYS_IOCTL:                  SysIoctl,
+		unix.SYS_IOPRIO_SET:             SysIoprioSet,
+		unix.SYS_IOPRIO_GET:             SysIoprioGet,
+		unix.SYS_FLOCK:                  SysFlock,
+		unix.SYS_MKNODAT:                SysMknodat,
+		unix.SYS_MKDIRAT:                SysMkdirat,
+		unix.SYS_UNLINKAT:               SysUnlinkat,
+		unix.SYS_SYMLINKAT:              SysSymlinkat,
+		unix.SYS_LINKAT:                 SysLinkat,
+		unix.SYS_RENAMEAT:               SysRenameat,
+		unix.SYS_UMOUNT2:                SysUmount2,
+		unix.SYS_MOUNT:                  SysMount,
+		unix.SYS_PIVOT_ROOT:             SysPivotRoot,
+		unix.SYS_NFSSERVCTL:             SysNfsservctl,
+		unix.SYS_STATFS:                 SysStatfs,
+		unix.SYS_FSTATFS:                SysFstatfs,
+		unix.SYS_TRUNCATE:               SysTruncate,
+		unix.SYS_FTRUNCATE:              SysFtruncate,
+		unix.SYS_FALLOCATE:              SysFallocate,
+		unix.SYS_FACCESSAT:              SysFaccessat,
+		unix.SYS_CHDIR:                  SysChdir,
+		unix.SYS_FCHDIR:                 SysFchdir,
+		unix.SYS_CHROOT:                 SysChroot,
+		unix.SYS_FCHMOD:                 SysFchmod,
+		unix.SYS_FCHMODAT:               SysFchmodat,
+		unix.SYS_FCHOWNAT:               SysFchownat,
+		unix.SYS_FCHOWN:                 SysFchown,
+		unix.SYS_OPENAT:                 SysOpenat,
+		unix.SYS_CLOSE:                  SysClose,
+		unix.SYS_VHANGUP:                SysVhangup,
+		unix.SYS_PIPE2:                  SysPipe2,
+		unix.SYS_QUOTACTL:               SysQuotactl,
+		unix.SYS_GETDENTS64:             SysGetdents64,
+		unix.SYS_LSEEK:                  SysLseek,
+		unix.SYS_READ:                   SysRead,
+		unix.SYS_WRITE:                  SysWrite,
+		unix.SYS_READV:                  SysReadv,
+		unix.SYS_WRITEV:                 SysWritev,
+		unix.SYS_PREAD64:                SysPread64,
+		unix.SYS_PWRITE64:               SysPwrite64,
+		unix.SYS_PREADV:                 SysPreadv,
+		unix.SYS_PWRITEV:                SysPwritev,
+		unix.SYS_SENDFILE:               SysSendfile,
+		unix.SYS_PSELECT6:               SysPselect6,
+		unix.SYS_PPOLL:                  SysPpoll,
+		unix.SYS_SIGNALFD4:              SysSignalfd4,
+		unix.SYS_VMSPLICE:               SysVmsplice,
+		unix.SYS_SPLICE:                 SysSplice,
+		unix.SYS_TEE:                    SysTee,
+		unix.SYS_READLINKAT:             SysReadlinkat,
+		unix.SYS_FSTATAT:                SysNewfstatat,
+		unix.SYS_FSTAT:                  SysFstat,
+		unix.SYS_SYNC:                   SysSync,
+		unix.SYS_FSYNC:                  SysFsync,
+		unix.SYS_FDATASYNC:              SysFdatasync,
+		unix.SYS_SYNC_FILE_RANGE:        SysSyncFileRange,
+		unix.SYS_TIMERFD_CREATE:         SysTimerfdCreate,
+		unix.SYS_TIMERFD_SETTIME:        SysTimerfdSettime,
+		unix.SYS_TIMERFD_GETTIME:        SysTimerfdGettime,
+		unix.SYS_UTIMENSAT:              SysUtimensat,
+		unix.SYS_ACCT:                   SysAcct,
+		unix.SYS_CAPGET:                 SysCapget,
+		unix.SYS_CAPSET:                 SysCapset,
+		unix.SYS_PERSONALITY:            SysPersonality,
+		unix.SYS_EXIT:                   SysExit,
+		unix.SYS_EXIT_GROUP:             SysExitGroup,
+		unix.SYS_WAITID:                 SysWaitid,
+		unix.SYS_SET_TID_ADDRESS:        SysSetTidAddress,
+		unix.SYS_UNSHARE:                SysUnshare,
+		unix.SYS_FUTEX:                  SysFutex,
+		unix.SYS_SET_ROBUST_LIST:        SysSetRobustList,
+		unix.SYS_GET_ROBUST_LIST:        SysGetRobustList,
+		unix.SYS_NANOSLEEP:              SysNanosleep,
+		unix.SYS_GETITIMER:              SysGetitimer,
+		unix.SYS_SETITIMER:              SysSetitimer,
+		unix.SYS_KEXEC_LOAD:             SysKexecLoad,
+		unix.SYS_INIT_MODULE:            SysInitModule,
+		unix.SYS_DELETE_MODULE:          SysDeleteModule,
+		unix.SYS_TIMER_CREATE:           SysTimerCreate,
+		unix.SYS_TIMER_GETTIME:          SysTimersysReadGettime,
+		unix.SYS_TIMER_GETOVERRUN:       SysTimerGetoverrun,
+		unix.SYS_TIMER_SETTIME:          SysTimerSettime,
+		unix.SYS_TIMER_DELETE:           SysTimerDelete,
+		unix.SYS_CLOCK_SETTIME:          SysClockSettime,
+		unix.SYS_CLOCK_GETTIME:          SysClockGettime,
+		unix.SYS_CLOCK_GETRES:           SysClockGetres,
+		unix.SYS_CLOCK_NANOSLEEP:        SysClockNanosleep,
+		unix.SYS_SYSLOG:                 SysSyslog,
+		unix.SYS_PTRACE:                 SysPtrace,
+		unix.SYS_SCHED_SETPARAM:         SysSchedSetparam,
+		unix.SYS_SCHED_SETSCHEDULER:     SysSchedSetscheduler,
+		unix.SYS_SCHED_GETSCHEDULER:     SysSchedGetscheduler,
+		unix.SYS_SCHED_GETPARAM:         SysSchedGetparam,
+		unix.SYS_SCHED_SETAFFINITY:      SysSchedSetaffinity,
+		unix.SYS_SCHED_GETAFFINITY:      SysSchedGetaffinity,
+		unix.SYS_SCHED_YIELD:            SysSchedYield,
+		unix.SYS_SCHED_GET_PRIORITY_MAX: SysSchedGetPriorityMax,
+		unix.SYS_SCHED_GET_PRIORITY_MIN: SysSchedGetPriorityMin,
+		unix.SYS_SCHED_RR_GET_INTERVAL:  SysSchedRrGetInterval,
+		unix.SYS_RESTART_SYSCALL:        SysRestartSyscall,
+		unix.SYS_KILL:                   SysKill,
+		unix.SYS_TKILL:                  SysTkill,
+		unix.SYS_TGKILL:                 SysTgkill,
+		unix.SYS_SIGALTSTACK:            SysSigaltstack,
+		unix.SYS_RT_SIGSUSPEND:          SysRtSigsuspend,
+		unix.SYS_RT_SIGACTION:           SysRtSigaction,
+		unix.SYS_RT_SIGPROCMASK:         SysRtSigprocmask,
+		unix.SYS_RT_SIGPENDING:          SysRtSigpending,
+		unix.SYS_RT_SIGTIMEDWAIT:        SysRtSigtimedwait,
+		unix.SYS_RT_SIGQUEUEINFO:        SysRtSigqueueinfo,
+		unix.SYS_RT_SIGRETURN:           SysRtSigreturn,
+		unix.SYS_SETPRIORITY:            SysSetpriority,
+		unix.SYS_GETPRIORITY:            SysGetpriority,
+		unix.SYS_REBOOT:                 SysReboot,
+		unix.SYS_SETREGID:               SysSetregid,
+		unix.SYS_SETGID:                 SysSetgid,
+		unix.SYS_SETREUID:               SysSetreuid,
+		unix.SYS_SETUID:                 SysSetuid,
+		unix.SYS_SETRESUID:              SysSetresuid,
+		unix.SYS_GETRESUID:              SysGetresuid,
+		unix.SYS_SETRESGID:              SysSetresgid,
+		unix.SYS_GETRESGID:              SysGetresgid,
+		unix.SYS_SETFSUID:               SysSetfsuid,
+		unix.SYS_SETFSGID:               SysSetfsgid,
+		unix.SYS_TIMES:                  SysTimes,
+		unix.SYS_SETPGID:                SysSetpgid,
+		unix.SYS_GETPGID:                SysGetpgid,
+		unix.SYS_GETSID:                 SysGetsid,
+		unix.SYS_SETSID:                 SysSetsid,
+		unix.SYS_GETGROUPS:              SysGetgroups,
+		unix.SYS_SETGROUPS:              SysSetgroups,
+		unix.SYS_UNAME:                  SysUname,
+		unix.SYS_SETHOSTNAME:            SysSethostname,
+		unix.SYS_SETDOMAINNAME:          SysSetdomainname,
+		unix.SYS_GETRLIMIT:              SysGetrlimit,
+		unix.SYS_SETRLIMIT:              SysSetrlimit,
+		unix.SYS_GETRUSAGE:              SysGetrusage,
+		unix.SYS_UMASK:                  SysUmask,
+		unix.SYS_PRCTL:                  SysPrctl,
+		unix.SYS_GETTIMEOFDAY:           SysGettimeofday,
+		unix.SYS_SETTIMEOFDAY:           SysSettimeofday,
+		unix.SYS_ADJTIMEX:               SysAdjtimex,
+		unix.SYS_GETPID:                 SysGetpid,
+		unix.SYS_GETPPID:                SysGetppid,
+		unix.SYS_GETUID:                 SysGetuid,
+		unix.SYS_GETEUID:                SysGeteuid,
+		unix.SYS_GETGID:                 SysGetgid,
+		unix.SYS_GETEGID:                SysGetegid,
+		unix.SYS_GETTID:                 SysGettid,
+		unix.SYS_SYSINFO:                SysSysinfo,
+		unix.SYS_MQ_OPEN:                SysMqOpen,
+		unix.SYS_MQ_UNLINK:              SysMqUnlink,
+		unix.SYS_MQ_TIMEDSEND:           SysMqTimedsend,
+		unix.SYS_MQ_TIMEDRECEIVE:        SysMqTimedreceive,
+		unix.SYS_MQ_NOTIFY:              SysMqNotify,
+		unix.SYS_MQ_GETSETATTR:          SysMqGetsetattr,
+		unix.SYS_MSGGET:                 SysMsgget,
+		unix.SYS_MSGCTL:                 SysMsgctl,
+		unix.SYS_MSGRCV:                 SysMsgrcv,
+		unix.SYS_MSGSND:                 SysMsgsnd,
+		unix.SYS_SEMGET:                 SysSemget,
+		unix.SYS_SEMCTL:                 SysSemctl,
+		unix.SYS_SEMTIMEDOP:             SysSemtimedop,
+		unix.SYS_SEMOP:                  SysSemop,
+		unix.SYS_SHMGET:                 SysShmget,
+		unix.SYS_SHMCTL:                 SysShmctl,
+		unix.SYS_SHMAT:                  SysShmat,
+		unix.SYS_SHMDT:                  SysShmdt,
+		unix.SYS_SOCKET:                 SysSocket,
+		unix.SYS_SOCKETPAIR:             SysSocketpair,
+		unix.SYS_BIND:                   SysBind,
+		unix.SYS_LISTEN:                 SysListen,
+		unix.SYS_ACCEPT:                 SysAccept,
+		unix.SYS_CONNECT:                SysConnect,
+		unix.SYS_GETSOCKNAME:            SysGetsockname,
+		unix.SYS_GETPEERNAME:            SysGetpeername,
+		unix.SYS_SENDTO:                 SysSendto,
+		unix.SYS_RECVFROM:               SysRecvfrom,
+		unix.SYS_SETSOCKOPT:             SysSetsockopt,
+		unix.SYS_GETSOCKOPT:             SysGetsockopt,
+		unix.SYS_SHUTDOWN:               SysShutdown,
+		unix.SYS_SENDMSG:                SysSendmsg,
+		unix.SYS_RECVMSG:                SysRecvmsg,
+		unix.SYS_READAHEAD:              SysReadahead,
+		unix.SYS_BRK:                    SysBrk,
+		unix.SYS_MUNMAP:                 SysMunmap,
+		unix.SYS_MREMAP:                 SysMremap,
+		unix.SYS_ADD_KEY:                SysAddKey,
+		unix.SYS_REQUEST_KEY:            SysRequestKey,
+		unix.SYS_KEYCTL:                 SysKeyctl,
+		unix.SYS_CLONE:                  SysClone,
+		unix.SYS_EXECVE:                 SysExecve,
+		unix.SYS_MMAP:                   SysMmap,
+		unix.SYS_FADVISE64:              SysFadvise64,
+		unix.SYS_SWAPON:                 SysSwapon,
+		unix.SYS_SWAPOFF:                SysSwapoff,
+		unix.SYS_MPROTECT:               SysMprotect,
+		unix.SYS_MSYNC:                  SysMsync,
+		unix.SYS_MLOCK:                  SysMlock,
+		unix.SYS_MUNLOCK:                SysMunlock,
+		unix.SYS_MLOCKALL:               SysMlockall,
+		unix.SYS_MUNLOCKALL:             SysMunlockall,
+		unix.SYS_MINCORE:                SysMincore,
+		unix.SYS_MADVISE:                SysMadvise,
+		unix.SYS_REMAP_FILE_PAGES:       SysRemapFilePages,
+		unix.SYS_MBIND:                  SysMbind,
+		unix.SYS_GET_MEMPOLICY:          SysGetMempolicy,
+		unix.SYS_SET_MEMPOLICY:          SysSetMempolicy,
+		unix.SYS_MIGRATE_PAGES:          SysMigratePages,
+		unix.SYS_MOVE_PAGES:             SysMovePages,
+		unix.SYS_RT_TGSIGQUEUEINFO:      SysRtTgsigqueueinfo,
+		unix.SYS_PERF_EVENT_OPEN:        SysPerfEventOpen,
+		unix.SYS_ACCEPT4:                SysAccept4,
+		unix.SYS_RECVMMSG:               SysRecvmmsg,
+		unix.SYS_WAIT4:                  SysWait4,
+		unix.SYS_PRLIMIT64:              SysPrlimit64,
+		unix.SYS_FANOTIFY_INIT:          SysFanotifyInit,
+		unix.SYS_FANOTIFY_MARK:          SysFanotifyMark,
+	}
+}
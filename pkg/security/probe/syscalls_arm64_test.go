@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestArm64Syscalls(t *testing.T) {
+	for nr, expected := range map[uint32]Syscall{
+		unix.SYS_OPENAT:    SysOpenat,
+		unix.SYS_EXECVE:    SysExecve,
+		unix.SYS_FSTATAT:   SysNewfstatat,
+		unix.SYS_PRLIMIT64: SysPrlimit64,
+	} {
+		if syscall, exists := newSyscall(nr); !exists || syscall != expected {
+			t.Errorf("expected %s for the syscall %d, got %s", expected, nr, syscall)
+		}
+	}
+
+	if _, exists := newSyscall(unix.SYS_RENAMEAT2); exists {
+		t.Errorf("expected renameat2 to be missing from the syscall identifiers")
+	}
+}
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func unlinkOnNewApprovers(probe *Probe, approvers rules.Approvers) error {
+	filenames := newFilenameApprovers(probe, FileUnlinkEventType)
+
+	for field, values := range approvers {
+		switch field {
+		case "unlink.basename":
+			filenames.addBasenames(stringValues(values)...)
+
+		case "unlink.filename":
+			filenames.addFilenames(stringValues(values)...)
+
+		default:
+			return errors.New("field unknown")
+		}
+	}
+
+	return filenames.apply("unlink_basename_approvers")
+}
@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+// userGroupRefreshPeriod is the period at which /etc/passwd and /etc/group are checked for changes
+const userGroupRefreshPeriod = 10 * time.Second
+
+// idFile holds the ids of the entries of /etc/passwd or /etc/group, by name and by id
+type idFile struct {
+	filename string
+	mtime    time.Time
+	ids      map[string]int
+	names    map[int]string
+}
+
+// refresh reads the file again if it changed since it was last read
+func (f *idFile) refresh() (bool, error) {
+	// read the file from the root of the host so that the agent can run in a container
+	filename := path.Join(utils.ProcRootPath(1), f.filename)
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return false, err
+	}
+
+	if info.ModTime().Equal(f.mtime) {
+		return false, nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	ids, names := make(map[string]int), make(map[int]string)
+
+	// the name and the id are the first and the third fields of both files, `root:x:0:0:root:/root:/bin/bash`
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		id, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+
+		ids[fields[0]] = id
+		if _, exists := names[id]; !exists {
+			names[id] = fields[0]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return false, errors.Wrapf(err, "failed to read %s", f.filename)
+	}
+
+	f.mtime, f.ids, f.names = info.ModTime(), ids, names
+
+	return true, nil
+}
+
+// UserGroupResolver resolves the users and the groups of the host, by name and by id
+type UserGroupResolver struct {
+	sync.RWMutex
+	users  idFile
+	groups idFile
+}
+
+// Refresh reads /etc/passwd and /etc/group again if they changed, it returns whether one of them changed
+func (r *UserGroupResolver) Refresh() (bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	usersChanged, err := r.users.refresh()
+	if err != nil {
+		return false, err
+	}
+
+	groupsChanged, err := r.groups.refresh()
+	if err != nil {
+		return usersChanged, err
+	}
+
+	return usersChanged || groupsChanged, nil
+}
+
+// ResolveUID returns the id of the given user
+func (r *UserGroupResolver) ResolveUID(name string) (int, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	uid, exists := r.users.ids[name]
+	if !exists {
+		return 0, errors.Errorf("unknown user `%s`", name)
+	}
+	return uid, nil
+}
+
+// ResolveGID returns the id of the given group
+func (r *UserGroupResolver) ResolveGID(name string) (int, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	gid, exists := r.groups.ids[name]
+	if !exists {
+		return 0, errors.Errorf("unknown group `%s`", name)
+	}
+	return gid, nil
+}
+
+// ResolveUser returns the name of the user of the given id, an empty string if unknown
+func (r *UserGroupResolver) ResolveUser(uid int) string {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.users.names[uid]
+}
+
+// ResolveGroup returns the name of the group of the given id, an empty string if unknown
+func (r *UserGroupResolver) ResolveGroup(gid int) string {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.groups.names[gid]
+}
+
+// isUserGroupField returns whether the values of the given field are user or group names, their approvers have to be
+// resolved to ids again when /etc/passwd or /etc/group change
+func isUserGroupField(field eval.Field) bool {
+	return strings.HasSuffix(field, ".user") || strings.HasSuffix(field, ".group")
+}
+
+// NewUserGroupResolver returns a new user and group resolver
+func NewUserGroupResolver() *UserGroupResolver {
+	return &UserGroupResolver{
+		users:  idFile{filename: "/etc/passwd"},
+		groups: idFile{filename: "/etc/group"},
+	}
+}
@@ -15,6 +15,8 @@ import (
 type RuleBucket struct {
 	rules  []*eval.Rule
 	fields []eval.Field
+	// filters holds the approvers hinted by the rules whose approvers can't be derived from their expressions
+	filters map[eval.RuleID]Approvers
 }
 
 // AddRule adds a rule to the bucket
@@ -70,31 +72,144 @@ func fieldCombinations(fields []eval.Field) FieldCombinations {
 	return result
 }
 
+// getRuleApprovers returns the approvers of a rule, the ones hinted by its filters if none can be derived from its
+// expression
+func getRuleApprovers(rule *eval.Rule, event eval.Event, fieldCaps FieldCapabilities, fcs FieldCombinations, filters Approvers) (Approvers, error) {
+	approvers, err := getExpressionApprovers(rule, event, fieldCaps, fcs)
+	if _, ok := err.(*ErrNoApprover); ok && len(filters) > 0 {
+		return getFilterApprovers(filters, fieldCaps)
+	}
+	return approvers, err
+}
+
+// getExpressionApprovers returns the approvers of the expression of a rule, trying the combinations of fields in order
+func getExpressionApprovers(rule *eval.Rule, event eval.Event, fieldCaps FieldCapabilities, fcs FieldCombinations) (Approvers, error) {
+	// the values of the variables are only known at evaluation time, no event can be filtered out
+	if len(rule.GetVariables()) > 0 {
+		return nil, &ErrNoApprover{Fields: fieldCaps.GetFields()}
+	}
+
+	// the elements of the iterable fields are only known at evaluation time, the truth table can't cover them while the
+	// partial evaluation considers that their comparisons may match
+	if len(rule.GetIterators()) > 0 {
+		return getPartialApprovers(rule, event, fieldCaps, fcs)
+	}
+
+	truthTable, err := newTruthTable(rule, event)
+	if err != nil {
+		// the regexps and the lists of some fields prevent the generation of the truth table, the approvers of
+		// the other fields can still be derived from the partial evaluation of the rule
+		if _, ok := err.(*ErrValueTypeUnknown); ok {
+			return getPartialApprovers(rule, event, fieldCaps, fcs)
+		}
+		return nil, err
+	}
+
+	var ruleApprovers map[eval.Field]FilterValues
+	for _, fields := range fcs {
+		ruleApprovers = truthTable.getApprovers(fields...)
+
+		// only one approver is currently required to ensure that the rule will be applied
+		// this could be improve by adding weight to use the most valuable one
+		if ruleApprovers != nil && len(ruleApprovers) > 0 && fieldCaps.Validate(ruleApprovers) {
+			break
+		}
+	}
+
+	// the truth table ignores the transformed fields, their conditions may leave no true entry
+	if ruleApprovers == nil || len(ruleApprovers) == 0 || !fieldCaps.Validate(ruleApprovers) {
+		return getPartialApprovers(rule, event, fieldCaps, fcs)
+	}
+
+	return ruleApprovers, nil
+}
+
+// partialEval returns the partial evaluation of a rule with the given fields, the ones of the single fields are
+// generated along with the rule
+func partialEval(rule *eval.Rule, fields []eval.Field) (func(ctx *eval.Context) bool, error) {
+	if len(fields) == 1 {
+		if evalFnc := rule.GetPartialEval(fields[0]); evalFnc != nil {
+			return evalFnc, nil
+		}
+	}
+	return rule.GenPartial(fields...)
+}
+
+// getPartialApprovers returns the approvers of a rule on the first combination of fields whose values can be derived
+// from the partial evaluation of the rule, the conditions on the other fields being assumed true. The values of a
+// combination are approvers only if the rule can't match when none of them does: a negated field can't be approved, but
+// it doesn't prevent the approval of the other fields of the combination, and the fields compared in a disjunction,
+// a basename or some flags for instance, are approved together
+func getPartialApprovers(rule *eval.Rule, event eval.Event, fieldCaps FieldCapabilities, fcs FieldCombinations) (Approvers, error) {
+	ctx := &eval.Context{}
+	ctx.SetObject(event.GetPointer())
+
+	// the fields the rule doesn't compare to static values, or to values whose approvers can't be derived, can't be
+	// approved
+	fieldValues := make(map[eval.Field]FilterValues)
+	for _, fieldCap := range fieldCaps {
+		fValues, exists := rule.GetEvaluator().FieldValues[fieldCap.Field]
+		if !exists || len(fValues) == 0 {
+			continue
+		}
+
+		values, err := genFieldFilterValues(fieldCap.Field, fValues, event)
+		if err != nil {
+			continue
+		}
+		fieldValues[fieldCap.Field] = values
+	}
+
+LOOP:
+	for _, fields := range fcs {
+		var filterValues []FilterValues
+		for _, field := range fields {
+			values, exists := fieldValues[field]
+			if !exists {
+				continue LOOP
+			}
+			filterValues = append(filterValues, values)
+		}
+
+		evalFnc, err := partialEval(rule, fields)
+		if err != nil {
+			continue
+		}
+
+		var truthTable truthTable
+		for _, combination := range combineFilterValues(filterValues) {
+			for _, value := range combination {
+				if err := event.SetFieldValue(value.Field, value.fieldValue()); err != nil {
+					continue LOOP
+				}
+			}
+
+			truthTable.Entries = append(truthTable.Entries, truthEntry{
+				Values: combination,
+				Result: evalFnc(ctx),
+			})
+		}
+
+		ruleApprovers := truthTable.getApprovers(fields...)
+		if len(ruleApprovers) > 0 && fieldCaps.Validate(ruleApprovers) {
+			return ruleApprovers, nil
+		}
+	}
+
+	return nil, &ErrNoApprover{Fields: fieldCaps.GetFields()}
+}
+
 // GetApprovers returns the approvers for an event
 func (rb *RuleBucket) GetApprovers(event eval.Event, fieldCaps FieldCapabilities) (Approvers, error) {
 	fcs := fieldCombinations(fieldCaps.GetFields())
 
 	approvers := make(Approvers)
 	for _, rule := range rb.rules {
-		truthTable, err := newTruthTable(rule, event)
+		ruleApprovers, err := getRuleApprovers(rule, event, fieldCaps, fcs, rb.filters[rule.ID])
 		if err != nil {
 			return nil, err
 		}
 
-		var ruleApprovers map[eval.Field]FilterValues
-		for _, fields := range fcs {
-			ruleApprovers = truthTable.getApprovers(fields...)
-
-			// only one approver is currently required to ensure that the rule will be applied
-			// this could be improve by adding weight to use the most valuable one
-			if ruleApprovers != nil && len(ruleApprovers) > 0 && fieldCaps.Validate(ruleApprovers) {
-				break
-			}
-		}
-
-		if ruleApprovers == nil || len(ruleApprovers) == 0 || !fieldCaps.Validate(ruleApprovers) {
-			return nil, &ErrNoApprover{Fields: fieldCaps.GetFields()}
-		}
 		for field, values := range ruleApprovers {
 			approvers[field] = approvers[field].Merge(values)
 		}
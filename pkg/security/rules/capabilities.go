@@ -10,10 +10,12 @@ import "github.com/DataDog/datadog-agent/pkg/security/secl/eval"
 // FieldCapabilities holds a list of field capabilities
 type FieldCapabilities []FieldCapability
 
-// FieldCapability represents a field and the type of its value (scalar, pattern, bitmask, ...)
+// FieldCapability represents a field and the type of its value (scalar, pattern, bitmask, ...). ValidateFnc, when set,
+// rejects the values of the supported types that can't be used as approvers
 type FieldCapability struct {
-	Field eval.Field
-	Types eval.FieldValueType
+	Field       eval.Field
+	Types       eval.FieldValueType
+	ValidateFnc func(value FilterValue) bool
 }
 
 // GetFields returns all the fields of FieldCapabilities
@@ -37,6 +39,10 @@ func (fcs FieldCapabilities) Validate(approvers map[eval.Field]FilterValues) boo
 			if value.Type&fc.Types == 0 {
 				return false
 			}
+
+			if fc.ValidateFnc != nil && !fc.ValidateFnc(value) {
+				return false
+			}
 		}
 	}
 
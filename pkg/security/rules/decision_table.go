@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// ErrNoDecisionTable is returned when the rules of an event type can't be pre-evaluated with the given fields
+type ErrNoDecisionTable struct {
+	RuleID eval.RuleID
+	Reason string
+}
+
+func (e ErrNoDecisionTable) Error() string {
+	return fmt.Sprintf("no decision table for rule `%s`: %s", e.RuleID, e.Reason)
+}
+
+// DecisionTable holds the combinations of field values for which the rules of an event type may match, an event
+// matching none of them can't match any rule. Each entry is the conjunction of its values, the values whose type isn't
+// supported by the capabilities of their field are left out so that an entry matches at least all the events its rule
+// matches
+type DecisionTable []FilterValues
+
+func (dt DecisionTable) contains(entry FilterValues) bool {
+LOOP:
+	for _, e := range dt {
+		if len(e) != len(entry) {
+			continue
+		}
+		for i := range e {
+			if e[i].Field != entry[i].Field || e[i].Value != entry[i].Value || e[i].Type != entry[i].Type {
+				continue LOOP
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// getDecisionEntries returns the entries of the decision table of a rule, from the true entries of its truth table
+func getDecisionEntries(rule *eval.Rule, event eval.Event, fieldCaps FieldCapabilities) (DecisionTable, error) {
+	types := make(map[eval.Field]eval.FieldValueType)
+	for _, fc := range fieldCaps {
+		types[fc.Field] = fc.Types
+	}
+
+	for _, field := range rule.GetFields() {
+		if _, exists := types[field]; !exists {
+			return nil, ErrNoDecisionTable{RuleID: rule.ID, Reason: fmt.Sprintf("field `%s` not available", field)}
+		}
+	}
+
+	// the values of the variables and the elements of the iterable fields are only known at evaluation time
+	if len(rule.GetVariables()) > 0 || len(rule.GetIterators()) > 0 {
+		return nil, ErrNoDecisionTable{RuleID: rule.ID, Reason: "values only known at evaluation time"}
+	}
+
+	truthTable, err := newTruthTable(rule, event)
+	if err != nil {
+		return nil, ErrNoDecisionTable{RuleID: rule.ID, Reason: err.Error()}
+	}
+	if truthTable == nil {
+		return nil, ErrNoDecisionTable{RuleID: rule.ID, Reason: "no field value"}
+	}
+
+	var table DecisionTable
+	for _, truthEntry := range truthTable.Entries {
+		if !truthEntry.Result {
+			continue
+		}
+
+		// the values different from all the ones of the rule match any value in kernel
+		var entry FilterValues
+		for _, value := range truthEntry.Values {
+			if !value.ignore && !value.Not && value.Type&types[value.Field] != 0 {
+				entry = append(entry, value)
+			}
+		}
+		sort.Slice(entry, func(i, j int) bool { return entry[i].Field < entry[j].Field })
+
+		if !table.contains(entry) {
+			table = append(table, entry)
+		}
+	}
+
+	// the truth table ignores the transformed fields, their conditions may leave no true entry
+	if len(table) == 0 {
+		return nil, ErrNoDecisionTable{RuleID: rule.ID, Reason: "no matching entry"}
+	}
+
+	return table, nil
+}
+
+// GetDecisionTable returns the decision table of the rules of the given event type, it fails when a rule tests a field
+// outside of the given capabilities or when its truth table can't be generated
+func (rs *RuleSet) GetDecisionTable(eventType eval.EventType, fieldCaps FieldCapabilities) (DecisionTable, error) {
+	bucket, exists := rs.eventRuleBuckets[eventType]
+	if !exists {
+		return nil, ErrNoEventTypeBucket{EventType: eventType}
+	}
+
+	var table DecisionTable
+	for _, rule := range bucket.rules {
+		entries, err := getDecisionEntries(rule, rs.eventCtor(), fieldCaps)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !table.contains(entry) {
+				table = append(table, entry)
+			}
+		}
+	}
+
+	return table, nil
+}
@@ -24,6 +24,15 @@ type FilterValue struct {
 	ignore bool
 }
 
+// fieldValue returns the value the field is set to when evaluating a rule with the filter value, the lower bound of a
+// range for instance
+func (fv FilterValue) fieldValue() interface{} {
+	if r, ok := fv.Value.(eval.IntRange); ok {
+		return r.Min
+	}
+	return fv.Value
+}
+
 // Merge merges to FilterValues ensuring there is no duplicate value
 func (fv FilterValues) Merge(n FilterValues) FilterValues {
 LOOP:
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// FilterDefinitions holds the values of the fields of the events a rule can match, `open.basename: [passwd, shadow]`
+// for instance. They are used as the approvers of the rule when none can be derived from its expression, the values
+// of the integer fields can be constant names
+type FilterDefinitions map[eval.Field][]interface{}
+
+// newFilters returns the approvers hinted by the filters of the given rule, their fields should belong to the event
+// type of the rule
+func (rs *RuleSet) newFilters(rule *eval.Rule, defs FilterDefinitions) (Approvers, error) {
+	event := rs.eventCtor()
+
+	approvers := make(Approvers)
+	for field, values := range defs {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("no value for field `%s`", field)
+		}
+
+		eventType, err := event.GetFieldEventType(field)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, ruleEventType := range rule.GetEventTypes() {
+			if eventType == ruleEventType {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("the field `%s` doesn't belong to the event type of the rule", field)
+		}
+
+		for _, value := range values {
+			value, err := rs.resolveFieldValue(event, field, value)
+			if err != nil {
+				return nil, err
+			}
+
+			approvers[field] = approvers[field].Merge(FilterValues{{Field: field, Value: value, Type: eval.ScalarValueType}})
+		}
+	}
+
+	return approvers, nil
+}
+
+// getFilterApprovers returns the approvers hinted by the filters of a rule. All the fields of the filters should be
+// supported, the events of a field left out would otherwise be filtered out
+func getFilterApprovers(filters Approvers, fieldCaps FieldCapabilities) (Approvers, error) {
+	for field := range filters {
+		found := false
+		for _, fc := range fieldCaps {
+			if fc.Field == field {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, &ErrNoApprover{Fields: fieldCaps.GetFields()}
+		}
+	}
+
+	if !fieldCaps.Validate(filters) {
+		return nil, &ErrNoApprover{Fields: fieldCaps.GetFields()}
+	}
+
+	return filters, nil
+}
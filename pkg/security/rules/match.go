@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// resolveConstant returns the value of an integer field given as constant names, `O_CREAT|O_RDWR` for instance
+func (rs *RuleSet) resolveConstant(value string) (int, error) {
+	var result int
+	for _, name := range strings.Split(value, "|") {
+		name = strings.TrimSpace(name)
+
+		evaluator, ok := rs.opts.Constants[name].(*eval.IntEvaluator)
+		if !ok {
+			return 0, fmt.Errorf("unknown constant `%s`", name)
+		}
+		result |= evaluator.Value
+	}
+	return result, nil
+}
+
+// resolveFieldValue returns the value of the given field of the event, the values of the integer fields can be
+// constant names
+func (rs *RuleSet) resolveFieldValue(event eval.Event, field eval.Field, value interface{}) (interface{}, error) {
+	kind, err := event.GetFieldType(field)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case string:
+		if kind == reflect.Int {
+			if value, err = rs.resolveConstant(v); err != nil {
+				return nil, errors.Wrapf(err, "invalid value for field `%s`", field)
+			}
+		}
+	case int:
+	case bool:
+	default:
+		return nil, fmt.Errorf("unsupported value `%v` for field `%s`", value, field)
+	}
+
+	if reflect.TypeOf(value).Kind() != kind {
+		return nil, &eval.ErrValueTypeMismatch{Field: field}
+	}
+
+	return value, nil
+}
+
+// newEventFromFields returns an event whose fields are set to the given values
+func (rs *RuleSet) newEventFromFields(fields map[eval.Field]interface{}) (eval.Event, error) {
+	event := rs.eventCtor()
+
+	for field, value := range fields {
+		value, err := rs.resolveFieldValue(event, field, value)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := event.SetFieldValue(field, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return event, nil
+}
+
+// getFieldsEventType returns the type of the event the given fields belong to
+func (rs *RuleSet) getFieldsEventType(fields map[eval.Field]interface{}) (eval.EventType, error) {
+	event := rs.eventCtor()
+
+	var eventType eval.EventType
+	for field := range fields {
+		fieldEventType, err := event.GetFieldEventType(field)
+		if err != nil {
+			return "", err
+		}
+
+		if fieldEventType == "*" || fieldEventType == eventType {
+			continue
+		}
+
+		if eventType != "" {
+			return "", fmt.Errorf("fields of both `%s` and `%s` events", eventType, fieldEventType)
+		}
+		eventType = fieldEventType
+	}
+
+	if eventType == "" {
+		return "", errors.New("no event type, none of the fields is specific to an event type")
+	}
+
+	return eventType, nil
+}
+
+// MatchEvent builds an event from the values of its fields and returns the sorted IDs of the rules matching it. The
+// event type is the one of its fields if none is given, and the values of the integer fields can be constant names,
+// `O_CREAT|O_RDWR` for instance. Only the expressions and the suppressions of the rules are evaluated: their scopes,
+// schedules, thresholds, sequences and requirements are ignored, their actions are not executed and the listeners
+// are not notified
+func (rs *RuleSet) MatchEvent(eventType eval.EventType, fields map[eval.Field]interface{}) ([]eval.RuleID, error) {
+	if eventType == "" {
+		var err error
+		if eventType, err = rs.getFieldsEventType(fields); err != nil {
+			return nil, err
+		}
+	}
+
+	event, err := rs.newEventFromFields(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &eval.Context{}
+	ctx.SetObject(event.GetPointer())
+
+	var matches []eval.RuleID
+	if bucket, exists := rs.eventRuleBuckets[eventType]; exists {
+		for _, rule := range bucket.rules {
+			if rule.GetEvaluator().Eval(ctx) && rs.suppressedBy(rule.ID, ctx) == nil {
+				matches = append(matches, rule.ID)
+			}
+		}
+	}
+	sort.Strings(matches)
+
+	return matches, nil
+}
@@ -7,6 +7,7 @@ package rules
 
 import (
 	"reflect"
+	"strings"
 	"syscall"
 	"unsafe"
 
@@ -36,14 +37,35 @@ type testEvent struct {
 	id   string
 	kind string
 
-	process testProcess
-	open    testOpen
-	mkdir   testMkdir
+	process   testProcess
+	ancestors []testProcess
+	open      testOpen
+	mkdir     testMkdir
 }
 
 type testModel struct {
 }
 
+type testProcessAncestorsIterator struct {
+	ancestors []testProcess
+	index     int
+	ancestor  testEvent
+}
+
+func (it *testProcessAncestorsIterator) Front(ctx *eval.Context) unsafe.Pointer {
+	it.ancestors = (*testEvent)(ctx.Object).ancestors
+	it.index = -1
+	return it.Next()
+}
+
+func (it *testProcessAncestorsIterator) Next() unsafe.Pointer {
+	if it.index++; it.index >= len(it.ancestors) {
+		return nil
+	}
+	it.ancestor.process = it.ancestors[it.index]
+	return unsafe.Pointer(&it.ancestor)
+}
+
 func (e *testEvent) GetType() string {
 	return e.kind
 }
@@ -56,12 +78,29 @@ func (m *testModel) NewEvent() eval.Event {
 	return &testEvent{}
 }
 
+func (m *testModel) GetIterable(key string) (string, string, bool) {
+	if strings.HasPrefix(key, "process.ancestors.") {
+		return "process.ancestors", "process." + strings.TrimPrefix(key, "process.ancestors."), true
+	}
+	return "", "", false
+}
+
+func (m *testModel) NewIterator(iterable string) (eval.Iterator, error) {
+	if iterable == "process.ancestors" {
+		return &testProcessAncestorsIterator{}, nil
+	}
+	return nil, &eval.ErrFieldNotFound{Field: iterable}
+}
+
 func (m *testModel) ValidateField(key string, value eval.FieldValue) error {
 	switch key {
 
 	case "process.uid":
 
 		uid, ok := value.Value.(int)
+		if r, isRange := value.Value.(eval.IntRange); isRange {
+			uid, ok = r.Min, true
+		}
 		if !ok {
 			return errors.New("invalid type for process.ui")
 		}
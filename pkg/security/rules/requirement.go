@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// RequirementDefinition holds the rule that has to fire before the rule defining the requirement is armed. The rule
+// stays armed during the given period after each match of the required rule. With a scope, process or container for
+// instance, the required rule has to match an event of the same entry of the scope. A chain of requirements is a
+// state machine, each rule being a stage armed by the previous one
+//
+// A requirement is either a structure or its short form: `rule_id within 5m`, `rule_id within 5m per process`
+type RequirementDefinition struct {
+	Rule   RuleID        `yaml:"rule"`
+	Within time.Duration `yaml:"within"`
+	Scope  string        `yaml:"scope"`
+}
+
+// UnmarshalYAML unmarshals a requirement from either its structure or its short form
+func (rd *RequirementDefinition) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var short string
+	if err := unmarshal(&short); err != nil {
+		type requirementDefinition RequirementDefinition
+		return unmarshal((*requirementDefinition)(rd))
+	}
+
+	def, err := parseRequirement(short)
+	if err != nil {
+		return err
+	}
+	*rd = *def
+
+	return nil
+}
+
+// parseRequirement parses the short form of a requirement, `rule_id within 5m per process` for instance
+func parseRequirement(s string) (*RequirementDefinition, error) {
+	fields := strings.Fields(s)
+	if (len(fields) != 3 && len(fields) != 5) || fields[1] != "within" || (len(fields) == 5 && fields[3] != "per") {
+		return nil, fmt.Errorf("invalid requirement `%s`, expected `rule_id within period [per scope]`", s)
+	}
+
+	within, err := time.ParseDuration(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid period for the requirement `%s`: %s", s, err)
+	}
+
+	def := &RequirementDefinition{
+		Rule:   fields[0],
+		Within: within,
+	}
+
+	if len(fields) == 5 {
+		def.Scope = fields[4]
+	}
+
+	return def, nil
+}
+
+// requirement tracks the matches of the required rule, per entry of its scope
+type requirement struct {
+	rule   RuleID
+	within time.Duration
+	scope  VariableScope
+	// matches holds the time of the last match of the required rule per entry of the scope, the least recently
+	// active entries are dropped first
+	matches *lru.Cache
+}
+
+func newRequirement(def *RequirementDefinition, scope VariableScope) (*requirement, error) {
+	matches, err := lru.New(maxScopeEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &requirement{
+		rule:    def.Rule,
+		within:  def.Within,
+		scope:   scope,
+		matches: matches,
+	}, nil
+}
+
+func (r *requirement) key(ctx *eval.Context) interface{} {
+	if r.scope == nil {
+		return globalScopeKey{}
+	}
+	return r.scope(ctx)
+}
+
+// record records a match of the required rule
+func (r *requirement) record(ctx *eval.Context, now time.Time) {
+	if key := r.key(ctx); key != nil {
+		r.matches.Add(key, now)
+	}
+}
+
+// armed returns true if the required rule matched within the period for the entry of the event
+func (r *requirement) armed(ctx *eval.Context, now time.Time) bool {
+	key := r.key(ctx)
+	if key == nil {
+		return false
+	}
+
+	value, found := r.matches.Get(key)
+	if !found {
+		return false
+	}
+
+	if now.Sub(value.(time.Time)) > r.within {
+		r.matches.Remove(key)
+		return false
+	}
+
+	return true
+}
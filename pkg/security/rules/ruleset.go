@@ -7,6 +7,10 @@ package rules
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
@@ -18,23 +22,101 @@ import (
 // MacroID represents the ID of a macro
 type MacroID = string
 
-// MacroDefinition holds the definition of a macro
+// MacroDefinition holds the definition of a macro. The parameters of a macro are referenced as identifiers in its
+// expression, and bound to the arguments of each call of the macro
 type MacroDefinition struct {
-	ID         MacroID `yaml:"id"`
-	Expression string  `yaml:"expression"`
+	ID         MacroID  `yaml:"id"`
+	Expression string   `yaml:"expression"`
+	Parameters []string `yaml:"parameters"`
+}
+
+// ListDefinition holds the definition of a list, its values are either listed in the definition or read from a
+// file, one value per line
+type ListDefinition struct {
+	ID     eval.ListID `yaml:"id"`
+	Values []string    `yaml:"values"`
+	File   string      `yaml:"file"`
 }
 
 // RuleID represents the ID of a rule
 type RuleID = string
 
-// RuleDefinition holds the definition of a rule
+// RuleMode represents the mode of a rule
+type RuleMode = string
+
+// RuleModeSimulation is the mode of the rules being trialed: their matches are counted but they raise no signal and
+// don't kill processes, only a sample of their events is sent, tagged as simulated
+const RuleModeSimulation RuleMode = "simulation"
+
+// RuleDefinition holds the definition of a rule. A rule is enabled unless explicitly disabled
 type RuleDefinition struct {
-	ID         RuleID            `yaml:"id"`
-	Expression string            `yaml:"expression"`
-	Tags       map[string]string `yaml:"tags"`
+	ID         RuleID               `yaml:"id"`
+	Version    string               `yaml:"version"`
+	Enabled    *bool                `yaml:"enabled"`
+	Mode       RuleMode             `yaml:"mode"`
+	Expression string               `yaml:"expression"`
+	Tags       map[string]string    `yaml:"tags"`
+	Severity   string               `yaml:"severity"`
+	Actions    []*ActionDefinition  `yaml:"actions"`
+	RateLimit  *RateLimitDefinition `yaml:"rate_limit"`
+	Schedule   *ScheduleDefinition  `yaml:"schedule"`
+	Threshold  *ThresholdDefinition `yaml:"threshold"`
+	Sequence   *SequenceDefinition  `yaml:"sequence"`
+	// Requires arms the rule only after another rule fired, `rule_id within 5m per process` for instance
+	Requires *RequirementDefinition `yaml:"requires"`
+	// Suppressions lists the exceptions of the rule, a match of the rule is ignored when one of them matches
+	Suppressions []*SuppressionDefinition `yaml:"suppressions"`
+	// Scope restricts the rule to the workloads it selects, the rule applies to all of them if it has none
+	Scope *ScopeDefinition `yaml:"scope"`
+	// Filters hints the approvers of the rule when none can be derived from its expression, the events of its event
+	// type being otherwise all sent by the kernel
+	Filters FilterDefinitions `yaml:"filters"`
+}
+
+// RateLimitDefinition holds the maximum number of events sent for a rule per period. With a scope, process or
+// container for instance, the limit applies to each entry of the scope
+type RateLimitDefinition struct {
+	Limit  int           `yaml:"limit"`
+	Period time.Duration `yaml:"period"`
+	Scope  string        `yaml:"scope"`
+}
+
+// ActionDefinition holds the definition of an action executed when a rule matches
+type ActionDefinition struct {
+	Set *SetDefinition `yaml:"set"`
+	// Kill is the name of the signal sent to the process of the matching event, SIGKILL for instance
+	Kill string `yaml:"kill"`
+}
+
+// KillSignals lists the names of the signals the kill action of a rule can send
+var KillSignals = map[string]bool{
+	"SIGKILL": true,
+	"SIGTERM": true,
+	"SIGINT":  true,
+	"SIGQUIT": true,
+	"SIGSTOP": true,
+}
+
+// SetDefinition holds the definition of an action setting a variable. The variable is set for the entry of the
+// scope, a process or a container for instance, of the matching event, and can be referenced by rules as
+// `${scope.name}`
+type SetDefinition struct {
+	Name  string      `yaml:"name"`
+	Value interface{} `yaml:"value"`
+	Scope string      `yaml:"scope"`
+}
+
+// IsEnabled returns whether the rule is enabled
+func (rd *RuleDefinition) IsEnabled() bool {
+	return rd.Enabled == nil || *rd.Enabled
 }
 
-// GetTags returns the tags associated to a rule
+// IsSimulated returns whether the rule is in simulation mode
+func (rd *RuleDefinition) IsSimulated() bool {
+	return rd.Mode == RuleModeSimulation
+}
+
+// GetTags returns the tags associated to a rule, sorted so that the tags of the events of a rule are always the same
 func (rd *RuleDefinition) GetTags() []string {
 	tags := []string{}
 	for k, v := range rd.Tags {
@@ -42,6 +124,7 @@ func (rd *RuleDefinition) GetTags() []string {
 			tags,
 			fmt.Sprintf("%s:%s", k, v))
 	}
+	sort.Strings(tags)
 	return tags
 }
 
@@ -56,6 +139,9 @@ type RuleSetListener interface {
 type Opts struct {
 	eval.Opts
 	SupportedDiscarders map[eval.Field]bool
+	VariableScopes      map[string]VariableScope
+	// Workload resolves the workload of the events, required by the rules having a scope
+	Workload WorkloadResolver
 }
 
 // NewOptsWithParams initializes a new Opts instance with Debug and Constants parameters
@@ -64,8 +150,11 @@ func NewOptsWithParams(constants map[string]interface{}, supportedDiscarders map
 		Opts: eval.Opts{
 			Constants: constants,
 			Macros:    make(map[eval.MacroID]*eval.Macro),
+			Lists:     make(map[eval.ListID]*eval.List),
+			Variables: make(map[string]eval.VariableValue),
 		},
 		SupportedDiscarders: supportedDiscarders,
+		VariableScopes:      make(map[string]VariableScope),
 	}
 }
 
@@ -78,6 +167,21 @@ type RuleSet struct {
 	model            eval.Model
 	eventCtor        func() eval.Event
 	listeners        []RuleSetListener
+	listDefinitions  map[eval.ListID]*ListDefinition
+	scopedVariables  map[string]*scopedVariables
+	ruleDefinitions  map[eval.RuleID]*RuleDefinition
+	severities       map[eval.RuleID]Severity
+	schedules        map[eval.RuleID]*schedule
+	thresholds       map[eval.RuleID]*threshold
+	sequences        map[eval.RuleID]*sequence
+	// stepSequences holds the sequences each rule is a step of
+	stepSequences map[eval.RuleID][]*sequence
+	suppressions  map[eval.RuleID][]*suppression
+	requirements  map[eval.RuleID]*requirement
+	// requiredBy holds the requirements each rule is the required rule of
+	requiredBy map[eval.RuleID][]*requirement
+	scopes     map[eval.RuleID]*workloadScope
+	now        func() time.Time
 	// fields holds the list of event field queries (like "process.uid") used by the entire set of rules
 	fields []string
 }
@@ -91,6 +195,191 @@ func (rs *RuleSet) ListRuleIDs() []string {
 	return ids
 }
 
+// AddList adds a list to the ruleset, with the given values
+func (rs *RuleSet) AddList(listDef *ListDefinition, values []string) error {
+	if _, exists := rs.opts.Lists[listDef.ID]; exists {
+		return fmt.Errorf("found multiple definition of the list '%s'", listDef.ID)
+	}
+
+	rs.opts.Lists[listDef.ID] = eval.NewList(listDef.ID, values)
+	rs.listDefinitions[listDef.ID] = listDef
+
+	return nil
+}
+
+// UpdateList replaces the values of a list, the rules referencing it don't need to be added again
+func (rs *RuleSet) UpdateList(id eval.ListID, values []string) error {
+	list, exists := rs.opts.Lists[id]
+	if !exists {
+		return fmt.Errorf("unknown list '%s'", id)
+	}
+
+	list.SetValues(values)
+
+	return nil
+}
+
+// GetListDefinitions returns the definitions of the lists of the ruleset
+func (rs *RuleSet) GetListDefinitions() []*ListDefinition {
+	var listDefs []*ListDefinition
+	for _, listDef := range rs.listDefinitions {
+		listDefs = append(listDefs, listDef)
+	}
+	return listDefs
+}
+
+// addVariable declares the variable set by an action so that rules can reference it
+func (rs *RuleSet) addVariable(setDef *SetDefinition) error {
+	if setDef.Name == "" {
+		return errors.New("variable has no name")
+	}
+
+	name := setDef.Scope + "." + setDef.Name
+	if variable, exists := rs.opts.Variables[name]; exists {
+		if !sameVariableType(variable, setDef.Value) {
+			return fmt.Errorf("conflicting types for the variable '%s'", name)
+		}
+		return nil
+	}
+
+	scoped, exists := rs.scopedVariables[setDef.Scope]
+	if !exists {
+		scope, exists := rs.opts.VariableScopes[setDef.Scope]
+		if !exists {
+			return fmt.Errorf("unknown scope '%s' for the variable '%s'", setDef.Scope, setDef.Name)
+		}
+
+		var err error
+		if scoped, err = newScopedVariables(scope); err != nil {
+			return err
+		}
+		rs.scopedVariables[setDef.Scope] = scoped
+	}
+
+	variable, err := scoped.newVariable(setDef.Name, setDef.Value)
+	if err != nil {
+		return err
+	}
+	rs.opts.Variables[name] = variable
+
+	return nil
+}
+
+// addActionVariables declares the variables set by the actions of a rule
+func (rs *RuleSet) addActionVariables(ruleDef *RuleDefinition) error {
+	for _, actionDef := range ruleDef.Actions {
+		if actionDef.Set != nil {
+			if err := rs.addVariable(actionDef.Set); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReleaseScopeEntry drops the variables set for the given entry of a scope, a process that exited for instance, so
+// that a new entry with the same key doesn't inherit them
+func (rs *RuleSet) ReleaseScopeEntry(scope string, key interface{}) {
+	if scoped, exists := rs.scopedVariables[scope]; exists {
+		scoped.release(key)
+	}
+}
+
+// InheritState takes over the state of the rules of the previous ruleset whose ID and expression didn't change: the
+// matches counted by their thresholds, the progress of their sequences and the matches recorded for their requirements,
+// as long as these are defined the same way. It returns the IDs of these rules. The ruleset shouldn't evaluate any
+// event yet, the previous one can
+func (rs *RuleSet) InheritState(previous *RuleSet) []eval.RuleID {
+	var unchanged []eval.RuleID
+	for id, ruleDef := range rs.ruleDefinitions {
+		previousDef, exists := previous.ruleDefinitions[id]
+		if !exists || previousDef.Expression != ruleDef.Expression {
+			continue
+		}
+		unchanged = append(unchanged, id)
+
+		if t, exists := previous.thresholds[id]; exists && reflect.DeepEqual(previousDef.Threshold, ruleDef.Threshold) {
+			rs.thresholds[id] = t
+		}
+
+		if s, exists := previous.sequences[id]; exists && reflect.DeepEqual(previousDef.Sequence, ruleDef.Sequence) {
+			for _, step := range s.steps {
+				replaceSequence(rs.stepSequences[step], rs.sequences[id], s)
+			}
+			rs.sequences[id] = s
+		}
+
+		if r, exists := previous.requirements[id]; exists && reflect.DeepEqual(previousDef.Requires, ruleDef.Requires) {
+			replaceRequirement(rs.requiredBy[r.rule], rs.requirements[id], r)
+			rs.requirements[id] = r
+		}
+	}
+	sort.Strings(unchanged)
+
+	return unchanged
+}
+
+func replaceSequence(sequences []*sequence, old *sequence, new *sequence) {
+	for i, s := range sequences {
+		if s == old {
+			sequences[i] = new
+		}
+	}
+}
+
+func replaceRequirement(requirements []*requirement, old *requirement, new *requirement) {
+	for i, r := range requirements {
+		if r == old {
+			requirements[i] = new
+		}
+	}
+}
+
+// GetRuleDefinition returns the definition of the given rule
+func (rs *RuleSet) GetRuleDefinition(id eval.RuleID) *RuleDefinition {
+	return rs.ruleDefinitions[id]
+}
+
+// GetActions returns the actions of the given rule
+func (rs *RuleSet) GetActions(id eval.RuleID) []*ActionDefinition {
+	if ruleDef, exists := rs.ruleDefinitions[id]; exists {
+		return ruleDef.Actions
+	}
+	return nil
+}
+
+// GetScopeKey returns the key of the entry of the given scope, a process or a container for instance, the event
+// belongs to. It returns nil if the scope is unknown or if the event doesn't belong to any entry of the scope
+func (rs *RuleSet) GetScopeKey(scope string, event eval.Event) interface{} {
+	scopeFnc, exists := rs.opts.VariableScopes[scope]
+	if !exists {
+		return nil
+	}
+
+	ctx := &eval.Context{}
+	ctx.SetObject(event.GetPointer())
+
+	return scopeFnc(ctx)
+}
+
+// GetSeverity returns the severity of the given rule
+func (rs *RuleSet) GetSeverity(id eval.RuleID) Severity {
+	if severity, exists := rs.severities[id]; exists {
+		return severity
+	}
+	return SeverityMedium
+}
+
+// runActions executes the actions of a rule matching the evaluated event. The actions acting on the system, like
+// kill, are left to the listeners of the ruleset
+func (rs *RuleSet) runActions(rule *eval.Rule, ctx *eval.Context) {
+	for _, actionDef := range rs.GetActions(rule.ID) {
+		if setDef := actionDef.Set; setDef != nil {
+			rs.scopedVariables[setDef.Scope].set(ctx, setDef.Name, setDef.Value)
+		}
+	}
+}
+
 // AddMacros parses the macros AST and adds them to the list of macros of the ruleset
 func (rs *RuleSet) AddMacros(macros []*MacroDefinition) error {
 	var result *multierror.Error
@@ -114,6 +403,7 @@ func (rs *RuleSet) AddMacro(macroDef *MacroDefinition) (*eval.Macro, error) {
 	macro := &eval.Macro{
 		ID:         macroDef.ID,
 		Expression: macroDef.Expression,
+		Parameters: macroDef.Parameters,
 	}
 
 	if err := macro.Parse(); err != nil {
@@ -129,16 +419,48 @@ func (rs *RuleSet) AddMacro(macroDef *MacroDefinition) (*eval.Macro, error) {
 	return macro, nil
 }
 
-// AddRules adds rules to the ruleset and generate their partials
+// AddRules adds rules to the ruleset and generate their partials, the disabled rules are ignored
 func (rs *RuleSet) AddRules(rules []*RuleDefinition) error {
 	var result *multierror.Error
 
+	var enabledRules []*RuleDefinition
 	for _, ruleDef := range rules {
+		if ruleDef.IsEnabled() {
+			enabledRules = append(enabledRules, ruleDef)
+		} else {
+			log.Debugf("rule %s is disabled", ruleDef.ID)
+		}
+	}
+
+	// declare the variables first so that a rule can reference a variable set by any other rule
+	for _, ruleDef := range enabledRules {
+		if err := rs.addActionVariables(ruleDef); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "couldn't add the actions of the rule %s to the ruleset", ruleDef.ID))
+		}
+	}
+
+	for _, ruleDef := range enabledRules {
 		if _, err := rs.AddRule(ruleDef); err != nil {
 			result = multierror.Append(result, errors.Wrapf(err, "couldn't add rule %s to the ruleset", ruleDef.ID))
 		}
 	}
 
+	// the rules of a sequence can be defined after the rule of the sequence
+	for id, ruleSequence := range rs.sequences {
+		for _, stepID := range ruleSequence.steps {
+			if _, exists := rs.rules[stepID]; !exists {
+				result = multierror.Append(result, fmt.Errorf("the sequence of the rule %s references an unknown rule '%s'", id, stepID))
+			}
+		}
+	}
+
+	// as well as the rules required by other rules
+	for id, ruleRequirement := range rs.requirements {
+		if _, exists := rs.rules[ruleRequirement.rule]; !exists {
+			result = multierror.Append(result, fmt.Errorf("the rule %s requires an unknown rule '%s'", id, ruleRequirement.rule))
+		}
+	}
+
 	if err := rs.generatePartials(); err != nil {
 		result = multierror.Append(result, errors.Wrap(err, "couldn't generate partials"))
 	}
@@ -152,15 +474,112 @@ func (rs *RuleSet) AddRule(ruleDef *RuleDefinition) (*eval.Rule, error) {
 		return nil, fmt.Errorf("found multiple definition of the rule '%s'", ruleDef.ID)
 	}
 
-	var tags []string
-	for k, v := range ruleDef.Tags {
-		tags = append(tags, k+":"+v)
+	severity, err := ParseSeverity(ruleDef.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	var ruleScope *workloadScope
+	if ruleDef.Scope != nil {
+		if rs.opts.Workload == nil {
+			return nil, errors.New("scopes are not supported, no workload resolver")
+		}
+		if ruleScope, err = newWorkloadScope(ruleDef.Scope); err != nil {
+			return nil, errors.Wrap(err, "invalid scope")
+		}
+	}
+
+	var ruleSchedule *schedule
+	if ruleDef.Schedule != nil {
+		if ruleSchedule, err = newSchedule(ruleDef.Schedule); err != nil {
+			return nil, errors.Wrap(err, "invalid schedule")
+		}
+	}
+
+	if rateLimit := ruleDef.RateLimit; rateLimit != nil {
+		if rateLimit.Limit <= 0 || rateLimit.Period <= 0 {
+			return nil, errors.New("the limit and the period of a rate limit should be positive")
+		}
+		if _, exists := rs.opts.VariableScopes[rateLimit.Scope]; rateLimit.Scope != "" && !exists {
+			return nil, fmt.Errorf("unknown scope '%s' for the rate limit", rateLimit.Scope)
+		}
+	}
+
+	var ruleThreshold *threshold
+	if thresholdDef := ruleDef.Threshold; thresholdDef != nil {
+		if thresholdDef.Count <= 0 || thresholdDef.Period <= 0 {
+			return nil, errors.New("the count and the period of a threshold should be positive")
+		}
+
+		var scope VariableScope
+		if thresholdDef.Scope != "" {
+			var exists bool
+			if scope, exists = rs.opts.VariableScopes[thresholdDef.Scope]; !exists {
+				return nil, fmt.Errorf("unknown scope '%s' for the threshold", thresholdDef.Scope)
+			}
+		}
+
+		if ruleThreshold, err = newThreshold(thresholdDef, scope); err != nil {
+			return nil, err
+		}
+	}
+
+	var ruleSequence *sequence
+	if sequenceDef := ruleDef.Sequence; sequenceDef != nil {
+		if len(sequenceDef.After) == 0 || sequenceDef.Within <= 0 {
+			return nil, errors.New("a sequence should have at least one rule and a positive period")
+		}
+
+		for _, id := range sequenceDef.After {
+			if id == ruleDef.ID {
+				return nil, errors.New("a sequence can't reference its own rule")
+			}
+		}
+
+		var scope VariableScope
+		if sequenceDef.Scope != "" {
+			var exists bool
+			if scope, exists = rs.opts.VariableScopes[sequenceDef.Scope]; !exists {
+				return nil, fmt.Errorf("unknown scope '%s' for the sequence", sequenceDef.Scope)
+			}
+		}
+
+		if ruleSequence, err = newSequence(ruleDef.ID, sequenceDef, scope); err != nil {
+			return nil, err
+		}
+	}
+
+	var ruleRequirement *requirement
+	if requirementDef := ruleDef.Requires; requirementDef != nil {
+		if requirementDef.Rule == "" || requirementDef.Within <= 0 {
+			return nil, errors.New("a requirement should have a rule and a positive period")
+		}
+
+		if requirementDef.Rule == ruleDef.ID {
+			return nil, errors.New("a rule can't require itself")
+		}
+
+		var scope VariableScope
+		if requirementDef.Scope != "" {
+			var exists bool
+			if scope, exists = rs.opts.VariableScopes[requirementDef.Scope]; !exists {
+				return nil, fmt.Errorf("unknown scope '%s' for the requirement", requirementDef.Scope)
+			}
+		}
+
+		if ruleRequirement, err = newRequirement(requirementDef, scope); err != nil {
+			return nil, err
+		}
 	}
 
 	rule := &eval.Rule{
 		ID:         ruleDef.ID,
 		Expression: ruleDef.Expression,
-		Tags:       tags,
+		Tags:       ruleDef.GetTags(),
+	}
+
+	if err := rs.addActionVariables(ruleDef); err != nil {
+		return nil, err
 	}
 
 	if err := rule.Parse(); err != nil {
@@ -171,6 +590,22 @@ func (rs *RuleSet) AddRule(ruleDef *RuleDefinition) (*eval.Rule, error) {
 		return nil, err
 	}
 
+	var ruleSuppressions []*suppression
+	for _, suppressionDef := range ruleDef.Suppressions {
+		s, err := rs.newSuppression(rule, suppressionDef)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid suppression `%s`", suppressionDef.ID)
+		}
+		ruleSuppressions = append(ruleSuppressions, s)
+	}
+
+	var ruleFilters Approvers
+	if len(ruleDef.Filters) > 0 {
+		if ruleFilters, err = rs.newFilters(rule, ruleDef.Filters); err != nil {
+			return nil, errors.Wrap(err, "invalid filters")
+		}
+	}
+
 	for _, event := range rule.GetEvaluator().EventTypes {
 		bucket, exists := rs.eventRuleBuckets[event]
 		if !exists {
@@ -181,6 +616,13 @@ func (rs *RuleSet) AddRule(ruleDef *RuleDefinition) (*eval.Rule, error) {
 		if err := bucket.AddRule(rule); err != nil {
 			return nil, err
 		}
+
+		if ruleFilters != nil {
+			if bucket.filters == nil {
+				bucket.filters = make(map[eval.RuleID]Approvers)
+			}
+			bucket.filters[rule.ID] = ruleFilters
+		}
 	}
 
 	if len(rule.GetEventTypes()) == 0 {
@@ -198,6 +640,30 @@ func (rs *RuleSet) AddRule(ruleDef *RuleDefinition) (*eval.Rule, error) {
 	rs.AddFields(rule.GetEvaluator().GetFields())
 
 	rs.rules[ruleDef.ID] = rule
+	rs.ruleDefinitions[ruleDef.ID] = ruleDef
+	rs.severities[ruleDef.ID] = severity
+	if ruleSchedule != nil {
+		rs.schedules[ruleDef.ID] = ruleSchedule
+	}
+	if ruleThreshold != nil {
+		rs.thresholds[ruleDef.ID] = ruleThreshold
+	}
+	if ruleSequence != nil {
+		rs.sequences[ruleDef.ID] = ruleSequence
+		for _, id := range ruleSequence.steps {
+			rs.stepSequences[id] = append(rs.stepSequences[id], ruleSequence)
+		}
+	}
+	if ruleRequirement != nil {
+		rs.requirements[ruleDef.ID] = ruleRequirement
+		rs.requiredBy[ruleRequirement.rule] = append(rs.requiredBy[ruleRequirement.rule], ruleRequirement)
+	}
+	if len(ruleSuppressions) > 0 {
+		rs.suppressions[ruleDef.ID] = ruleSuppressions
+	}
+	if ruleScope != nil {
+		rs.scopes[ruleDef.ID] = ruleScope
+	}
 
 	return rule, nil
 }
@@ -248,6 +714,23 @@ func (rs *RuleSet) GetApprovers(eventType eval.EventType, fieldCaps FieldCapabil
 	return bucket.GetApprovers(rs.eventCtor(), fieldCaps)
 }
 
+// GetRuleApprovers returns the approvers of the given rule alone
+func (rs *RuleSet) GetRuleApprovers(id eval.RuleID, fieldCaps FieldCapabilities) (Approvers, error) {
+	rule, exists := rs.rules[id]
+	if !exists {
+		return nil, fmt.Errorf("unknown rule '%s'", id)
+	}
+
+	var filters Approvers
+	for _, eventType := range rule.GetEventTypes() {
+		if bucket, exists := rs.eventRuleBuckets[eventType]; exists {
+			filters = bucket.filters[id]
+		}
+	}
+
+	return getRuleApprovers(rule, rs.eventCtor(), fieldCaps, fieldCombinations(fieldCaps.GetFields()), filters)
+}
+
 // GetFieldValues returns all the values of the given field
 func (rs *RuleSet) GetFieldValues(field eval.Field) []eval.FieldValue {
 	var values []eval.FieldValue
@@ -269,6 +752,12 @@ func (rs *RuleSet) IsDiscarder(event eval.Event, field eval.Field) (bool, error)
 		return false, err
 	}
 
+	return rs.IsDiscarderOfEventType(event, field, eventType)
+}
+
+// IsDiscarderOfEventType partially evaluates an Event against a field with the rules of the given event type, the
+// fields shared by all the event types, the process ones for instance, are discarders of a given event type
+func (rs *RuleSet) IsDiscarderOfEventType(event eval.Event, field eval.Field, eventType eval.EventType) (bool, error) {
 	bucket, exists := rs.eventRuleBuckets[eventType]
 	if !exists {
 		return false, &ErrNoEventTypeBucket{EventType: eventType}
@@ -286,6 +775,23 @@ func (rs *RuleSet) IsDiscarder(event eval.Event, field eval.Field) (bool, error)
 	return true, nil
 }
 
+// IsDiscarderOfAllEventTypes partially evaluates an Event against a field with the rules of all the event types, it
+// returns whether no rule, whatever its event type, can match the value of the field
+func (rs *RuleSet) IsDiscarderOfAllEventTypes(event eval.Event, field eval.Field) (bool, error) {
+	ctx := &eval.Context{}
+	ctx.SetObject(event.GetPointer())
+
+	for _, bucket := range rs.eventRuleBuckets {
+		for _, rule := range bucket.rules {
+			isTrue, err := rule.PartialEval(ctx, field)
+			if err != nil || isTrue {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
 // Evaluate the specified event against the set of rules
 func (rs *RuleSet) Evaluate(event eval.Event) bool {
 	ctx := &eval.Context{}
@@ -300,12 +806,61 @@ func (rs *RuleSet) Evaluate(event eval.Event) bool {
 	}
 	log.Tracef("Evaluating event of type `%s` against set of %d rules", eventType, len(bucket.rules))
 
+	// the workload of the event is only resolved if a scoped rule is evaluated
+	var workload *Workload
+
 	for _, rule := range bucket.rules {
+		if ruleScope, exists := rs.scopes[rule.ID]; exists {
+			if workload == nil {
+				workload = rs.opts.Workload(ctx)
+			}
+			if !ruleScope.matches(workload) {
+				continue
+			}
+		}
+
 		if rule.GetEvaluator().Eval(ctx) {
 			log.Tracef("Rule `%s` matches with event `%s`\n", rule.ID, event)
 
-			rs.NotifyRuleMatch(rule, event)
+			// a suppressed rule, outside of its schedule, below its threshold, with an incomplete sequence or not armed still matches, so that no discarder is generated for its fields
 			result = true
+
+			if s := rs.suppressedBy(rule.ID, ctx); s != nil {
+				log.Tracef("Rule `%s` is suppressed by `%s`", rule.ID, s.id)
+				atomic.AddInt64(&s.hits, 1)
+				continue
+			}
+
+			if ruleSchedule, exists := rs.schedules[rule.ID]; exists && !ruleSchedule.isActive(rs.now()) {
+				log.Tracef("Rule `%s` is not active, the match is ignored", rule.ID)
+				continue
+			}
+
+			if ruleThreshold, exists := rs.thresholds[rule.ID]; exists && !ruleThreshold.hit(ctx, rs.now()) {
+				log.Tracef("Rule `%s` is below its threshold", rule.ID)
+				continue
+			}
+
+			if ruleSequence, exists := rs.sequences[rule.ID]; exists && !ruleSequence.complete(ctx, rs.now()) {
+				log.Tracef("Rule `%s` didn't complete its sequence", rule.ID)
+				continue
+			}
+
+			if ruleRequirement, exists := rs.requirements[rule.ID]; exists && !ruleRequirement.armed(ctx, rs.now()) {
+				log.Tracef("Rule `%s` isn't armed, `%s` didn't fire", rule.ID, ruleRequirement.rule)
+				continue
+			}
+
+			for _, stepSequence := range rs.stepSequences[rule.ID] {
+				stepSequence.advance(ctx, rule.ID, rs.now())
+			}
+
+			for _, dependent := range rs.requiredBy[rule.ID] {
+				dependent.record(ctx, rs.now())
+			}
+
+			rs.runActions(rule, ctx)
+			rs.NotifyRuleMatch(rule, event)
 		}
 	}
 
@@ -345,6 +900,21 @@ func (rs *RuleSet) GetEventTypes() []eval.EventType {
 	return eventTypes
 }
 
+// GetWorkloadEventTypes returns the event types having at least one rule applying to the given workload, the rules
+// without scope apply to all the workloads
+func (rs *RuleSet) GetWorkloadEventTypes(workload *Workload) []eval.EventType {
+	var eventTypes []eval.EventType
+	for eventType, bucket := range rs.eventRuleBuckets {
+		for _, rule := range bucket.rules {
+			if scope, exists := rs.scopes[rule.ID]; !exists || scope.matches(workload) {
+				eventTypes = append(eventTypes, eventType)
+				break
+			}
+		}
+	}
+	return eventTypes
+}
+
 // AddFields merges the provided set of fields with the existing set of fields of the ruleset
 func (rs *RuleSet) AddFields(fields []eval.EventType) {
 NewFields:
@@ -381,5 +951,18 @@ func NewRuleSet(model eval.Model, eventCtor func() eval.Event, opts *Opts) *Rule
 		opts:             opts,
 		eventRuleBuckets: make(map[eval.EventType]*RuleBucket),
 		rules:            make(map[eval.RuleID]*eval.Rule),
+		listDefinitions:  make(map[eval.ListID]*ListDefinition),
+		scopedVariables:  make(map[string]*scopedVariables),
+		ruleDefinitions:  make(map[eval.RuleID]*RuleDefinition),
+		severities:       make(map[eval.RuleID]Severity),
+		schedules:        make(map[eval.RuleID]*schedule),
+		thresholds:       make(map[eval.RuleID]*threshold),
+		sequences:        make(map[eval.RuleID]*sequence),
+		stepSequences:    make(map[eval.RuleID][]*sequence),
+		suppressions:     make(map[eval.RuleID][]*suppression),
+		requirements:     make(map[eval.RuleID]*requirement),
+		requiredBy:       make(map[eval.RuleID][]*requirement),
+		scopes:           make(map[eval.RuleID]*workloadScope),
+		now:              time.Now,
 	}
 }
@@ -8,8 +8,14 @@ package rules
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
 
 	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
 )
@@ -163,6 +169,31 @@ func TestRuleSetDiscarders(t *testing.T) {
 	}
 }
 
+func TestRuleSetDiscarderOfEventType(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd" && process.uid != 0`, `mkdir.filename =~ "/var/run/*"`)
+
+	event := &testEvent{
+		process: testProcess{
+			uid: 0,
+		},
+	}
+
+	if isDiscarder, err := rs.IsDiscarderOfEventType(event, "process.uid", "open"); err != nil || !isDiscarder {
+		t.Errorf("the uid should be a discarder of the open events: %v", err)
+	}
+
+	// the mkdir rule may match any uid
+	if isDiscarder, _ := rs.IsDiscarderOfEventType(event, "process.uid", "mkdir"); isDiscarder {
+		t.Error("the uid shouldn't be a discarder of the mkdir events")
+	}
+
+	event.process.uid = 1000
+	if isDiscarder, _ := rs.IsDiscarderOfEventType(event, "process.uid", "open"); isDiscarder {
+		t.Error("the uid shouldn't be a discarder of the open events")
+	}
+}
+
 func TestRuleSetFilters1(t *testing.T) {
 	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
 
@@ -329,6 +360,20 @@ func TestRuleSetFilters4(t *testing.T) {
 	if _, err := rs.GetApprovers("open", caps); err != nil {
 		t.Fatal("expected approver not found")
 	}
+
+	caps = FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType | eval.PatternValueType,
+			ValidateFnc: func(value FilterValue) bool {
+				return value.Type != eval.PatternValueType
+			},
+		},
+	}
+
+	if _, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatal("shouldn't get any approver, the pattern isn't valid")
+	}
 }
 
 func TestRuleSetFilters5(t *testing.T) {
@@ -371,3 +416,1605 @@ func TestRuleSetFilters6(t *testing.T) {
 		t.Fatal("shouldn't get any approver")
 	}
 }
+
+func TestRuleSetFilters7(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd" || process.name =~ r"^/usr/bin/.*"`)
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	if _, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatal("shouldn't get any approver")
+	}
+}
+
+func TestRuleSetFilters8(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd" || process.name in [ip"10.0.0.0/8"]`)
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	if _, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatal("shouldn't get any approver")
+	}
+}
+
+func TestRuleSetFilters9(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `open.filename == "/etc/shadow" && !(process.name =~ r"^/usr/bin/.*")`, `open.filename != "/etc/passwd" && open.flags & O_CREAT > 0 && process.name =~ r"^/tmp/.*"`)
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+		{
+			Field: "open.flags",
+			Types: eval.ScalarValueType | eval.BitmaskValueType,
+		},
+	}
+
+	approvers, err := rs.GetApprovers("open", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["open.filename"]; !exists || len(values) != 1 || values[0].Value != "/etc/shadow" {
+		t.Fatalf("expected an approver for /etc/shadow, got %+v", approvers)
+	}
+
+	if values, exists := approvers["open.flags"]; !exists || len(values) != 1 || values[0].Value != syscall.O_CREAT {
+		t.Fatalf("expected an approver for O_CREAT, got %+v", approvers)
+	}
+}
+
+func TestRuleSetFilters10(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	if err := rs.AddList(&ListDefinition{ID: "editors"}, []string{"vim", "nano"}); err != nil {
+		t.Fatal(err)
+	}
+
+	addRuleExpr(t, rs, `open.filename == "/etc/shadow" && process.name in @editors`)
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	approvers, err := rs.GetApprovers("open", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["open.filename"]; !exists || len(values) != 1 || values[0].Value != "/etc/shadow" {
+		t.Fatalf("expected an approver for /etc/shadow, got %+v", approvers)
+	}
+
+	rs = NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `open.filename != "/etc/shadow" && process.name =~ r"^/usr/bin/.*"`)
+
+	if _, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatal("shouldn't get any approver")
+	}
+}
+
+func TestRuleSetFiltersCaseInsensitive(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `open.filename =~ i"/Etc/*"`)
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType | eval.PatternValueType,
+		},
+	}
+
+	// the original case of the pattern would approve /Etc/passwd but not /etc/passwd
+	if approvers, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatalf("shouldn't get any approver, got %+v", approvers)
+	}
+}
+
+func TestRuleSetFilters11(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `(open.filename == "/etc/shadow" || open.flags & O_TRUNC > 0) && process.name =~ r"^/usr/bin/.*"`, `open.filename != "/etc/passwd" && open.flags & O_CREAT > 0 && process.name =~ r"^/tmp/.*"`)
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+		{
+			Field: "open.flags",
+			Types: eval.ScalarValueType | eval.BitmaskValueType,
+		},
+	}
+
+	approvers, err := rs.GetApprovers("open", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["open.filename"]; !exists || len(values) != 1 || values[0].Value != "/etc/shadow" {
+		t.Fatalf("expected an approver for /etc/shadow, got %+v", approvers)
+	}
+
+	values, exists := approvers["open.flags"]
+	if !exists || len(values) != 2 {
+		t.Fatalf("expected approvers for O_TRUNC and O_CREAT, got %+v", approvers)
+	}
+	for _, value := range values {
+		if value.Value != syscall.O_TRUNC && value.Value != syscall.O_CREAT {
+			t.Errorf("unexpected flags approver %+v", value)
+		}
+	}
+
+	// the fields compared in a disjunction with a negated field can't be approved
+	rs = NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `(open.filename != "/etc/passwd" || open.flags & O_CREAT > 0) && process.name =~ r"^/tmp/.*"`)
+
+	if approvers, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatalf("shouldn't get any approver, got %+v", approvers)
+	}
+}
+
+func TestRuleSetLists(t *testing.T) {
+	model := &testModel{}
+
+	handler := &testHandler{
+		model:   model,
+		filters: make(map[string]testFieldValues),
+	}
+	rs := NewRuleSet(model, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	rs.AddListener(handler)
+
+	if err := rs.AddList(&ListDefinition{ID: "sensitive_files"}, []string{"/etc/shadow"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rs.AddList(&ListDefinition{ID: "sensitive_files"}, nil); err == nil {
+		t.Fatal("a list can't be defined twice")
+	}
+
+	addRuleExpr(t, rs, `open.filename in @sensitive_files && process.uid != 0`)
+
+	event := &testEvent{
+		kind: "open",
+		process: testProcess{
+			uid: 0,
+		},
+		open: testOpen{
+			filename: "/etc/passwd",
+		},
+	}
+
+	if rs.Evaluate(event) {
+		t.Fatal("the rule shouldn't match")
+	}
+
+	// the values of the list may change, the filename can't be a discarder
+	if _, exists := handler.filters["open"]["open.filename"]; exists {
+		t.Fatal("unexpected discarder on a field tested against a list")
+	}
+
+	event.process.uid = 1000
+	if rs.Evaluate(event) {
+		t.Fatal("the rule shouldn't match")
+	}
+
+	if err := rs.UpdateList("sensitive_files", []string{"/etc/shadow", "/etc/passwd"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !rs.Evaluate(event) {
+		t.Fatal("the rule should match after the update of the list")
+	}
+
+	if err := rs.UpdateList("unknown", nil); err == nil {
+		t.Fatal("an unknown list can't be updated")
+	}
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	if _, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatal("shouldn't get any approver")
+	}
+}
+
+func TestRuleSetVariables(t *testing.T) {
+	model := &testModel{}
+
+	handler := &testHandler{
+		model:   model,
+		filters: make(map[string]testFieldValues),
+	}
+
+	opts := NewOptsWithParams(testConstants, testSupportedDiscarders)
+	opts.VariableScopes["process"] = func(ctx *eval.Context) interface{} {
+		return (*testEvent)(ctx.Object).process.name
+	}
+
+	rs := NewRuleSet(model, func() eval.Event { return &testEvent{} }, opts)
+	rs.AddListener(handler)
+
+	// the variable is tested by a rule defined before the one setting it
+	ruleDefs := []*RuleDefinition{
+		{
+			ID:         "config_read",
+			Expression: `open.filename == "/etc/shadow" && ${process.auth_failed}`,
+		},
+		{
+			ID:         "auth_failed",
+			Expression: `mkdir.filename == "/var/run/auth_failed"`,
+			Actions: []*ActionDefinition{
+				{Set: &SetDefinition{Name: "auth_failed", Value: true, Scope: "process"}},
+			},
+		},
+	}
+
+	if err := rs.AddRules(ruleDefs); err != nil {
+		t.Fatal(err)
+	}
+
+	open := &testEvent{
+		kind: "open",
+		process: testProcess{
+			name: "/usr/sbin/sshd",
+		},
+		open: testOpen{
+			filename: "/etc/shadow",
+		},
+	}
+
+	if rs.Evaluate(open) {
+		t.Fatal("the rule shouldn't match before the variable is set")
+	}
+
+	// the variable may be set later on, the filename can't be a discarder
+	if _, exists := handler.filters["open"]["open.filename"]; exists {
+		t.Fatal("unexpected discarder on a field tested along with a variable")
+	}
+
+	mkdir := &testEvent{
+		kind: "mkdir",
+		process: testProcess{
+			name: "/usr/sbin/sshd",
+		},
+		mkdir: testMkdir{
+			filename: "/var/run/auth_failed",
+		},
+	}
+
+	if !rs.Evaluate(mkdir) {
+		t.Fatal("the rule setting the variable should match")
+	}
+
+	if !rs.Evaluate(open) {
+		t.Fatal("the rule should match once the variable is set")
+	}
+
+	// variables are scoped, another process isn't affected
+	open.process.name = "/usr/bin/cat"
+	if rs.Evaluate(open) {
+		t.Fatal("the rule shouldn't match for another process")
+	}
+
+	// the variables of a released entry, an exited process, aren't inherited by the next one with the same key
+	open.process.name = "/usr/sbin/sshd"
+	rs.ReleaseScopeEntry("process", "/usr/sbin/sshd")
+	if rs.Evaluate(open) {
+		t.Fatal("the rule shouldn't match once the process is released")
+	}
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	if _, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatal("shouldn't get any approver")
+	}
+
+	conflicting := &RuleDefinition{
+		ID:         "conflicting",
+		Expression: `mkdir.filename == "/var/run/auth_ok"`,
+		Actions: []*ActionDefinition{
+			{Set: &SetDefinition{Name: "auth_failed", Value: "no", Scope: "process"}},
+		},
+	}
+
+	if _, err := rs.AddRule(conflicting); err == nil {
+		t.Fatal("a variable can't be set with values of different types")
+	}
+
+	unknownScope := &RuleDefinition{
+		ID:         "unknown_scope",
+		Expression: `mkdir.filename == "/var/run/auth_ok"`,
+		Actions: []*ActionDefinition{
+			{Set: &SetDefinition{Name: "auth_ok", Value: true, Scope: "unknown"}},
+		},
+	}
+
+	if _, err := rs.AddRule(unknownScope); err == nil {
+		t.Fatal("a variable can't be set in an unknown scope")
+	}
+}
+
+func TestRuleSetMacroVariables(t *testing.T) {
+	var authFailed bool
+
+	opts := NewOptsWithParams(testConstants, testSupportedDiscarders)
+	opts.Variables["auth_failed"] = eval.NewBoolVariable(func(ctx *eval.Context) bool { return authFailed })
+
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, opts)
+
+	if err := rs.AddMacros([]*MacroDefinition{{ID: "is_auth_failed", Expression: `${auth_failed}`}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the open rule references the macro, the approvers of the mkdir rule are kept
+	addRuleExpr(t, rs, `open.filename == "/etc/shadow" && is_auth_failed`, `mkdir.filename == "/var/run/auth_failed"`)
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+		{
+			Field: "mkdir.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	if approvers, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatalf("shouldn't get any approver for the rule referencing the variable, got %+v", approvers)
+	}
+
+	approvers, err := rs.GetApprovers("mkdir", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["mkdir.filename"]; !exists || len(values) != 1 || values[0].Value != "/var/run/auth_failed" {
+		t.Fatalf("expected an approver for /var/run/auth_failed, got %+v", approvers)
+	}
+}
+
+func TestRuleSetTags(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	ruleDef := &RuleDefinition{
+		ID:         "download",
+		Expression: `open.filename == "/usr/bin/wget"`,
+		Tags: map[string]string{
+			"team":      "sre",
+			"technique": "T1105",
+		},
+	}
+
+	rule, err := rs.AddRule(ruleDef)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"team:sre", "technique:T1105"}
+	if !reflect.DeepEqual(rule.Tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, rule.Tags)
+	}
+}
+
+func TestRuleSetSeverity(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	ruleDefs := []*RuleDefinition{
+		{ID: "high", Expression: `open.filename == "/etc/shadow"`, Severity: "high"},
+		{ID: "default", Expression: `open.filename == "/etc/passwd"`},
+	}
+
+	if err := rs.AddRules(ruleDefs); err != nil {
+		t.Fatal(err)
+	}
+
+	if severity := rs.GetSeverity("high"); severity != SeverityHigh {
+		t.Errorf("expected severity high, got %s", severity)
+	}
+
+	if severity := rs.GetSeverity("default"); severity != SeverityMedium {
+		t.Errorf("expected severity medium, got %s", severity)
+	}
+
+	if _, err := rs.AddRule(&RuleDefinition{ID: "unknown", Expression: `open.filename == "/etc/group"`, Severity: "urgent"}); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+
+	if SeverityInfo >= SeverityLow || SeverityHigh >= SeverityCritical {
+		t.Error("severities should be ordered")
+	}
+}
+
+func TestRuleSetRateLimit(t *testing.T) {
+	opts := NewOptsWithParams(testConstants, testSupportedDiscarders)
+	opts.VariableScopes["process"] = func(ctx *eval.Context) interface{} {
+		return (*testEvent)(ctx.Object).process.name
+	}
+
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, opts)
+
+	ruleDef := &RuleDefinition{
+		ID:         "limited",
+		Expression: `open.filename == "/etc/shadow"`,
+		RateLimit:  &RateLimitDefinition{Limit: 5, Period: time.Minute, Scope: "process"},
+	}
+
+	if _, err := rs.AddRule(ruleDef); err != nil {
+		t.Fatal(err)
+	}
+
+	if rs.GetRuleDefinition("limited") != ruleDef {
+		t.Error("expected the definition of the rule")
+	}
+
+	event := &testEvent{kind: "open", process: testProcess{name: "/usr/bin/cat"}}
+	if key := rs.GetScopeKey("process", event); key != "/usr/bin/cat" {
+		t.Errorf("unexpected scope key %v", key)
+	}
+
+	invalids := []*RuleDefinition{
+		{ID: "no_limit", Expression: `open.filename == "/etc/passwd"`, RateLimit: &RateLimitDefinition{Period: time.Minute}},
+		{ID: "no_period", Expression: `open.filename == "/etc/passwd"`, RateLimit: &RateLimitDefinition{Limit: 5}},
+		{ID: "unknown_scope", Expression: `open.filename == "/etc/passwd"`, RateLimit: &RateLimitDefinition{Limit: 5, Period: time.Minute, Scope: "unknown"}},
+	}
+
+	for _, ruleDef := range invalids {
+		if _, err := rs.AddRule(ruleDef); err == nil {
+			t.Errorf("expected an error for the rate limit of %s", ruleDef.ID)
+		}
+	}
+}
+
+type testMatchCounter struct {
+	testHandler
+	matches int
+}
+
+func (f *testMatchCounter) RuleMatch(rule *eval.Rule, event eval.Event) {
+	f.matches++
+}
+
+func TestRuleSetSchedule(t *testing.T) {
+	model := &testModel{}
+
+	handler := &testMatchCounter{
+		testHandler: testHandler{
+			model:   model,
+			filters: make(map[string]testFieldValues),
+		},
+	}
+
+	rs := NewRuleSet(model, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	rs.AddListener(handler)
+
+	ruleDef := &RuleDefinition{
+		ID:         "after_hours",
+		Expression: `open.filename == "/etc/shadow"`,
+		Schedule: &ScheduleDefinition{
+			Timezone: "UTC",
+			Periods: []*PeriodDefinition{
+				{Days: []string{"mon", "tuesday"}, From: "18:00", To: "08:00"},
+				{Days: []string{"sat", "sun"}, From: "00:00", To: "00:00"},
+			},
+			Silenced: []*WindowDefinition{
+				{Start: "2020-11-02T22:00:00Z", End: "2020-11-02T23:00:00Z"},
+			},
+		},
+	}
+
+	if _, err := rs.AddRule(ruleDef); err != nil {
+		t.Fatal(err)
+	}
+
+	event := &testEvent{
+		kind: "open",
+		open: testOpen{
+			filename: "/etc/shadow",
+		},
+	}
+
+	tests := []struct {
+		Now      string
+		Expected bool
+	}{
+		// monday 2020-11-02
+		{Now: "2020-11-02T12:00:00Z", Expected: false},
+		{Now: "2020-11-02T19:00:00Z", Expected: true},
+		{Now: "2020-11-02T22:30:00Z", Expected: false},
+		{Now: "2020-11-03T07:59:00Z", Expected: true},
+		{Now: "2020-11-03T08:00:00Z", Expected: false},
+		// the period of tuesday ends on wednesday
+		{Now: "2020-11-04T07:00:00Z", Expected: true},
+		{Now: "2020-11-05T07:00:00Z", Expected: false},
+		{Now: "2020-11-07T12:00:00Z", Expected: true},
+	}
+
+	for _, test := range tests {
+		now, _ := time.Parse(time.RFC3339, test.Now)
+		rs.now = func() time.Time { return now }
+
+		handler.matches = 0
+		if !rs.Evaluate(event) {
+			t.Errorf("the rule should match at %s", test.Now)
+		}
+
+		if notified := handler.matches == 1; notified != test.Expected {
+			t.Errorf("expected notification %t at %s", test.Expected, test.Now)
+		}
+	}
+
+	invalids := []*ScheduleDefinition{
+		{Timezone: "Unknown/Zone"},
+		{Periods: []*PeriodDefinition{{From: "25:00", To: "08:00"}}},
+		{Periods: []*PeriodDefinition{{Days: []string{"someday"}, From: "18:00", To: "08:00"}}},
+		{Silenced: []*WindowDefinition{{Start: "2020-11-02T23:00:00Z", End: "2020-11-02T22:00:00Z"}}},
+	}
+
+	for i, schedule := range invalids {
+		ruleDef := &RuleDefinition{
+			ID:         fmt.Sprintf("invalid%d", i),
+			Expression: `open.filename == "/etc/passwd"`,
+			Schedule:   schedule,
+		}
+
+		if _, err := rs.AddRule(ruleDef); err == nil {
+			t.Errorf("expected an error for the schedule %d", i)
+		}
+	}
+}
+
+func TestRuleSetThreshold(t *testing.T) {
+	model := &testModel{}
+
+	handler := &testMatchCounter{
+		testHandler: testHandler{
+			model:   model,
+			filters: make(map[string]testFieldValues),
+		},
+	}
+
+	opts := NewOptsWithParams(testConstants, testSupportedDiscarders)
+	opts.VariableScopes["process"] = func(ctx *eval.Context) interface{} {
+		return (*testEvent)(ctx.Object).process.name
+	}
+
+	rs := NewRuleSet(model, func() eval.Event { return &testEvent{} }, opts)
+	rs.AddListener(handler)
+
+	ruleDef := &RuleDefinition{
+		ID:         "brute_force",
+		Expression: `open.filename == "/etc/shadow"`,
+		Threshold:  &ThresholdDefinition{Count: 3, Period: 30 * time.Second, Scope: "process"},
+	}
+
+	if _, err := rs.AddRule(ruleDef); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	rs.now = func() time.Time { return now }
+
+	newEvent := func(process string) *testEvent {
+		return &testEvent{
+			kind:    "open",
+			process: testProcess{name: process},
+			open:    testOpen{filename: "/etc/shadow"},
+		}
+	}
+
+	tests := []struct {
+		Elapsed  time.Duration
+		Process  string
+		Expected int
+	}{
+		{Elapsed: 0, Process: "/usr/bin/cat", Expected: 0},
+		{Elapsed: time.Second, Process: "/usr/bin/cat", Expected: 0},
+		{Elapsed: time.Second, Process: "/usr/bin/vim", Expected: 0},
+		{Elapsed: time.Second, Process: "/usr/bin/cat", Expected: 1},
+		// the count is reset once the threshold is reached
+		{Elapsed: time.Second, Process: "/usr/bin/cat", Expected: 1},
+		{Elapsed: time.Second, Process: "/usr/bin/vim", Expected: 1},
+		// the first matches of vim slid out of the period
+		{Elapsed: 35 * time.Second, Process: "/usr/bin/vim", Expected: 1},
+		{Elapsed: time.Second, Process: "/usr/bin/vim", Expected: 1},
+		{Elapsed: time.Second, Process: "/usr/bin/vim", Expected: 2},
+	}
+
+	for i, test := range tests {
+		now = now.Add(test.Elapsed)
+
+		if !rs.Evaluate(newEvent(test.Process)) {
+			t.Errorf("the rule should match the event %d", i)
+		}
+
+		if handler.matches != test.Expected {
+			t.Errorf("expected %d notifications after the event %d, got %d", test.Expected, i, handler.matches)
+		}
+	}
+
+	invalids := []*RuleDefinition{
+		{ID: "no_count", Expression: `open.filename == "/etc/passwd"`, Threshold: &ThresholdDefinition{Period: time.Minute}},
+		{ID: "no_period", Expression: `open.filename == "/etc/passwd"`, Threshold: &ThresholdDefinition{Count: 5}},
+		{ID: "unknown_scope", Expression: `open.filename == "/etc/passwd"`, Threshold: &ThresholdDefinition{Count: 5, Period: time.Minute, Scope: "unknown"}},
+	}
+
+	for _, ruleDef := range invalids {
+		if _, err := rs.AddRule(ruleDef); err == nil {
+			t.Errorf("expected an error for the threshold of %s", ruleDef.ID)
+		}
+	}
+}
+
+func TestRuleSetInheritState(t *testing.T) {
+	newRuleSet := func(ruleDefs ...*RuleDefinition) (*RuleSet, *testMatchCounter) {
+		model := &testModel{}
+		handler := &testMatchCounter{
+			testHandler: testHandler{
+				model:   model,
+				filters: make(map[string]testFieldValues),
+			},
+		}
+
+		rs := NewRuleSet(model, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+		rs.AddListener(handler)
+		if err := rs.AddRules(ruleDefs); err != nil {
+			t.Fatal(err)
+		}
+		return rs, handler
+	}
+
+	threshold := &ThresholdDefinition{Count: 2, Period: time.Minute}
+	previous, _ := newRuleSet(
+		&RuleDefinition{ID: "unchanged", Expression: `open.filename == "/etc/shadow"`, Threshold: threshold},
+		&RuleDefinition{ID: "new_expression", Expression: `open.filename == "/etc/shadow"`, Threshold: threshold},
+		&RuleDefinition{ID: "new_threshold", Expression: `open.filename == "/etc/shadow"`, Threshold: threshold},
+	)
+
+	event := &testEvent{kind: "open", open: testOpen{filename: "/etc/shadow"}}
+	previous.Evaluate(event)
+
+	rs, handler := newRuleSet(
+		&RuleDefinition{ID: "unchanged", Expression: `open.filename == "/etc/shadow"`, Threshold: threshold},
+		&RuleDefinition{ID: "new_expression", Expression: `open.filename in ["/etc/shadow", "/etc/passwd"]`, Threshold: threshold},
+		&RuleDefinition{ID: "new_threshold", Expression: `open.filename == "/etc/shadow"`, Threshold: &ThresholdDefinition{Count: 2, Period: time.Hour}},
+		&RuleDefinition{ID: "added", Expression: `open.filename == "/etc/shadow"`, Threshold: threshold},
+	)
+
+	unchanged := rs.InheritState(previous)
+	if len(unchanged) != 2 || unchanged[0] != "new_threshold" || unchanged[1] != "unchanged" {
+		t.Errorf("expected the rules whose expression didn't change, got %v", unchanged)
+	}
+
+	// only the rule whose threshold didn't change kept the match of the previous ruleset
+	rs.Evaluate(event)
+	if handler.matches != 1 {
+		t.Errorf("expected a single rule to reach its threshold, got %d matches", handler.matches)
+	}
+}
+
+func TestRuleSetSequence(t *testing.T) {
+	model := &testModel{}
+
+	handler := &testMatchCounter{
+		testHandler: testHandler{
+			model:   model,
+			filters: make(map[string]testFieldValues),
+		},
+	}
+
+	opts := NewOptsWithParams(testConstants, testSupportedDiscarders)
+	opts.VariableScopes["user"] = func(ctx *eval.Context) interface{} {
+		return (*testEvent)(ctx.Object).process.uid
+	}
+
+	rs := NewRuleSet(model, func() eval.Event { return &testEvent{} }, opts)
+	rs.AddListener(handler)
+
+	ruleDefs := []*RuleDefinition{
+		{
+			ID:         "dropper",
+			Expression: `open.filename == "/tmp/.hidden/payload"`,
+			Sequence: &SequenceDefinition{
+				After:  []RuleID{"download", "hide"},
+				Within: time.Minute,
+				Scope:  "user",
+			},
+		},
+		{ID: "download", Expression: `open.filename == "/tmp/payload"`},
+		{ID: "hide", Expression: `mkdir.filename == "/tmp/.hidden"`},
+	}
+
+	if err := rs.AddRules(ruleDefs); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	rs.now = func() time.Time { return now }
+
+	download := func(uid int) *testEvent {
+		return &testEvent{kind: "open", process: testProcess{uid: uid}, open: testOpen{filename: "/tmp/payload"}}
+	}
+	hide := func(uid int) *testEvent {
+		return &testEvent{kind: "mkdir", process: testProcess{uid: uid}, mkdir: testMkdir{filename: "/tmp/.hidden"}}
+	}
+	drop := func(uid int) *testEvent {
+		return &testEvent{kind: "open", process: testProcess{uid: uid}, open: testOpen{filename: "/tmp/.hidden/payload"}}
+	}
+
+	tests := []struct {
+		Name    string
+		Events  []*testEvent
+		Elapsed time.Duration
+		Dropped bool
+	}{
+		{Name: "complete", Events: []*testEvent{download(1), hide(1), drop(1)}, Dropped: true},
+		{Name: "out of order", Events: []*testEvent{hide(1), download(1), drop(1)}},
+		{Name: "another user", Events: []*testEvent{download(1), hide(2), drop(1)}},
+		{Name: "missing step", Events: []*testEvent{download(1), drop(1)}},
+		{Name: "expired", Events: []*testEvent{download(1), hide(1), drop(1)}, Elapsed: 40 * time.Second},
+		{Name: "restarted", Events: []*testEvent{download(1), download(1), hide(1), drop(1)}, Elapsed: 25 * time.Second, Dropped: true},
+	}
+
+	for _, test := range tests {
+		// reset the sequences of the previous test
+		now = now.Add(time.Hour)
+		handler.matches = 0
+
+		var dropped bool
+		for _, event := range test.Events {
+			before := handler.matches
+			if !rs.Evaluate(event) {
+				t.Errorf("%s: the rules should match the events", test.Name)
+			}
+			dropped = handler.matches > before && event.open.filename == "/tmp/.hidden/payload"
+			now = now.Add(test.Elapsed)
+		}
+
+		if dropped != test.Dropped {
+			t.Errorf("%s: expected the sequence dropped to be %t", test.Name, test.Dropped)
+		}
+	}
+
+	// the sequence is reset once complete
+	for _, event := range []*testEvent{download(1), hide(1), drop(1)} {
+		rs.Evaluate(event)
+	}
+	handler.matches = 0
+	if rs.Evaluate(drop(1)); handler.matches != 0 {
+		t.Error("the sequence should be reset once complete")
+	}
+
+	invalids := []*RuleDefinition{
+		{ID: "no_step", Expression: `open.filename == "/etc/passwd"`, Sequence: &SequenceDefinition{Within: time.Minute}},
+		{ID: "no_period", Expression: `open.filename == "/etc/passwd"`, Sequence: &SequenceDefinition{After: []RuleID{"download"}}},
+		{ID: "itself", Expression: `open.filename == "/etc/passwd"`, Sequence: &SequenceDefinition{After: []RuleID{"itself"}, Within: time.Minute}},
+		{ID: "unknown_scope", Expression: `open.filename == "/etc/passwd"`, Sequence: &SequenceDefinition{After: []RuleID{"download"}, Within: time.Minute, Scope: "unknown"}},
+	}
+
+	for _, ruleDef := range invalids {
+		if _, err := rs.AddRule(ruleDef); err == nil {
+			t.Errorf("expected an error for the sequence of %s", ruleDef.ID)
+		}
+	}
+
+	rs = NewRuleSet(model, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	unknownStep := &RuleDefinition{
+		ID:         "unknown_step",
+		Expression: `open.filename == "/etc/passwd"`,
+		Sequence:   &SequenceDefinition{After: []RuleID{"unknown"}, Within: time.Minute},
+	}
+	if err := rs.AddRules([]*RuleDefinition{unknownStep}); err == nil {
+		t.Error("expected an error for the unknown rule of the sequence")
+	}
+}
+
+func TestRuleSetDisabledRules(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	disabled, enabled := false, true
+	ruleDefs := []*RuleDefinition{
+		{ID: "default", Version: "1.0.0", Expression: `open.filename == "/etc/passwd"`},
+		{ID: "enabled", Enabled: &enabled, Expression: `open.filename == "/etc/shadow"`},
+		{ID: "disabled", Enabled: &disabled, Expression: `open.filename == "/etc/group"`},
+	}
+
+	if err := rs.AddRules(ruleDefs); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := rs.ListRuleIDs()
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"default", "enabled"}) {
+		t.Errorf("unexpected rules %v", ids)
+	}
+
+	if version := rs.GetRuleDefinition("default").Version; version != "1.0.0" {
+		t.Errorf("unexpected version %s", version)
+	}
+
+	if rs.Evaluate(&testEvent{kind: "open", open: testOpen{filename: "/etc/group"}}) {
+		t.Error("a disabled rule shouldn't match")
+	}
+}
+
+func TestRuleSetMatchEvent(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	ruleDefs := []*RuleDefinition{
+		{ID: "shadow", Expression: `open.filename == "/etc/shadow" && open.flags & O_RDWR > 0`},
+		{ID: "root", Expression: `open.filename =~ "/etc/*" && process.uid == 0`},
+		{ID: "mkdir", Expression: `mkdir.filename == "/etc/shadow"`},
+	}
+
+	if err := rs.AddRules(ruleDefs); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := rs.MatchEvent("", map[eval.Field]interface{}{
+		"open.filename": "/etc/shadow",
+		"open.flags":    "O_CREAT|O_RDWR",
+		"process.uid":   0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(matches, []eval.RuleID{"root", "shadow"}) {
+		t.Errorf("unexpected matches %v", matches)
+	}
+
+	matches, err = rs.MatchEvent("open", map[eval.Field]interface{}{
+		"open.filename": "/etc/shadow",
+		"process.uid":   1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("unexpected matches %v", matches)
+	}
+
+	if _, err := rs.MatchEvent("", map[eval.Field]interface{}{"process.uid": 0}); err == nil {
+		t.Error("an event without event type should be reported")
+	}
+
+	if _, err := rs.MatchEvent("", map[eval.Field]interface{}{"open.filename": 0}); err == nil {
+		t.Error("a value type mismatch should be reported")
+	}
+
+	if _, err := rs.MatchEvent("", map[eval.Field]interface{}{"open.flags": "O_UNKNOWN"}); err == nil {
+		t.Error("an unknown constant should be reported")
+	}
+}
+
+func TestRuleSetSuppressions(t *testing.T) {
+	model := &testModel{}
+
+	handler := &testMatchCounter{
+		testHandler: testHandler{
+			model:   model,
+			filters: make(map[string]testFieldValues),
+		},
+	}
+
+	rs := NewRuleSet(model, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	rs.AddListener(handler)
+
+	ruleDef := &RuleDefinition{
+		ID:         "shadow",
+		Expression: `open.filename == "/etc/shadow"`,
+		Suppressions: []*SuppressionDefinition{
+			{ID: "backup", Expression: `process.name == "backup"`},
+			{ID: "root", Expression: `process.uid == 0 && open.flags & O_RDWR == 0`},
+		},
+	}
+
+	if _, err := rs.AddRule(ruleDef); err != nil {
+		t.Fatal(err)
+	}
+
+	events := []*testEvent{
+		{kind: "open", process: testProcess{name: "cat", uid: 1000}, open: testOpen{filename: "/etc/shadow"}},
+		{kind: "open", process: testProcess{name: "backup", uid: 1000}, open: testOpen{filename: "/etc/shadow"}},
+		{kind: "open", process: testProcess{name: "cat", uid: 0}, open: testOpen{filename: "/etc/shadow"}},
+		{kind: "open", process: testProcess{name: "cat", uid: 0}, open: testOpen{filename: "/etc/shadow", flags: syscall.O_RDWR}},
+	}
+
+	for _, event := range events {
+		if !rs.Evaluate(event) {
+			t.Error("a suppressed rule should still match, so that no discarder is generated")
+		}
+	}
+
+	if handler.matches != 2 {
+		t.Errorf("expected 2 matches, got %d", handler.matches)
+	}
+
+	hits := rs.GetSuppressionHits()
+	if !reflect.DeepEqual(hits, map[eval.RuleID]SuppressionHits{"shadow": {"backup": 1, "root": 1}}) {
+		t.Errorf("unexpected suppression hits %v", hits)
+	}
+
+	if hits := rs.GetSuppressionHits(); len(hits) != 0 {
+		t.Errorf("the suppression hits should be reset, got %v", hits)
+	}
+
+	invalid := &RuleDefinition{
+		ID:           "mkdir",
+		Expression:   `open.filename == "/etc/passwd"`,
+		Suppressions: []*SuppressionDefinition{{ID: "mkdir", Expression: `mkdir.filename == "/tmp"`}},
+	}
+
+	if _, err := rs.AddRule(invalid); err == nil {
+		t.Error("a suppression of another event type should be reported")
+	}
+}
+
+func TestRuleSetTransformers(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `lower(process.name) == "vim" && open.flags & O_CREAT > 0`)
+
+	if !rs.Evaluate(&testEvent{kind: "open", process: testProcess{name: "VIM"}, open: testOpen{flags: syscall.O_CREAT}}) {
+		t.Error("the rule should match")
+	}
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+		{
+			Field: "open.flags",
+			Types: eval.ScalarValueType | eval.BitmaskValueType,
+		},
+	}
+
+	approvers, err := rs.GetApprovers("open", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["open.flags"]; !exists || len(values) != 1 || values[0].Value != syscall.O_CREAT {
+		t.Fatalf("expected an approver for O_CREAT, got %+v", approvers)
+	}
+}
+
+func TestRuleSetIterators(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd" && process.ancestors.name == "sshd"`, `open.filename == "/etc/shadow" || process.ancestors.name == "sshd"`)
+
+	event := &testEvent{
+		kind:      "open",
+		ancestors: []testProcess{{name: "bash"}, {name: "sshd"}},
+		open:      testOpen{filename: "/etc/hosts"},
+	}
+
+	if !rs.Evaluate(event) {
+		t.Error("the second rule should match")
+	}
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	// the second rule may match any file opened by a descendant of sshd
+	if approvers, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatalf("shouldn't get any approver, got %+v", approvers)
+	}
+
+	rs = NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd" && process.ancestors.name == "sshd"`)
+
+	approvers, err := rs.GetApprovers("open", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["open.filename"]; !exists || len(values) != 1 || values[0].Value != "/etc/passwd" {
+		t.Fatalf("expected an approver for /etc/passwd, got %+v", approvers)
+	}
+}
+
+func TestRuleSetMacroIterators(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	if err := rs.AddMacros([]*MacroDefinition{{ID: "from_sshd", Expression: `process.ancestors.name == "sshd"`}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the open rule references the macro, the mkdir rule isn't evaluated on the ancestors
+	addRuleExpr(t, rs, `open.filename == "/etc/shadow" || from_sshd`, `mkdir.filename == "/var/run/sshd"`)
+
+	if iterators := rs.rules["ID0"].GetIterators(); len(iterators) != 1 || iterators[0] != "process.ancestors" {
+		t.Errorf("expected the iterable field of the macro, got %v", iterators)
+	}
+	if iterators := rs.rules["ID1"].GetIterators(); len(iterators) != 0 {
+		t.Errorf("expected no iterable field for the rule not referencing the macro, got %v", iterators)
+	}
+
+	caps := FieldCapabilities{
+		{
+			Field: "mkdir.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	approvers, err := rs.GetApprovers("mkdir", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["mkdir.filename"]; !exists || len(values) != 1 || values[0].Value != "/var/run/sshd" {
+		t.Fatalf("expected an approver for /var/run/sshd, got %+v", approvers)
+	}
+}
+
+type testAtomicMatchCounter struct {
+	testHandler
+	matches int64
+}
+
+func (f *testAtomicMatchCounter) RuleMatch(rule *eval.Rule, event eval.Event) {
+	atomic.AddInt64(&f.matches, 1)
+}
+
+func TestRuleSetIteratorsConcurrent(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	handler := &testAtomicMatchCounter{testHandler: testHandler{filters: make(map[string]testFieldValues)}}
+	rs.AddListener(handler)
+
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd" && process.ancestors.name == "sshd" && count(process.ancestors) == 2`)
+
+	// the workers of the probe evaluate the events of different processes concurrently
+	events := []*testEvent{
+		{kind: "open", ancestors: []testProcess{{name: "bash"}, {name: "sshd"}}, open: testOpen{filename: "/etc/passwd"}},
+		{kind: "open", ancestors: []testProcess{{name: "cron"}, {name: "systemd"}}, open: testOpen{filename: "/etc/passwd"}},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(event *testEvent) {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				rs.Evaluate(event)
+			}
+		}(events[i%2])
+	}
+	wg.Wait()
+
+	if matches := atomic.LoadInt64(&handler.matches); matches != 4*1000 {
+		t.Errorf("expected only the events of the descendants of sshd to match, got %d matches", matches)
+	}
+}
+
+func TestRuleSetScopes(t *testing.T) {
+	model := &testModel{}
+
+	handler := &testMatchCounter{
+		testHandler: testHandler{
+			model:   model,
+			filters: make(map[string]testFieldValues),
+		},
+	}
+
+	// the test events have no container, their workload is the one of the name of their process
+	workloads := map[string]*Workload{
+		"host":  {},
+		"nginx": {ContainerID: "1", ImageName: "docker.io/nginx:1.19", Labels: map[string]string{"app": "web"}},
+		"redis": {ContainerID: "2", ImageName: "docker.io/redis:6", Labels: map[string]string{"app": "cache"}},
+	}
+
+	opts := NewOptsWithParams(testConstants, testSupportedDiscarders)
+	opts.Workload = func(ctx *eval.Context) *Workload {
+		return workloads[(*testEvent)(ctx.Object).process.name]
+	}
+
+	rs := NewRuleSet(model, func() eval.Event { return &testEvent{} }, opts)
+	rs.AddListener(handler)
+
+	tests := []struct {
+		scope   *ScopeDefinition
+		matches []string
+	}{
+		{nil, []string{"host", "nginx", "redis"}},
+		{&ScopeDefinition{Host: true}, []string{"host"}},
+		{&ScopeDefinition{Image: "docker.io/nginx:*"}, []string{"nginx"}},
+		{&ScopeDefinition{Image: "*"}, []string{"nginx", "redis"}},
+		{&ScopeDefinition{Labels: map[string]string{"app": "cache"}}, []string{"redis"}},
+		{&ScopeDefinition{Image: "docker.io/nginx:*", Labels: map[string]string{"app": "cache"}}, nil},
+	}
+
+	for i, test := range tests {
+		ruleDef := &RuleDefinition{
+			ID:         fmt.Sprintf("scope%d", i),
+			Expression: fmt.Sprintf(`open.filename == "/etc/scope%d"`, i),
+			Scope:      test.scope,
+		}
+
+		if _, err := rs.AddRule(ruleDef); err != nil {
+			t.Fatal(err)
+		}
+
+		var matches []string
+		for _, name := range []string{"host", "nginx", "redis"} {
+			event := &testEvent{kind: "open", process: testProcess{name: name}, open: testOpen{filename: fmt.Sprintf("/etc/scope%d", i)}}
+
+			handler.matches = 0
+			rs.Evaluate(event)
+			if handler.matches > 0 {
+				matches = append(matches, name)
+			}
+		}
+
+		if !reflect.DeepEqual(matches, test.matches) {
+			t.Errorf("expected the scope %+v to match %v, got %v", test.scope, test.matches, matches)
+		}
+	}
+
+	invalid := []*ScopeDefinition{
+		{},
+		{Host: true, Image: "nginx"},
+		{Host: true, Labels: map[string]string{"app": "web"}},
+	}
+
+	for i, scope := range invalid {
+		ruleDef := &RuleDefinition{
+			ID:         fmt.Sprintf("invalid%d", i),
+			Expression: `open.filename == "/etc/passwd"`,
+			Scope:      scope,
+		}
+
+		if _, err := rs.AddRule(ruleDef); err == nil {
+			t.Errorf("the scope %+v should be reported as invalid", scope)
+		}
+	}
+
+	rs = NewRuleSet(model, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	if _, err := rs.AddRule(&RuleDefinition{ID: "scope", Expression: `open.filename == "/etc/passwd"`, Scope: &ScopeDefinition{Host: true}}); err == nil {
+		t.Error("a scope without workload resolver should be reported")
+	}
+
+	for workload, resolved := range map[*Workload]bool{
+		workloads["host"]:  true,
+		workloads["nginx"]: true,
+		{ContainerID: "3"}: false,
+		{ContainerID: "3", ImageName: "docker.io/nginx:1.19"}:                              false,
+		{ContainerID: "3", ImageName: "docker.io/nginx:1.19", Labels: map[string]string{}}: true,
+	} {
+		if workload.IsResolved() != resolved {
+			t.Errorf("expected the workload %+v to be resolved: %v", workload, resolved)
+		}
+	}
+}
+
+func TestRuleSetWorkloadEventTypes(t *testing.T) {
+	opts := NewOptsWithParams(testConstants, testSupportedDiscarders)
+	opts.Workload = func(ctx *eval.Context) *Workload {
+		return &Workload{}
+	}
+
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, opts)
+
+	ruleDefs := []*RuleDefinition{
+		{ID: "host", Expression: `open.filename == "/etc/passwd"`, Scope: &ScopeDefinition{Host: true}},
+		{ID: "nginx", Expression: `mkdir.filename == "/var/log/nginx"`, Scope: &ScopeDefinition{Image: "docker.io/nginx:*"}},
+	}
+	if err := rs.AddRules(ruleDefs); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		workload   *Workload
+		eventTypes []eval.EventType
+	}{
+		{&Workload{}, []eval.EventType{"open"}},
+		{&Workload{ContainerID: "1", ImageName: "docker.io/nginx:1.19"}, []eval.EventType{"mkdir"}},
+		{&Workload{ContainerID: "2", ImageName: "docker.io/redis:6"}, nil},
+	}
+
+	for _, test := range tests {
+		eventTypes := rs.GetWorkloadEventTypes(test.workload)
+		sort.Strings(eventTypes)
+
+		if !reflect.DeepEqual(eventTypes, test.eventTypes) {
+			t.Errorf("expected the event types %v for %+v, got %v", test.eventTypes, test.workload, eventTypes)
+		}
+	}
+}
+
+func TestRuleSetFilterHints(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	ruleDef := &RuleDefinition{
+		ID:         "hinted",
+		Expression: `open.filename =~ "/etc/*" && process.uid == 0`,
+		Filters: FilterDefinitions{
+			"open.filename": {"/etc/passwd", "/etc/shadow"},
+			"open.flags":    {"O_CREAT|O_RDWR"},
+		},
+	}
+
+	if _, err := rs.AddRule(ruleDef); err != nil {
+		t.Fatal(err)
+	}
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+		{
+			Field: "open.flags",
+			Types: eval.ScalarValueType | eval.BitmaskValueType,
+		},
+	}
+
+	approvers, err := rs.GetApprovers("open", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, exists := approvers["open.filename"]; !exists || len(values) != 2 {
+		t.Fatalf("expected the hinted approvers of open.filename, got %+v", approvers)
+	}
+
+	if values, exists := approvers["open.flags"]; !exists || len(values) != 1 || values[0].Value != syscall.O_CREAT|syscall.O_RDWR {
+		t.Fatalf("expected the hinted approver of open.flags, got %+v", approvers)
+	}
+
+	if _, err := rs.GetRuleApprovers("hinted", caps); err != nil {
+		t.Errorf("expected the hinted approvers of the rule: %s", err)
+	}
+
+	// the events of the flags would be filtered out if only the filename was approved
+	if _, err := rs.GetApprovers("open", caps[:1]); err == nil {
+		t.Error("the hinted approvers of an unsupported field shouldn't be applied")
+	}
+
+	// the approvers derived from the expression take precedence over the hints
+	rs = NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	ruleDef = &RuleDefinition{
+		ID:         "derived",
+		Expression: `open.filename == "/etc/passwd"`,
+		Filters:    FilterDefinitions{"open.filename": {"/etc/shadow"}},
+	}
+
+	if _, err := rs.AddRule(ruleDef); err != nil {
+		t.Fatal(err)
+	}
+
+	if approvers, err := rs.GetApprovers("open", caps); err != nil || len(approvers["open.filename"]) != 1 || approvers["open.filename"][0].Value != "/etc/passwd" {
+		t.Errorf("expected the approver derived from the expression, got %+v: %v", approvers, err)
+	}
+
+	invalid := []FilterDefinitions{
+		{"mkdir.filename": {"/tmp"}},
+		{"open.flags": {"O_UNKNOWN"}},
+		{"open.filename": {1}},
+		{"open.filename": {}},
+	}
+
+	for i, filters := range invalid {
+		ruleDef := &RuleDefinition{
+			ID:         fmt.Sprintf("invalid%d", i),
+			Expression: `open.filename =~ "/etc/*"`,
+			Filters:    filters,
+		}
+
+		if _, err := rs.AddRule(ruleDef); err == nil {
+			t.Errorf("the filters %+v should be reported as invalid", filters)
+		}
+	}
+}
+
+func TestRuleSetRangeApprovers(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `process.uid in [0, 1000..2000] && open.flags & O_CREAT > 0`)
+
+	if !rs.Evaluate(&testEvent{kind: "open", process: testProcess{uid: 1500}, open: testOpen{flags: syscall.O_CREAT}}) {
+		t.Error("the rule should match")
+	}
+
+	caps := FieldCapabilities{
+		{
+			Field: "process.uid",
+			Types: eval.ScalarValueType | eval.RangeValueType,
+		},
+	}
+
+	approvers, err := rs.GetApprovers("open", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := FilterValues{
+		{Field: "process.uid", Value: 0, Type: eval.ScalarValueType},
+		{Field: "process.uid", Value: eval.IntRange{Min: 1000, Max: 2000}, Type: eval.RangeValueType},
+	}
+
+	values := approvers["process.uid"]
+	if len(values) != len(expected) {
+		t.Fatalf("expected the approvers %+v, got %+v", expected, approvers)
+	}
+
+	for _, value := range expected {
+		found := false
+		for _, v := range values {
+			if v.Value == value.Value && v.Type == value.Type {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected the approver %+v, got %+v", value, values)
+		}
+	}
+
+	caps[0].Types = eval.ScalarValueType
+	if _, err := rs.GetApprovers("open", caps); err == nil {
+		t.Error("the ranges shouldn't be approved without the capability")
+	}
+}
+
+func TestRuleSetRequirements(t *testing.T) {
+	model := &testModel{}
+
+	handler := &testMatchCounter{
+		testHandler: testHandler{
+			model:   model,
+			filters: make(map[string]testFieldValues),
+		},
+	}
+
+	opts := NewOptsWithParams(testConstants, testSupportedDiscarders)
+	opts.VariableScopes["process"] = func(ctx *eval.Context) interface{} {
+		return (*testEvent)(ctx.Object).process.name
+	}
+
+	rs := NewRuleSet(model, func() eval.Event { return &testEvent{} }, opts)
+	rs.AddListener(handler)
+
+	var ruleDefs []*RuleDefinition
+	policy := `
+- id: recon
+  expression: open.filename == "/etc/passwd"
+- id: staging
+  expression: mkdir.filename == "/tmp/.hidden"
+  requires: recon within 5m per process
+- id: exfiltration
+  expression: open.filename == "/tmp/.hidden/payload"
+  requires:
+    rule: staging
+    within: 1m
+    scope: process
+`
+	if err := yaml.Unmarshal([]byte(policy), &ruleDefs); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rs.AddRules(ruleDefs); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	rs.now = func() time.Time { return now }
+
+	recon := func(name string) *testEvent {
+		return &testEvent{kind: "open", process: testProcess{name: name}, open: testOpen{filename: "/etc/passwd"}}
+	}
+	staging := func(name string) *testEvent {
+		return &testEvent{kind: "mkdir", process: testProcess{name: name}, mkdir: testMkdir{filename: "/tmp/.hidden"}}
+	}
+	exfiltration := func(name string) *testEvent {
+		return &testEvent{kind: "open", process: testProcess{name: name}, open: testOpen{filename: "/tmp/.hidden/payload"}}
+	}
+
+	tests := []struct {
+		Name    string
+		Events  []*testEvent
+		Elapsed time.Duration
+		Matches int
+	}{
+		{Name: "not armed", Events: []*testEvent{staging("sh"), exfiltration("sh")}, Matches: 0},
+		{Name: "armed", Events: []*testEvent{recon("sh"), staging("sh")}, Matches: 2},
+		{Name: "chain", Events: []*testEvent{recon("sh"), staging("sh"), exfiltration("sh")}, Matches: 3},
+		{Name: "stays armed", Events: []*testEvent{recon("sh"), staging("sh"), staging("sh")}, Matches: 3},
+		{Name: "another process", Events: []*testEvent{recon("sh"), staging("bash")}, Matches: 1},
+		{Name: "expired", Events: []*testEvent{recon("sh"), staging("sh"), exfiltration("sh")}, Elapsed: 2 * time.Minute, Matches: 2},
+	}
+
+	for _, test := range tests {
+		// disarm the rules of the previous test
+		now = now.Add(time.Hour)
+		handler.matches = 0
+
+		for _, event := range test.Events {
+			if !rs.Evaluate(event) {
+				t.Errorf("%s: the rules should match the events", test.Name)
+			}
+			now = now.Add(test.Elapsed)
+		}
+
+		if handler.matches != test.Matches {
+			t.Errorf("%s: expected %d matches, got %d", test.Name, test.Matches, handler.matches)
+		}
+	}
+
+	invalids := []*RuleDefinition{
+		{ID: "no_rule", Expression: `open.filename == "/etc/passwd"`, Requires: &RequirementDefinition{Within: time.Minute}},
+		{ID: "no_period", Expression: `open.filename == "/etc/passwd"`, Requires: &RequirementDefinition{Rule: "recon"}},
+		{ID: "itself", Expression: `open.filename == "/etc/passwd"`, Requires: &RequirementDefinition{Rule: "itself", Within: time.Minute}},
+		{ID: "unknown_scope", Expression: `open.filename == "/etc/passwd"`, Requires: &RequirementDefinition{Rule: "recon", Within: time.Minute, Scope: "unknown"}},
+	}
+
+	for _, ruleDef := range invalids {
+		if _, err := rs.AddRule(ruleDef); err == nil {
+			t.Errorf("expected an error for the requirement of %s", ruleDef.ID)
+		}
+	}
+
+	for _, short := range []string{`recon`, `recon within`, `recon within 5 minutes`, `recon within 5m for process`} {
+		var def RequirementDefinition
+		if err := yaml.Unmarshal([]byte(short), &def); err == nil {
+			t.Errorf("expected an error for the requirement `%s`", short)
+		}
+	}
+
+	rs = NewRuleSet(model, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	unknown := &RuleDefinition{
+		ID:         "unknown_rule",
+		Expression: `open.filename == "/etc/passwd"`,
+		Requires:   &RequirementDefinition{Rule: "unknown", Within: time.Minute},
+	}
+	if err := rs.AddRules([]*RuleDefinition{unknown}); err == nil {
+		t.Error("expected an error for the unknown rule of the requirement")
+	}
+}
+
+func TestRuleSetDiscarderOfAllEventTypes(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	exprs := []string{
+		`open.filename == "/etc/passwd" && process.name == "cat"`,
+		`mkdir.filename == "/tmp/.hidden" && process.name != "kubelet"`,
+	}
+
+	addRuleExpr(t, rs, exprs...)
+
+	tests := []struct {
+		Name        string
+		IsDiscarder bool
+	}{
+		{Name: "kubelet", IsDiscarder: true},
+		{Name: "cat", IsDiscarder: false},
+		{Name: "sh", IsDiscarder: false},
+	}
+
+	for _, test := range tests {
+		event := &testEvent{kind: "open", process: testProcess{name: test.Name}}
+
+		isDiscarder, err := rs.IsDiscarderOfAllEventTypes(event, "process.name")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if isDiscarder != test.IsDiscarder {
+			t.Errorf("%s: expected discarder %t, got %t", test.Name, test.IsDiscarder, isDiscarder)
+		}
+	}
+}
+
+func TestRuleSetDecisionTable(t *testing.T) {
+	fieldCaps := FieldCapabilities{
+		{Field: "open.flags", Types: eval.BitmaskValueType},
+		{Field: "process.uid", Types: eval.ScalarValueType},
+		{Field: "process.name", Types: eval.ScalarValueType},
+	}
+
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	addRuleExpr(t, rs, `open.flags & O_CREAT > 0 && process.uid == 0`, `process.name == "cat" && open.flags & O_TRUNC == 0`, `open.flags & O_CREAT > 0 && process.uid == 0 && process.name != "cat"`)
+
+	table, err := rs.GetDecisionTable("open", fieldCaps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the third rule is covered by the entry of the first one, the values other than the ones of the rules match any
+	// value
+	expected := DecisionTable{
+		{
+			{Field: "open.flags", Value: syscall.O_CREAT, Type: eval.BitmaskValueType},
+			{Field: "process.uid", Value: 0, Type: eval.ScalarValueType},
+		},
+		{
+			{Field: "process.name", Value: "cat", Type: eval.ScalarValueType},
+		},
+	}
+	if !reflect.DeepEqual(table, expected) {
+		t.Errorf("expected decision table %+v, got %+v", expected, table)
+	}
+
+	// a field outside of the capabilities can't be pre-evaluated
+	rs = NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	addRuleExpr(t, rs, `process.name == "cat" && open.flags & O_CREAT > 0`, `open.filename == "/etc/passwd"`)
+
+	if _, err := rs.GetDecisionTable("open", fieldCaps); err == nil {
+		t.Error("expected an error for the field outside of the capabilities")
+	}
+}
+
+func TestRuleSetRuleApprovers(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd"`, `open.filename != "/etc/shadow"`)
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	// a single rule without approver prevents the approvers of the event type
+	if _, err := rs.GetApprovers("open", caps); err == nil {
+		t.Fatal("shouldn't get any approver")
+	}
+
+	approvers, err := rs.GetRuleApprovers("ID0", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values, exists := approvers["open.filename"]; !exists || len(values) != 1 || values[0].Value != "/etc/passwd" {
+		t.Fatalf("expected approver not found: %v", values)
+	}
+
+	if _, err := rs.GetRuleApprovers("ID1", caps); err == nil {
+		t.Error("shouldn't get any approver for the rule ID1")
+	}
+
+	if _, err := rs.GetRuleApprovers("unknown", caps); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ScheduleDefinition holds the definition of the schedule of a rule. A rule is active during its periods, all the
+// time if it has none, except during its silenced windows, maintenance windows for instance
+type ScheduleDefinition struct {
+	Timezone string              `yaml:"timezone"`
+	Periods  []*PeriodDefinition `yaml:"periods"`
+	Silenced []*WindowDefinition `yaml:"silenced"`
+}
+
+// PeriodDefinition holds the definition of a weekly period, from `From` to `To`, formatted as 15:04, on the given
+// days, every day if none. A period ending before it starts ends on the next day
+type PeriodDefinition struct {
+	Days []string `yaml:"days"`
+	From string   `yaml:"from"`
+	To   string   `yaml:"to"`
+}
+
+// WindowDefinition holds the definition of a window of time, its start and end are formatted as RFC 3339
+type WindowDefinition struct {
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+}
+
+type period struct {
+	days     map[time.Weekday]bool
+	from, to time.Duration
+}
+
+func (p *period) onDay(day time.Weekday) bool {
+	return len(p.days) == 0 || p.days[day]
+}
+
+func (p *period) contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	day := t.Weekday()
+
+	switch {
+	case p.from == p.to:
+		return p.onDay(day)
+	case p.from < p.to:
+		return p.onDay(day) && offset >= p.from && offset < p.to
+	default:
+		// the period started on the previous day
+		return (p.onDay(day) && offset >= p.from) || (p.onDay((day+6)%7) && offset < p.to)
+	}
+}
+
+type window struct {
+	start, end time.Time
+}
+
+// schedule is the compiled form of a ScheduleDefinition
+type schedule struct {
+	location *time.Location
+	periods  []period
+	silenced []window
+}
+
+func parseDayOffset(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day `%s`", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func parseWeekday(value string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if value = strings.ToLower(value); value == name || value == name[:3] {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid day `%s`", value)
+}
+
+func newSchedule(def *ScheduleDefinition) (*schedule, error) {
+	location := time.Local
+	if def.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(def.Timezone); err != nil {
+			return nil, errors.Wrapf(err, "invalid timezone `%s`", def.Timezone)
+		}
+	}
+
+	s := &schedule{location: location}
+
+	for _, periodDef := range def.Periods {
+		from, err := parseDayOffset(periodDef.From)
+		if err != nil {
+			return nil, err
+		}
+
+		to, err := parseDayOffset(periodDef.To)
+		if err != nil {
+			return nil, err
+		}
+
+		p := period{from: from, to: to, days: make(map[time.Weekday]bool)}
+		for _, name := range periodDef.Days {
+			day, err := parseWeekday(name)
+			if err != nil {
+				return nil, err
+			}
+			p.days[day] = true
+		}
+
+		s.periods = append(s.periods, p)
+	}
+
+	for _, windowDef := range def.Silenced {
+		start, err := time.Parse(time.RFC3339, windowDef.Start)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid window start `%s`", windowDef.Start)
+		}
+
+		end, err := time.Parse(time.RFC3339, windowDef.End)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid window end `%s`", windowDef.End)
+		}
+
+		if !end.After(start) {
+			return nil, fmt.Errorf("window ending before it starts: %s - %s", windowDef.Start, windowDef.End)
+		}
+
+		s.silenced = append(s.silenced, window{start: start, end: end})
+	}
+
+	return s, nil
+}
+
+// isActive returns whether the rule is active at the given time
+func (s *schedule) isActive(t time.Time) bool {
+	for _, w := range s.silenced {
+		if !t.Before(w.start) && t.Before(w.end) {
+			return false
+		}
+	}
+
+	if len(s.periods) == 0 {
+		return true
+	}
+
+	t = t.In(s.location)
+	for _, p := range s.periods {
+		if p.contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
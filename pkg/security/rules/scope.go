@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// ScopeDefinition holds the workloads a rule applies to, checked before its expression is evaluated. A rule either
+// applies to the containers whose image matches `Image`, a pattern where `*` matches any sequence of characters, and
+// having all the labels of `Labels`, or only to the processes running on the host, outside of any container
+type ScopeDefinition struct {
+	Image  string            `yaml:"image"`
+	Labels map[string]string `yaml:"labels"`
+	Host   bool              `yaml:"host"`
+}
+
+// Workload holds the container an event comes from, its ID is empty for the events of the host
+type Workload struct {
+	ContainerID string
+	ImageName   string
+	Labels      map[string]string
+}
+
+// IsResolved returns whether the image and the labels of the container were resolved, the scopes of a workload that
+// isn't may change once it is
+func (w *Workload) IsResolved() bool {
+	return w.ContainerID == "" || (w.ImageName != "" && w.Labels != nil)
+}
+
+// WorkloadResolver returns the workload of the event of the given context
+type WorkloadResolver func(ctx *eval.Context) *Workload
+
+type workloadScope struct {
+	image  *regexp.Regexp
+	labels map[string]string
+	host   bool
+}
+
+func newWorkloadScope(def *ScopeDefinition) (*workloadScope, error) {
+	if def.Host && (def.Image != "" || len(def.Labels) > 0) {
+		return nil, errors.New("a host scope can't select an image or labels")
+	}
+
+	if !def.Host && def.Image == "" && len(def.Labels) == 0 {
+		return nil, errors.New("a scope should select an image, labels or the host")
+	}
+
+	s := &workloadScope{
+		labels: def.Labels,
+		host:   def.Host,
+	}
+
+	if def.Image != "" {
+		parts := strings.Split(def.Image, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+
+		var err error
+		if s.image, err = regexp.Compile("^" + strings.Join(parts, ".*") + "$"); err != nil {
+			return nil, errors.Wrapf(err, "invalid image pattern `%s`", def.Image)
+		}
+	}
+
+	return s, nil
+}
+
+// matches returns whether the given workload is part of the scope
+func (s *workloadScope) matches(workload *Workload) bool {
+	if s.host {
+		return workload.ContainerID == ""
+	}
+
+	if workload.ContainerID == "" {
+		return false
+	}
+
+	if s.image != nil && !s.image.MatchString(workload.ImageName) {
+		return false
+	}
+
+	for key, value := range s.labels {
+		if label, exists := workload.Labels[key]; !exists || label != value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// SequenceDefinition holds the rules that have to match, in order and within the given period, before the rule
+// defining the sequence can fire. With a scope, session or container for instance, the rules have to match events
+// of the same entry of the scope
+type SequenceDefinition struct {
+	After  []RuleID      `yaml:"after"`
+	Within time.Duration `yaml:"within"`
+	Scope  string        `yaml:"scope"`
+}
+
+// sequenceProgress holds the progress of a sequence for an entry of its scope
+type sequenceProgress struct {
+	next  int
+	start time.Time
+}
+
+// sequence tracks the progress of the sequence of a rule, per entry of its scope
+type sequence struct {
+	sync.Mutex
+	ruleID eval.RuleID
+	steps  []RuleID
+	within time.Duration
+	scope  VariableScope
+	// progress holds the progress of the sequence per entry of the scope, the least recently active entries are
+	// dropped first
+	progress *lru.Cache
+}
+
+func newSequence(ruleID eval.RuleID, def *SequenceDefinition, scope VariableScope) (*sequence, error) {
+	progress, err := lru.New(maxScopeEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sequence{
+		ruleID:   ruleID,
+		steps:    def.After,
+		within:   def.Within,
+		scope:    scope,
+		progress: progress,
+	}, nil
+}
+
+func (s *sequence) key(ctx *eval.Context) interface{} {
+	if s.scope == nil {
+		return globalScopeKey{}
+	}
+	return s.scope(ctx)
+}
+
+// getProgress returns the progress of the sequence for the given entry, nil if the sequence isn't started or expired
+func (s *sequence) getProgress(key interface{}, now time.Time) *sequenceProgress {
+	value, found := s.progress.Get(key)
+	if !found {
+		return nil
+	}
+
+	progress := value.(*sequenceProgress)
+	if now.Sub(progress.start) > s.within {
+		s.progress.Remove(key)
+		return nil
+	}
+
+	return progress
+}
+
+// advance records the match of the given step rule
+func (s *sequence) advance(ctx *eval.Context, ruleID eval.RuleID, now time.Time) {
+	key := s.key(ctx)
+	if key == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	progress := s.getProgress(key, now)
+	if progress != nil && progress.next < len(s.steps) && s.steps[progress.next] == ruleID {
+		progress.next++
+		return
+	}
+
+	// a new match of the first step restarts the sequence from this event
+	if s.steps[0] == ruleID && (progress == nil || progress.next <= 1) {
+		s.progress.Add(key, &sequenceProgress{next: 1, start: now})
+	}
+}
+
+// complete returns true if all the steps of the sequence matched, in which case the sequence is reset
+func (s *sequence) complete(ctx *eval.Context, now time.Time) bool {
+	key := s.key(ctx)
+	if key == nil {
+		return false
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	progress := s.getProgress(key, now)
+	if progress == nil || progress.next < len(s.steps) {
+		return false
+	}
+
+	s.progress.Remove(key)
+	return true
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import "fmt"
+
+// Severity represents the severity of a rule, and thus of its events
+type Severity int
+
+const (
+	// SeverityInfo is the severity of informational rules
+	SeverityInfo Severity = iota
+	// SeverityLow is the low severity
+	SeverityLow
+	// SeverityMedium is the medium severity, the one of the rules without severity
+	SeverityMedium
+	// SeverityHigh is the high severity
+	SeverityHigh
+	// SeverityCritical is the critical severity
+	SeverityCritical
+)
+
+var severityNames = []string{"info", "low", "medium", "high", "critical"}
+
+func (s Severity) String() string {
+	if s < SeverityInfo || s > SeverityCritical {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity returns the severity of the given name, an empty name is the medium severity
+func ParseSeverity(name string) (Severity, error) {
+	if name == "" {
+		return SeverityMedium, nil
+	}
+
+	for severity, severityName := range severityNames {
+		if severityName == name {
+			return Severity(severity), nil
+		}
+	}
+
+	return SeverityMedium, fmt.Errorf("unknown severity `%s`", name)
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// SuppressionDefinition holds the definition of a suppression of a rule: an exception expression evaluated when the
+// rule matches, the match being ignored if the exception matches as well
+type SuppressionDefinition struct {
+	ID         string `yaml:"id"`
+	Expression string `yaml:"expression"`
+}
+
+// SuppressionHits holds the number of matches ignored by each suppression of a rule
+type SuppressionHits map[string]int64
+
+type suppression struct {
+	id   string
+	rule *eval.Rule
+	hits int64
+}
+
+// newSuppression compiles a suppression of the given rule, its expression can only reference the fields of the
+// events of the rule
+func (rs *RuleSet) newSuppression(rule *eval.Rule, def *SuppressionDefinition) (*suppression, error) {
+	suppressionRule := &eval.Rule{
+		ID:         rule.ID,
+		Expression: def.Expression,
+	}
+
+	if err := suppressionRule.Parse(); err != nil {
+		return nil, err
+	}
+
+	if err := suppressionRule.GenEvaluator(rs.model, &rs.opts.Opts); err != nil {
+		return nil, err
+	}
+
+	for _, eventType := range suppressionRule.GetEventTypes() {
+		found := false
+		for _, ruleEventType := range rule.GetEventTypes() {
+			if eventType == ruleEventType {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("the event type `%s` of the suppression doesn't match the one of the rule", eventType)
+		}
+	}
+
+	return &suppression{id: def.ID, rule: suppressionRule}, nil
+}
+
+// suppressedBy returns the suppression of the given rule matching the event of the context, if any
+func (rs *RuleSet) suppressedBy(id eval.RuleID, ctx *eval.Context) *suppression {
+	for _, s := range rs.suppressions[id] {
+		if s.rule.Eval(ctx) {
+			return s
+		}
+	}
+	return nil
+}
+
+// GetSuppressionHits returns the number of matches ignored by the suppressions of each rule since the last call
+func (rs *RuleSet) GetSuppressionHits() map[eval.RuleID]SuppressionHits {
+	result := make(map[eval.RuleID]SuppressionHits)
+	for id, ruleSuppressions := range rs.suppressions {
+		for _, s := range ruleSuppressions {
+			if hits := atomic.SwapInt64(&s.hits, 0); hits > 0 {
+				if result[id] == nil {
+					result[id] = make(SuppressionHits)
+				}
+				result[id][s.id] += hits
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// ThresholdDefinition holds the number of matching events required within a sliding period for a rule to fire. With
+// a scope, process, container or user for instance, the events are counted per entry of the scope
+type ThresholdDefinition struct {
+	Count  int           `yaml:"count"`
+	Period time.Duration `yaml:"period"`
+	Scope  string        `yaml:"scope"`
+}
+
+// threshold counts the matches of a rule, per entry of its scope
+type threshold struct {
+	sync.Mutex
+	count  int
+	period time.Duration
+	scope  VariableScope
+	// matches holds the timestamps of the matches of the current period, per entry of the scope
+	matches *lru.Cache
+}
+
+func newThreshold(def *ThresholdDefinition, scope VariableScope) (*threshold, error) {
+	matches, err := lru.New(maxScopeEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &threshold{
+		count:   def.Count,
+		period:  def.Period,
+		scope:   scope,
+		matches: matches,
+	}, nil
+}
+
+// hit records a match of the rule and returns true if the threshold is reached, in which case the count of the
+// entry is reset. The events that don't belong to any entry of the scope are not counted
+func (t *threshold) hit(ctx *eval.Context, now time.Time) bool {
+	var key interface{} = globalScopeKey{}
+	if t.scope != nil {
+		if key = t.scope(ctx); key == nil {
+			return false
+		}
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	var timestamps []time.Time
+	if value, found := t.matches.Get(key); found {
+		timestamps = value.([]time.Time)
+	}
+
+	// drop the matches that slid out of the period
+	start := now.Add(-t.period)
+	for len(timestamps) > 0 && !timestamps[0].After(start) {
+		timestamps = timestamps[1:]
+	}
+	timestamps = append(timestamps, now)
+
+	if len(timestamps) >= t.count {
+		t.matches.Remove(key)
+		return true
+	}
+
+	t.matches.Add(key, timestamps)
+	return false
+}
@@ -6,6 +6,7 @@
 package rules
 
 import (
+	"net"
 	"reflect"
 
 	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
@@ -81,82 +82,135 @@ func combineBitmasks(bitmasks []int) []int {
 	return result
 }
 
-func genFilterValues(rule *eval.Rule, event eval.Event) ([]FilterValues, error) {
-	var filterValues []FilterValues
-	for field, fValues := range rule.GetEvaluator().FieldValues {
-		// case where there is no static value, ex: process.gid == process.uid
-		// so generate fake value in order to be able to get the truth table
-		// note that we want to have the comparison returning true
-		if len(fValues) == 0 {
-			var value interface{}
+// genFieldFilterValues returns the values of a field used to generate the truth table of a rule: the values the
+// rule compares the field to, along with values different from all of them
+func genFieldFilterValues(field eval.Field, fValues []eval.FieldValue, event eval.Event) (FilterValues, error) {
+	// case where there is no static value, ex: process.gid == process.uid
+	// so generate fake value in order to be able to get the truth table
+	// note that we want to have the comparison returning true
+	if len(fValues) == 0 {
+		var value interface{}
+
+		kind, err := event.GetFieldType(field)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case reflect.String:
+			// an empty value would be resolved by the model, a path from its inode for instance
+			value = "/"
+		case reflect.Int:
+			value = 0
+		case reflect.Bool:
+			value = false
+		default:
+			return nil, &ErrFieldTypeUnknown{Field: field}
+		}
 
-			kind, err := event.GetFieldType(field)
+		return FilterValues{
+			{
+				Field:  field,
+				Value:  value,
+				Type:   eval.ScalarValueType,
+				ignore: true,
+			},
+		}, nil
+	}
+
+	var bitmasks []int
+
+	var values FilterValues
+	for _, fValue := range fValues {
+		switch fValue.Type {
+		case eval.ScalarValueType, eval.PatternValueType:
+			values = append(values, FilterValue{
+				Field: field,
+				Value: fValue.Value,
+				Type:  fValue.Type,
+			})
+
+			notValue, err := notOfValue(fValue.Value)
 			if err != nil {
-				return nil, err
+				return nil, &ErrValueTypeUnknown{Field: field}
 			}
-			switch kind {
-			case reflect.String:
-				value = ""
-			case reflect.Int:
-				value = 0
-			case reflect.Bool:
-				value = false
-			default:
-				return nil, &ErrFieldTypeUnknown{Field: field}
+
+			values = append(values, FilterValue{
+				Field: field,
+				Value: notValue,
+				Type:  fValue.Type,
+				Not:   true,
+			})
+		case eval.BitmaskValueType:
+			bitmasks = append(bitmasks, fValue.Value.(int))
+		case eval.CIDRValueType:
+			// an address of a range matches it, a random string never does
+			ip, _, err := net.ParseCIDR(fValue.Value.(string))
+			if err != nil {
+				ip = net.ParseIP(fValue.Value.(string))
 			}
 
-			filterValues = append(filterValues, FilterValues{
-				{
-					Field:  field,
-					Value:  value,
-					Type:   eval.ScalarValueType,
-					ignore: true,
-				},
+			values = append(values, FilterValue{
+				Field: field,
+				Value: ip.String(),
+				Type:  fValue.Type,
 			})
 
-			continue
-		}
+			notValue, err := notOfValue(fValue.Value)
+			if err != nil {
+				return nil, &ErrValueTypeUnknown{Field: field}
+			}
 
-		var bitmasks []int
-
-		var values FilterValues
-		for _, fValue := range fValues {
-			switch fValue.Type {
-			case eval.ScalarValueType, eval.PatternValueType:
-				values = append(values, FilterValue{
-					Field: field,
-					Value: fValue.Value,
-					Type:  fValue.Type,
-				})
-
-				notValue, err := notOfValue(fValue.Value)
-				if err != nil {
-					return nil, &ErrValueTypeUnknown{Field: field}
-				}
+			values = append(values, FilterValue{
+				Field: field,
+				Value: notValue,
+				Type:  fValue.Type,
+				Not:   true,
+			})
+		case eval.RangeValueType:
+			// the lower bound of a range matches it, its approvers are the ranges themselves
+			r := fValue.Value.(eval.IntRange)
+
+			values = append(values, FilterValue{
+				Field: field,
+				Value: r,
+				Type:  fValue.Type,
+			})
 
-				values = append(values, FilterValue{
-					Field: field,
-					Value: notValue,
-					Type:  fValue.Type,
-					Not:   true,
-				})
-			case eval.BitmaskValueType:
-				bitmasks = append(bitmasks, fValue.Value.(int))
-			}
+			values = append(values, FilterValue{
+				Field: field,
+				Value: ^r.Min,
+				Type:  fValue.Type,
+				Not:   true,
+			})
+		case eval.RegexpValueType, eval.ListValueType, eval.CaseInsensitivePatternValueType:
+			// no matching value can be derived from a regexp, nor from a list whose values can be replaced, nor from a
+			// case-insensitive pattern, the truth table can't be generated
+			return nil, &ErrValueTypeUnknown{Field: field}
 		}
+	}
 
-		// add combinations of bitmask if bitmasks are used
-		if len(bitmasks) > 0 {
-			for _, mask := range combineBitmasks(bitmasks) {
-				values = append(values, FilterValue{
-					Field: field,
-					Value: mask,
-					Type:  eval.BitmaskValueType,
-					Not:   mask == 0,
-				})
-			}
+	// add combinations of bitmask if bitmasks are used
+	if len(bitmasks) > 0 {
+		for _, mask := range combineBitmasks(bitmasks) {
+			values = append(values, FilterValue{
+				Field: field,
+				Value: mask,
+				Type:  eval.BitmaskValueType,
+				Not:   mask == 0,
+			})
 		}
+	}
+
+	return values, nil
+}
 
+func genFilterValues(rule *eval.Rule, event eval.Event) ([]FilterValues, error) {
+	var filterValues []FilterValues
+	for field, fValues := range rule.GetEvaluator().FieldValues {
+		values, err := genFieldFilterValues(field, fValues, event)
+		if err != nil {
+			return nil, err
+		}
 		filterValues = append(filterValues, values)
 	}
 
@@ -208,7 +262,7 @@ func newTruthTable(rule *eval.Rule, event eval.Event) (*truthTable, error) {
 		var entry truthEntry
 
 		for _, filterValue := range combination {
-			if err = event.SetFieldValue(filterValue.Field, filterValue.Value); err != nil {
+			if err = event.SetFieldValue(filterValue.Field, filterValue.fieldValue()); err != nil {
 				return nil, err
 			}
 
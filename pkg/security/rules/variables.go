@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// maxScopeEntries is the maximum number of entries, processes or containers for instance, for which the variables
+// of a scope are kept. The least recently used entries are dropped first
+const maxScopeEntries = 8192
+
+// VariableScope returns the key of the entry of a scope, a process or a container for instance, the evaluated
+// event belongs to. A nil key means that the event doesn't belong to any entry of the scope
+type VariableScope func(ctx *eval.Context) interface{}
+
+// globalScopeKey is the key of the single entry of the thresholds and sequences without scope
+type globalScopeKey struct{}
+
+// scopedVariables holds the values of the variables of a scope, per entry of the scope
+type scopedVariables struct {
+	sync.RWMutex
+	scope   VariableScope
+	entries *lru.Cache
+}
+
+func newScopedVariables(scope VariableScope) (*scopedVariables, error) {
+	entries, err := lru.New(maxScopeEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scopedVariables{
+		scope:   scope,
+		entries: entries,
+	}, nil
+}
+
+// get returns the value of a variable for the entry of the evaluated event, nil if the variable wasn't set
+func (s *scopedVariables) get(ctx *eval.Context, name string) interface{} {
+	key := s.scope(ctx)
+	if key == nil {
+		return nil
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	values, found := s.entries.Get(key)
+	if !found {
+		return nil
+	}
+
+	return values.(map[string]interface{})[name]
+}
+
+// set sets the value of a variable for the entry of the evaluated event
+func (s *scopedVariables) set(ctx *eval.Context, name string, value interface{}) {
+	key := s.scope(ctx)
+	if key == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	values, found := s.entries.Get(key)
+	if !found {
+		values = make(map[string]interface{})
+		s.entries.Add(key, values)
+	}
+
+	values.(map[string]interface{})[name] = value
+}
+
+// release drops the values of the variables of the given entry
+func (s *scopedVariables) release(key interface{}) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.entries.Remove(key)
+}
+
+// newVariable returns a SECL variable reading its value from the given scope, an unset variable evaluates to the
+// zero value of its type
+func (s *scopedVariables) newVariable(name string, value interface{}) (eval.VariableValue, error) {
+	switch value.(type) {
+	case bool:
+		return eval.NewBoolVariable(func(ctx *eval.Context) bool {
+			value, _ := s.get(ctx, name).(bool)
+			return value
+		}), nil
+	case int:
+		return eval.NewIntVariable(func(ctx *eval.Context) int {
+			value, _ := s.get(ctx, name).(int)
+			return value
+		}), nil
+	case string:
+		return eval.NewStringVariable(func(ctx *eval.Context) string {
+			value, _ := s.get(ctx, name).(string)
+			return value
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T for the value of the variable '%s'", value, name)
+	}
+}
+
+// sameVariableType returns whether the given value has the type of the variable
+func sameVariableType(variable eval.VariableValue, value interface{}) bool {
+	switch variable.(type) {
+	case *eval.BoolVariable:
+		_, ok := value.(bool)
+		return ok
+	case *eval.IntVariable:
+		_, ok := value.(int)
+		return ok
+	case *eval.StringVariable:
+		_, ok := value.(string)
+		return ok
+	}
+	return false
+}
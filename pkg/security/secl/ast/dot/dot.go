@@ -9,8 +9,8 @@ import (
 	"fmt"
 	"io"
 	"reflect"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/security/secl/ast"
 )
@@ -90,6 +90,8 @@ func (d *Marshaler) getID(n interface{}) (string, error) {
 		return fmt.Sprintf("ArrayComparison%d", n.Pos.Offset), nil
 	case *ast.Array:
 		return fmt.Sprintf("Array%d", n.Pos.Offset), nil
+	case *ast.Argument:
+		return fmt.Sprintf("Argument%d", n.Pos.Offset), nil
 	case *ast.BooleanExpression:
 		return fmt.Sprintf("BooleanExpression%d", n.Pos.Offset), nil
 	case *ast.BitOperation:
@@ -150,6 +152,11 @@ func (d *Marshaler) getChildren(n interface{}) ([]interface{}, error) {
 			n.Next,
 		}, nil
 	case *ast.Array:
+		if n.List != nil {
+			return []interface{}{
+				newNode(fmt.Sprintf("Array%p", n), "@"+*n.List),
+			}, nil
+		}
 		if len(n.Strings) > 0 {
 			return []interface{}{
 				newNode(fmt.Sprintf("Array%p", n), strings.Join(n.Strings, ",")),
@@ -158,14 +165,39 @@ func (d *Marshaler) getChildren(n interface{}) ([]interface{}, error) {
 		s := ""
 		for i, n := range n.Numbers {
 			if i != 0 {
-				s += ", " + strconv.Itoa(n)
+				s += ", " + n.String()
 			} else {
-				s += strconv.Itoa(n)
+				s += n.String()
 			}
 		}
 		return []interface{}{
 			newNode(fmt.Sprintf("Array%p", n), s),
 		}, nil
+	case *ast.Argument:
+		if n.List != nil {
+			return []interface{}{
+				newNode(fmt.Sprintf("Argument%p", n), "@"+*n.List),
+			}, nil
+		}
+		if len(n.Strings) > 0 {
+			return []interface{}{
+				newNode(fmt.Sprintf("Argument%p", n), strings.Join(n.Strings, ",")),
+			}, nil
+		}
+		if len(n.Numbers) > 0 {
+			s := ""
+			for i, n := range n.Numbers {
+				if i != 0 {
+					s += ", " + n.String()
+				} else {
+					s += n.String()
+				}
+			}
+			return []interface{}{
+				newNode(fmt.Sprintf("Argument%p", n), s),
+			}, nil
+		}
+		return []interface{}{n.Primary}, nil
 	case *ast.BitOperation:
 		children := []interface{}{n.Unary}
 		if n.Op != nil {
@@ -189,14 +221,30 @@ func (d *Marshaler) getChildren(n interface{}) ([]interface{}, error) {
 		return children, nil
 	case *ast.Primary:
 		if n.Ident != nil {
-			return []interface{}{newNode(fmt.Sprintf("Ident%p", n.Ident), fmt.Sprintf("Ident\\n%s", *n.Ident))}, nil
+			children := []interface{}{newNode(fmt.Sprintf("Ident%p", n.Ident), fmt.Sprintf("Ident\\n%s", *n.Ident))}
+			for _, argument := range n.Arguments {
+				children = append(children, argument)
+			}
+			return children, nil
+		}
+		if n.Variable != nil {
+			return []interface{}{newNode(fmt.Sprintf("Variable%p", n.Variable), fmt.Sprintf("Variable\\n%s", *n.Variable))}, nil
 		}
 		if n.Number != nil {
 			return []interface{}{newNode(fmt.Sprintf("Number%p", n.Number), fmt.Sprintf("Number\\n%d", *n.Number))}, nil
 		}
+		if n.Duration != nil {
+			return []interface{}{newNode(fmt.Sprintf("Duration%p", n.Duration), fmt.Sprintf("Duration\\n%s", time.Duration(*n.Duration)))}, nil
+		}
 		if n.String != nil {
 			return []interface{}{newNode(fmt.Sprintf("String%p", n.String), fmt.Sprintf("String\\n%s", *n.String))}, nil
 		}
+		if n.Regexp != nil {
+			return []interface{}{newNode(fmt.Sprintf("Regexp%p", n.Regexp), fmt.Sprintf("Regexp\\n%s", *n.Regexp))}, nil
+		}
+		if n.CaseInsensitiveString != nil {
+			return []interface{}{newNode(fmt.Sprintf("CaseInsensitiveString%p", n.CaseInsensitiveString), fmt.Sprintf("CaseInsensitiveString\\n%s", *n.CaseInsensitiveString))}, nil
+		}
 		if n.SubExpression != nil {
 			return []interface{}{n.SubExpression}, nil
 		}
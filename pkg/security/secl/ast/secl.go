@@ -7,6 +7,9 @@ package ast
 
 import (
 	"bytes"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/participle"
 	"github.com/alecthomas/participle/lexer"
@@ -15,8 +18,14 @@ import (
 
 var (
 	seclLexer = lexer.Must(ebnf.New(`
+Variable = "${" (alpha | "_") { "_" | alpha | digit | "." } "}" .
+Regexp = "r\"" { "\u0000"…"\uffff"-"\""-"\\" | "\\" any } "\"" .
+IP = "ip\"" { "\u0000"…"\uffff"-"\""-"\\" | "\\" any } "\"" .
+CaseInsensitiveString = "i\"" { "\u0000"…"\uffff"-"\""-"\\" | "\\" any } "\"" .
 Ident = (alpha | "_") { "_" | alpha | digit | "." } .
 String = "\"" { "\u0000"…"\uffff"-"\""-"\\" | "\\" any } "\"" .
+Duration = digit { digit } ( "m" [ "s" ] | "s" | "h" | "d" ) .
+Size = digit { digit } ( "KB" | "MB" | "GB" | "TB" ) .
 Int = [ "-" | "+" ] digit { digit } .
 Punct = "!"…"/" | ":"…"@" | "["…` + "\"`\"" + ` | "{"…"~" .
 Whitespace = ( " " | "\t" ) { " " | "\t" } .
@@ -26,12 +35,99 @@ any = "\u0000"…"\uffff" .
 `))
 )
 
+// durationUnits holds the units of the duration literals
+var durationUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+}
+
+// sizeUnits holds the units of the size literals, in bytes
+var sizeUnits = map[string]int{
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+}
+
+// splitUnit splits a literal with a unit, `30s` for instance, into its number and its unit
+func splitUnit(token lexer.Token) (int, string, error) {
+	i := strings.IndexFunc(token.Value, func(r rune) bool { return r < '0' || r > '9' })
+
+	value, err := strconv.Atoi(token.Value[:i])
+	if err != nil {
+		return 0, "", lexer.Errorf(token.Pos, "invalid number %s: %s", token.Value, err)
+	}
+	return value, token.Value[i:], nil
+}
+
+// convertDuration converts a duration literal, `30s` for instance, to a number of nanoseconds
+func convertDuration(token lexer.Token) (lexer.Token, error) {
+	value, unit, err := splitUnit(token)
+	if err != nil {
+		return token, err
+	}
+	token.Value = strconv.FormatInt(int64(time.Duration(value)*durationUnits[unit]), 10)
+	return token, nil
+}
+
+// convertSize converts a size literal, `100MB` for instance, to a number of bytes
+func convertSize(token lexer.Token) (lexer.Token, error) {
+	value, unit, err := splitUnit(token)
+	if err != nil {
+		return token, err
+	}
+	token.Value = strconv.Itoa(value * sizeUnits[unit])
+	return token, nil
+}
+
+// unquoteVariable strips the delimiters of a variable reference
+func unquoteVariable(token lexer.Token) (lexer.Token, error) {
+	token.Value = token.Value[2 : len(token.Value)-1]
+	return token, nil
+}
+
+// unquoteRegexp strips the delimiters of a regexp, the backslashes are kept as they are part of the regexp syntax,
+// except the ones escaping a double quote
+func unquoteRegexp(token lexer.Token) (lexer.Token, error) {
+	token.Value = strings.Replace(token.Value[2:len(token.Value)-1], `\"`, `"`, -1)
+	return token, nil
+}
+
+// unquoteCaseInsensitiveString strips the case-insensitive modifier and unquotes the string
+func unquoteCaseInsensitiveString(token lexer.Token) (lexer.Token, error) {
+	value, err := strconv.Unquote(token.Value[1:])
+	if err != nil {
+		return token, lexer.Errorf(token.Pos, "invalid string %s: %s", token.Value, err)
+	}
+	token.Value = value
+	return token, nil
+}
+
+// unquoteIP strips the IP modifier and unquotes the IP address or CIDR range
+func unquoteIP(token lexer.Token) (lexer.Token, error) {
+	value, err := strconv.Unquote(token.Value[2:])
+	if err != nil {
+		return token, lexer.Errorf(token.Pos, "invalid IP %s: %s", token.Value, err)
+	}
+	token.Value = value
+	return token, nil
+}
+
 // ParseRule parses a SECL rule.
 func ParseRule(expr string) (*Rule, error) {
 	parser, err := participle.Build(&Rule{},
 		participle.Lexer(seclLexer),
 		participle.Elide("Whitespace"),
-		participle.Unquote("String"))
+		participle.Unquote("String"),
+		participle.Map(unquoteVariable, "Variable"),
+		participle.Map(unquoteRegexp, "Regexp"),
+		participle.Map(unquoteCaseInsensitiveString, "CaseInsensitiveString"),
+		participle.Map(unquoteIP, "IP"),
+		participle.Map(convertDuration, "Duration"),
+		participle.Map(convertSize, "Size"))
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +156,13 @@ func ParseMacro(expr string) (*Macro, error) {
 	parser, err := participle.Build(&Macro{},
 		participle.Lexer(seclLexer),
 		participle.Elide("Whitespace"),
-		participle.Unquote("String"))
+		participle.Unquote("String"),
+		participle.Map(unquoteVariable, "Variable"),
+		participle.Map(unquoteRegexp, "Regexp"),
+		participle.Map(unquoteCaseInsensitiveString, "CaseInsensitiveString"),
+		participle.Map(unquoteIP, "IP"),
+		participle.Map(convertDuration, "Duration"),
+		participle.Map(convertSize, "Size"))
 	if err != nil {
 		return nil, err
 	}
@@ -125,12 +227,12 @@ type ArrayComparison struct {
 	Array *Array  `parser:"@@ )"`
 }
 
-// BitOperation describes an operation on bits
+// BitOperation describes an operation on bits or an arithmetic operation, an addition or a subtraction
 type BitOperation struct {
 	Pos lexer.Position
 
 	Unary *Unary        `parser:"@@"`
-	Op    *string       `parser:"[ @( \"&\" | \"|\" | \"^\" )"`
+	Op    *string       `parser:"[ @( \"&\" | \"|\" | \"^\" | \"+\" | \"-\" )"`
 	Next  *BitOperation `parser:"@@ ]"`
 }
 
@@ -143,22 +245,57 @@ type Unary struct {
 	Primary *Primary `parser:"| @@"`
 }
 
-// Primary describes a single operand. It can be a simple identifier, a number,
-// a string or a full expression in parenthesis
+// Primary describes a single operand. It can be a simple identifier, a variable, a number, a size, a duration,
+// a string or a full expression in parenthesis. Sizes are converted to bytes and durations to nanoseconds
 type Primary struct {
 	Pos lexer.Position
 
-	Ident         *string     `parser:"@Ident"`
-	Number        *int        `parser:"| @Int"`
-	String        *string     `parser:"| @String"`
-	SubExpression *Expression `parser:"| \"(\" @@ \")\""`
+	Ident                 *string     `parser:"( @Ident"`
+	Arguments             []*Argument `parser:"[ \"(\" @@ { \",\" @@ } \")\" ] )"`
+	Variable              *string     `parser:"| @Variable"`
+	Number                *int        `parser:"| @( Int | Size )"`
+	Duration              *int        `parser:"| @Duration"`
+	String                *string     `parser:"| @String"`
+	Regexp                *string     `parser:"| @Regexp"`
+	CaseInsensitiveString *string     `parser:"| @CaseInsensitiveString"`
+	SubExpression         *Expression `parser:"| \"(\" @@ \")\""`
+}
+
+// Argument describes an argument of a macro call
+type Argument struct {
+	Pos lexer.Position
+
+	Strings []string  `parser:"\"[\" @String { \",\" @String } \"]\""`
+	Numbers []*Number `parser:"| \"[\" @@ { \",\" @@ } \"]\""`
+	List    *string   `parser:"| \"@\" @Ident"`
+	Primary *Primary  `parser:"| @@"`
+}
+
+// Number describes a number of an array, or a range of numbers when it has an upper bound, `1000..2000` for instance.
+// The bounds of a range are included
+type Number struct {
+	Pos lexer.Position
+
+	Value int  `parser:"@( Int | Size )"`
+	To    *int `parser:"[ \".\" \".\" @( Int | Size ) ]"`
+}
+
+// String returns the number or the range, as written in a rule
+func (n *Number) String() string {
+	if n.To != nil {
+		return strconv.Itoa(n.Value) + ".." + strconv.Itoa(*n.To)
+	}
+	return strconv.Itoa(n.Value)
 }
 
-// Array describes an array of values
+// Array describes an array of values. An array of IP addresses and CIDR ranges, `[ip"10.0.0.0/8", ip"::1"]` for
+// instance, matches the IP addresses it covers
 type Array struct {
 	Pos lexer.Position
 
-	Strings []string `parser:"\"[\" @String { \",\" @String } \"]\""`
-	Numbers []int    `parser:"| \"[\" @Int { \",\" @Int } \"]\""`
-	Ident   *string  `parser:"| @Ident"`
+	Strings []string  `parser:"\"[\" @String { \",\" @String } \"]\""`
+	IPs     []string  `parser:"| \"[\" @IP { \",\" @IP } \"]\""`
+	Numbers []*Number `parser:"| \"[\" @@ { \",\" @@ } \"]\""`
+	Ident   *string   `parser:"| @Ident"`
+	List    *string   `parser:"| \"@\" @Ident"`
 }
@@ -8,6 +8,7 @@ package ast
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func print(t *testing.T, i interface{}) {
@@ -89,6 +90,48 @@ func TestInArrayString(t *testing.T) {
 	print(t, rule)
 }
 
+func TestCompareRegexp(t *testing.T) {
+	rule, err := ParseRule(`process.name =~ r"^/usr/bin/[a-z\"]+$"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	print(t, rule)
+
+	regexp := rule.BooleanExpression.Expression.Comparison.ScalarComparison.Next.BitOperation.Unary.Primary.Regexp
+	if regexp == nil || *regexp != `^/usr/bin/[a-z"]+$` {
+		t.Errorf("unexpected regexp: %v", regexp)
+	}
+}
+
+func TestInArrayIP(t *testing.T) {
+	rule, err := ParseRule(`process.session.source_ip in [ip"10.0.0.0/8", ip"2001:db8::/32"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	print(t, rule)
+
+	ips := rule.BooleanExpression.Expression.Comparison.ArrayComparison.Array.IPs
+	if len(ips) != 2 || ips[0] != "10.0.0.0/8" || ips[1] != "2001:db8::/32" {
+		t.Errorf("unexpected IPs: %v", ips)
+	}
+}
+
+func TestVariable(t *testing.T) {
+	rule, err := ParseRule(`open.filename == "/etc/shadow" && ${process.auth_failed}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	print(t, rule)
+
+	variable := rule.BooleanExpression.Expression.Next.Expression.Comparison.BitOperation.Unary.Primary.Variable
+	if variable == nil || *variable != "process.auth_failed" {
+		t.Errorf("unexpected variable: %v", variable)
+	}
+}
+
 func TestInArrayInteger(t *testing.T) {
 	rule, err := ParseRule(`1 in [ 1, 2, 3 ]`)
 	if err != nil {
@@ -98,6 +141,20 @@ func TestInArrayInteger(t *testing.T) {
 	print(t, rule)
 }
 
+func TestInArrayRange(t *testing.T) {
+	rule, err := ParseRule(`process.uid in [ 0, 1000..2000 ]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	numbers := rule.BooleanExpression.Expression.Comparison.ArrayComparison.Array.Numbers
+	if len(numbers) != 2 || numbers[0].To != nil || numbers[1].Value != 1000 || numbers[1].To == nil || *numbers[1].To != 2000 {
+		t.Errorf("unexpected numbers: %v", numbers)
+	}
+
+	print(t, rule)
+}
+
 func TestMacroList(t *testing.T) {
 	macro, err := ParseMacro(`[ 1, 2, 3 ]`)
 	if err != nil {
@@ -124,3 +181,42 @@ func TestMacroExpression(t *testing.T) {
 
 	print(t, macro)
 }
+
+func TestMacroCall(t *testing.T) {
+	rule, err := ParseRule(`shell_exec("/bin/sh", [ "-c", "-i" ], 1, @shells, O_CREAT) && process.name == "sh"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	print(t, rule)
+
+	primary := rule.BooleanExpression.Expression.Comparison.BitOperation.Unary.Primary
+	if primary.Ident == nil || *primary.Ident != "shell_exec" || len(primary.Arguments) != 5 {
+		t.Fatalf("unexpected macro call: %+v", primary)
+	}
+
+	args := primary.Arguments
+	if *args[0].Primary.String != "/bin/sh" || len(args[1].Strings) != 2 || *args[2].Primary.Number != 1 ||
+		*args[3].List != "shells" || *args[4].Primary.Ident != "O_CREAT" {
+		t.Errorf("unexpected arguments: %+v", args)
+	}
+}
+
+func TestDurationAndSize(t *testing.T) {
+	rule, err := ParseRule(`process.created_at < 90s && process.file.size > 100MB`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	print(t, rule)
+
+	duration := rule.BooleanExpression.Expression.Comparison.ScalarComparison.Next.BitOperation.Unary.Primary.Duration
+	if duration == nil || *duration != int(90*time.Second) {
+		t.Errorf("unexpected duration: %v", duration)
+	}
+
+	size := rule.BooleanExpression.Expression.Next.Expression.Comparison.ScalarComparison.Next.BitOperation.Unary.Primary.Number
+	if size == nil || *size != 100*1024*1024 {
+		t.Errorf("unexpected size: %v", size)
+	}
+}
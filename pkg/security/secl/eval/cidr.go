@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"net"
+	"strings"
+)
+
+// CIDRArray represents an array of IP addresses and CIDR ranges
+type CIDRArray struct {
+	Values []string
+
+	trie *cidrTrie
+}
+
+// newCIDRArray returns a CIDR array of the given IP addresses and CIDR ranges
+func newCIDRArray(values []string) (*CIDRArray, error) {
+	trie := &cidrTrie{}
+	for _, value := range values {
+		ipNet, err := parseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		trie.insert(ipNet)
+	}
+
+	return &CIDRArray{Values: values, trie: trie}, nil
+}
+
+// Contains returns whether the given IP address belongs to one of the ranges of the array
+func (c *CIDRArray) Contains(ip net.IP) bool {
+	return c.trie.contains(ip)
+}
+
+// parseCIDR parses a CIDR range, an IP address is a range of a single address
+func parseCIDR(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: value}
+		}
+
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(value)
+	return ipNet, err
+}
+
+type cidrTrieNode struct {
+	children [2]*cidrTrieNode
+	terminal bool
+}
+
+// cidrTrie is a binary trie of CIDR ranges, a lookup costs at most one step per bit of the address whatever the
+// number of ranges
+type cidrTrie struct {
+	v4 cidrTrieNode
+	v6 cidrTrieNode
+}
+
+func (t *cidrTrie) insert(ipNet *net.IPNet) {
+	ones, bits := ipNet.Mask.Size()
+
+	node, ip := &t.v6, ipNet.IP.To16()
+	if bits == 8*net.IPv4len {
+		node, ip = &t.v4, ipNet.IP.To4()
+	}
+
+	for i := 0; i < ones && !node.terminal; i++ {
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &cidrTrieNode{}
+		}
+		node = node.children[bit]
+	}
+
+	// a range covers all its sub ranges
+	node.terminal = true
+	node.children = [2]*cidrTrieNode{}
+}
+
+func (t *cidrTrie) contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	node := &t.v6
+	if ip4 := ip.To4(); ip4 != nil {
+		node, ip = &t.v4, ip4
+	}
+
+	for i := 0; i < 8*len(ip); i++ {
+		if node.terminal {
+			return true
+		}
+
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+		if node = node.children[bit]; node == nil {
+			return false
+		}
+	}
+
+	return node.terminal
+}
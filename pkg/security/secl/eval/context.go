@@ -12,6 +12,9 @@ import (
 // Context describes the context used during a rule evaluation
 type Context struct {
 	Object unsafe.Pointer
+
+	// element is the current element of the iteration of a comparison on an iterable field
+	element unsafe.Pointer
 }
 
 // SetObject set the given object to the context
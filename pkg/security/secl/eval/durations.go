@@ -0,0 +1,28 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"time"
+)
+
+// ageEvaluator returns the evaluator of the age of a timestamp field, in nanoseconds since epoch, so that
+// `process.created_at < 30s` matches the processes created less than 30 seconds ago. Only the fields of the model
+// are timestamps, the other evaluators, constants or results of arithmetic operations, are returned as they are
+func ageEvaluator(evaluator *IntEvaluator) *IntEvaluator {
+	if evaluator.EvalFnc == nil || evaluator.Field == "" || isVariableField(evaluator.Field) || isTransformedField(evaluator.Field) {
+		return evaluator
+	}
+
+	evalFnc := evaluator.EvalFnc
+	return &IntEvaluator{
+		EvalFnc: func(ctx *Context) int {
+			return int(time.Now().UnixNano()) - evalFnc(ctx)
+		},
+		Field:     transformedField("age", evaluator.Field),
+		isPartial: evaluator.isPartial,
+	}
+}
@@ -22,6 +22,16 @@ func (e ErrInvalidPattern) Error() string {
 	return fmt.Sprintf("invalid pattern `%s`", e.Pattern)
 }
 
+// ErrInvalidRegexp is returned for a regexp that doesn't follow the RE2 syntax
+type ErrInvalidRegexp struct {
+	Regexp string
+	Err    error
+}
+
+func (e ErrInvalidRegexp) Error() string {
+	return fmt.Sprintf("invalid regexp `%s`: %s", e.Regexp, e.Err)
+}
+
 // ErrAstToEval describes an error that occurred during the conversion from the AST to an evaluator
 type ErrAstToEval struct {
 	Pos  lexer.Position
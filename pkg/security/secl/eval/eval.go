@@ -13,6 +13,7 @@ import (
 	"sort"
 
 	"github.com/alecthomas/participle/lexer"
+	"github.com/pkg/errors"
 
 	"github.com/DataDog/datadog-agent/pkg/security/secl/ast"
 )
@@ -33,6 +34,13 @@ const (
 	ScalarValueType  FieldValueType = 1
 	PatternValueType FieldValueType = 2
 	BitmaskValueType FieldValueType = 4
+	RegexpValueType  FieldValueType = 8
+	CIDRValueType    FieldValueType = 16
+	ListValueType    FieldValueType = 32
+	RangeValueType   FieldValueType = 64
+	// CaseInsensitivePatternValueType is the type of the case-insensitive patterns, from which no value matched
+	// case-sensitively can be derived
+	CaseInsensitivePatternValueType FieldValueType = 128
 )
 
 // FieldValue describes a field value with its type
@@ -45,6 +53,8 @@ type FieldValue struct {
 type Opts struct {
 	Constants map[string]interface{}
 	Macros    map[MacroID]*Macro
+	Lists     map[ListID]*List
+	Variables map[string]VariableValue
 }
 
 // NewOptsWithParams initializes a new Opts instance with Constants parameters
@@ -52,6 +62,8 @@ func NewOptsWithParams(constants map[string]interface{}) *Opts {
 	return &Opts{
 		Constants: constants,
 		Macros:    make(map[MacroID]*Macro),
+		Lists:     make(map[ListID]*List),
+		Variables: make(map[string]VariableValue),
 	}
 }
 
@@ -95,7 +107,8 @@ type IntEvaluator struct {
 	Field   Field
 	Value   int
 
-	isPartial bool
+	isPartial  bool
+	isDuration bool
 }
 
 // Eval returns the result of the evaluation
@@ -109,7 +122,9 @@ type StringEvaluator struct {
 	Field   Field
 	Value   string
 
-	isPartial bool
+	isPartial         bool
+	isRegexp          bool
+	isCaseInsensitive bool
 }
 
 // Eval returns the result of the evaluation
@@ -122,9 +137,21 @@ type StringArray struct {
 	Values []string
 }
 
-// IntArray represents an array of integer values
+// IntRange represents a range of integer values, its bounds included
+type IntRange struct {
+	Min int
+	Max int
+}
+
+// Contains returns whether the given value belongs to the range
+func (r IntRange) Contains(value int) bool {
+	return value >= r.Min && value <= r.Max
+}
+
+// IntArray represents an array of integer values and ranges, `[0, 1000..2000]` for instance
 type IntArray struct {
 	Values []int
+	Ranges []IntRange
 }
 
 func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, interface{}, lexer.Position, error) {
@@ -132,10 +159,28 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 	case *ast.BooleanExpression:
 		return nodeToEvaluator(obj.Expression, opts, state)
 	case *ast.Expression:
+		// the comparisons on the fields of the elements of an iterable field are evaluated on each element
+		iterator, iterable := state.iterator, state.iterable
+		state.iterator, state.iterable = nil, ""
+
+		costly := state.costly
+		state.costly = false
+
 		cmp, _, pos, err := nodeToEvaluator(obj.Comparison, opts, state)
 		if err != nil {
 			return nil, nil, pos, err
 		}
+		cmpCostly := state.costly
+
+		if cmpBool, ok := cmp.(*BoolEvaluator); ok && state.iterator != nil {
+			cmp = iterate(cmpBool, state.iterator, state)
+			state.iterator, state.iterable = nil, ""
+		}
+
+		// an operand in parenthesis belongs to the comparison it is part of
+		if state.iterator == nil {
+			state.iterator, state.iterable = iterator, iterable
+		}
 
 		if obj.Op != nil {
 			cmpBool, ok := cmp.(*BoolEvaluator)
@@ -143,6 +188,8 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 				return nil, nil, obj.Pos, NewTypeError(obj.Pos, reflect.Bool)
 			}
 
+			state.costly = false
+
 			next, _, pos, err := nodeToEvaluator(obj.Next, opts, state)
 			if err != nil {
 				return nil, nil, pos, err
@@ -153,6 +200,13 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 				return nil, nil, pos, NewTypeError(pos, reflect.Bool)
 			}
 
+			// the operand resolving costly fields is evaluated last, only when the other one doesn't already decide
+			// the result
+			if cmpCostly && !state.costly {
+				cmpBool, nextBool = nextBool, cmpBool
+			}
+			state.costly = costly || cmpCostly || state.costly
+
 			switch *obj.Op {
 			case "||":
 				boolEvaluator, err := Or(cmpBool, nextBool, opts, state)
@@ -169,6 +223,8 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 			}
 			return nil, nil, pos, NewOpUnknownError(obj.Pos, *obj.Op)
 		}
+		state.costly = costly || cmpCostly
+
 		return cmp, nil, obj.Pos, nil
 	case *ast.BitOperation:
 		unary, _, pos, err := nodeToEvaluator(obj.Unary, opts, state)
@@ -176,6 +232,31 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 			return nil, nil, pos, err
 		}
 
+		// additions and subtractions are evaluated from left to right, before the operations on bits
+		for obj.Op != nil && (*obj.Op == "+" || *obj.Op == "-") {
+			left, ok := unary.(*IntEvaluator)
+			if !ok {
+				return nil, nil, obj.Pos, NewTypeError(obj.Pos, reflect.Int)
+			}
+
+			next, _, pos, err := nodeToEvaluator(obj.Next.Unary, opts, state)
+			if err != nil {
+				return nil, nil, pos, err
+			}
+
+			nextInt, ok := next.(*IntEvaluator)
+			if !ok {
+				return nil, nil, pos, NewTypeError(pos, reflect.Int)
+			}
+
+			if *obj.Op == "+" {
+				unary = IntAdd(left, nextInt, opts, state)
+			} else {
+				unary = IntSubtract(left, nextInt, opts, state)
+			}
+			obj = obj.Next
+		}
+
 		if obj.Op != nil {
 			bitInt, ok := unary.(*IntEvaluator)
 			if !ok {
@@ -230,6 +311,22 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 
 			switch unary := unary.(type) {
 			case *StringEvaluator:
+				if nextList, ok := next.(*List); ok {
+					boolEvaluator, err := StringListContains(unary, nextList, *obj.ArrayComparison.Op == "notin", opts, state)
+					if err != nil {
+						return nil, nil, pos, err
+					}
+					return boolEvaluator, nil, obj.Pos, nil
+				}
+
+				if nextCIDRArray, ok := next.(*CIDRArray); ok {
+					boolEvaluator, err := StringArrayMatchesCIDR(unary, nextCIDRArray, *obj.ArrayComparison.Op == "notin", opts, state)
+					if err != nil {
+						return nil, nil, pos, err
+					}
+					return boolEvaluator, nil, obj.Pos, nil
+				}
+
 				nextStringArray, ok := next.(*StringArray)
 				if !ok {
 					return nil, nil, pos, NewTypeError(pos, reflect.Array)
@@ -288,6 +385,11 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 					return nil, nil, pos, NewTypeError(pos, reflect.String)
 				}
 
+				// regexps and case-insensitive patterns only make sense for the matching operators
+				if op := *obj.ScalarComparison.Op; (nextString.isRegexp || nextString.isCaseInsensitive) && op != "=~" && op != "!~" {
+					return nil, nil, pos, NewOpError(obj.Pos, op, errors.New("regexps and case-insensitive patterns require a matching operator"))
+				}
+
 				switch *obj.ScalarComparison.Op {
 				case "!=":
 					stringEvaluator, err := StringNotEquals(unary, nextString, opts, state)
@@ -315,6 +417,13 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 					return nil, nil, pos, NewTypeError(pos, reflect.Int)
 				}
 
+				// a field compared to a duration holds a timestamp, its age is compared
+				if nextInt.isDuration {
+					unary = ageEvaluator(unary)
+				} else if unary.isDuration {
+					nextInt = ageEvaluator(nextInt)
+				}
+
 				switch *obj.ScalarComparison.Op {
 				case "<":
 					boolEvaluator, err := LesserThan(unary, nextInt, opts, state)
@@ -402,52 +511,146 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 	case *ast.Primary:
 		switch {
 		case obj.Ident != nil:
+			if len(obj.Arguments) != 0 {
+				// the macros take precedence over the transformers and the functions of the same name
+				if _, isMacro := opts.Macros[*obj.Ident]; !isMacro {
+					if transformers[*obj.Ident] != nil {
+						evaluator, pos, err := transformerCallToEvaluator(obj, opts, state)
+						return evaluator, nil, pos, err
+					}
+
+					if *obj.Ident == "count" {
+						evaluator, pos, err := countCallToEvaluator(obj, state)
+						return evaluator, nil, pos, err
+					}
+				}
+
+				evaluator, pos, err := macroCallToEvaluator(obj, opts, state)
+				return evaluator, nil, pos, err
+			}
+
+			if parameter, ok := state.parameters[*obj.Ident]; ok {
+				return parameter, nil, obj.Pos, nil
+			}
+
+			if macro, ok := opts.Macros[*obj.Ident]; ok && len(macro.Parameters) != 0 {
+				return nil, nil, obj.Pos, NewError(obj.Pos, fmt.Sprintf("macro '%s' expects %d arguments", *obj.Ident, len(macro.Parameters)))
+			}
+
 			if accessor, ok := opts.Constants[*obj.Ident]; ok {
 				return accessor, nil, obj.Pos, nil
 			}
 
 			if state.macros != nil {
 				if macro, ok := state.macros[*obj.Ident]; ok {
+					state.UpdateMacros(*obj.Ident)
+					state.UpdateCost(macro.GetFields()...)
 					return macro.Value, nil, obj.Pos, nil
 				}
 			}
 
+			if model, ok := state.model.(IteratorModel); ok {
+				if iterable, field, ok := model.GetIterable(*obj.Ident); ok {
+					evaluator, err := iteratedFieldToEvaluator(*obj.Ident, iterable, field, obj.Pos, state)
+					return evaluator, nil, obj.Pos, err
+				}
+			}
+
 			accessor, err := state.model.GetEvaluator(*obj.Ident)
 			if err != nil {
 				return nil, nil, obj.Pos, err
 			}
 
 			state.UpdateFields(*obj.Ident)
+			state.UpdateCost(*obj.Ident)
 
 			return accessor, nil, obj.Pos, nil
+		case obj.Variable != nil:
+			variable, ok := opts.Variables[*obj.Variable]
+			if !ok {
+				return nil, nil, obj.Pos, NewError(obj.Pos, fmt.Sprintf("unknown variable '%s'", *obj.Variable))
+			}
+
+			state.UpdateVariables(*obj.Variable)
+
+			return variableToEvaluator(*obj.Variable, variable), nil, obj.Pos, nil
 		case obj.Number != nil:
 			return &IntEvaluator{
 				Value: *obj.Number,
 			}, nil, obj.Pos, nil
+		case obj.Duration != nil:
+			return &IntEvaluator{
+				Value:      *obj.Duration,
+				isDuration: true,
+			}, nil, obj.Pos, nil
 		case obj.String != nil:
 			return &StringEvaluator{
 				Value: *obj.String,
 			}, nil, obj.Pos, nil
+		case obj.Regexp != nil:
+			return &StringEvaluator{
+				Value:    *obj.Regexp,
+				isRegexp: true,
+			}, nil, obj.Pos, nil
+		case obj.CaseInsensitiveString != nil:
+			return &StringEvaluator{
+				Value:             *obj.CaseInsensitiveString,
+				isCaseInsensitive: true,
+			}, nil, obj.Pos, nil
 		case obj.SubExpression != nil:
 			return nodeToEvaluator(obj.SubExpression, opts, state)
 		default:
 			return nil, nil, obj.Pos, NewError(obj.Pos, fmt.Sprintf("unknown primary '%s'", reflect.TypeOf(obj)))
 		}
+	case *ast.Argument:
+		if obj.Primary != nil {
+			return nodeToEvaluator(obj.Primary, opts, state)
+		}
+		return nodeToEvaluator(&ast.Array{Pos: obj.Pos, Strings: obj.Strings, Numbers: obj.Numbers, List: obj.List}, opts, state)
+
 	case *ast.Array:
 		if len(obj.Numbers) != 0 {
-			ints := obj.Numbers
-			sort.Ints(ints)
-			return &IntArray{Values: ints}, nil, obj.Pos, nil
+			var array IntArray
+			for _, number := range obj.Numbers {
+				if number.To == nil {
+					array.Values = append(array.Values, number.Value)
+					continue
+				}
+
+				if *number.To < number.Value {
+					return nil, nil, number.Pos, NewError(number.Pos, fmt.Sprintf("invalid range '%s'", number))
+				}
+				array.Ranges = append(array.Ranges, IntRange{Min: number.Value, Max: *number.To})
+			}
+			sort.Ints(array.Values)
+			return &array, nil, obj.Pos, nil
 		} else if len(obj.Strings) != 0 {
 			strs := obj.Strings
 			sort.Strings(strs)
 			return &StringArray{Values: strs}, nil, obj.Pos, nil
+		} else if len(obj.IPs) != 0 {
+			array, err := newCIDRArray(obj.IPs)
+			if err != nil {
+				return nil, nil, obj.Pos, NewError(obj.Pos, fmt.Sprintf("invalid IP: %s", err))
+			}
+			return array, nil, obj.Pos, nil
 		} else if obj.Ident != nil {
+			if parameter, ok := state.parameters[*obj.Ident]; ok {
+				return parameter, nil, obj.Pos, nil
+			}
+
 			if state.macros != nil {
 				if macro, ok := state.macros[*obj.Ident]; ok {
+					state.UpdateMacros(*obj.Ident)
 					return macro.Value, nil, obj.Pos, nil
 				}
 			}
+		} else if obj.List != nil {
+			list, ok := opts.Lists[*obj.List]
+			if !ok {
+				return nil, nil, obj.Pos, NewError(obj.Pos, fmt.Sprintf("unknown list '%s'", *obj.List))
+			}
+			return list, nil, obj.Pos, nil
 		}
 	}
 
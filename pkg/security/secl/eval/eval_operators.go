@@ -5,10 +5,10 @@ package eval
 func Or(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -20,7 +20,7 @@ func Or(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEval
 	if a.EvalFnc != nil && b.EvalFnc != nil {
 		ea, eb := a.EvalFnc, b.EvalFnc
 
-		if state.field != "" {
+		if state.isPartial() {
 			if a.isPartial {
 				ea = func(ctx *Context) bool {
 					return true
@@ -46,7 +46,7 @@ func Or(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEval
 	if a.EvalFnc == nil && b.EvalFnc == nil {
 		ea, eb := a.Value, b.Value
 
-		if state.field != "" {
+		if state.isPartial() {
 			if a.isPartial {
 				ea = true
 			}
@@ -70,7 +70,7 @@ func Or(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEval
 			}
 		}
 
-		if state.field != "" {
+		if state.isPartial() {
 			if a.isPartial {
 				ea = func(ctx *Context) bool {
 					return true
@@ -99,7 +99,7 @@ func Or(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEval
 		}
 	}
 
-	if state.field != "" {
+	if state.isPartial() {
 		if a.isPartial {
 			ea = true
 		}
@@ -123,10 +123,10 @@ func Or(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEval
 func And(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -138,7 +138,7 @@ func And(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEva
 	if a.EvalFnc != nil && b.EvalFnc != nil {
 		ea, eb := a.EvalFnc, b.EvalFnc
 
-		if state.field != "" {
+		if state.isPartial() {
 			if a.isPartial {
 				ea = func(ctx *Context) bool {
 					return true
@@ -164,7 +164,7 @@ func And(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEva
 	if a.EvalFnc == nil && b.EvalFnc == nil {
 		ea, eb := a.Value, b.Value
 
-		if state.field != "" {
+		if state.isPartial() {
 			if a.isPartial {
 				ea = true
 			}
@@ -188,7 +188,7 @@ func And(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEva
 			}
 		}
 
-		if state.field != "" {
+		if state.isPartial() {
 			if a.isPartial {
 				ea = func(ctx *Context) bool {
 					return true
@@ -217,7 +217,7 @@ func And(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEva
 		}
 	}
 
-	if state.field != "" {
+	if state.isPartial() {
 		if a.isPartial {
 			ea = true
 		}
@@ -241,10 +241,10 @@ func And(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEva
 func IntEquals(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -315,10 +315,10 @@ func IntEquals(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*Boo
 func IntNotEquals(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -389,10 +389,10 @@ func IntNotEquals(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*
 func IntAnd(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*IntEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -463,10 +463,10 @@ func IntAnd(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*IntEva
 func IntOr(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*IntEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -537,10 +537,10 @@ func IntOr(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*IntEval
 func IntXor(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*IntEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -611,10 +611,10 @@ func IntXor(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*IntEva
 func StringEquals(a *StringEvaluator, b *StringEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -685,10 +685,10 @@ func StringEquals(a *StringEvaluator, b *StringEvaluator, opts *Opts, state *sta
 func StringNotEquals(a *StringEvaluator, b *StringEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -759,10 +759,10 @@ func StringNotEquals(a *StringEvaluator, b *StringEvaluator, opts *Opts, state *
 func BoolEquals(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -833,10 +833,10 @@ func BoolEquals(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*
 func BoolNotEquals(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -907,10 +907,10 @@ func BoolNotEquals(a *BoolEvaluator, b *BoolEvaluator, opts *Opts, state *state)
 func GreaterThan(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -981,10 +981,10 @@ func GreaterThan(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*B
 func GreaterOrEqualThan(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -1055,10 +1055,10 @@ func GreaterOrEqualThan(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *sta
 func LesserThan(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -1129,10 +1129,10 @@ func LesserThan(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*Bo
 func LesserOrEqualThan(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) (*BoolEvaluator, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
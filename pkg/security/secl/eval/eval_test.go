@@ -8,8 +8,10 @@ package eval
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"syscall"
 	"testing"
+	"time"
 	"unsafe"
 
 	"github.com/DataDog/datadog-agent/pkg/security/secl/ast"
@@ -253,6 +255,124 @@ func TestSimpleBitOperations(t *testing.T) {
 	}
 }
 
+func TestArithmeticOperations(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			uid: 444,
+			gid: 555,
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `1 + 2 == 3`, Expected: true},
+		{Expr: `10 - 2 - 3 == 5`, Expected: true},
+		{Expr: `10 - (2 - 3) == 11`, Expected: true},
+		{Expr: `1 + 2 & 3 == 3`, Expected: true},
+		{Expr: `process.uid + 1 == 445`, Expected: true},
+		{Expr: `process.gid - process.uid == 111`, Expected: true},
+		{Expr: `process.uid - 1 > process.gid`, Expected: false},
+		{Expr: `2KB == 2048`, Expected: true},
+		{Expr: `1MB - 1KB == 1047552`, Expected: true},
+		{Expr: `1GB > 1023MB`, Expected: true},
+		{Expr: `1024 in [1KB, 1MB]`, Expected: true},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	if _, _, err := eval(t, event, `process.name + 1 == 2`); err == nil {
+		t.Error("expected a type error")
+	}
+}
+
+func TestDurations(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			createdAt: int(time.Now().Add(-10 * time.Second).UnixNano()),
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `1s == 1000ms`, Expected: true},
+		{Expr: `2m == 120s`, Expected: true},
+		{Expr: `1d == 24h`, Expected: true},
+		{Expr: `process.created_at < 30s`, Expected: true},
+		{Expr: `process.created_at < 5s`, Expected: false},
+		{Expr: `process.created_at > 5s`, Expected: true},
+		{Expr: `5s < process.created_at`, Expected: true},
+		{Expr: `process.created_at > 1h`, Expected: false},
+		{Expr: `process.created_at - (process.created_at - 1m) == 1m`, Expected: true},
+		{Expr: `process.created_at < 5s + 25s`, Expected: true},
+		{Expr: `process.created_at < 1m - 55s`, Expected: false},
+		{Expr: `process.created_at > 0s + 5`, Expected: true},
+		{Expr: `5s + 10s < process.created_at`, Expected: false},
+		{Expr: `process.created_at < -(5s - 35s)`, Expected: true},
+		{Expr: `process.created_at > 0`, Expected: true},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+}
+
+func TestIntRanges(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			uid: 1500,
+			gid: 0,
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `process.uid in [0, 1000..2000]`, Expected: true},
+		{Expr: `process.uid in [1000..1500]`, Expected: true},
+		{Expr: `process.uid in [1501..2000, 3000]`, Expected: false},
+		{Expr: `process.uid not in [1000..2000]`, Expected: false},
+		{Expr: `process.gid in [0, 1000..2000]`, Expected: true},
+		{Expr: `process.gid in [1..1KB]`, Expected: false},
+		{Expr: `1500 in [1000..2000]`, Expected: true},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	if _, _, err := eval(t, event, `process.uid in [2000..1000]`); err == nil {
+		t.Error("a range whose upper bound is lower than its lower bound should be reported")
+	}
+}
+
 func TestRegexp(t *testing.T) {
 	event := &testEvent{
 		process: testProcess{
@@ -284,6 +404,501 @@ func TestRegexp(t *testing.T) {
 	}
 }
 
+func TestExtendedPattern(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "/var/lib/Docker/overlay2/conf/Daemon.json",
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `process.name =~ "/var/lib/**/Daemon.json"`, Expected: true},
+		{Expr: `process.name =~ "/var/lib/Docker/**/*.json"`, Expected: true},
+		{Expr: `process.name =~ "/var/lib/Docker/overlay2/conf/**/Daemon.json"`, Expected: true},
+		{Expr: `process.name =~ "/var/**"`, Expected: true},
+		{Expr: `process.name =~ "/etc/**/Daemon.json"`, Expected: false},
+		{Expr: `process.name =~ "/var/lib/**/daemon.json"`, Expected: false},
+		{Expr: `process.name =~ i"/var/lib/**/daemon.json"`, Expected: true},
+		{Expr: `process.name =~ i"/VAR/LIB/DOCKER/*"`, Expected: true},
+		{Expr: `process.name !~ i"/var/lib/docker/*"`, Expected: false},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	if _, _, err := eval(t, event, `process.name == i"/VAR/LIB/DOCKER"`); err == nil {
+		t.Error("expected an error for a case-insensitive string used without a matching operator")
+	}
+}
+
+func TestRegexpOperator(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "/tmp/ZXZpbC5zaA==",
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `process.name =~ r"^/tmp/[A-Za-z0-9+/]{8,}={0,2}$"`, Expected: true},
+		{Expr: `process.name =~ r"^/usr/bin/.*"`, Expected: false},
+		{Expr: `process.name !~ r"^/usr/bin/.*"`, Expected: true},
+		{Expr: `process.name =~ r"\.sh$"`, Expected: false},
+		{Expr: `process.name =~ r"(a+)+$"`, Expected: false},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	if _, _, err := eval(t, event, `process.name =~ r"(?<=a)b"`); err == nil {
+		t.Error("expected an error for a regexp not supported by RE2")
+	}
+}
+
+func TestCIDR(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "192.168.10.20",
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `process.name in [ip"10.0.0.0/8", ip"192.168.0.0/16"]`, Expected: true},
+		{Expr: `process.name in [ip"10.0.0.0/8", ip"192.168.10.20"]`, Expected: true},
+		{Expr: `process.name in [ip"10.0.0.0/8", ip"172.16.0.0/12"]`, Expected: false},
+		{Expr: `process.name not in [ip"10.0.0.0/8", ip"172.16.0.0/12"]`, Expected: true},
+		{Expr: `process.name in [ip"2001:db8::/32"]`, Expected: false},
+		// the strings are compared as strings, whatever they look like
+		{Expr: `process.name in ["192.168.0.0/16"]`, Expected: false},
+		{Expr: `process.name in ["10.0.0.0/8", "192.168.10.20"]`, Expected: true},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	for _, expr := range []string{
+		`process.name in [ip"192.168.0.0/16", ip"abc"]`,
+		`process.name in [ip"192.168.0.0/16", "10.0.0.0/8"]`,
+		`process.uid in [ip"192.168.0.0/16"]`,
+	} {
+		if _, _, err := eval(t, event, expr); err == nil {
+			t.Errorf("expected an error for `%s`", expr)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "/usr/bin/nc",
+		},
+	}
+
+	opts := NewOptsWithParams(testConstants)
+	opts.Lists["tools"] = NewList("tools", []string{"/usr/bin/wget", "/usr/bin/curl"})
+
+	ctx := &Context{}
+	ctx.SetObject(unsafe.Pointer(event))
+
+	rule, err := parseRule(`process.name in @tools`, &testModel{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notRule, err := parseRule(`process.name not in @tools`, &testModel{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rule.Eval(ctx) || !notRule.Eval(ctx) {
+		t.Error("the process shouldn't be in the list")
+	}
+
+	opts.Lists["tools"].SetValues([]string{"/usr/bin/nc"})
+
+	if !rule.Eval(ctx) || notRule.Eval(ctx) {
+		t.Error("the process should be in the list once its values are replaced")
+	}
+
+	if _, err := parseRule(`process.name in @unknown`, &testModel{}, opts); err == nil {
+		t.Error("expected an error for an unknown list")
+	}
+}
+
+func TestCostlyFields(t *testing.T) {
+	opts := NewOptsWithParams(testConstants)
+	opts.Lists["malware_hashes"] = NewList("malware_hashes", []string{"aaaa", "bbbb"})
+
+	tests := []struct {
+		Expr     string
+		Filename string
+		Expected bool
+		Hashes   int
+	}{
+		{Expr: `open.sha256 in @malware_hashes && open.filename == "/tmp/payload"`, Filename: "/tmp/payload", Expected: true, Hashes: 1},
+		{Expr: `open.sha256 in @malware_hashes && open.filename == "/tmp/payload"`, Filename: "/tmp/other", Expected: false, Hashes: 0},
+		{Expr: `open.sha256 in @malware_hashes && process.name == "curl" && open.filename == "/tmp/payload"`, Filename: "/tmp/payload", Expected: false, Hashes: 0},
+		{Expr: `open.sha256 == "cccc" || open.filename == "/tmp/payload"`, Filename: "/tmp/payload", Expected: true, Hashes: 0},
+		{Expr: `(open.filename == "/tmp/payload" || open.sha256 == "cccc") && open.sha256 in @malware_hashes`, Filename: "/tmp/payload", Expected: true, Hashes: 1},
+	}
+
+	for _, test := range tests {
+		event := &testEvent{
+			process: testProcess{
+				name: "wget",
+			},
+			open: testOpen{
+				filename: test.Filename,
+				sha256:   "aaaa",
+			},
+		}
+
+		ctx := &Context{}
+		ctx.SetObject(unsafe.Pointer(event))
+
+		rule, err := parseRule(test.Expr, &testModel{}, opts)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result := rule.Eval(ctx); result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+
+		if event.open.hashes != test.Hashes {
+			t.Errorf("expected the hash to be resolved %d times, got %d\n%s", test.Hashes, event.open.hashes, test.Expr)
+		}
+	}
+}
+
+func TestTransformers(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "/usr/bin/VIM",
+		},
+		open: testOpen{
+			filename: "/etc/shadow",
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `basename(open.filename) == "shadow"`, Expected: true},
+		{Expr: `dirname(open.filename) == "/etc"`, Expected: true},
+		{Expr: `lower(process.name) == "/usr/bin/vim"`, Expected: true},
+		{Expr: `basename(lower(process.name)) in ["vim", "nano"]`, Expected: true},
+		{Expr: `lower(basename(process.name)) =~ "vi*"`, Expected: true},
+		{Expr: `dirname(dirname(open.filename)) == "/"`, Expected: true},
+		{Expr: `lower("ABC") == "abc"`, Expected: true},
+		{Expr: `basename(open.filename) == "passwd"`, Expected: false},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	for _, expr := range []string{`lower(process.uid) == "abc"`, `lower(process.name, open.filename) == "abc"`, `lower(r"^abc") == "abc"`} {
+		if _, _, err := eval(t, event, expr); err == nil {
+			t.Errorf("expected an error for `%s`", expr)
+		}
+	}
+
+	// the values compared to a transformed field are not values of the field
+	rule, err := parseRule(`lower(process.name) == "/usr/bin/vim" && open.filename == "/etc/shadow"`, &testModel{}, NewOptsWithParams(testConstants))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values := rule.GetFieldValues("process.name"); len(values) != 0 {
+		t.Errorf("unexpected values %v for the transformed field", values)
+	}
+
+	if err := rule.GenPartials(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &Context{}
+	ctx.SetObject(unsafe.Pointer(&testEvent{process: testProcess{name: "/usr/bin/cat"}, open: testOpen{filename: "/etc/shadow"}}))
+
+	// a transformed field is partial, the field it transforms can't be a discarder
+	if result, err := rule.PartialEval(ctx, "process.name"); err != nil || !result {
+		t.Error("a transformed field shouldn't lead to a discarder")
+	}
+
+	ctx.SetObject(unsafe.Pointer(&testEvent{process: testProcess{name: "/usr/bin/cat"}, open: testOpen{filename: "/etc/passwd"}}))
+
+	if result, err := rule.PartialEval(ctx, "open.filename"); err != nil || result {
+		t.Error("the other fields should still lead to discarders")
+	}
+}
+
+func TestIterators(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "bash",
+			uid:  1000,
+		},
+		ancestors: []testProcess{
+			{name: "sudo", uid: 1000},
+			{name: "sshd", uid: 0},
+			{name: "systemd", uid: 0},
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `process.ancestors.name == "sshd"`, Expected: true},
+		{Expr: `process.ancestors.name == "cron"`, Expected: false},
+		{Expr: `process.ancestors.name != "sshd"`, Expected: true},
+		{Expr: `!(process.ancestors.name == "sshd")`, Expected: false},
+		{Expr: `process.ancestors.name in ["cron", "sshd"]`, Expected: true},
+		{Expr: `process.ancestors.name == process.name`, Expected: false},
+		{Expr: `process.ancestors.name == "sudo" && process.ancestors.uid == process.uid`, Expected: true},
+		{Expr: `process.ancestors.name == "sshd" && process.ancestors.uid == 0`, Expected: true},
+		{Expr: `process.ancestors.name == "sudo" && process.ancestors.uid == 0`, Expected: true},
+		{Expr: `process.ancestors.name == "sudo" && process.ancestors.uid == 0 && process.name == "bash"`, Expected: true},
+		{Expr: `(process.ancestors.name) == "systemd"`, Expected: true},
+		{Expr: `count(process.ancestors) == 3`, Expected: true},
+		{Expr: `count(process.ancestors) > 10`, Expected: false},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	for _, expr := range []string{`count(process.name) > 1`, `count(process.ancestors, process.ancestors) > 1`, `process.ancestors.undefined == 1`} {
+		if _, _, err := eval(t, event, expr); err == nil {
+			t.Errorf("expected an error for `%s`", expr)
+		}
+	}
+
+	rule, err := parseRule(`process.ancestors.name == "sshd" && process.name == "bash"`, &testModel{}, &Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if iterators := rule.GetIterators(); len(iterators) != 1 || iterators[0] != "process.ancestors" {
+		t.Errorf("unexpected iterators: %v", iterators)
+	}
+
+	if fields := rule.GetFields(); len(fields) != 1 || fields[0] != "process.name" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
+
+func TestIteratorsConcurrent(t *testing.T) {
+	rule, err := parseRule(`process.ancestors.name == "sshd" && count(process.ancestors) == 2`, &testModel{}, &Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []*testEvent{
+		{ancestors: []testProcess{{name: "bash"}, {name: "sshd"}}},
+		{ancestors: []testProcess{{name: "cron"}, {name: "systemd"}}},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(event *testEvent, expected bool) {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				ctx := &Context{}
+				ctx.SetObject(event.GetPointer())
+
+				if result := rule.Eval(ctx); result != expected {
+					t.Errorf("expected result `%t`, got `%t`", expected, result)
+					return
+				}
+			}
+		}(events[i%2], i%2 == 0)
+	}
+	wg.Wait()
+}
+
+func TestVariables(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "/usr/bin/cat",
+			uid:  123,
+		},
+		open: testOpen{
+			filename: "/etc/shadow",
+		},
+	}
+
+	var authFailed bool
+	var maxUID int
+	var tool string
+
+	opts := NewOptsWithParams(testConstants)
+	opts.Variables["auth_failed"] = NewBoolVariable(func(ctx *Context) bool { return authFailed })
+	opts.Variables["max_uid"] = NewIntVariable(func(ctx *Context) int { return maxUID })
+	opts.Variables["tool"] = NewStringVariable(func(ctx *Context) string { return tool })
+
+	ctx := &Context{}
+	ctx.SetObject(unsafe.Pointer(event))
+
+	tests := []struct {
+		Expr        string
+		Field       string
+		IsDiscarder bool
+	}{
+		{Expr: `open.filename == "/etc/shadow" && ${auth_failed}`, Field: "open.filename", IsDiscarder: false},
+		{Expr: `open.filename == "/etc/passwd" && ${auth_failed}`, Field: "open.filename", IsDiscarder: true},
+		{Expr: `open.filename == "/etc/passwd" || !${auth_failed}`, Field: "open.filename", IsDiscarder: false},
+		{Expr: `open.filename == "/etc/shadow" && process.uid > ${max_uid}`, Field: "process.uid", IsDiscarder: false},
+		{Expr: `open.filename == "/etc/shadow" && process.name == ${tool}`, Field: "process.name", IsDiscarder: false},
+	}
+
+	for _, test := range tests {
+		rule, err := parseRule(test.Expr, &testModel{}, opts)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if err := rule.GenPartials(); err != nil {
+			t.Fatalf("error while generating partials `%s`: %s", test.Expr, err)
+		}
+
+		if len(rule.GetVariables()) != 1 {
+			t.Errorf("expected one variable, got `%v`\n%s", rule.GetVariables(), test.Expr)
+		}
+
+		for _, field := range rule.GetFields() {
+			if isVariableField(field) {
+				t.Errorf("variable reported as a field\n%s", test.Expr)
+			}
+		}
+
+		result, err := rule.PartialEval(ctx, test.Field)
+		if err != nil {
+			t.Fatalf("error while partial evaluating `%s` for `%s`: %s", test.Expr, test.Field, err)
+		}
+
+		if !result != test.IsDiscarder {
+			t.Fatalf("expected result `%t` for `%s`, got `%t`\n%s", test.IsDiscarder, test.Field, result, test.Expr)
+		}
+	}
+
+	rule, err := parseRule(`open.filename == "/etc/shadow" && ${auth_failed} && process.uid > ${max_uid} && process.name == ${tool}`, &testModel{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rule.Eval(ctx) {
+		t.Error("the rule shouldn't match before the variables are set")
+	}
+
+	authFailed, maxUID, tool = true, 100, "/usr/bin/cat"
+
+	if !rule.Eval(ctx) {
+		t.Error("the rule should match once the variables are set")
+	}
+
+	if _, err := parseRule(`${unknown}`, &testModel{}, opts); err == nil {
+		t.Error("expected an error for an unknown variable")
+	}
+
+	// the variables of the macros are collected along with the ones of the rule, the evaluator of the rule is left
+	// untouched
+	macro := &Macro{ID: "is_auth_failed", Expression: `${auth_failed}`}
+	if err := macro.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if err := macro.GenEvaluator(&testModel{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	opts.Macros = map[string]*Macro{macro.ID: macro}
+
+	if rule, err = parseRule(`process.name == ${tool} && is_auth_failed`, &testModel{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if variables := rule.GetVariables(); len(variables) != 2 || variables[0] != "tool" || variables[1] != "auth_failed" {
+		t.Errorf("expected the variables of the rule and of the macro, got %v", variables)
+	}
+	if variables := rule.GetEvaluator().Variables; len(variables) != 1 {
+		t.Errorf("expected the evaluator to hold the variables of the rule only, got %v", variables)
+	}
+
+	// only the macros referenced by a rule, directly or through another macro, add their variables to it
+	nested := &Macro{ID: "is_cat_auth_failed", Expression: `process.name == "/usr/bin/cat" && is_auth_failed`}
+	if err := nested.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if err := nested.GenEvaluator(&testModel{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	opts.Macros[nested.ID] = nested
+
+	withMacro, err := parseRule(`open.filename == "/etc/shadow" && is_cat_auth_failed`, &testModel{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if variables := withMacro.GetVariables(); len(variables) != 1 || variables[0] != "auth_failed" {
+		t.Errorf("expected the variable of the nested macro, got %v", variables)
+	}
+
+	withoutMacro, err := parseRule(`open.filename == "/etc/shadow"`, &testModel{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if variables := withoutMacro.GetVariables(); len(variables) != 0 {
+		t.Errorf("expected no variable for the rule not referencing the macro, got %v", variables)
+	}
+}
+
 func TestInArray(t *testing.T) {
 	event := &testEvent{
 		process: testProcess{
@@ -404,6 +1019,13 @@ func TestPartial(t *testing.T) {
 		{Expr: `open.filename == "test1" && process.uid == 123`, Field: "process.uid", IsDiscarder: false},
 		{Expr: `open.filename == "test1" && !process.is_root`, Field: "process.is_root", IsDiscarder: true},
 		{Expr: `open.filename == "test1" && process.is_root`, Field: "process.is_root", IsDiscarder: false},
+		{Expr: `open.filename == "test1" && process.uid + 1 == 457`, Field: "process.uid", IsDiscarder: true},
+		{Expr: `open.filename == "test1" && process.uid + 1 == 124`, Field: "process.uid", IsDiscarder: false},
+		{Expr: `open.filename == "test1" && process.created_at < 30s`, Field: "process.created_at", IsDiscarder: false},
+		{Expr: `open.filename == "test1" && process.created_at > 1h`, Field: "process.created_at", IsDiscarder: false},
+		{Expr: `open.filename == "test1" && process.ancestors.name == "sshd"`, Field: "open.filename", IsDiscarder: true},
+		{Expr: `open.filename == "xyz" && process.ancestors.name == "sshd"`, Field: "open.filename", IsDiscarder: false},
+		{Expr: `open.filename == "xyz" && count(process.ancestors) > 10`, Field: "open.filename", IsDiscarder: false},
 	}
 
 	ctx := &Context{}
@@ -431,6 +1053,52 @@ func TestPartial(t *testing.T) {
 	}
 }
 
+func TestPartialFields(t *testing.T) {
+	event := testEvent{
+		process: testProcess{
+			name: "abc",
+			uid:  123,
+		},
+		open: testOpen{
+			filename: "xyz",
+		},
+	}
+
+	tests := []struct {
+		Expr        string
+		Fields      []Field
+		IsDiscarder bool
+	}{
+		{Expr: `(open.filename == "test1" || process.name == "cat") && process.uid == 456`, Fields: []Field{"open.filename"}, IsDiscarder: false},
+		{Expr: `(open.filename == "test1" || process.name == "cat") && process.uid == 456`, Fields: []Field{"open.filename", "process.name"}, IsDiscarder: true},
+		{Expr: `(open.filename == "xyz" || process.name == "cat") && process.uid == 456`, Fields: []Field{"open.filename", "process.name"}, IsDiscarder: false},
+		{Expr: `open.filename == "test1" && process.name == "abc"`, Fields: []Field{"open.filename", "process.name"}, IsDiscarder: true},
+		{Expr: `open.filename != "test1" && process.name == "abc" && process.uid == 456`, Fields: []Field{"open.filename", "process.name"}, IsDiscarder: false},
+		{Expr: `!(open.filename != "test1" || process.name != "abc")`, Fields: []Field{"open.filename", "process.name"}, IsDiscarder: true},
+	}
+
+	ctx := &Context{}
+	ctx.SetObject(unsafe.Pointer(&event))
+
+	for _, test := range tests {
+		model := &testModel{}
+		opts := &Opts{Constants: testConstants}
+		rule, err := parseRule(test.Expr, model, opts)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		evalFnc, err := rule.GenPartial(test.Fields...)
+		if err != nil {
+			t.Fatalf("error while partial evaluating `%s` for `%v`: %s", test.Expr, test.Fields, err)
+		}
+
+		if result := evalFnc(ctx); !result != test.IsDiscarder {
+			t.Fatalf("expected result `%t` for `%v`, got `%t`\n%s", test.IsDiscarder, test.Fields, result, test.Expr)
+		}
+	}
+}
+
 func TestMacroList(t *testing.T) {
 	macro := &Macro{
 		ID:         "list",
@@ -512,6 +1180,100 @@ func TestMacroExpression(t *testing.T) {
 	}
 }
 
+func TestMacroParameters(t *testing.T) {
+	model := &testModel{}
+	opts := NewOptsWithParams(make(map[string]interface{}))
+
+	macros := []*Macro{
+		{
+			ID:         "opened_by",
+			Expression: `open.filename in files && process.name == name`,
+			Parameters: []string{"files", "name"},
+		},
+		{
+			ID:         "shadow_opened_by",
+			Expression: `opened_by([ "/etc/shadow" ], name)`,
+			Parameters: []string{"name"},
+		},
+	}
+
+	for _, macro := range macros {
+		if err := macro.Parse(); err != nil {
+			t.Fatalf("%s\n%s", err, macro.Expression)
+		}
+
+		if err := macro.GenEvaluator(model, opts); err != nil {
+			t.Fatalf("%s\n%s", err, macro.Expression)
+		}
+
+		opts.Macros[macro.ID] = macro
+	}
+
+	event := &testEvent{
+		process: testProcess{
+			name: "httpd",
+		},
+		open: testOpen{
+			filename: "/etc/shadow",
+		},
+	}
+
+	ctx := &Context{}
+	ctx.SetObject(unsafe.Pointer(event))
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `opened_by([ "/etc/shadow", "/etc/passwd" ], "httpd")`, Expected: true},
+		{Expr: `opened_by([ "/etc/passwd" ], "httpd")`, Expected: false},
+		{Expr: `opened_by([ "/etc/shadow" ], "nginx") || opened_by([ "/etc/shadow" ], "httpd")`, Expected: true},
+		{Expr: `shadow_opened_by("httpd")`, Expected: true},
+		{Expr: `shadow_opened_by("nginx")`, Expected: false},
+	}
+
+	for _, test := range tests {
+		rule, err := parseRule(test.Expr, model, opts)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result := rule.Eval(ctx); result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+
+		// the fields of the macro are the ones of the rule
+		if values := rule.GetFieldValues("process.name"); len(values) == 0 {
+			t.Errorf("expected values for `process.name`\n%s", test.Expr)
+		}
+	}
+
+	rule, err := parseRule(`opened_by([ "/etc/shadow" ], "nginx")`, model, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rule.GenPartials(); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, _ := rule.PartialEval(ctx, "process.name"); result {
+		t.Error("`process.name` should be a discarder")
+	}
+
+	errorExprs := []string{
+		`opened_by([ "/etc/shadow" ])`,
+		`opened_by`,
+		`unknown("httpd")`,
+	}
+
+	for _, expr := range errorExprs {
+		if _, err := parseRule(expr, model, opts); err == nil {
+			t.Errorf("expected an error for `%s`", expr)
+		}
+	}
+}
+
 func TestMacroPartial(t *testing.T) {
 	macro := &Macro{
 		ID:         "is_passwd",
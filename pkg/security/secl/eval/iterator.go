@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/alecthomas/participle/lexer"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/ast"
+)
+
+// Iterator iterates over the elements of an iterable field, the ancestors of a process for instance. The elements are
+// objects of the model, Front and Next return nil at the end of the iteration
+type Iterator interface {
+	Front(ctx *Context) unsafe.Pointer
+	Next() unsafe.Pointer
+}
+
+// IteratorModel is implemented by the models having iterable fields, the ancestors of a process for instance
+type IteratorModel interface {
+	// GetIterable returns the iterable field the given field belongs to, along with the field of the model it is
+	// evaluated as on each element. `process.ancestors.name` belongs to `process.ancestors` and is evaluated as
+	// `process.name` on each ancestor for instance. It returns false if the field doesn't belong to an iterable field
+	GetIterable(field Field) (Field, Field, bool)
+	// NewIterator returns a new iterator over the elements of the given iterable field
+	NewIterator(iterable Field) (Iterator, error)
+}
+
+// iteratorFactory returns a new iterator over the elements of an iterable field. The iterators hold the state of an
+// iteration, one is allocated at each evaluation so that the events can be evaluated concurrently
+type iteratorFactory func() Iterator
+
+// newIteratorFactory returns the iterator factory of the given iterable field of the model
+func newIteratorFactory(model IteratorModel, iterable Field) (iteratorFactory, error) {
+	if _, err := model.NewIterator(iterable); err != nil {
+		return nil, err
+	}
+
+	return func() Iterator {
+		// the iterable field was validated at compilation time
+		iterator, _ := model.NewIterator(iterable)
+		return iterator
+	}, nil
+}
+
+// iteratedField returns the field of the evaluators of the fields of the elements of an iterator, as for the
+// transformed fields it can't collide with the fields of a model
+func iteratedField(field Field) Field {
+	return transformedField("any", field)
+}
+
+// iteratedFieldToEvaluator returns the evaluator of a field of the elements of an iterable field, it evaluates the
+// accessor of the field of the model on the current element of the iteration of the comparison it is part of. The
+// fields of a comparison can only belong to one iterable field
+func iteratedFieldToEvaluator(field Field, iterable Field, modelField Field, pos lexer.Position, state *state) (interface{}, error) {
+	if state.iterable != "" && state.iterable != iterable {
+		return nil, NewError(pos, fmt.Sprintf("'%s' can't be compared to the elements of '%s'", field, state.iterable))
+	}
+
+	if state.iterable == "" {
+		newIterator, err := newIteratorFactory(state.model.(IteratorModel), iterable)
+		if err != nil {
+			return nil, err
+		}
+		state.iterator = newIterator
+		state.iterable = iterable
+	}
+	state.UpdateIterators(iterable)
+	state.UpdateCost(modelField)
+
+	accessor, err := state.model.GetEvaluator(modelField)
+	if err != nil {
+		return nil, err
+	}
+
+	switch accessor := accessor.(type) {
+	case *IntEvaluator:
+		evalFnc := accessor.EvalFnc
+		return &IntEvaluator{
+			EvalFnc: func(ctx *Context) int {
+				object := ctx.Object
+				ctx.Object = ctx.element
+				value := evalFnc(ctx)
+				ctx.Object = object
+				return value
+			},
+			Field: iteratedField(field),
+		}, nil
+	case *StringEvaluator:
+		evalFnc := accessor.EvalFnc
+		return &StringEvaluator{
+			EvalFnc: func(ctx *Context) string {
+				object := ctx.Object
+				ctx.Object = ctx.element
+				value := evalFnc(ctx)
+				ctx.Object = object
+				return value
+			},
+			Field: iteratedField(field),
+		}, nil
+	case *BoolEvaluator:
+		evalFnc := accessor.EvalFnc
+		return &BoolEvaluator{
+			EvalFnc: func(ctx *Context) bool {
+				object := ctx.Object
+				ctx.Object = ctx.element
+				value := evalFnc(ctx)
+				ctx.Object = object
+				return value
+			},
+			Field: iteratedField(field),
+		}, nil
+	}
+
+	return nil, NewError(pos, fmt.Sprintf("unsupported type of field '%s'", field))
+}
+
+// iterate returns the evaluator of a comparison on the fields of the elements of an iterator, it matches if the
+// comparison matches for any of the elements, the iteration stopping at the first one. The elements are only known at
+// evaluation time, a partial evaluation considers that the comparison may match
+func iterate(evaluator *BoolEvaluator, newIterator iteratorFactory, state *state) *BoolEvaluator {
+	if evaluator.EvalFnc == nil {
+		return evaluator
+	}
+
+	if state.isPartial() {
+		return &BoolEvaluator{
+			Value:     true,
+			isPartial: true,
+		}
+	}
+
+	evalFnc := evaluator.EvalFnc
+	return &BoolEvaluator{
+		EvalFnc: func(ctx *Context) bool {
+			result := false
+			iterator := newIterator()
+			for ctx.element = iterator.Front(ctx); ctx.element != nil; ctx.element = iterator.Next() {
+				if result = evalFnc(ctx); result {
+					break
+				}
+			}
+			ctx.element = nil
+			return result
+		},
+	}
+}
+
+// countCallToEvaluator returns the evaluator of the number of elements of an iterable field,
+// `count(process.ancestors)` for instance
+func countCallToEvaluator(call *ast.Primary, state *state) (interface{}, lexer.Position, error) {
+	if len(call.Arguments) != 1 || call.Arguments[0].Primary == nil || call.Arguments[0].Primary.Ident == nil {
+		return nil, call.Pos, NewError(call.Pos, "'count' expects an iterable field")
+	}
+	iterable := *call.Arguments[0].Primary.Ident
+
+	model, ok := state.model.(IteratorModel)
+	if !ok {
+		return nil, call.Pos, NewError(call.Pos, fmt.Sprintf("'%s' is not an iterable field", iterable))
+	}
+
+	newIterator, err := newIteratorFactory(model, iterable)
+	if err != nil {
+		return nil, call.Pos, NewError(call.Pos, fmt.Sprintf("'%s' is not an iterable field", iterable))
+	}
+	state.UpdateIterators(iterable)
+
+	// the number of elements is only known at evaluation time
+	if state.isPartial() {
+		return &IntEvaluator{
+			EvalFnc: func(ctx *Context) int {
+				return 0
+			},
+			isPartial: true,
+		}, call.Pos, nil
+	}
+
+	return &IntEvaluator{
+		EvalFnc: func(ctx *Context) int {
+			var count int
+			iterator := newIterator()
+			for element := iterator.Front(ctx); element != nil; element = iterator.Next() {
+				count++
+			}
+			return count
+		},
+	}, call.Pos, nil
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// ListID - ID of a List
+type ListID = string
+
+// List - List of values identified by an `ID`, referenced in rules as `@ID`. Its values can be replaced at
+// any time, without generating the evaluators of the rules again
+type List struct {
+	ID ListID
+
+	values atomic.Value
+}
+
+// NewList - Returns a new List with the given values
+func NewList(id ListID, values []string) *List {
+	list := &List{ID: id}
+	list.SetValues(values)
+	return list
+}
+
+// SetValues - Replaces the values of the List
+func (l *List) SetValues(values []string) {
+	sorted := make([]string, len(values))
+	copy(sorted, values)
+	sort.Strings(sorted)
+
+	l.values.Store(sorted)
+}
+
+// Values - Returns the values of the List
+func (l *List) Values() []string {
+	return l.values.Load().([]string)
+}
+
+// Contains - Returns whether the List contains the given value
+func (l *List) Contains(value string) bool {
+	values := l.Values()
+	i := sort.SearchStrings(values, value)
+	return i < len(values) && values[i] == value
+}
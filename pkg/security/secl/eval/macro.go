@@ -6,17 +6,23 @@
 package eval
 
 import (
-	"github.com/DataDog/datadog-agent/pkg/security/secl/ast"
+	"fmt"
+
+	"github.com/alecthomas/participle/lexer"
 	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/ast"
 )
 
 //MacroID - ID of a Macro
 type MacroID = string
 
-// Macro - Macro object identified by an `ID` containing a SECL `Expression`
+// Macro - Macro object identified by an `ID` containing a SECL `Expression`. A Macro with `Parameters` is called
+// by rules with arguments, `ID(arg1, arg2)`, the parameters being referenced as identifiers in the `Expression`
 type Macro struct {
 	ID         MacroID
 	Expression string
+	Parameters []string
 	Opts       *Opts
 
 	evaluator *MacroEvaluator
@@ -28,6 +34,9 @@ type MacroEvaluator struct {
 	Value       interface{}
 	EventTypes  []EventType
 	FieldValues map[Field][]FieldValue
+	Variables   []string
+	Iterators   []Field
+	Macros      []MacroID
 }
 
 // GetEvaluator - Returns the MacroEvaluator of the Macro corresponding to the SECL `Expression`
@@ -50,12 +59,12 @@ func (m *Macro) Parse() error {
 	return nil
 }
 
-func macroToEvaluator(macro *ast.Macro, model Model, opts *Opts, field Field) (*MacroEvaluator, error) {
+func macroToEvaluator(macro *ast.Macro, model Model, opts *Opts, fields ...Field) (*MacroEvaluator, error) {
 	macros := make(map[MacroID]*MacroEvaluator)
 	for id, macro := range opts.Macros {
 		macros[id] = macro.evaluator
 	}
-	state := newState(model, field, macros)
+	state := newState(model, macros, fields...)
 
 	var eval interface{}
 	var err error
@@ -82,6 +91,9 @@ func macroToEvaluator(macro *ast.Macro, model Model, opts *Opts, field Field) (*
 		Value:       eval,
 		EventTypes:  events,
 		FieldValues: state.fieldValues,
+		Variables:   state.Variables(),
+		Iterators:   state.Iterators(),
+		Macros:      state.Macros(),
 	}, nil
 }
 
@@ -89,7 +101,13 @@ func macroToEvaluator(macro *ast.Macro, model Model, opts *Opts, field Field) (*
 func (m *Macro) GenEvaluator(model Model, opts *Opts) error {
 	m.Opts = opts
 
-	evaluator, err := macroToEvaluator(m.ast, model, opts, "")
+	// the evaluator of a parameterized macro depends on its arguments, it is generated for each call
+	if len(m.Parameters) != 0 {
+		m.evaluator = &MacroEvaluator{}
+		return nil
+	}
+
+	evaluator, err := macroToEvaluator(m.ast, model, opts)
 	if err != nil {
 		if err, ok := err.(*ErrAstToEval); ok {
 			return errors.Wrap(&ErrRuleParse{pos: err.Pos, expr: m.Expression}, "macro syntax error")
@@ -133,3 +151,58 @@ func (m *MacroEvaluator) GetFields() []Field {
 	}
 	return fields
 }
+
+// macroCallToEvaluator generates the evaluator of a call of a parameterized macro. The expression of the macro is
+// evaluated within the state of the caller, its parameters being bound to the evaluators of the arguments, so that
+// the fields of the macro are the ones of the caller
+func macroCallToEvaluator(call *ast.Primary, opts *Opts, state *state) (interface{}, lexer.Position, error) {
+	macro, ok := opts.Macros[*call.Ident]
+	if !ok {
+		return nil, call.Pos, NewError(call.Pos, fmt.Sprintf("unknown macro '%s'", *call.Ident))
+	}
+
+	if len(call.Arguments) != len(macro.Parameters) {
+		return nil, call.Pos, NewError(call.Pos, fmt.Sprintf("macro '%s' expects %d arguments, got %d", macro.ID, len(macro.Parameters), len(call.Arguments)))
+	}
+
+	if state.calls[macro.ID] {
+		return nil, call.Pos, NewError(call.Pos, fmt.Sprintf("recursive call of the macro '%s'", macro.ID))
+	}
+
+	parameters := make(map[string]interface{})
+	for i, argument := range call.Arguments {
+		evaluator, _, pos, err := nodeToEvaluator(argument, opts, state)
+		if err != nil {
+			return nil, pos, err
+		}
+		parameters[macro.Parameters[i]] = evaluator
+	}
+
+	callerParameters := state.parameters
+	state.parameters = parameters
+	state.calls[macro.ID] = true
+
+	defer func() {
+		state.parameters = callerParameters
+		delete(state.calls, macro.ID)
+	}()
+
+	var evaluator interface{}
+	var pos lexer.Position
+	var err error
+
+	switch {
+	case macro.ast.Expression != nil:
+		evaluator, _, pos, err = nodeToEvaluator(macro.ast.Expression, opts, state)
+	case macro.ast.Array != nil:
+		evaluator, _, pos, err = nodeToEvaluator(macro.ast.Array, opts, state)
+	case macro.ast.Primary != nil:
+		evaluator, _, pos, err = nodeToEvaluator(macro.ast.Primary, opts, state)
+	}
+
+	if err != nil {
+		return nil, pos, errors.Wrapf(err, "macro '%s'", macro.ID)
+	}
+
+	return evaluator, call.Pos, nil
+}
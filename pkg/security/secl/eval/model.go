@@ -14,3 +14,11 @@ type Model interface {
 	// NewEvent - Returns a new event instance
 	NewEvent() Event
 }
+
+// CostModel is implemented by the models having fields costly to resolve, the hash of the content of a file for
+// instance. The operand of a `&&` or a `||` comparing such a field is evaluated after the other one, the field is then
+// only resolved when the rest of the expression doesn't already decide the result
+type CostModel interface {
+	// IsCostlyField returns whether the given field is costly to resolve
+	IsCostlyField(field Field) bool
+}
@@ -7,6 +7,7 @@ package eval
 
 import (
 	"reflect"
+	"strings"
 	"syscall"
 	"unsafe"
 
@@ -14,16 +15,20 @@ import (
 )
 
 type testProcess struct {
-	name   string
-	uid    int
-	gid    int
-	isRoot bool
+	name      string
+	uid       int
+	gid       int
+	isRoot    bool
+	createdAt int
 }
 
 type testOpen struct {
 	filename string
 	mode     int
 	flags    int
+	sha256   string
+	// hashes counts the resolutions of the hash of the file
+	hashes int
 }
 
 type testMkdir struct {
@@ -35,14 +40,35 @@ type testEvent struct {
 	id   string
 	kind string
 
-	process testProcess
-	open    testOpen
-	mkdir   testMkdir
+	process   testProcess
+	ancestors []testProcess
+	open      testOpen
+	mkdir     testMkdir
 }
 
 type testModel struct {
 }
 
+type testProcessAncestorsIterator struct {
+	ancestors []testProcess
+	index     int
+	ancestor  testEvent
+}
+
+func (it *testProcessAncestorsIterator) Front(ctx *Context) unsafe.Pointer {
+	it.ancestors = (*testEvent)(ctx.Object).ancestors
+	it.index = -1
+	return it.Next()
+}
+
+func (it *testProcessAncestorsIterator) Next() unsafe.Pointer {
+	if it.index++; it.index >= len(it.ancestors) {
+		return nil
+	}
+	it.ancestor.process = it.ancestors[it.index]
+	return unsafe.Pointer(&it.ancestor)
+}
+
 func (e *testEvent) GetType() string {
 	return e.kind
 }
@@ -55,12 +81,33 @@ func (m *testModel) NewEvent() Event {
 	return &testEvent{}
 }
 
+func (m *testModel) GetIterable(key string) (string, string, bool) {
+	if strings.HasPrefix(key, "process.ancestors.") {
+		return "process.ancestors", "process." + strings.TrimPrefix(key, "process.ancestors."), true
+	}
+	return "", "", false
+}
+
+func (m *testModel) NewIterator(iterable string) (Iterator, error) {
+	if iterable == "process.ancestors" {
+		return &testProcessAncestorsIterator{}, nil
+	}
+	return nil, &ErrFieldNotFound{Field: iterable}
+}
+
+func (m *testModel) IsCostlyField(key string) bool {
+	return key == "open.sha256"
+}
+
 func (m *testModel) ValidateField(key string, value FieldValue) error {
 	switch key {
 
 	case "process.uid":
 
 		uid, ok := value.Value.(int)
+		if r, isRange := value.Value.(IntRange); isRange {
+			uid, ok = r.Min, true
+		}
 		if !ok {
 			return errors.New("invalid type for process.ui")
 		}
@@ -98,6 +145,13 @@ func (m *testModel) GetEvaluator(key string) (Evaluator, error) {
 			Field:   key,
 		}, nil
 
+	case "process.created_at":
+
+		return &IntEvaluator{
+			EvalFnc: func(ctx *Context) int { return (*testEvent)(ctx.Object).process.createdAt },
+			Field:   key,
+		}, nil
+
 	case "process.is_root":
 
 		return &BoolEvaluator{
@@ -126,6 +180,16 @@ func (m *testModel) GetEvaluator(key string) (Evaluator, error) {
 			Field:   key,
 		}, nil
 
+	case "open.sha256":
+
+		return &StringEvaluator{
+			EvalFnc: func(ctx *Context) string {
+				(*testEvent)(ctx.Object).open.hashes++
+				return (*testEvent)(ctx.Object).open.sha256
+			},
+			Field: key,
+		}, nil
+
 	case "mkdir.filename":
 
 		return &StringEvaluator{
@@ -164,6 +228,10 @@ func (e *testEvent) GetFieldValue(key string) (interface{}, error) {
 
 		return e.process.isRoot, nil
 
+	case "process.created_at":
+
+		return e.process.createdAt, nil
+
 	case "open.filename":
 
 		return e.open.filename, nil
@@ -176,6 +244,10 @@ func (e *testEvent) GetFieldValue(key string) (interface{}, error) {
 
 		return e.open.mode, nil
 
+	case "open.sha256":
+
+		return e.open.sha256, nil
+
 	case "mkdir.filename":
 
 		return e.mkdir.filename, nil
@@ -208,6 +280,10 @@ func (e *testEvent) GetFieldEventType(key string) (string, error) {
 
 		return "*", nil
 
+	case "process.created_at":
+
+		return "*", nil
+
 	case "open.filename":
 
 		return "open", nil
@@ -220,6 +296,10 @@ func (e *testEvent) GetFieldEventType(key string) (string, error) {
 
 		return "open", nil
 
+	case "open.sha256":
+
+		return "open", nil
+
 	case "mkdir.filename":
 
 		return "mkdir", nil
@@ -256,6 +336,11 @@ func (e *testEvent) SetFieldValue(key string, value interface{}) error {
 		e.process.isRoot = value.(bool)
 		return nil
 
+	case "process.created_at":
+
+		e.process.createdAt = value.(int)
+		return nil
+
 	case "open.filename":
 
 		e.open.filename = value.(string)
@@ -271,6 +356,11 @@ func (e *testEvent) SetFieldValue(key string, value interface{}) error {
 		e.open.mode = value.(int)
 		return nil
 
+	case "open.sha256":
+
+		e.open.sha256 = value.(string)
+		return nil
+
 	case "mkdir.filename":
 
 		e.mkdir.filename = value.(string)
@@ -305,6 +395,10 @@ func (e *testEvent) GetFieldType(key string) (reflect.Kind, error) {
 
 		return reflect.Bool, nil
 
+	case "process.created_at":
+
+		return reflect.Int, nil
+
 	case "open.filename":
 
 		return reflect.String, nil
@@ -317,6 +411,10 @@ func (e *testEvent) GetFieldType(key string) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "open.sha256":
+
+		return reflect.String, nil
+
 	case "mkdir.filename":
 
 		return reflect.String, nil
@@ -6,6 +6,7 @@
 package eval
 
 import (
+	"net"
 	"regexp"
 	"sort"
 
@@ -15,7 +16,7 @@ import (
 // IntNot - ^int operator
 func IntNot(a *IntEvaluator, opts *Opts, state *state) *IntEvaluator {
 	isPartialLeaf := a.isPartial
-	if a.Field != "" && state.field != "" && a.Field != state.field {
+	if a.Field != "" && state.isPartial() && !state.isPartialField(a.Field) {
 		isPartialLeaf = true
 	}
 
@@ -39,41 +40,63 @@ func IntNot(a *IntEvaluator, opts *Opts, state *state) *IntEvaluator {
 }
 
 func patternToRegexp(pattern string) (*regexp.Regexp, error) {
-	// only accept suffix wilcard, ex: /etc/* or /etc/*.conf
-	if matched, err := regexp.Match(`\*.*/`, []byte(pattern)); err != nil || matched {
+	// only accept suffix wilcard, ex: /etc/* or /etc/*.conf, and multi-directory wildcard, ex: /etc/**/*.conf
+	if matched, err := regexp.Match(`(^|[^*])\*([^*].*)?/`, []byte(pattern)); err != nil || matched {
 		return nil, &ErrInvalidPattern{Pattern: pattern}
 	}
 
 	// quote eveything except wilcard
-	re := regexp.MustCompile(`[\.*+?()|\[\]{}^$]`)
+	re := regexp.MustCompile(`\*\*/|\*\*|[\.*+?()|\[\]{}^$]`)
 	quoted := re.ReplaceAllStringFunc(pattern, func(s string) string {
-		if s != "*" {
-			return "\\" + s
+		switch s {
+		case "**/":
+			// any number of directories, including none
+			return "(.*/)?"
+		case "**", "*":
+			return ".*"
 		}
-		return ".*"
+		return "\\" + s
 	})
 
 	return regexp.Compile("^" + quoted + "$")
 }
 
-// StringMatches - String pattern matching operator
+// StringMatches - String pattern and regexp matching operator
 func StringMatches(a *StringEvaluator, b *StringEvaluator, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
-	re, err := patternToRegexp(b.Value)
-	if err != nil {
-		return nil, err
-	}
-
 	if b.EvalFnc != nil {
 		return nil, errors.New("regex has to be a scalar string")
 	}
 
+	var re *regexp.Regexp
+	var err error
+
+	valueType := PatternValueType
+	if b.isRegexp {
+		// compiled once, RE2 guarantees a matching time linear in the size of the input, no backtracking
+		re, err = regexp.Compile(b.Value)
+		if err != nil {
+			return nil, &ErrInvalidRegexp{Regexp: b.Value, Err: err}
+		}
+		valueType = RegexpValueType
+	} else {
+		re, err = patternToRegexp(b.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if b.isCaseInsensitive {
+		re = regexp.MustCompile("(?i)" + re.String())
+		valueType = CaseInsensitivePatternValueType
+	}
+
 	isPartialLeaf := a.isPartial
-	if a.Field != "" && state.field != "" && a.Field != state.field {
+	if a.Field != "" && state.isPartial() && !state.isPartialField(a.Field) {
 		isPartialLeaf = true
 	}
 
 	if a.Field != "" {
-		if err := state.UpdateFieldValues(a.Field, FieldValue{Value: b.Value, Type: PatternValueType}); err != nil {
+		if err := state.UpdateFieldValues(a.Field, FieldValue{Value: b.Value, Type: valueType}); err != nil {
 			return nil, err
 		}
 	}
@@ -115,7 +138,7 @@ func StringMatches(a *StringEvaluator, b *StringEvaluator, not bool, opts *Opts,
 // Not - !true operator
 func Not(a *BoolEvaluator, opts *Opts, state *state) *BoolEvaluator {
 	isPartialLeaf := a.isPartial
-	if a.Field != "" && state.field != "" && a.Field != state.field {
+	if a.Field != "" && state.isPartial() && !state.isPartialField(a.Field) {
 		isPartialLeaf = true
 	}
 
@@ -124,7 +147,7 @@ func Not(a *BoolEvaluator, opts *Opts, state *state) *BoolEvaluator {
 			return !a.EvalFnc(ctx)
 		}
 
-		if state.field != "" {
+		if state.isPartial() {
 			if a.isPartial {
 				ea = func(ctx *Context) bool {
 					return true
@@ -152,7 +175,7 @@ func Not(a *BoolEvaluator, opts *Opts, state *state) *BoolEvaluator {
 // Minus - -int operator
 func Minus(a *IntEvaluator, opts *Opts, state *state) *IntEvaluator {
 	isPartialLeaf := a.isPartial
-	if a.Field != "" && state.field != "" && a.Field != state.field {
+	if a.Field != "" && state.isPartial() && !state.isPartialField(a.Field) {
 		isPartialLeaf = true
 	}
 
@@ -170,15 +193,64 @@ func Minus(a *IntEvaluator, opts *Opts, state *state) *IntEvaluator {
 	}
 
 	return &IntEvaluator{
-		Value:     -a.Value,
+		Value:      -a.Value,
+		isPartial:  isPartialLeaf,
+		isDuration: a.isDuration,
+	}
+}
+
+// intArithmetic returns the evaluator of an arithmetic operation, the values of the fields are not registered
+// as the result of the operation is compared, not the fields themselves. An operation on durations and constants is a
+// duration, an operation on a field and a duration being a timestamp
+func intArithmetic(a *IntEvaluator, b *IntEvaluator, state *state, op func(a, b int) int) *IntEvaluator {
+	isPartialLeaf := a.isPartial || b.isPartial
+	if a.Field != "" && state.isPartial() && !state.isPartialField(a.Field) {
+		isPartialLeaf = true
+	}
+	if b.Field != "" && state.isPartial() && !state.isPartialField(b.Field) {
+		isPartialLeaf = true
+	}
+
+	if a.EvalFnc == nil && b.EvalFnc == nil {
+		return &IntEvaluator{
+			Value:      op(a.Value, b.Value),
+			isPartial:  isPartialLeaf,
+			isDuration: a.isDuration || b.isDuration,
+		}
+	}
+
+	ea, eb := a.EvalFnc, b.EvalFnc
+	if ea == nil {
+		value := a.Value
+		ea = func(ctx *Context) int { return value }
+	}
+	if eb == nil {
+		value := b.Value
+		eb = func(ctx *Context) int { return value }
+	}
+
+	return &IntEvaluator{
+		EvalFnc: func(ctx *Context) int {
+			return op(ea(ctx), eb(ctx))
+		},
 		isPartial: isPartialLeaf,
 	}
 }
 
+// IntAdd - int + int operator
+func IntAdd(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) *IntEvaluator {
+	return intArithmetic(a, b, state, func(a, b int) int { return a + b })
+}
+
+// IntSubtract - int - int operator
+func IntSubtract(a *IntEvaluator, b *IntEvaluator, opts *Opts, state *state) *IntEvaluator {
+	return intArithmetic(a, b, state, func(a, b int) int { return a - b })
+}
+
 // StringArrayContains - "test" in ["...", "..."] operator
 func StringArrayContains(a *StringEvaluator, b *StringArray, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
 	isPartialLeaf := a.isPartial
-	if a.Field != "" && state.field != "" && a.Field != state.field {
+	if a.Field != "" && state.isPartial() && !state.isPartialField(a.Field) {
 		isPartialLeaf = true
 	}
 
@@ -224,10 +296,99 @@ func StringArrayContains(a *StringEvaluator, b *StringArray, not bool, opts *Opt
 	}, nil
 }
 
+// StringArrayMatchesCIDR - "10.0.0.1" in [ip"10.0.0.0/8", ip"192.168.0.0/16"] operator
+func StringArrayMatchesCIDR(a *StringEvaluator, b *CIDRArray, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
+	isPartialLeaf := a.isPartial
+	if a.Field != "" && state.isPartial() && !state.isPartialField(a.Field) {
+		isPartialLeaf = true
+	}
+
+	if a.Field != "" {
+		for _, value := range b.Values {
+			if err := state.UpdateFieldValues(a.Field, FieldValue{Value: value, Type: CIDRValueType}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if a.EvalFnc != nil {
+		ea := a.EvalFnc
+
+		evalFnc := func(ctx *Context) bool {
+			result := b.Contains(net.ParseIP(ea(ctx)))
+			if not {
+				result = !result
+			}
+			return result
+		}
+
+		return &BoolEvaluator{
+			EvalFnc:   evalFnc,
+			isPartial: isPartialLeaf,
+		}, nil
+	}
+
+	ea := true
+	if !isPartialLeaf {
+		ea = b.Contains(net.ParseIP(a.Value))
+		if not {
+			ea = !ea
+		}
+	}
+
+	return &BoolEvaluator{
+		Value:     ea,
+		isPartial: isPartialLeaf,
+	}, nil
+}
+
+// StringListContains - "test" in @list operator
+func StringListContains(a *StringEvaluator, b *List, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
+	isPartialLeaf := a.isPartial
+	if a.Field != "" && state.isPartial() && !state.isPartialField(a.Field) {
+		isPartialLeaf = true
+	}
+
+	if a.Field != "" {
+		if err := state.UpdateFieldValues(a.Field, FieldValue{Value: b.ID, Type: ListValueType}); err != nil {
+			return nil, err
+		}
+
+		// the values of the list can be replaced at any time, a discarder of the field would become invalid
+		if state.isPartialField(a.Field) {
+			return &BoolEvaluator{
+				Value:     true,
+				isPartial: isPartialLeaf,
+			}, nil
+		}
+	}
+
+	ea := a.EvalFnc
+	if ea == nil {
+		value := a.Value
+		ea = func(ctx *Context) string {
+			return value
+		}
+	}
+
+	evalFnc := func(ctx *Context) bool {
+		result := b.Contains(ea(ctx))
+		if not {
+			result = !result
+		}
+		return result
+	}
+
+	return &BoolEvaluator{
+		EvalFnc:   evalFnc,
+		isPartial: isPartialLeaf,
+	}, nil
+}
+
 // IntArrayContains - 1 in [1, 2, 3] operator
 func IntArrayContains(a *IntEvaluator, b *IntArray, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
 	isPartialLeaf := a.isPartial
-	if a.Field != "" && state.field != "" && a.Field != state.field {
+	if a.Field != "" && state.isPartial() && !state.isPartialField(a.Field) {
 		isPartialLeaf = true
 	}
 
@@ -237,15 +398,30 @@ func IntArrayContains(a *IntEvaluator, b *IntArray, not bool, opts *Opts, state
 				return nil, err
 			}
 		}
+		for _, r := range b.Ranges {
+			if err := state.UpdateFieldValues(a.Field, FieldValue{Value: r, Type: RangeValueType}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	contains := func(n int) bool {
+		if i := sort.SearchInts(b.Values, n); i < len(b.Values) && b.Values[i] == n {
+			return true
+		}
+		for _, r := range b.Ranges {
+			if r.Contains(n) {
+				return true
+			}
+		}
+		return false
 	}
 
 	if a.EvalFnc != nil {
 		ea := a.EvalFnc
 
 		evalFnc := func(ctx *Context) bool {
-			n := ea(ctx)
-			i := sort.SearchInts(b.Values, n)
-			result := i < len(b.Values) && b.Values[i] == n
+			result := contains(ea(ctx))
 			if not {
 				result = !result
 			}
@@ -260,8 +436,7 @@ func IntArrayContains(a *IntEvaluator, b *IntArray, not bool, opts *Opts, state
 
 	ea := true
 	if !isPartialLeaf {
-		i := sort.SearchInts(b.Values, a.Value)
-		ea = i < len(b.Values) && b.Values[i] == a.Value
+		ea = contains(a.Value)
 		if not {
 			ea = !ea
 		}
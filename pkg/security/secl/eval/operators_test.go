@@ -6,6 +6,8 @@
 package eval
 
 import (
+	"fmt"
+	"net"
 	"testing"
 )
 
@@ -19,6 +21,19 @@ func TestPatternValue(t *testing.T) {
 		t.Fatalf("expected regexp not found: %s", re.String())
 	}
 
+	re, err = patternToRegexp("/var/lib/**/*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if re.String() != "^/var/lib/(.*/)?.*\\.conf$" {
+		t.Fatalf("expected regexp not found: %s", re.String())
+	}
+
+	if _, err = patternToRegexp("/etc/**/passwd/*"); err != nil {
+		t.Fatalf("multi-directory wildcard should be accepted: %s", err)
+	}
+
 	if _, err = patternToRegexp("*/passwd"); err == nil {
 		t.Fatal("only suffix wildcard are accepted")
 	}
@@ -27,3 +42,45 @@ func TestPatternValue(t *testing.T) {
 		t.Fatal("only suffix wildcard are accepted")
 	}
 }
+
+func TestCIDRArray(t *testing.T) {
+	values := []string{"10.0.0.0/8", "10.1.0.0/16", "192.168.1.1", "2001:db8::/32"}
+	for i := 0; i < 1000; i++ {
+		values = append(values, fmt.Sprintf("172.%d.%d.0/24", 16+i/256, i%256))
+	}
+
+	cidrs, err := newCIDRArray(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		IP       string
+		Expected bool
+	}{
+		{IP: "10.2.3.4", Expected: true},
+		{IP: "10.1.3.4", Expected: true},
+		{IP: "11.0.0.1", Expected: false},
+		{IP: "192.168.1.1", Expected: true},
+		{IP: "192.168.1.2", Expected: false},
+		{IP: "172.19.231.7", Expected: true},
+		{IP: "172.19.232.7", Expected: false},
+		{IP: "2001:db8:1::1", Expected: true},
+		{IP: "2001:db9::1", Expected: false},
+		{IP: "::ffff:10.0.0.1", Expected: true},
+	}
+
+	for _, test := range tests {
+		if result := cidrs.Contains(net.ParseIP(test.IP)); result != test.Expected {
+			t.Errorf("expected `%t` for %s, got `%t`", test.Expected, test.IP, result)
+		}
+	}
+
+	if cidrs.Contains(nil) {
+		t.Error("an invalid IP address shouldn't match")
+	}
+
+	if _, err := newCIDRArray([]string{"10.0.0.0/8", "/usr/bin"}); err == nil {
+		t.Error("only IP addresses and CIDR ranges are accepted")
+	}
+}
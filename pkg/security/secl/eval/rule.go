@@ -25,6 +25,8 @@ type Rule struct {
 
 	evaluator *RuleEvaluator
 	ast       *ast.Rule
+	variables []string
+	iterators []Field
 }
 
 // RuleEvaluator - Evaluation part of a Rule
@@ -32,6 +34,9 @@ type RuleEvaluator struct {
 	Eval        func(ctx *Context) bool
 	EventTypes  []EventType
 	FieldValues map[Field][]FieldValue
+	Variables   []string
+	Iterators   []Field
+	Macros      []MacroID
 
 	partialEvals map[Field]func(ctx *Context) bool
 }
@@ -95,6 +100,16 @@ func (r *Rule) GetFields() []Field {
 	return fields
 }
 
+// GetVariables - Returns all the variables of the Rule including the variables of the Macro used
+func (r *Rule) GetVariables() []string {
+	return r.variables
+}
+
+// GetIterators - Returns all the iterable fields of the Rule including the iterable fields of the Macro used
+func (r *Rule) GetIterators() []Field {
+	return r.iterators
+}
+
 // GetEvaluator - Returns the RuleEvaluator of the Rule corresponding to the SECL `Expression`
 func (r *Rule) GetEvaluator() *RuleEvaluator {
 	return r.evaluator
@@ -131,7 +146,7 @@ func ruleToEvaluator(rule *ast.Rule, model Model, opts *Opts) (*RuleEvaluator, e
 	for id, macro := range opts.Macros {
 		macros[id] = macro.evaluator
 	}
-	state := newState(model, "", macros)
+	state := newState(model, macros)
 
 	eval, _, _, err := nodeToEvaluator(rule.BooleanExpression, opts, state)
 	if err != nil {
@@ -156,6 +171,9 @@ func ruleToEvaluator(rule *ast.Rule, model Model, opts *Opts) (*RuleEvaluator, e
 			},
 			EventTypes:  events,
 			FieldValues: state.fieldValues,
+			Variables:   state.Variables(),
+			Iterators:   state.Iterators(),
+			Macros:      state.Macros(),
 		}, nil
 	}
 
@@ -163,6 +181,9 @@ func ruleToEvaluator(rule *ast.Rule, model Model, opts *Opts) (*RuleEvaluator, e
 		Eval:        evalBool.EvalFnc,
 		EventTypes:  events,
 		FieldValues: state.fieldValues,
+		Variables:   state.Variables(),
+		Iterators:   state.Iterators(),
+		Macros:      state.Macros(),
 	}, nil
 }
 
@@ -180,61 +201,105 @@ func (r *Rule) GenEvaluator(model Model, opts *Opts) error {
 	}
 	r.evaluator = evaluator
 
+	// the variables and the iterable fields are collected once, they're checked for each approver of the rule. They're
+	// copied so that the slices of the evaluators are never appended to
+	r.variables = append([]string{}, evaluator.Variables...)
+	r.iterators = append([]Field{}, evaluator.Iterators...)
+	for _, macro := range r.referencedMacros() {
+		r.variables = append(r.variables, macro.evaluator.Variables...)
+		r.iterators = append(r.iterators, macro.evaluator.Iterators...)
+	}
+
 	return nil
 }
 
-func (r *Rule) genMacroPartials() (map[Field]map[MacroID]*MacroEvaluator, error) {
-	partials := make(map[Field]map[MacroID]*MacroEvaluator)
-	for _, field := range r.GetFields() {
-		for id, macro := range r.Opts.Macros {
-
-			// NOTE(safchain) this is not working with nested macro. It will be removed once partial
-			// will be generated another way
-			evaluator, err := macroToEvaluator(macro.ast, r.Model, r.Opts, field)
-			if err != nil {
-				if err, ok := err.(*ErrAstToEval); ok {
-					return nil, errors.Wrap(&ErrRuleParse{pos: err.Pos, expr: macro.Expression}, "macro syntax error")
-				}
-				return nil, errors.Wrap(err, "macro compilation error")
-			}
-			macroEvaluators, exists := partials[field]
-			if !exists {
-				macroEvaluators = make(map[MacroID]*MacroEvaluator)
-				partials[field] = macroEvaluators
+// referencedMacros returns the macros referenced by the rule, directly or through the macros it references
+func (r *Rule) referencedMacros() []*Macro {
+	var macros []*Macro
+
+	visited := make(map[MacroID]bool)
+	pending := append([]MacroID{}, r.evaluator.Macros...)
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		macro, exists := r.Opts.Macros[id]
+		if !exists || macro.evaluator == nil {
+			continue
+		}
+		macros = append(macros, macro)
+		pending = append(pending, macro.evaluator.Macros...)
+	}
+
+	return macros
+}
+
+func (r *Rule) genMacroPartials(fields ...Field) (map[MacroID]*MacroEvaluator, error) {
+	macroEvaluators := make(map[MacroID]*MacroEvaluator)
+	for id, macro := range r.Opts.Macros {
+		// the partials of parameterized macros are generated along with the ones of the rule calling them
+		if len(macro.Parameters) != 0 {
+			continue
+		}
+
+		// NOTE(safchain) this is not working with nested macro. It will be removed once partial
+		// will be generated another way
+		evaluator, err := macroToEvaluator(macro.ast, r.Model, r.Opts, fields...)
+		if err != nil {
+			if err, ok := err.(*ErrAstToEval); ok {
+				return nil, errors.Wrap(&ErrRuleParse{pos: err.Pos, expr: macro.Expression}, "macro syntax error")
 			}
-			macroEvaluators[id] = evaluator
+			return nil, errors.Wrap(err, "macro compilation error")
 		}
+		macroEvaluators[id] = evaluator
 	}
 
-	return partials, nil
+	return macroEvaluators, nil
 }
 
-// GenPartials - Compiles and generates partial Evaluators
-func (r *Rule) GenPartials() error {
-	macroPartials, err := r.genMacroPartials()
+// GenPartial returns the partial evaluation of the rule with the given fields, the comparisons of the other fields
+// being assumed true. It is false only if the rule can't match the values of the given fields, whatever the values of
+// the other fields
+func (r *Rule) GenPartial(fields ...Field) (func(ctx *Context) bool, error) {
+	macroPartials, err := r.genMacroPartials(fields...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, field := range r.GetFields() {
-		state := newState(r.Model, field, macroPartials[field])
-		pEval, _, _, err := nodeToEvaluator(r.ast.BooleanExpression, r.Opts, state)
-		if err != nil {
-			return errors.Wrapf(err, "couldn't generate partial for field %s and rule %s", field, r.ID)
-		}
+	state := newState(r.Model, macroPartials, fields...)
+	pEval, _, _, err := nodeToEvaluator(r.ast.BooleanExpression, r.Opts, state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't generate partial for fields %v and rule %s", fields, r.ID)
+	}
 
-		pEvalBool, ok := pEval.(*BoolEvaluator)
-		if !ok {
-			return NewTypeError(r.ast.Pos, reflect.Bool)
-		}
+	pEvalBool, ok := pEval.(*BoolEvaluator)
+	if !ok {
+		return nil, NewTypeError(r.ast.Pos, reflect.Bool)
+	}
 
-		if pEvalBool.EvalFnc == nil {
-			pEvalBool.EvalFnc = func(ctx *Context) bool {
-				return pEvalBool.Value
-			}
+	if pEvalBool.EvalFnc == nil {
+		return func(ctx *Context) bool {
+			return pEvalBool.Value
+		}, nil
+	}
+
+	return pEvalBool.EvalFnc, nil
+}
+
+// GenPartials - Compiles and generates partial Evaluators
+func (r *Rule) GenPartials() error {
+	for _, field := range r.GetFields() {
+		pEval, err := r.GenPartial(field)
+		if err != nil {
+			return err
 		}
 
-		r.evaluator.setPartial(field, pEvalBool.EvalFnc)
+		r.evaluator.setPartial(field, pEval)
 	}
 
 	return nil
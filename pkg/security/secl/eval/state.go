@@ -8,11 +8,38 @@ package eval
 import "sort"
 
 type state struct {
-	model       Model
-	field       Field
+	model Model
+	// fields holds the fields of the partial evaluation being compiled, the comparisons of the other fields are assumed
+	// true. It is empty when the whole expression is compiled
+	fields      map[Field]bool
 	events      map[EventType]bool
 	fieldValues map[Field][]FieldValue
 	macros      map[MacroID]*MacroEvaluator
+	variables   map[string]bool
+	iterators   map[Field]bool
+	parameters  map[string]interface{}
+	calls       map[MacroID]bool
+	// referenced holds the macros referenced by the expression, their variables and iterable fields are the ones of
+	// the expression too
+	referenced map[MacroID]bool
+
+	// iterator and iterable are the iterator factory and the iterable field of the comparison being compiled
+	iterator iteratorFactory
+	iterable Field
+
+	// costly reports whether the operand being compiled resolves a costly field
+	costly bool
+}
+
+// isPartial reports whether a partial evaluation is being compiled
+func (s *state) isPartial() bool {
+	return len(s.fields) > 0
+}
+
+// isPartialField reports whether the comparisons of the given field are evaluated by the partial evaluation being
+// compiled
+func (s *state) isPartialField(field Field) bool {
+	return s.fields[field]
 }
 
 //
@@ -22,7 +49,27 @@ func (s *state) UpdateFields(field Field) {
 	}
 }
 
+// UpdateCost marks the operand being compiled as costly if one of the given fields is costly to resolve
+func (s *state) UpdateCost(fields ...Field) {
+	model, ok := s.model.(CostModel)
+	if !ok {
+		return
+	}
+
+	for _, field := range fields {
+		if model.IsCostlyField(field) {
+			s.costly = true
+			return
+		}
+	}
+}
+
 func (s *state) UpdateFieldValues(field Field, value FieldValue) error {
+	// variables and transformed fields are not fields of the model, their values are only known at evaluation time
+	if isVariableField(field) || isTransformedField(field) {
+		return nil
+	}
+
 	values, ok := s.fieldValues[field]
 	if !ok {
 		values = []FieldValue{}
@@ -32,6 +79,51 @@ func (s *state) UpdateFieldValues(field Field, value FieldValue) error {
 	return s.model.ValidateField(field, value)
 }
 
+func (s *state) UpdateVariables(name string) {
+	s.variables[name] = true
+}
+
+func (s *state) Variables() []string {
+	var variables []string
+
+	for variable := range s.variables {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+
+	return variables
+}
+
+func (s *state) UpdateMacros(id MacroID) {
+	s.referenced[id] = true
+}
+
+func (s *state) Macros() []MacroID {
+	var macros []MacroID
+
+	for id := range s.referenced {
+		macros = append(macros, id)
+	}
+	sort.Strings(macros)
+
+	return macros
+}
+
+func (s *state) UpdateIterators(iterable Field) {
+	s.iterators[iterable] = true
+}
+
+func (s *state) Iterators() []Field {
+	var iterators []Field
+
+	for iterable := range s.iterators {
+		iterators = append(iterators, iterable)
+	}
+	sort.Strings(iterators)
+
+	return iterators
+}
+
 func (s *state) Events() []EventType {
 	var events []EventType
 
@@ -43,15 +135,25 @@ func (s *state) Events() []EventType {
 	return events
 }
 
-func newState(model Model, field Field, macros map[MacroID]*MacroEvaluator) *state {
+func newState(model Model, macros map[MacroID]*MacroEvaluator, fields ...Field) *state {
 	if macros == nil {
 		macros = make(map[MacroID]*MacroEvaluator)
 	}
+
+	partialFields := make(map[Field]bool)
+	for _, field := range fields {
+		partialFields[field] = true
+	}
+
 	return &state{
-		field:       field,
+		fields:      partialFields,
 		macros:      macros,
 		model:       model,
 		events:      make(map[EventType]bool),
 		fieldValues: make(map[Field][]FieldValue),
+		variables:   make(map[string]bool),
+		iterators:   make(map[Field]bool),
+		calls:       make(map[MacroID]bool),
+		referenced:  make(map[MacroID]bool),
 	}
 }
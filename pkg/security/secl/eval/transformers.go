@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/alecthomas/participle/lexer"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/ast"
+)
+
+// transformers holds the functions that can be applied to the string values of a rule, like
+// `basename(open.filename) == "passwd"`
+var transformers = map[string]func(value string) string{
+	"basename": func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return path.Base(value)
+	},
+	"dirname": func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return path.Dir(value)
+	},
+	"lower": strings.ToLower,
+}
+
+// transformedField returns the field of the evaluators of a transformed field, it can't collide with the fields of a
+// model. As for variables, operators consider such a field as partial when generating the partial of another field.
+func transformedField(name string, field Field) Field {
+	return name + "(" + field + ")"
+}
+
+func isTransformedField(field Field) bool {
+	return strings.HasSuffix(field, ")")
+}
+
+// transformerCallToEvaluator returns the evaluator of a call to a transformer. The basename of a field is replaced by
+// the basename field of the model when it exists, `open.basename` for `open.filename` for instance, so that the
+// comparisons of the basename still generate approvers and discarders
+func transformerCallToEvaluator(call *ast.Primary, opts *Opts, state *state) (interface{}, lexer.Position, error) {
+	name := *call.Ident
+	transform := transformers[name]
+
+	if len(call.Arguments) != 1 {
+		return nil, call.Pos, NewError(call.Pos, fmt.Sprintf("'%s' expects 1 argument, got %d", name, len(call.Arguments)))
+	}
+
+	argument, _, pos, err := nodeToEvaluator(call.Arguments[0], opts, state)
+	if err != nil {
+		return nil, pos, err
+	}
+
+	value, ok := argument.(*StringEvaluator)
+	if !ok || value.isRegexp || value.isCaseInsensitive {
+		return nil, pos, NewTypeError(pos, reflect.String)
+	}
+
+	if value.EvalFnc == nil {
+		return &StringEvaluator{Value: transform(value.Value)}, call.Pos, nil
+	}
+
+	if name == "basename" && strings.HasSuffix(value.Field, ".filename") {
+		field := strings.TrimSuffix(value.Field, ".filename") + ".basename"
+		if accessor, err := state.model.GetEvaluator(field); err == nil {
+			state.UpdateFields(field)
+			return accessor, call.Pos, nil
+		}
+	}
+
+	evalFnc := value.EvalFnc
+	evaluator := &StringEvaluator{
+		EvalFnc: func(ctx *Context) string {
+			return transform(evalFnc(ctx))
+		},
+		isPartial: value.isPartial,
+	}
+
+	if value.Field != "" {
+		evaluator.Field = transformedField(name, value.Field)
+	}
+
+	return evaluator, call.Pos, nil
+}
@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import "strings"
+
+// VariableValue - Value of a variable, referenced in rules as `${name}`. The value is read at evaluation time
+// as it can be changed by the actions of other rules
+type VariableValue interface {
+	GetEvaluator() interface{}
+}
+
+// BoolVariable - Variable holding a bool value
+type BoolVariable struct {
+	getFnc func(ctx *Context) bool
+}
+
+// NewBoolVariable - Returns a new bool variable, the given function returns its value for the evaluated context
+func NewBoolVariable(getFnc func(ctx *Context) bool) *BoolVariable {
+	return &BoolVariable{getFnc: getFnc}
+}
+
+// GetEvaluator - Returns the evaluator of the variable. The value of a variable can't be known in advance, it
+// is thus considered as partial so that it never leads to a discarder
+func (b *BoolVariable) GetEvaluator() interface{} {
+	return &BoolEvaluator{
+		EvalFnc:   b.getFnc,
+		isPartial: true,
+	}
+}
+
+// IntVariable - Variable holding an int value
+type IntVariable struct {
+	getFnc func(ctx *Context) int
+}
+
+// NewIntVariable - Returns a new int variable, the given function returns its value for the evaluated context
+func NewIntVariable(getFnc func(ctx *Context) int) *IntVariable {
+	return &IntVariable{getFnc: getFnc}
+}
+
+// GetEvaluator - Returns the evaluator of the variable
+func (i *IntVariable) GetEvaluator() interface{} {
+	return &IntEvaluator{
+		EvalFnc:   i.getFnc,
+		isPartial: true,
+	}
+}
+
+// StringVariable - Variable holding a string value
+type StringVariable struct {
+	getFnc func(ctx *Context) string
+}
+
+// NewStringVariable - Returns a new string variable, the given function returns its value for the evaluated context
+func NewStringVariable(getFnc func(ctx *Context) string) *StringVariable {
+	return &StringVariable{getFnc: getFnc}
+}
+
+// GetEvaluator - Returns the evaluator of the variable
+func (s *StringVariable) GetEvaluator() interface{} {
+	return &StringEvaluator{
+		EvalFnc:   s.getFnc,
+		isPartial: true,
+	}
+}
+
+// variableField returns the field of the evaluators of a variable, it can't collide with the fields of a model.
+// Operators consider such a field as partial when generating the partial of another field.
+func variableField(name string) Field {
+	return "${" + name + "}"
+}
+
+func isVariableField(field Field) bool {
+	return strings.HasPrefix(field, "${")
+}
+
+// variableToEvaluator returns the evaluator of the given variable
+func variableToEvaluator(name string, variable VariableValue) interface{} {
+	evaluator := variable.GetEvaluator()
+
+	switch evaluator := evaluator.(type) {
+	case *BoolEvaluator:
+		evaluator.Field = variableField(name)
+	case *IntEvaluator:
+		evaluator.Field = variableField(name)
+	case *StringEvaluator:
+		evaluator.Field = variableField(name)
+	}
+
+	return evaluator
+}
@@ -28,10 +28,10 @@ package	eval
 func {{ .FuncName }}(a *{{ .Arg1Type }}, b *{{ .Arg2Type }}, opts *Opts, state *state) (*{{ .FuncReturnType }}, error) {
 	partialA, partialB := a.isPartial, b.isPartial
 
-	if a.EvalFnc == nil || (a.Field != "" && a.Field != state.field) {
+	if a.EvalFnc == nil || (a.Field != "" && !state.isPartialField(a.Field)) {
 		partialA = true
 	}
-	if b.EvalFnc == nil || (b.Field != "" && b.Field != state.field) {
+	if b.EvalFnc == nil || (b.Field != "" && !state.isPartialField(b.Field)) {
 		partialB = true
 	}
 	isPartialLeaf := partialA && partialB
@@ -44,7 +44,7 @@ func {{ .FuncName }}(a *{{ .Arg1Type }}, b *{{ .Arg2Type }}, opts *Opts, state *
 		ea, eb := a.EvalFnc, b.EvalFnc
 
 		{{ if or (eq .FuncName "Or") (eq .FuncName "And") }}
-			if state.field != "" {
+			if state.isPartial() {
 				if a.isPartial {
 					ea = func(ctx *Context) {{ .EvalReturnType }} {
 						return true
@@ -72,7 +72,7 @@ func {{ .FuncName }}(a *{{ .Arg1Type }}, b *{{ .Arg2Type }}, opts *Opts, state *
 		ea, eb := a.Value, b.Value
 
 		{{ if or (eq .FuncName "Or") (eq .FuncName "And") }}
-		if state.field != "" {
+		if state.isPartial() {
 			if a.isPartial {
 				ea = true
 			}
@@ -98,7 +98,7 @@ func {{ .FuncName }}(a *{{ .Arg1Type }}, b *{{ .Arg2Type }}, opts *Opts, state *
 		}
 
 		{{ if or (eq .FuncName "Or") (eq .FuncName "And") }}
-			if state.field != "" {
+			if state.isPartial() {
 				if a.isPartial {
 					ea = func(ctx *Context) {{ .EvalReturnType }} {
 						return true
@@ -129,7 +129,7 @@ func {{ .FuncName }}(a *{{ .Arg1Type }}, b *{{ .Arg2Type }}, opts *Opts, state *
 	}
 
 	{{ if or (eq .FuncName "Or") (eq .FuncName "And") }}
-		if state.field != "" {
+		if state.isPartial() {
 			if a.isPartial {
 				ea = true
 			}
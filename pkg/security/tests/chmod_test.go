@@ -106,6 +106,15 @@ func TestChmod(t *testing.T) {
 				t.Errorf("expected chmod mode 0757, got %#o", mode)
 			}
 
+			// the previous mode was set by the fchmod test
+			if mode := event.Chmod.FileMetadata.Mode; mode != syscall.S_IFREG|0707 {
+				t.Errorf("expected previous file mode %#o, got %#o", syscall.S_IFREG|0707, mode)
+			}
+
+			if uid := event.Chmod.FileMetadata.UID; uid != uint32(os.Getuid()) {
+				t.Errorf("expected previous file uid %d, got %d", os.Getuid(), uid)
+			}
+
 			if inode := getInode(t, testFile); inode != event.Chmod.Inode {
 				t.Errorf("expected inode %d, got %d", event.Chmod.Inode, inode)
 			}
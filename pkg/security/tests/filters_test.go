@@ -155,6 +155,50 @@ func TestOpenParentDiscarderFilter(t *testing.T) {
 	}
 }
 
+func TestOpenDiscarderLink(t *testing.T) {
+	rule := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: `open.filename == "{{.Root}}/test-odl-link" && open.flags & O_CREAT > 0`,
+	}
+
+	test, err := newTestProbe(nil, []*rules.RuleDefinition{rule}, testOpts{enableFilters: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	fd1, testFile, err := openTestFile(test, "test-odl-file", syscall.O_CREAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syscall.Close(fd1)
+	defer os.Remove(testFile)
+
+	if _, err := waitForOpenDiscarder(test, testFile); err != nil {
+		t.Fatal(err)
+	}
+
+	// the inode discarder of the file doesn't apply to its new name
+	testLink, _, err := test.Path("test-odl-link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(testFile, testLink); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(testLink)
+
+	fd2, _, err := openTestFile(test, "test-odl-link", syscall.O_CREAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fd2)
+
+	if _, err := waitForOpenEvent(test, testLink); err != nil {
+		t.Errorf("expected an event for the linked file: %s", err)
+	}
+}
+
 func TestOpenFlagsApproverFilter(t *testing.T) {
 	rule := &rules.RuleDefinition{
 		ID:         "test_rule",
@@ -233,3 +277,190 @@ func TestOpenProcessPidDiscarder(t *testing.T) {
 		t.Fatalf("shouldn't get an event: %+v", event)
 	}
 }
+
+func TestOpenPrefixApproverFilter(t *testing.T) {
+	rule := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: `open.filename =~ "{{.Root}}/test-opa/*"`,
+	}
+
+	test, err := newTestProbe(nil, []*rules.RuleDefinition{rule}, testOpts{enableFilters: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	// the prefixes are only matched on the files of the mounts rooted at the root of the namespace
+	var root, testRoot syscall.Stat_t
+	if err := syscall.Stat("/", &root); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Stat(test.Root(), &testRoot); err != nil {
+		t.Fatal(err)
+	}
+	if root.Dev != testRoot.Dev {
+		t.Skip("the test root isn't on the root filesystem")
+	}
+
+	fd1, testFile1, err := openTestFile(test, "test-opa/test-opa-1", syscall.O_CREAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fd1)
+	defer os.Remove(testFile1)
+
+	if _, err := waitForOpenEvent(test, testFile1); err != nil {
+		t.Fatal(err)
+	}
+
+	fd2, testFile2, err := openTestFile(test, "test-opa-2", syscall.O_CREAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fd2)
+	defer os.Remove(testFile2)
+
+	if event, err := waitForOpenEvent(test, testFile2); err == nil {
+		t.Fatalf("shouldn't get an event: %+v", event)
+	}
+}
+
+func TestOpenEventTypeSwitch(t *testing.T) {
+	rule := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: `open.filename =~ "{{.Root}}/test-ets-*"`,
+	}
+
+	test, err := newTestProbe(nil, []*rules.RuleDefinition{rule}, testOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	if err := test.probe.SetEventTypeEnabled("open", false); err != nil {
+		t.Fatal(err)
+	}
+
+	fd1, testFile1, err := openTestFile(test, "test-ets-1", syscall.O_CREAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fd1)
+	defer os.Remove(testFile1)
+
+	if event, err := waitForOpenEvent(test, testFile1); err == nil {
+		t.Fatalf("shouldn't get an event: %+v", event)
+	}
+
+	if err := test.probe.SetEventTypeEnabled("open", true); err != nil {
+		t.Fatal(err)
+	}
+
+	fd2, testFile2, err := openTestFile(test, "test-ets-2", syscall.O_CREAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fd2)
+	defer os.Remove(testFile2)
+
+	if _, err := waitForOpenEvent(test, testFile2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenProbePauseRename(t *testing.T) {
+	rule := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: `open.filename =~ "{{.Root}}/test-pause-rename-*"`,
+	}
+
+	test, err := newTestProbe(nil, []*rules.RuleDefinition{rule}, testOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	fd1, oldFile, err := openTestFile(test, "test-pause-rename-old", syscall.O_CREAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syscall.Close(fd1)
+	defer os.Remove(oldFile)
+
+	// the path of the file is cached
+	if _, err := waitForOpenEvent(test, oldFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := test.probe.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	newFile, _, err := test.Path("test-pause-rename-new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(newFile)
+
+	// the rename event isn't sent while paused
+	if err := os.Rename(oldFile, newFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := test.probe.Resume(); err != nil {
+		t.Fatal(err)
+	}
+
+	fd2, _, err := openTestFile(test, "test-pause-rename-new", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fd2)
+
+	if _, err := waitForOpenEvent(test, newFile); err != nil {
+		t.Errorf("expected the path renamed while paused to be resolved: %s", err)
+	}
+}
+
+func TestOpenProbePause(t *testing.T) {
+	rule := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: `open.filename =~ "{{.Root}}/test-pause-*"`,
+	}
+
+	test, err := newTestProbe(nil, []*rules.RuleDefinition{rule}, testOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	if err := test.probe.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	fd1, testFile1, err := openTestFile(test, "test-pause-1", syscall.O_CREAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fd1)
+	defer os.Remove(testFile1)
+
+	if event, err := waitForOpenEvent(test, testFile1); err == nil {
+		t.Fatalf("shouldn't get an event: %+v", event)
+	}
+
+	if err := test.probe.Resume(); err != nil {
+		t.Fatal(err)
+	}
+
+	fd2, testFile2, err := openTestFile(test, "test-pause-2", syscall.O_CREAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fd2)
+	defer os.Remove(testFile2)
+
+	if _, err := waitForOpenEvent(test, testFile2); err != nil {
+		t.Fatal(err)
+	}
+}
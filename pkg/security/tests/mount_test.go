@@ -15,6 +15,9 @@ import (
 	"testing"
 	"time"
 	"unsafe"
+
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/pkg/errors"
 )
 
 func TestMount(t *testing.T) {
@@ -72,6 +75,11 @@ func TestMount(t *testing.T) {
 			if fs := event.Mount.GetFSType(); fs != "bind" {
 				t.Errorf("expected a bind mount, got %v", fs)
 			}
+
+			// a bind mount reports the device of the mounted directory
+			if source, _ := event.GetFieldValue("mount.source"); source.(string) != testDrive.dev.Path() {
+				t.Errorf("expected source %s, got %v", testDrive.dev.Path(), source)
+			}
 			mntID = event.Mount.MountID
 		}
 	})
@@ -135,3 +143,87 @@ func TestMount(t *testing.T) {
 		}
 	})
 }
+
+func waitForMountDiscarder(test *testProbe, fsType string) error {
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case <-test.events:
+		case discarder := <-test.discarders:
+			test.probe.OnNewDiscarder(test.rs, discarder.event.(*sprobe.Event), discarder.field, discarder.eventType)
+			if value, _ := discarder.event.GetFieldValue("mount.fs_type"); discarder.field == "mount.fs_type" && value == fsType {
+				return nil
+			}
+		case <-timeout:
+			return errors.New("timeout")
+		}
+	}
+}
+
+func TestMountFSTypeDiscarder(t *testing.T) {
+	rule := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: `mount.fs_type == "tmpfs" && mount.mount_point == "/test-mount-tmpfs"`,
+	}
+
+	testDrive, err := newTestDrive("ext4", []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test, err := newTestProbe(nil, []*rules.RuleDefinition{rule}, testOpts{testDir: testDrive.Root(), enableFilters: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	mntPath, _, err := testDrive.Path("test-mount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(mntPath, 0755)
+	defer os.RemoveAll(mntPath)
+
+	dstMntPath, _, err := testDrive.Path("test-dest-mount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(dstMntPath, 0755)
+	defer os.RemoveAll(dstMntPath)
+
+	if err := syscall.Mount(mntPath, dstMntPath, "bind", syscall.MS_BIND, ""); err != nil {
+		t.Fatalf("could not create bind mount: %s", err)
+	}
+
+	if err := waitForMountDiscarder(test, "bind"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := syscall.Unmount(dstMntPath, syscall.MNT_DETACH); err != nil {
+		t.Fatalf("could not unmount test-dest-mount: %s", err)
+	}
+
+	if event, err := test.GetEvent(time.Second, "umount"); err != nil {
+		t.Error(err)
+	} else if event.GetType() != "umount" {
+		t.Errorf("expected umount event, got %s", event.GetType())
+	}
+
+	// the bind mounts are now discarded in the kernel, as well as their umount
+	if err := syscall.Mount(mntPath, dstMntPath, "bind", syscall.MS_BIND, ""); err != nil {
+		t.Fatalf("could not create bind mount: %s", err)
+	}
+
+	if event, err := test.GetEvent(time.Second, "mount"); err == nil {
+		t.Errorf("shouldn't get an event: %+v", event)
+	}
+
+	if err := syscall.Unmount(dstMntPath, syscall.MNT_DETACH); err != nil {
+		t.Fatalf("could not unmount test-dest-mount: %s", err)
+	}
+
+	if event, err := test.GetEvent(time.Second, "umount"); err == nil {
+		t.Errorf("shouldn't get an event: %+v", event)
+	}
+}
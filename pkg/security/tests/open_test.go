@@ -11,9 +11,11 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
+	"runtime"
 	"strings"
 	"syscall"
 	"testing"
@@ -105,6 +107,35 @@ func TestOpen(t *testing.T) {
 		}
 	})
 
+	t.Run("openat-largefile", func(t *testing.T) {
+		if runtime.GOARCH != "amd64" && runtime.GOARCH != "386" {
+			t.Skip()
+		}
+
+		// O_LARGEFILE is set by the 32 bits processes, it isn't reported. The Go packages of the 64 bits
+		// architectures define it as 0, the flag of the kernel is used
+		oLargeFile := 0100000
+		fd, _, errno := syscall.Syscall6(syscall.SYS_OPENAT, 0, uintptr(testFilePtr), uintptr(syscall.O_CREAT|oLargeFile), 0711, 0, 0)
+		if errno != 0 {
+			t.Fatal(error(errno))
+		}
+		defer os.Remove(testFile)
+		defer syscall.Close(int(fd))
+
+		event, _, err := test.GetEvent()
+		if err != nil {
+			t.Error(err)
+		} else {
+			if event.GetType() != "open" {
+				t.Errorf("expected open event, got %s", event.GetType())
+			}
+
+			if flags := event.Open.Flags; flags != syscall.O_CREAT {
+				t.Errorf("expected open flag O_CREAT, got %d", flags)
+			}
+		}
+	})
+
 	t.Run("creat", func(t *testing.T) {
 		fd, _, errno := syscall.Syscall(syscall.SYS_CREAT, uintptr(testFilePtr), 0, 0)
 		if errno != 0 {
@@ -219,6 +250,56 @@ func TestOpen(t *testing.T) {
 
 }
 
+func TestOpenSymlink(t *testing.T) {
+	rule := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: `open.destination.path == "{{.Root}}/test-open-target"`,
+	}
+
+	test, err := newTestModule(nil, []*rules.RuleDefinition{rule}, testOpts{enableFilters: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	testFile, _, err := test.Path("test-open-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(testFile, []byte{}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(testFile)
+
+	testLink, _, err := test.Path("test-open-link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("test-open-target", testLink); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(testLink)
+
+	f, err := os.Open(testLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	event, _, err := test.GetEvent()
+	if err != nil {
+		t.Error(err)
+	} else {
+		if value, _ := event.GetFieldValue("open.filename"); value.(string) != testLink {
+			t.Errorf("expected the filename of the open to be the link %s, got %v", testLink, value)
+		}
+
+		if inode := getInode(t, testLink); inode != event.Open.Inode {
+			t.Errorf("expected inode %d, got %d", inode, event.Open.Inode)
+		}
+	}
+}
+
 func openMountByID(mountID int) (f *os.File, err error) {
 	mi, err := os.Open("/proc/self/mountinfo")
 	if err != nil {
@@ -9,13 +9,16 @@ package tests
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"os/user"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
 )
 
 func TestProcess(t *testing.T) {
@@ -155,6 +158,162 @@ func TestProcessContext(t *testing.T) {
 		}
 	})
 
+	t.Run("namespaces", func(t *testing.T) {
+		mntns, err := utils.GetMountNS(uint32(os.Getpid()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the pid namespace is the one of the task, not the one of its future children
+		link, err := os.Readlink("/proc/self/ns/pid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pidns int
+		if _, err := fmt.Sscanf(link, "pid:[%d]", &pidns); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := os.Open(testFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		event, _, err := test.GetEvent()
+		if err != nil {
+			t.Error(err)
+		} else {
+			if ns, _ := event.GetFieldValue("process.mntns"); uint64(ns.(int)) != mntns {
+				t.Errorf("expected mount namespace %d, got %v", mntns, ns)
+			}
+
+			if ns, _ := event.GetFieldValue("process.pidns"); ns.(int) != pidns {
+				t.Errorf("expected pid namespace %d, got %v", pidns, ns)
+			}
+		}
+	})
+
+	t.Run("executable", func(t *testing.T) {
+		executable, err := os.Executable()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fi, err := os.Stat(executable)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := os.Open(testFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		event, _, err := test.GetEvent()
+		if err != nil {
+			t.Error(err)
+		} else {
+			if size, _ := event.GetFieldValue("process.file.size"); int64(size.(int)) != fi.Size() {
+				t.Errorf("expected executable size %d, got %v", fi.Size(), size)
+			}
+
+			if mtime, _ := event.GetFieldValue("process.file.mtime"); int64(mtime.(int)) != fi.ModTime().UnixNano() {
+				t.Errorf("expected executable mtime %d, got %v", fi.ModTime().UnixNano(), mtime)
+			}
+
+			if setuid, _ := event.GetFieldValue("process.file.setuid"); setuid.(bool) {
+				t.Error("expected executable without setuid bit")
+			}
+		}
+	})
+
+	t.Run("security-context", func(t *testing.T) {
+		context, err := utils.PidSecurityContext(uint32(os.Getpid()))
+		if err != nil {
+			t.Skip("no LSM available")
+		}
+
+		f, err := os.Open(testFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		event, _, err := test.GetEvent()
+		if err != nil {
+			t.Error(err)
+		} else {
+			if value, _ := event.GetFieldValue("process.security_context"); value.(string) != context {
+				t.Errorf("expected security context %s, got %v", context, value)
+			}
+		}
+	})
+
+	t.Run("auid", func(t *testing.T) {
+		loginUID, err := utils.PidLoginUID(uint32(os.Getpid()))
+		if err != nil {
+			t.Skip("audit not available")
+		}
+
+		executable := "/usr/bin/cat"
+		if _, err := os.Stat(executable); err != nil {
+			executable = "/bin/cat"
+		}
+
+		cmd := exec.Command(executable, testFile)
+		if _, err := cmd.CombinedOutput(); err != nil {
+			t.Error(err)
+		}
+
+		event, _, err := test.GetEvent()
+		if err != nil {
+			t.Error(err)
+		} else {
+			if auid, _ := event.GetFieldValue("process.auid"); auid.(int) != int(loginUID) {
+				t.Errorf("expected auid %d, got %v", loginUID, auid)
+			}
+		}
+	})
+
+	t.Run("interpreter", func(t *testing.T) {
+		interpreter, err := filepath.EvalSymlinks("/bin/sh")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		script, _, err := test.Path("test-script.sh")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the shell opens the file itself, the redirection doesn't spawn a child process
+		content := fmt.Sprintf("#!/bin/sh\n: < %s\n", testFile)
+		if err := ioutil.WriteFile(script, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(script)
+
+		cmd := exec.Command(script)
+		if _, err := cmd.CombinedOutput(); err != nil {
+			t.Error(err)
+		}
+
+		event, _, err := test.GetEvent()
+		if err != nil {
+			t.Error(err)
+		} else {
+			if filename, _ := event.GetFieldValue("process.filename"); filename.(string) != script {
+				t.Errorf("expected script %s, got %v", script, filename)
+			}
+
+			if filename, _ := event.GetFieldValue("process.interpreter.filename"); filename.(string) != interpreter {
+				t.Errorf("expected interpreter %s, got %v", interpreter, filename)
+			}
+		}
+	})
+
 	t.Run("tty", func(t *testing.T) {
 		// not working on centos8
 		t.Skip()
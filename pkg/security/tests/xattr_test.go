@@ -134,7 +134,8 @@ func TestSetXAttr(t *testing.T) {
 		defer f.Close()
 		defer os.Remove(testFile)
 
-		_, _, errno := syscall.Syscall6(syscall.SYS_FSETXATTR, f.Fd(), uintptr(xattrNamePtr), uintptr(xattrValuePtr), 0, unix.XATTR_CREATE, 0)
+		value := []byte("test_value")
+		_, _, errno := syscall.Syscall6(syscall.SYS_FSETXATTR, f.Fd(), uintptr(xattrNamePtr), uintptr(unsafe.Pointer(&value[0])), uintptr(len(value)), unix.XATTR_CREATE, 0)
 		if errno != 0 {
 			t.Fatal(error(errno))
 		}
@@ -151,6 +152,14 @@ func TestSetXAttr(t *testing.T) {
 				t.Errorf("expected setxattr name user.test_xattr, got %s", event.SetXAttr.Name)
 			}
 
+			if xattrValue, _ := event.GetFieldValue("setxattr.value"); xattrValue.(string) != string(value) {
+				t.Errorf("expected setxattr value %s, got %v", value, xattrValue)
+			}
+
+			if size := event.SetXAttr.ValueSize; size != uint32(len(value)) {
+				t.Errorf("expected setxattr value size %d, got %d", len(value), size)
+			}
+
 			if inode := getInode(t, testFile); inode != event.SetXAttr.Inode {
 				t.Errorf("expected inode %d, got %d", event.SetXAttr.Inode, inode)
 			}
@@ -54,35 +54,55 @@ type ControlGroup struct {
 	Path string
 }
 
-// GetContainerID returns the container id extracted from the path of the control group
+// GetContainerID returns the container id extracted from the path of the control group. The path is walked from
+// its last element so that nested layouts, like systemd slices on the cgroup v2 unified hierarchy
+// (/kubepods.slice/.../cri-containerd-<id>.scope), resolve to the innermost container.
 func (cg ControlGroup) GetContainerID() ContainerID {
-	return ContainerID(FindContainerID(cg.Path))
+	elements := strings.Split(cg.Path, "/")
+	for i := len(elements) - 1; i >= 0; i-- {
+		if id := FindContainerID(elements[i]); id != "" {
+			return ContainerID(id)
+		}
+	}
+	return ""
 }
 
-// GetProcControlGroups returns the cgroup membership of the specified task.
-func GetProcControlGroups(tgid, pid uint32) ([]ControlGroup, error) {
-	data, err := ioutil.ReadFile(CgroupTaskPath(tgid, pid))
-	if err != nil {
-		return nil, err
-	}
+// parseProcControlGroups parses the content of a /proc/[pid]/cgroup file. Each line is formatted as
+// hierarchy-ID:controller-list:cgroup-path, the controller list being empty for the cgroup v2 unified hierarchy.
+func parseProcControlGroups(data []byte) []ControlGroup {
 	var cgroups []ControlGroup
 	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
 		t := scanner.Text()
-		parts := strings.Split(t, ":")
-		var ID int
-		ID, err = strconv.Atoi(parts[0])
+		parts := strings.SplitN(t, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		ID, err := strconv.Atoi(parts[0])
 		if err != nil {
 			continue
 		}
+		var controllers []string
+		if len(parts[1]) > 0 {
+			controllers = strings.Split(parts[1], ",")
+		}
 		c := ControlGroup{
 			ID:          ID,
-			Controllers: strings.Split(parts[1], ","),
+			Controllers: controllers,
 			Path:        parts[2],
 		}
 		cgroups = append(cgroups, c)
 	}
-	return cgroups, nil
+	return cgroups
+}
+
+// GetProcControlGroups returns the cgroup membership of the specified task.
+func GetProcControlGroups(tgid, pid uint32) ([]ControlGroup, error) {
+	data, err := ioutil.ReadFile(CgroupTaskPath(tgid, pid))
+	if err != nil {
+		return nil, err
+	}
+	return parseProcControlGroups(data), nil
 }
 
 // GetProcContainerID returns the container ID which the process belongs to. Returns "" if the process does not belong
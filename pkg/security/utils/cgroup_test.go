@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package utils
+
+import (
+	"testing"
+)
+
+const testContainerID = "c6f3b8f0c3c537a66e4511efd8b0e2a8ce3bbf2e3c1ad798b81c6288ff19b3a0"
+
+func TestParseProcControlGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected ContainerID
+	}{
+		{
+			name:     "cgroup-v1",
+			content:  "12:memory:/docker/" + testContainerID + "\n1:name=systemd:/docker/" + testContainerID + "\n",
+			expected: testContainerID,
+		},
+		{
+			name:     "cgroup-v2-docker",
+			content:  "0::/system.slice/docker-" + testContainerID + ".scope\n",
+			expected: testContainerID,
+		},
+		{
+			name:     "cgroup-v2-kubernetes",
+			content:  "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod2f4b7a7d_9b5e_4b4f_8b3c_0d0f1d7d9a6e.slice/cri-containerd-" + testContainerID + ".scope\n",
+			expected: testContainerID,
+		},
+		{
+			name:     "cgroup-v2-host",
+			content:  "0::/user.slice/user-1000.slice/session-2.scope\n",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cgroups := parseProcControlGroups([]byte(test.content))
+			if len(cgroups) == 0 {
+				t.Fatal("no cgroup parsed")
+			}
+
+			var containerID ContainerID
+			for _, cgroup := range cgroups {
+				if containerID = cgroup.GetContainerID(); containerID != "" {
+					break
+				}
+			}
+
+			if containerID != test.expected {
+				t.Errorf("expected container ID `%s`, got `%s`", test.expected, containerID)
+			}
+		})
+	}
+
+	cgroups := parseProcControlGroups([]byte("0::/system.slice/docker-" + testContainerID + ".scope\n"))
+	if cgroups[0].ID != 0 || len(cgroups[0].Controllers) != 0 {
+		t.Errorf("unexpected unified hierarchy cgroup: %+v", cgroups[0])
+	}
+}
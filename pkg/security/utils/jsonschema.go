@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDraft is the version of the JSON Schema specification followed by the generated schemas
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSONSchema is the subset of JSON Schema describing the JSON encoding of Go structs
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Minimum              *int64                 `json:"minimum,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Definitions          map[string]*JSONSchema `json:"definitions,omitempty"`
+}
+
+// Encode returns the indented JSON encoding of the schema, as it is published
+func (s *JSONSchema) Encode() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// GenerateJSONSchema generates the schema of the JSON encoding of the given struct, following the rules of
+// encoding/json: the fields tagged with `omitempty` are optional, the other ones are required. The nested structs are
+// described in the definitions of the schema
+func GenerateJSONSchema(id string, title string, v interface{}) *JSONSchema {
+	r := &schemaReflector{definitions: make(map[string]*JSONSchema)}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	schema := r.reflectStruct(t)
+	schema.Schema = JSONSchemaDraft
+	schema.ID = id
+	schema.Title = title
+	if len(r.definitions) > 0 {
+		schema.Definitions = r.definitions
+	}
+	return schema
+}
+
+type schemaReflector struct {
+	definitions map[string]*JSONSchema
+}
+
+func (r *schemaReflector) reflectType(t reflect.Type) *JSONSchema {
+	if t == timeType {
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return r.reflectType(t.Elem())
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var minimum int64
+		return &JSONSchema{Type: "integer", Minimum: &minimum}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json encodes the byte slices in base64
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: r.reflectType(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: r.reflectType(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if _, exists := r.definitions[name]; !exists {
+			// registered before being reflected to stop the recursion of the self referencing structs
+			r.definitions[name] = nil
+			r.definitions[name] = r.reflectStruct(t)
+		}
+		return &JSONSchema{Ref: "#/definitions/" + name}
+	default:
+		// interfaces accept any value
+		return &JSONSchema{}
+	}
+}
+
+func (r *schemaReflector) reflectStruct(t reflect.Type) *JSONSchema {
+	schema := &JSONSchema{
+		Type:                 "object",
+		Properties:           make(map[string]*JSONSchema),
+		AdditionalProperties: false,
+	}
+	r.reflectFields(t, schema)
+	return schema
+}
+
+func (r *schemaReflector) reflectFields(t reflect.Type, schema *JSONSchema) {
+	for i := 0; i != t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options := tag, ""
+		if index := strings.Index(tag, ","); index >= 0 {
+			name, options = tag[:index], tag[index+1:]
+		}
+
+		// the fields of the untagged embedded structs are promoted
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			r.reflectFields(fieldType, schema)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = r.reflectType(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testSchemaEmbedded struct {
+	Embedded string `json:"embedded"`
+}
+
+type testSchemaNested struct {
+	Values map[string]int `json:"values,omitempty"`
+}
+
+type testSchemaStruct struct {
+	testSchemaEmbedded
+	Name      string            `json:"name"`
+	Count     uint32            `json:"count,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	Nested    *testSchemaNested `json:"nested,omitempty"`
+	List      []testSchemaNested
+	Ignored   string `json:"-"`
+	private   string
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	schema := GenerateJSONSchema("test.schema.json", "Test", &testSchemaStruct{})
+
+	if schema.Schema != JSONSchemaDraft || schema.ID != "test.schema.json" || schema.Title != "Test" || schema.Type != "object" {
+		t.Errorf("unexpected schema header: %+v", schema)
+	}
+
+	var properties []string
+	for name := range schema.Properties {
+		properties = append(properties, name)
+	}
+	if len(properties) != 6 {
+		t.Errorf("unexpected properties: %v", properties)
+	}
+
+	if expected := []string{"embedded", "name", "timestamp", "List"}; !reflect.DeepEqual(schema.Required, expected) {
+		t.Errorf("expected the required properties %v, got %v", expected, schema.Required)
+	}
+
+	if count := schema.Properties["count"]; count.Type != "integer" || count.Minimum == nil || *count.Minimum != 0 {
+		t.Errorf("unexpected count: %+v", count)
+	}
+	if timestamp := schema.Properties["timestamp"]; timestamp.Type != "string" || timestamp.Format != "date-time" {
+		t.Errorf("unexpected timestamp: %+v", timestamp)
+	}
+	if nested := schema.Properties["nested"]; nested.Ref != "#/definitions/testSchemaNested" {
+		t.Errorf("unexpected nested: %+v", nested)
+	}
+	if list := schema.Properties["List"]; list.Type != "array" || list.Items == nil || list.Items.Ref != "#/definitions/testSchemaNested" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	nested := schema.Definitions["testSchemaNested"]
+	if nested == nil || len(schema.Definitions) != 1 {
+		t.Fatalf("unexpected definitions: %+v", schema.Definitions)
+	}
+	if values := nested.Properties["values"]; values.Type != "object" || values.AdditionalProperties.(*JSONSchema).Type != "integer" {
+		t.Errorf("unexpected values: %+v", values)
+	}
+
+	if _, err := schema.Encode(); err != nil {
+		t.Error(err)
+	}
+}
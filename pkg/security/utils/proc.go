@@ -8,17 +8,27 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/moby/sys/mountinfo"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
+// KallsymsPath returns the path to the kernel symbols table in /proc
+func KallsymsPath() string {
+	return filepath.Join(util.HostProc(), "/kallsyms")
+}
+
 // MountInfoPath returns the path to the mountinfo file of the current pid in /proc
 func MountInfoPath() string {
 	return filepath.Join(util.HostProc(), "/self/mountinfo")
@@ -29,6 +39,26 @@ func MountInfoPidPath(pid uint32) string {
 	return filepath.Join(util.HostProc(), fmt.Sprintf("/%d/mountinfo", pid))
 }
 
+// MountNSPath returns the path to the mount namespace of a pid in /proc
+func MountNSPath(pid uint32) string {
+	return filepath.Join(util.HostProc(), fmt.Sprintf("/%d/ns/mnt", pid))
+}
+
+// GetMountNS returns the inode number of the mount namespace of the given pid
+func GetMountNS(pid uint32) (uint64, error) {
+	link, err := os.Readlink(MountNSPath(pid))
+	if err != nil {
+		return 0, err
+	}
+
+	var ns uint64
+	if _, err := fmt.Sscanf(link, "mnt:[%d]", &ns); err != nil {
+		return 0, errors.Wrapf(err, "failed to parse mount namespace `%s`", link)
+	}
+
+	return ns, nil
+}
+
 // CgroupTaskPath returns the path to the cgroup file of a pid in /proc
 func CgroupTaskPath(tgid, pid uint32) string {
 	return filepath.Join(util.HostProc(), fmt.Sprintf("%d/task/%d/cgroup", tgid, pid))
@@ -39,8 +69,106 @@ func ProcExePath(pid uint32) string {
 	return filepath.Join(util.HostProc(), fmt.Sprintf("%d/exe", pid))
 }
 
+// ProcRootPath returns the path to the root directory of a pid in /proc
+func ProcRootPath(pid uint32) string {
+	return filepath.Join(util.HostProc(), fmt.Sprintf("%d/root", pid))
+}
+
+// ProcStatPath returns the path to the stat file of a pid in /proc
+func ProcStatPath(pid uint32) string {
+	return filepath.Join(util.HostProc(), fmt.Sprintf("%d/stat", pid))
+}
+
+// tty device majors, see Documentation/admin-guide/devices.txt
+const (
+	ttyMajor          = 4
+	ttySerialMinorMin = 64
+	ptsMajorMin       = 136
+	ptsMajorMax       = 143
+)
+
+// ttyName returns the kernel name of a tty device, as reported by the tty `name` field
+func ttyName(ttyNr uint64) string {
+	major, minor := unix.Major(ttyNr), unix.Minor(ttyNr)
+
+	switch {
+	case major >= ptsMajorMin && major <= ptsMajorMax:
+		return fmt.Sprintf("pts%d", (major-ptsMajorMin)*256+minor)
+	case major == ttyMajor && minor < ttySerialMinorMin:
+		return fmt.Sprintf("tty%d", minor)
+	case major == ttyMajor:
+		return fmt.Sprintf("ttyS%d", minor-ttySerialMinorMin)
+	}
+
+	return ""
+}
+
+// pidControllingTTY returns the controlling terminal of the given pid, read from /proc/[pid]/stat
+func pidControllingTTY(pid uint32) string {
+	data, err := ioutil.ReadFile(ProcStatPath(pid))
+	if err != nil {
+		return ""
+	}
+
+	// the command name can contain spaces and parenthesis, skip it
+	end := bytes.LastIndexByte(data, ')')
+	if end == -1 {
+		return ""
+	}
+
+	// fields after the command: state ppid pgrp session tty_nr
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 5 {
+		return ""
+	}
+
+	ttyNr, err := strconv.ParseUint(fields[4], 10, 32)
+	if err != nil || ttyNr == 0 {
+		return ""
+	}
+
+	return ttyName(ttyNr)
+}
+
+// userHZ is the frequency of the clock ticks of the times reported in /proc, independent of the kernel configuration
+const userHZ = 100
+
+// parseProcStartTime returns the start time of a process, in nanoseconds since boot, given its /proc/[pid]/stat file
+func parseProcStartTime(data []byte) (uint64, error) {
+	// the command name can contain spaces and parenthesis, skip it
+	end := bytes.LastIndexByte(data, ')')
+	if end == -1 {
+		return 0, errors.New("invalid stat file")
+	}
+
+	// starttime is the 22nd field, the 20th after the command
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 20 {
+		return 0, errors.New("invalid stat file")
+	}
+
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid start time")
+	}
+	return ticks * (1000000000 / userHZ), nil
+}
+
+// PidStartTime returns the start time of the given pid, in nanoseconds since boot
+func PidStartTime(pid uint32) (uint64, error) {
+	data, err := ioutil.ReadFile(ProcStatPath(pid))
+	if err != nil {
+		return 0, err
+	}
+	return parseProcStartTime(data)
+}
+
 // PidTTY returns the TTY of the given pid
 func PidTTY(pid uint32) string {
+	if tty := pidControllingTTY(pid); tty != "" {
+		return tty
+	}
+
 	fdPath := filepath.Join(util.HostProc(), fmt.Sprintf("%d/fd/0", pid))
 
 	ttyPath, err := os.Readlink(fdPath)
@@ -63,6 +191,77 @@ func PidTTY(pid uint32) string {
 	return ""
 }
 
+// AuditUnset is the value of the login uid and session id of processes that were not started from a login session
+const AuditUnset = ^uint32(0)
+
+// readProcUint32 reads a single integer value from a /proc/[pid] file
+func readProcUint32(pid uint32, name string) (uint32, error) {
+	data, err := ioutil.ReadFile(filepath.Join(util.HostProc(), fmt.Sprintf("%d/%s", pid, name)))
+	if err != nil {
+		return AuditUnset, err
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return AuditUnset, errors.Wrapf(err, "failed to parse %s of %d", name, pid)
+	}
+
+	return uint32(value), nil
+}
+
+// PidLoginUID returns the audit login uid of the given pid
+func PidLoginUID(pid uint32) (uint32, error) {
+	return readProcUint32(pid, "loginuid")
+}
+
+// PidSessionID returns the audit session id of the given pid
+func PidSessionID(pid uint32) (uint32, error) {
+	return readProcUint32(pid, "sessionid")
+}
+
+// PidSecurityContext returns the LSM security context of the given pid, either its SELinux context or its AppArmor profile
+func PidSecurityContext(pid uint32) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(util.HostProc(), fmt.Sprintf("%d/attr/current", pid)))
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimRight(data, "\n\x00")), nil
+}
+
+// PidEnv returns the environment variables of the given pid, read from /proc/[pid]/environ
+func PidEnv(pid uint32) ([]string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(util.HostProc(), fmt.Sprintf("%d/environ", pid)))
+	if err != nil {
+		return nil, err
+	}
+
+	var env []string
+	for _, variable := range bytes.Split(data, []byte{0}) {
+		if len(variable) > 0 {
+			env = append(env, string(variable))
+		}
+	}
+
+	return env, nil
+}
+
+// BlockDeviceName returns the kernel name of the block device of the given major and minor numbers, read from sysfs
+func BlockDeviceName(major, minor uint32) (string, error) {
+	data, err := ioutil.ReadFile(util.HostSys(fmt.Sprintf("dev/block/%d:%d/uevent", major, minor)))
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "DEVNAME=") {
+			return strings.TrimPrefix(line, "DEVNAME="), nil
+		}
+	}
+
+	return "", errors.Errorf("no device name found for %d:%d", major, minor)
+}
+
 // ParseMountInfoFile collects the mounts for a specific process ID.
 func ParseMountInfoFile(pid uint32) ([]*mountinfo.Info, error) {
 	f, err := os.Open(MountInfoPidPath(pid))
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package utils
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestTTYName(t *testing.T) {
+	tests := map[uint64]string{
+		unix.Mkdev(136, 3):  "pts3",
+		unix.Mkdev(137, 1):  "pts257",
+		unix.Mkdev(4, 1):    "tty1",
+		unix.Mkdev(4, 64):   "ttyS0",
+		unix.Mkdev(1, 3):    "",
+		unix.Mkdev(254, 12): "",
+	}
+
+	for ttyNr, expected := range tests {
+		if name := ttyName(ttyNr); name != expected {
+			t.Errorf("expected `%s` for %d:%d, got `%s`", expected, unix.Major(ttyNr), unix.Minor(ttyNr), name)
+		}
+	}
+}
+
+func TestParseProcStartTime(t *testing.T) {
+	stat := "1234 (a (weird) name) S 1 1234 1234 0 -1 4194560 100 0 0 0 1 2 0 0 20 0 1 0 4567 10000 200 18446744073709551615\n"
+	startTime, err := parseProcStartTime([]byte(stat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if startTime != 45670000000 {
+		t.Errorf("expected the start time 45.67s after boot, got %d", startTime)
+	}
+
+	if _, err := parseProcStartTime([]byte("1234 (name) S 1 1234")); err == nil {
+		t.Error("expected an error for a truncated stat file")
+	}
+}
@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RotatingFile is a file rotated once it reaches its maximum size. The rotated files are suffixed with their index,
+// .1 being the most recent one, and compressed with gzip in the background when requested. The oldest ones are removed
+// past the maximum number of rotated files
+type RotatingFile struct {
+	sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	compress bool
+	file     *os.File
+	size     int64
+
+	// compressing tracks the compression of the last rotated file, compressErr holds its error once done
+	compressing sync.WaitGroup
+	compressErr error
+}
+
+// NewRotatingFile opens the file of the given path, created along with its directory if needed, to append to it
+func NewRotatingFile(path string, maxSize int64, maxFiles int, compress bool) (*RotatingFile, error) {
+	if maxSize <= 0 || maxFiles < 0 {
+		return nil, errors.New("the maximum size and number of rotated files should be positive")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the directory of %s", path)
+	}
+
+	f := &RotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		compress: compress,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", f.path)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to stat %s", f.path)
+	}
+
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends the given data to the file, rotating it first when the data would exceed its maximum size. The data
+// is never split across two files
+func (f *RotatingFile) Write(data []byte) (int, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		return 0, errors.Errorf("%s is closed", f.path)
+	}
+
+	if f.size > 0 && f.size+int64(len(data)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) rotatedPath(index int, ext string) string {
+	return fmt.Sprintf("%s.%d%s", f.path, index, ext)
+}
+
+// waitCompression waits for the compression of the last rotated file and returns its error
+func (f *RotatingFile) waitCompression() error {
+	f.compressing.Wait()
+
+	err := f.compressErr
+	f.compressErr = nil
+	return err
+}
+
+// rotate shifts the rotated files, whether compressed or not, and reopens an empty file. The rotated file is compressed
+// in the background, the previous compression being waited for before the files are shifted. The file is reopened even
+// when the rotation fails, so that the writes resume
+func (f *RotatingFile) rotate() error {
+	compressErr := f.waitCompression()
+
+	err := f.file.Close()
+	f.file = nil
+
+	for index := f.maxFiles; index > 0 && err == nil; index-- {
+		for _, ext := range []string{"", ".gz"} {
+			if index == f.maxFiles {
+				if rmErr := os.Remove(f.rotatedPath(index, ext)); rmErr != nil && !os.IsNotExist(rmErr) {
+					err = rmErr
+				}
+			} else if mvErr := os.Rename(f.rotatedPath(index, ext), f.rotatedPath(index+1, ext)); mvErr != nil && !os.IsNotExist(mvErr) {
+				err = mvErr
+			}
+		}
+	}
+
+	if err == nil {
+		if f.maxFiles == 0 {
+			err = os.Remove(f.path)
+		} else if err = os.Rename(f.path, f.rotatedPath(1, "")); err == nil && f.compress {
+			f.compressing.Add(1)
+			go func(path string) {
+				defer f.compressing.Done()
+				f.compressErr = compressFile(path)
+			}(f.rotatedPath(1, ""))
+		}
+	}
+
+	if openErr := f.open(); openErr != nil {
+		return openErr
+	}
+	if err == nil {
+		err = compressErr
+	}
+	return errors.Wrapf(err, "failed to rotate %s", f.path)
+}
+
+// compressFile replaces the file of the given path with its gzip compressed version, suffixed with .gz
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// Close closes the file once the last rotated file is compressed
+func (f *RotatingFile) Close() error {
+	f.Lock()
+	defer f.Unlock()
+
+	err := f.waitCompression()
+	if f.file == nil {
+		return err
+	}
+
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	f.file = nil
+	return err
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package utils
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readGzipFile(t *testing.T, path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotating-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit", "events.json")
+	f, err := NewRotatingFile(path, 8, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each line exceeds the free space of the file, which is rotated before each write
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "fourth\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+
+	if content := readGzipFile(t, path+".1.gz"); content != "third\n" {
+		t.Errorf("unexpected content of the first rotated file: %q", content)
+	}
+	if content := readGzipFile(t, path+".2.gz"); content != "second\n" {
+		t.Errorf("unexpected content of the second rotated file: %q", content)
+	}
+
+	for _, removed := range []string{path + ".1", path + ".3.gz"} {
+		if _, err := os.Stat(removed); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed", removed)
+		}
+	}
+
+	// the file is appended to when reopened
+	if f, err = NewRotatingFile(path, 64, 2, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("fifth\n")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if data, _ := ioutil.ReadFile(path); string(data) != "fourth\nfifth\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+}
@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package utils
+
+import (
+	"bytes"
+
+	"golang.org/x/sys/unix"
+)
+
+// selinuxXAttr is the extended attribute holding the SELinux label of a file
+const selinuxXAttr = "security.selinux"
+
+// FileSecurityLabel returns the SELinux label of the given file
+func FileSecurityLabel(filename string) (string, error) {
+	buf := make([]byte, 256)
+
+	n, err := unix.Lgetxattr(filename, selinuxXAttr, buf)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimRight(buf[:n], "\x00")), nil
+}
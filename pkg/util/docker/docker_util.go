@@ -232,6 +232,26 @@ func (d *DockerUtil) ResolveImageNameFromContainer(co types.ContainerJSON) (stri
 	return d.imageNameBySha[image], nil
 }
 
+// ResolveImageDigest returns the registry digest of an image, formatted as sha256:hash.
+// Images that were not pulled from a registry don't have a repo digest, their image ID is returned instead.
+func (d *DockerUtil) ResolveImageDigest(image string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.queryTimeout)
+	defer cancel()
+	r, _, err := d.cli.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", err
+	}
+
+	if len(r.RepoDigests) > 0 {
+		// Digests formatted like quay.io/foo/bar@sha256:hash
+		sort.Strings(r.RepoDigests)
+		if sp := strings.SplitN(r.RepoDigests[0], "@", 2); len(sp) == 2 {
+			return sp[1], nil
+		}
+	}
+	return r.ID, nil
+}
+
 func getBestImageName(r types.ImageInspect, configImage string) string {
 	var imageName string
 	// Try RepoTags first and fall back to RepoDigest otherwise.
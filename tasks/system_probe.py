@@ -401,13 +401,32 @@ def build_object_files(ctx, bundle_ebpf=False):
             obj_file=security_agent_syscall_wrapper_obj_file,
         )
     )
-    bindata_files.extend([security_agent_obj_file, security_agent_syscall_wrapper_obj_file])
+
+    # Build the CO-RE object, relocated at runtime with the BTF of the kernel. It holds the programs of the kernels
+    # with and without syscall wrappers, and has to be compiled for the bpf target to record the field accesses
+    core_arch_flag = {"x86": "-D__x86_64__", "arm64": "-D__aarch64__"}.get(arch, "")
+    security_agent_core_bc_file = os.path.join(security_agent_c_dir, "runtime-security-core.bc")
+    security_agent_core_obj_file = os.path.join(security_agent_c_dir, "runtime-security-core.o")
+    commands.append(
+        cmd.format(
+            flags=" ".join(flags + ["-target bpf", "-g", core_arch_flag, "-DUSE_CORE=1"]),
+            c_file=security_c_file,
+            bc_file=security_agent_core_bc_file,
+        )
+    )
+    commands.append(
+        llc_cmd.format(flags=" ".join(flags), bc_file=security_agent_core_bc_file, obj_file=security_agent_core_obj_file)
+    )
+    bindata_files.extend(
+        [security_agent_obj_file, security_agent_syscall_wrapper_obj_file, security_agent_core_obj_file]
+    )
 
     if bundle_ebpf:
         assets_cmd = (
             "go run github.com/shuLhan/go-bindata/cmd/go-bindata"
             + " -pkg bytecode -tags ebpf_bindata -prefix '{c_dir}' -modtime 1 -o '{go_file}' '{bindata_files}'"
         )
+        # the objects are compiled against the headers of the host, the arm64 builders regenerate the bundled assets
         go_file = os.path.join(bpf_dir, "bytecode", "tracer-ebpf.go")
         commands.append(assets_cmd.format(c_dir=c_dir, go_file=go_file, bindata_files="' '".join(bindata_files)))
         commands.append("gofmt -w -s {go_file}".format(go_file=go_file))